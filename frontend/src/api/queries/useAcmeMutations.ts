@@ -0,0 +1,40 @@
+import { useMutation, useQueryClient } from '@tanstack/react-query';
+
+import { HttpUtil } from '@/utils';
+import { keys } from '@/api/queryKeys';
+import type { AcmeIssueValues } from '@/schemas/acme';
+
+const JSON_HEADERS = { headers: { 'Content-Type': 'application/json' } };
+
+export function useAcmeMutations() {
+  const queryClient = useQueryClient();
+  const invalidate = () => queryClient.invalidateQueries({ queryKey: keys.acme.root() });
+
+  const issueMut = useMutation({
+    mutationFn: (payload: AcmeIssueValues) =>
+      HttpUtil.post('/panel/api/acme/issue', payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const renewMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post(`/panel/api/acme/renew/${id}`),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const removeMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post(`/panel/api/acme/del/${id}`),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  return {
+    issue: (payload: AcmeIssueValues) => issueMut.mutateAsync(payload),
+    renew: (id: number) => renewMut.mutateAsync(id),
+    remove: (id: number) => removeMut.mutateAsync(id),
+  };
+}
@@ -0,0 +1,46 @@
+import { useQuery } from '@tanstack/react-query';
+import { useMemo } from 'react';
+
+import { HttpUtil } from '@/utils';
+import { parseMsg } from '@/utils/zodValidate';
+import { AcmeCertificateListSchema, type AcmeCertificate } from '@/schemas/acme';
+import { keys } from '@/api/queryKeys';
+
+export type { AcmeCertificate };
+
+async function fetchCertificates(): Promise<AcmeCertificate[]> {
+  const msg = await HttpUtil.get('/panel/api/acme/list', undefined, { silent: true });
+  if (!msg?.success) throw new Error(msg?.msg || 'Failed to fetch certificates');
+  const validated = parseMsg(msg, AcmeCertificateListSchema, 'acme/list');
+  return Array.isArray(validated.obj) ? validated.obj : [];
+}
+
+async function fetchProviders(): Promise<string[]> {
+  const msg = await HttpUtil.get('/panel/api/acme/providers', undefined, { silent: true });
+  return msg?.success && Array.isArray(msg.obj) ? (msg.obj as string[]) : [];
+}
+
+export function useAcmeQuery() {
+  const query = useQuery({
+    queryKey: keys.acme.list(),
+    queryFn: fetchCertificates,
+    // Orders finish in the background; poll while one is still running.
+    refetchInterval: (q) =>
+      (q.state.data ?? []).some((c) => c.status === 'pending') ? 3000 : false,
+  });
+  const providersQuery = useQuery({
+    queryKey: keys.acme.providers(),
+    queryFn: fetchProviders,
+    staleTime: Infinity,
+  });
+
+  const certificates = useMemo(() => query.data ?? [], [query.data]);
+  const providers = useMemo(() => providersQuery.data ?? [], [providersQuery.data]);
+
+  return {
+    certificates,
+    providers,
+    loading: query.isFetching,
+    refetch: query.refetch,
+  };
+}
@@ -0,0 +1,59 @@
+import { keepPreviousData, useQuery } from '@tanstack/react-query';
+
+import { HttpUtil } from '@/utils';
+import { parseMsg } from '@/utils/zodValidate';
+import { AuditPageSchema, type AuditLog, type AuditPage } from '@/schemas/audit';
+import { keys } from '@/api/queryKeys';
+
+export type { AuditLog, AuditPage };
+
+export interface AuditQueryParams {
+  page: number;
+  pageSize: number;
+  search?: string;
+  actor?: string;
+  targetType?: string;
+  targetId?: string;
+}
+
+function buildQS(p: Partial<AuditQueryParams>): string {
+  const sp = new URLSearchParams();
+  if (p.page) sp.set('page', String(p.page));
+  if (p.pageSize) sp.set('pageSize', String(p.pageSize));
+  if (p.search) sp.set('search', p.search);
+  if (p.actor) sp.set('actor', p.actor);
+  if (p.targetType) sp.set('targetType', p.targetType);
+  if (p.targetId) sp.set('targetId', p.targetId);
+  return sp.toString();
+}
+
+async function fetchAuditPage(params: AuditQueryParams): Promise<AuditPage> {
+  const msg = await HttpUtil.get(`/panel/api/audit/list?${buildQS(params)}`, undefined, {
+    silent: true,
+  });
+  if (!msg?.success || !msg.obj) throw new Error(msg?.msg || 'Failed to fetch audit log');
+  const validated = parseMsg(msg, AuditPageSchema, 'audit/list');
+  if (!validated.obj) throw new Error('Empty audit response');
+  return validated.obj;
+}
+
+// auditExportUrl downloads the same filter as CSV; paging is ignored server-side.
+export function auditExportUrl(params: AuditQueryParams): string {
+  const qs = buildQS({ ...params, page: 0, pageSize: 0 });
+  return (window.X_UI_BASE_PATH || '') + 'panel/api/audit/export?' + qs;
+}
+
+export function useAuditQuery(params: AuditQueryParams, enabled = true) {
+  const query = useQuery({
+    queryKey: keys.audit.list(params),
+    queryFn: () => fetchAuditPage(params),
+    placeholderData: keepPreviousData,
+    enabled,
+  });
+  return {
+    items: query.data?.items ?? [],
+    total: query.data?.total ?? 0,
+    loading: query.isFetching,
+    refetch: query.refetch,
+  };
+}
@@ -0,0 +1,56 @@
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query';
+import { useMemo } from 'react';
+
+import { HttpUtil } from '@/utils';
+import { parseMsg } from '@/utils/zodValidate';
+import { BackupListSchema, type BackupManifest } from '@/schemas/backup';
+import { keys } from '@/api/queryKeys';
+
+export type { BackupManifest };
+
+const JSON_HEADERS = { headers: { 'Content-Type': 'application/json' } };
+
+interface BackupList {
+  items: BackupManifest[];
+  error?: string;
+}
+
+// An unreachable target still returns the backups found on the others, so a
+// failed listing is surfaced as an error next to the partial results.
+async function fetchBackups(): Promise<BackupList> {
+  const msg = await HttpUtil.get('/panel/api/backups/list', undefined, { silent: true });
+  if (!msg) throw new Error('Failed to fetch backups');
+  const validated = parseMsg(msg, BackupListSchema, 'backups/list');
+  return {
+    items: validated.obj ?? [],
+    error: msg.success ? undefined : msg.msg || 'Failed to fetch backups',
+  };
+}
+
+export function useBackupsQuery(enabled = true) {
+  const query = useQuery({ queryKey: keys.backups.list(), queryFn: fetchBackups, enabled });
+  const backups = useMemo(() => query.data?.items ?? [], [query.data]);
+  return { backups, error: query.data?.error, loading: query.isFetching, refetch: query.refetch };
+}
+
+export function useBackupMutations() {
+  const queryClient = useQueryClient();
+  const invalidate = () => queryClient.invalidateQueries({ queryKey: keys.backups.root() });
+
+  const runMut = useMutation({
+    mutationFn: () => HttpUtil.post('/panel/api/backups/run'),
+    onSettled: invalidate,
+  });
+
+  const restoreMut = useMutation({
+    mutationFn: (payload: { target: string; name: string; keepHostSettings: boolean }) =>
+      HttpUtil.post('/panel/api/backups/restore', payload, JSON_HEADERS),
+  });
+
+  return {
+    run: () => runMut.mutateAsync(),
+    running: runMut.isPending,
+    restore: (target: string, name: string, keepHostSettings: boolean) =>
+      restoreMut.mutateAsync({ target, name, keepHostSettings }),
+  };
+}
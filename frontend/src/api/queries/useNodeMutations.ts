@@ -4,15 +4,16 @@ import { HttpUtil, Msg } from '@/utils';
 import { parseMsg } from '@/utils/zodValidate';
 import { keys } from '@/api/queryKeys';
 import type { NodeRecord } from '@/api/queries/useNodesQuery';
+import { mergeRollout, type NodeRollout } from '@/api/queries/useNodeRolloutsQuery';
 import { ProbeResultSchema, type ProbeResult } from '@/schemas/node';
 
 export type { ProbeResult };
 
-export interface NodeUpdateResult {
-  id: number;
-  name?: string;
-  ok: boolean;
-  error?: string;
+// RolloutOptions sizes a rolling upgrade: the canary batch goes first and
+// every later batch waits for the previous one to come back healthy.
+export interface RolloutOptions {
+  canarySize?: number;
+  batchSize?: number;
 }
 
 export interface RemoteInboundOption {
@@ -69,18 +70,40 @@ export function useNodeMutations() {
     },
   });
 
+  const onRollout = (msg: Msg<NodeRollout> | undefined) => {
+    if (msg?.success && msg.obj) {
+      const rollout = msg.obj;
+      queryClient.setQueryData<NodeRollout[]>(keys.nodes.rollouts(), (prev) => mergeRollout(prev, rollout));
+    }
+  };
+
   const updatePanelsMut = useMutation({
-    mutationFn: ({ ids, dev }: { ids: number[]; dev: boolean }) =>
-      HttpUtil.post<NodeUpdateResult[]>(
+    mutationFn: ({ ids, dev, opts }: { ids: number[]; dev: boolean; opts: RolloutOptions }) =>
+      HttpUtil.post<NodeRollout>(
         '/panel/api/nodes/updatePanel',
-        { ids, dev },
+        { ids, dev, ...opts },
         {
           headers: { 'Content-Type': 'application/json' },
         },
       ),
-    onSuccess: (msg) => {
-      if (msg?.success) invalidate();
-    },
+    onSuccess: onRollout,
+  });
+
+  const updateXrayMut = useMutation({
+    mutationFn: ({ ids, version, opts }: { ids: number[]; version: string; opts: RolloutOptions }) =>
+      HttpUtil.post<NodeRollout>(
+        '/panel/api/nodes/updateXray',
+        { ids, version, ...opts },
+        {
+          headers: { 'Content-Type': 'application/json' },
+        },
+      ),
+    onSuccess: onRollout,
+  });
+
+  const cancelRolloutMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post<NodeRollout>(`/panel/api/nodes/rollouts/cancel/${id}`),
+    onSuccess: onRollout,
   });
 
   return {
@@ -89,8 +112,17 @@ export function useNodeMutations() {
     remove: (id: number) => removeMut.mutateAsync(id),
     setEnable: (id: number, enable: boolean) => setEnableMut.mutateAsync({ id, enable }),
     probe: (id: number) => probeMut.mutateAsync(id),
-    updatePanels: (ids: number[], dev: boolean): Promise<Msg<NodeUpdateResult[]>> =>
-      updatePanelsMut.mutateAsync({ ids, dev }),
+    updatePanels: (
+      ids: number[],
+      dev: boolean,
+      opts: RolloutOptions = {},
+    ): Promise<Msg<NodeRollout>> => updatePanelsMut.mutateAsync({ ids, dev, opts }),
+    updateXray: (
+      ids: number[],
+      version: string,
+      opts: RolloutOptions = {},
+    ): Promise<Msg<NodeRollout>> => updateXrayMut.mutateAsync({ ids, version, opts }),
+    cancelRollout: (id: number): Promise<Msg<NodeRollout>> => cancelRolloutMut.mutateAsync(id),
     testConnection: async (payload: Partial<NodeRecord>): Promise<Msg<ProbeResult>> => {
       const raw = await HttpUtil.post('/panel/api/nodes/test', payload);
       return parseMsg(raw, ProbeResultSchema, 'nodes/test');
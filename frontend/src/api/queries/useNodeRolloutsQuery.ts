@@ -0,0 +1,28 @@
+import { useQuery } from '@tanstack/react-query';
+
+import { HttpUtil } from '@/utils';
+import { keys } from '@/api/queryKeys';
+import type { NodeRollout, NodeRolloutStep } from '@/generated/types';
+
+export type { NodeRollout, NodeRolloutStep };
+
+async function fetchRollouts(): Promise<NodeRollout[]> {
+  const msg = await HttpUtil.get<NodeRollout[]>('/panel/api/nodes/rollouts', undefined, { silent: true });
+  if (!msg?.success) throw new Error(msg?.msg || 'Failed to fetch rollouts');
+  return Array.isArray(msg.obj) ? msg.obj : [];
+}
+
+// Newest first. The master pushes every change over the websocket, so the
+// list only needs fetching once per page visit.
+export function useNodeRolloutsQuery() {
+  return useQuery({
+    queryKey: keys.nodes.rollouts(),
+    queryFn: fetchRollouts,
+  });
+}
+
+// mergeRollout replaces the rollout with the same id, or puts a new one on top.
+export function mergeRollout(list: NodeRollout[] | undefined, rollout: NodeRollout): NodeRollout[] {
+  const rest = (list ?? []).filter((r) => r.id !== rollout.id);
+  return [rollout, ...rest].sort((a, b) => b.id - a.id);
+}
@@ -0,0 +1,74 @@
+import { useMutation, useQueryClient } from '@tanstack/react-query';
+
+import { HttpUtil } from '@/utils';
+import { keys } from '@/api/queryKeys';
+import type { PlanFormValues, PlanRenewResult } from '@/schemas/plan';
+
+const JSON_HEADERS = { headers: { 'Content-Type': 'application/json' } };
+
+interface BulkCreateResult {
+  created: number;
+  skipped?: { email: string; reason: string }[] | null;
+}
+
+export function usePlanMutations() {
+  const queryClient = useQueryClient();
+  // Plans live under the clients key, so this also refreshes the client lists
+  // that create and renew change.
+  const invalidate = () => queryClient.invalidateQueries({ queryKey: keys.clients.root() });
+
+  const addMut = useMutation({
+    mutationFn: (payload: PlanFormValues) =>
+      HttpUtil.post('/panel/api/clients/plans/add', payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const updateMut = useMutation({
+    mutationFn: ({ id, payload }: { id: number; payload: PlanFormValues }) =>
+      HttpUtil.post(`/panel/api/clients/plans/update/${id}`, payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const removeMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post(`/panel/api/clients/plans/del/${id}`),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const createMut = useMutation({
+    mutationFn: ({ id, emails }: { id: number; emails: string[] }) =>
+      HttpUtil.post<BulkCreateResult>(
+        `/panel/api/clients/plans/create/${id}`,
+        { clients: emails.map((email) => ({ email })) },
+        JSON_HEADERS,
+      ),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const renewMut = useMutation({
+    mutationFn: ({ id, emails }: { id: number; emails: string[] }) =>
+      HttpUtil.post<PlanRenewResult>(
+        `/panel/api/clients/plans/renew/${id}`,
+        { emails },
+        JSON_HEADERS,
+      ),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  return {
+    add: (payload: PlanFormValues) => addMut.mutateAsync(payload),
+    update: (id: number, payload: PlanFormValues) => updateMut.mutateAsync({ id, payload }),
+    remove: (id: number) => removeMut.mutateAsync(id),
+    createClients: (id: number, emails: string[]) => createMut.mutateAsync({ id, emails }),
+    renewClients: (id: number, emails: string[]) => renewMut.mutateAsync({ id, emails }),
+  };
+}
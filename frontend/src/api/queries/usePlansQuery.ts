@@ -0,0 +1,28 @@
+import { useQuery } from '@tanstack/react-query';
+import { useMemo } from 'react';
+
+import { HttpUtil } from '@/utils';
+import { parseMsg } from '@/utils/zodValidate';
+import { PlanListSchema, type Plan } from '@/schemas/plan';
+import { keys } from '@/api/queryKeys';
+
+export type { Plan };
+
+async function fetchPlans(): Promise<Plan[]> {
+  const msg = await HttpUtil.get('/panel/api/clients/plans', undefined, { silent: true });
+  if (!msg?.success) throw new Error(msg?.msg || 'Failed to fetch plans');
+  const validated = parseMsg(msg, PlanListSchema, 'clients/plans');
+  return Array.isArray(validated.obj) ? validated.obj : [];
+}
+
+export function usePlansQuery() {
+  const query = useQuery({ queryKey: keys.clients.plans(), queryFn: fetchPlans });
+  const plans = useMemo(() => query.data ?? [], [query.data]);
+  return {
+    plans,
+    loading: query.isFetching,
+    fetched: query.data !== undefined || query.isError,
+    error: query.error ? (query.error as Error).message : '',
+    refetch: query.refetch,
+  };
+}
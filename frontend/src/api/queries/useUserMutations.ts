@@ -0,0 +1,64 @@
+import { useMutation, useQueryClient } from '@tanstack/react-query';
+
+import { HttpUtil } from '@/utils';
+import { keys } from '@/api/queryKeys';
+import type { UserAccountValues } from '@/schemas/user';
+
+const JSON_HEADERS = { headers: { 'Content-Type': 'application/json' } };
+
+export interface TwoFactorPayload {
+  enable: boolean;
+  token?: string;
+  code?: string;
+}
+
+export function useUserMutations() {
+  const queryClient = useQueryClient();
+  const invalidate = () => queryClient.invalidateQueries({ queryKey: keys.users.root() });
+
+  const addMut = useMutation({
+    mutationFn: (payload: UserAccountValues) =>
+      HttpUtil.post('/panel/api/users/add', payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const updateMut = useMutation({
+    mutationFn: ({ id, payload }: { id: number; payload: UserAccountValues }) =>
+      HttpUtil.post(`/panel/api/users/update/${id}`, payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const removeMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post(`/panel/api/users/del/${id}`),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const resetTwoFactorMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post(`/panel/api/users/resetTwoFactor/${id}`),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const setMyTwoFactorMut = useMutation({
+    mutationFn: (payload: TwoFactorPayload) =>
+      HttpUtil.post('/panel/api/users/me/twoFactor', payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  return {
+    add: (payload: UserAccountValues) => addMut.mutateAsync(payload),
+    update: (id: number, payload: UserAccountValues) => updateMut.mutateAsync({ id, payload }),
+    remove: (id: number) => removeMut.mutateAsync(id),
+    resetTwoFactor: (id: number) => resetTwoFactorMut.mutateAsync(id),
+    setMyTwoFactor: (payload: TwoFactorPayload) => setMyTwoFactorMut.mutateAsync(payload),
+  };
+}
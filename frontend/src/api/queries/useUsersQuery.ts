@@ -0,0 +1,51 @@
+import { useQuery } from '@tanstack/react-query';
+import { useMemo } from 'react';
+
+import { HttpUtil } from '@/utils';
+import { parseMsg } from '@/utils/zodValidate';
+import { PanelUserListSchema, PanelUserSchema, type PanelUser } from '@/schemas/user';
+import { keys } from '@/api/queryKeys';
+
+export type { PanelUser };
+
+async function fetchMe(): Promise<PanelUser | null> {
+  const msg = await HttpUtil.get('/panel/api/users/me', undefined, { silent: true });
+  if (!msg?.success) throw new Error(msg?.msg || 'Failed to fetch account');
+  return parseMsg(msg, PanelUserSchema, 'users/me').obj;
+}
+
+async function fetchUsers(): Promise<PanelUser[]> {
+  const msg = await HttpUtil.get('/panel/api/users/list', undefined, { silent: true });
+  if (!msg?.success) throw new Error(msg?.msg || 'Failed to fetch accounts');
+  const validated = parseMsg(msg, PanelUserListSchema, 'users/list');
+  return Array.isArray(validated.obj) ? validated.obj : [];
+}
+
+// useMeQuery is the signed-in account; pages use it to hide owner-only UI.
+export function useMeQuery() {
+  const query = useQuery({
+    queryKey: keys.users.me(),
+    queryFn: fetchMe,
+    staleTime: 60_000,
+  });
+  const me = query.data ?? null;
+  return {
+    me,
+    isOwner: me?.role === 'owner',
+    loading: query.isFetching,
+  };
+}
+
+export function useUsersQuery(enabled = true) {
+  const query = useQuery({
+    queryKey: keys.users.list(),
+    queryFn: fetchUsers,
+    enabled,
+  });
+  const users = useMemo(() => query.data ?? [], [query.data]);
+  return {
+    users,
+    loading: query.isFetching,
+    refetch: query.refetch,
+  };
+}
@@ -0,0 +1,41 @@
+import { useMutation, useQueryClient } from '@tanstack/react-query';
+
+import { HttpUtil } from '@/utils';
+import { keys } from '@/api/queryKeys';
+import type { Voucher, VoucherGenerateFormValues, VoucherUpdateValues } from '@/schemas/voucher';
+
+const JSON_HEADERS = { headers: { 'Content-Type': 'application/json' } };
+
+export function useVoucherMutations() {
+  const queryClient = useQueryClient();
+  const invalidate = () => queryClient.invalidateQueries({ queryKey: keys.clients.vouchers() });
+
+  const generateMut = useMutation({
+    mutationFn: (payload: VoucherGenerateFormValues) =>
+      HttpUtil.post<Voucher[]>('/panel/api/clients/vouchers/generate', payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const updateMut = useMutation({
+    mutationFn: ({ id, payload }: { id: number; payload: VoucherUpdateValues }) =>
+      HttpUtil.post(`/panel/api/clients/vouchers/update/${id}`, payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const removeMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post(`/panel/api/clients/vouchers/del/${id}`),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  return {
+    generate: (payload: VoucherGenerateFormValues) => generateMut.mutateAsync(payload),
+    update: (id: number, payload: VoucherUpdateValues) => updateMut.mutateAsync({ id, payload }),
+    remove: (id: number) => removeMut.mutateAsync(id),
+  };
+}
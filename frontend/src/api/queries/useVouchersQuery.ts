@@ -0,0 +1,26 @@
+import { useQuery } from '@tanstack/react-query';
+import { useMemo } from 'react';
+
+import { HttpUtil } from '@/utils';
+import { parseMsg } from '@/utils/zodValidate';
+import { VoucherListSchema, type Voucher } from '@/schemas/voucher';
+import { keys } from '@/api/queryKeys';
+
+export type { Voucher };
+
+async function fetchVouchers(): Promise<Voucher[]> {
+  const msg = await HttpUtil.get('/panel/api/clients/vouchers', undefined, { silent: true });
+  if (!msg?.success) throw new Error(msg?.msg || 'Failed to fetch vouchers');
+  const validated = parseMsg(msg, VoucherListSchema, 'clients/vouchers');
+  return Array.isArray(validated.obj) ? validated.obj : [];
+}
+
+export function useVouchersQuery() {
+  const query = useQuery({ queryKey: keys.clients.vouchers(), queryFn: fetchVouchers });
+  const vouchers = useMemo(() => query.data ?? [], [query.data]);
+  return {
+    vouchers,
+    loading: query.isFetching,
+    error: query.error ? (query.error as Error).message : '',
+  };
+}
@@ -0,0 +1,54 @@
+import { useMutation, useQueryClient } from '@tanstack/react-query';
+
+import { HttpUtil } from '@/utils';
+import { keys } from '@/api/queryKeys';
+import type { WebhookFormValues } from '@/schemas/webhook';
+
+const JSON_HEADERS = { headers: { 'Content-Type': 'application/json' } };
+
+export function useWebhookMutations() {
+  const queryClient = useQueryClient();
+  const invalidate = () => queryClient.invalidateQueries({ queryKey: keys.webhooks.root() });
+
+  const addMut = useMutation({
+    mutationFn: (payload: WebhookFormValues) =>
+      HttpUtil.post('/panel/api/webhooks/add', payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const updateMut = useMutation({
+    mutationFn: ({ id, payload }: { id: number; payload: WebhookFormValues }) =>
+      HttpUtil.post(`/panel/api/webhooks/update/${id}`, payload, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const removeMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post(`/panel/api/webhooks/del/${id}`),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  // Test and replay are logged as deliveries whether or not they succeed.
+  const testMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post(`/panel/api/webhooks/test/${id}`),
+    onSettled: invalidate,
+  });
+
+  const replayMut = useMutation({
+    mutationFn: (id: number) => HttpUtil.post(`/panel/api/webhooks/deliveries/replay/${id}`),
+    onSettled: invalidate,
+  });
+
+  return {
+    add: (payload: WebhookFormValues) => addMut.mutateAsync(payload),
+    update: (id: number, payload: WebhookFormValues) => updateMut.mutateAsync({ id, payload }),
+    remove: (id: number) => removeMut.mutateAsync(id),
+    test: (id: number) => testMut.mutateAsync(id),
+    replay: (id: number) => replayMut.mutateAsync(id),
+  };
+}
@@ -0,0 +1,67 @@
+import { keepPreviousData, useQuery } from '@tanstack/react-query';
+import { useMemo } from 'react';
+
+import { HttpUtil } from '@/utils';
+import { parseMsg } from '@/utils/zodValidate';
+import {
+  WebhookDeliveryPageSchema,
+  WebhookListSchema,
+  type Webhook,
+  type WebhookDelivery,
+  type WebhookDeliveryPage,
+} from '@/schemas/webhook';
+import { keys } from '@/api/queryKeys';
+
+export type { Webhook, WebhookDelivery };
+
+export interface WebhookDeliveryParams {
+  page: number;
+  pageSize: number;
+  webhookId?: number;
+  status?: string;
+}
+
+async function fetchWebhooks(): Promise<Webhook[]> {
+  const msg = await HttpUtil.get('/panel/api/webhooks/list', undefined, { silent: true });
+  if (!msg?.success) throw new Error(msg?.msg || 'Failed to fetch webhooks');
+  const validated = parseMsg(msg, WebhookListSchema, 'webhooks/list');
+  return Array.isArray(validated.obj) ? validated.obj : [];
+}
+
+async function fetchDeliveries(p: WebhookDeliveryParams): Promise<WebhookDeliveryPage> {
+  const sp = new URLSearchParams();
+  sp.set('page', String(p.page));
+  sp.set('pageSize', String(p.pageSize));
+  if (p.webhookId) sp.set('webhookId', String(p.webhookId));
+  if (p.status) sp.set('status', p.status);
+  const msg = await HttpUtil.get(`/panel/api/webhooks/deliveries?${sp}`, undefined, {
+    silent: true,
+  });
+  if (!msg?.success || !msg.obj) throw new Error(msg?.msg || 'Failed to fetch deliveries');
+  const validated = parseMsg(msg, WebhookDeliveryPageSchema, 'webhooks/deliveries');
+  if (!validated.obj) throw new Error('Empty deliveries response');
+  return validated.obj;
+}
+
+export function useWebhooksQuery() {
+  const query = useQuery({ queryKey: keys.webhooks.list(), queryFn: fetchWebhooks });
+  const webhooks = useMemo(() => query.data ?? [], [query.data]);
+  return { webhooks, loading: query.isFetching };
+}
+
+export function useWebhookDeliveriesQuery(params: WebhookDeliveryParams) {
+  const query = useQuery({
+    queryKey: keys.webhooks.deliveries(params),
+    queryFn: () => fetchDeliveries(params),
+    placeholderData: keepPreviousData,
+    // Pending deliveries are retried in the background; poll until they settle.
+    refetchInterval: (q) =>
+      (q.state.data?.items ?? []).some((d) => d.status === 'pending') ? 5000 : false,
+  });
+  return {
+    items: query.data?.items ?? [],
+    total: query.data?.total ?? 0,
+    loading: query.isFetching,
+    refetch: query.refetch,
+  };
+}
@@ -6,6 +6,7 @@ export const keys = {
   nodes: {
     root: () => ['nodes'] as const,
     list: () => ['nodes', 'list'] as const,
+    rollouts: () => ['nodes', 'rollouts'] as const,
   },
   hosts: {
     root: () => ['hosts'] as const,
@@ -13,6 +14,29 @@ export const keys = {
     byInbound: (inboundId: number) => ['hosts', 'byInbound', inboundId] as const,
     tags: () => ['hosts', 'tags'] as const,
   },
+  acme: {
+    root: () => ['acme'] as const,
+    list: () => ['acme', 'list'] as const,
+    providers: () => ['acme', 'providers'] as const,
+  },
+  users: {
+    root: () => ['users'] as const,
+    me: () => ['users', 'me'] as const,
+    list: () => ['users', 'list'] as const,
+  },
+  audit: {
+    root: () => ['audit'] as const,
+    list: (params: unknown) => ['audit', 'list', params] as const,
+  },
+  webhooks: {
+    root: () => ['webhooks'] as const,
+    list: () => ['webhooks', 'list'] as const,
+    deliveries: (params: unknown) => ['webhooks', 'deliveries', params] as const,
+  },
+  backups: {
+    root: () => ['backups'] as const,
+    list: () => ['backups', 'list'] as const,
+  },
   settings: {
     root: () => ['settings'] as const,
     all: () => ['settings', 'all'] as const,
@@ -33,6 +57,8 @@ export const keys = {
     activeInbounds: () => ['clients', 'activeInbounds'] as const,
     lastOnline: () => ['clients', 'lastOnline'] as const,
     groups: () => ['clients', 'groups'] as const,
+    plans: () => ['clients', 'plans'] as const,
+    vouchers: () => ['clients', 'vouchers'] as const,
   },
   xray: {
     root: () => ['xray'] as const,
@@ -4,6 +4,7 @@ import { useQueryClient } from '@tanstack/react-query';
 import { getSharedWebSocketClient } from '@/api/websocket';
 import { keys } from '@/api/queryKeys';
 import { isRecentLocalInvalidate } from '@/api/invalidationTracker';
+import { mergeRollout, type NodeRollout } from '@/api/queries/useNodeRolloutsQuery';
 
 type Handler = (payload: unknown) => void;
 
@@ -40,6 +41,12 @@ export function useWebSocketBridge() {
       queryClient.setQueryData(keys.nodes.list(), payload);
     };
 
+    const onNodeRollout: Handler = (payload) => {
+      const r = payload as NodeRollout | undefined;
+      if (!r || typeof r.id !== 'number') return;
+      queryClient.setQueryData<NodeRollout[]>(keys.nodes.rollouts(), (prev) => mergeRollout(prev, r));
+    };
+
     const onInbounds: Handler = (payload) => {
       if (!Array.isArray(payload)) return;
       queryClient.setQueryData(keys.inbounds.slim(), payload);
@@ -48,6 +55,7 @@ export function useWebSocketBridge() {
     client.on('invalidate', onInvalidate);
     client.on('outbounds', onOutbounds);
     client.on('nodes', onNodes);
+    client.on('node_rollout', onNodeRollout);
     client.on('inbounds', onInbounds);
     client.connect();
 
@@ -55,6 +63,7 @@ export function useWebSocketBridge() {
       client.off('invalidate', onInvalidate);
       client.off('outbounds', onOutbounds);
       client.off('nodes', onNodes);
+      client.off('node_rollout', onNodeRollout);
       client.off('inbounds', onInbounds);
       if (invalidateTimer != null) {
         clearTimeout(invalidateTimer);
@@ -14,6 +14,7 @@ interface SubPageData {
   subUrl?: string;
   subJsonUrl?: string;
   subClashUrl?: string;
+  subSingboxUrl?: string;
   subTitle?: string;
   links?: string[];
   emails?: string[];
@@ -22,6 +23,10 @@ interface SubPageData {
   downloadByte?: string | number;
   uploadByte?: string | number;
   usedByte?: string | number;
+  usage?: { t: number; up: number; down: number }[];
+  redeemUrl?: string;
+  // Set on the standalone <subPath>redeem page, which has no subscription.
+  redeemOnly?: boolean;
 }
 
 interface Window {
@@ -1,6 +1,52 @@
 // Code generated by tools/openapigen. DO NOT EDIT.
 export const EXAMPLES: Record<string, unknown> = {
+  "AcmeCertificate": {
+    "autoRenew": false,
+    "certFile": "",
+    "challenge": "",
+    "createdAt": 0,
+    "dnsProvider": "",
+    "domains": [
+      ""
+    ],
+    "hasDnsConfig": false,
+    "id": 0,
+    "keyFile": "",
+    "lastAttempt": 0,
+    "lastError": "",
+    "notAfter": 0,
+    "notBefore": 0,
+    "status": "",
+    "updatedAt": 0
+  },
+  "AcmeIssueRequest": {
+    "autoRenew": false,
+    "challenge": "http-01",
+    "dnsConfig": {},
+    "dnsProvider": "",
+    "domains": [
+      ""
+    ]
+  },
   "AllSetting": {
+    "acmeDirectoryUrl": "",
+    "acmeEmail": "",
+    "acmeHttpPort": 0,
+    "acmeRenewDays": 1,
+    "acmeTlsAlpnPort": 0,
+    "auditRetentionDays": 0,
+    "backupCron": "",
+    "backupEnable": false,
+    "backupKeep": 0,
+    "backupLocalDir": "",
+    "backupPassphrase": "",
+    "backupS3AccessKey": "",
+    "backupS3Bucket": "",
+    "backupS3Endpoint": "",
+    "backupS3PathStyle": false,
+    "backupS3Prefix": "",
+    "backupS3Region": "",
+    "backupS3SecretKey": "",
     "datepicker": "",
     "expireDiff": 0,
     "externalTrafficInformEnable": false,
@@ -27,6 +73,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "ldapUserAttr": "",
     "ldapUserFilter": "",
     "ldapVlessField": "",
+    "metricsClientLabels": false,
     "outboundDownThreshold": 1,
     "pageSize": 0,
     "panelOutbound": "",
@@ -77,6 +124,13 @@ export const EXAMPLES: Record<string, unknown> = {
     "subProfileUrl": "",
     "subRoutingRules": "",
     "subShowIdentityOnAllLinks": false,
+    "subSingboxAutoDetect": false,
+    "subSingboxEnable": false,
+    "subSingboxMux": "",
+    "subSingboxPath": "",
+    "subSingboxRules": "",
+    "subSingboxURI": "",
+    "subSingboxUserAgentRegex": "",
     "subSupportUrl": "",
     "subThemeDir": "",
     "subTitle": "",
@@ -95,28 +149,49 @@ export const EXAMPLES: Record<string, unknown> = {
     "tgRunTime": "",
     "timeLocation": "",
     "trafficDiff": 0,
+    "trafficHistoryDays": 0,
+    "trafficHistoryHourlyDays": 1,
     "trustedProxyCIDRs": "",
-    "twoFactorEnable": false,
-    "twoFactorToken": "",
     "warpUpdateInterval": 0,
     "webBasePath": "",
     "webCertFile": "",
     "webDomain": "",
     "webKeyFile": "",
     "webListen": "",
-    "webPort": 1
+    "webPort": 1,
+    "webhookCpu": 0,
+    "webhookMemory": 0
   },
   "AllSettingView": {
+    "acmeDirectoryUrl": "",
+    "acmeEmail": "",
+    "acmeHttpPort": 0,
+    "acmeRenewDays": 1,
+    "acmeTlsAlpnPort": 0,
+    "auditRetentionDays": 0,
+    "backupCron": "",
+    "backupEnable": false,
+    "backupKeep": 0,
+    "backupLocalDir": "",
+    "backupPassphrase": "",
+    "backupS3AccessKey": "",
+    "backupS3Bucket": "",
+    "backupS3Endpoint": "",
+    "backupS3PathStyle": false,
+    "backupS3Prefix": "",
+    "backupS3Region": "",
+    "backupS3SecretKey": "",
     "datepicker": "",
     "expireDiff": 0,
     "externalTrafficInformEnable": false,
     "externalTrafficInformURI": "",
     "hasApiToken": false,
+    "hasBackupPassphrase": false,
+    "hasBackupS3SecretKey": false,
     "hasLdapPassword": false,
     "hasNordSecret": false,
     "hasSmtpPassword": false,
     "hasTgBotToken": false,
-    "hasTwoFactorToken": false,
     "hasWarpSecret": false,
     "ipLimitAllowlist": "",
     "ldapAutoCreate": false,
@@ -140,6 +215,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "ldapUserAttr": "",
     "ldapUserFilter": "",
     "ldapVlessField": "",
+    "metricsClientLabels": false,
     "outboundDownThreshold": 1,
     "pageSize": 0,
     "panelOutbound": "",
@@ -190,6 +266,13 @@ export const EXAMPLES: Record<string, unknown> = {
     "subProfileUrl": "",
     "subRoutingRules": "",
     "subShowIdentityOnAllLinks": false,
+    "subSingboxAutoDetect": false,
+    "subSingboxEnable": false,
+    "subSingboxMux": "",
+    "subSingboxPath": "",
+    "subSingboxRules": "",
+    "subSingboxURI": "",
+    "subSingboxUserAgentRegex": "",
     "subSupportUrl": "",
     "subThemeDir": "",
     "subTitle": "",
@@ -208,16 +291,21 @@ export const EXAMPLES: Record<string, unknown> = {
     "tgRunTime": "",
     "timeLocation": "",
     "trafficDiff": 0,
+    "trafficHistoryDays": 0,
+    "trafficHistoryHourlyDays": 1,
     "trustedProxyCIDRs": "",
-    "twoFactorEnable": false,
-    "twoFactorToken": "",
+    "unreadableSecrets": [
+      ""
+    ],
     "warpUpdateInterval": 0,
     "webBasePath": "",
     "webCertFile": "",
     "webDomain": "",
     "webKeyFile": "",
     "webListen": "",
-    "webPort": 1
+    "webPort": 1,
+    "webhookCpu": 0,
+    "webhookMemory": 0
   },
   "ApiToken": {
     "createdAt": 0,
@@ -237,6 +325,59 @@ export const EXAMPLES: Record<string, unknown> = {
     "scope": "admin",
     "token": "new-token-string"
   },
+  "AuditChange": {
+    "after": null,
+    "before": null
+  },
+  "AuditLog": {
+    "actor": "admin",
+    "actorType": "user",
+    "changes": {},
+    "createdAt": 1767225600000,
+    "error": "",
+    "id": 1,
+    "method": "POST",
+    "route": "/inbounds/update/:id",
+    "sourceIp": "203.0.113.7",
+    "success": true,
+    "targetId": "3",
+    "targetType": "inbound"
+  },
+  "AuditPage": {
+    "items": [
+      {
+        "actor": "admin",
+        "actorType": "user",
+        "changes": {},
+        "createdAt": 1767225600000,
+        "error": "",
+        "id": 1,
+        "method": "POST",
+        "route": "/inbounds/update/:id",
+        "sourceIp": "203.0.113.7",
+        "success": true,
+        "targetId": "3",
+        "targetType": "inbound"
+      }
+    ],
+    "page": 1,
+    "pageSize": 50,
+    "total": 120
+  },
+  "BackupManifest": {
+    "createdAt": 1767322800000,
+    "database": "sqlite",
+    "encrypted": true,
+    "name": "panel.example.com_2026-01-02_030000.sql.enc",
+    "sha256": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+    "size": 524288,
+    "target": "s3",
+    "version": "3.0.0"
+  },
+  "BulkCreateReport": {
+    "email": "",
+    "reason": ""
+  },
   "Client": {
     "adTag": "0123456789abcdef0123456789abcdef",
     "allowedIPs": [
@@ -245,6 +386,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "auth": "",
     "comment": "",
     "created_at": 0,
+    "egress": "",
     "email": "",
     "enable": false,
     "expiryTime": 0,
@@ -261,6 +403,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "resetDay": 0,
     "resetMax": 0,
     "reverse": null,
+    "schedule": "",
     "secret": "ee1234567890abcdef1234567890abcd7777772e636c6f7564666c6172652e636f6d",
     "security": "",
     "subId": "",
@@ -282,6 +425,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "auth": "",
     "comment": "",
     "createdAt": 0,
+    "egress": "",
     "email": "",
     "enable": false,
     "expiryTime": 0,
@@ -291,6 +435,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "keepAlive": 0,
     "limitHwid": 0,
     "limitIp": 0,
+    "ownerId": 0,
     "password": "",
     "preSharedKey": "",
     "privateKey": "",
@@ -299,6 +444,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "resetDay": 0,
     "resetMax": 0,
     "reverse": null,
+    "schedule": "",
     "secret": "",
     "security": "",
     "subId": "",
@@ -330,6 +476,33 @@ export const EXAMPLES: Record<string, unknown> = {
     "up": 1048576,
     "uuid": "e18c9a96-71bf-48d4-933f-8b9a46d4290c"
   },
+  "ConfigPreview": {
+    "runtimes": [
+      {
+        "addedInbounds": [
+          ""
+        ],
+        "addedOutbounds": [
+          ""
+        ],
+        "addedUsers": "inbound-443/alice",
+        "diff": "--- current\n+++ proposed\n@@ -12,3 +12,7 @@\n",
+        "nodeId": 0,
+        "removedInbounds": [
+          ""
+        ],
+        "removedOutbounds": [
+          ""
+        ],
+        "removedUsers": [
+          ""
+        ],
+        "restart": false,
+        "routingChanged": false,
+        "runtime": "local"
+      }
+    ]
+  },
   "FallbackParentInfo": {
     "masterId": 0,
     "path": ""
@@ -382,6 +555,13 @@ export const EXAMPLES: Record<string, unknown> = {
     "reason": "categoryMissing",
     "token": "geosite:blabla"
   },
+  "HAStatus": {
+    "enabled": true,
+    "leader": "panel-a",
+    "node": "panel-a",
+    "role": "leader",
+    "since": 1735689600
+  },
   "HistoryOfSeeders": {
     "id": 0,
     "seederName": ""
@@ -479,6 +659,34 @@ export const EXAMPLES: Record<string, unknown> = {
     "verifyPeerCertByName": "",
     "vlessRoute": ""
   },
+  "ImportInboundReport": {
+    "action": "create",
+    "reason": "port 443 is already in use",
+    "source": "VLESS TCP REALITY",
+    "target": "VLESS TCP REALITY"
+  },
+  "ImportReport": {
+    "created": 120,
+    "dryRun": false,
+    "inbounds": [
+      {
+        "action": "create",
+        "reason": "port 443 is already in use",
+        "source": "VLESS TCP REALITY",
+        "target": "VLESS TCP REALITY"
+      }
+    ],
+    "skipped": [
+      {
+        "email": "",
+        "reason": ""
+      }
+    ],
+    "source": "marzban",
+    "warnings": [
+      ""
+    ]
+  },
   "Inbound": {
     "clientStats": [
       {
@@ -504,6 +712,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "down": 0,
     "enable": true,
     "expiryTime": 0,
+    "failoverOf": null,
     "fallbackParent": null,
     "id": 1,
     "lastTrafficResetTime": 0,
@@ -517,10 +726,12 @@ export const EXAMPLES: Record<string, unknown> = {
     "shareAddr": "",
     "shareAddrStrategy": "node",
     "sniffing": null,
+    "standbyNodeId": null,
     "streamSettings": null,
     "subSortIndex": 1,
     "tag": "in-443-tcp",
     "total": 0,
+    "trafficCoefficient": 1,
     "trafficReset": "never",
     "trafficResetDay": 1,
     "up": 0
@@ -594,6 +805,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "netUp": 1048576,
     "onlineCount": 3,
     "outboundTag": "",
+    "outboxCount": 0,
     "panelVersion": "v3.x.x",
     "parentGuid": "",
     "pinnedCertSha256": "",
@@ -602,6 +814,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "scheme": "https",
     "status": "online",
     "tlsVerifyMode": "verify",
+    "trafficCoefficient": 1,
     "transitive": false,
     "updatedAt": 1700000000,
     "uptimeSecs": 86400,
@@ -609,6 +822,22 @@ export const EXAMPLES: Record<string, unknown> = {
     "xrayState": "",
     "xrayVersion": "25.10.31"
   },
+  "NodeJoinToken": {
+    "allowPrivateAddress": false,
+    "createdAt": 0,
+    "expiresAt": 1736003600000,
+    "id": 1,
+    "name": "de-fra-2",
+    "nodeId": 0,
+    "usedAt": 0
+  },
+  "NodeJoinTokenCreated": {
+    "expiresAt": 1736003600000,
+    "id": 1,
+    "masterPin": "",
+    "name": "de-fra-2",
+    "token": "k3Jd8s..."
+  },
   "NodeMutationRequest": {
     "address": "",
     "allowPrivateAddress": false,
@@ -627,7 +856,61 @@ export const EXAMPLES: Record<string, unknown> = {
     "port": 1,
     "remark": "",
     "scheme": "http",
-    "tlsVerifyMode": "verify"
+    "tlsVerifyMode": "verify",
+    "trafficCoefficient": 0
+  },
+  "NodeOutboxEntry": {
+    "attempts": 0,
+    "createdAt": 0,
+    "email": "alice",
+    "id": 1,
+    "key": "3f2a...",
+    "lastError": "",
+    "nextAttemptAt": 1736003600000,
+    "nodeId": 1,
+    "op": "updateUser",
+    "status": "pending",
+    "tag": "inbound-443",
+    "updatedAt": 0
+  },
+  "NodeRollout": {
+    "batchSize": 5,
+    "canarySize": 1,
+    "createdAt": 0,
+    "dev": false,
+    "error": "",
+    "finishedAt": 0,
+    "id": 1,
+    "kind": "panel",
+    "status": "running",
+    "steps": [
+      {
+        "batch": 0,
+        "error": "",
+        "finishedAt": 0,
+        "fromVersion": "v3.6.0",
+        "id": 1,
+        "nodeId": 3,
+        "nodeName": "de-fra-1",
+        "rolloutId": 1,
+        "startedAt": 0,
+        "state": "pending"
+      }
+    ],
+    "targetVersion": "v3.6.1",
+    "updatedAt": 0
+  },
+  "NodeRolloutStep": {
+    "batch": 0,
+    "error": "",
+    "finishedAt": 0,
+    "fromVersion": "v3.6.0",
+    "id": 1,
+    "nodeId": 3,
+    "nodeName": "de-fra-1",
+    "rolloutId": 1,
+    "startedAt": 0,
+    "state": "pending"
   },
   "NodeView": {
     "activeCount": 20,
@@ -667,6 +950,7 @@ export const EXAMPLES: Record<string, unknown> = {
     "scheme": "https",
     "status": "online",
     "tlsVerifyMode": "verify",
+    "trafficCoefficient": 1,
     "transitive": false,
     "updatedAt": 1700003600,
     "uptimeSecs": 86400,
@@ -685,7 +969,25 @@ export const EXAMPLES: Record<string, unknown> = {
     "exitCode": 0,
     "finishedAt": 1735689612,
     "runId": "1735689600123456789",
-    "state": "success"
+    "state": "success",
+    "verification": null
+  },
+  "Plan": {
+    "comment": "",
+    "createdAt": 0,
+    "durationDays": 30,
+    "group": "",
+    "id": 1,
+    "inboundIds": "1,2",
+    "limitHwid": 0,
+    "limitIp": 0,
+    "name": "monthly-50",
+    "renewMode": "extend",
+    "startAfterFirstUse": false,
+    "totalGB": 53687091200,
+    "trafficReset": "never",
+    "trafficResetDay": 0,
+    "updatedAt": 0
   },
   "ProbeResultUI": {
     "cpuPct": 12.5,
@@ -723,14 +1025,185 @@ export const EXAMPLES: Record<string, unknown> = {
     "tlsVersion": "1.3",
     "x25519": true
   },
+  "ResellerUsage": {
+    "clients": 12,
+    "totalGB": 128849018880
+  },
+  "RuntimePreview": {
+    "addedInbounds": [
+      ""
+    ],
+    "addedOutbounds": [
+      ""
+    ],
+    "addedUsers": "inbound-443/alice",
+    "diff": "--- current\n+++ proposed\n@@ -12,3 +12,7 @@\n",
+    "nodeId": 0,
+    "removedInbounds": [
+      ""
+    ],
+    "removedOutbounds": [
+      ""
+    ],
+    "removedUsers": [
+      ""
+    ],
+    "restart": false,
+    "routingChanged": false,
+    "runtime": "local"
+  },
   "Setting": {
     "id": 0,
     "key": "",
     "value": ""
   },
+  "StateApplyResult": {
+    "applied": 3,
+    "changes": [
+      {
+        "action": "update",
+        "fields": [
+          "port",
+          "streamSettings"
+        ],
+        "key": "in-443-tcp",
+        "kind": "inbound"
+      }
+    ],
+    "needRestart": true
+  },
+  "StateChange": {
+    "action": "update",
+    "fields": [
+      "port",
+      "streamSettings"
+    ],
+    "key": "in-443-tcp",
+    "kind": "inbound"
+  },
+  "StatePlan": {
+    "changes": [
+      {
+        "action": "update",
+        "fields": [
+          "port",
+          "streamSettings"
+        ],
+        "key": "in-443-tcp",
+        "kind": "inbound"
+      }
+    ],
+    "errors": [
+      ""
+    ]
+  },
+  "TrafficHistoryPoint": {
+    "down": 2097152,
+    "t": 1735689600,
+    "up": 1048576
+  },
+  "UpdateVerification": {
+    "error": "",
+    "keyId": "5f1c0e2a9b7d3c41",
+    "tag": "v3.4.0",
+    "verified": true
+  },
   "User": {
+    "allowedInbounds": [
+      0
+    ],
     "id": 0,
+    "maxClients": 0,
+    "maxExpiryDays": 0,
+    "maxTrafficGB": 0,
     "password": "",
+    "permissions": {},
+    "role": "",
+    "twoFactorEnable": false,
     "username": ""
+  },
+  "UserAccountRequest": {
+    "allowedInbounds": [
+      0
+    ],
+    "maxClients": 0,
+    "maxExpiryDays": 0,
+    "maxTrafficGB": 0,
+    "password": "",
+    "permissions": {},
+    "role": "",
+    "username": ""
+  },
+  "UserView": {
+    "allowedInbounds": [
+      0
+    ],
+    "effective": {},
+    "id": 2,
+    "maxClients": 0,
+    "maxExpiryDays": 0,
+    "maxTrafficGB": 0,
+    "permissions": {},
+    "primary": false,
+    "role": "read-only",
+    "twoFactorEnable": false,
+    "usage": null,
+    "username": "support"
+  },
+  "Voucher": {
+    "code": "K7QM-9XPT-4RAZ",
+    "comment": "",
+    "createdAt": 0,
+    "enable": true,
+    "expiresAt": 0,
+    "id": 1,
+    "kind": "any",
+    "maxUses": 1,
+    "planId": 1,
+    "updatedAt": 0,
+    "uses": 0
+  },
+  "Webhook": {
+    "allowPrivate": false,
+    "createdAt": 0,
+    "enable": true,
+    "events": "node.down,xray.crash",
+    "id": 1,
+    "name": "ops",
+    "updatedAt": 0,
+    "url": "https://hooks.example.com/3x-ui"
+  },
+  "WebhookDelivery": {
+    "attempts": 1,
+    "createdAt": 0,
+    "error": "",
+    "event": "node.down",
+    "id": 1,
+    "nextAttemptAt": 0,
+    "payload": "",
+    "responseCode": 200,
+    "status": "success",
+    "updatedAt": 0,
+    "webhookId": 1
+  },
+  "WebhookDeliveryPage": {
+    "items": [
+      {
+        "attempts": 1,
+        "createdAt": 0,
+        "error": "",
+        "event": "node.down",
+        "id": 1,
+        "nextAttemptAt": 0,
+        "payload": "",
+        "responseCode": 200,
+        "status": "success",
+        "updatedAt": 0,
+        "webhookId": 1
+      }
+    ],
+    "page": 1,
+    "pageSize": 50,
+    "total": 42
   }
 };
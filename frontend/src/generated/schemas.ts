@@ -1,7 +1,185 @@
 // Code generated by tools/openapigen. DO NOT EDIT.
 export const SCHEMAS: Record<string, unknown> = {
+  "AcmeCertificate": {
+    "description": "AcmeCertificate is a certificate issued by the built-in ACME client. The DB\nrow is the source of truth; CertFile/KeyFile are rewritten from it.",
+    "properties": {
+      "autoRenew": {
+        "type": "boolean"
+      },
+      "certFile": {
+        "type": "string"
+      },
+      "challenge": {
+        "type": "string"
+      },
+      "createdAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "dnsProvider": {
+        "type": "string"
+      },
+      "domains": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "hasDnsConfig": {
+        "type": "boolean"
+      },
+      "id": {
+        "type": "integer"
+      },
+      "keyFile": {
+        "type": "string"
+      },
+      "lastAttempt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "lastError": {
+        "type": "string"
+      },
+      "notAfter": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "notBefore": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "status": {
+        "type": "string"
+      },
+      "updatedAt": {
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "autoRenew",
+      "certFile",
+      "challenge",
+      "createdAt",
+      "dnsProvider",
+      "domains",
+      "hasDnsConfig",
+      "id",
+      "keyFile",
+      "lastAttempt",
+      "lastError",
+      "notAfter",
+      "notBefore",
+      "status",
+      "updatedAt"
+    ],
+    "type": "object"
+  },
+  "AcmeIssueRequest": {
+    "description": "AcmeIssueRequest orders a new certificate. DnsConfig holds the provider's\ncredentials and is only read for the dns-01 challenge.",
+    "properties": {
+      "autoRenew": {
+        "type": "boolean"
+      },
+      "challenge": {
+        "enum": [
+          "http-01",
+          "tls-alpn-01",
+          "dns-01"
+        ],
+        "type": "string"
+      },
+      "dnsConfig": {
+        "additionalProperties": {
+          "type": "string"
+        },
+        "type": "object"
+      },
+      "dnsProvider": {
+        "type": "string"
+      },
+      "domains": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      }
+    },
+    "required": [
+      "autoRenew",
+      "challenge",
+      "dnsConfig",
+      "dnsProvider",
+      "domains"
+    ],
+    "type": "object"
+  },
   "AllSetting": {
     "properties": {
+      "acmeDirectoryUrl": {
+        "type": "string"
+      },
+      "acmeEmail": {
+        "type": "string"
+      },
+      "acmeHttpPort": {
+        "maximum": 65535,
+        "minimum": 0,
+        "type": "integer"
+      },
+      "acmeRenewDays": {
+        "maximum": 89,
+        "minimum": 1,
+        "type": "integer"
+      },
+      "acmeTlsAlpnPort": {
+        "maximum": 65535,
+        "minimum": 0,
+        "type": "integer"
+      },
+      "auditRetentionDays": {
+        "minimum": 0,
+        "type": "integer"
+      },
+      "backupCron": {
+        "type": "string"
+      },
+      "backupEnable": {
+        "description": "Scheduled backups",
+        "type": "boolean"
+      },
+      "backupKeep": {
+        "minimum": 0,
+        "type": "integer"
+      },
+      "backupLocalDir": {
+        "type": "string"
+      },
+      "backupPassphrase": {
+        "type": "string"
+      },
+      "backupS3AccessKey": {
+        "type": "string"
+      },
+      "backupS3Bucket": {
+        "type": "string"
+      },
+      "backupS3Endpoint": {
+        "type": "string"
+      },
+      "backupS3PathStyle": {
+        "type": "boolean"
+      },
+      "backupS3Prefix": {
+        "type": "string"
+      },
+      "backupS3Region": {
+        "type": "string"
+      },
+      "backupS3SecretKey": {
+        "type": "string"
+      },
       "datepicker": {
         "type": "string"
       },
@@ -86,6 +264,9 @@ export const SCHEMAS: Record<string, unknown> = {
       "ldapVlessField": {
         "type": "string"
       },
+      "metricsClientLabels": {
+        "type": "boolean"
+      },
       "outboundDownThreshold": {
         "maximum": 100,
         "minimum": 1,
@@ -250,6 +431,27 @@ export const SCHEMAS: Record<string, unknown> = {
       "subShowIdentityOnAllLinks": {
         "type": "boolean"
       },
+      "subSingboxAutoDetect": {
+        "type": "boolean"
+      },
+      "subSingboxEnable": {
+        "type": "boolean"
+      },
+      "subSingboxMux": {
+        "type": "string"
+      },
+      "subSingboxPath": {
+        "type": "string"
+      },
+      "subSingboxRules": {
+        "type": "string"
+      },
+      "subSingboxURI": {
+        "type": "string"
+      },
+      "subSingboxUserAgentRegex": {
+        "type": "string"
+      },
       "subSupportUrl": {
         "type": "string"
       },
@@ -312,13 +514,16 @@ export const SCHEMAS: Record<string, unknown> = {
         "minimum": 0,
         "type": "integer"
       },
-      "trustedProxyCIDRs": {
-        "type": "string"
+      "trafficHistoryDays": {
+        "minimum": 0,
+        "type": "integer"
       },
-      "twoFactorEnable": {
-        "type": "boolean"
+      "trafficHistoryHourlyDays": {
+        "maximum": 90,
+        "minimum": 1,
+        "type": "integer"
       },
-      "twoFactorToken": {
+      "trustedProxyCIDRs": {
         "type": "string"
       },
       "warpUpdateInterval": {
@@ -344,9 +549,37 @@ export const SCHEMAS: Record<string, unknown> = {
         "maximum": 65535,
         "minimum": 1,
         "type": "integer"
+      },
+      "webhookCpu": {
+        "maximum": 100,
+        "minimum": 0,
+        "type": "integer"
+      },
+      "webhookMemory": {
+        "maximum": 100,
+        "minimum": 0,
+        "type": "integer"
       }
     },
     "required": [
+      "acmeDirectoryUrl",
+      "acmeEmail",
+      "acmeHttpPort",
+      "acmeRenewDays",
+      "acmeTlsAlpnPort",
+      "auditRetentionDays",
+      "backupCron",
+      "backupEnable",
+      "backupKeep",
+      "backupLocalDir",
+      "backupPassphrase",
+      "backupS3AccessKey",
+      "backupS3Bucket",
+      "backupS3Endpoint",
+      "backupS3PathStyle",
+      "backupS3Prefix",
+      "backupS3Region",
+      "backupS3SecretKey",
       "datepicker",
       "expireDiff",
       "externalTrafficInformEnable",
@@ -373,6 +606,7 @@ export const SCHEMAS: Record<string, unknown> = {
       "ldapUserAttr",
       "ldapUserFilter",
       "ldapVlessField",
+      "metricsClientLabels",
       "outboundDownThreshold",
       "pageSize",
       "panelOutbound",
@@ -423,6 +657,13 @@ export const SCHEMAS: Record<string, unknown> = {
       "subProfileUrl",
       "subRoutingRules",
       "subShowIdentityOnAllLinks",
+      "subSingboxAutoDetect",
+      "subSingboxEnable",
+      "subSingboxMux",
+      "subSingboxPath",
+      "subSingboxRules",
+      "subSingboxURI",
+      "subSingboxUserAgentRegex",
       "subSupportUrl",
       "subThemeDir",
       "subTitle",
@@ -441,21 +682,86 @@ export const SCHEMAS: Record<string, unknown> = {
       "tgRunTime",
       "timeLocation",
       "trafficDiff",
+      "trafficHistoryDays",
+      "trafficHistoryHourlyDays",
       "trustedProxyCIDRs",
-      "twoFactorEnable",
-      "twoFactorToken",
       "warpUpdateInterval",
       "webBasePath",
       "webCertFile",
       "webDomain",
       "webKeyFile",
       "webListen",
-      "webPort"
+      "webPort",
+      "webhookCpu",
+      "webhookMemory"
     ],
     "type": "object"
   },
   "AllSettingView": {
     "properties": {
+      "acmeDirectoryUrl": {
+        "type": "string"
+      },
+      "acmeEmail": {
+        "type": "string"
+      },
+      "acmeHttpPort": {
+        "maximum": 65535,
+        "minimum": 0,
+        "type": "integer"
+      },
+      "acmeRenewDays": {
+        "maximum": 89,
+        "minimum": 1,
+        "type": "integer"
+      },
+      "acmeTlsAlpnPort": {
+        "maximum": 65535,
+        "minimum": 0,
+        "type": "integer"
+      },
+      "auditRetentionDays": {
+        "minimum": 0,
+        "type": "integer"
+      },
+      "backupCron": {
+        "type": "string"
+      },
+      "backupEnable": {
+        "description": "Scheduled backups",
+        "type": "boolean"
+      },
+      "backupKeep": {
+        "minimum": 0,
+        "type": "integer"
+      },
+      "backupLocalDir": {
+        "type": "string"
+      },
+      "backupPassphrase": {
+        "type": "string"
+      },
+      "backupS3AccessKey": {
+        "type": "string"
+      },
+      "backupS3Bucket": {
+        "type": "string"
+      },
+      "backupS3Endpoint": {
+        "type": "string"
+      },
+      "backupS3PathStyle": {
+        "type": "boolean"
+      },
+      "backupS3Prefix": {
+        "type": "string"
+      },
+      "backupS3Region": {
+        "type": "string"
+      },
+      "backupS3SecretKey": {
+        "type": "string"
+      },
       "datepicker": {
         "type": "string"
       },
@@ -472,6 +778,12 @@ export const SCHEMAS: Record<string, unknown> = {
       "hasApiToken": {
         "type": "boolean"
       },
+      "hasBackupPassphrase": {
+        "type": "boolean"
+      },
+      "hasBackupS3SecretKey": {
+        "type": "boolean"
+      },
       "hasLdapPassword": {
         "type": "boolean"
       },
@@ -484,9 +796,6 @@ export const SCHEMAS: Record<string, unknown> = {
       "hasTgBotToken": {
         "type": "boolean"
       },
-      "hasTwoFactorToken": {
-        "type": "boolean"
-      },
       "hasWarpSecret": {
         "type": "boolean"
       },
@@ -561,6 +870,9 @@ export const SCHEMAS: Record<string, unknown> = {
       "ldapVlessField": {
         "type": "string"
       },
+      "metricsClientLabels": {
+        "type": "boolean"
+      },
       "outboundDownThreshold": {
         "maximum": 100,
         "minimum": 1,
@@ -725,6 +1037,27 @@ export const SCHEMAS: Record<string, unknown> = {
       "subShowIdentityOnAllLinks": {
         "type": "boolean"
       },
+      "subSingboxAutoDetect": {
+        "type": "boolean"
+      },
+      "subSingboxEnable": {
+        "type": "boolean"
+      },
+      "subSingboxMux": {
+        "type": "string"
+      },
+      "subSingboxPath": {
+        "type": "string"
+      },
+      "subSingboxRules": {
+        "type": "string"
+      },
+      "subSingboxURI": {
+        "type": "string"
+      },
+      "subSingboxUserAgentRegex": {
+        "type": "string"
+      },
       "subSupportUrl": {
         "type": "string"
       },
@@ -787,15 +1120,25 @@ export const SCHEMAS: Record<string, unknown> = {
         "minimum": 0,
         "type": "integer"
       },
-      "trustedProxyCIDRs": {
-        "type": "string"
+      "trafficHistoryDays": {
+        "minimum": 0,
+        "type": "integer"
       },
-      "twoFactorEnable": {
-        "type": "boolean"
+      "trafficHistoryHourlyDays": {
+        "maximum": 90,
+        "minimum": 1,
+        "type": "integer"
       },
-      "twoFactorToken": {
+      "trustedProxyCIDRs": {
         "type": "string"
       },
+      "unreadableSecrets": {
+        "description": "UnreadableSecrets names, by scope, the sealed values this panel's keyring\ncannot open. They must be entered again.",
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
       "warpUpdateInterval": {
         "minimum": 0,
         "type": "integer"
@@ -819,19 +1162,48 @@ export const SCHEMAS: Record<string, unknown> = {
         "maximum": 65535,
         "minimum": 1,
         "type": "integer"
+      },
+      "webhookCpu": {
+        "maximum": 100,
+        "minimum": 0,
+        "type": "integer"
+      },
+      "webhookMemory": {
+        "maximum": 100,
+        "minimum": 0,
+        "type": "integer"
       }
     },
     "required": [
+      "acmeDirectoryUrl",
+      "acmeEmail",
+      "acmeHttpPort",
+      "acmeRenewDays",
+      "acmeTlsAlpnPort",
+      "auditRetentionDays",
+      "backupCron",
+      "backupEnable",
+      "backupKeep",
+      "backupLocalDir",
+      "backupPassphrase",
+      "backupS3AccessKey",
+      "backupS3Bucket",
+      "backupS3Endpoint",
+      "backupS3PathStyle",
+      "backupS3Prefix",
+      "backupS3Region",
+      "backupS3SecretKey",
       "datepicker",
       "expireDiff",
       "externalTrafficInformEnable",
       "externalTrafficInformURI",
       "hasApiToken",
+      "hasBackupPassphrase",
+      "hasBackupS3SecretKey",
       "hasLdapPassword",
       "hasNordSecret",
       "hasSmtpPassword",
       "hasTgBotToken",
-      "hasTwoFactorToken",
       "hasWarpSecret",
       "ipLimitAllowlist",
       "ldapAutoCreate",
@@ -855,6 +1227,7 @@ export const SCHEMAS: Record<string, unknown> = {
       "ldapUserAttr",
       "ldapUserFilter",
       "ldapVlessField",
+      "metricsClientLabels",
       "outboundDownThreshold",
       "pageSize",
       "panelOutbound",
@@ -905,6 +1278,13 @@ export const SCHEMAS: Record<string, unknown> = {
       "subProfileUrl",
       "subRoutingRules",
       "subShowIdentityOnAllLinks",
+      "subSingboxAutoDetect",
+      "subSingboxEnable",
+      "subSingboxMux",
+      "subSingboxPath",
+      "subSingboxRules",
+      "subSingboxURI",
+      "subSingboxUserAgentRegex",
       "subSupportUrl",
       "subThemeDir",
       "subTitle",
@@ -923,16 +1303,19 @@ export const SCHEMAS: Record<string, unknown> = {
       "tgRunTime",
       "timeLocation",
       "trafficDiff",
+      "trafficHistoryDays",
+      "trafficHistoryHourlyDays",
       "trustedProxyCIDRs",
-      "twoFactorEnable",
-      "twoFactorToken",
+      "unreadableSecrets",
       "warpUpdateInterval",
       "webBasePath",
       "webCertFile",
       "webDomain",
       "webKeyFile",
       "webListen",
-      "webPort"
+      "webPort",
+      "webhookCpu",
+      "webhookMemory"
     ],
     "type": "object"
   },
@@ -1017,16 +1400,193 @@ export const SCHEMAS: Record<string, unknown> = {
     ],
     "type": "object"
   },
-  "Client": {
-    "description": "Client represents a client configuration for Xray inbounds with traffic limits and settings.",
+  "AuditChange": {
+    "description": "AuditChange is one changed field; a nil side means the field was absent.",
     "properties": {
-      "adTag": {
-        "example": "0123456789abcdef0123456789abcdef",
+      "after": {},
+      "before": {}
+    },
+    "required": [
+      "after",
+      "before"
+    ],
+    "type": "object"
+  },
+  "AuditLog": {
+    "description": "AuditLog is one state-changing panel API call. Changes maps dotted field\npaths of the target to their values before and after the call.",
+    "properties": {
+      "actor": {
+        "example": "admin",
         "type": "string"
       },
-      "allowedIPs": {
-        "items": {
-          "type": "string"
+      "actorType": {
+        "example": "user",
+        "type": "string"
+      },
+      "changes": {
+        "additionalProperties": {
+          "$ref": "#/components/schemas/AuditChange"
+        },
+        "type": "object"
+      },
+      "createdAt": {
+        "example": 1767225600000,
+        "format": "int64",
+        "type": "integer"
+      },
+      "error": {
+        "type": "string"
+      },
+      "id": {
+        "example": 1,
+        "type": "integer"
+      },
+      "method": {
+        "example": "POST",
+        "type": "string"
+      },
+      "route": {
+        "example": "/inbounds/update/:id",
+        "type": "string"
+      },
+      "sourceIp": {
+        "example": "203.0.113.7",
+        "type": "string"
+      },
+      "success": {
+        "example": true,
+        "type": "boolean"
+      },
+      "targetId": {
+        "example": "3",
+        "type": "string"
+      },
+      "targetType": {
+        "example": "inbound",
+        "type": "string"
+      }
+    },
+    "required": [
+      "actor",
+      "actorType",
+      "changes",
+      "createdAt",
+      "error",
+      "id",
+      "method",
+      "route",
+      "sourceIp",
+      "success",
+      "targetId",
+      "targetType"
+    ],
+    "type": "object"
+  },
+  "AuditPage": {
+    "description": "AuditPage is one page of audit entries, newest first.",
+    "properties": {
+      "items": {
+        "items": {
+          "$ref": "#/components/schemas/AuditLog"
+        },
+        "type": "array"
+      },
+      "page": {
+        "example": 1,
+        "type": "integer"
+      },
+      "pageSize": {
+        "example": 50,
+        "type": "integer"
+      },
+      "total": {
+        "example": 120,
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "items",
+      "page",
+      "pageSize",
+      "total"
+    ],
+    "type": "object"
+  },
+  "BackupManifest": {
+    "description": "BackupManifest is stored next to every dump and describes it; Sha256 and\nSize cover the stored (possibly encrypted) bytes.",
+    "properties": {
+      "createdAt": {
+        "example": 1767322800000,
+        "format": "int64",
+        "type": "integer"
+      },
+      "database": {
+        "example": "sqlite",
+        "type": "string"
+      },
+      "encrypted": {
+        "example": true,
+        "type": "boolean"
+      },
+      "name": {
+        "example": "panel.example.com_2026-01-02_030000.sql.enc",
+        "type": "string"
+      },
+      "sha256": {
+        "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+        "type": "string"
+      },
+      "size": {
+        "example": 524288,
+        "format": "int64",
+        "type": "integer"
+      },
+      "target": {
+        "example": "s3",
+        "type": "string"
+      },
+      "version": {
+        "example": "3.0.0",
+        "type": "string"
+      }
+    },
+    "required": [
+      "createdAt",
+      "database",
+      "encrypted",
+      "name",
+      "sha256",
+      "size",
+      "version"
+    ],
+    "type": "object"
+  },
+  "BulkCreateReport": {
+    "properties": {
+      "email": {
+        "type": "string"
+      },
+      "reason": {
+        "type": "string"
+      }
+    },
+    "required": [
+      "email",
+      "reason"
+    ],
+    "type": "object"
+  },
+  "Client": {
+    "description": "Client represents a client configuration for Xray inbounds with traffic limits and settings.",
+    "properties": {
+      "adTag": {
+        "example": "0123456789abcdef0123456789abcdef",
+        "type": "string"
+      },
+      "allowedIPs": {
+        "items": {
+          "type": "string"
         },
         "type": "array"
       },
@@ -1043,6 +1603,10 @@ export const SCHEMAS: Record<string, unknown> = {
         "format": "int64",
         "type": "integer"
       },
+      "egress": {
+        "description": "Outbound or balancer tag the client's traffic leaves through; overrides\nthe group's.",
+        "type": "string"
+      },
       "email": {
         "description": "Client email identifier",
         "type": "string"
@@ -1109,6 +1673,10 @@ export const SCHEMAS: Record<string, unknown> = {
         "description": "VLESS simple reverse proxy settings",
         "nullable": true
       },
+      "schedule": {
+        "description": "Weekly windows the client may connect in, like \"mon-fri 09:00-18:00\",\nread in the panel's time zone; overrides the group's. Empty is always.",
+        "type": "string"
+      },
       "secret": {
         "example": "ee1234567890abcdef1234567890abcd7777772e636c6f7564666c6172652e636f6d",
         "type": "string"
@@ -1211,6 +1779,10 @@ export const SCHEMAS: Record<string, unknown> = {
         "format": "int64",
         "type": "integer"
       },
+      "egress": {
+        "description": "overrides the group's egress",
+        "type": "string"
+      },
       "email": {
         "type": "string"
       },
@@ -1239,6 +1811,10 @@ export const SCHEMAS: Record<string, unknown> = {
       "limitIp": {
         "type": "integer"
       },
+      "ownerId": {
+        "description": "reseller that created it; 0 = panel",
+        "type": "integer"
+      },
       "password": {
         "type": "string"
       },
@@ -1261,6 +1837,10 @@ export const SCHEMAS: Record<string, unknown> = {
         "type": "integer"
       },
       "reverse": {},
+      "schedule": {
+        "description": "overrides the group's schedule",
+        "type": "string"
+      },
       "secret": {
         "type": "string"
       },
@@ -1298,6 +1878,7 @@ export const SCHEMAS: Record<string, unknown> = {
       "auth",
       "comment",
       "createdAt",
+      "egress",
       "email",
       "enable",
       "expiryTime",
@@ -1307,6 +1888,7 @@ export const SCHEMAS: Record<string, unknown> = {
       "keepAlive",
       "limitHwid",
       "limitIp",
+      "ownerId",
       "password",
       "preSharedKey",
       "privateKey",
@@ -1315,6 +1897,7 @@ export const SCHEMAS: Record<string, unknown> = {
       "resetDay",
       "resetMax",
       "reverse",
+      "schedule",
       "secret",
       "security",
       "subId",
@@ -1435,6 +2018,21 @@ export const SCHEMAS: Record<string, unknown> = {
     ],
     "type": "object"
   },
+  "ConfigPreview": {
+    "description": "ConfigPreview is the outcome of a dry-run mutation: the config each affected\nruntime would end up with, as a diff against what it is generated as now.",
+    "properties": {
+      "runtimes": {
+        "items": {
+          "$ref": "#/components/schemas/RuntimePreview"
+        },
+        "type": "array"
+      }
+    },
+    "required": [
+      "runtimes"
+    ],
+    "type": "object"
+  },
   "FallbackParentInfo": {
     "description": "FallbackParentInfo carries everything the frontend needs to rewrite a\nchild inbound's client link: where to connect (the master's address\nand port) and which path matched on the master's fallbacks array.\nThe frontend already has the master inbound in its dbInbounds list,\nso we only ship identifiers + the match path here.",
     "properties": {
@@ -1601,6 +2199,39 @@ export const SCHEMAS: Record<string, unknown> = {
     ],
     "type": "object"
   },
+  "HAStatus": {
+    "description": "HAStatus is the HA state reported by the panel API.",
+    "properties": {
+      "enabled": {
+        "example": true,
+        "type": "boolean"
+      },
+      "leader": {
+        "example": "panel-a",
+        "type": "string"
+      },
+      "node": {
+        "example": "panel-a",
+        "type": "string"
+      },
+      "role": {
+        "example": "leader",
+        "type": "string"
+      },
+      "since": {
+        "example": 1735689600,
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "enabled",
+      "node",
+      "role",
+      "since"
+    ],
+    "type": "object"
+  },
   "HistoryOfSeeders": {
     "description": "HistoryOfSeeders tracks which database seeders have been executed to prevent re-running.",
     "properties": {
@@ -1967,6 +2598,72 @@ export const SCHEMAS: Record<string, unknown> = {
     ],
     "type": "object"
   },
+  "ImportInboundReport": {
+    "description": "ImportInboundReport says what became of one inbound of the source panel.",
+    "properties": {
+      "action": {
+        "example": "create",
+        "type": "string"
+      },
+      "reason": {
+        "example": "port 443 is already in use",
+        "type": "string"
+      },
+      "source": {
+        "example": "VLESS TCP REALITY",
+        "type": "string"
+      },
+      "target": {
+        "example": "VLESS TCP REALITY",
+        "type": "string"
+      }
+    },
+    "required": [
+      "action",
+      "source"
+    ],
+    "type": "object"
+  },
+  "ImportReport": {
+    "description": "ImportReport is the outcome of importing another panel: each source inbound,\nhow many clients were created and the clients skipped with the reason.",
+    "properties": {
+      "created": {
+        "example": 120,
+        "type": "integer"
+      },
+      "dryRun": {
+        "type": "boolean"
+      },
+      "inbounds": {
+        "items": {
+          "$ref": "#/components/schemas/ImportInboundReport"
+        },
+        "type": "array"
+      },
+      "skipped": {
+        "items": {
+          "$ref": "#/components/schemas/BulkCreateReport"
+        },
+        "type": "array"
+      },
+      "source": {
+        "example": "marzban",
+        "type": "string"
+      },
+      "warnings": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      }
+    },
+    "required": [
+      "created",
+      "inbounds",
+      "source"
+    ],
+    "type": "object"
+  },
   "Inbound": {
     "description": "Inbound represents an Xray inbound configuration with traffic statistics and settings.",
     "properties": {
@@ -1996,6 +2693,10 @@ export const SCHEMAS: Record<string, unknown> = {
         "format": "int64",
         "type": "integer"
       },
+      "failoverOf": {
+        "nullable": true,
+        "type": "integer"
+      },
       "fallbackParent": {
         "allOf": [
           {
@@ -2068,6 +2769,11 @@ export const SCHEMAS: Record<string, unknown> = {
         "type": "string"
       },
       "sniffing": {},
+      "standbyNodeId": {
+        "description": "StandbyNodeID is where a node-hosted inbound fails over while its node is\ndown; FailoverOf marks the standby copy there, never edited or listed.",
+        "nullable": true,
+        "type": "integer"
+      },
       "streamSettings": {},
       "subSortIndex": {
         "description": "1-based sort order of this inbound's links in subscription output only (lower first; ties by id)",
@@ -2084,6 +2790,14 @@ export const SCHEMAS: Record<string, unknown> = {
         "format": "int64",
         "type": "integer"
       },
+      "trafficCoefficient": {
+        "description": "TrafficCoefficient weighs the traffic of this inbound's clients against\ntheir quotas: 2 counts double, 0 is free. Unset counts 1:1.",
+        "example": 1,
+        "maximum": 100,
+        "minimum": 0,
+        "nullable": true,
+        "type": "number"
+      },
       "trafficReset": {
         "description": "Traffic reset schedule",
         "enum": [
@@ -2401,6 +3115,11 @@ export const SCHEMAS: Record<string, unknown> = {
       "outboundTag": {
         "type": "string"
       },
+      "outboxCount": {
+        "description": "OutboxCount is how many runtime operations are queued for the node.",
+        "example": 0,
+        "type": "integer"
+      },
       "panelVersion": {
         "example": "v3.x.x",
         "type": "string"
@@ -2443,6 +3162,14 @@ export const SCHEMAS: Record<string, unknown> = {
         ],
         "type": "string"
       },
+      "trafficCoefficient": {
+        "description": "TrafficCoefficient weighs the traffic clients use on this node, on top\nof the inbound's. Unset counts 1:1.",
+        "example": 1,
+        "maximum": 100,
+        "minimum": 0,
+        "nullable": true,
+        "type": "number"
+      },
       "transitive": {
         "type": "boolean"
       },
@@ -2495,6 +3222,7 @@ export const SCHEMAS: Record<string, unknown> = {
       "netUp",
       "onlineCount",
       "outboundTag",
+      "outboxCount",
       "panelVersion",
       "pinnedCertSha256",
       "port",
@@ -2510,50 +3238,130 @@ export const SCHEMAS: Record<string, unknown> = {
     ],
     "type": "object"
   },
-  "NodeMutationRequest": {
-    "description": "NodeMutationRequest is the node write/probe contract. ApiToken is accepted\nonly as input. On update, nil means keep the stored token; replacement and\nclearing are explicit and mutually exclusive.",
+  "NodeJoinToken": {
+    "description": "NodeJoinToken is a short-lived, single-use secret for `x-ui join`. Only its\nSHA-256 is stored; the plaintext is shown once, when minted.",
     "properties": {
-      "address": {
-        "type": "string"
-      },
       "allowPrivateAddress": {
         "type": "boolean"
       },
-      "apiToken": {
-        "nullable": true,
-        "type": "string"
-      },
-      "basePath": {
-        "type": "string"
-      },
-      "clearApiToken": {
-        "type": "boolean"
+      "createdAt": {
+        "format": "int64",
+        "type": "integer"
       },
-      "enable": {
-        "type": "boolean"
+      "expiresAt": {
+        "description": "unix ms",
+        "example": 1736003600000,
+        "format": "int64",
+        "type": "integer"
       },
       "id": {
+        "example": 1,
         "type": "integer"
       },
-      "inboundSyncMode": {
-        "enum": [
-          "all",
-          "selected"
-        ],
-        "type": "string"
-      },
-      "inboundTags": {
-        "items": {
-          "type": "string"
-        },
-        "type": "array"
-      },
       "name": {
+        "description": "Name is the node name to register; empty uses the name the node sends.",
+        "example": "de-fra-2",
+        "type": "string"
+      },
+      "nodeId": {
+        "example": 0,
+        "type": "integer"
+      },
+      "usedAt": {
+        "description": "unix ms; 0 = unused",
+        "example": 0,
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "allowPrivateAddress",
+      "createdAt",
+      "expiresAt",
+      "id",
+      "name",
+      "nodeId",
+      "usedAt"
+    ],
+    "type": "object"
+  },
+  "NodeJoinTokenCreated": {
+    "description": "NodeJoinTokenCreated is a freshly minted join token. Token is the only time\nthe plaintext is available.",
+    "properties": {
+      "expiresAt": {
+        "example": 1736003600000,
+        "format": "int64",
+        "type": "integer"
+      },
+      "id": {
+        "example": 1,
+        "type": "integer"
+      },
+      "masterPin": {
+        "description": "MasterPin is the SHA-256 of this panel's web certificate when it is not\npublicly trusted, for `x-ui join -pin`.",
+        "type": "string"
+      },
+      "name": {
+        "example": "de-fra-2",
+        "type": "string"
+      },
+      "token": {
+        "example": "k3Jd8s...",
+        "type": "string"
+      }
+    },
+    "required": [
+      "expiresAt",
+      "id",
+      "name",
+      "token"
+    ],
+    "type": "object"
+  },
+  "NodeMutationRequest": {
+    "description": "NodeMutationRequest is the node write/probe contract. ApiToken is accepted\nonly as input. On update, nil means keep the stored token; replacement and\nclearing are explicit and mutually exclusive.",
+    "properties": {
+      "address": {
+        "type": "string"
+      },
+      "allowPrivateAddress": {
+        "type": "boolean"
+      },
+      "apiToken": {
+        "nullable": true,
+        "type": "string"
+      },
+      "basePath": {
+        "type": "string"
+      },
+      "clearApiToken": {
+        "type": "boolean"
+      },
+      "enable": {
+        "type": "boolean"
+      },
+      "id": {
+        "type": "integer"
+      },
+      "inboundSyncMode": {
+        "enum": [
+          "all",
+          "selected"
+        ],
+        "type": "string"
+      },
+      "inboundTags": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "name": {
+        "type": "string"
+      },
+      "outboundTag": {
         "type": "string"
       },
-      "outboundTag": {
-        "type": "string"
-      },
       "pinnedCertSha256": {
         "type": "string"
       },
@@ -2580,6 +3388,12 @@ export const SCHEMAS: Record<string, unknown> = {
           "mtls"
         ],
         "type": "string"
+      },
+      "trafficCoefficient": {
+        "maximum": 100,
+        "minimum": 0,
+        "nullable": true,
+        "type": "number"
       }
     },
     "required": [
@@ -2600,6 +3414,208 @@ export const SCHEMAS: Record<string, unknown> = {
     ],
     "type": "object"
   },
+  "NodeOutboxEntry": {
+    "description": "NodeOutboxEntry is replayed in Id order once the node is back. Payload is\nthe arguments as queued, so replay reproduces the same sequence of states.",
+    "properties": {
+      "attempts": {
+        "example": 0,
+        "type": "integer"
+      },
+      "createdAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "email": {
+        "example": "alice",
+        "type": "string"
+      },
+      "id": {
+        "example": 1,
+        "type": "integer"
+      },
+      "key": {
+        "description": "Key identifies the operation and its arguments; an entry is not queued\nagain while the node's newest pending entry carries the same key.",
+        "example": "3f2a...",
+        "type": "string"
+      },
+      "lastError": {
+        "type": "string"
+      },
+      "nextAttemptAt": {
+        "description": "unix ms",
+        "example": 1736003600000,
+        "format": "int64",
+        "type": "integer"
+      },
+      "nodeId": {
+        "example": 1,
+        "type": "integer"
+      },
+      "op": {
+        "example": "updateUser",
+        "type": "string"
+      },
+      "status": {
+        "description": "Status is \"pending\", or \"failed\" once automatic retries gave up.",
+        "example": "pending",
+        "type": "string"
+      },
+      "tag": {
+        "example": "inbound-443",
+        "type": "string"
+      },
+      "updatedAt": {
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "attempts",
+      "createdAt",
+      "email",
+      "id",
+      "key",
+      "lastError",
+      "nextAttemptAt",
+      "nodeId",
+      "op",
+      "status",
+      "tag",
+      "updatedAt"
+    ],
+    "type": "object"
+  },
+  "NodeRollout": {
+    "description": "NodeRollout upgrades the panel or Xray across nodes batch by batch, canary first,\nadvancing only once the heartbeat shows the target running; a failure aborts it.",
+    "properties": {
+      "batchSize": {
+        "example": 5,
+        "type": "integer"
+      },
+      "canarySize": {
+        "example": 1,
+        "type": "integer"
+      },
+      "createdAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "dev": {
+        "type": "boolean"
+      },
+      "error": {
+        "type": "string"
+      },
+      "finishedAt": {
+        "description": "unix ms; 0 = running",
+        "format": "int64",
+        "type": "integer"
+      },
+      "id": {
+        "example": 1,
+        "type": "integer"
+      },
+      "kind": {
+        "description": "panel|xray",
+        "example": "panel",
+        "type": "string"
+      },
+      "status": {
+        "description": "Status is \"running\", then \"succeeded\", \"aborted\" after a failed node,\nor \"cancelled\" by an operator.",
+        "example": "running",
+        "type": "string"
+      },
+      "steps": {
+        "items": {
+          "$ref": "#/components/schemas/NodeRolloutStep"
+        },
+        "type": "array"
+      },
+      "targetVersion": {
+        "description": "TargetVersion is the version every node must report when done: a panel\nrelease tag, \"dev+\u003ccommit\u003e\" on the dev channel, or an Xray release tag.",
+        "example": "v3.6.1",
+        "type": "string"
+      },
+      "updatedAt": {
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "batchSize",
+      "canarySize",
+      "createdAt",
+      "dev",
+      "finishedAt",
+      "id",
+      "kind",
+      "status",
+      "steps",
+      "targetVersion",
+      "updatedAt"
+    ],
+    "type": "object"
+  },
+  "NodeRolloutStep": {
+    "description": "NodeRolloutStep is one node's place in a rollout.",
+    "properties": {
+      "batch": {
+        "description": "0 is the canary batch",
+        "example": 0,
+        "type": "integer"
+      },
+      "error": {
+        "type": "string"
+      },
+      "finishedAt": {
+        "description": "unix ms",
+        "format": "int64",
+        "type": "integer"
+      },
+      "fromVersion": {
+        "example": "v3.6.0",
+        "type": "string"
+      },
+      "id": {
+        "example": 1,
+        "type": "integer"
+      },
+      "nodeId": {
+        "example": 3,
+        "type": "integer"
+      },
+      "nodeName": {
+        "example": "de-fra-1",
+        "type": "string"
+      },
+      "rolloutId": {
+        "example": 1,
+        "type": "integer"
+      },
+      "startedAt": {
+        "description": "unix ms",
+        "format": "int64",
+        "type": "integer"
+      },
+      "state": {
+        "description": "State is \"pending\", \"upgrading\" once the node was told to upgrade,\n\"done\", \"failed\", or \"skipped\" for a node that was never upgraded.",
+        "example": "pending",
+        "type": "string"
+      }
+    },
+    "required": [
+      "batch",
+      "finishedAt",
+      "fromVersion",
+      "id",
+      "nodeId",
+      "nodeName",
+      "rolloutId",
+      "startedAt",
+      "state"
+    ],
+    "type": "object"
+  },
   "NodeView": {
     "description": "NodeView is the browser/API read contract for nodes. Credentials are\nwrite-only: responses expose only whether a node has a token configured.",
     "properties": {
@@ -2750,6 +3766,11 @@ export const SCHEMAS: Record<string, unknown> = {
         "example": "verify",
         "type": "string"
       },
+      "trafficCoefficient": {
+        "example": 1,
+        "nullable": true,
+        "type": "number"
+      },
       "transitive": {
         "example": false,
         "type": "boolean"
@@ -2869,6 +3890,15 @@ export const SCHEMAS: Record<string, unknown> = {
       "state": {
         "example": "success",
         "type": "string"
+      },
+      "verification": {
+        "allOf": [
+          {
+            "$ref": "#/components/schemas/UpdateVerification"
+          }
+        ],
+        "description": "nil from updaters predating the check",
+        "nullable": true
       }
     },
     "required": [
@@ -2879,17 +3909,100 @@ export const SCHEMAS: Record<string, unknown> = {
     ],
     "type": "object"
   },
-  "ProbeResultUI": {
+  "Plan": {
+    "description": "Plan provisions and renews clients. RenewMode picks what a renewal does:\nextend the expiry by DurationDays, add TotalGB, or reset usage for a new period.",
     "properties": {
-      "cpuPct": {
-        "example": 12.5,
-        "type": "number"
-      },
-      "error": {
+      "comment": {
         "type": "string"
       },
-      "latencyMs": {
-        "example": 42,
+      "createdAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "durationDays": {
+        "description": "0 = never expires",
+        "example": 30,
+        "type": "integer"
+      },
+      "group": {
+        "type": "string"
+      },
+      "id": {
+        "example": 1,
+        "type": "integer"
+      },
+      "inboundIds": {
+        "example": "1,2",
+        "items": {
+          "type": "integer"
+        },
+        "type": "array"
+      },
+      "limitHwid": {
+        "type": "integer"
+      },
+      "limitIp": {
+        "type": "integer"
+      },
+      "name": {
+        "example": "monthly-50",
+        "type": "string"
+      },
+      "renewMode": {
+        "example": "extend",
+        "type": "string"
+      },
+      "startAfterFirstUse": {
+        "type": "boolean"
+      },
+      "totalGB": {
+        "description": "bytes; 0 = unlimited",
+        "example": 53687091200,
+        "format": "int64",
+        "type": "integer"
+      },
+      "trafficReset": {
+        "example": "never",
+        "type": "string"
+      },
+      "trafficResetDay": {
+        "type": "integer"
+      },
+      "updatedAt": {
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "comment",
+      "createdAt",
+      "durationDays",
+      "group",
+      "id",
+      "inboundIds",
+      "limitHwid",
+      "limitIp",
+      "name",
+      "renewMode",
+      "startAfterFirstUse",
+      "totalGB",
+      "trafficReset",
+      "trafficResetDay",
+      "updatedAt"
+    ],
+    "type": "object"
+  },
+  "ProbeResultUI": {
+    "properties": {
+      "cpuPct": {
+        "example": 12.5,
+        "type": "number"
+      },
+      "error": {
+        "type": "string"
+      },
+      "latencyMs": {
+        "example": 42,
         "type": "integer"
       },
       "memPct": {
@@ -3045,6 +4158,93 @@ export const SCHEMAS: Record<string, unknown> = {
     ],
     "type": "object"
   },
+  "ResellerUsage": {
+    "description": "ResellerUsage is what a reseller has handed out against its caps. TotalGB is\nin bytes, like ClientRecord.TotalGB.",
+    "properties": {
+      "clients": {
+        "example": 12,
+        "type": "integer"
+      },
+      "totalGB": {
+        "example": 128849018880,
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "clients",
+      "totalGB"
+    ],
+    "type": "object"
+  },
+  "RuntimePreview": {
+    "description": "RuntimePreview covers the whole generated config for the local panel, and\nonly the inbounds pushed to it for a node.",
+    "properties": {
+      "addedInbounds": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "addedOutbounds": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "addedUsers": {
+        "example": "inbound-443/alice",
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "diff": {
+        "example": "--- current\n+++ proposed\n@@ -12,3 +12,7 @@\n",
+        "type": "string"
+      },
+      "nodeId": {
+        "example": 0,
+        "type": "integer"
+      },
+      "removedInbounds": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "removedOutbounds": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "removedUsers": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "restart": {
+        "example": false,
+        "type": "boolean"
+      },
+      "routingChanged": {
+        "example": false,
+        "type": "boolean"
+      },
+      "runtime": {
+        "example": "local",
+        "type": "string"
+      }
+    },
+    "required": [
+      "diff",
+      "restart",
+      "runtime"
+    ],
+    "type": "object"
+  },
   "Setting": {
     "description": "Setting stores key-value configuration settings for the 3x-ui panel.",
     "properties": {
@@ -3065,24 +4265,523 @@ export const SCHEMAS: Record<string, unknown> = {
     ],
     "type": "object"
   },
+  "StateApplyResult": {
+    "description": "StateApplyResult reports an apply. Applied counts the changes that went\nthrough; when apply stops on an error it is the number done before it.",
+    "properties": {
+      "applied": {
+        "example": 3,
+        "type": "integer"
+      },
+      "changes": {
+        "items": {
+          "$ref": "#/components/schemas/StateChange"
+        },
+        "type": "array"
+      },
+      "needRestart": {
+        "example": true,
+        "type": "boolean"
+      }
+    },
+    "required": [
+      "applied",
+      "changes",
+      "needRestart"
+    ],
+    "type": "object"
+  },
+  "StateChange": {
+    "description": "StateChange is one step of an apply. Fields lists the top-level fields an\nupdate changes.",
+    "properties": {
+      "action": {
+        "example": "update",
+        "type": "string"
+      },
+      "fields": {
+        "example": [
+          "port",
+          "streamSettings"
+        ],
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "key": {
+        "example": "in-443-tcp",
+        "type": "string"
+      },
+      "kind": {
+        "example": "inbound",
+        "type": "string"
+      }
+    },
+    "required": [
+      "action",
+      "key",
+      "kind"
+    ],
+    "type": "object"
+  },
+  "StatePlan": {
+    "description": "StatePlan is what applying a state document would change, in execution\norder. A plan with Errors cannot be applied.",
+    "properties": {
+      "changes": {
+        "items": {
+          "$ref": "#/components/schemas/StateChange"
+        },
+        "type": "array"
+      },
+      "errors": {
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      }
+    },
+    "required": [
+      "changes"
+    ],
+    "type": "object"
+  },
+  "TrafficHistoryPoint": {
+    "description": "TrafficHistoryPoint is the traffic of one bucket summed over every source;\nT is the bucket start in unix seconds.",
+    "properties": {
+      "down": {
+        "example": 2097152,
+        "format": "int64",
+        "type": "integer"
+      },
+      "t": {
+        "example": 1735689600,
+        "format": "int64",
+        "type": "integer"
+      },
+      "up": {
+        "example": 1048576,
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "down",
+      "t",
+      "up"
+    ],
+    "type": "object"
+  },
+  "UpdateVerification": {
+    "description": "UpdateVerification is checked before the updater launches; a failed check\nleaves the running install as it was.",
+    "properties": {
+      "error": {
+        "type": "string"
+      },
+      "keyId": {
+        "example": "5f1c0e2a9b7d3c41",
+        "type": "string"
+      },
+      "tag": {
+        "example": "v3.4.0",
+        "type": "string"
+      },
+      "verified": {
+        "example": true,
+        "type": "boolean"
+      }
+    },
+    "required": [
+      "verified"
+    ],
+    "type": "object"
+  },
   "User": {
-    "description": "User represents a user account in the 3x-ui panel.",
+    "description": "User represents a user account in the 3x-ui panel.\nRole and Permissions decide what the account may do; owners ignore Permissions.",
     "properties": {
+      "allowedInbounds": {
+        "description": "Reseller limits. A reseller only sees AllowedInbounds; a zero cap is unlimited.",
+        "items": {
+          "type": "integer"
+        },
+        "type": "array"
+      },
       "id": {
         "type": "integer"
       },
+      "maxClients": {
+        "type": "integer"
+      },
+      "maxExpiryDays": {
+        "type": "integer"
+      },
+      "maxTrafficGB": {
+        "type": "integer"
+      },
       "password": {
         "type": "string"
       },
+      "permissions": {
+        "additionalProperties": {
+          "type": "string"
+        },
+        "type": "object"
+      },
+      "role": {
+        "type": "string"
+      },
+      "twoFactorEnable": {
+        "type": "boolean"
+      },
       "username": {
         "type": "string"
       }
     },
     "required": [
+      "allowedInbounds",
       "id",
+      "maxClients",
+      "maxExpiryDays",
+      "maxTrafficGB",
       "password",
+      "permissions",
+      "role",
+      "twoFactorEnable",
       "username"
     ],
     "type": "object"
+  },
+  "UserAccountRequest": {
+    "description": "UserAccountRequest creates or edits a panel account. A blank password on\nupdate keeps the current one. The limits apply to resellers; 0 is unlimited.",
+    "properties": {
+      "allowedInbounds": {
+        "items": {
+          "type": "integer"
+        },
+        "type": "array"
+      },
+      "maxClients": {
+        "minimum": 0,
+        "type": "integer"
+      },
+      "maxExpiryDays": {
+        "minimum": 0,
+        "type": "integer"
+      },
+      "maxTrafficGB": {
+        "minimum": 0,
+        "type": "integer"
+      },
+      "password": {
+        "maxLength": 128,
+        "type": "string"
+      },
+      "permissions": {
+        "additionalProperties": {
+          "type": "string"
+        },
+        "type": "object"
+      },
+      "role": {
+        "type": "string"
+      },
+      "username": {
+        "maxLength": 64,
+        "type": "string"
+      }
+    },
+    "required": [
+      "allowedInbounds",
+      "maxClients",
+      "maxExpiryDays",
+      "maxTrafficGB",
+      "password",
+      "permissions",
+      "role",
+      "username"
+    ],
+    "type": "object"
+  },
+  "UserView": {
+    "description": "UserView leaves out credentials. Effective is what the account can actually\ndo; the reseller limits and Usage are filled in for resellers only.",
+    "properties": {
+      "allowedInbounds": {
+        "items": {
+          "type": "integer"
+        },
+        "type": "array"
+      },
+      "effective": {
+        "additionalProperties": {
+          "type": "string"
+        },
+        "type": "object"
+      },
+      "id": {
+        "example": 2,
+        "type": "integer"
+      },
+      "maxClients": {
+        "example": 0,
+        "type": "integer"
+      },
+      "maxExpiryDays": {
+        "example": 0,
+        "type": "integer"
+      },
+      "maxTrafficGB": {
+        "example": 0,
+        "type": "integer"
+      },
+      "permissions": {
+        "additionalProperties": {
+          "type": "string"
+        },
+        "type": "object"
+      },
+      "primary": {
+        "example": false,
+        "type": "boolean"
+      },
+      "role": {
+        "example": "read-only",
+        "type": "string"
+      },
+      "twoFactorEnable": {
+        "example": false,
+        "type": "boolean"
+      },
+      "usage": {
+        "allOf": [
+          {
+            "$ref": "#/components/schemas/ResellerUsage"
+          }
+        ],
+        "nullable": true
+      },
+      "username": {
+        "example": "support",
+        "type": "string"
+      }
+    },
+    "required": [
+      "allowedInbounds",
+      "effective",
+      "id",
+      "maxClients",
+      "maxExpiryDays",
+      "maxTrafficGB",
+      "permissions",
+      "primary",
+      "role",
+      "twoFactorEnable",
+      "username"
+    ],
+    "type": "object"
+  },
+  "Voucher": {
+    "description": "Voucher provisions a client from a plan or tops a subscription up by its\nrenew mode; Kind limits a code to one of the two (invites are create-only).",
+    "properties": {
+      "code": {
+        "example": "K7QM-9XPT-4RAZ",
+        "type": "string"
+      },
+      "comment": {
+        "type": "string"
+      },
+      "createdAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "enable": {
+        "example": true,
+        "type": "boolean"
+      },
+      "expiresAt": {
+        "description": "unix ms; 0 = never",
+        "example": 0,
+        "format": "int64",
+        "type": "integer"
+      },
+      "id": {
+        "example": 1,
+        "type": "integer"
+      },
+      "kind": {
+        "example": "any",
+        "type": "string"
+      },
+      "maxUses": {
+        "description": "0 = unlimited",
+        "example": 1,
+        "type": "integer"
+      },
+      "planId": {
+        "example": 1,
+        "type": "integer"
+      },
+      "updatedAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "uses": {
+        "example": 0,
+        "type": "integer"
+      }
+    },
+    "required": [
+      "code",
+      "comment",
+      "createdAt",
+      "enable",
+      "expiresAt",
+      "id",
+      "kind",
+      "maxUses",
+      "planId",
+      "updatedAt",
+      "uses"
+    ],
+    "type": "object"
+  },
+  "Webhook": {
+    "description": "Webhook is an HTTP endpoint that receives signed JSON for the event bus\nevents it subscribes to. An empty Events list subscribes to all of them.",
+    "properties": {
+      "allowPrivate": {
+        "type": "boolean"
+      },
+      "createdAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "enable": {
+        "example": true,
+        "type": "boolean"
+      },
+      "events": {
+        "example": "node.down,xray.crash",
+        "items": {
+          "type": "string"
+        },
+        "type": "array"
+      },
+      "id": {
+        "example": 1,
+        "type": "integer"
+      },
+      "name": {
+        "example": "ops",
+        "type": "string"
+      },
+      "updatedAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "url": {
+        "example": "https://hooks.example.com/3x-ui",
+        "type": "string"
+      }
+    },
+    "required": [
+      "allowPrivate",
+      "createdAt",
+      "enable",
+      "events",
+      "id",
+      "name",
+      "updatedAt",
+      "url"
+    ],
+    "type": "object"
+  },
+  "WebhookDelivery": {
+    "description": "WebhookDelivery is one payload sent, or still to be sent, to a webhook.\nPending rows are retried with backoff once NextAttemptAt has passed.",
+    "properties": {
+      "attempts": {
+        "example": 1,
+        "type": "integer"
+      },
+      "createdAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "error": {
+        "type": "string"
+      },
+      "event": {
+        "example": "node.down",
+        "type": "string"
+      },
+      "id": {
+        "example": 1,
+        "type": "integer"
+      },
+      "nextAttemptAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "payload": {
+        "type": "string"
+      },
+      "responseCode": {
+        "example": 200,
+        "type": "integer"
+      },
+      "status": {
+        "example": "success",
+        "type": "string"
+      },
+      "updatedAt": {
+        "format": "int64",
+        "type": "integer"
+      },
+      "webhookId": {
+        "example": 1,
+        "type": "integer"
+      }
+    },
+    "required": [
+      "attempts",
+      "createdAt",
+      "error",
+      "event",
+      "id",
+      "nextAttemptAt",
+      "payload",
+      "responseCode",
+      "status",
+      "updatedAt",
+      "webhookId"
+    ],
+    "type": "object"
+  },
+  "WebhookDeliveryPage": {
+    "description": "WebhookDeliveryPage is one page of deliveries, newest first.",
+    "properties": {
+      "items": {
+        "items": {
+          "$ref": "#/components/schemas/WebhookDelivery"
+        },
+        "type": "array"
+      },
+      "page": {
+        "example": 1,
+        "type": "integer"
+      },
+      "pageSize": {
+        "example": 50,
+        "type": "integer"
+      },
+      "total": {
+        "example": 42,
+        "format": "int64",
+        "type": "integer"
+      }
+    },
+    "required": [
+      "items",
+      "page",
+      "pageSize",
+      "total"
+    ],
+    "type": "object"
   }
 };
@@ -1,14 +1,62 @@
 // Code generated by tools/openapigen. DO NOT EDIT.
 export type GeoKind = string;
+export type Locker = unknown;
 export type OnlineAPISupport = number;
 export type ProcessState = string;
 export type Protocol = string;
+export type Role = string;
 export type SubLinkProvider = unknown;
+export type backupStore = unknown;
+export type pushedInboundAdvancer = unknown;
 export type staticEgressResolver = string;
 export type trafficLocalApplyAction = number;
 export type transportBits = number;
 
+export interface AcmeCertificate {
+  autoRenew: boolean;
+  certFile: string;
+  challenge: string;
+  createdAt: number;
+  dnsProvider: string;
+  domains: string[];
+  hasDnsConfig: boolean;
+  id: number;
+  keyFile: string;
+  lastAttempt: number;
+  lastError: string;
+  notAfter: number;
+  notBefore: number;
+  status: string;
+  updatedAt: number;
+}
+
+export interface AcmeIssueRequest {
+  autoRenew: boolean;
+  challenge: string;
+  dnsConfig: Record<string, string>;
+  dnsProvider: string;
+  domains: string[];
+}
+
 export interface AllSetting {
+  acmeDirectoryUrl: string;
+  acmeEmail: string;
+  acmeHttpPort: number;
+  acmeRenewDays: number;
+  acmeTlsAlpnPort: number;
+  auditRetentionDays: number;
+  backupCron: string;
+  backupEnable: boolean;
+  backupKeep: number;
+  backupLocalDir: string;
+  backupPassphrase: string;
+  backupS3AccessKey: string;
+  backupS3Bucket: string;
+  backupS3Endpoint: string;
+  backupS3PathStyle: boolean;
+  backupS3Prefix: string;
+  backupS3Region: string;
+  backupS3SecretKey: string;
   datepicker: string;
   expireDiff: number;
   externalTrafficInformEnable: boolean;
@@ -35,6 +83,7 @@ export interface AllSetting {
   ldapUserAttr: string;
   ldapUserFilter: string;
   ldapVlessField: string;
+  metricsClientLabels: boolean;
   outboundDownThreshold: number;
   pageSize: number;
   panelOutbound: string;
@@ -85,6 +134,13 @@ export interface AllSetting {
   subProfileUrl: string;
   subRoutingRules: string;
   subShowIdentityOnAllLinks: boolean;
+  subSingboxAutoDetect: boolean;
+  subSingboxEnable: boolean;
+  subSingboxMux: string;
+  subSingboxPath: string;
+  subSingboxRules: string;
+  subSingboxURI: string;
+  subSingboxUserAgentRegex: string;
   subSupportUrl: string;
   subThemeDir: string;
   subTitle: string;
@@ -103,9 +159,9 @@ export interface AllSetting {
   tgRunTime: string;
   timeLocation: string;
   trafficDiff: number;
+  trafficHistoryDays: number;
+  trafficHistoryHourlyDays: number;
   trustedProxyCIDRs: string;
-  twoFactorEnable: boolean;
-  twoFactorToken: string;
   warpUpdateInterval: number;
   webBasePath: string;
   webCertFile: string;
@@ -113,19 +169,40 @@ export interface AllSetting {
   webKeyFile: string;
   webListen: string;
   webPort: number;
+  webhookCpu: number;
+  webhookMemory: number;
 }
 
 export interface AllSettingView {
+  acmeDirectoryUrl: string;
+  acmeEmail: string;
+  acmeHttpPort: number;
+  acmeRenewDays: number;
+  acmeTlsAlpnPort: number;
+  auditRetentionDays: number;
+  backupCron: string;
+  backupEnable: boolean;
+  backupKeep: number;
+  backupLocalDir: string;
+  backupPassphrase: string;
+  backupS3AccessKey: string;
+  backupS3Bucket: string;
+  backupS3Endpoint: string;
+  backupS3PathStyle: boolean;
+  backupS3Prefix: string;
+  backupS3Region: string;
+  backupS3SecretKey: string;
   datepicker: string;
   expireDiff: number;
   externalTrafficInformEnable: boolean;
   externalTrafficInformURI: string;
   hasApiToken: boolean;
+  hasBackupPassphrase: boolean;
+  hasBackupS3SecretKey: boolean;
   hasLdapPassword: boolean;
   hasNordSecret: boolean;
   hasSmtpPassword: boolean;
   hasTgBotToken: boolean;
-  hasTwoFactorToken: boolean;
   hasWarpSecret: boolean;
   ipLimitAllowlist: string;
   ldapAutoCreate: boolean;
@@ -149,6 +226,7 @@ export interface AllSettingView {
   ldapUserAttr: string;
   ldapUserFilter: string;
   ldapVlessField: string;
+  metricsClientLabels: boolean;
   outboundDownThreshold: number;
   pageSize: number;
   panelOutbound: string;
@@ -199,6 +277,13 @@ export interface AllSettingView {
   subProfileUrl: string;
   subRoutingRules: string;
   subShowIdentityOnAllLinks: boolean;
+  subSingboxAutoDetect: boolean;
+  subSingboxEnable: boolean;
+  subSingboxMux: string;
+  subSingboxPath: string;
+  subSingboxRules: string;
+  subSingboxURI: string;
+  subSingboxUserAgentRegex: string;
   subSupportUrl: string;
   subThemeDir: string;
   subTitle: string;
@@ -217,9 +302,10 @@ export interface AllSettingView {
   tgRunTime: string;
   timeLocation: string;
   trafficDiff: number;
+  trafficHistoryDays: number;
+  trafficHistoryHourlyDays: number;
   trustedProxyCIDRs: string;
-  twoFactorEnable: boolean;
-  twoFactorToken: string;
+  unreadableSecrets: string[];
   warpUpdateInterval: number;
   webBasePath: string;
   webCertFile: string;
@@ -227,6 +313,8 @@ export interface AllSettingView {
   webKeyFile: string;
   webListen: string;
   webPort: number;
+  webhookCpu: number;
+  webhookMemory: number;
 }
 
 export interface ApiToken {
@@ -249,12 +337,56 @@ export interface ApiTokenView {
   token?: string;
 }
 
+export interface AuditChange {
+  after: unknown;
+  before: unknown;
+}
+
+export interface AuditLog {
+  actor: string;
+  actorType: string;
+  changes: Record<string, AuditChange>;
+  createdAt: number;
+  error: string;
+  id: number;
+  method: string;
+  route: string;
+  sourceIp: string;
+  success: boolean;
+  targetId: string;
+  targetType: string;
+}
+
+export interface AuditPage {
+  items: AuditLog[];
+  page: number;
+  pageSize: number;
+  total: number;
+}
+
+export interface BackupManifest {
+  createdAt: number;
+  database: string;
+  encrypted: boolean;
+  name: string;
+  sha256: string;
+  size: number;
+  target?: string;
+  version: string;
+}
+
+export interface BulkCreateReport {
+  email: string;
+  reason: string;
+}
+
 export interface Client {
   adTag?: string;
   allowedIPs?: string[];
   auth?: string;
   comment: string;
   created_at?: number;
+  egress?: string;
   email: string;
   enable: boolean;
   expiryTime: number;
@@ -271,6 +403,7 @@ export interface Client {
   resetDay: number;
   resetMax: number;
   reverse?: ClientReverse | null;
+  schedule?: string;
   secret?: string;
   security: string;
   subId: string;
@@ -294,6 +427,7 @@ export interface ClientRecord {
   auth: string;
   comment: string;
   createdAt: number;
+  egress: string;
   email: string;
   enable: boolean;
   expiryTime: number;
@@ -303,6 +437,7 @@ export interface ClientRecord {
   keepAlive: number;
   limitHwid: number;
   limitIp: number;
+  ownerId: number;
   password: string;
   preSharedKey: string;
   privateKey: string;
@@ -311,6 +446,7 @@ export interface ClientRecord {
   resetDay: number;
   resetMax: number;
   reverse: unknown;
+  schedule: string;
   secret: string;
   security: string;
   subId: string;
@@ -345,6 +481,10 @@ export interface ClientTraffic {
   uuid: string;
 }
 
+export interface ConfigPreview {
+  runtimes: RuntimePreview[];
+}
+
 export interface FallbackParentInfo {
   masterId: number;
   path?: string;
@@ -387,6 +527,14 @@ export interface GeodataTokenIssue {
   token: string;
 }
 
+export interface HAStatus {
+  enabled: boolean;
+  leader?: string;
+  node: string;
+  role: Role;
+  since: number;
+}
+
 export interface HistoryOfSeeders {
   id: number;
   seederName: string;
@@ -463,12 +611,29 @@ export interface HostGroup {
   vlessRoute: string;
 }
 
+export interface ImportInboundReport {
+  action: string;
+  reason?: string;
+  source: string;
+  target?: string;
+}
+
+export interface ImportReport {
+  created: number;
+  dryRun?: boolean;
+  inbounds: ImportInboundReport[];
+  skipped?: BulkCreateReport[];
+  source: string;
+  warnings?: string[];
+}
+
 export interface Inbound {
   clientStats: ClientTraffic[];
   disableFlow: boolean;
   down: number;
   enable: boolean;
   expiryTime: number;
+  failoverOf?: number | null;
   fallbackParent?: FallbackParentInfo | null;
   id: number;
   lastTrafficResetTime: number;
@@ -482,10 +647,12 @@ export interface Inbound {
   shareAddr: string;
   shareAddrStrategy: string;
   sniffing: unknown;
+  standbyNodeId?: number | null;
   streamSettings: unknown;
   subSortIndex: number;
   tag: string;
   total: number;
+  trafficCoefficient?: number | null;
   trafficReset: string;
   trafficResetDay: number;
   up: number;
@@ -562,6 +729,7 @@ export interface Node {
   netUp: number;
   onlineCount: number;
   outboundTag: string;
+  outboxCount: number;
   panelVersion: string;
   parentGuid?: string;
   pinnedCertSha256: string;
@@ -570,6 +738,7 @@ export interface Node {
   scheme: string;
   status: string;
   tlsVerifyMode: string;
+  trafficCoefficient?: number | null;
   transitive?: boolean;
   updatedAt: number;
   uptimeSecs: number;
@@ -578,6 +747,24 @@ export interface Node {
   xrayVersion: string;
 }
 
+export interface NodeJoinToken {
+  allowPrivateAddress: boolean;
+  createdAt: number;
+  expiresAt: number;
+  id: number;
+  name: string;
+  nodeId: number;
+  usedAt: number;
+}
+
+export interface NodeJoinTokenCreated {
+  expiresAt: number;
+  id: number;
+  masterPin?: string;
+  name: string;
+  token: string;
+}
+
 export interface NodeMutationRequest {
   address: string;
   allowPrivateAddress: boolean;
@@ -595,6 +782,50 @@ export interface NodeMutationRequest {
   remark: string;
   scheme: string;
   tlsVerifyMode: string;
+  trafficCoefficient?: number | null;
+}
+
+export interface NodeOutboxEntry {
+  attempts: number;
+  createdAt: number;
+  email: string;
+  id: number;
+  key: string;
+  lastError: string;
+  nextAttemptAt: number;
+  nodeId: number;
+  op: string;
+  status: string;
+  tag: string;
+  updatedAt: number;
+}
+
+export interface NodeRollout {
+  batchSize: number;
+  canarySize: number;
+  createdAt: number;
+  dev: boolean;
+  error?: string;
+  finishedAt: number;
+  id: number;
+  kind: string;
+  status: string;
+  steps: NodeRolloutStep[];
+  targetVersion: string;
+  updatedAt: number;
+}
+
+export interface NodeRolloutStep {
+  batch: number;
+  error?: string;
+  finishedAt: number;
+  fromVersion: string;
+  id: number;
+  nodeId: number;
+  nodeName: string;
+  rolloutId: number;
+  startedAt: number;
+  state: string;
 }
 
 export interface NodeView {
@@ -633,6 +864,7 @@ export interface NodeView {
   scheme: string;
   status: string;
   tlsVerifyMode: string;
+  trafficCoefficient?: number | null;
   transitive?: boolean;
   updatedAt: number;
   uptimeSecs: number;
@@ -654,6 +886,25 @@ export interface PanelUpdateStatus {
   finishedAt: number;
   runId: string;
   state: string;
+  verification?: UpdateVerification | null;
+}
+
+export interface Plan {
+  comment: string;
+  createdAt: number;
+  durationDays: number;
+  group: string;
+  id: number;
+  inboundIds: number[];
+  limitHwid: number;
+  limitIp: number;
+  name: string;
+  renewMode: string;
+  startAfterFirstUse: boolean;
+  totalGB: number;
+  trafficReset: string;
+  trafficResetDay: number;
+  updatedAt: number;
 }
 
 export interface ProbeResultUI {
@@ -692,15 +943,144 @@ export interface RealityScanResult {
   x25519: boolean;
 }
 
+export interface ResellerUsage {
+  clients: number;
+  totalGB: number;
+}
+
+export interface RuntimePreview {
+  addedInbounds?: string[];
+  addedOutbounds?: string[];
+  addedUsers?: string[];
+  diff: string;
+  nodeId?: number;
+  removedInbounds?: string[];
+  removedOutbounds?: string[];
+  removedUsers?: string[];
+  restart: boolean;
+  routingChanged?: boolean;
+  runtime: string;
+}
+
 export interface Setting {
   id: number;
   key: string;
   value: string;
 }
 
+export interface StateApplyResult {
+  applied: number;
+  changes: StateChange[];
+  needRestart: boolean;
+}
+
+export interface StateChange {
+  action: string;
+  fields?: string[];
+  key: string;
+  kind: string;
+}
+
+export interface StatePlan {
+  changes: StateChange[];
+  errors?: string[];
+}
+
+export interface TrafficHistoryPoint {
+  down: number;
+  t: number;
+  up: number;
+}
+
+export interface UpdateVerification {
+  error?: string;
+  keyId?: string;
+  tag?: string;
+  verified: boolean;
+}
+
 export interface User {
+  allowedInbounds: number[];
   id: number;
+  maxClients: number;
+  maxExpiryDays: number;
+  maxTrafficGB: number;
   password: string;
+  permissions: Record<string, string>;
+  role: string;
+  twoFactorEnable: boolean;
   username: string;
 }
 
+export interface UserAccountRequest {
+  allowedInbounds: number[];
+  maxClients: number;
+  maxExpiryDays: number;
+  maxTrafficGB: number;
+  password: string;
+  permissions: Record<string, string>;
+  role: string;
+  username: string;
+}
+
+export interface UserView {
+  allowedInbounds: number[];
+  effective: Record<string, string>;
+  id: number;
+  maxClients: number;
+  maxExpiryDays: number;
+  maxTrafficGB: number;
+  permissions: Record<string, string>;
+  primary: boolean;
+  role: string;
+  twoFactorEnable: boolean;
+  usage?: ResellerUsage | null;
+  username: string;
+}
+
+export interface Voucher {
+  code: string;
+  comment: string;
+  createdAt: number;
+  enable: boolean;
+  expiresAt: number;
+  id: number;
+  kind: string;
+  maxUses: number;
+  planId: number;
+  updatedAt: number;
+  uses: number;
+}
+
+export interface Webhook {
+  allowPrivate: boolean;
+  createdAt: number;
+  enable: boolean;
+  events: string[];
+  id: number;
+  name: string;
+  updatedAt: number;
+  url: string;
+}
+
+export interface WebhookDelivery {
+  attempts: number;
+  createdAt: number;
+  error: string;
+  event: string;
+  id: number;
+  nextAttemptAt: number;
+  payload: string;
+  responseCode: number;
+  status: string;
+  updatedAt: number;
+  webhookId: number;
+}
+
+export interface WebhookDeliveryPage {
+  items: WebhookDelivery[];
+  page: number;
+  pageSize: number;
+  total: number;
+}
+
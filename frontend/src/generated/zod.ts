@@ -3,6 +3,9 @@ import { z } from 'zod';
 export const GeoKindSchema = z.string();
 export type GeoKind = z.infer<typeof GeoKindSchema>;
 
+export const LockerSchema = z.unknown();
+export type Locker = z.infer<typeof LockerSchema>;
+
 export const OnlineAPISupportSchema = z.number().int();
 export type OnlineAPISupport = z.infer<typeof OnlineAPISupportSchema>;
 
@@ -12,9 +15,18 @@ export type ProcessState = z.infer<typeof ProcessStateSchema>;
 export const ProtocolSchema = z.string();
 export type Protocol = z.infer<typeof ProtocolSchema>;
 
+export const RoleSchema = z.string();
+export type Role = z.infer<typeof RoleSchema>;
+
 export const SubLinkProviderSchema = z.unknown();
 export type SubLinkProvider = z.infer<typeof SubLinkProviderSchema>;
 
+export const backupStoreSchema = z.unknown();
+export type backupStore = z.infer<typeof backupStoreSchema>;
+
+export const pushedInboundAdvancerSchema = z.unknown();
+export type pushedInboundAdvancer = z.infer<typeof pushedInboundAdvancerSchema>;
+
 export const staticEgressResolverSchema = z.string();
 export type staticEgressResolver = z.infer<typeof staticEgressResolverSchema>;
 
@@ -24,7 +36,53 @@ export type trafficLocalApplyAction = z.infer<typeof trafficLocalApplyActionSche
 export const transportBitsSchema = z.number().int();
 export type transportBits = z.infer<typeof transportBitsSchema>;
 
+export const AcmeCertificateSchema = z.object({
+  autoRenew: z.boolean(),
+  certFile: z.string(),
+  challenge: z.string(),
+  createdAt: z.number().int(),
+  dnsProvider: z.string(),
+  domains: z.array(z.string()),
+  hasDnsConfig: z.boolean(),
+  id: z.number().int(),
+  keyFile: z.string(),
+  lastAttempt: z.number().int(),
+  lastError: z.string(),
+  notAfter: z.number().int(),
+  notBefore: z.number().int(),
+  status: z.string(),
+  updatedAt: z.number().int(),
+});
+export type AcmeCertificate = z.infer<typeof AcmeCertificateSchema>;
+
+export const AcmeIssueRequestSchema = z.object({
+  autoRenew: z.boolean(),
+  challenge: z.enum(['http-01', 'tls-alpn-01', 'dns-01']),
+  dnsConfig: z.record(z.string(), z.string()),
+  dnsProvider: z.string(),
+  domains: z.array(z.string()),
+});
+export type AcmeIssueRequest = z.infer<typeof AcmeIssueRequestSchema>;
+
 export const AllSettingSchema = z.object({
+  acmeDirectoryUrl: z.string(),
+  acmeEmail: z.string(),
+  acmeHttpPort: z.number().int().min(0).max(65535),
+  acmeRenewDays: z.number().int().min(1).max(89),
+  acmeTlsAlpnPort: z.number().int().min(0).max(65535),
+  auditRetentionDays: z.number().int().min(0),
+  backupCron: z.string(),
+  backupEnable: z.boolean(),
+  backupKeep: z.number().int().min(0),
+  backupLocalDir: z.string(),
+  backupPassphrase: z.string(),
+  backupS3AccessKey: z.string(),
+  backupS3Bucket: z.string(),
+  backupS3Endpoint: z.string(),
+  backupS3PathStyle: z.boolean(),
+  backupS3Prefix: z.string(),
+  backupS3Region: z.string(),
+  backupS3SecretKey: z.string(),
   datepicker: z.string(),
   expireDiff: z.number().int().min(0),
   externalTrafficInformEnable: z.boolean(),
@@ -51,6 +109,7 @@ export const AllSettingSchema = z.object({
   ldapUserAttr: z.string(),
   ldapUserFilter: z.string(),
   ldapVlessField: z.string(),
+  metricsClientLabels: z.boolean(),
   outboundDownThreshold: z.number().int().min(1).max(100),
   pageSize: z.number().int().min(0).max(1000),
   panelOutbound: z.string(),
@@ -101,6 +160,13 @@ export const AllSettingSchema = z.object({
   subProfileUrl: z.string(),
   subRoutingRules: z.string(),
   subShowIdentityOnAllLinks: z.boolean(),
+  subSingboxAutoDetect: z.boolean(),
+  subSingboxEnable: z.boolean(),
+  subSingboxMux: z.string(),
+  subSingboxPath: z.string(),
+  subSingboxRules: z.string(),
+  subSingboxURI: z.string(),
+  subSingboxUserAgentRegex: z.string(),
   subSupportUrl: z.string(),
   subThemeDir: z.string(),
   subTitle: z.string(),
@@ -119,9 +185,9 @@ export const AllSettingSchema = z.object({
   tgRunTime: z.string(),
   timeLocation: z.string(),
   trafficDiff: z.number().int().min(0).max(100),
+  trafficHistoryDays: z.number().int().min(0),
+  trafficHistoryHourlyDays: z.number().int().min(1).max(90),
   trustedProxyCIDRs: z.string(),
-  twoFactorEnable: z.boolean(),
-  twoFactorToken: z.string(),
   warpUpdateInterval: z.number().int().min(0),
   webBasePath: z.string(),
   webCertFile: z.string(),
@@ -129,20 +195,41 @@ export const AllSettingSchema = z.object({
   webKeyFile: z.string(),
   webListen: z.string(),
   webPort: z.number().int().min(1).max(65535),
+  webhookCpu: z.number().int().min(0).max(100),
+  webhookMemory: z.number().int().min(0).max(100),
 });
 export type AllSetting = z.infer<typeof AllSettingSchema>;
 
 export const AllSettingViewSchema = z.object({
+  acmeDirectoryUrl: z.string(),
+  acmeEmail: z.string(),
+  acmeHttpPort: z.number().int().min(0).max(65535),
+  acmeRenewDays: z.number().int().min(1).max(89),
+  acmeTlsAlpnPort: z.number().int().min(0).max(65535),
+  auditRetentionDays: z.number().int().min(0),
+  backupCron: z.string(),
+  backupEnable: z.boolean(),
+  backupKeep: z.number().int().min(0),
+  backupLocalDir: z.string(),
+  backupPassphrase: z.string(),
+  backupS3AccessKey: z.string(),
+  backupS3Bucket: z.string(),
+  backupS3Endpoint: z.string(),
+  backupS3PathStyle: z.boolean(),
+  backupS3Prefix: z.string(),
+  backupS3Region: z.string(),
+  backupS3SecretKey: z.string(),
   datepicker: z.string(),
   expireDiff: z.number().int().min(0),
   externalTrafficInformEnable: z.boolean(),
   externalTrafficInformURI: z.string(),
   hasApiToken: z.boolean(),
+  hasBackupPassphrase: z.boolean(),
+  hasBackupS3SecretKey: z.boolean(),
   hasLdapPassword: z.boolean(),
   hasNordSecret: z.boolean(),
   hasSmtpPassword: z.boolean(),
   hasTgBotToken: z.boolean(),
-  hasTwoFactorToken: z.boolean(),
   hasWarpSecret: z.boolean(),
   ipLimitAllowlist: z.string(),
   ldapAutoCreate: z.boolean(),
@@ -166,6 +253,7 @@ export const AllSettingViewSchema = z.object({
   ldapUserAttr: z.string(),
   ldapUserFilter: z.string(),
   ldapVlessField: z.string(),
+  metricsClientLabels: z.boolean(),
   outboundDownThreshold: z.number().int().min(1).max(100),
   pageSize: z.number().int().min(0).max(1000),
   panelOutbound: z.string(),
@@ -216,6 +304,13 @@ export const AllSettingViewSchema = z.object({
   subProfileUrl: z.string(),
   subRoutingRules: z.string(),
   subShowIdentityOnAllLinks: z.boolean(),
+  subSingboxAutoDetect: z.boolean(),
+  subSingboxEnable: z.boolean(),
+  subSingboxMux: z.string(),
+  subSingboxPath: z.string(),
+  subSingboxRules: z.string(),
+  subSingboxURI: z.string(),
+  subSingboxUserAgentRegex: z.string(),
   subSupportUrl: z.string(),
   subThemeDir: z.string(),
   subTitle: z.string(),
@@ -234,9 +329,10 @@ export const AllSettingViewSchema = z.object({
   tgRunTime: z.string(),
   timeLocation: z.string(),
   trafficDiff: z.number().int().min(0).max(100),
+  trafficHistoryDays: z.number().int().min(0),
+  trafficHistoryHourlyDays: z.number().int().min(1).max(90),
   trustedProxyCIDRs: z.string(),
-  twoFactorEnable: z.boolean(),
-  twoFactorToken: z.string(),
+  unreadableSecrets: z.array(z.string()),
   warpUpdateInterval: z.number().int().min(0),
   webBasePath: z.string(),
   webCertFile: z.string(),
@@ -244,6 +340,8 @@ export const AllSettingViewSchema = z.object({
   webKeyFile: z.string(),
   webListen: z.string(),
   webPort: z.number().int().min(1).max(65535),
+  webhookCpu: z.number().int().min(0).max(100),
+  webhookMemory: z.number().int().min(0).max(100),
 });
 export type AllSettingView = z.infer<typeof AllSettingViewSchema>;
 
@@ -269,12 +367,61 @@ export const ApiTokenViewSchema = z.object({
 });
 export type ApiTokenView = z.infer<typeof ApiTokenViewSchema>;
 
+export const AuditChangeSchema = z.object({
+  after: z.unknown(),
+  before: z.unknown(),
+});
+export type AuditChange = z.infer<typeof AuditChangeSchema>;
+
+export const AuditLogSchema = z.object({
+  actor: z.string(),
+  actorType: z.string(),
+  changes: z.record(z.string(), z.lazy(() => AuditChangeSchema)),
+  createdAt: z.number().int(),
+  error: z.string(),
+  id: z.number().int(),
+  method: z.string(),
+  route: z.string(),
+  sourceIp: z.string(),
+  success: z.boolean(),
+  targetId: z.string(),
+  targetType: z.string(),
+});
+export type AuditLog = z.infer<typeof AuditLogSchema>;
+
+export const AuditPageSchema = z.object({
+  items: z.array(z.lazy(() => AuditLogSchema)),
+  page: z.number().int(),
+  pageSize: z.number().int(),
+  total: z.number().int(),
+});
+export type AuditPage = z.infer<typeof AuditPageSchema>;
+
+export const BackupManifestSchema = z.object({
+  createdAt: z.number().int(),
+  database: z.string(),
+  encrypted: z.boolean(),
+  name: z.string(),
+  sha256: z.string(),
+  size: z.number().int(),
+  target: z.string().optional(),
+  version: z.string(),
+});
+export type BackupManifest = z.infer<typeof BackupManifestSchema>;
+
+export const BulkCreateReportSchema = z.object({
+  email: z.string(),
+  reason: z.string(),
+});
+export type BulkCreateReport = z.infer<typeof BulkCreateReportSchema>;
+
 export const ClientSchema = z.object({
   adTag: z.string().optional(),
   allowedIPs: z.array(z.string()).optional(),
   auth: z.string().optional(),
   comment: z.string(),
   created_at: z.number().int().optional(),
+  egress: z.string().optional(),
   email: z.string(),
   enable: z.boolean(),
   expiryTime: z.number().int(),
@@ -291,6 +438,7 @@ export const ClientSchema = z.object({
   resetDay: z.number().int(),
   resetMax: z.number().int(),
   reverse: z.lazy(() => ClientReverseSchema).nullable().optional(),
+  schedule: z.string().optional(),
   secret: z.string().optional(),
   security: z.string(),
   subId: z.string(),
@@ -316,6 +464,7 @@ export const ClientRecordSchema = z.object({
   auth: z.string(),
   comment: z.string(),
   createdAt: z.number().int(),
+  egress: z.string(),
   email: z.string(),
   enable: z.boolean(),
   expiryTime: z.number().int(),
@@ -325,6 +474,7 @@ export const ClientRecordSchema = z.object({
   keepAlive: z.number().int(),
   limitHwid: z.number().int(),
   limitIp: z.number().int(),
+  ownerId: z.number().int(),
   password: z.string(),
   preSharedKey: z.string(),
   privateKey: z.string(),
@@ -333,6 +483,7 @@ export const ClientRecordSchema = z.object({
   resetDay: z.number().int(),
   resetMax: z.number().int(),
   reverse: z.unknown(),
+  schedule: z.string(),
   secret: z.string(),
   security: z.string(),
   subId: z.string(),
@@ -370,6 +521,11 @@ export const ClientTrafficSchema = z.object({
 });
 export type ClientTraffic = z.infer<typeof ClientTrafficSchema>;
 
+export const ConfigPreviewSchema = z.object({
+  runtimes: z.array(z.lazy(() => RuntimePreviewSchema)),
+});
+export type ConfigPreview = z.infer<typeof ConfigPreviewSchema>;
+
 export const FallbackParentInfoSchema = z.object({
   masterId: z.number().int(),
   path: z.string().optional(),
@@ -419,6 +575,15 @@ export const GeodataTokenIssueSchema = z.object({
 });
 export type GeodataTokenIssue = z.infer<typeof GeodataTokenIssueSchema>;
 
+export const HAStatusSchema = z.object({
+  enabled: z.boolean(),
+  leader: z.string().optional(),
+  node: z.string(),
+  role: z.lazy(() => RoleSchema),
+  since: z.number().int(),
+});
+export type HAStatus = z.infer<typeof HAStatusSchema>;
+
 export const HistoryOfSeedersSchema = z.object({
   id: z.number().int(),
   seederName: z.string(),
@@ -498,12 +663,31 @@ export const HostGroupSchema = z.object({
 });
 export type HostGroup = z.infer<typeof HostGroupSchema>;
 
+export const ImportInboundReportSchema = z.object({
+  action: z.string(),
+  reason: z.string().optional(),
+  source: z.string(),
+  target: z.string().optional(),
+});
+export type ImportInboundReport = z.infer<typeof ImportInboundReportSchema>;
+
+export const ImportReportSchema = z.object({
+  created: z.number().int(),
+  dryRun: z.boolean().optional(),
+  inbounds: z.array(z.lazy(() => ImportInboundReportSchema)),
+  skipped: z.array(z.lazy(() => BulkCreateReportSchema)).optional(),
+  source: z.string(),
+  warnings: z.array(z.string()).optional(),
+});
+export type ImportReport = z.infer<typeof ImportReportSchema>;
+
 export const InboundSchema = z.object({
   clientStats: z.array(z.lazy(() => ClientTrafficSchema)),
   disableFlow: z.boolean(),
   down: z.number().int(),
   enable: z.boolean(),
   expiryTime: z.number().int(),
+  failoverOf: z.number().int().nullable().optional(),
   fallbackParent: z.lazy(() => FallbackParentInfoSchema).nullable().optional(),
   id: z.number().int(),
   lastTrafficResetTime: z.number().int(),
@@ -517,10 +701,12 @@ export const InboundSchema = z.object({
   shareAddr: z.string(),
   shareAddrStrategy: z.enum(['node', 'listen', 'custom']),
   sniffing: z.unknown(),
+  standbyNodeId: z.number().int().nullable().optional(),
   streamSettings: z.unknown(),
   subSortIndex: z.number().int().min(1),
   tag: z.string(),
   total: z.number().int(),
+  trafficCoefficient: z.number().nullable().optional(),
   trafficReset: z.enum(['never', 'hourly', 'daily', 'weekly', 'monthly']),
   trafficResetDay: z.number().int().min(1).max(31),
   up: z.number().int(),
@@ -602,6 +788,7 @@ export const NodeSchema = z.object({
   netUp: z.number().int(),
   onlineCount: z.number().int(),
   outboundTag: z.string(),
+  outboxCount: z.number().int(),
   panelVersion: z.string(),
   parentGuid: z.string().optional(),
   pinnedCertSha256: z.string(),
@@ -610,6 +797,7 @@ export const NodeSchema = z.object({
   scheme: z.enum(['http', 'https']),
   status: z.string(),
   tlsVerifyMode: z.enum(['verify', 'skip', 'pin', 'mtls']),
+  trafficCoefficient: z.number().nullable().optional(),
   transitive: z.boolean().optional(),
   updatedAt: z.number().int(),
   uptimeSecs: z.number().int(),
@@ -619,6 +807,26 @@ export const NodeSchema = z.object({
 });
 export type Node = z.infer<typeof NodeSchema>;
 
+export const NodeJoinTokenSchema = z.object({
+  allowPrivateAddress: z.boolean(),
+  createdAt: z.number().int(),
+  expiresAt: z.number().int(),
+  id: z.number().int(),
+  name: z.string(),
+  nodeId: z.number().int(),
+  usedAt: z.number().int(),
+});
+export type NodeJoinToken = z.infer<typeof NodeJoinTokenSchema>;
+
+export const NodeJoinTokenCreatedSchema = z.object({
+  expiresAt: z.number().int(),
+  id: z.number().int(),
+  masterPin: z.string().optional(),
+  name: z.string(),
+  token: z.string(),
+});
+export type NodeJoinTokenCreated = z.infer<typeof NodeJoinTokenCreatedSchema>;
+
 export const NodeMutationRequestSchema = z.object({
   address: z.string(),
   allowPrivateAddress: z.boolean(),
@@ -636,9 +844,56 @@ export const NodeMutationRequestSchema = z.object({
   remark: z.string(),
   scheme: z.enum(['http', 'https']),
   tlsVerifyMode: z.enum(['verify', 'skip', 'pin', 'mtls']),
+  trafficCoefficient: z.number().nullable().optional(),
 });
 export type NodeMutationRequest = z.infer<typeof NodeMutationRequestSchema>;
 
+export const NodeOutboxEntrySchema = z.object({
+  attempts: z.number().int(),
+  createdAt: z.number().int(),
+  email: z.string(),
+  id: z.number().int(),
+  key: z.string(),
+  lastError: z.string(),
+  nextAttemptAt: z.number().int(),
+  nodeId: z.number().int(),
+  op: z.string(),
+  status: z.string(),
+  tag: z.string(),
+  updatedAt: z.number().int(),
+});
+export type NodeOutboxEntry = z.infer<typeof NodeOutboxEntrySchema>;
+
+export const NodeRolloutSchema = z.object({
+  batchSize: z.number().int(),
+  canarySize: z.number().int(),
+  createdAt: z.number().int(),
+  dev: z.boolean(),
+  error: z.string().optional(),
+  finishedAt: z.number().int(),
+  id: z.number().int(),
+  kind: z.string(),
+  status: z.string(),
+  steps: z.array(z.lazy(() => NodeRolloutStepSchema)),
+  targetVersion: z.string(),
+  updatedAt: z.number().int(),
+});
+export type NodeRollout = z.infer<typeof NodeRolloutSchema>;
+
+export const NodeRolloutStepSchema = z.object({
+  batch: z.number().int(),
+  error: z.string().optional(),
+  finishedAt: z.number().int(),
+  fromVersion: z.string(),
+  id: z.number().int(),
+  nodeId: z.number().int(),
+  nodeName: z.string(),
+  rolloutId: z.number().int(),
+  startedAt: z.number().int(),
+  state: z.string(),
+});
+export type NodeRolloutStep = z.infer<typeof NodeRolloutStepSchema>;
+
 export const NodeViewSchema = z.object({
   activeCount: z.number().int(),
   address: z.string(),
@@ -675,6 +930,7 @@ export const NodeViewSchema = z.object({
   scheme: z.string(),
   status: z.string(),
   tlsVerifyMode: z.string(),
+  trafficCoefficient: z.number().nullable().optional(),
   transitive: z.boolean().optional(),
   updatedAt: z.number().int(),
   uptimeSecs: z.number().int(),
@@ -698,9 +954,29 @@ export const PanelUpdateStatusSchema = z.object({
   finishedAt: z.number().int(),
   runId: z.string(),
   state: z.string(),
+  verification: z.lazy(() => UpdateVerificationSchema).nullable().optional(),
 });
 export type PanelUpdateStatus = z.infer<typeof PanelUpdateStatusSchema>;
 
+export const PlanSchema = z.object({
+  comment: z.string(),
+  createdAt: z.number().int(),
+  durationDays: z.number().int(),
+  group: z.string(),
+  id: z.number().int(),
+  inboundIds: z.array(z.number().int()),
+  limitHwid: z.number().int(),
+  limitIp: z.number().int(),
+  name: z.string(),
+  renewMode: z.string(),
+  startAfterFirstUse: z.boolean(),
+  totalGB: z.number().int(),
+  trafficReset: z.string(),
+  trafficResetDay: z.number().int(),
+  updatedAt: z.number().int(),
+});
+export type Plan = z.infer<typeof PlanSchema>;
+
 export const ProbeResultUISchema = z.object({
   cpuPct: z.number(),
   error: z.string(),
@@ -739,6 +1015,27 @@ export const RealityScanResultSchema = z.object({
 });
 export type RealityScanResult = z.infer<typeof RealityScanResultSchema>;
 
+export const ResellerUsageSchema = z.object({
+  clients: z.number().int(),
+  totalGB: z.number().int(),
+});
+export type ResellerUsage = z.infer<typeof ResellerUsageSchema>;
+
+export const RuntimePreviewSchema = z.object({
+  addedInbounds: z.array(z.string()).optional(),
+  addedOutbounds: z.array(z.string()).optional(),
+  addedUsers: z.array(z.string()).optional(),
+  diff: z.string(),
+  nodeId: z.number().int().optional(),
+  removedInbounds: z.array(z.string()).optional(),
+  removedOutbounds: z.array(z.string()).optional(),
+  removedUsers: z.array(z.string()).optional(),
+  restart: z.boolean(),
+  routingChanged: z.boolean().optional(),
+  runtime: z.string(),
+});
+export type RuntimePreview = z.infer<typeof RuntimePreviewSchema>;
+
 export const SettingSchema = z.object({
   id: z.number().int(),
   key: z.string(),
@@ -746,10 +1043,131 @@ export const SettingSchema = z.object({
 });
 export type Setting = z.infer<typeof SettingSchema>;
 
+export const StateApplyResultSchema = z.object({
+  applied: z.number().int(),
+  changes: z.array(z.lazy(() => StateChangeSchema)),
+  needRestart: z.boolean(),
+});
+export type StateApplyResult = z.infer<typeof StateApplyResultSchema>;
+
+export const StateChangeSchema = z.object({
+  action: z.string(),
+  fields: z.array(z.string()).optional(),
+  key: z.string(),
+  kind: z.string(),
+});
+export type StateChange = z.infer<typeof StateChangeSchema>;
+
+export const StatePlanSchema = z.object({
+  changes: z.array(z.lazy(() => StateChangeSchema)),
+  errors: z.array(z.string()).optional(),
+});
+export type StatePlan = z.infer<typeof StatePlanSchema>;
+
+export const TrafficHistoryPointSchema = z.object({
+  down: z.number().int(),
+  t: z.number().int(),
+  up: z.number().int(),
+});
+export type TrafficHistoryPoint = z.infer<typeof TrafficHistoryPointSchema>;
+
+export const UpdateVerificationSchema = z.object({
+  error: z.string().optional(),
+  keyId: z.string().optional(),
+  tag: z.string().optional(),
+  verified: z.boolean(),
+});
+export type UpdateVerification = z.infer<typeof UpdateVerificationSchema>;
+
 export const UserSchema = z.object({
+  allowedInbounds: z.array(z.number().int()),
   id: z.number().int(),
+  maxClients: z.number().int(),
+  maxExpiryDays: z.number().int(),
+  maxTrafficGB: z.number().int(),
   password: z.string(),
+  permissions: z.record(z.string(), z.string()),
+  role: z.string(),
+  twoFactorEnable: z.boolean(),
   username: z.string(),
 });
 export type User = z.infer<typeof UserSchema>;
 
+export const UserAccountRequestSchema = z.object({
+  allowedInbounds: z.array(z.number().int()),
+  maxClients: z.number().int().min(0),
+  maxExpiryDays: z.number().int().min(0),
+  maxTrafficGB: z.number().int().min(0),
+  password: z.string().max(128),
+  permissions: z.record(z.string(), z.string()),
+  role: z.string(),
+  username: z.string().max(64),
+});
+export type UserAccountRequest = z.infer<typeof UserAccountRequestSchema>;
+
+export const UserViewSchema = z.object({
+  allowedInbounds: z.array(z.number().int()),
+  effective: z.record(z.string(), z.string()),
+  id: z.number().int(),
+  maxClients: z.number().int(),
+  maxExpiryDays: z.number().int(),
+  maxTrafficGB: z.number().int(),
+  permissions: z.record(z.string(), z.string()),
+  primary: z.boolean(),
+  role: z.string(),
+  twoFactorEnable: z.boolean(),
+  usage: z.lazy(() => ResellerUsageSchema).nullable().optional(),
+  username: z.string(),
+});
+export type UserView = z.infer<typeof UserViewSchema>;
+
+export const VoucherSchema = z.object({
+  code: z.string(),
+  comment: z.string(),
+  createdAt: z.number().int(),
+  enable: z.boolean(),
+  expiresAt: z.number().int(),
+  id: z.number().int(),
+  kind: z.string(),
+  maxUses: z.number().int(),
+  planId: z.number().int(),
+  updatedAt: z.number().int(),
+  uses: z.number().int(),
+});
+export type Voucher = z.infer<typeof VoucherSchema>;
+
+export const WebhookSchema = z.object({
+  allowPrivate: z.boolean(),
+  createdAt: z.number().int(),
+  enable: z.boolean(),
+  events: z.array(z.string()),
+  id: z.number().int(),
+  name: z.string(),
+  updatedAt: z.number().int(),
+  url: z.string(),
+});
+export type Webhook = z.infer<typeof WebhookSchema>;
+
+export const WebhookDeliverySchema = z.object({
+  attempts: z.number().int(),
+  createdAt: z.number().int(),
+  error: z.string(),
+  event: z.string(),
+  id: z.number().int(),
+  nextAttemptAt: z.number().int(),
+  payload: z.string(),
+  responseCode: z.number().int(),
+  status: z.string(),
+  updatedAt: z.number().int(),
+  webhookId: z.number().int(),
+});
+export type WebhookDelivery = z.infer<typeof WebhookDeliverySchema>;
+
+export const WebhookDeliveryPageSchema = z.object({
+  items: z.array(z.lazy(() => WebhookDeliverySchema)),
+  page: z.number().int(),
+  pageSize: z.number().int(),
+  total: z.number().int(),
+});
+export type WebhookDeliveryPage = z.infer<typeof WebhookDeliveryPageSchema>;
+
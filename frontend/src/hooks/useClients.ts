@@ -55,6 +55,8 @@ interface SubSettings {
   subJsonEnable: boolean;
   subClashURI: string;
   subClashEnable: boolean;
+  subSingboxURI: string;
+  subSingboxEnable: boolean;
   publicHost: string;
 }
 
@@ -271,6 +273,8 @@ export function useClients(options: UseClientsOptions = {}) {
       subJsonEnable: !!defaults.subJsonEnable,
       subClashURI: (defaults.subClashURI as string) || '',
       subClashEnable: !!defaults.subClashEnable,
+      subSingboxURI: (defaults.subSingboxURI as string) || '',
+      subSingboxEnable: !!defaults.subSingboxEnable,
       publicHost: (defaults.subDomain as string) || (defaults.webDomain as string) || '',
     }),
     [
@@ -280,6 +284,8 @@ export function useClients(options: UseClientsOptions = {}) {
       defaults.subJsonEnable,
       defaults.subClashURI,
       defaults.subClashEnable,
+      defaults.subSingboxURI,
+      defaults.subSingboxEnable,
       defaults.subDomain,
       defaults.webDomain,
     ],
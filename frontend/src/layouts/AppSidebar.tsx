@@ -7,6 +7,7 @@ import type { MenuProps } from 'antd';
 import {
   ApiOutlined,
   CloseOutlined,
+  CloudUploadOutlined,
   CloudServerOutlined,
   ClusterOutlined,
   CodeOutlined,
@@ -16,6 +17,7 @@ import {
   GithubOutlined,
   GlobalOutlined,
   HeartOutlined,
+  HistoryOutlined,
   ImportOutlined,
   LogoutOutlined,
   MailOutlined,
@@ -23,9 +25,11 @@ import {
   MessageOutlined,
   MoonFilled,
   MoonOutlined,
+  ProfileOutlined,
   PushpinFilled,
   PushpinOutlined,
   ReadOutlined,
+  SafetyCertificateOutlined,
   SafetyOutlined,
   SettingOutlined,
   SunOutlined,
@@ -39,6 +43,7 @@ import { HttpUtil } from '@/utils';
 import { formatPanelVersion } from '@/lib/panel-version';
 import { pauseAnimationsUntilLeave, useTheme } from '@/hooks/useTheme';
 import { useAllSettings } from '@/api/queries/useAllSettings';
+import { useMeQuery } from '@/api/queries/useUsersQuery';
 import './AppSidebar.css';
 
 const DONATE_URL = 'https://donate.sanaei.dev/';
@@ -56,6 +61,7 @@ type IconName =
   | 'inbound'
   | 'team'
   | 'groups'
+  | 'plans'
   | 'setting'
   | 'tool'
   | 'cluster'
@@ -70,6 +76,7 @@ const iconByName: Record<IconName, ComponentType> = {
   inbound: ImportOutlined,
   team: TeamOutlined,
   groups: TagsOutlined,
+  plans: ProfileOutlined,
   setting: SettingOutlined,
   tool: ToolOutlined,
   cluster: ClusterOutlined,
@@ -176,7 +183,12 @@ export default function AppSidebar() {
   const navigate = useNavigate();
   const { pathname, hash } = useLocation();
   const { allSetting } = useAllSettings();
-  const showSubFormats = !!(allSetting.subJsonEnable || allSetting.subClashEnable);
+  const { isOwner } = useMeQuery();
+  const showSubFormats = !!(
+    allSetting.subJsonEnable ||
+    allSetting.subClashEnable ||
+    allSetting.subSingboxEnable
+  );
 
   const [hovered, setHovered] = useState(() => hoveredAcrossRemounts);
   const [pinned, setPinned] = useState(readSidebarPinned);
@@ -216,6 +228,7 @@ export default function AppSidebar() {
       { key: '/inbounds', icon: 'inbound', title: t('menu.inbounds') },
       { key: '/clients', icon: 'team', title: t('menu.clients') },
       { key: '/groups', icon: 'groups', title: t('menu.groups') },
+      { key: '/plans', icon: 'plans', title: t('menu.plans') },
       { key: '/nodes', icon: 'cluster', title: t('menu.nodes') },
       { key: '/hosts', icon: 'hosts', title: t('menu.hosts') },
       { key: '/outbound', icon: 'outbound', title: t('menu.outbounds') },
@@ -262,8 +275,35 @@ export default function AppSidebar() {
         label: t('menu.subFormats'),
       });
     }
+    children.push({
+      key: '/settings#certificates',
+      icon: <SafetyCertificateOutlined />,
+      label: t('pages.settings.certs'),
+    });
+    children.push({
+      key: '/settings#webhooks',
+      icon: <ApiOutlined />,
+      label: t('pages.settings.webhooks.title'),
+    });
+    if (isOwner) {
+      children.push({
+        key: '/settings#users',
+        icon: <TeamOutlined />,
+        label: t('pages.settings.users.title'),
+      });
+      children.push({
+        key: '/settings#audit',
+        icon: <HistoryOutlined />,
+        label: t('pages.settings.audit.title'),
+      });
+      children.push({
+        key: '/settings#backups',
+        icon: <CloudUploadOutlined />,
+        label: t('pages.settings.backups.title'),
+      });
+    }
     return children;
-  }, [t, showSubFormats]);
+  }, [t, showSubFormats, isOwner]);
 
   const xrayChildren = useMemo<NonNullable<MenuProps['items']>>(
     () => [
@@ -52,6 +52,8 @@ export interface RawInboundRow {
   shareAddr?: string;
   subSortIndex?: number;
   disableFlow?: boolean;
+  trafficCoefficient?: number | null;
+  standbyNodeId?: number | null;
   clientStats?: unknown;
 }
 
@@ -81,6 +83,8 @@ export interface WireInboundPayload {
   shareAddr: string;
   subSortIndex: number;
   disableFlow: boolean;
+  trafficCoefficient: number;
+  standbyNodeId?: number;
 }
 
 function coerceJsonObject(value: unknown): Record<string, unknown> {
@@ -216,6 +220,8 @@ export function rawInboundToFormValues(row: RawInboundRow): InboundFormValues {
     shareAddr: row.shareAddr ?? '',
     subSortIndex: Math.max(1, row.subSortIndex ?? 1),
     disableFlow: row.disableFlow ?? false,
+    trafficCoefficient: row.trafficCoefficient ?? 1,
+    standbyNodeId: row.standbyNodeId ?? null,
     protocol,
     settings,
   } as InboundFormValues;
@@ -380,7 +386,9 @@ export function formValuesToWirePayload(values: InboundFormValues): WireInboundP
     shareAddr: values.shareAddr,
     subSortIndex: values.subSortIndex,
     disableFlow: values.disableFlow,
+    trafficCoefficient: values.trafficCoefficient,
   };
   if (values.nodeId != null) payload.nodeId = values.nodeId;
+  if (values.nodeId != null && values.standbyNodeId != null) payload.standbyNodeId = values.standbyNodeId;
   return payload;
 }
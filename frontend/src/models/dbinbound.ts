@@ -45,6 +45,9 @@ export type DBInboundInit = Partial<{
   shareAddr: string;
   subSortIndex: number;
   disableFlow: boolean;
+  trafficCoefficient: number | null;
+  standbyNodeId: number | null;
+  failoverOf: number | null;
   originNodeGuid: string;
   fallbackParent: FallbackParentRef | null;
 }>;
@@ -94,6 +97,9 @@ export class DBInbound {
   shareAddr: string;
   subSortIndex: number;
   disableFlow: boolean;
+  trafficCoefficient: number | null;
+  standbyNodeId: number | null;
+  failoverOf: number | null;
   originNodeGuid: string;
   fallbackParent: FallbackParentRef | null;
 
@@ -125,6 +131,9 @@ export class DBInbound {
     this.shareAddr = '';
     this.subSortIndex = 1;
     this.disableFlow = false;
+    this.trafficCoefficient = null;
+    this.standbyNodeId = null;
+    this.failoverOf = null;
     this.originNodeGuid = '';
     this.fallbackParent = null;
     if (data == null) {
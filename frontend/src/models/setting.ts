@@ -26,8 +26,6 @@ export class AllSetting {
   tgCpu = 80;
   tgMemory = 80;
   tgLang = 'en-US';
-  twoFactorEnable = false;
-  twoFactorToken = '';
   xrayTemplateConfig = '';
   subEnable = true;
   subJsonEnable = false;
@@ -36,6 +34,8 @@ export class AllSetting {
   subJsonUserAgentRegex = '';
   subClashAutoDetect = false;
   subClashUserAgentRegex = '';
+  subSingboxAutoDetect = false;
+  subSingboxUserAgentRegex = '';
   subTitle = '';
   subSupportUrl = '';
   subProfileUrl = '';
@@ -50,6 +50,8 @@ export class AllSetting {
   subJsonPath = '/json/';
   subClashEnable = false;
   subClashPath = '/clash/';
+  subSingboxEnable = false;
+  subSingboxPath = '/singbox/';
   subDomain = '';
   externalTrafficInformEnable = false;
   externalTrafficInformURI = '';
@@ -63,6 +65,9 @@ export class AllSetting {
   subClashURI = '';
   subClashEnableRouting = false;
   subClashRules = '';
+  subSingboxURI = '';
+  subSingboxMux = '';
+  subSingboxRules = '';
   subJsonMux = '';
   subJsonRules = '';
   subJsonFinalMask = '';
@@ -105,17 +110,44 @@ export class AllSetting {
   smtpEnabledEvents = 'login.attempt,cpu.high';
   smtpCpu = 80;
   smtpMemory = 80;
+  webhookCpu = 80;
+  webhookMemory = 80;
   outboundDownThreshold = 3;
+  acmeEmail = '';
+  acmeDirectoryUrl = 'https://acme-v02.api.letsencrypt.org/directory';
+  acmeHttpPort = 80;
+  acmeTlsAlpnPort = 443;
+  acmeRenewDays = 30;
+  auditRetentionDays = 90;
+  metricsClientLabels = false;
+  trafficHistoryHourlyDays = 7;
+  trafficHistoryDays = 365;
+  backupEnable = false;
+  backupCron = '@daily';
+  backupKeep = 7;
+  backupPassphrase = '';
+  backupLocalDir = '';
+  backupS3Endpoint = '';
+  backupS3Region = 'us-east-1';
+  backupS3Bucket = '';
+  backupS3Prefix = 'x-ui/';
+  backupS3AccessKey = '';
+  backupS3SecretKey = '';
+  backupS3PathStyle = true;
   hasTgBotToken = false;
-  hasTwoFactorToken = false;
   hasLdapPassword = false;
   hasApiToken = false;
   hasWarpSecret = false;
   hasNordSecret = false;
   hasSmtpPassword = false;
+  hasBackupPassphrase = false;
+  hasBackupS3SecretKey = false;
+  unreadableSecrets: string[] = [];
   clearTgBotToken = false;
   clearLdapPassword = false;
   clearSmtpPassword = false;
+  clearBackupPassphrase = false;
+  clearBackupS3SecretKey = false;
 
   constructor(data?: unknown) {
     if (data != null) {
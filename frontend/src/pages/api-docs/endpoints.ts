@@ -55,6 +55,16 @@ export interface Section {
   endpoints: Endpoint[];
 }
 
+// Only the routes in dryRunRoutes (controller/util.go) accept this; any other
+// route answers 400 rather than applying the change.
+const dryRunParam: EndpointParam = {
+  name: 'dryRun',
+  in: 'query',
+  type: 'integer',
+  optional: true,
+  desc: 'Pass 1 to validate the change and return its effect without applying it. obj.runtimes lists each runtime whose generated Xray config would change (local, or node:<name> with only the inbounds pushed to it), with a unified diff and whether it hot-applies (added/removed inbounds, users "tag/email", outbounds, routingChanged) or needs restart=true.',
+};
+
 export const sections: readonly Section[] = [
   {
     id: 'authentication',
@@ -98,7 +108,7 @@ export const sections: readonly Section[] = [
         method: 'POST',
         path: '/getTwoFactorEnable',
         summary:
-          'Returns whether 2FA is enabled on the panel — used by the login page to decide whether to show the OTP field.',
+          'Returns whether any panel account has 2FA enabled — used by the login page to decide whether to show the OTP field.',
         response: '{\n  "success": true,\n  "obj": false\n}',
       },
     ],
@@ -152,15 +162,16 @@ export const sections: readonly Section[] = [
         method: 'POST',
         path: '/panel/api/inbounds/add',
         summary:
-          'Create a new inbound. Send the full inbound payload (protocol, port, settings, streamSettings, sniffing, remark, expiryTime, total, enable). settings, streamSettings, and sniffing may be sent as nested JSON objects (preferred) or as JSON-encoded strings (legacy).',
-        body: '{\n  "enable": true,\n  "remark": "VLESS-443",\n  "listen": "",\n  "port": 443,\n  "protocol": "vless",\n  "expiryTime": 0,\n  "total": 0,\n  "settings": {\n    "clients": [{ "id": "...", "email": "user1" }],\n    "decryption": "none",\n    "fallbacks": []\n  },\n  "streamSettings": {\n    "network": "tcp",\n    "security": "reality",\n    "realitySettings": { "show": false, "dest": "..." }\n  },\n  "sniffing": {\n    "enabled": true,\n    "destOverride": ["http", "tls"]\n  }\n}',
+          'Create a new inbound. Send the full inbound payload (protocol, port, settings, streamSettings, sniffing, remark, expiryTime, total, enable). settings, streamSettings, and sniffing may be sent as nested JSON objects (preferred) or as JSON-encoded strings (legacy). trafficCoefficient (0 to 100, default 1) weighs the traffic of its clients against their quotas: 2 counts double, 0 is free. standbyNodeId (node inbounds only) names another node that serves the inbound while its own node is down; the panel then lists the standby copy with failoverOf set to the original id and removes it once the node is back.',
+        body: '{\n  "enable": true,\n  "remark": "VLESS-443",\n  "listen": "",\n  "port": 443,\n  "protocol": "vless",\n  "expiryTime": 0,\n  "total": 0,\n  "trafficCoefficient": 1,\n  "settings": {\n    "clients": [{ "id": "...", "email": "user1" }],\n    "decryption": "none",\n    "fallbacks": []\n  },\n  "streamSettings": {\n    "network": "tcp",\n    "security": "reality",\n    "realitySettings": { "show": false, "dest": "..." }\n  },\n  "sniffing": {\n    "enabled": true,\n    "destOverride": ["http", "tls"]\n  }\n}',
+        params: [dryRunParam],
         errorResponse: '{\n  "success": false,\n  "msg": "Port 443 is already in use"\n}',
       },
       {
         method: 'POST',
         path: '/panel/api/inbounds/del/:id',
         summary: 'Delete an inbound by ID. Also removes its associated client stats rows.',
-        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Inbound ID.' }],
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Inbound ID.' }, dryRunParam],
       },
       {
         method: 'POST',
@@ -176,7 +187,7 @@ export const sections: readonly Section[] = [
         path: '/panel/api/inbounds/update/:id',
         summary:
           'Replace an inbound’s configuration. Body shape mirrors /add. Heavy on inbounds with thousands of clients — prefer /setEnable for enable-only flips.',
-        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Inbound ID.' }],
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Inbound ID.' }, dryRunParam],
       },
       {
         method: 'POST',
@@ -300,6 +311,14 @@ export const sections: readonly Section[] = [
         response:
           '{\n  "success": true,\n  "obj": {\n    "cpu": 12.5,\n    "mem": { "current": 2147483648, "total": 8589934592 },\n    "swap": { "current": 0, "total": 4294967296 },\n    "disk": { "current": 53687091200, "total": 268435456000 },\n    "netIO": { "up": 1073741824, "down": 2147483648 },\n    "xray": { "state": "running", "version": "v25.10.31" },\n    "tcpCount": 42,\n    "load": { "load1": 0.5, "load5": 0.3, "load15": 0.2 }\n  }\n}',
       },
+      {
+        method: 'GET',
+        path: '/panel/api/metrics',
+        summary:
+          'Prometheus / OpenMetrics scrape target (text, not JSON). Host, Xray, inbound, outbound, observatory and node gauges and counters, labelled by inbound tag, outbound tag and node name. Per-client series labelled by email are emitted only when metricsClientLabels is on, since they add four series per client. Accepts monitor-scope API tokens; point the scrape job at metrics_path "<basePath>panel/api/metrics" with a bearer token.',
+        response:
+          '# TYPE xui_inbound_up_bytes counter\n# UNIT xui_inbound_up_bytes bytes\n# HELP xui_inbound_up_bytes Upload traffic through the inbound.\nxui_inbound_up_bytes_total{inbound="in-443-tcp",protocol="vless"} 1048576\n# TYPE xui_node_up gauge\n# HELP xui_node_up Whether the node answered its last heartbeat.\nxui_node_up{node="de-fra-1"} 1\n# EOF',
+      },
       {
         method: 'GET',
         path: '/panel/api/server/fail2banStatus',
@@ -414,6 +433,13 @@ export const sections: readonly Section[] = [
           'Report the outcome of the most recently launched panel self-update (see POST updatePanel). Compare the returned runId against the one updatePanel returned to tell this run apart from a stale result.',
         responseSchema: 'PanelUpdateStatus',
       },
+      {
+        method: 'GET',
+        path: '/panel/api/server/haStatus',
+        summary:
+          'Report this panel\u2019s role in an active/passive HA pair (XUI_HA=1) and which node holds the leader lock. The standby rejects writes with 503. Without HA the panel is always the leader.',
+        responseSchema: 'HAStatus',
+      },
       {
         method: 'GET',
         path: '/panel/api/server/getConfigJson',
@@ -455,6 +481,13 @@ export const sections: readonly Section[] = [
         response:
           '{\n  "success": true,\n  "obj": [\n    {\n      "guid": "c3d4-...",\n      "parentGuid": "a1b2-...",\n      "name": "Node3",\n      "address": "10.0.0.3",\n      "status": "online"\n    }\n  ]\n}',
       },
+      {
+        method: 'POST',
+        path: '/panel/api/server/telemetry',
+        summary:
+          'Offer sent by a master to a node (via the node API token) that advertises the telemetry capability in X-3x-Node-Caps. The node dials back to POST /panel/nodes/telemetry on the master with the same token and streams its telemetry instead of being polled. An empty address means the address the offer came from; certSha256 pins a master certificate that is not publicly trusted.',
+        body: '{\n  "nodeId": 3,\n  "scheme": "https",\n  "address": "",\n  "port": 2053,\n  "basePath": "/",\n  "certSha256": ""\n}',
+      },
       {
         method: 'GET',
         path: '/panel/api/server/getNewX25519Cert',
@@ -761,7 +794,7 @@ export const sections: readonly Section[] = [
     id: 'clients',
     title: 'Clients',
     description:
-      'Manage clients as first-class entities that can be attached to one or more inbounds. A single client row drives the settings.clients entry in every inbound it belongs to. Endpoints live under /panel/api/clients.',
+      'Manage clients as first-class entities that can be attached to one or more inbounds. A single client row drives the settings.clients entry in every inbound it belongs to. Reseller accounts only see and change the clients they created; panel-wide actions (groups, delDepleted, delOrphans, resetAllTraffics) and traffic resets or rewrites, which would bypass their traffic cap, are refused to them. Endpoints live under /panel/api/clients.',
     endpoints: [
       {
         method: 'GET',
@@ -852,7 +885,7 @@ export const sections: readonly Section[] = [
             name: 'client',
             in: 'body (json)',
             type: 'object',
-            desc: 'Client fields: email, subId, id (uuid), password, auth, flow, totalGB, expiryTime, limitIp, limitHwid, tgId (numeric Telegram user ID, 0 = none), comment, enable. Protocol-specific: secret and adTag (mtproto), privateKey, publicKey, preSharedKey, allowedIPs and keepAlive (WireGuard).',
+            desc: 'Client fields: email, subId, id (uuid), password, auth, flow, totalGB, expiryTime, limitIp, limitHwid, tgId (numeric Telegram user ID, 0 = none), comment, enable, egress (outbound or balancer tag the traffic of the client leaves through; overrides the egress of its group), schedule (weekly access windows in the panel time zone such as "mon-fri 09:00-18:00; sat 10:00-14:00"; overrides the schedule of its group, empty allows any time). Protocol-specific: secret and adTag (mtproto), privateKey, publicKey, preSharedKey, allowedIPs and keepAlive (WireGuard).',
           },
           {
             name: 'inboundIds',
@@ -860,6 +893,7 @@ export const sections: readonly Section[] = [
             type: 'integer[]',
             desc: 'Inbound IDs to attach the client to. At least one required.',
           },
+          dryRunParam,
         ],
         body: '{\n  "client": {\n    "email": "alice@example.com",\n    "totalGB": 53687091200,\n    "expiryTime": 1735689600000,\n    "tgId": 0,\n    "limitIp": 0,\n    "limitHwid": 0,\n    "enable": true\n  },\n  "inboundIds": [3, 5]\n}',
         response: '{\n  "success": true,\n  "msg": "Client added"\n}',
@@ -876,6 +910,7 @@ export const sections: readonly Section[] = [
             type: 'string',
             desc: 'Current client email (unique identifier).',
           },
+          dryRunParam,
         ],
         body: '{\n  "email": "alice@example.com",\n  "totalGB": 107374182400,\n  "expiryTime": 1767225600000,\n  "limitHwid": 2,\n  "tgId": 123456789,\n  "enable": true\n}',
         response: '{\n  "success": true,\n  "msg": "Client updated"\n}',
@@ -893,6 +928,7 @@ export const sections: readonly Section[] = [
             type: 'integer',
             desc: 'Pass 1 to retain the xray_client_traffic row after deletion.',
           },
+          dryRunParam,
         ],
         response: '{\n  "success": true,\n  "msg": "Client deleted"\n}',
       },
@@ -990,6 +1026,7 @@ export const sections: readonly Section[] = [
         path: '/panel/api/clients/bulkAdjust',
         summary:
           'Shift expiry and/or traffic quota for many clients in one call. addDays/addBytes may be negative. Clients with unlimited expiry (expiryTime=0) or unlimited traffic (totalGB=0) are skipped for the corresponding field — bulk extend never converts unlimited to limited. A client that was auto-disabled solely because it was depleted (expired or over quota) is automatically re-enabled — locally and on its node — when the adjustment lifts it out of depletion; a manually-disabled or still-depleted client is left disabled. The optional flow directive sets the XTLS flow on every client: "none" clears it, "xtls-rprx-vision"/"xtls-rprx-vision-udp443" set it where the inbound supports it (omit or "" to leave it unchanged). Returns the adjusted count and per-email skip reasons.',
+        params: [dryRunParam],
         body: '{\n  "emails": ["alice", "bob"],\n  "addDays": 30,\n  "addBytes": 53687091200,\n  "flow": "xtls-rprx-vision"\n}',
         response:
           '{\n  "success": true,\n  "obj": {\n    "adjusted": 2,\n    "skipped": [\n      { "email": "carol", "reason": "unlimited expiry" }\n    ]\n  }\n}',
@@ -999,6 +1036,7 @@ export const sections: readonly Section[] = [
         path: '/panel/api/clients/bulkEnable',
         summary:
           'Enable many clients in one call. Emails are grouped by inbound and applied with a single read-modify-write per inbound; the running Xray (local or remote node) is updated to add each user. Note that enabling a client whose quota is exhausted or whose expiry has passed only flips the flag — the traffic loop will disable it again on the next tick. Returns the changed count and per-email skip reasons.',
+        params: [dryRunParam],
         body: '{\n  "emails": ["alice", "bob"]\n}',
         response:
           '{\n  "success": true,\n  "obj": {\n    "changed": 2,\n    "skipped": [\n      { "email": "carol", "reason": "client not found" }\n    ]\n  }\n}',
@@ -1008,6 +1046,7 @@ export const sections: readonly Section[] = [
         path: '/panel/api/clients/bulkDisable',
         summary:
           'Disable many clients in one call. Emails are grouped by inbound and applied with a single read-modify-write per inbound; the running Xray (local or remote node) is updated to remove each user. Returns the changed count and per-email skip reasons.',
+        params: [dryRunParam],
         body: '{\n  "emails": ["alice", "bob"]\n}',
         response:
           '{\n  "success": true,\n  "obj": {\n    "changed": 2,\n    "skipped": [\n      { "email": "carol", "reason": "client not found" }\n    ]\n  }\n}',
@@ -1017,6 +1056,7 @@ export const sections: readonly Section[] = [
         path: '/panel/api/clients/bulkDel',
         summary:
           'Delete many clients in one call. The server processes the list sequentially so each delete sees the committed state of the previous one — avoids the race the per-email fan-out had on the panel side. Pass keepTraffic=true to retain the xray_client_traffic rows after deletion.',
+        params: [dryRunParam],
         body: '{\n  "emails": ["alice", "bob"],\n  "keepTraffic": false\n}',
         response:
           '{\n  "success": true,\n  "obj": {\n    "deleted": 2,\n    "skipped": [\n      { "email": "carol", "reason": "client not found" }\n    ]\n  }\n}',
@@ -1026,6 +1066,7 @@ export const sections: readonly Section[] = [
         path: '/panel/api/clients/bulkCreate',
         summary:
           'Create many clients in one call. Body is a JSON array of {client, inboundIds} payloads — the same shape /add accepts. Items are processed sequentially; per-email skip reasons are returned for items that fail (e.g., duplicate email). Triggers a single Xray restart at the end if any inbound was running.',
+        params: [dryRunParam],
         body: '[\n  {\n    "client": {\n      "email": "alice@example.com",\n      "totalGB": 53687091200,\n      "expiryTime": 0,\n      "limitHwid": 2,\n      "enable": true\n    },\n    "inboundIds": [7]\n  },\n  {\n    "client": {\n      "email": "bob@example.com",\n      "totalGB": 53687091200,\n      "expiryTime": 0,\n      "limitHwid": 0,\n      "enable": true\n    },\n    "inboundIds": [7, 9]\n  }\n]',
         response:
           '{\n  "success": true,\n  "obj": {\n    "created": 2,\n    "skipped": [\n      { "email": "alice@example.com", "reason": "email already in use" }\n    ]\n  }\n}',
@@ -1064,6 +1105,7 @@ export const sections: readonly Section[] = [
             type: 'integer[]',
             desc: 'Target inbound IDs to attach every client to.',
           },
+          dryRunParam,
         ],
         body: '{\n  "emails": ["alice", "bob"],\n  "inboundIds": [7, 9]\n}',
         response:
@@ -1087,6 +1129,7 @@ export const sections: readonly Section[] = [
             type: 'integer[]',
             desc: 'Inbound IDs to detach the clients from.',
           },
+          dryRunParam,
         ],
         body: '{\n  "emails": ["alice", "bob"],\n  "inboundIds": [7, 9]\n}',
         response:
@@ -1106,7 +1149,7 @@ export const sections: readonly Section[] = [
         summary:
           'List all client groups with their member counts. Merges persisted groups (rows in client_groups, including empty placeholders) with the distinct group_name values currently set on clients. Sorted alphabetically (case-insensitive).',
         response:
-          '{\n  "success": true,\n  "obj": [\n    { "name": "customer-a", "clientCount": 5 },\n    { "name": "internal", "clientCount": 0 }\n  ]\n}',
+          '{\n  "success": true,\n  "obj": [\n    { "name": "customer-a", "clientCount": 5, "egress": "warp" },\n    { "name": "internal", "clientCount": 0 }\n  ]\n}',
       },
       {
         method: 'GET',
@@ -1148,6 +1191,101 @@ export const sections: readonly Section[] = [
         body: '{\n  "name": "customer-a"\n}',
         response: '{\n  "success": true,\n  "obj": {\n    "name": "customer-a"\n  }\n}',
       },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/groups/egress',
+        summary:
+          "Route the group's members through an outbound or balancer tag; an empty egress releases them. A client's own egress wins over its group's. The panel generates one routing rule per egress, matching the clients by email, placed after the API and block rules, and hot-applies the routing change; an unknown tag is skipped with a warning in the log. Creates the client_groups row if the group exists only as a derived label.",
+        body: '{\n  "name": "premium",\n  "egress": "warp"\n}',
+        response: '{\n  "success": true,\n  "obj": {\n    "name": "premium",\n    "egress": "warp"\n  }\n}',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/groups/schedule',
+        summary:
+          'Limit the members of the group without a schedule of their own to weekly access windows, read in the panel time zone; an empty schedule lifts the limit. Windows are separated by semicolons, each a day spec (mon-fri, sat,sun or daily) and comma-separated HH:MM-HH:MM ranges; a range ending at or before its start runs past midnight. Returns the schedule in canonical form. Outside its windows a client is removed from the local Xray within a minute and added back when the next window opens; its enable flag and quota are untouched. Creates the client_groups row if the group exists only as a derived label.',
+        body: '{\n  "name": "office",\n  "schedule": "mon-fri 09:00-18:00"\n}',
+        response: '{\n  "success": true,\n  "obj": {\n    "name": "office",\n    "schedule": "mon-fri 09:00-18:00"\n  }\n}',
+      },
+      {
+        method: 'GET',
+        path: '/panel/api/clients/plans',
+        summary: 'List every client plan, sorted by name.',
+        responseSchema: 'Plan',
+        responseSchemaArray: true,
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/plans/add',
+        summary:
+          'Create a plan: a named bundle of traffic quota, duration, IP/HWID limits, traffic reset, group and inbounds. renewMode is extend (default), addQuota or reset. Plan names are unique.',
+        body: '{\n  "name": "monthly-100",\n  "comment": "",\n  "totalGB": 107374182400,\n  "durationDays": 30,\n  "startAfterFirstUse": false,\n  "limitIp": 2,\n  "limitHwid": 0,\n  "trafficReset": "never",\n  "trafficResetDay": 0,\n  "group": "customers",\n  "inboundIds": [1, 2],\n  "renewMode": "extend"\n}',
+        responseSchema: 'Plan',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/plans/update/:id',
+        summary: 'Edit a plan. Clients already made from it keep their current limits.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Plan ID.' }],
+        body: '{\n  "name": "monthly-100",\n  "comment": "",\n  "totalGB": 107374182400,\n  "durationDays": 30,\n  "startAfterFirstUse": false,\n  "limitIp": 2,\n  "limitHwid": 0,\n  "trafficReset": "never",\n  "trafficResetDay": 0,\n  "group": "customers",\n  "inboundIds": [1, 2],\n  "renewMode": "extend"\n}',
+        responseSchema: 'Plan',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/plans/del/:id',
+        summary: 'Delete a plan. Clients made from it are not touched.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Plan ID.' }],
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/plans/create/:id',
+        summary:
+          "Provision clients from a plan. Each client gets the plan's quota, expiry (counted from first use when startAfterFirstUse is set), limits and group, and is attached to the plan's inbounds. Only identity fields are read from the request; missing UUIDs/passwords/subIds are generated. Same result shape as /bulkCreate.",
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Plan ID.' }],
+        body: '{\n  "clients": [\n    { "email": "alice" },\n    { "email": "bob" }\n  ]\n}',
+        response:
+          '{\n  "success": true,\n  "obj": {\n    "created": 2\n  }\n}',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/plans/renew/:id',
+        summary:
+          "Renew clients with a plan's rule. extend pushes the expiry out by the plan's duration from now, or from the current expiry if later; addQuota adds the plan's traffic to the quota; reset re-applies every plan limit and zeroes usage. Renewed clients are re-enabled. Clients the rule cannot change (unlimited expiry or traffic) are reported under skipped.",
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Plan ID.' }],
+        body: '{\n  "emails": ["alice", "bob"]\n}',
+        response:
+          '{\n  "success": true,\n  "obj": {\n    "renewed": 1,\n    "skipped": [{ "email": "bob", "reason": "unlimited expiry" }]\n  }\n}',
+      },
+      {
+        method: 'GET',
+        path: '/panel/api/clients/vouchers',
+        summary: 'List every voucher code, newest first.',
+        responseSchema: 'Voucher',
+        responseSchemaArray: true,
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/vouchers/generate',
+        summary:
+          'Mint a batch of voucher codes for a plan. kind is any (default), create (new client only) or topUp (existing subscription only). maxUses 0 means unlimited; expiresAt is Unix ms, 0 for never. Codes are redeemed on the subscription page (POST <subPath>redeem) or with the bot /redeem command.',
+        body: '{\n  "planId": 1,\n  "count": 10,\n  "kind": "any",\n  "maxUses": 1,\n  "expiresAt": 0,\n  "prefix": "SPRING",\n  "comment": "spring promo"\n}',
+        responseSchema: 'Voucher',
+        responseSchemaArray: true,
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/vouchers/update/:id',
+        summary: 'Edit a voucher. The code and plan are fixed; uses already counted are kept.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Voucher ID.' }],
+        body: '{\n  "kind": "topUp",\n  "maxUses": 5,\n  "expiresAt": 0,\n  "enable": true,\n  "comment": ""\n}',
+        responseSchema: 'Voucher',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/clients/vouchers/del/:id',
+        summary: 'Delete a voucher. Clients it already created or topped up are not touched.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Voucher ID.' }],
+      },
       {
         method: 'POST',
         path: '/panel/api/clients/resetTraffic/:email',
@@ -1253,6 +1391,34 @@ export const sections: readonly Section[] = [
         ],
         responseSchema: 'ClientTraffic',
       },
+      {
+        method: 'GET',
+        path: '/panel/api/clients/history/:email/:bucket',
+        summary:
+          'Traffic history of a client, oldest bucket first, summed across the panel and every node. Hourly detail is kept for trafficHistoryHourlyDays, then folded into daily buckets. Empty buckets are returned as zeros.',
+        params: [
+          {
+            name: 'email',
+            in: 'path',
+            type: 'string',
+            desc: 'Client email (unique across the panel).',
+          },
+          {
+            name: 'bucket',
+            in: 'path',
+            type: 'number',
+            desc: 'Bucket size in seconds: 3600 (hourly) or 86400 (daily, aligned to the panel time zone).',
+          },
+          {
+            name: 'count',
+            in: 'query',
+            type: 'number',
+            desc: 'Number of buckets ending with the current one. Defaults to 30, capped at 1000.',
+          },
+        ],
+        responseSchema: 'TrafficHistoryPoint',
+        responseSchemaArray: true,
+      },
       {
         method: 'GET',
         path: '/panel/api/clients/subLinks/:subId',
@@ -1318,6 +1484,63 @@ export const sections: readonly Section[] = [
         summary:
           'Validate the stored master mTLS client credential and invalidate cached transports. Each transport closes its old idle pool and rebuilds with the rotated certificate before its next request.',
       },
+      {
+        method: 'GET',
+        path: '/panel/api/nodes/joinTokens',
+        summary:
+          'List the join tokens minted in the last week, newest first. usedAt is 0 until a node redeems the token; nodeId is the node it created. The token itself is never returned.',
+        responseSchema: 'NodeJoinToken',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/nodes/joinTokens/add',
+        summary:
+          'Mint a single-use join token. A new node redeems it with x-ui join <master-url> <token> and is added in mtls mode with its certificate pinned. The plaintext token is returned only here. masterPin is set when this panel certificate is not publicly trusted; pass it to x-ui join -pin.',
+        body: '{\n  "name": "de-fra-2",\n  "ttlMinutes": 30,\n  "allowPrivateAddress": false\n}',
+        responseSchema: 'NodeJoinTokenCreated',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/nodes/joinTokens/del/:id',
+        summary: 'Revoke a join token.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Join token ID.' }],
+      },
+      {
+        method: 'POST',
+        path: '/panel/nodes/join',
+        summary:
+          'Called by x-ui join on the new node; no session or API token, the join token is the credential. The master probes the node with the API token and certificate pin it sent before spending the token, then returns the node ID and the CA of its mTLS client certificate. Failed attempts are rate limited per IP.',
+        body: '{\n  "token": "k3Jd8s...",\n  "name": "de-fra-2",\n  "scheme": "https",\n  "address": "",\n  "port": 2053,\n  "basePath": "/",\n  "apiToken": "node-sync token",\n  "certSha256": "9f86d081884c7d65..."\n}',
+        response:
+          '{\n  "success": true,\n  "obj": {\n    "nodeId": 3,\n    "name": "de-fra-2",\n    "caCert": "-----BEGIN CERTIFICATE-----\\n..."\n  }\n}',
+      },
+      {
+        method: 'POST',
+        path: '/panel/nodes/telemetry',
+        summary:
+          'Called by a node after a telemetry offer; no session, the node sends the API token the master calls it with as a bearer token and its node ID in X-3x-Node-Id. The body is a long-lived stream of newline-delimited JSON frames, each applied as it arrives: status, traffic, clientIps, clientIpsByGuid and descendants when they change, and a ping when idle. Traffic opens with a full frame and then carries only the changed inbounds and clients, plus the node write number it has settled. Failed attempts are rate limited per IP.',
+        body: '{"type":"status","data":{"cpu":12.5,"xray":{"state":"running"}}}\n{"type":"traffic","data":{"full":true,"epoch":"m1x2k3","settled":0,"inbounds":[],"onlineTree":{},"activeInboundTree":{}}}\n{"type":"ping"}',
+      },
+      {
+        method: 'GET',
+        path: '/panel/api/nodes/outbox/:id',
+        summary:
+          'List the runtime operations queued for a node while it was unreachable, in delivery order. The node sync job replays them once the node answers; an entry the node refuses falls back to a full reconcile, and after 8 refusals it is marked failed and waits for retry or discard.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Node ID.' }],
+        responseSchema: 'NodeOutboxEntry',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/nodes/outbox/retry/:id',
+        summary: 'Make a queued operation due on the next node sync with a fresh set of attempts, including a failed one.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Outbox entry ID.' }],
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/nodes/outbox/discard/:id',
+        summary: 'Drop a queued operation without delivering it and mark the node for a full reconcile instead.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Outbox entry ID.' }],
+      },
       {
         method: 'GET',
         path: '/panel/api/nodes/get/:id',
@@ -1338,8 +1561,8 @@ export const sections: readonly Section[] = [
         method: 'POST',
         path: '/panel/api/nodes/add',
         summary:
-          'Register a new remote node. Provide its URL, write-only apiToken, and optional remark / allowPrivateAddress flag. Responses expose hasApiToken only.',
-        body: '{\n  "name": "de-fra-1",\n  "remark": "",\n  "scheme": "https",\n  "address": "node1.example.com",\n  "port": 2053,\n  "basePath": "/",\n  "apiToken": "abcdef...",\n  "clearApiToken": false,\n  "enable": true,\n  "allowPrivateAddress": false\n}',
+          'Register a new remote node. Provide its URL, write-only apiToken, and optional remark / allowPrivateAddress flag. trafficCoefficient (0 to 100, default 1) weighs client traffic through the node against quotas, on top of the coefficient of each inbound. Responses expose hasApiToken only.',
+        body: '{\n  "name": "de-fra-1",\n  "remark": "",\n  "scheme": "https",\n  "address": "node1.example.com",\n  "port": 2053,\n  "basePath": "/",\n  "apiToken": "abcdef...",\n  "clearApiToken": false,\n  "enable": true,\n  "allowPrivateAddress": false,\n  "trafficCoefficient": 1\n}',
         responseSchema: 'NodeView',
       },
       {
@@ -1348,7 +1571,7 @@ export const sections: readonly Section[] = [
         summary:
           'Replace a node\u2019s connection details. apiToken is write-only: omit it or send an empty string to keep the stored token; set clearApiToken=true to clear it.',
         params: [{ name: 'id', in: 'path', type: 'number', desc: 'Node ID.' }],
-        body: '{\n  "name": "de-fra-1",\n  "remark": "",\n  "scheme": "https",\n  "address": "node1.example.com",\n  "port": 2053,\n  "basePath": "/",\n  "apiToken": "",\n  "clearApiToken": false,\n  "enable": true,\n  "allowPrivateAddress": false\n}',
+        body: '{\n  "name": "de-fra-1",\n  "remark": "",\n  "scheme": "https",\n  "address": "node1.example.com",\n  "port": 2053,\n  "basePath": "/",\n  "apiToken": "",\n  "clearApiToken": false,\n  "enable": true,\n  "allowPrivateAddress": false,\n  "trafficCoefficient": 1\n}',
       },
       {
         method: 'POST',
@@ -1398,10 +1621,32 @@ export const sections: readonly Section[] = [
         method: 'POST',
         path: '/panel/api/nodes/updatePanel',
         summary:
-          'Trigger the official panel self-updater on each given node (downloads the latest release and restarts). Only enabled, online nodes are updated; offline/disabled ones are reported as skipped. Set "dev": true to move the nodes to the rolling per-commit dev channel instead of the latest stable release. Returns a per-node result list.',
-        body: '{\n  "ids": [1, 2, 3],\n  "dev": false\n}',
-        response:
-          '{\n  "success": true,\n  "obj": [\n    { "id": 1, "name": "de-1", "ok": true },\n    { "id": 2, "name": "fr-1", "ok": false, "error": "node is offline" }\n  ]\n}',
+          'Start a rolling panel upgrade of the given nodes to the latest release of the channel ("dev": true for the rolling per-commit dev channel). The first canarySize nodes (default 1) are upgraded first, then the rest in batches of batchSize (default 5); a batch starts only once every node of the previous one reported the new version with Xray running, within 15 minutes. The first failed node aborts the rollout. Disabled and offline nodes are skipped. Only one rollout runs at a time; progress is pushed over the WebSocket as node_rollout messages.',
+        body: '{\n  "ids": [1, 2, 3],\n  "dev": false,\n  "canarySize": 1,\n  "batchSize": 5\n}',
+        responseSchema: 'NodeRollout',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/nodes/updateXray',
+        summary:
+          'Start a rolling switch of the given nodes to an Xray release from GET /panel/api/server/getXrayVersion, batched and health-gated like updatePanel. A node counts as done once it reports the version with Xray running, within 5 minutes.',
+        body: '{\n  "ids": [1, 2, 3],\n  "version": "v26.7.1",\n  "canarySize": 1,\n  "batchSize": 5\n}',
+        responseSchema: 'NodeRollout',
+      },
+      {
+        method: 'GET',
+        path: '/panel/api/nodes/rollouts',
+        summary:
+          'List the 10 most recent node rollouts, newest first, with the state of each node: pending, upgrading, done, failed, or skipped.',
+        responseSchema: 'NodeRollout',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/nodes/rollouts/cancel/:id',
+        summary:
+          'Cancel a running rollout. No further node is started; nodes already upgrading finish on their own.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Rollout ID.' }],
+        responseSchema: 'NodeRollout',
       },
       {
         method: 'GET',
@@ -1516,6 +1761,273 @@ export const sections: readonly Section[] = [
     ],
   },
 
+  {
+    id: 'acme',
+    title: 'ACME certificates',
+    description:
+      'Certificates issued and renewed by the panel through an ACME CA (Let\'s Encrypt by default). Issued files can back the panel, the subscription server or any inbound TLS. All endpoints under /panel/api/acme.',
+    endpoints: [
+      {
+        method: 'GET',
+        path: '/panel/api/acme/list',
+        summary:
+          'List every managed certificate with its status, validity window and on-disk file paths. PEM material and DNS credentials are never returned.',
+        responseSchema: 'AcmeCertificate',
+        responseSchemaArray: true,
+      },
+      {
+        method: 'GET',
+        path: '/panel/api/acme/providers',
+        summary: 'Names of the DNS providers available for dns-01 challenges.',
+        response: '{\n  "success": true,\n  "obj": ["cloudflare"]\n}',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/acme/issue',
+        summary:
+          'Start a new order. Returns the pending certificate immediately; poll /list until its status turns valid or error. Wildcard names require dns-01.',
+        body: '{\n  "domains": ["panel.example.com"],\n  "challenge": "http-01",\n  "autoRenew": true\n}',
+        responseSchema: 'AcmeCertificate',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/acme/renew/:id',
+        summary: 'Re-order a certificate now, regardless of its expiry.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Certificate ID.' }],
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/acme/del/:id',
+        summary:
+          'Delete a certificate and its files. Refused while the panel, the subscription server or any local or node-hosted inbound is configured to use it; the error names the inbound tags.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Certificate ID.' }],
+      },
+    ],
+  },
+
+  {
+    id: 'users',
+    title: 'Accounts',
+    description:
+      'Panel accounts and their roles. Owners have full access; admin, reseller and read-only accounts get per-group access (inbounds, clients, nodes, settings, xray) from their role, optionally overridden per account. Resellers only see the clients they created, on their allowed inbounds, within their client-count, traffic and expiry caps. Everything except /me is owner-only. All endpoints under /panel/api/users.',
+    endpoints: [
+      {
+        method: 'GET',
+        path: '/panel/api/users/me',
+        summary: 'The signed-in account with its role and effective permissions.',
+        responseSchema: 'UserView',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/users/me/twoFactor',
+        summary:
+          'Enable, rebind or disable 2FA for the signed-in account. While 2FA is on, the current code is required. Other sessions of the account are signed out.',
+        body: '{\n  "enable": true,\n  "token": "JBSWY3DPEHPK3PXP",\n  "code": ""\n}',
+      },
+      {
+        method: 'GET',
+        path: '/panel/api/users/list',
+        summary: 'List every panel account. Passwords and 2FA secrets are never returned.',
+        responseSchema: 'UserView',
+        responseSchemaArray: true,
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/users/add',
+        summary:
+          'Create an account. Permissions map a group to none, read or write and override the role preset; they are ignored for owners.',
+        body: '{\n  "username": "support",\n  "password": "change-me",\n  "role": "read-only",\n  "permissions": { "clients": "write" }\n}',
+        responseSchema: 'UserView',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/users/update/:id',
+        summary:
+          'Edit an account. A blank password keeps the current one; a new one signs the account out. The primary account must stay an owner, and at least one owner always remains. allowedInbounds and the max* caps apply to resellers only; 0 is unlimited.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Account ID.' }],
+        body: '{\n  "username": "partner",\n  "password": "",\n  "role": "reseller",\n  "permissions": {},\n  "allowedInbounds": [1, 3],\n  "maxClients": 100,\n  "maxTrafficGB": 2000,\n  "maxExpiryDays": 31\n}',
+        responseSchema: 'UserView',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/users/del/:id',
+        summary: 'Delete an account. The primary account and your own account cannot be deleted.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Account ID.' }],
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/users/resetTwoFactor/:id',
+        summary: 'Turn off 2FA for an account that lost its authenticator.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Account ID.' }],
+      },
+    ],
+  },
+
+  {
+    id: 'audit',
+    title: 'Audit log',
+    description:
+      'Every state-changing API call that passed authentication: who made it (account, API token or client certificate), from which IP, against which entity, whether it succeeded, and a field-level before/after diff of inbounds, clients, hosts, nodes, settings and the Xray template. Credentials in diffs are redacted. Entries older than auditRetentionDays are pruned daily. Owner-only. All endpoints under /panel/api/audit.',
+    endpoints: [
+      {
+        method: 'GET',
+        path: '/panel/api/audit/list',
+        summary: 'One page of audit entries matching the filters, newest first.',
+        params: [
+          {
+            name: 'page',
+            in: 'query',
+            type: 'number',
+            desc: '1-indexed page number. Defaults to 1.',
+          },
+          {
+            name: 'pageSize',
+            in: 'query',
+            type: 'number',
+            desc: 'Rows per page. Defaults to 50, capped at 500.',
+          },
+          {
+            name: 'search',
+            in: 'query',
+            type: 'string',
+            desc: 'Case-insensitive substring match on route / actor / target ID / source IP.',
+          },
+          {
+            name: 'actor',
+            in: 'query',
+            type: 'string',
+            desc: 'Exact account username, API token name or client certificate CN.',
+          },
+          {
+            name: 'targetType',
+            in: 'query',
+            type: 'string',
+            desc: 'inbound | client | host | node | setting | xray | group | apiToken | certificate | user | webhook | server.',
+          },
+          {
+            name: 'targetId',
+            in: 'query',
+            type: 'string',
+            desc: 'Inbound or node ID, client email or host group ID.',
+          },
+          { name: 'from', in: 'query', type: 'number', desc: 'Earliest entry, unix milliseconds.' },
+          { name: 'to', in: 'query', type: 'number', desc: 'Latest entry, unix milliseconds.' },
+        ],
+        responseSchema: 'AuditPage',
+      },
+      {
+        method: 'GET',
+        path: '/panel/api/audit/export',
+        summary:
+          'Stream every entry matching the filters as a CSV attachment, oldest first. Paging is ignored; the changes column holds the diff as JSON.',
+        params: [
+          {
+            name: 'search',
+            in: 'query',
+            type: 'string',
+            desc: 'Case-insensitive substring match on route / actor / target ID / source IP.',
+          },
+          {
+            name: 'actor',
+            in: 'query',
+            type: 'string',
+            desc: 'Exact account username, API token name or client certificate CN.',
+          },
+          {
+            name: 'targetType',
+            in: 'query',
+            type: 'string',
+            desc: 'inbound | client | host | node | setting | xray | group | apiToken | certificate | user | webhook | server.',
+          },
+          {
+            name: 'targetId',
+            in: 'query',
+            type: 'string',
+            desc: 'Inbound or node ID, client email or host group ID.',
+          },
+          { name: 'from', in: 'query', type: 'number', desc: 'Earliest entry, unix milliseconds.' },
+          { name: 'to', in: 'query', type: 'number', desc: 'Latest entry, unix milliseconds.' },
+        ],
+      },
+    ],
+  },
+
+  {
+    id: 'webhooks',
+    title: 'Webhooks',
+    description:
+      'Event bus notifications POSTed as JSON to your own URLs: <code>{"id", "event", "source", "hostname", "timestamp", "data"}</code>. Each request carries <code>X-3xui-Event</code>, <code>X-3xui-Delivery</code> (the payload id, stable across retries), <code>X-3xui-Timestamp</code> (unix seconds) and <code>X-3xui-Signature: sha256=&lt;hex&gt;</code>, an HMAC-SHA256 of <code>timestamp + "." + body</code> keyed with the webhook secret. Any non-2xx answer is retried up to 6 attempts with backoff from 30s doubling to 1h. Delivery logs are kept for 14 days. cpu.high and memory.high use the webhookCpu / webhookMemory thresholds and start sampling after a panel restart. All endpoints under /panel/api/webhooks.',
+    endpoints: [
+      {
+        method: 'GET',
+        path: '/panel/api/webhooks/list',
+        summary: 'List every webhook. Secrets are never returned.',
+        responseSchema: 'Webhook',
+        responseSchemaArray: true,
+      },
+      {
+        method: 'GET',
+        path: '/panel/api/webhooks/deliveries',
+        summary: 'One page of delivery attempts, newest first.',
+        params: [
+          {
+            name: 'page',
+            in: 'query',
+            type: 'number',
+            desc: '1-indexed page number. Defaults to 1.',
+          },
+          {
+            name: 'pageSize',
+            in: 'query',
+            type: 'number',
+            desc: 'Rows per page. Defaults to 50, capped at 500.',
+          },
+          { name: 'webhookId', in: 'query', type: 'number', desc: 'Only this webhook.' },
+          { name: 'status', in: 'query', type: 'string', desc: 'pending | success | failed.' },
+        ],
+        responseSchema: 'WebhookDeliveryPage',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/webhooks/add',
+        summary:
+          'Create a webhook. An empty events list subscribes to all of outbound.down, outbound.up, xray.crash, node.down, node.up, cpu.high, memory.high and login.attempt. URLs resolving to private addresses are refused unless allowPrivate is set.',
+        body: '{\n  "name": "ops",\n  "url": "https://hooks.example.com/3x-ui",\n  "secret": "s3cr3t-shared-with-receiver",\n  "events": ["xray.crash", "node.down"],\n  "enable": true,\n  "allowPrivate": false\n}',
+        responseSchema: 'Webhook',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/webhooks/update/:id',
+        summary: 'Edit a webhook. A blank secret keeps the current one.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Webhook ID.' }],
+        body: '{\n  "name": "ops",\n  "url": "https://hooks.example.com/3x-ui",\n  "secret": "",\n  "events": [],\n  "enable": true,\n  "allowPrivate": false\n}',
+        responseSchema: 'Webhook',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/webhooks/del/:id',
+        summary: 'Delete a webhook together with its delivery log.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Webhook ID.' }],
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/webhooks/test/:id',
+        summary:
+          'Send a signed webhook.test event right away, once, without retries. Fails with the receiver error when it does not answer 2xx.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Webhook ID.' }],
+        responseSchema: 'WebhookDelivery',
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/webhooks/deliveries/replay/:id',
+        summary:
+          'Send a recorded payload again as a new delivery with the normal retry policy. The payload id is kept so receivers can deduplicate.',
+        params: [{ name: 'id', in: 'path', type: 'number', desc: 'Delivery ID.' }],
+        responseSchema: 'WebhookDelivery',
+      },
+    ],
+  },
+
   {
     id: 'backup',
     title: 'Backup',
@@ -1530,6 +2042,124 @@ export const sections: readonly Section[] = [
     ],
   },
 
+  {
+    id: 'backups',
+    title: 'Scheduled backups',
+    description:
+      'Database backups written on the backupCron schedule to a local directory and/or an S3-compatible bucket. Each backup file has a <code>.manifest.json</code> sidecar with its size and SHA-256, checked before a restore. Backups are encrypted with AES-256-GCM when backupPassphrase is set. Each target keeps the newest backupKeep backups. Owner only. All endpoints under /panel/api/backups.',
+    endpoints: [
+      {
+        method: 'GET',
+        path: '/panel/api/backups/list',
+        summary:
+          'Every backup on every configured target, newest first. When a target cannot be read, success is false and obj still holds the backups found on the others.',
+        responseSchema: 'BackupManifest',
+        responseSchemaArray: true,
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/backups/run',
+        summary:
+          'Take a backup now and write it to every configured target, then apply retention. Returns the manifests written.',
+        responseSchema: 'BackupManifest',
+        responseSchemaArray: true,
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/backups/restore',
+        summary:
+          'Verify a backup against its manifest and import it, like /panel/api/server/importDB. keepHostSettings defaults to true. Restart the panel afterwards.',
+        body: '{\n  "target": "s3",\n  "name": "panel.example.com_2026-10-17_033000.sql.enc",\n  "keepHostSettings": true\n}',
+      },
+    ],
+  },
+
+  {
+    id: 'state',
+    title: 'Declarative state',
+    description:
+      'Config as code: the panel as one versioned YAML or JSON document covering settings, the Xray template, outbound subscriptions, nodes, client groups, inbounds with fallbacks, hosts and clients. Every list section present is managed in full (created, updated and deleted to match); a section left out is not touched. Settings are applied key by key. Secrets and machine-bound settings are never exported or accepted. The same document drives <code>x-ui export-state</code> and <code>x-ui apply</code>. Owner only. All endpoints under /panel/api/state.',
+    endpoints: [
+      {
+        method: 'GET',
+        path: '/panel/api/state/export',
+        summary: 'Download the current state document as an attachment.',
+        params: [
+          {
+            name: 'format',
+            in: 'query',
+            type: 'string',
+            desc: 'yaml or json.',
+            optional: true,
+            defaultValue: 'yaml',
+          },
+        ],
+      },
+      {
+        method: 'POST',
+        path: '/panel/api/state/apply',
+        summary:
+          'Make the panel match the state document in the request body (YAML or JSON). Changes run in dependency order and stop at the first failure; applied counts those done. With dryRun the plan is returned instead.',
+        params: [dryRunParam],
+        body: 'version: 1\ninbounds:\n  - tag: in-443-tcp\n    protocol: vless\n    port: 443\n    ...',
+        responseSchema: 'StateApplyResult',
+      },
+    ],
+  },
+
+  {
+    id: 'importer',
+    title: 'Import from other panels',
+    description:
+      'Recreate the users and inbounds of Marzban, Hiddify, Remnawave or a legacy x-ui database on this panel, keeping UUIDs, passwords, subscription ids, quotas, used traffic and expiry. Source inbounds are created here unless mapped onto an existing inbound; an inbound whose tag or port is taken, and a client whose email exists, is skipped and reported, never merged. The same import runs as <code>x-ui import</code>. Owner only. All endpoints under /panel/api/importer.',
+    endpoints: [
+      {
+        method: 'POST',
+        path: '/panel/api/importer/import',
+        summary:
+          'Import another panel from an uploaded database or backup file, or from a PostgreSQL DSN. With dryRun nothing is written and the report says what would happen.',
+        params: [
+          {
+            name: 'source',
+            in: 'body (multipart)',
+            type: 'string',
+            desc: 'marzban, hiddify, remnawave or x-ui.',
+          },
+          {
+            name: 'file',
+            in: 'body (multipart)',
+            type: 'file',
+            desc: 'SQLite database of the source panel, or the Hiddify backup JSON.',
+            optional: true,
+          },
+          {
+            name: 'dsn',
+            in: 'body (multipart)',
+            type: 'string',
+            desc: 'PostgreSQL DSN of the source panel, instead of file.',
+            optional: true,
+          },
+          {
+            name: 'xrayConfig',
+            in: 'body (multipart)',
+            type: 'file',
+            desc: "Marzban's xray_config.json. Without it Marzban users can only be mapped.",
+            optional: true,
+          },
+          {
+            name: 'map',
+            in: 'body (multipart)',
+            type: 'string',
+            desc: 'JSON object of source inbound tag to existing inbound tag.',
+            optional: true,
+          },
+          dryRunParam,
+        ],
+        responseSchema: 'ImportReport',
+      },
+    ],
+  },
+
   {
     id: 'settings',
     title: 'Settings',
@@ -1745,6 +2375,7 @@ export const sections: readonly Section[] = [
             type: 'string',
             desc: 'URL used for outbound reachability tests. Defaults to https://www.google.com/generate_204.',
           },
+          dryRunParam,
         ],
       },
       {
@@ -2193,7 +2824,7 @@ export const sections: readonly Section[] = [
     id: 'subscription',
     title: 'Subscription Server',
     description:
-      'A separate HTTP/HTTPS server that serves proxy subscription links (standard, JSON, and Clash) to clients. The server listens on its own port (default 10882) and is configured in Settings → Subscription. Paths are configurable; defaults are shown below. All subscription endpoints set response headers for client apps to read traffic/expiry info.',
+      'A separate HTTP/HTTPS server that serves proxy subscription links (standard, JSON, Clash, and sing-box) to clients. The server listens on its own port (default 10882) and is configured in Settings → Subscription. Paths are configurable; defaults are shown below. All subscription endpoints set response headers for client apps to read traffic/expiry info.',
     subHeader: [
       {
         name: 'Subscription-Userinfo',
@@ -2221,7 +2852,7 @@ export const sections: readonly Section[] = [
         method: 'GET',
         path: '/{subPath}:subid',
         summary:
-          'Return base64-encoded subscription links for all enabled clients matching the subscription ID. When the request has an Accept: text/html header or ?html=1, renders a styled info page instead. With ?format=info, returns the page view-model as JSON (traffic, expiry, online status; no links) for live polling. Default path: /sub/:subid.',
+          'Return base64-encoded subscription links for all enabled clients matching the subscription ID. When the request has an Accept: text/html header or ?html=1, renders a styled info page instead. With ?format=info, returns the page view-model as JSON (traffic, expiry, online status, daily usage for the last 30 days; no links) for live polling. Default path: /sub/:subid.',
         params: [
           { name: 'subid', in: 'path', type: 'string', desc: 'Client subscription ID.' },
           {
@@ -2247,6 +2878,13 @@ export const sections: readonly Section[] = [
           'Return subscription as a Clash/Mihomo-compatible YAML config, including configured global Clash routing rules. Only when Clash subscription is enabled in settings. Default path: /clash/:subid.',
         params: [{ name: 'subid', in: 'path', type: 'string', desc: 'Client subscription ID.' }],
       },
+      {
+        method: 'GET',
+        path: '/{singboxPath}:subid',
+        summary:
+          'Return subscription as a sing-box (1.12+) JSON config: one outbound per enabled client endpoint behind a "proxy" selector and an "auto" urltest, plus the configured sing-box multiplex and route rules. Only when sing-box subscription is enabled in settings. Default path: /singbox/:subid.',
+        params: [{ name: 'subid', in: 'path', type: 'string', desc: 'Client subscription ID.' }],
+      },
     ],
   },
 
@@ -47,6 +47,7 @@ import type {
   ExternalLinkInput,
 } from '@/hooks/useClients';
 import { useFail2banStatusQuery, getLimitIpNotice } from '@/api/queries/useFail2banStatusQuery';
+import { useOutboundTagGroups } from '@/api/queries/useOutboundTags';
 import { ClientFormSchema, ClientCreateFormSchema, type ClientFormValues } from '@/schemas/client';
 
 const FLOW_OPTIONS = Object.values(TLS_FLOW_CONTROL);
@@ -154,6 +155,8 @@ const EMPTY: Values = {
   limitHwid: 0,
   tgId: 0,
   group: '',
+  egress: '',
+  schedule: '',
   comment: '',
   enable: true,
   inboundIds: [],
@@ -261,6 +264,19 @@ export default function ClientFormModal({
   const fail2ban = useFail2banStatusQuery();
   const limitIpDisabled = !fail2ban.usable;
   const limitIpNotice = getLimitIpNotice(fail2ban, t);
+  // Accounts without Xray access get no tags and so no egress picker.
+  const { data: outboundGroups } = useOutboundTagGroups({ excludeBlackhole: true });
+  const egressOptions = useMemo(() => {
+    const outOpts = (outboundGroups?.outbounds ?? []).map((tag) => ({ label: tag, value: tag }));
+    if (!outboundGroups?.balancers.length) return outOpts;
+    return [
+      { label: t('pages.xray.Outbounds'), options: outOpts },
+      {
+        label: t('pages.xray.Balancers'),
+        options: outboundGroups.balancers.map((tag) => ({ label: tag, value: tag })),
+      },
+    ];
+  }, [outboundGroups, t]);
 
   function addExternalLinkRow(kind: 'link' | 'subscription') {
     appendExternalLink({
@@ -305,6 +321,8 @@ export default function ClientFormModal({
         limitHwid: client.limitHwid || 0,
         tgId: Number(client.tgId) || 0,
         group: client.group || '',
+        egress: client.egress || '',
+        schedule: client.schedule || '',
         comment: client.comment || '',
         enable: !!client.enable,
         inboundIds: Array.isArray(attachedIds) ? [...attachedIds] : [],
@@ -585,6 +603,8 @@ export default function ClientFormModal({
       limitHwid: values.limitHwid,
       tgId: values.tgId,
       group: values.group,
+      egress: values.egress,
+      schedule: values.schedule,
       comment: values.comment,
       enable: values.enable,
       inboundIds: values.inboundIds,
@@ -617,6 +637,8 @@ export default function ClientFormModal({
       limitHwid: Number(values.limitHwid) || 0,
       tgId: Number(values.tgId) || 0,
       group: values.group,
+      egress: values.egress,
+      schedule: values.schedule,
       comment: values.comment,
       enable: !!values.enable,
     };
@@ -949,6 +971,41 @@ export default function ClientFormModal({
                         </Col>
                       </Row>
 
+                      <Row gutter={16}>
+                        <Col xs={24} md={12}>
+                          <FormField
+                            name="schedule"
+                            label={t('pages.clients.schedule')}
+                            tooltip={t('pages.clients.scheduleDesc')}
+                          >
+                            <Input placeholder="mon-fri 09:00-18:00; sat 10:00-14:00" />
+                          </FormField>
+                        </Col>
+                      </Row>
+
+                      {outboundGroups && (
+                        <Row gutter={16}>
+                          <Col xs={24} md={12}>
+                            <FormField
+                              name="egress"
+                              label={t('pages.clients.egress')}
+                              tooltip={t('pages.clients.egressDesc')}
+                              transform={{
+                                input: (v) => (v as string) || undefined,
+                                output: (v) => v ?? '',
+                              }}
+                            >
+                              <Select
+                                allowClear
+                                showSearch
+                                placeholder={t('pages.clients.egressPlaceholder')}
+                                options={egressOptions}
+                              />
+                            </FormField>
+                          </Col>
+                        </Row>
+                      )}
+
                       {(tgBotEnable || showReverseTag) && (
                         <Row gutter={16}>
                           {tgBotEnable && (
@@ -49,6 +49,8 @@ interface SubSettings {
   subJsonEnable: boolean;
   subClashURI: string;
   subClashEnable: boolean;
+  subSingboxURI: string;
+  subSingboxEnable: boolean;
   publicHost?: string;
 }
 
@@ -73,6 +75,8 @@ const DEFAULT_SUB: SubSettings = {
   subJsonEnable: false,
   subClashURI: '',
   subClashEnable: false,
+  subSingboxURI: '',
+  subSingboxEnable: false,
   publicHost: '',
 };
 
@@ -80,6 +84,7 @@ const SUBSCRIPTION_DOWNLOAD_NAMES = {
   standard: 'subscription-standard.txt',
   json: 'subscription-json.json',
   clash: 'subscription-clash.yaml',
+  singbox: 'subscription-singbox.json',
 } as const;
 
 export default function ClientInfoModal({
@@ -170,6 +175,10 @@ export default function ClientInfoModal({
     subId && subSettings?.subClashEnable && subSettings?.subClashURI
       ? subSettings.subClashURI + subId
       : '';
+  const subSingboxLink =
+    subId && subSettings?.subSingboxEnable && subSettings?.subSingboxURI
+      ? subSettings.subSingboxURI + subId
+      : '';
 
   const showSubscription = !!(subSettings?.enable && client?.subId);
   const wgInbound = useMemo(
@@ -701,6 +710,64 @@ export default function ClientInfoModal({
                     </div>
                   </div>
                 )}
+                {subSingboxLink && (
+                  <div className="link-row">
+                    <Tooltip title="sing-box / Hiddify / NekoBox">
+                      <Tag color="cyan" className="link-row-tag">
+                        SING-BOX
+                      </Tag>
+                    </Tooltip>
+                    <a
+                      href={subSingboxLink}
+                      target="_blank"
+                      rel="noopener noreferrer"
+                      className="link-row-title link-row-title-anchor"
+                      title={subSingboxLink}
+                    >
+                      {client.subId}
+                    </a>
+                    <div className="link-row-actions">
+                      <Tooltip title={t('copy')}>
+                        <Button
+                          size="small"
+                          icon={<CopyOutlined />}
+                          aria-label={t('copy')}
+                          onClick={() => copyValue(subSingboxLink)}
+                        />
+                      </Tooltip>
+                      <Tooltip title={t('download')}>
+                        <Button
+                          size="small"
+                          icon={<DownloadOutlined />}
+                          aria-label={t('download')}
+                          loading={downloadingFormat === 'singbox'}
+                          disabled={downloadingFormat !== null}
+                          onClick={() => void downloadSubscription(subSingboxLink, 'singbox')}
+                        />
+                      </Tooltip>
+                      <Popover
+                        trigger="click"
+                        placement="left"
+                        destroyOnHidden
+                        content={
+                          <QrPanel
+                            value={subSingboxLink}
+                            remark={`${client.email} — sing-box`}
+                            size={220}
+                          />
+                        }
+                      >
+                        <Tooltip title={t('pages.clients.qrCode')}>
+                          <Button
+                            size="small"
+                            icon={<QrcodeOutlined />}
+                            aria-label={t('pages.clients.qrCode')}
+                          />
+                        </Tooltip>
+                      </Popover>
+                    </div>
+                  </div>
+                )}
               </>
             )}
 
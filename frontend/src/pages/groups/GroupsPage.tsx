@@ -12,6 +12,7 @@ import {
   Modal,
   Result,
   Row,
+  Select,
   Space,
   Spin,
   Statistic,
@@ -27,11 +28,13 @@ import {
   ClockCircleOutlined,
   DeleteOutlined,
   EditOutlined,
+  ExportOutlined,
   LinkOutlined,
   MoreOutlined,
   PieChartOutlined,
   PlusOutlined,
   RetweetOutlined,
+  ScheduleOutlined,
   TagsOutlined,
   TeamOutlined,
   UsergroupAddOutlined,
@@ -49,6 +52,7 @@ import { setMessageInstance } from '@/utils/messageBus';
 import AppSidebar from '@/layouts/AppSidebar';
 import { LazyMount } from '@/components/utility';
 import { keys } from '@/api/queryKeys';
+import { useOutboundTagGroups } from '@/api/queries/useOutboundTags';
 import {
   ClientRecordSchema,
   GroupSummaryListSchema,
@@ -147,6 +151,35 @@ export default function GroupsPage() {
     },
   });
 
+  const egressMut = useMutation({
+    mutationFn: (body: { name: string; egress: string }) =>
+      HttpUtil.post('/panel/api/clients/groups/egress', body, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const scheduleMut = useMutation({
+    mutationFn: (body: { name: string; schedule: string }) =>
+      HttpUtil.post('/panel/api/clients/groups/schedule', body, JSON_HEADERS),
+    onSuccess: (msg) => {
+      if (msg?.success) invalidate();
+    },
+  });
+
+  const { data: outboundGroups } = useOutboundTagGroups({ excludeBlackhole: true });
+  const egressOptions = useMemo(() => {
+    const outOpts = (outboundGroups?.outbounds ?? []).map((tag) => ({ label: tag, value: tag }));
+    if (!outboundGroups?.balancers.length) return outOpts;
+    return [
+      { label: t('pages.xray.Outbounds'), options: outOpts },
+      {
+        label: t('pages.xray.Balancers'),
+        options: outboundGroups.balancers.map((tag) => ({ label: tag, value: tag })),
+      },
+    ];
+  }, [outboundGroups, t]);
+
   const [createOpen, setCreateOpen] = useState(false);
   const [createName, setCreateName] = useState('');
 
@@ -154,6 +187,12 @@ export default function GroupsPage() {
   const [renameTarget, setRenameTarget] = useState<GroupSummary | null>(null);
   const [renameValue, setRenameValue] = useState('');
 
+  const [egressTarget, setEgressTarget] = useState<GroupSummary | null>(null);
+  const [egressValue, setEgressValue] = useState('');
+
+  const [scheduleTarget, setScheduleTarget] = useState<GroupSummary | null>(null);
+  const [scheduleValue, setScheduleValue] = useState('');
+
   const [subLinksOpen, setSubLinksOpen] = useState(false);
   const [adjustOpen, setAdjustOpen] = useState(false);
   const [addClientsOpen, setAddClientsOpen] = useState(false);
@@ -234,6 +273,34 @@ export default function GroupsPage() {
     }
   }
 
+  function openEgress(g: GroupSummary) {
+    setEgressTarget(g);
+    setEgressValue(g.egress ?? '');
+  }
+
+  async function confirmEgress() {
+    if (!egressTarget) return;
+    const msg = await egressMut.mutateAsync({ name: egressTarget.name, egress: egressValue });
+    if (msg?.success) {
+      messageApi.success(t('pages.groups.egressSuccess', { name: egressTarget.name }));
+      setEgressTarget(null);
+    }
+  }
+
+  function openSchedule(g: GroupSummary) {
+    setScheduleTarget(g);
+    setScheduleValue(g.schedule ?? '');
+  }
+
+  async function confirmSchedule() {
+    if (!scheduleTarget) return;
+    const msg = await scheduleMut.mutateAsync({ name: scheduleTarget.name, schedule: scheduleValue });
+    if (msg?.success) {
+      messageApi.success(t('pages.groups.scheduleSuccess', { name: scheduleTarget.name }));
+      setScheduleTarget(null);
+    }
+  }
+
   function onDelete(g: GroupSummary) {
     modal.confirm({
       title: t('pages.groups.deleteConfirmTitle', { name: g.name }),
@@ -384,6 +451,18 @@ export default function GroupsPage() {
         label: t('pages.groups.rename'),
         onClick: () => openRename(row),
       },
+      {
+        key: 'egress',
+        icon: <ExportOutlined />,
+        label: t('pages.groups.egress'),
+        onClick: () => openEgress(row),
+      },
+      {
+        key: 'schedule',
+        icon: <ScheduleOutlined />,
+        label: t('pages.groups.schedule'),
+        onClick: () => openSchedule(row),
+      },
       { type: 'divider' },
       {
         key: 'removeClients',
@@ -450,6 +529,20 @@ export default function GroupsPage() {
         </Tag>
       ),
     },
+    {
+      title: t('pages.groups.egress'),
+      dataIndex: 'egress',
+      key: 'egress',
+      width: 160,
+      render: (egress?: string) => (egress ? <Tag style={{ margin: 0 }}>{egress}</Tag> : '-'),
+    },
+    {
+      title: t('pages.groups.schedule'),
+      dataIndex: 'schedule',
+      key: 'schedule',
+      width: 200,
+      render: (schedule?: string) => schedule || '-',
+    },
     {
       title: t('pages.groups.clientCount'),
       dataIndex: 'clientCount',
@@ -639,6 +732,54 @@ export default function GroupsPage() {
           </Form>
         </Modal>
 
+        <Modal
+          open={egressTarget !== null}
+          title={egressTarget ? t('pages.groups.egressTitle', { name: egressTarget.name }) : ''}
+          okText={t('save')}
+          cancelText={t('cancel')}
+          confirmLoading={egressMut.isPending}
+          onCancel={() => setEgressTarget(null)}
+          onOk={confirmEgress}
+          destroyOnHidden
+        >
+          <Form layout="vertical">
+            <Form.Item label={t('pages.groups.egress')} extra={t('pages.groups.egressDesc')}>
+              <Select
+                allowClear
+                showSearch
+                value={egressValue || undefined}
+                onChange={(v?: string) => setEgressValue(v ?? '')}
+                placeholder={t('pages.clients.egressPlaceholder')}
+                options={egressOptions}
+              />
+            </Form.Item>
+          </Form>
+        </Modal>
+
+        <Modal
+          open={scheduleTarget !== null}
+          title={scheduleTarget ? t('pages.groups.scheduleTitle', { name: scheduleTarget.name }) : ''}
+          okText={t('save')}
+          cancelText={t('cancel')}
+          confirmLoading={scheduleMut.isPending}
+          onCancel={() => setScheduleTarget(null)}
+          onOk={confirmSchedule}
+          destroyOnHidden
+        >
+          <Form layout="vertical">
+            <Form.Item label={t('pages.groups.schedule')} extra={t('pages.groups.scheduleDesc')}>
+              <Input
+                allowClear
+                value={scheduleValue}
+                onChange={(e) => setScheduleValue(e.target.value)}
+                placeholder="mon-fri 09:00-18:00; sat 10:00-14:00"
+                onPressEnter={confirmSchedule}
+                autoFocus
+              />
+            </Form.Item>
+          </Form>
+        </Modal>
+
         <LazyMount when={subLinksOpen}>
           <SubLinksModal
             open={subLinksOpen}
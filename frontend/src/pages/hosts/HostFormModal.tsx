@@ -400,7 +400,7 @@ export default function HostFormModal({
                               <Select
                                 mode="multiple"
                                 allowClear
-                                options={['raw', 'json', 'clash'].map((v) => ({
+                                options={['raw', 'json', 'clash', 'singbox'].map((v) => ({
                                   value: v,
                                   label: v,
                                 }))}
@@ -595,6 +595,25 @@ export default function InboundFormModal({
         </FormField>
       )}
 
+      {typeof wNodeId === 'number' && selectableNodes.length > 1 && (
+        <FormField
+          name="standbyNodeId"
+          label={labelWithHint(
+            t('pages.inbounds.form.standbyNode'),
+            t('pages.inbounds.form.standbyNodeHelp'),
+          )}
+        >
+          <Select
+            showSearch
+            allowClear
+            placeholder={t('none')}
+            options={selectableNodes
+              .filter((n) => n.id !== wNodeId)
+              .map((n) => ({ value: n.id, label: n.name }))}
+          />
+        </FormField>
+      )}
+
       <FormField name="protocol" label={t('pages.inbounds.protocol')}>
         <Select id="protocol" disabled={mode === 'edit'} options={PROTOCOL_OPTIONS} />
       </FormField>
@@ -649,6 +668,16 @@ export default function InboundFormModal({
         <InputNumber min={1} />
       </FormField>
 
+      <FormField
+        name="trafficCoefficient"
+        label={labelWithHint(
+          t('pages.inbounds.form.trafficCoefficient'),
+          t('pages.inbounds.form.trafficCoefficientHelp'),
+        )}
+      >
+        <InputNumber min={0} max={100} step={0.1} />
+      </FormField>
+
       {protocol === Protocols.VLESS && (
         <FormField
           name="disableFlow"
@@ -158,7 +158,16 @@ export function useInboundColumns({
           if (!node) {
             return <Tag color="orange">node #{record.nodeId}</Tag>;
           }
-          return <Tag color={node.status === 'online' ? 'blue' : 'red'}>{node.name}</Tag>;
+          const tag = <Tag color={node.status === 'online' ? 'blue' : 'red'}>{node.name}</Tag>;
+          if (record.failoverOf == null) return tag;
+          return (
+            <Tooltip title={t('pages.inbounds.standbyCopyHint')}>
+              <span>
+                {tag}
+                <Tag color="gold">{t('pages.inbounds.standbyCopy')}</Tag>
+              </span>
+            </Tooltip>
+          );
         },
       });
     }
@@ -0,0 +1,222 @@
+import { useCallback, useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { Button, Form, Input, InputNumber, Modal, Switch, Table, Tag, Typography } from 'antd';
+import type { ColumnsType } from 'antd/es/table';
+import type { MessageInstance } from 'antd/es/message/interface';
+import { DeleteOutlined } from '@ant-design/icons';
+
+import { ClipboardManager, HttpUtil, IntlUtil } from '@/utils';
+
+interface JoinTokenRow {
+  id: number;
+  name: string;
+  allowPrivateAddress: boolean;
+  expiresAt: number;
+  usedAt: number;
+  nodeId: number;
+}
+
+interface JoinTokenCreated {
+  id: number;
+  token: string;
+  expiresAt: number;
+  masterPin?: string;
+}
+
+interface JoinTokensModalProps {
+  open: boolean;
+  messageApi: MessageInstance;
+  onClose: () => void;
+}
+
+// masterUrl is the URL a node reaches this panel at, as the browser sees it.
+function masterUrl(): string {
+  let basePath = window.X_UI_BASE_PATH || '/';
+  if (!basePath.startsWith('/')) basePath = '/' + basePath;
+  if (!basePath.endsWith('/')) basePath += '/';
+  return window.location.origin + basePath;
+}
+
+// JoinTokensModal mints single-use tokens a new node redeems with `x-ui join`
+// and lists the recent ones with what became of them.
+export default function JoinTokensModal({ open, messageApi, onClose }: JoinTokensModalProps) {
+  const { t } = useTranslation();
+  const [modal, modalContextHolder] = Modal.useModal();
+  const [tokens, setTokens] = useState<JoinTokenRow[]>([]);
+  const [loading, setLoading] = useState(false);
+  const [creating, setCreating] = useState(false);
+  const [name, setName] = useState('');
+  const [ttlMinutes, setTtlMinutes] = useState(30);
+  const [allowPrivate, setAllowPrivate] = useState(false);
+  const [command, setCommand] = useState('');
+
+  const load = useCallback(async () => {
+    setLoading(true);
+    try {
+      const msg = await HttpUtil.get<JoinTokenRow[]>('/panel/api/nodes/joinTokens');
+      if (msg?.success) setTokens(Array.isArray(msg.obj) ? msg.obj : []);
+    } finally {
+      setLoading(false);
+    }
+  }, []);
+
+  useEffect(() => {
+    if (!open) return;
+    setCommand('');
+    void load();
+  }, [open, load]);
+
+  const onCreate = useCallback(async () => {
+    setCreating(true);
+    try {
+      const msg = await HttpUtil.post<JoinTokenCreated>('/panel/api/nodes/joinTokens/add', {
+        name: name.trim(),
+        ttlMinutes,
+        allowPrivateAddress: allowPrivate,
+      });
+      const created = msg?.obj;
+      if (msg?.success && created) {
+        const pin = created.masterPin ? ` -pin ${created.masterPin}` : '';
+        setCommand(`x-ui join${pin} ${masterUrl()} ${created.token}`);
+        setName('');
+        await load();
+      }
+    } finally {
+      setCreating(false);
+    }
+  }, [name, ttlMinutes, allowPrivate, load]);
+
+  const onCopy = useCallback(async () => {
+    if (await ClipboardManager.copyText(command)) {
+      messageApi.success(t('copySuccess'));
+    }
+  }, [command, messageApi, t]);
+
+  const onRevoke = useCallback(
+    (row: JoinTokenRow) => {
+      modal.confirm({
+        title: t('pages.nodes.join.revokeConfirm'),
+        okText: t('delete'),
+        okType: 'danger',
+        cancelText: t('cancel'),
+        onOk: async () => {
+          const msg = await HttpUtil.post(`/panel/api/nodes/joinTokens/del/${row.id}`);
+          if (msg?.success) await load();
+        },
+      });
+    },
+    [modal, t, load],
+  );
+
+  const columns: ColumnsType<JoinTokenRow> = [
+    {
+      title: t('pages.nodes.join.name'),
+      dataIndex: 'name',
+      render: (value: string) => value || '-',
+    },
+    {
+      title: t('status'),
+      key: 'status',
+      render: (_, row) => {
+        if (row.usedAt > 0) return <Tag color="green">{t('pages.nodes.join.used')}</Tag>;
+        if (row.expiresAt <= Date.now()) return <Tag>{t('pages.nodes.join.expired')}</Tag>;
+        return <Tag color="blue">{t('pages.nodes.join.pending')}</Tag>;
+      },
+    },
+    {
+      title: t('pages.nodes.join.expires'),
+      dataIndex: 'expiresAt',
+      render: (value: number) => IntlUtil.formatDate(value),
+    },
+    {
+      title: t('pages.nodes.join.node'),
+      dataIndex: 'nodeId',
+      render: (value: number) => (value > 0 ? `#${value}` : '-'),
+    },
+    {
+      key: 'actions',
+      width: 48,
+      render: (_, row) =>
+        row.usedAt === 0 && row.expiresAt > Date.now() ? (
+          <Button
+            type="text"
+            danger
+            size="small"
+            icon={<DeleteOutlined />}
+            aria-label={t('delete')}
+            onClick={() => onRevoke(row)}
+          />
+        ) : null,
+    },
+  ];
+
+  return (
+    <Modal
+      open={open}
+      title={t('pages.nodes.join.title')}
+      footer={null}
+      width={720}
+      onCancel={onClose}
+      destroyOnHidden
+    >
+      {modalContextHolder}
+      <Typography.Paragraph type="secondary" style={{ marginTop: 0 }}>
+        {t('pages.nodes.join.intro')}
+      </Typography.Paragraph>
+      <Form layout="vertical">
+        <Form.Item label={t('pages.nodes.join.name')}>
+          <Input
+            value={name}
+            onChange={(e) => setName(e.target.value)}
+            placeholder={t('pages.nodes.join.namePlaceholder')}
+          />
+        </Form.Item>
+        <Form.Item label={t('pages.nodes.join.ttl')}>
+          <InputNumber
+            min={1}
+            max={1440}
+            value={ttlMinutes}
+            onChange={(v) => setTtlMinutes(v ?? 30)}
+          />
+        </Form.Item>
+        <Form.Item
+          label={t('pages.nodes.join.allowPrivate')}
+          tooltip={t('pages.nodes.join.allowPrivateHint')}
+        >
+          <Switch checked={allowPrivate} onChange={setAllowPrivate} />
+        </Form.Item>
+        <Button type="primary" onClick={onCreate} loading={creating} block>
+          {t('create')}
+        </Button>
+      </Form>
+
+      {command && (
+        <div style={{ marginTop: 16 }}>
+          <Typography.Paragraph type="secondary" style={{ marginBottom: 4 }}>
+            {t('pages.nodes.join.commandHint')}
+          </Typography.Paragraph>
+          <Input.TextArea
+            readOnly
+            autoSize
+            value={command}
+            style={{ fontFamily: 'monospace' }}
+          />
+          <Button onClick={onCopy} style={{ marginTop: 8 }}>
+            {t('copy')}
+          </Button>
+        </div>
+      )}
+
+      <Table<JoinTokenRow>
+        style={{ marginTop: 16 }}
+        size="small"
+        rowKey="id"
+        loading={loading}
+        columns={columns}
+        dataSource={tokens}
+        pagination={false}
+        scroll={{ x: 'max-content' }}
+      />
+    </Modal>
+  );
+}
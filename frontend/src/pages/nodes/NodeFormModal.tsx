@@ -53,6 +53,7 @@ function defaultValues(): NodeFormValues {
     inboundSyncMode: 'all',
     inboundTags: [],
     outboundTag: '',
+    trafficCoefficient: 1,
   };
 }
 
@@ -119,6 +120,7 @@ export default function NodeFormModal({
             scheme: (node.scheme as 'http' | 'https') || base.scheme,
             inboundSyncMode: (node.inboundSyncMode as 'all' | 'selected') || base.inboundSyncMode,
             inboundTags: node.inboundTags ?? [],
+            trafficCoefficient: node.trafficCoefficient ?? 1,
             apiToken: '',
             hasStoredToken: node.hasApiToken ?? false,
           }
@@ -149,10 +151,14 @@ export default function NodeFormModal({
       enable: values.enable,
       allowPrivateAddress: values.allowPrivateAddress,
       tlsVerifyMode: values.tlsVerifyMode,
-      pinnedCertSha256: values.tlsVerifyMode === 'pin' ? values.pinnedCertSha256.trim() : '',
+      pinnedCertSha256:
+        values.tlsVerifyMode === 'pin' || values.tlsVerifyMode === 'mtls'
+          ? values.pinnedCertSha256.trim()
+          : '',
       inboundSyncMode: values.inboundSyncMode,
       inboundTags: values.inboundSyncMode === 'selected' ? values.inboundTags : [],
       outboundTag: values.outboundTag || '',
+      trafficCoefficient: values.trafficCoefficient,
     };
     if (token) payload.apiToken = token;
     return payload;
@@ -365,11 +371,12 @@ export default function NodeFormModal({
               />
             )}
 
-            {tlsVerifyMode === 'pin' && (
+            {(tlsVerifyMode === 'pin' || tlsVerifyMode === 'mtls') && (
               <FormField
                 label={t('pages.nodes.pinnedCert')}
                 name="pinnedCertSha256"
                 tooltip={t('pages.nodes.pinnedCertHint')}
+                extra={tlsVerifyMode === 'mtls' ? t('pages.nodes.pinnedCertMtlsHint') : undefined}
               >
                 <Input.Search
                   placeholder={t('pages.nodes.pinnedCertPlaceholder')}
@@ -410,6 +417,14 @@ export default function NodeFormModal({
               />
             </FormField>
 
+            <FormField
+              label={t('pages.nodes.trafficCoefficient')}
+              name="trafficCoefficient"
+              tooltip={t('pages.nodes.trafficCoefficientHint')}
+            >
+              <InputNumber min={0} max={100} step={0.1} style={{ width: '100%' }} />
+            </FormField>
+
             <FormField
               label={t('pages.nodes.inboundSyncMode')}
               name="inboundSyncMode"
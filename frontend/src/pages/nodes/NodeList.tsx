@@ -7,12 +7,15 @@ import {
   ApartmentOutlined,
   ClusterOutlined,
   CloudDownloadOutlined,
+  CloudSyncOutlined,
   DeleteOutlined,
   EditOutlined,
   ExclamationCircleOutlined,
+  InboxOutlined,
   EyeInvisibleOutlined,
   EyeOutlined,
   InfoCircleOutlined,
+  KeyOutlined,
   MoreOutlined,
   PlusOutlined,
   RightOutlined,
@@ -36,12 +39,14 @@ interface NodeListProps {
   onSelectionChange: (ids: number[]) => void;
   onAdd: () => void;
   onMtls: () => void;
+  onJoinTokens: () => void;
   onEdit: (node: NodeRecord) => void;
   onDelete: (node: NodeRecord) => void;
   onProbe: (node: NodeRecord) => void;
+  onOutbox: (node: NodeRecord) => void;
   onToggleEnable: (node: NodeRecord, next: boolean) => void;
-  onUpdateNode: (node: NodeRecord) => void;
-  onUpdateSelected: () => void;
+  onUpdateNode: (node: NodeRecord, kind: 'panel' | 'xray') => void;
+  onUpdateSelected: (kind: 'panel' | 'xray') => void;
 }
 
 function isUpdateEligible(n: NodeRecord): boolean {
@@ -167,9 +172,11 @@ export default function NodeList({
   onSelectionChange,
   onAdd,
   onMtls,
+  onJoinTokens,
   onEdit,
   onDelete,
   onProbe,
+  onOutbox,
   onToggleEnable,
   onUpdateNode,
   onUpdateSelected,
@@ -253,6 +260,20 @@ export default function NodeList({
             </Tooltip>
           ) : (
             <Space>
+              {!!record.outboxCount && (
+                <Tooltip title={t('pages.nodes.outbox.title')}>
+                  <Badge count={record.outboxCount} size="small">
+                    <Button
+                      type="text"
+                      size="small"
+                      style={{ fontSize: 16 }}
+                      icon={<InboxOutlined />}
+                      aria-label={t('pages.nodes.outbox.title')}
+                      onClick={() => onOutbox(record)}
+                    />
+                  </Badge>
+                </Tooltip>
+              )}
               <Tooltip title={t('pages.nodes.probe')}>
                 <Button
                   type="text"
@@ -271,7 +292,19 @@ export default function NodeList({
                     style={{ fontSize: 16 }}
                     icon={<CloudDownloadOutlined />}
                     aria-label={t('pages.nodes.updatePanel')}
-                    onClick={() => onUpdateNode(record)}
+                    onClick={() => onUpdateNode(record, 'panel')}
+                  />
+                </Tooltip>
+              )}
+              {isUpdateEligible(record) && (
+                <Tooltip title={t('pages.nodes.updateXray')}>
+                  <Button
+                    type="text"
+                    size="small"
+                    style={{ fontSize: 16 }}
+                    icon={<CloudSyncOutlined />}
+                    aria-label={t('pages.nodes.updateXray')}
+                    onClick={() => onUpdateNode(record, 'xray')}
                   />
                 </Tooltip>
               )}
@@ -431,8 +464,8 @@ export default function NodeList({
                     style={{ margin: 0, cursor: 'pointer' }}
                     role="button"
                     tabIndex={0}
-                    onClick={() => onUpdateNode(record)}
-                    onKeyDown={activateOnKey(() => onUpdateNode(record))}
+                    onClick={() => onUpdateNode(record, 'panel')}
+                    onKeyDown={activateOnKey(() => onUpdateNode(record, 'panel'))}
                   >
                     {t('pages.nodes.updateAvailable')}
                   </Tag>
@@ -523,6 +556,7 @@ export default function NodeList({
       latestVersion,
       onToggleEnable,
       onProbe,
+      onOutbox,
       onEdit,
       onDelete,
       onUpdateNode,
@@ -539,11 +573,19 @@ export default function NodeList({
         <Button icon={<SafetyCertificateOutlined />} onClick={onMtls}>
           {t('pages.nodes.mtls.title')}
         </Button>
+        <Button icon={<KeyOutlined />} onClick={onJoinTokens}>
+          {t('pages.nodes.join.title')}
+        </Button>
         {selectedIds.length > 0 && (
-          <Button icon={<CloudDownloadOutlined />} onClick={onUpdateSelected}>
+          <Button icon={<CloudDownloadOutlined />} onClick={() => onUpdateSelected('panel')}>
             {t('pages.nodes.updateSelected', { count: selectedIds.length })}
           </Button>
         )}
+        {selectedIds.length > 0 && (
+          <Button icon={<CloudSyncOutlined />} onClick={() => onUpdateSelected('xray')}>
+            {t('pages.nodes.updateXraySelected', { count: selectedIds.length })}
+          </Button>
+        )}
       </div>
 
       {isMobile ? (
@@ -623,6 +665,20 @@ export default function NodeList({
                                 ),
                                 onClick: () => onProbe(record),
                               },
+                              ...(record.outboxCount
+                                ? [
+                                    {
+                                      key: 'outbox',
+                                      label: (
+                                        <>
+                                          <InboxOutlined />{' '}
+                                          {`${t('pages.nodes.outbox.title')} (${record.outboxCount})`}
+                                        </>
+                                      ),
+                                      onClick: () => onOutbox(record),
+                                    },
+                                  ]
+                                : []),
                               ...(isUpdateEligible(record)
                                 ? [
                                     {
@@ -632,7 +688,16 @@ export default function NodeList({
                                           <CloudDownloadOutlined /> {t('pages.nodes.updatePanel')}
                                         </>
                                       ),
-                                      onClick: () => onUpdateNode(record),
+                                      onClick: () => onUpdateNode(record, 'panel'),
+                                    },
+                                    {
+                                      key: 'updateXray',
+                                      label: (
+                                        <>
+                                          <CloudSyncOutlined /> {t('pages.nodes.updateXray')}
+                                        </>
+                                      ),
+                                      onClick: () => onUpdateNode(record, 'xray'),
                                     },
                                   ]
                                 : []),
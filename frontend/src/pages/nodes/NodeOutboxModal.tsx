@@ -0,0 +1,182 @@
+import { useCallback, useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { Button, Modal, Space, Table, Tag, Tooltip, Typography } from 'antd';
+import type { ColumnsType } from 'antd/es/table';
+import type { MessageInstance } from 'antd/es/message/interface';
+import { DeleteOutlined, RedoOutlined } from '@ant-design/icons';
+
+import type { NodeRecord } from '@/api/queries/useNodesQuery';
+import { HttpUtil, IntlUtil } from '@/utils';
+
+interface OutboxEntry {
+  id: number;
+  op: string;
+  tag: string;
+  email: string;
+  attempts: number;
+  nextAttemptAt: number;
+  lastError: string;
+  status: 'pending' | 'failed';
+  createdAt: number;
+}
+
+interface NodeOutboxModalProps {
+  node: NodeRecord | null;
+  messageApi: MessageInstance;
+  onClose: () => void;
+}
+
+// Lists a node's queued operations in delivery order; a stuck one can be
+// retried now or discarded in favour of a full reconcile.
+export default function NodeOutboxModal({ node, messageApi, onClose }: NodeOutboxModalProps) {
+  const { t } = useTranslation();
+  const [modal, modalContextHolder] = Modal.useModal();
+  const [entries, setEntries] = useState<OutboxEntry[]>([]);
+  const [loading, setLoading] = useState(false);
+  const nodeId = node?.id;
+
+  const load = useCallback(async () => {
+    if (!nodeId) return;
+    setLoading(true);
+    try {
+      const msg = await HttpUtil.get<OutboxEntry[]>(`/panel/api/nodes/outbox/${nodeId}`);
+      if (msg?.success) setEntries(Array.isArray(msg.obj) ? msg.obj : []);
+    } finally {
+      setLoading(false);
+    }
+  }, [nodeId]);
+
+  useEffect(() => {
+    if (!nodeId) return;
+    setEntries([]);
+    void load();
+  }, [nodeId, load]);
+
+  const onRetry = useCallback(
+    async (row: OutboxEntry) => {
+      const msg = await HttpUtil.post(`/panel/api/nodes/outbox/retry/${row.id}`);
+      if (msg?.success) {
+        messageApi.success(t('pages.nodes.outbox.retryQueued'));
+        await load();
+      }
+    },
+    [messageApi, t, load],
+  );
+
+  const onDiscard = useCallback(
+    (row: OutboxEntry) => {
+      modal.confirm({
+        title: t('pages.nodes.outbox.discardConfirm'),
+        content: t('pages.nodes.outbox.discardHint'),
+        okText: t('delete'),
+        okType: 'danger',
+        cancelText: t('cancel'),
+        onOk: async () => {
+          const msg = await HttpUtil.post(`/panel/api/nodes/outbox/discard/${row.id}`);
+          if (msg?.success) await load();
+        },
+      });
+    },
+    [modal, t, load],
+  );
+
+  const columns: ColumnsType<OutboxEntry> = [
+    {
+      title: '#',
+      dataIndex: 'id',
+      width: 56,
+    },
+    {
+      title: t('pages.nodes.outbox.op'),
+      key: 'op',
+      render: (_, row) => (
+        <Space orientation="vertical" size={0}>
+          <Typography.Text code>{row.op}</Typography.Text>
+          {(row.tag || row.email) && (
+            <Typography.Text type="secondary">
+              {[row.tag, row.email].filter(Boolean).join(' · ')}
+            </Typography.Text>
+          )}
+        </Space>
+      ),
+    },
+    {
+      title: t('status'),
+      key: 'status',
+      render: (_, row) => {
+        const tag =
+          row.status === 'failed' ? (
+            <Tag color="red">{t('pages.nodes.outbox.failed')}</Tag>
+          ) : (
+            <Tag color="blue">{t('pages.nodes.outbox.pending')}</Tag>
+          );
+        return row.lastError ? <Tooltip title={row.lastError}>{tag}</Tooltip> : tag;
+      },
+    },
+    {
+      title: t('pages.nodes.outbox.attempts'),
+      dataIndex: 'attempts',
+      align: 'center',
+    },
+    {
+      title: t('pages.nodes.outbox.nextAttempt'),
+      key: 'nextAttemptAt',
+      render: (_, row) =>
+        row.status === 'failed' || row.nextAttemptAt <= Date.now()
+          ? '-'
+          : IntlUtil.formatDate(row.nextAttemptAt),
+    },
+    {
+      key: 'actions',
+      width: 80,
+      render: (_, row) => (
+        <Space size={0}>
+          <Tooltip title={t('pages.nodes.outbox.retry')}>
+            <Button
+              type="text"
+              size="small"
+              icon={<RedoOutlined />}
+              aria-label={t('pages.nodes.outbox.retry')}
+              onClick={() => void onRetry(row)}
+            />
+          </Tooltip>
+          <Tooltip title={t('delete')}>
+            <Button
+              type="text"
+              danger
+              size="small"
+              icon={<DeleteOutlined />}
+              aria-label={t('delete')}
+              onClick={() => onDiscard(row)}
+            />
+          </Tooltip>
+        </Space>
+      ),
+    },
+  ];
+
+  return (
+    <Modal
+      open={!!node}
+      title={`${t('pages.nodes.outbox.title')}: ${node?.name || ''}`}
+      footer={null}
+      width={760}
+      onCancel={onClose}
+      destroyOnHidden
+    >
+      {modalContextHolder}
+      <Typography.Paragraph type="secondary" style={{ marginTop: 0 }}>
+        {t('pages.nodes.outbox.intro')}
+      </Typography.Paragraph>
+      <Table<OutboxEntry>
+        size="small"
+        rowKey="id"
+        loading={loading}
+        columns={columns}
+        dataSource={entries}
+        pagination={false}
+        scroll={{ x: 'max-content' }}
+      />
+    </Modal>
+  );
+}
@@ -0,0 +1,123 @@
+import { useTranslation } from 'react-i18next';
+import { Alert, Button, Card, Progress, Space, Table, Tag, Tooltip, Typography } from 'antd';
+import type { ColumnsType } from 'antd/es/table';
+import { CloseOutlined, StopOutlined } from '@ant-design/icons';
+
+import type { NodeRollout, NodeRolloutStep } from '@/api/queries/useNodeRolloutsQuery';
+
+const statusColor: Record<string, string> = {
+  running: 'processing',
+  succeeded: 'success',
+  aborted: 'error',
+  cancelled: 'default',
+};
+
+const stateColor: Record<string, string> = {
+  pending: 'default',
+  upgrading: 'processing',
+  done: 'success',
+  failed: 'error',
+  skipped: 'warning',
+};
+
+interface NodeRolloutCardProps {
+  rollout: NodeRollout;
+  onCancel: (rollout: NodeRollout) => void;
+  onDismiss: () => void;
+}
+
+// NodeRolloutCard shows a rolling panel / Xray upgrade, one row per node in batch
+// order, following the master's websocket pushes instead of polling.
+export default function NodeRolloutCard({ rollout, onCancel, onDismiss }: NodeRolloutCardProps) {
+  const { t } = useTranslation();
+  const running = rollout.status === 'running';
+  const steps = [...(rollout.steps ?? [])].sort((a, b) => a.batch - b.batch || a.id - b.id);
+  const finished = steps.filter((s) => s.state === 'done').length;
+
+  const columns: ColumnsType<NodeRolloutStep> = [
+    {
+      title: t('pages.nodes.name'),
+      dataIndex: 'nodeName',
+    },
+    {
+      title: t('pages.nodes.rollout.batchCol'),
+      key: 'batch',
+      render: (_, row) =>
+        row.batch === 0
+          ? t('pages.nodes.rollout.canaryBatch')
+          : t('pages.nodes.rollout.batchN', { n: row.batch }),
+    },
+    {
+      title: t('from'),
+      key: 'from',
+      render: (_, row) => row.fromVersion || '-',
+    },
+    {
+      title: t('status'),
+      key: 'state',
+      render: (_, row) => {
+        const tag = (
+          <Tag color={stateColor[row.state] ?? 'default'}>
+            {t(`pages.nodes.rollout.state.${row.state}`)}
+          </Tag>
+        );
+        return row.error ? <Tooltip title={row.error}>{tag}</Tooltip> : tag;
+      },
+    },
+  ];
+
+  const target =
+    rollout.kind === 'xray'
+      ? t('pages.nodes.rollout.xray', { version: rollout.targetVersion })
+      : t('pages.nodes.rollout.panel', { version: rollout.targetVersion });
+
+  return (
+    <Card
+      size="small"
+      hoverable
+      title={
+        <Space>
+          {t('pages.nodes.rollout.title')}
+          <Typography.Text type="secondary">{target}</Typography.Text>
+          <Tag color={statusColor[rollout.status] ?? 'default'}>
+            {t(`pages.nodes.rollout.status.${rollout.status}`)}
+          </Tag>
+        </Space>
+      }
+      extra={
+        running ? (
+          <Button size="small" danger icon={<StopOutlined />} onClick={() => onCancel(rollout)}>
+            {t('pages.nodes.rollout.cancel')}
+          </Button>
+        ) : (
+          <Tooltip title={t('close')}>
+            <Button
+              type="text"
+              size="small"
+              icon={<CloseOutlined />}
+              aria-label={t('close')}
+              onClick={onDismiss}
+            />
+          </Tooltip>
+        )
+      }
+    >
+      <Progress
+        percent={steps.length ? Math.round((finished / steps.length) * 100) : 0}
+        status={rollout.status === 'aborted' ? 'exception' : running ? 'active' : 'normal'}
+        format={() => t('pages.nodes.rollout.progress', { done: finished, total: steps.length })}
+      />
+      {rollout.error && (
+        <Alert type="error" showIcon style={{ margin: '8px 0' }} title={rollout.error} />
+      )}
+      <Table<NodeRolloutStep>
+        size="small"
+        rowKey="id"
+        columns={columns}
+        dataSource={steps}
+        pagination={false}
+        scroll={{ x: 'max-content' }}
+      />
+    </Card>
+  );
+}
@@ -9,10 +9,13 @@ import {
   Col,
   ConfigProvider,
   Input,
+  InputNumber,
   Layout,
   Modal,
   Result,
   Row,
+  Select,
+  Space,
   Spin,
   Statistic,
   Typography,
@@ -30,40 +33,108 @@ import { useMediaQuery } from '@/hooks/useMediaQuery';
 import { useNodesQuery } from '@/api/queries/useNodesQuery';
 import type { NodeRecord } from '@/api/queries/useNodesQuery';
 import { useNodeMutations } from '@/api/queries/useNodeMutations';
+import { useNodeRolloutsQuery, type NodeRollout } from '@/api/queries/useNodeRolloutsQuery';
 import AppSidebar from '@/layouts/AppSidebar';
 import NodeList from './NodeList';
 import NodeFormModal from './NodeFormModal';
+import JoinTokensModal from './JoinTokensModal';
+import NodeOutboxModal from './NodeOutboxModal';
+import NodeRolloutCard from './NodeRolloutCard';
 import { setMessageInstance } from '@/utils/messageBus';
 import { HttpUtil } from '@/utils';
 import type { PanelUpdateInfo } from '../index/PanelUpdateModal';
 
-// Confirm-dialog body that lets the operator pick the stable or dev channel for
-// a node panel update. Reports changes via onChange so the imperative
-// modal.confirm onOk can read the latest choice through a ref.
-function UpdateChannelChoice({ onChange }: { onChange: (dev: boolean) => void }) {
+interface RolloutChoiceValue {
+  dev: boolean;
+  version: string;
+  canarySize: number;
+  batchSize: number;
+}
+
+const defaultRolloutChoice: RolloutChoiceValue = {
+  dev: false,
+  version: '',
+  canarySize: 1,
+  batchSize: 5,
+};
+
+// Confirm-dialog body for a rolling node update. Reports changes via onChange so
+// the imperative modal.confirm onOk can read the latest choice through a ref.
+function RolloutChoice({
+  kind,
+  count,
+  onChange,
+}: {
+  kind: 'panel' | 'xray';
+  count: number;
+  onChange: (v: RolloutChoiceValue) => void;
+}) {
   const { t } = useTranslation();
-  const [dev, setDev] = useState(false);
+  const [value, setValue] = useState(defaultRolloutChoice);
+  const { data: versions = [], isLoading } = useQuery({
+    queryKey: ['server', 'xrayVersions'],
+    queryFn: async () => {
+      const msg = await HttpUtil.get<string[]>('/panel/api/server/getXrayVersion');
+      return msg?.success && Array.isArray(msg.obj) ? msg.obj : [];
+    },
+    enabled: kind === 'xray',
+    staleTime: 5 * 60 * 1000,
+  });
+  const set = (patch: Partial<RolloutChoiceValue>) => setValue((prev) => ({ ...prev, ...patch }));
+  useEffect(() => {
+    onChange(value);
+  }, [value, onChange]);
+  // Preselect the newest release so the common case is a single click.
+  useEffect(() => {
+    if (versions.length === 0) return;
+    setValue((prev) => (prev.version ? prev : { ...prev, version: versions[0] }));
+  }, [versions]);
   return (
-    <div>
-      <p>{t('pages.nodes.updateConfirmContent')}</p>
-      <Checkbox
-        checked={dev}
-        onChange={(e) => {
-          setDev(e.target.checked);
-          onChange(e.target.checked);
-        }}
-      >
-        {t('pages.nodes.updateDevChannel')}
-      </Checkbox>
-      {dev && (
-        <Alert
-          type="info"
-          showIcon
-          style={{ marginTop: 8 }}
-          title={t('pages.index.devChannelWarning')}
+    <Space orientation="vertical" style={{ width: '100%' }}>
+      <span>
+        {kind === 'xray'
+          ? t('pages.nodes.rollout.xrayConfirmContent')
+          : t('pages.nodes.updateConfirmContent')}
+      </span>
+      {kind === 'xray' ? (
+        <Select
+          style={{ width: '100%' }}
+          loading={isLoading}
+          placeholder={t('pages.nodes.rollout.version')}
+          value={value.version || undefined}
+          options={versions.map((v) => ({ value: v, label: v }))}
+          onChange={(v: string) => set({ version: v })}
         />
+      ) : (
+        <>
+          <Checkbox checked={value.dev} onChange={(e) => set({ dev: e.target.checked })}>
+            {t('pages.nodes.updateDevChannel')}
+          </Checkbox>
+          {value.dev && <Alert type="info" showIcon title={t('pages.index.devChannelWarning')} />}
+        </>
+      )}
+      {count > 1 && (
+        <>
+          <Space wrap>
+            <InputNumber
+              prefix={t('pages.nodes.rollout.canary')}
+              min={1}
+              max={count}
+              value={value.canarySize}
+              onChange={(v) => set({ canarySize: v ?? 1 })}
+            />
+            <InputNumber
+              prefix={t('pages.nodes.rollout.batch')}
+              min={1}
+              max={50}
+              value={value.batchSize}
+              onChange={(v) => set({ batchSize: v ?? 1 })}
+            />
+          </Space>
+          <Typography.Text type="secondary">{t('pages.nodes.rollout.canaryHint')}</Typography.Text>
+        </>
       )}
-    </div>
+    </Space>
   );
 }
 
@@ -88,7 +159,14 @@ export default function NodesPage() {
     fetchInbounds,
     probe,
     updatePanels,
+    updateXray,
+    cancelRollout,
   } = useNodeMutations();
+  const { data: rollouts = [] } = useNodeRolloutsQuery();
+  const [dismissedRollout, setDismissedRollout] = useState(0);
+  const latestRollout = rollouts[0];
+  const shownRollout =
+    latestRollout && latestRollout.id !== dismissedRollout ? latestRollout : undefined;
 
   const { data: latestVersion = '' } = useQuery({
     queryKey: ['server', 'panelUpdateInfo'],
@@ -104,6 +182,8 @@ export default function NodesPage() {
   const [formNode, setFormNode] = useState<NodeRecord | null>(null);
   const [selectedIds, setSelectedIds] = useState<number[]>([]);
   const [mtlsOpen, setMtlsOpen] = useState(false);
+  const [joinOpen, setJoinOpen] = useState(false);
+  const [outboxNode, setOutboxNode] = useState<NodeRecord | null>(null);
   const [trustCa, setTrustCa] = useState('');
   const [copyingCa, setCopyingCa] = useState(false);
   const [savingTrustCa, setSavingTrustCa] = useState(false);
@@ -206,73 +286,82 @@ export default function NodesPage() {
     [setEnable],
   );
 
-  const devRef = useRef(false);
+  const choiceRef = useRef<RolloutChoiceValue>(defaultRolloutChoice);
 
-  const runUpdate = useCallback(
-    async (ids: number[], dev: boolean) => {
-      const msg = await updatePanels(ids, dev);
-      if (!msg?.success) {
-        messageApi.error(msg?.msg || t('somethingWentWrong'));
+  const runRollout = useCallback(
+    async (kind: 'panel' | 'xray', ids: number[]) => {
+      const { dev, version, canarySize, batchSize } = choiceRef.current;
+      if (kind === 'xray' && !version) {
+        messageApi.warning(t('pages.nodes.rollout.version'));
         return;
       }
-      const results = msg.obj ?? [];
-      const ok = results.filter((r) => r.ok).length;
-      const failed = results.length - ok;
-      if (failed === 0) {
-        messageApi.success(t('pages.nodes.toasts.updateStarted'));
-      } else {
-        const firstError = results.find((r) => !r.ok)?.error ?? '';
-        const base = t('pages.nodes.toasts.updateResult', { ok, failed });
-        messageApi.warning(firstError ? `${base} — ${firstError}` : base);
-      }
+      const opts = { canarySize, batchSize };
+      const msg =
+        kind === 'xray' ? await updateXray(ids, version, opts) : await updatePanels(ids, dev, opts);
+      if (!msg?.success) return;
+      setDismissedRollout(0);
       setSelectedIds([]);
     },
-    [updatePanels, messageApi, t],
+    [updatePanels, updateXray, messageApi, t],
   );
 
-  const onUpdateNode = useCallback(
-    (node: NodeRecord) => {
-      devRef.current = false;
+  const confirmRollout = useCallback(
+    (kind: 'panel' | 'xray', ids: number[]) => {
+      choiceRef.current = defaultRolloutChoice;
       modal.confirm({
-        title: t('pages.nodes.updateConfirmTitle', { count: 1 }),
+        title:
+          kind === 'xray'
+            ? t('pages.nodes.rollout.xrayConfirmTitle', { count: ids.length })
+            : t('pages.nodes.updateConfirmTitle', { count: ids.length }),
         content: (
-          <UpdateChannelChoice
+          <RolloutChoice
+            kind={kind}
+            count={ids.length}
             onChange={(v) => {
-              devRef.current = v;
+              choiceRef.current = v;
             }}
           />
         ),
         okText: t('update'),
         cancelText: t('cancel'),
-        onOk: () => runUpdate([node.id], devRef.current),
+        onOk: () => runRollout(kind, ids),
       });
     },
-    [modal, t, runUpdate],
+    [modal, t, runRollout],
   );
 
-  const onUpdateSelected = useCallback(() => {
-    const eligible = nodes
-      .filter((n) => selectedIds.includes(n.id) && n.enable && n.status === 'online')
-      .map((n) => n.id);
-    if (eligible.length === 0) {
-      messageApi.warning(t('pages.nodes.toasts.updateNoneEligible'));
-      return;
-    }
-    devRef.current = false;
-    modal.confirm({
-      title: t('pages.nodes.updateConfirmTitle', { count: eligible.length }),
-      content: (
-        <UpdateChannelChoice
-          onChange={(v) => {
-            devRef.current = v;
-          }}
-        />
-      ),
-      okText: t('update'),
-      cancelText: t('cancel'),
-      onOk: () => runUpdate(eligible, devRef.current),
-    });
-  }, [modal, t, nodes, selectedIds, runUpdate, messageApi]);
+  const onUpdateNode = useCallback(
+    (node: NodeRecord, kind: 'panel' | 'xray') => confirmRollout(kind, [node.id]),
+    [confirmRollout],
+  );
+
+  const onUpdateSelected = useCallback(
+    (kind: 'panel' | 'xray') => {
+      const eligible = nodes
+        .filter((n) => selectedIds.includes(n.id) && n.enable && n.status === 'online')
+        .map((n) => n.id);
+      if (eligible.length === 0) {
+        messageApi.warning(t('pages.nodes.toasts.updateNoneEligible'));
+        return;
+      }
+      confirmRollout(kind, eligible);
+    },
+    [nodes, selectedIds, confirmRollout, messageApi, t],
+  );
+
+  const onCancelRollout = useCallback(
+    (rollout: NodeRollout) => {
+      modal.confirm({
+        title: t('pages.nodes.rollout.cancelConfirm'),
+        content: t('pages.nodes.rollout.cancelHint'),
+        okText: t('pages.nodes.rollout.cancel'),
+        okType: 'danger',
+        cancelText: t('close'),
+        onOk: () => cancelRollout(rollout.id),
+      });
+    },
+    [modal, t, cancelRollout],
+  );
 
   const pageClass = useMemo(() => {
     const classes = ['nodes-page'];
@@ -345,6 +434,16 @@ export default function NodesPage() {
                     </Card>
                   </Col>
 
+                  {shownRollout && (
+                    <Col span={24}>
+                      <NodeRolloutCard
+                        rollout={shownRollout}
+                        onCancel={onCancelRollout}
+                        onDismiss={() => setDismissedRollout(shownRollout.id)}
+                      />
+                    </Col>
+                  )}
+
                   <Col span={24}>
                     <NodeList
                       nodes={nodes}
@@ -355,9 +454,11 @@ export default function NodesPage() {
                       onSelectionChange={setSelectedIds}
                       onAdd={onAdd}
                       onMtls={() => setMtlsOpen(true)}
+                      onJoinTokens={() => setJoinOpen(true)}
                       onEdit={onEdit}
                       onDelete={onDelete}
                       onProbe={onProbe}
+                      onOutbox={setOutboxNode}
                       onToggleEnable={onToggleEnable}
                       onUpdateNode={onUpdateNode}
                       onUpdateSelected={onUpdateSelected}
@@ -411,6 +512,25 @@ export default function NodesPage() {
             {t('pages.nodes.mtls.save')}
           </Button>
         </Modal>
+
+        <JoinTokensModal
+          open={joinOpen}
+          messageApi={messageApi}
+          onClose={() => {
+            setJoinOpen(false);
+            // Nodes that joined while the modal was open show up now.
+            refetch();
+          }}
+        />
+
+        <NodeOutboxModal
+          node={outboxNode}
+          messageApi={messageApi}
+          onClose={() => {
+            setOutboxNode(null);
+            refetch();
+          }}
+        />
       </Layout>
     </ConfigProvider>
   );
@@ -0,0 +1,139 @@
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { Alert, Input, Modal, Select, Typography, message } from 'antd';
+
+import { useClientOptions } from '@/api/queries/useClientOptions';
+import { usePlanMutations } from '@/api/queries/usePlanMutations';
+import type { Plan } from '@/api/queries/usePlansQuery';
+
+interface PlanApplyModalProps {
+  open: boolean;
+  mode: 'create' | 'renew';
+  plan: Plan | null;
+  onClose: () => void;
+}
+
+interface SkippedClient {
+  email: string;
+  reason: string;
+}
+
+// PlanApplyModal provisions new clients from a plan (one email per line) or
+// renews existing ones picked from the client list.
+export default function PlanApplyModal({ open, mode, plan, onClose }: PlanApplyModalProps) {
+  const { t } = useTranslation();
+  const [messageApi, messageContextHolder] = message.useMessage();
+  const { createClients, renewClients } = usePlanMutations();
+  const clientOptions = useClientOptions(open && mode === 'renew');
+  const [emailsText, setEmailsText] = useState('');
+  const [selected, setSelected] = useState<string[]>([]);
+  const [skipped, setSkipped] = useState<SkippedClient[]>([]);
+  const [saving, setSaving] = useState(false);
+
+  // Reset during render so the first frame of a reopened modal is clean.
+  const [wasOpen, setWasOpen] = useState(false);
+  if (open !== wasOpen) {
+    setWasOpen(open);
+    if (open) {
+      setEmailsText('');
+      setSelected([]);
+      setSkipped([]);
+    }
+  }
+
+  const emails =
+    mode === 'create'
+      ? emailsText
+          .split(/\r?\n/)
+          .map((e) => e.trim())
+          .filter(Boolean)
+      : selected;
+
+  async function submit() {
+    if (!plan || emails.length === 0) return;
+    setSaving(true);
+    try {
+      if (mode === 'create') {
+        const msg = await createClients(plan.id, emails);
+        if (!msg?.success) return;
+        const result = msg.obj ?? { created: 0 };
+        const rest = result.skipped ?? [];
+        messageApi.success(
+          t('pages.plans.createResult', { created: result.created, skipped: rest.length }),
+        );
+        if (rest.length === 0) onClose();
+        setSkipped(rest);
+      } else {
+        const msg = await renewClients(plan.id, emails);
+        if (!msg?.success) return;
+        const result = msg.obj ?? { renewed: 0 };
+        const rest = result.skipped ?? [];
+        messageApi.success(
+          t('pages.plans.renewResult', { renewed: result.renewed, skipped: rest.length }),
+        );
+        if (rest.length === 0) onClose();
+        setSkipped(rest);
+      }
+    } finally {
+      setSaving(false);
+    }
+  }
+
+  const title =
+    mode === 'create' ? t('pages.plans.createClients') : t('pages.plans.renewClients');
+
+  return (
+    <Modal
+      open={open}
+      title={`${title}: ${plan?.name ?? ''}`}
+      okText={mode === 'create' ? t('create') : t('pages.plans.renew')}
+      cancelText={t('cancel')}
+      okButtonProps={{ disabled: emails.length === 0, loading: saving }}
+      onOk={submit}
+      onCancel={onClose}
+    >
+      {messageContextHolder}
+      <Typography.Paragraph type="secondary">
+        {mode === 'create'
+          ? t('pages.plans.createClientsDesc')
+          : t(`pages.plans.renewModeHints.${plan?.renewMode ?? 'extend'}`)}
+      </Typography.Paragraph>
+      {mode === 'create' ? (
+        <Input.TextArea
+          rows={8}
+          value={emailsText}
+          placeholder={'alice@example.com\nbob@example.com'}
+          onChange={(e) => setEmailsText(e.target.value)}
+        />
+      ) : (
+        <Select
+          mode="multiple"
+          allowClear
+          style={{ width: '100%' }}
+          value={selected}
+          loading={clientOptions.isFetching}
+          placeholder={t('pages.plans.pickClients')}
+          options={(clientOptions.data ?? []).map((email) => ({ value: email, label: email }))}
+          onChange={setSelected}
+        />
+      )}
+      {skipped.length > 0 && (
+        <Alert
+          type="warning"
+          showIcon
+          style={{ marginTop: 12 }}
+          title={t('pages.plans.skipped')}
+          description={
+            <ul style={{ margin: 0, paddingInlineStart: 18 }}>
+              {skipped.map((s) => (
+                <li key={s.email}>
+                  <code>{s.email}</code>: {s.reason}
+                </li>
+              ))}
+            </ul>
+          }
+        />
+      )}
+    </Modal>
+  );
+}
@@ -0,0 +1,408 @@
+import { lazy, useEffect, useMemo, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import {
+  Button,
+  Card,
+  Col,
+  ConfigProvider,
+  Form,
+  Input,
+  InputNumber,
+  Layout,
+  Modal,
+  Popconfirm,
+  Result,
+  Row,
+  Select,
+  Space,
+  Spin,
+  Switch,
+  Table,
+  Tag,
+  Tooltip,
+  message,
+} from 'antd';
+import type { TableColumnsType } from 'antd';
+import {
+  DeleteOutlined,
+  EditOutlined,
+  GiftOutlined,
+  PlusOutlined,
+  ProfileOutlined,
+  RetweetOutlined,
+  UserAddOutlined,
+} from '@ant-design/icons';
+
+import { useTheme } from '@/hooks/useTheme';
+import { useMediaQuery } from '@/hooks/useMediaQuery';
+import { usePageTitle } from '@/hooks/usePageTitle';
+import { SizeFormatter } from '@/utils';
+import { setMessageInstance } from '@/utils/messageBus';
+import AppSidebar from '@/layouts/AppSidebar';
+import { LazyMount } from '@/components/utility';
+import { TRAFFIC_RESETS } from '@/schemas/primitives';
+import { PLAN_RENEW_MODES, PlanFormSchema, type PlanFormValues } from '@/schemas/plan';
+import { usePlansQuery, type Plan } from '@/api/queries/usePlansQuery';
+import { usePlanMutations } from '@/api/queries/usePlanMutations';
+import { useInboundOptions } from '@/api/queries/useInboundOptions';
+
+import VouchersCard from './VouchersCard';
+
+const PlanApplyModal = lazy(() => import('./PlanApplyModal'));
+const VoucherGenerateModal = lazy(() => import('./VoucherGenerateModal'));
+
+// The form edits traffic in GB; the API stores bytes.
+type PlanFormFields = PlanFormValues & { totalGBInput: number };
+
+export default function PlansPage() {
+  usePageTitle();
+  const { t } = useTranslation();
+  const { isDark, isUltra, antdThemeConfig } = useTheme();
+  const { isMobile } = useMediaQuery();
+  const [messageApi, messageContextHolder] = message.useMessage();
+  useEffect(() => {
+    setMessageInstance(messageApi);
+  }, [messageApi]);
+
+  const { plans, loading, fetched, error, refetch } = usePlansQuery();
+  const { add, update, remove } = usePlanMutations();
+  const inboundOptions = useInboundOptions();
+  const [form] = Form.useForm<PlanFormFields>();
+  const trafficReset = Form.useWatch('trafficReset', form);
+  const [editing, setEditing] = useState<Plan | null>(null);
+  const [formOpen, setFormOpen] = useState(false);
+  const [submitting, setSubmitting] = useState(false);
+  const [applyMode, setApplyMode] = useState<'create' | 'renew'>('create');
+  const [applyPlan, setApplyPlan] = useState<Plan | null>(null);
+  const [voucherPlan, setVoucherPlan] = useState<Plan | null>(null);
+
+  const inboundLabel = useMemo(() => {
+    const byId = new Map((inboundOptions.data ?? []).map((ib) => [ib.id, ib]));
+    return (id: number) => {
+      const ib = byId.get(id);
+      return ib?.remark || ib?.tag || `#${id}`;
+    };
+  }, [inboundOptions.data]);
+
+  function openForm(plan: Plan | null) {
+    setEditing(plan);
+    const values = PlanFormSchema.parse({
+      ...(plan ?? { name: '' }),
+      comment: plan?.comment ?? '',
+      group: plan?.group ?? '',
+      inboundIds: plan?.inboundIds ?? [],
+      trafficReset: plan?.trafficReset || 'never',
+      trafficResetDay: plan?.trafficResetDay || 1,
+    });
+    form.setFieldsValue({ ...values, totalGBInput: values.totalGB / SizeFormatter.ONE_GB });
+    setFormOpen(true);
+  }
+
+  async function submit() {
+    const { totalGBInput, ...fields } = await form.validateFields();
+    const payload = PlanFormSchema.parse({
+      ...fields,
+      totalGB: Math.round((Number(totalGBInput) || 0) * SizeFormatter.ONE_GB),
+    });
+    setSubmitting(true);
+    try {
+      const msg = editing ? await update(editing.id, payload) : await add(payload);
+      if (msg?.success) setFormOpen(false);
+    } finally {
+      setSubmitting(false);
+    }
+  }
+
+  function openApply(plan: Plan, mode: 'create' | 'renew') {
+    setApplyMode(mode);
+    setApplyPlan(plan);
+  }
+
+  const columns: TableColumnsType<Plan> = [
+    {
+      key: 'actions',
+      width: 180,
+      render: (_, plan) => (
+        <Space size={4}>
+          <Tooltip title={t('edit')}>
+            <Button size="small" icon={<EditOutlined />} onClick={() => openForm(plan)} />
+          </Tooltip>
+          <Tooltip title={t('pages.plans.createClients')}>
+            <Button
+              size="small"
+              icon={<UserAddOutlined />}
+              disabled={!plan.inboundIds?.length}
+              onClick={() => openApply(plan, 'create')}
+            />
+          </Tooltip>
+          <Tooltip title={t('pages.plans.renewClients')}>
+            <Button
+              size="small"
+              icon={<RetweetOutlined />}
+              onClick={() => openApply(plan, 'renew')}
+            />
+          </Tooltip>
+          <Tooltip title={t('pages.plans.generateVouchers')}>
+            <Button size="small" icon={<GiftOutlined />} onClick={() => setVoucherPlan(plan)} />
+          </Tooltip>
+          <Popconfirm
+            title={t('pages.plans.deleteConfirm', { name: plan.name })}
+            okText={t('delete')}
+            cancelText={t('cancel')}
+            okButtonProps={{ danger: true }}
+            onConfirm={() => remove(plan.id)}
+          >
+            <Button size="small" danger icon={<DeleteOutlined />} />
+          </Popconfirm>
+        </Space>
+      ),
+    },
+    {
+      title: t('pages.plans.name'),
+      key: 'name',
+      render: (_, plan) => (
+        <Tooltip title={plan.comment}>
+          <Tag color="purple" style={{ margin: 0, fontSize: 13 }}>
+            {plan.name}
+          </Tag>
+        </Tooltip>
+      ),
+    },
+    {
+      title: t('pages.plans.traffic'),
+      key: 'traffic',
+      render: (_, plan) =>
+        plan.totalGB > 0 ? SizeFormatter.sizeFormat(plan.totalGB) : t('unlimited'),
+    },
+    {
+      title: t('pages.clients.duration'),
+      key: 'duration',
+      render: (_, plan) =>
+        plan.durationDays > 0 ? (
+          <Space size={4}>
+            {t('pages.plans.days', { count: plan.durationDays })}
+            {plan.startAfterFirstUse && <Tag>{t('pages.clients.delayedStart')}</Tag>}
+          </Space>
+        ) : (
+          t('unlimited')
+        ),
+    },
+    {
+      title: t('pages.clients.attachedInbounds'),
+      key: 'inbounds',
+      render: (_, plan) => (
+        <Space size={[4, 4]} wrap>
+          {(plan.inboundIds ?? []).map((id) => (
+            <Tag key={id}>{inboundLabel(id)}</Tag>
+          ))}
+        </Space>
+      ),
+    },
+    {
+      title: t('pages.clients.group'),
+      key: 'group',
+      render: (_, plan) => (plan.group ? <Tag color="geekblue">{plan.group}</Tag> : '—'),
+    },
+    {
+      title: t('pages.plans.renewMode'),
+      key: 'renewMode',
+      render: (_, plan) => <Tag color="blue">{t(`pages.plans.renewModes.${plan.renewMode}`)}</Tag>,
+    },
+  ];
+
+  const pageClass = useMemo(() => {
+    const classes = ['plans-page'];
+    if (isDark) classes.push('is-dark');
+    if (isUltra) classes.push('is-ultra');
+    return classes.join(' ');
+  }, [isDark, isUltra]);
+
+  return (
+    <ConfigProvider theme={antdThemeConfig}>
+      {messageContextHolder}
+      <Layout className={pageClass}>
+        <AppSidebar />
+        <Layout className="content-shell">
+          <Layout.Content id="content-layout" className="content-area">
+            <Spin spinning={!fetched} delay={200} description={t('loading')} size="large">
+              {!fetched ? (
+                <div className="loading-spacer" />
+              ) : error ? (
+                <Result
+                  status="error"
+                  title={t('somethingWentWrong')}
+                  subTitle={error}
+                  extra={
+                    <Button type="primary" loading={loading} onClick={() => refetch()}>
+                      {t('refresh')}
+                    </Button>
+                  }
+                />
+              ) : (
+                <Row gutter={[isMobile ? 8 : 16, isMobile ? 8 : 12]}>
+                  <Col span={24}>
+                    <Card
+                      size="small"
+                      hoverable
+                      title={
+                        <div className="card-toolbar">
+                          <Button
+                            aria-label={t('pages.plans.addPlan')}
+                            type="primary"
+                            icon={<PlusOutlined />}
+                            onClick={() => openForm(null)}
+                          >
+                            {!isMobile && t('pages.plans.addPlan')}
+                          </Button>
+                        </div>
+                      }
+                    >
+                      <Table<Plan>
+                        dataSource={plans}
+                        columns={columns}
+                        rowKey="id"
+                        size="small"
+                        pagination={false}
+                        loading={loading}
+                        scroll={{ x: 'max-content' }}
+                        locale={{
+                          emptyText: (
+                            <div className="card-empty">
+                              <ProfileOutlined style={{ fontSize: 32, marginBottom: 8 }} />
+                              <div>{t('noData')}</div>
+                            </div>
+                          ),
+                        }}
+                      />
+                    </Card>
+                  </Col>
+                  <Col span={24}>
+                    <VouchersCard plans={plans} />
+                  </Col>
+                </Row>
+              )}
+            </Spin>
+          </Layout.Content>
+        </Layout>
+
+        <Modal
+          open={formOpen}
+          title={editing ? t('pages.plans.editPlan') : t('pages.plans.addPlan')}
+          okText={editing ? t('save') : t('create')}
+          cancelText={t('cancel')}
+          confirmLoading={submitting}
+          onOk={submit}
+          onCancel={() => setFormOpen(false)}
+          destroyOnHidden
+        >
+          <Form<PlanFormFields> form={form} layout="vertical">
+            <Form.Item
+              name="name"
+              label={t('pages.plans.name')}
+              rules={[{ required: true, max: 64 }]}
+            >
+              <Input />
+            </Form.Item>
+            <Form.Item name="comment" label={t('comment')}>
+              <Input />
+            </Form.Item>
+            <Form.Item
+              name="inboundIds"
+              label={t('pages.clients.attachedInbounds')}
+              extra={t('pages.plans.inboundsDesc')}
+            >
+              <Select
+                mode="multiple"
+                allowClear
+                loading={inboundOptions.isFetching}
+                placeholder={t('pages.clients.selectInbound')}
+                options={(inboundOptions.data ?? []).map((ib) => ({
+                  value: ib.id,
+                  label: inboundLabel(ib.id),
+                }))}
+              />
+            </Form.Item>
+            <Form.Item
+              name="totalGBInput"
+              label={t('pages.clients.totalGB')}
+              extra={t('pages.clients.totalGBDesc')}
+            >
+              <InputNumber min={0} step={1} style={{ width: '100%' }} />
+            </Form.Item>
+            <Space size="large" wrap>
+              <Form.Item name="durationDays" label={t('pages.clients.expireDays')}>
+                <InputNumber min={0} max={36500} />
+              </Form.Item>
+              <Form.Item
+                name="startAfterFirstUse"
+                label={t('pages.clients.delayedStart')}
+                valuePropName="checked"
+              >
+                <Switch />
+              </Form.Item>
+            </Space>
+            <Space size="large" wrap>
+              <Form.Item name="limitIp" label={t('pages.clients.limitIp')}>
+                <InputNumber min={0} />
+              </Form.Item>
+              <Form.Item name="limitHwid" label={t('pages.clients.limitHwid')}>
+                <InputNumber min={0} />
+              </Form.Item>
+            </Space>
+            <Space size="large" wrap>
+              <Form.Item name="trafficReset" label={t('pages.inbounds.periodicTrafficResetTitle')}>
+                <Select
+                  style={{ minWidth: 160 }}
+                  options={TRAFFIC_RESETS.map((r) => ({
+                    value: r,
+                    label: t(`pages.inbounds.periodicTrafficReset.${r}`),
+                  }))}
+                />
+              </Form.Item>
+              {trafficReset === 'monthly' && (
+                <Form.Item
+                  name="trafficResetDay"
+                  label={t('pages.inbounds.periodicTrafficResetDay')}
+                >
+                  <InputNumber min={1} max={31} />
+                </Form.Item>
+              )}
+            </Space>
+            <Form.Item name="group" label={t('pages.clients.group')}>
+              <Input placeholder={t('pages.clients.groupPlaceholder')} />
+            </Form.Item>
+            <Form.Item
+              name="renewMode"
+              label={t('pages.plans.renewMode')}
+              extra={t('pages.plans.renewModeDesc')}
+            >
+              <Select
+                options={PLAN_RENEW_MODES.map((m) => ({
+                  value: m,
+                  label: t(`pages.plans.renewModes.${m}`),
+                }))}
+              />
+            </Form.Item>
+          </Form>
+        </Modal>
+
+        <LazyMount when={applyPlan !== null}>
+          <PlanApplyModal
+            open={applyPlan !== null}
+            mode={applyMode}
+            plan={applyPlan}
+            onClose={() => setApplyPlan(null)}
+          />
+        </LazyMount>
+
+        <LazyMount when={voucherPlan !== null}>
+          <VoucherGenerateModal
+            open={voucherPlan !== null}
+            plan={voucherPlan}
+            onClose={() => setVoucherPlan(null)}
+          />
+        </LazyMount>
+      </Layout>
+    </ConfigProvider>
+  );
+}
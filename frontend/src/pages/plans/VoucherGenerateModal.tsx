@@ -0,0 +1,144 @@
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { Button, Form, Input, InputNumber, Modal, Select, Space, message } from 'antd';
+import { CopyOutlined } from '@ant-design/icons';
+import dayjs from 'dayjs';
+
+import { ClipboardManager } from '@/utils';
+import { DateTimePicker } from '@/components/form';
+import { useVoucherMutations } from '@/api/queries/useVoucherMutations';
+import type { Plan } from '@/api/queries/usePlansQuery';
+import {
+  VOUCHER_KINDS,
+  VoucherGenerateFormSchema,
+  type VoucherGenerateFormValues,
+} from '@/schemas/voucher';
+
+interface VoucherGenerateModalProps {
+  open: boolean;
+  plan: Plan | null;
+  onClose: () => void;
+}
+
+// expiresAt lives outside the form because DateTimePicker works in Dayjs.
+type GenerateFields = Omit<VoucherGenerateFormValues, 'planId' | 'expiresAt'>;
+
+const DEFAULTS: GenerateFields = {
+  count: 10,
+  kind: 'any',
+  maxUses: 1,
+  prefix: '',
+  comment: '',
+};
+
+// VoucherGenerateModal mints a batch of codes for a plan and then shows them
+// once so they can be copied out.
+export default function VoucherGenerateModal({ open, plan, onClose }: VoucherGenerateModalProps) {
+  const { t } = useTranslation();
+  const [messageApi, messageContextHolder] = message.useMessage();
+  const { generate } = useVoucherMutations();
+  const [form] = Form.useForm<GenerateFields>();
+  const [expiresAt, setExpiresAt] = useState(0);
+  const [codes, setCodes] = useState<string[]>([]);
+  const [saving, setSaving] = useState(false);
+
+  const [wasOpen, setWasOpen] = useState(false);
+  if (open !== wasOpen) {
+    setWasOpen(open);
+    if (open) {
+      setCodes([]);
+      setExpiresAt(0);
+    }
+  }
+
+  async function submit() {
+    if (!plan) return;
+    const fields = await form.validateFields();
+    const payload = VoucherGenerateFormSchema.parse({
+      ...fields,
+      planId: plan.id,
+      expiresAt,
+    });
+    setSaving(true);
+    try {
+      const msg = await generate(payload);
+      if (msg?.success) setCodes((msg.obj ?? []).map((v) => v.code));
+    } finally {
+      setSaving(false);
+    }
+  }
+
+  async function copyAll() {
+    const ok = await ClipboardManager.copyText(codes.join('\n'));
+    if (ok) messageApi.success(t('copied'));
+  }
+
+  const done = codes.length > 0;
+
+  return (
+    <Modal
+      open={open}
+      title={`${t('pages.plans.generateVouchers')}: ${plan?.name ?? ''}`}
+      okText={done ? t('close') : t('create')}
+      cancelText={t('cancel')}
+      cancelButtonProps={{ style: done ? { display: 'none' } : undefined }}
+      confirmLoading={saving}
+      onOk={done ? onClose : submit}
+      onCancel={onClose}
+      destroyOnHidden
+    >
+      {messageContextHolder}
+      {done ? (
+        <Space orientation="vertical" style={{ width: '100%' }}>
+          <Input.TextArea readOnly rows={10} value={codes.join('\n')} />
+          <Button icon={<CopyOutlined />} onClick={copyAll}>
+            {t('copy')}
+          </Button>
+        </Space>
+      ) : (
+        <Form<GenerateFields> form={form} layout="vertical" initialValues={DEFAULTS}>
+          <Space size="large" wrap>
+            <Form.Item name="count" label={t('pages.plans.voucherCount')}>
+              <InputNumber min={1} max={500} />
+            </Form.Item>
+            <Form.Item
+              name="maxUses"
+              label={t('pages.plans.voucherMaxUses')}
+              tooltip={t('pages.plans.voucherMaxUsesDesc')}
+            >
+              <InputNumber min={0} />
+            </Form.Item>
+          </Space>
+          <Form.Item
+            name="kind"
+            label={t('pages.plans.voucherKind')}
+            extra={t('pages.plans.voucherKindDesc')}
+          >
+            <Select
+              options={VOUCHER_KINDS.map((k) => ({
+                value: k,
+                label: t(`pages.plans.voucherKinds.${k}`),
+              }))}
+            />
+          </Form.Item>
+          <Form.Item label={t('pages.plans.voucherExpires')}>
+            <DateTimePicker
+              value={expiresAt > 0 ? dayjs(expiresAt) : null}
+              onChange={(next) => setExpiresAt(next ? next.valueOf() : 0)}
+            />
+          </Form.Item>
+          <Form.Item
+            name="prefix"
+            label={t('pages.plans.voucherPrefix')}
+            rules={[{ pattern: /^[A-Za-z0-9]*$/, max: 16 }]}
+          >
+            <Input placeholder="SPRING" />
+          </Form.Item>
+          <Form.Item name="comment" label={t('comment')}>
+            <Input />
+          </Form.Item>
+        </Form>
+      )}
+    </Modal>
+  );
+}
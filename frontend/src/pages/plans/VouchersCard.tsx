@@ -0,0 +1,135 @@
+import { useMemo } from 'react';
+import { useTranslation } from 'react-i18next';
+import { Button, Card, Popconfirm, Space, Switch, Table, Tag, Tooltip, message } from 'antd';
+import type { TableColumnsType } from 'antd';
+import { CopyOutlined, DeleteOutlined, GiftOutlined } from '@ant-design/icons';
+
+import { ClipboardManager, IntlUtil } from '@/utils';
+import { useDatepicker } from '@/hooks/useDatepicker';
+import { useVouchersQuery, type Voucher } from '@/api/queries/useVouchersQuery';
+import { useVoucherMutations } from '@/api/queries/useVoucherMutations';
+import type { Plan } from '@/api/queries/usePlansQuery';
+import type { VoucherKind } from '@/schemas/voucher';
+
+interface VouchersCardProps {
+  plans: Plan[];
+}
+
+// VouchersCard lists every voucher code with its plan and usage. Codes are
+// minted from the plans table above.
+export default function VouchersCard({ plans }: VouchersCardProps) {
+  const { t } = useTranslation();
+  const { datepicker } = useDatepicker();
+  const [messageApi, messageContextHolder] = message.useMessage();
+  const { vouchers, loading } = useVouchersQuery();
+  const { update, remove } = useVoucherMutations();
+
+  const planName = useMemo(() => {
+    const byId = new Map(plans.map((p) => [p.id, p.name]));
+    return (id: number) => byId.get(id) ?? `#${id}`;
+  }, [plans]);
+
+  async function copy(code: string) {
+    const ok = await ClipboardManager.copyText(code);
+    if (ok) messageApi.success(t('copied'));
+  }
+
+  function setEnable(v: Voucher, enable: boolean) {
+    return update(v.id, {
+      kind: v.kind as VoucherKind,
+      maxUses: v.maxUses,
+      expiresAt: v.expiresAt,
+      enable,
+      comment: v.comment ?? '',
+    });
+  }
+
+  const columns: TableColumnsType<Voucher> = [
+    {
+      key: 'actions',
+      width: 90,
+      render: (_, v) => (
+        <Space size={4}>
+          <Tooltip title={t('copy')}>
+            <Button size="small" icon={<CopyOutlined />} onClick={() => copy(v.code)} />
+          </Tooltip>
+          <Popconfirm
+            title={t('pages.plans.voucherDeleteConfirm')}
+            okText={t('delete')}
+            cancelText={t('cancel')}
+            okButtonProps={{ danger: true }}
+            onConfirm={() => remove(v.id)}
+          >
+            <Button size="small" danger icon={<DeleteOutlined />} />
+          </Popconfirm>
+        </Space>
+      ),
+    },
+    {
+      title: t('enable'),
+      key: 'enable',
+      render: (_, v) => (
+        <Switch size="small" checked={v.enable} onChange={(next) => setEnable(v, next)} />
+      ),
+    },
+    {
+      title: t('pages.plans.voucherCode'),
+      key: 'code',
+      render: (_, v) => (
+        <Tooltip title={v.comment}>
+          <code>{v.code}</code>
+        </Tooltip>
+      ),
+    },
+    {
+      title: t('pages.plans.name'),
+      key: 'plan',
+      render: (_, v) => <Tag color="purple">{planName(v.planId)}</Tag>,
+    },
+    {
+      title: t('pages.plans.voucherKind'),
+      key: 'kind',
+      render: (_, v) => <Tag color="blue">{t(`pages.plans.voucherKinds.${v.kind}`)}</Tag>,
+    },
+    {
+      title: t('pages.plans.voucherUses'),
+      key: 'uses',
+      render: (_, v) => {
+        const usedUp = v.maxUses > 0 && v.uses >= v.maxUses;
+        return (
+          <Tag color={usedUp ? 'red' : undefined}>
+            {v.uses} / {v.maxUses > 0 ? v.maxUses : '∞'}
+          </Tag>
+        );
+      },
+    },
+    {
+      title: t('pages.plans.voucherExpires'),
+      key: 'expiresAt',
+      render: (_, v) => (v.expiresAt > 0 ? IntlUtil.formatDate(v.expiresAt, datepicker) : '—'),
+    },
+  ];
+
+  return (
+    <Card size="small" hoverable title={t('pages.plans.vouchers')}>
+      {messageContextHolder}
+      <Table<Voucher>
+        dataSource={vouchers}
+        columns={columns}
+        rowKey="id"
+        size="small"
+        loading={loading}
+        pagination={{ pageSize: 20, hideOnSinglePage: true, showSizeChanger: false }}
+        scroll={{ x: 'max-content' }}
+        locale={{
+          emptyText: (
+            <div className="card-empty">
+              <GiftOutlined style={{ fontSize: 32, marginBottom: 8 }} />
+              <div>{t('pages.plans.vouchersEmpty')}</div>
+            </div>
+          ),
+        }}
+      />
+    </Card>
+  );
+}
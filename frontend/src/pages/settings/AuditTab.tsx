@@ -0,0 +1,197 @@
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { Alert, Button, Input, InputNumber, Select, Space, Table, Tag, Tooltip } from 'antd';
+import type { TableColumnsType } from 'antd';
+import { DownloadOutlined, ReloadOutlined } from '@ant-design/icons';
+
+import { IntlUtil } from '@/utils';
+import type { CalendarKind } from '@/utils';
+import { onNumber } from '@/utils/onNumber';
+import type { AllSetting } from '@/models/setting';
+import { SettingListItem } from '@/components/ui';
+import { useMeQuery } from '@/api/queries/useUsersQuery';
+import {
+  auditExportUrl,
+  useAuditQuery,
+  type AuditLog,
+  type AuditQueryParams,
+} from '@/api/queries/useAuditQuery';
+import { AUDIT_TARGETS, type AuditChange } from '@/schemas/audit';
+
+interface AuditTabProps {
+  allSetting: AllSetting;
+  updateSetting: (patch: Partial<AllSetting>) => void;
+}
+
+const actorColor: Record<string, string> = {
+  user: 'blue',
+  token: 'purple',
+  mtls: 'cyan',
+};
+
+function formatValue(v: unknown): string {
+  if (v === undefined || v === null) return '—';
+  return typeof v === 'string' ? v : JSON.stringify(v);
+}
+
+function ChangesTable({ changes }: { changes: Record<string, AuditChange> }) {
+  const { t } = useTranslation();
+  const rows = Object.entries(changes).map(([field, c]) => ({ field, ...c }));
+  return (
+    <Table
+      rowKey="field"
+      size="small"
+      pagination={false}
+      dataSource={rows}
+      columns={[
+        { title: t('pages.settings.audit.field'), dataIndex: 'field', key: 'field' },
+        {
+          title: t('pages.settings.audit.before'),
+          key: 'before',
+          render: (_, row) => <code>{formatValue(row.before)}</code>,
+        },
+        {
+          title: t('pages.settings.audit.after'),
+          key: 'after',
+          render: (_, row) => <code>{formatValue(row.after)}</code>,
+        },
+      ]}
+    />
+  );
+}
+
+export default function AuditTab({ allSetting, updateSetting }: AuditTabProps) {
+  const { t } = useTranslation();
+  const { isOwner } = useMeQuery();
+  const [params, setParams] = useState<AuditQueryParams>({ page: 1, pageSize: 50 });
+  const { items, total, loading, refetch } = useAuditQuery(params, isOwner);
+  const calendar = (allSetting.datepicker || 'gregorian') as CalendarKind;
+
+  // Any filter change starts over from the first page.
+  const setFilter = (patch: Partial<AuditQueryParams>) =>
+    setParams((p) => ({ ...p, ...patch, page: 1 }));
+
+  if (!isOwner) {
+    return <Alert type="info" showIcon title={t('pages.settings.audit.ownerOnly')} />;
+  }
+
+  const columns: TableColumnsType<AuditLog> = [
+    {
+      title: t('pages.settings.audit.time'),
+      key: 'time',
+      render: (_, row) => IntlUtil.formatDate(row.createdAt, calendar),
+    },
+    {
+      title: t('pages.settings.audit.actor'),
+      key: 'actor',
+      render: (_, row) => (
+        <Space size={4}>
+          {row.actor || '—'}
+          {row.actorType && <Tag color={actorColor[row.actorType]}>{row.actorType}</Tag>}
+        </Space>
+      ),
+    },
+    { title: t('pages.settings.audit.sourceIp'), dataIndex: 'sourceIp', key: 'sourceIp' },
+    {
+      title: t('pages.settings.audit.action'),
+      key: 'action',
+      render: (_, row) => (
+        <code>
+          {row.method} {row.route}
+        </code>
+      ),
+    },
+    {
+      title: t('pages.settings.audit.target'),
+      key: 'target',
+      render: (_, row) =>
+        row.targetType ? (
+          <Space size={4}>
+            <Tag>{row.targetType}</Tag>
+            {row.targetId}
+          </Space>
+        ) : (
+          '—'
+        ),
+    },
+    {
+      title: t('pages.settings.audit.result'),
+      key: 'result',
+      render: (_, row) =>
+        row.success ? (
+          <Tag color="green">{t('pages.settings.audit.success')}</Tag>
+        ) : (
+          <Tooltip title={row.error}>
+            <Tag color="red">{t('pages.settings.audit.failed')}</Tag>
+          </Tooltip>
+        ),
+    },
+  ];
+
+  return (
+    <>
+      <SettingListItem
+        paddings="small"
+        title={t('pages.settings.audit.retention')}
+        description={t('pages.settings.audit.retentionDesc')}
+      >
+        <InputNumber
+          value={allSetting.auditRetentionDays}
+          min={0}
+          style={{ width: '100%' }}
+          onChange={onNumber((v) => updateSetting({ auditRetentionDays: v }))}
+        />
+      </SettingListItem>
+      <Space wrap style={{ padding: '10px 20px' }}>
+        <Input.Search
+          allowClear
+          placeholder={t('pages.settings.audit.search')}
+          onSearch={(search) => setFilter({ search })}
+          style={{ width: 240 }}
+        />
+        <Input.Search
+          allowClear
+          placeholder={t('pages.settings.audit.actor')}
+          onSearch={(actor) => setFilter({ actor })}
+          style={{ width: 160 }}
+        />
+        <Select
+          allowClear
+          placeholder={t('pages.settings.audit.target')}
+          value={params.targetType}
+          onChange={(targetType) => setFilter({ targetType })}
+          options={AUDIT_TARGETS.map((v) => ({ value: v, label: v }))}
+          style={{ width: 140 }}
+        />
+        <Button icon={<ReloadOutlined />} onClick={() => refetch()} />
+        <Button
+          icon={<DownloadOutlined />}
+          onClick={() => {
+            window.location.href = auditExportUrl(params);
+          }}
+        >
+          {t('pages.settings.audit.export')}
+        </Button>
+      </Space>
+      <Table<AuditLog>
+        rowKey="id"
+        size="small"
+        loading={loading}
+        columns={columns}
+        dataSource={items}
+        scroll={{ x: 'max-content' }}
+        pagination={{
+          current: params.page,
+          pageSize: params.pageSize,
+          total,
+          showSizeChanger: true,
+          onChange: (page, pageSize) => setParams((p) => ({ ...p, page, pageSize })),
+        }}
+        expandable={{
+          expandedRowRender: (row) => <ChangesTable changes={row.changes ?? {}} />,
+          rowExpandable: (row) => Object.keys(row.changes ?? {}).length > 0,
+        }}
+      />
+    </>
+  );
+}
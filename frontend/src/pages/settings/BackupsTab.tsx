@@ -0,0 +1,319 @@
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import {
+  Alert,
+  Button,
+  Checkbox,
+  Input,
+  InputNumber,
+  Popconfirm,
+  Space,
+  Switch,
+  Table,
+  Tabs,
+  Tag,
+  Tooltip,
+} from 'antd';
+import type { TableColumnsType } from 'antd';
+import {
+  CloudServerOutlined,
+  CloudUploadOutlined,
+  FolderOutlined,
+  LockOutlined,
+  ReloadOutlined,
+  UndoOutlined,
+} from '@ant-design/icons';
+
+import { HttpUtil, IntlUtil, PromiseUtil, SizeFormatter } from '@/utils';
+import type { CalendarKind } from '@/utils';
+import { onNumber } from '@/utils/onNumber';
+import type { AllSetting } from '@/models/setting';
+import { SettingListItem } from '@/components/ui';
+import { useMediaQuery } from '@/hooks/useMediaQuery';
+import { useMeQuery } from '@/api/queries/useUsersQuery';
+import {
+  useBackupMutations,
+  useBackupsQuery,
+  type BackupManifest,
+} from '@/api/queries/useBackupsQuery';
+import { catTabLabel } from './catTabLabel';
+import SecretInput from './SecretInput';
+
+interface BackupsTabProps {
+  allSetting: AllSetting;
+  updateSetting: (patch: Partial<AllSetting>) => void;
+}
+
+export default function BackupsTab({ allSetting, updateSetting }: BackupsTabProps) {
+  const { t } = useTranslation();
+  const { isMobile } = useMediaQuery();
+  const { isOwner } = useMeQuery();
+  const { backups, error, loading, refetch } = useBackupsQuery(isOwner);
+  const { run, running, restore } = useBackupMutations();
+  const [keepHostSettings, setKeepHostSettings] = useState(true);
+  const [restoring, setRestoring] = useState<string | null>(null);
+  const calendar = (allSetting.datepicker || 'gregorian') as CalendarKind;
+
+  if (!isOwner) {
+    return <Alert type="info" showIcon title={t('pages.settings.backups.ownerOnly')} />;
+  }
+
+  // A restore replaces the whole database, so the panel restarts just like
+  // after a manual import.
+  async function restoreBackup(b: BackupManifest) {
+    setRestoring(b.target + b.name);
+    try {
+      const msg = await restore(b.target, b.name, keepHostSettings);
+      if (!msg?.success) return;
+      const restart = await HttpUtil.post('/panel/api/setting/restartPanel');
+      if (restart?.success) {
+        await PromiseUtil.sleep(5000);
+        window.location.reload();
+      }
+    } finally {
+      setRestoring(null);
+    }
+  }
+
+  const columns: TableColumnsType<BackupManifest> = [
+    {
+      title: t('pages.settings.backups.created'),
+      key: 'created',
+      render: (_, b) => IntlUtil.formatDate(b.createdAt, calendar),
+    },
+    {
+      title: t('pages.settings.backups.name'),
+      key: 'name',
+      render: (_, b) => (
+        <Tooltip title={b.sha256 && `SHA-256 ${b.sha256}`}>
+          <code>{b.name}</code>
+        </Tooltip>
+      ),
+    },
+    {
+      title: t('pages.settings.backups.target'),
+      key: 'target',
+      render: (_, b) => (
+        <Tag color={b.target === 's3' ? 'purple' : 'blue'}>
+          {t(`pages.settings.backups.targets.${b.target}`)}
+        </Tag>
+      ),
+    },
+    {
+      title: t('pages.settings.backups.size'),
+      key: 'size',
+      render: (_, b) => SizeFormatter.sizeFormat(b.size),
+    },
+    {
+      key: 'flags',
+      render: (_, b) => (
+        <Space size={4}>
+          {b.database && <Tag>{b.database}</Tag>}
+          {b.encrypted && (
+            <Tag icon={<LockOutlined />} color="green">
+              {t('pages.settings.backups.encrypted')}
+            </Tag>
+          )}
+        </Space>
+      ),
+    },
+    {
+      key: 'actions',
+      render: (_, b) => (
+        <Popconfirm
+          title={t('pages.settings.backups.restoreConfirm')}
+          description={
+            <Checkbox
+              checked={keepHostSettings}
+              onChange={(e) => setKeepHostSettings(e.target.checked)}
+            >
+              {t('pages.index.importKeepHostSettings')}
+            </Checkbox>
+          }
+          okText={t('pages.settings.backups.restore')}
+          cancelText={t('cancel')}
+          okButtonProps={{ danger: true, loading: restoring === b.target + b.name }}
+          onConfirm={() => restoreBackup(b)}
+        >
+          <Tooltip title={t('pages.settings.backups.restore')}>
+            <Button size="small" danger icon={<UndoOutlined />} disabled={restoring !== null} />
+          </Tooltip>
+        </Popconfirm>
+      ),
+    },
+  ];
+
+  return (
+    <Tabs
+      defaultActiveKey="1"
+      items={[
+        {
+          key: '1',
+          label: catTabLabel(<CloudUploadOutlined />, t('pages.settings.backups.list'), isMobile),
+          children: (
+            <>
+              <Space wrap style={{ padding: '10px 20px' }}>
+                <Button
+                  type="primary"
+                  icon={<CloudUploadOutlined />}
+                  loading={running}
+                  onClick={() => void run()}
+                >
+                  {t('pages.settings.backups.runNow')}
+                </Button>
+                <Button icon={<ReloadOutlined />} onClick={() => void refetch()} />
+              </Space>
+              {error && (
+                <Alert type="warning" showIcon title={error} style={{ margin: '0 20px 10px' }} />
+              )}
+              <Table<BackupManifest>
+                rowKey={(b) => `${b.target}/${b.name}`}
+                size="small"
+                loading={loading && backups.length === 0}
+                columns={columns}
+                dataSource={backups}
+                pagination={false}
+                scroll={{ x: 'max-content' }}
+              />
+            </>
+          ),
+        },
+        {
+          key: '2',
+          label: catTabLabel(<FolderOutlined />, t('pages.settings.backups.schedule'), isMobile),
+          children: (
+            <>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.backups.enable')}
+                description={t('pages.settings.backups.enableDesc')}
+              >
+                <Switch
+                  checked={allSetting.backupEnable}
+                  onChange={(v) => updateSetting({ backupEnable: v })}
+                />
+              </SettingListItem>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.backups.cron')}
+                description={t('pages.settings.backups.cronDesc')}
+              >
+                <Input
+                  value={allSetting.backupCron}
+                  placeholder="@daily"
+                  onChange={(e) => updateSetting({ backupCron: e.target.value })}
+                />
+              </SettingListItem>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.backups.keep')}
+                description={t('pages.settings.backups.keepDesc')}
+              >
+                <InputNumber
+                  value={allSetting.backupKeep}
+                  min={0}
+                  style={{ width: '100%' }}
+                  onChange={onNumber((v) => updateSetting({ backupKeep: v }))}
+                />
+              </SettingListItem>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.backups.passphrase')}
+                description={t('pages.settings.backups.passphraseDesc')}
+              >
+                <SecretInput
+                  value={allSetting.backupPassphrase}
+                  configured={allSetting.hasBackupPassphrase}
+                  clearArmed={allSetting.clearBackupPassphrase}
+                  placeholder={t('pages.settings.backups.secretPlaceholder')}
+                  onChange={(v) => updateSetting({ backupPassphrase: v })}
+                  onClearArmedChange={(armed) => updateSetting({ clearBackupPassphrase: armed })}
+                />
+              </SettingListItem>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.backups.localDir')}
+                description={t('pages.settings.backups.localDirDesc')}
+              >
+                <Input
+                  value={allSetting.backupLocalDir}
+                  placeholder="/var/backups/x-ui"
+                  onChange={(e) => updateSetting({ backupLocalDir: e.target.value })}
+                />
+              </SettingListItem>
+            </>
+          ),
+        },
+        {
+          key: '3',
+          label: catTabLabel(<CloudServerOutlined />, 'S3', isMobile),
+          children: (
+            <>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.backups.s3Endpoint')}
+                description={t('pages.settings.backups.s3EndpointDesc')}
+              >
+                <Input
+                  value={allSetting.backupS3Endpoint}
+                  placeholder="https://s3.amazonaws.com"
+                  onChange={(e) => updateSetting({ backupS3Endpoint: e.target.value })}
+                />
+              </SettingListItem>
+              <SettingListItem paddings="small" title={t('pages.settings.backups.s3Region')}>
+                <Input
+                  value={allSetting.backupS3Region}
+                  placeholder="us-east-1"
+                  onChange={(e) => updateSetting({ backupS3Region: e.target.value })}
+                />
+              </SettingListItem>
+              <SettingListItem paddings="small" title={t('pages.settings.backups.s3Bucket')}>
+                <Input
+                  value={allSetting.backupS3Bucket}
+                  onChange={(e) => updateSetting({ backupS3Bucket: e.target.value })}
+                />
+              </SettingListItem>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.backups.s3Prefix')}
+                description={t('pages.settings.backups.s3PrefixDesc')}
+              >
+                <Input
+                  value={allSetting.backupS3Prefix}
+                  placeholder="x-ui/"
+                  onChange={(e) => updateSetting({ backupS3Prefix: e.target.value })}
+                />
+              </SettingListItem>
+              <SettingListItem paddings="small" title={t('pages.settings.backups.s3AccessKey')}>
+                <Input
+                  value={allSetting.backupS3AccessKey}
+                  onChange={(e) => updateSetting({ backupS3AccessKey: e.target.value })}
+                />
+              </SettingListItem>
+              <SettingListItem paddings="small" title={t('pages.settings.backups.s3SecretKey')}>
+                <SecretInput
+                  value={allSetting.backupS3SecretKey}
+                  configured={allSetting.hasBackupS3SecretKey}
+                  clearArmed={allSetting.clearBackupS3SecretKey}
+                  placeholder={t('pages.settings.backups.secretPlaceholder')}
+                  onChange={(v) => updateSetting({ backupS3SecretKey: v })}
+                  onClearArmedChange={(armed) => updateSetting({ clearBackupS3SecretKey: armed })}
+                />
+              </SettingListItem>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.backups.s3PathStyle')}
+                description={t('pages.settings.backups.s3PathStyleDesc')}
+              >
+                <Switch
+                  checked={allSetting.backupS3PathStyle}
+                  onChange={(v) => updateSetting({ backupS3PathStyle: v })}
+                />
+              </SettingListItem>
+            </>
+          ),
+        },
+      ]}
+    />
+  );
+}
@@ -0,0 +1,359 @@
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import {
+  Button,
+  Form,
+  Input,
+  InputNumber,
+  Modal,
+  Popconfirm,
+  Select,
+  Space,
+  Switch,
+  Table,
+  Tabs,
+  Tag,
+  Tooltip,
+  Typography,
+} from 'antd';
+import type { TableColumnsType } from 'antd';
+import {
+  DeleteOutlined,
+  PlusOutlined,
+  ReloadOutlined,
+  SafetyCertificateOutlined,
+  SettingOutlined,
+} from '@ant-design/icons';
+
+import { IntlUtil } from '@/utils';
+import type { CalendarKind } from '@/utils';
+import { onNumber } from '@/utils/onNumber';
+import type { AllSetting } from '@/models/setting';
+import { SettingListItem } from '@/components/ui';
+import { useMediaQuery } from '@/hooks/useMediaQuery';
+import { useAcmeQuery, type AcmeCertificate } from '@/api/queries/useAcmeQuery';
+import { useAcmeMutations } from '@/api/queries/useAcmeMutations';
+import { AcmeIssueFormSchema, type AcmeChallenge } from '@/schemas/acme';
+import { catTabLabel } from './catTabLabel';
+
+interface CertificatesTabProps {
+  allSetting: AllSetting;
+  updateSetting: (patch: Partial<AllSetting>) => void;
+}
+
+interface IssueFormValues {
+  domains: string[];
+  challenge: AcmeChallenge;
+  dnsProvider?: string;
+  dnsConfig?: Record<string, string>;
+  autoRenew: boolean;
+}
+
+// Credentials each DNS provider reads from dnsConfig; must match internal/acme.
+const dnsProviderFields: Record<string, { key: string; secret?: boolean; optional?: boolean }[]> = {
+  cloudflare: [{ key: 'apiToken', secret: true }, { key: 'zoneId', optional: true }],
+};
+
+const challengeDesc: Record<AcmeChallenge, string> = {
+  'http-01': 'pages.settings.acme.http01Desc',
+  'tls-alpn-01': 'pages.settings.acme.tlsAlpn01Desc',
+  'dns-01': 'pages.settings.acme.dns01Desc',
+};
+
+const statusColor: Record<string, string> = {
+  valid: 'green',
+  pending: 'blue',
+  error: 'red',
+};
+
+export default function CertificatesTab({ allSetting, updateSetting }: CertificatesTabProps) {
+  const { t } = useTranslation();
+  const { isMobile } = useMediaQuery();
+  const { certificates, providers, loading } = useAcmeQuery();
+  const { issue, renew, remove } = useAcmeMutations();
+  const [issueOpen, setIssueOpen] = useState(false);
+  const [submitting, setSubmitting] = useState(false);
+  const [form] = Form.useForm<IssueFormValues>();
+  const challenge = Form.useWatch('challenge', form);
+  const dnsProvider = Form.useWatch('dnsProvider', form);
+  const calendar = (allSetting.datepicker || 'gregorian') as CalendarKind;
+
+  async function submitIssue() {
+    const values = await form.validateFields();
+    const payload = AcmeIssueFormSchema.parse({
+      ...values,
+      dnsProvider: values.challenge === 'dns-01' ? values.dnsProvider : '',
+      dnsConfig: values.challenge === 'dns-01' ? values.dnsConfig : {},
+    });
+    setSubmitting(true);
+    try {
+      const msg = await issue(payload);
+      if (msg?.success) {
+        setIssueOpen(false);
+        form.resetFields();
+      }
+    } finally {
+      setSubmitting(false);
+    }
+  }
+
+  const columns: TableColumnsType<AcmeCertificate> = [
+    {
+      title: t('pages.settings.acme.domains'),
+      key: 'domains',
+      render: (_, cert) => (
+        <Space size={[0, 4]} wrap>
+          {(cert.domains ?? []).map((d) => (
+            <Tag key={d}>{d}</Tag>
+          ))}
+        </Space>
+      ),
+    },
+    {
+      title: t('status'),
+      key: 'status',
+      render: (_, cert) => {
+        const tag = (
+          <Tag color={statusColor[cert.status ?? ''] ?? 'default'}>
+            {t(`pages.settings.acme.status.${cert.status ?? 'pending'}`)}
+          </Tag>
+        );
+        return cert.lastError ? <Tooltip title={cert.lastError}>{tag}</Tooltip> : tag;
+      },
+    },
+    {
+      title: t('pages.settings.acme.expires'),
+      key: 'notAfter',
+      render: (_, cert) => (cert.notAfter ? IntlUtil.formatDate(cert.notAfter, calendar) : '-'),
+    },
+    {
+      title: t('pages.settings.acme.files'),
+      key: 'files',
+      responsive: ['lg'],
+      render: (_, cert) => (
+        <Space orientation="vertical" size={0}>
+          <Typography.Text copyable type="secondary">
+            {cert.certFile}
+          </Typography.Text>
+          <Typography.Text copyable type="secondary">
+            {cert.keyFile}
+          </Typography.Text>
+        </Space>
+      ),
+    },
+    {
+      title: t('pages.settings.actions'),
+      key: 'actions',
+      render: (_, cert) => (
+        <Space wrap>
+          <Button
+            size="small"
+            disabled={cert.status !== 'valid'}
+            onClick={() =>
+              updateSetting({ webCertFile: cert.certFile ?? '', webKeyFile: cert.keyFile ?? '' })
+            }
+          >
+            {t('pages.settings.acme.useForPanel')}
+          </Button>
+          <Button
+            size="small"
+            disabled={cert.status !== 'valid'}
+            onClick={() =>
+              updateSetting({ subCertFile: cert.certFile ?? '', subKeyFile: cert.keyFile ?? '' })
+            }
+          >
+            {t('pages.settings.acme.useForSub')}
+          </Button>
+          <Tooltip title={t('pages.settings.acme.renew')}>
+            <Button
+              size="small"
+              icon={<ReloadOutlined />}
+              disabled={cert.status === 'pending'}
+              onClick={() => renew(cert.id)}
+            />
+          </Tooltip>
+          <Popconfirm
+            title={t('pages.settings.acme.deleteConfirm')}
+            okText={t('delete')}
+            cancelText={t('cancel')}
+            okButtonProps={{ danger: true }}
+            onConfirm={() => remove(cert.id)}
+          >
+            <Button size="small" danger icon={<DeleteOutlined />} />
+          </Popconfirm>
+        </Space>
+      ),
+    },
+  ];
+
+  return (
+    <>
+      <Tabs
+        defaultActiveKey="1"
+        items={[
+          {
+            key: '1',
+            label: catTabLabel(
+              <SafetyCertificateOutlined />,
+              t('pages.settings.acme.certificates'),
+              isMobile,
+            ),
+            children: (
+              <>
+                <Space style={{ padding: '10px 20px' }}>
+                  <Button type="primary" icon={<PlusOutlined />} onClick={() => setIssueOpen(true)}>
+                    {t('pages.settings.acme.issue')}
+                  </Button>
+                </Space>
+                <Table<AcmeCertificate>
+                  rowKey="id"
+                  size="small"
+                  loading={loading && certificates.length === 0}
+                  columns={columns}
+                  dataSource={certificates}
+                  pagination={false}
+                  scroll={{ x: 'max-content' }}
+                />
+              </>
+            ),
+          },
+          {
+            key: '2',
+            label: catTabLabel(<SettingOutlined />, t('pages.settings.acme.account'), isMobile),
+            children: (
+              <>
+                <SettingListItem
+                  paddings="small"
+                  title={t('pages.settings.acme.email')}
+                  description={t('pages.settings.acme.emailDesc')}
+                >
+                  <Input
+                    value={allSetting.acmeEmail}
+                    placeholder="admin@example.com"
+                    onChange={(e) => updateSetting({ acmeEmail: e.target.value })}
+                  />
+                </SettingListItem>
+                <SettingListItem
+                  paddings="small"
+                  title={t('pages.settings.acme.directoryUrl')}
+                  description={t('pages.settings.acme.directoryUrlDesc')}
+                >
+                  <Input
+                    value={allSetting.acmeDirectoryUrl}
+                    onChange={(e) => updateSetting({ acmeDirectoryUrl: e.target.value })}
+                  />
+                </SettingListItem>
+                <SettingListItem
+                  paddings="small"
+                  title={t('pages.settings.acme.httpPort')}
+                  description={t('pages.settings.acme.httpPortDesc')}
+                >
+                  <InputNumber
+                    value={allSetting.acmeHttpPort}
+                    min={0}
+                    max={65535}
+                    style={{ width: '100%' }}
+                    onChange={onNumber((v) => updateSetting({ acmeHttpPort: v }))}
+                  />
+                </SettingListItem>
+                <SettingListItem
+                  paddings="small"
+                  title={t('pages.settings.acme.tlsAlpnPort')}
+                  description={t('pages.settings.acme.tlsAlpnPortDesc')}
+                >
+                  <InputNumber
+                    value={allSetting.acmeTlsAlpnPort}
+                    min={0}
+                    max={65535}
+                    style={{ width: '100%' }}
+                    onChange={onNumber((v) => updateSetting({ acmeTlsAlpnPort: v }))}
+                  />
+                </SettingListItem>
+                <SettingListItem
+                  paddings="small"
+                  title={t('pages.settings.acme.renewDays')}
+                  description={t('pages.settings.acme.renewDaysDesc')}
+                >
+                  <InputNumber
+                    value={allSetting.acmeRenewDays}
+                    min={1}
+                    max={89}
+                    style={{ width: '100%' }}
+                    onChange={onNumber((v) => updateSetting({ acmeRenewDays: v }))}
+                  />
+                </SettingListItem>
+              </>
+            ),
+          },
+        ]}
+      />
+
+      <Modal
+        open={issueOpen}
+        title={t('pages.settings.acme.issue')}
+        okText={t('create')}
+        cancelText={t('cancel')}
+        confirmLoading={submitting}
+        onOk={submitIssue}
+        onCancel={() => setIssueOpen(false)}
+        destroyOnHidden
+      >
+        <Form<IssueFormValues>
+          form={form}
+          layout="vertical"
+          initialValues={{ challenge: 'http-01', autoRenew: true, domains: [] }}
+        >
+          <Form.Item
+            name="domains"
+            label={t('pages.settings.acme.domains')}
+            extra={t('pages.settings.acme.domainsDesc')}
+            rules={[{ required: true, type: 'array', min: 1 }]}
+          >
+            <Select mode="tags" open={false} tokenSeparators={[',', ' ']} />
+          </Form.Item>
+          <Form.Item
+            name="challenge"
+            label={t('pages.settings.acme.challenge')}
+            extra={t(challengeDesc[challenge ?? 'http-01'])}
+          >
+            <Select
+              options={[
+                { value: 'http-01', label: 'HTTP-01' },
+                { value: 'tls-alpn-01', label: 'TLS-ALPN-01' },
+                { value: 'dns-01', label: 'DNS-01' },
+              ]}
+            />
+          </Form.Item>
+          {challenge === 'dns-01' && (
+            <>
+              <Form.Item
+                name="dnsProvider"
+                label={t('pages.settings.acme.dnsProvider')}
+                rules={[{ required: true }]}
+              >
+                <Select options={providers.map((p) => ({ value: p, label: p }))} />
+              </Form.Item>
+              {(dnsProviderFields[dnsProvider ?? ''] ?? []).map((field) => (
+                <Form.Item
+                  key={field.key}
+                  name={['dnsConfig', field.key]}
+                  label={field.key}
+                  rules={[{ required: !field.optional }]}
+                >
+                  {field.secret ? <Input.Password autoComplete="off" /> : <Input />}
+                </Form.Item>
+              ))}
+            </>
+          )}
+          <Form.Item
+            name="autoRenew"
+            label={t('pages.settings.acme.autoRenew')}
+            valuePropName="checked"
+          >
+            <Switch />
+          </Form.Item>
+        </Form>
+      </Modal>
+    </>
+  );
+}
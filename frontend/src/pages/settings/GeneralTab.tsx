@@ -1,11 +1,12 @@
 import { useEffect, useMemo, useState } from 'react';
 import { useTranslation } from 'react-i18next';
-import { Input, InputNumber, Select, Switch, Tabs } from 'antd';
+import { Input, InputNumber, Select, Switch, Tabs, Typography } from 'antd';
 import {
   ApartmentOutlined,
   BellOutlined,
   ClockCircleOutlined,
   GlobalOutlined,
+  LineChartOutlined,
   SafetyCertificateOutlined,
   SettingOutlined,
 } from '@ant-design/icons';
@@ -673,6 +674,70 @@ export default function GeneralTab({ allSetting, updateSetting }: GeneralTabProp
             </>
           ),
         },
+        {
+          key: '7',
+          label: catTabLabel(<LineChartOutlined />, t('pages.settings.metrics.title'), isMobile),
+          children: (
+            <>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.metrics.endpoint')}
+                description={t('pages.settings.metrics.endpointDesc')}
+              >
+                <Typography.Text code copyable>
+                  {`${allSetting.webBasePath}panel/api/metrics`}
+                </Typography.Text>
+              </SettingListItem>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.metrics.clientLabels')}
+                description={t('pages.settings.metrics.clientLabelsDesc')}
+              >
+                <Switch
+                  checked={allSetting.metricsClientLabels}
+                  onChange={(v) => updateSetting({ metricsClientLabels: v })}
+                />
+              </SettingListItem>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.metrics.historyHourlyDays')}
+                badge={
+                  <DefaultSettingTag
+                    settingKey="trafficHistoryHourlyDays"
+                    value={allSetting.trafficHistoryHourlyDays}
+                  />
+                }
+                description={t('pages.settings.metrics.historyHourlyDaysDesc')}
+              >
+                <InputNumber
+                  value={allSetting.trafficHistoryHourlyDays}
+                  min={1}
+                  max={90}
+                  style={{ width: '100%' }}
+                  onChange={onNumber((v) => updateSetting({ trafficHistoryHourlyDays: v }))}
+                />
+              </SettingListItem>
+              <SettingListItem
+                paddings="small"
+                title={t('pages.settings.metrics.historyDays')}
+                badge={
+                  <DefaultSettingTag
+                    settingKey="trafficHistoryDays"
+                    value={allSetting.trafficHistoryDays}
+                  />
+                }
+                description={t('pages.settings.metrics.historyDaysDesc')}
+              >
+                <InputNumber
+                  value={allSetting.trafficHistoryDays}
+                  min={0}
+                  style={{ width: '100%' }}
+                  onChange={onNumber((v) => updateSetting({ trafficHistoryDays: v }))}
+                />
+              </SettingListItem>
+            </>
+          ),
+        },
       ]}
     />
   );
@@ -1,11 +1,25 @@
 import { useCallback, useEffect, useState } from 'react';
 import { useTranslation } from 'react-i18next';
-import { Button, Empty, Form, Input, Modal, Space, Spin, Switch, Tabs, message } from 'antd';
+import {
+  Button,
+  Empty,
+  Form,
+  Input,
+  Modal,
+  Select,
+  Space,
+  Spin,
+  Switch,
+  Tabs,
+  Tag,
+  message,
+} from 'antd';
 import { ApiOutlined, SafetyOutlined, UserOutlined } from '@ant-design/icons';
 import { ClipboardManager, HttpUtil, IntlUtil, RandomUtil } from '@/utils';
-import type { AllSetting } from '@/models/setting';
 import { SettingListItem } from '@/components/ui';
 import { useMediaQuery } from '@/hooks/useMediaQuery';
+import { useMeQuery } from '@/api/queries/useUsersQuery';
+import { useUserMutations } from '@/api/queries/useUserMutations';
 import { catTabLabel } from './catTabLabel';
 import TwoFactorModal from './TwoFactorModal';
 import './SecurityTab.css';
@@ -21,15 +35,11 @@ interface ApiTokenRow {
   name: string;
   enabled: boolean;
   createdAt: number;
-  scope: 'admin' | 'monitor' | 'node-sync';
+  scope: ApiTokenScope;
   expiresAt: number;
 }
 
-interface SecurityTabProps {
-  allSetting: AllSetting;
-  updateSetting: (patch: Partial<AllSetting>) => void;
-  saveSetting: (payload: Partial<AllSetting> & Record<string, unknown>) => Promise<unknown>;
-}
+type ApiTokenScope = 'admin' | 'monitor' | 'node-sync';
 
 const UNIX_MILLISECONDS_THRESHOLD = 100_000_000_000;
 
@@ -57,11 +67,14 @@ const TFA_INITIAL: TfaState = {
   onConfirm: () => {},
 };
 
-export default function SecurityTab({ allSetting, updateSetting, saveSetting }: SecurityTabProps) {
+export default function SecurityTab() {
   const { t } = useTranslation();
   const { isMobile } = useMediaQuery();
   const [modal, modalContextHolder] = Modal.useModal();
   const [messageApi, messageContextHolder] = message.useMessage();
+  const { me, isOwner } = useMeQuery();
+  const { setMyTwoFactor } = useUserMutations();
+  const twoFactorEnable = !!me?.twoFactorEnable;
 
   const [tfa, setTfa] = useState<TfaState>(TFA_INITIAL);
   const [user, setUser] = useState({
@@ -76,6 +89,7 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
   const [apiTokensLoading, setApiTokensLoading] = useState(true);
   const [createOpen, setCreateOpen] = useState(false);
   const [createName, setCreateName] = useState('');
+  const [createScope, setCreateScope] = useState<ApiTokenScope>('admin');
   const [creating, setCreating] = useState(false);
   const [createdToken, setCreatedToken] = useState<{ name: string; token: string } | null>(null);
 
@@ -115,7 +129,7 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
   );
 
   function onUpdateUserClick() {
-    if (allSetting.twoFactorEnable) {
+    if (twoFactorEnable) {
       openTfa({
         title: t('pages.settings.security.twoFactorModalChangeCredentialsTitle'),
         description: t('pages.settings.security.twoFactorModalChangeCredentialsStep'),
@@ -145,8 +159,9 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
   }, [fetchApiTokens]);
 
   useEffect(() => {
-    void fetchApiTokens();
-  }, [fetchApiTokens]);
+    // API tokens act as the primary owner, so only owners may manage them.
+    if (isOwner) void fetchApiTokens();
+  }, [fetchApiTokens, isOwner]);
 
   async function copyToken(token: string) {
     if (!token) return;
@@ -157,6 +172,7 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
 
   function openCreateModal() {
     setCreateName('');
+    setCreateScope('admin');
     setCreateOpen(true);
   }
 
@@ -168,9 +184,10 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
     }
     setCreating(true);
     try {
-      const msg = (await HttpUtil.post('/panel/api/setting/apiTokens/create', { name })) as ApiMsg<{
-        token?: string;
-      }>;
+      const msg = (await HttpUtil.post('/panel/api/setting/apiTokens/create', {
+        name,
+        scope: createScope,
+      })) as ApiMsg<{ token?: string }>;
       if (msg?.success) {
         setCreateOpen(false);
         await loadApiTokens();
@@ -218,19 +235,18 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
   }
 
   function toggleTwoFactor() {
-    if (!allSetting.twoFactorEnable) {
+    if (!twoFactorEnable) {
       const newToken = RandomUtil.randomBase32String();
       openTfa({
         title: t('pages.settings.security.twoFactorModalSetTitle'),
         description: '',
         token: newToken,
         type: 'set',
-        onConfirm: (ok: boolean) => {
-          if (ok) {
+        onConfirm: async (ok: boolean) => {
+          if (!ok) return;
+          const msg = (await setMyTwoFactor({ enable: true, token: newToken })) as ApiMsg;
+          if (msg?.success) {
             messageApi.success(t('pages.settings.security.twoFactorModalSetSuccess'));
-            updateSetting({ twoFactorToken: newToken, twoFactorEnable: true });
-          } else {
-            updateSetting({ twoFactorEnable: false });
           }
         },
       });
@@ -242,16 +258,9 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
         type: 'confirm',
         onConfirm: async (ok: boolean, code?: string) => {
           if (!ok) return;
-          const next = {
-            ...allSetting,
-            twoFactorEnable: false,
-            twoFactorToken: '',
-            twoFactorCode: code || '',
-          };
-          const msg = (await saveSetting(next)) as ApiMsg;
+          const msg = (await setMyTwoFactor({ enable: false, code: code || '' })) as ApiMsg;
           if (msg?.success) {
             messageApi.success(t('pages.settings.security.twoFactorModalDeleteSuccess'));
-            updateSetting({ twoFactorEnable: false, twoFactorToken: '', hasTwoFactorToken: false });
           }
         },
       });
@@ -320,7 +329,7 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
                 title={t('pages.settings.security.twoFactorEnable')}
                 description={t('pages.settings.security.twoFactorEnableDesc')}
               >
-                <Switch checked={allSetting.twoFactorEnable} onClick={toggleTwoFactor} />
+                <Switch checked={twoFactorEnable} onClick={toggleTwoFactor} />
               </SettingListItem>
             ),
           },
@@ -346,6 +355,7 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
                       <div className="api-token-row-head">
                         <div className="api-token-name-wrap">
                           <span className="api-token-name">{row.name}</span>
+                          {row.scope !== 'admin' && <Tag>{row.scope}</Tag>}
                           <span className="api-token-created">
                             {formatTokenDate(row.createdAt)}
                           </span>
@@ -372,7 +382,7 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
               </div>
             ),
           },
-        ]}
+        ].filter((item) => item.key !== '3' || isOwner)}
       />
 
       <Modal
@@ -396,6 +406,20 @@ export default function SecurityTab({ allSetting, updateSetting, saveSetting }:
               onPressEnter={confirmCreateToken}
             />
           </Form.Item>
+          <Form.Item
+            label={t('pages.settings.security.apiTokenScope')}
+            extra={t('pages.settings.security.apiTokenScopeDesc')}
+          >
+            <Select
+              value={createScope}
+              onChange={setCreateScope}
+              options={[
+                { value: 'admin', label: t('pages.settings.security.apiTokenScopeAdmin') },
+                { value: 'monitor', label: t('pages.settings.security.apiTokenScopeMonitor') },
+                { value: 'node-sync', label: t('pages.settings.security.apiTokenScopeNodeSync') },
+              ]}
+            />
+          </Form.Item>
         </Form>
       </Modal>
 
@@ -29,6 +29,11 @@ import TelegramTab from './TelegramTab';
 import EmailTab from './EmailTab';
 import SubscriptionGeneralTab from './SubscriptionGeneralTab';
 import SubscriptionFormatsTab from './SubscriptionFormatsTab';
+import CertificatesTab from './CertificatesTab';
+import UsersTab from './UsersTab';
+import AuditTab from './AuditTab';
+import WebhooksTab from './WebhooksTab';
+import BackupsTab from './BackupsTab';
 import './SettingsPage.css';
 
 interface ApiMsg {
@@ -42,6 +47,11 @@ const tabSlugs = [
   'email',
   'subscription',
   'subscription-formats',
+  'certificates',
+  'webhooks',
+  'users',
+  'audit',
+  'backups',
 ];
 
 function isIp(h: string): boolean {
@@ -83,7 +93,6 @@ export default function SettingsPage() {
     setSpinning,
     saveDisabled,
     saveAll,
-    savePayload,
   } = useAllSettings();
 
   const [entryHost] = useState(() => window.location.hostname);
@@ -204,13 +213,7 @@ export default function SettingsPage() {
   const categoryBody = useMemo(() => {
     switch (activeSlug) {
       case 'security':
-        return (
-          <SecurityTab
-            allSetting={allSetting}
-            updateSetting={updateSetting}
-            saveSetting={savePayload}
-          />
-        );
+        return <SecurityTab />;
       case 'telegram':
         return <TelegramTab allSetting={allSetting} updateSetting={updateSetting} />;
       case 'email':
@@ -219,10 +222,20 @@ export default function SettingsPage() {
         return <SubscriptionGeneralTab allSetting={allSetting} updateSetting={updateSetting} />;
       case 'subscription-formats':
         return <SubscriptionFormatsTab allSetting={allSetting} updateSetting={updateSetting} />;
+      case 'certificates':
+        return <CertificatesTab allSetting={allSetting} updateSetting={updateSetting} />;
+      case 'webhooks':
+        return <WebhooksTab allSetting={allSetting} updateSetting={updateSetting} />;
+      case 'users':
+        return <UsersTab />;
+      case 'audit':
+        return <AuditTab allSetting={allSetting} updateSetting={updateSetting} />;
+      case 'backups':
+        return <BackupsTab allSetting={allSetting} updateSetting={updateSetting} />;
       default:
         return <GeneralTab allSetting={allSetting} updateSetting={updateSetting} />;
     }
-  }, [activeSlug, allSetting, updateSetting, savePayload]);
+  }, [activeSlug, allSetting, updateSetting]);
 
   return (
     <ConfigProvider theme={antdThemeConfig}>
@@ -263,6 +276,22 @@ export default function SettingsPage() {
                     />
                   )}
 
+                  {(allSetting.unreadableSecrets?.length ?? 0) > 0 && (
+                    <Alert
+                      type="warning"
+                      showIcon
+                      className="conf-alert"
+                      title={t('pages.settings.unreadableSecrets')}
+                      description={
+                        <ul>
+                          {allSetting.unreadableSecrets.map((scope) => (
+                            <li key={scope}>{scope}</li>
+                          ))}
+                        </ul>
+                      }
+                    />
+                  )}
+
                   <Row gutter={[isMobile ? 8 : 16, isMobile ? 0 : 12]}>
                     <Col span={24}>
                       <Card hoverable>
@@ -2,6 +2,7 @@ import { useMemo } from 'react';
 import { useTranslation } from 'react-i18next';
 import { Card, Input, InputNumber, Select, Switch, Tabs } from 'antd';
 import {
+  DeploymentUnitOutlined,
   FileTextOutlined,
   NodeIndexOutlined,
   PartitionOutlined,
@@ -283,6 +284,91 @@ export default function SubscriptionFormatsTab({
                   </SettingListItem>
                 </Card>
               )}
+              {allSetting.subSingboxEnable && (
+                <Card
+                  size="small"
+                  className="subscription-format-card"
+                  title={
+                    <span className="subscription-format-card-title">
+                      <DeploymentUnitOutlined />
+                      {t('pages.settings.subSingboxEnableTitle')}
+                    </span>
+                  }
+                >
+                  <SettingListItem
+                    paddings="small"
+                    title={<>sing-box {t('pages.settings.subPath')}</>}
+                    description={t('pages.settings.subPathDesc')}
+                  >
+                    <Input
+                      value={allSetting.subSingboxPath}
+                      placeholder="/singbox/"
+                      onChange={(e) =>
+                        updateSetting({ subSingboxPath: sanitizePath(e.target.value) })
+                      }
+                      onBlur={() =>
+                        updateSetting({ subSingboxPath: normalizePath(allSetting.subSingboxPath) })
+                      }
+                    />
+                  </SettingListItem>
+                  <SettingListItem
+                    paddings="small"
+                    title={<>sing-box {t('pages.settings.subURI')}</>}
+                    description={t('pages.settings.subURIDesc')}
+                  >
+                    <Input
+                      value={allSetting.subSingboxURI}
+                      placeholder="(http|https)://domain[:port]/path/"
+                      onChange={(e) => updateSetting({ subSingboxURI: e.target.value })}
+                    />
+                  </SettingListItem>
+                  <SettingListItem
+                    paddings="small"
+                    title={t('pages.settings.subSingboxAutoDetect')}
+                    description={t('pages.settings.subSingboxAutoDetectDesc')}
+                  >
+                    <Switch
+                      checked={allSetting.subSingboxAutoDetect}
+                      onChange={(v) => updateSetting({ subSingboxAutoDetect: v })}
+                    />
+                  </SettingListItem>
+                  <SettingListItem
+                    paddings="small"
+                    title={t('pages.settings.subSingboxUserAgentRegex')}
+                    description={t('pages.settings.subSingboxUserAgentRegexDesc')}
+                  >
+                    <GoRegexInput
+                      value={allSetting.subSingboxUserAgentRegex}
+                      placeholder="(?i)(sing-box|^sf[aimt]/|hiddify|nekobox|karing)"
+                      onChange={(value) => updateSetting({ subSingboxUserAgentRegex: value })}
+                    />
+                  </SettingListItem>
+                  <SettingListItem
+                    paddings="small"
+                    title={t('pages.settings.subSingboxMux')}
+                    description={t('pages.settings.subSingboxMuxDesc')}
+                  >
+                    <Input.TextArea
+                      value={allSetting.subSingboxMux}
+                      rows={3}
+                      placeholder='{"enabled": true, "protocol": "h2mux", "max_connections": 4}'
+                      onChange={(e) => updateSetting({ subSingboxMux: e.target.value })}
+                    />
+                  </SettingListItem>
+                  <SettingListItem
+                    paddings="small"
+                    title={t('pages.settings.subSingboxRules')}
+                    description={t('pages.settings.subSingboxRulesDesc')}
+                  >
+                    <Input.TextArea
+                      value={allSetting.subSingboxRules}
+                      rows={6}
+                      placeholder='[{"domain_suffix": [".ir"], "outbound": "direct"}]'
+                      onChange={(e) => updateSetting({ subSingboxRules: e.target.value })}
+                    />
+                  </SettingListItem>
+                </Card>
+              )}
             </div>
           ),
         },
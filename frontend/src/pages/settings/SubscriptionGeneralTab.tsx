@@ -71,7 +71,15 @@ export default function SubscriptionGeneralTab({
                   onChange={(v) => updateSetting({ subClashEnable: v })}
                 />
               </SettingListItem>
-              {(allSetting.subJsonEnable || allSetting.subClashEnable) && (
+              <SettingListItem paddings="small" title={t('pages.settings.subSingboxEnableTitle')}>
+                <Switch
+                  checked={allSetting.subSingboxEnable}
+                  onChange={(v) => updateSetting({ subSingboxEnable: v })}
+                />
+              </SettingListItem>
+              {(allSetting.subJsonEnable ||
+                allSetting.subClashEnable ||
+                allSetting.subSingboxEnable) && (
                 <Alert
                   type="info"
                   showIcon
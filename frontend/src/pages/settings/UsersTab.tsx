@@ -0,0 +1,336 @@
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import {
+  Alert,
+  Button,
+  Form,
+  Input,
+  InputNumber,
+  Modal,
+  Popconfirm,
+  Select,
+  Space,
+  Table,
+  Tag,
+  Tooltip,
+} from 'antd';
+import type { TableColumnsType } from 'antd';
+import { DeleteOutlined, EditOutlined, KeyOutlined, PlusOutlined } from '@ant-design/icons';
+
+import { useInboundOptions } from '@/api/queries/useInboundOptions';
+import { useMeQuery, useUsersQuery, type PanelUser } from '@/api/queries/useUsersQuery';
+import { useUserMutations } from '@/api/queries/useUserMutations';
+import { SizeFormatter } from '@/utils';
+import {
+  PERM_GROUPS,
+  UserAccountFormSchema,
+  type AccessLevel,
+  type PermGroup,
+  type UserRole,
+} from '@/schemas/user';
+
+interface AccountFormValues {
+  username: string;
+  password?: string;
+  role: UserRole;
+  // '' keeps the role preset for that group.
+  permissions?: Partial<Record<PermGroup, AccessLevel | ''>>;
+  allowedInbounds?: number[];
+  maxClients?: number;
+  maxTrafficGB?: number;
+  maxExpiryDays?: number;
+}
+
+const roles: UserRole[] = ['owner', 'admin', 'reseller', 'read-only'];
+
+const roleColor: Record<string, string> = {
+  owner: 'gold',
+  admin: 'blue',
+  reseller: 'green',
+  'read-only': 'default',
+};
+
+const accessColor: Record<string, string> = {
+  write: 'green',
+  read: 'blue',
+  none: 'default',
+};
+
+export default function UsersTab() {
+  const { t } = useTranslation();
+  const { me, isOwner } = useMeQuery();
+  const { users, loading } = useUsersQuery(isOwner);
+  const { add, update, remove, resetTwoFactor } = useUserMutations();
+  const { data: inboundOptions = [] } = useInboundOptions();
+  const [editing, setEditing] = useState<PanelUser | null>(null);
+  const [open, setOpen] = useState(false);
+  const [submitting, setSubmitting] = useState(false);
+  const [form] = Form.useForm<AccountFormValues>();
+  const role = Form.useWatch('role', form);
+
+  function openModal(user: PanelUser | null) {
+    setEditing(user);
+    form.setFieldsValue({
+      username: user?.username ?? '',
+      password: '',
+      role: (user?.role as UserRole) ?? 'read-only',
+      permissions: Object.fromEntries(
+        PERM_GROUPS.map((g) => [g, (user?.permissions?.[g] as AccessLevel) ?? '']),
+      ),
+      allowedInbounds: user?.allowedInbounds ?? [],
+      maxClients: user?.maxClients ?? 0,
+      maxTrafficGB: user?.maxTrafficGB ?? 0,
+      maxExpiryDays: user?.maxExpiryDays ?? 0,
+    });
+    setOpen(true);
+  }
+
+  async function submit() {
+    const values = await form.validateFields();
+    const permissions = Object.fromEntries(
+      Object.entries(values.permissions ?? {}).filter(([, level]) => level),
+    );
+    // A cleared InputNumber reports null; the schema wants 0 for unlimited.
+    const payload = UserAccountFormSchema.parse({
+      ...values,
+      permissions,
+      maxClients: values.maxClients ?? 0,
+      maxTrafficGB: values.maxTrafficGB ?? 0,
+      maxExpiryDays: values.maxExpiryDays ?? 0,
+    });
+    setSubmitting(true);
+    try {
+      const msg = editing ? await update(editing.id, payload) : await add(payload);
+      if (msg?.success) setOpen(false);
+    } finally {
+      setSubmitting(false);
+    }
+  }
+
+  if (!isOwner) {
+    return <Alert type="info" showIcon title={t('pages.settings.users.ownerOnly')} />;
+  }
+
+  const columns: TableColumnsType<PanelUser> = [
+    {
+      title: t('username'),
+      key: 'username',
+      render: (_, user) => (
+        <Space size={4}>
+          {user.username}
+          {user.primary && <Tag>{t('pages.settings.users.primary')}</Tag>}
+          {user.id === me?.id && <Tag color="purple">{t('pages.settings.users.you')}</Tag>}
+        </Space>
+      ),
+    },
+    {
+      title: t('pages.settings.users.role'),
+      key: 'role',
+      render: (_, user) => (
+        <Tag color={roleColor[user.role] ?? 'default'}>
+          {t(`pages.settings.users.roles.${user.role}`)}
+        </Tag>
+      ),
+    },
+    {
+      title: t('pages.settings.users.permissions'),
+      key: 'effective',
+      responsive: ['md'],
+      render: (_, user) =>
+        user.role === 'owner' ? (
+          <Tag color="gold">{t('pages.settings.users.fullAccess')}</Tag>
+        ) : (
+          <Space size={[0, 4]} wrap>
+            {PERM_GROUPS.map((g) => {
+              const level = user.effective?.[g] ?? 'none';
+              return (
+                <Tag key={g} color={accessColor[level]}>
+                  {t(`pages.settings.users.groups.${g}`)}: {t(`pages.settings.users.access.${level}`)}
+                </Tag>
+              );
+            })}
+          </Space>
+        ),
+    },
+    {
+      title: t('pages.settings.users.limits'),
+      key: 'limits',
+      responsive: ['lg'],
+      render: (_, user) =>
+        user.role === 'reseller' && (
+          <Space size={[0, 4]} wrap>
+            <Tag>
+              {t('pages.settings.users.usageClients', {
+                used: user.usage?.clients ?? 0,
+                max: user.maxClients || t('unlimited'),
+              })}
+            </Tag>
+            <Tag>
+              {t('pages.settings.users.usageTraffic', {
+                used: SizeFormatter.sizeFormat(user.usage?.totalGB ?? 0),
+                max: user.maxTrafficGB ? `${user.maxTrafficGB} GB` : t('unlimited'),
+              })}
+            </Tag>
+            {!!user.maxExpiryDays && (
+              <Tag>{t('pages.settings.users.usageExpiry', { days: user.maxExpiryDays })}</Tag>
+            )}
+          </Space>
+        ),
+    },
+    {
+      title: t('pages.settings.security.twoFactor'),
+      key: 'twoFactor',
+      render: (_, user) =>
+        user.twoFactorEnable ? <Tag color="green">{t('enabled')}</Tag> : <Tag>{t('disabled')}</Tag>,
+    },
+    {
+      title: t('pages.settings.actions'),
+      key: 'actions',
+      render: (_, user) => (
+        <Space wrap>
+          <Tooltip title={t('edit')}>
+            <Button size="small" icon={<EditOutlined />} onClick={() => openModal(user)} />
+          </Tooltip>
+          <Popconfirm
+            title={t('pages.settings.users.resetTwoFactorConfirm')}
+            okText={t('confirm')}
+            cancelText={t('cancel')}
+            disabled={!user.twoFactorEnable}
+            onConfirm={() => resetTwoFactor(user.id)}
+          >
+            <Tooltip title={t('pages.settings.users.resetTwoFactor')}>
+              <Button size="small" icon={<KeyOutlined />} disabled={!user.twoFactorEnable} />
+            </Tooltip>
+          </Popconfirm>
+          <Popconfirm
+            title={t('pages.settings.users.deleteConfirm')}
+            okText={t('delete')}
+            cancelText={t('cancel')}
+            okButtonProps={{ danger: true }}
+            disabled={user.primary || user.id === me?.id}
+            onConfirm={() => remove(user.id)}
+          >
+            <Button
+              size="small"
+              danger
+              icon={<DeleteOutlined />}
+              disabled={user.primary || user.id === me?.id}
+            />
+          </Popconfirm>
+        </Space>
+      ),
+    },
+  ];
+
+  return (
+    <>
+      <Space style={{ padding: '10px 20px' }}>
+        <Button type="primary" icon={<PlusOutlined />} onClick={() => openModal(null)}>
+          {t('pages.settings.users.add')}
+        </Button>
+      </Space>
+      <Table<PanelUser>
+        rowKey="id"
+        size="small"
+        loading={loading && users.length === 0}
+        columns={columns}
+        dataSource={users}
+        pagination={false}
+        scroll={{ x: 'max-content' }}
+      />
+
+      <Modal
+        open={open}
+        title={editing ? t('pages.settings.users.edit') : t('pages.settings.users.add')}
+        okText={editing ? t('confirm') : t('create')}
+        cancelText={t('cancel')}
+        confirmLoading={submitting}
+        onOk={submit}
+        onCancel={() => setOpen(false)}
+        destroyOnHidden
+      >
+        <Form<AccountFormValues> form={form} layout="vertical">
+          <Form.Item name="username" label={t('username')} rules={[{ required: true, max: 64 }]}>
+            <Input autoComplete="off" />
+          </Form.Item>
+          <Form.Item
+            name="password"
+            label={t('password')}
+            extra={editing ? t('pages.settings.users.passwordKeep') : undefined}
+            rules={[{ required: !editing, max: 128 }]}
+          >
+            <Input.Password autoComplete="new-password" disabled={editing?.id === me?.id} />
+          </Form.Item>
+          <Form.Item
+            name="role"
+            label={t('pages.settings.users.role')}
+            extra={role ? t(`pages.settings.users.roleDesc.${role}`) : undefined}
+          >
+            <Select
+              disabled={editing?.primary}
+              options={roles.map((r) => ({ value: r, label: t(`pages.settings.users.roles.${r}`) }))}
+            />
+          </Form.Item>
+          {role && role !== 'owner' && (
+            <Form.Item
+              label={t('pages.settings.users.permissions')}
+              extra={t('pages.settings.users.permissionsDesc')}
+            >
+              {PERM_GROUPS.map((g) => (
+                <Form.Item
+                  key={g}
+                  name={['permissions', g]}
+                  label={t(`pages.settings.users.groups.${g}`)}
+                  labelCol={{ span: 8 }}
+                  style={{ marginBottom: 8 }}
+                >
+                  <Select
+                    options={[
+                      { value: '', label: t('pages.settings.users.access.preset') },
+                      { value: 'none', label: t('pages.settings.users.access.none') },
+                      { value: 'read', label: t('pages.settings.users.access.read') },
+                      { value: 'write', label: t('pages.settings.users.access.write') },
+                    ]}
+                  />
+                </Form.Item>
+              ))}
+            </Form.Item>
+          )}
+          {role === 'reseller' && (
+            <Form.Item
+              label={t('pages.settings.users.limits')}
+              extra={t('pages.settings.users.limitsDesc')}
+            >
+              <Form.Item
+                name="allowedInbounds"
+                label={t('pages.settings.users.allowedInbounds')}
+                extra={t('pages.settings.users.allowedInboundsDesc')}
+              >
+                <Select
+                  mode="multiple"
+                  allowClear
+                  optionFilterProp="label"
+                  options={inboundOptions.map((ib) => ({
+                    value: ib.id,
+                    label: ib.remark || ib.tag || `#${ib.id}`,
+                  }))}
+                />
+              </Form.Item>
+              <Space wrap>
+                <Form.Item name="maxClients" label={t('pages.settings.users.maxClients')}>
+                  <InputNumber min={0} precision={0} />
+                </Form.Item>
+                <Form.Item name="maxTrafficGB" label={t('pages.settings.users.maxTrafficGB')}>
+                  <InputNumber min={0} precision={0} />
+                </Form.Item>
+                <Form.Item name="maxExpiryDays" label={t('pages.settings.users.maxExpiryDays')}>
+                  <InputNumber min={0} precision={0} />
+                </Form.Item>
+              </Space>
+            </Form.Item>
+          )}
+        </Form>
+      </Modal>
+    </>
+  );
+}
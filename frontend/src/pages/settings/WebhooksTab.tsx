@@ -0,0 +1,398 @@
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import {
+  Button,
+  Form,
+  Input,
+  InputNumber,
+  Modal,
+  Popconfirm,
+  Select,
+  Space,
+  Switch,
+  Table,
+  Tabs,
+  Tag,
+  Tooltip,
+} from 'antd';
+import type { TableColumnsType } from 'antd';
+import {
+  ApiOutlined,
+  DeleteOutlined,
+  EditOutlined,
+  HistoryOutlined,
+  PlusOutlined,
+  RedoOutlined,
+  SendOutlined,
+  SettingOutlined,
+} from '@ant-design/icons';
+
+import { IntlUtil, RandomUtil } from '@/utils';
+import type { CalendarKind } from '@/utils';
+import { onNumber } from '@/utils/onNumber';
+import type { AllSetting } from '@/models/setting';
+import { SettingListItem } from '@/components/ui';
+import { useMediaQuery } from '@/hooks/useMediaQuery';
+import {
+  useWebhookDeliveriesQuery,
+  useWebhooksQuery,
+  type Webhook,
+  type WebhookDelivery,
+  type WebhookDeliveryParams,
+} from '@/api/queries/useWebhooksQuery';
+import { useWebhookMutations } from '@/api/queries/useWebhookMutations';
+import { WEBHOOK_EVENTS, WebhookFormSchema, type WebhookFormValues } from '@/schemas/webhook';
+import { catTabLabel } from './catTabLabel';
+
+interface WebhooksTabProps {
+  allSetting: AllSetting;
+  updateSetting: (patch: Partial<AllSetting>) => void;
+}
+
+const statusColor: Record<string, string> = {
+  success: 'green',
+  pending: 'blue',
+  failed: 'red',
+};
+
+function prettyPayload(payload?: string): string {
+  try {
+    return JSON.stringify(JSON.parse(payload ?? ''), null, 2);
+  } catch {
+    return payload ?? '';
+  }
+}
+
+export default function WebhooksTab({ allSetting, updateSetting }: WebhooksTabProps) {
+  const { t } = useTranslation();
+  const { isMobile } = useMediaQuery();
+  const { webhooks, loading } = useWebhooksQuery();
+  const { add, update, remove, test, replay } = useWebhookMutations();
+  const [params, setParams] = useState<WebhookDeliveryParams>({ page: 1, pageSize: 50 });
+  const deliveries = useWebhookDeliveriesQuery(params);
+  const [editing, setEditing] = useState<Webhook | null>(null);
+  const [open, setOpen] = useState(false);
+  const [submitting, setSubmitting] = useState(false);
+  const [form] = Form.useForm<WebhookFormValues>();
+  const calendar = (allSetting.datepicker || 'gregorian') as CalendarKind;
+
+  const eventOptions = WEBHOOK_EVENTS.map((e) => ({
+    value: e.key,
+    label: t(`pages.settings.${e.label}`),
+  }));
+  const webhookName = (id: number) => webhooks.find((w) => w.id === id)?.name ?? `#${id}`;
+
+  function openModal(hook: Webhook | null) {
+    setEditing(hook);
+    form.setFieldsValue({
+      name: hook?.name ?? '',
+      url: hook?.url ?? '',
+      // A new webhook gets a random secret to copy into the receiver.
+      secret: hook ? '' : RandomUtil.randomSeq(32),
+      events: hook?.events ?? [],
+      enable: hook?.enable ?? true,
+      allowPrivate: hook?.allowPrivate ?? false,
+    });
+    setOpen(true);
+  }
+
+  async function submit() {
+    const payload = WebhookFormSchema.parse(await form.validateFields());
+    setSubmitting(true);
+    try {
+      const msg = editing ? await update(editing.id, payload) : await add(payload);
+      if (msg?.success) setOpen(false);
+    } finally {
+      setSubmitting(false);
+    }
+  }
+
+  function toggleEnable(hook: Webhook, enable: boolean) {
+    void update(hook.id, WebhookFormSchema.parse({ ...hook, events: hook.events ?? [], enable }));
+  }
+
+  const columns: TableColumnsType<Webhook> = [
+    { title: t('pages.settings.webhooks.name'), dataIndex: 'name', key: 'name' },
+    {
+      title: t('pages.settings.webhooks.url'),
+      dataIndex: 'url',
+      key: 'url',
+      render: (url: string) => <code>{url}</code>,
+    },
+    {
+      title: t('pages.settings.webhooks.events'),
+      key: 'events',
+      render: (_, hook) =>
+        hook.events?.length ? (
+          <Space size={[4, 4]} wrap>
+            {hook.events.map((e) => (
+              <Tag key={e}>{e}</Tag>
+            ))}
+          </Space>
+        ) : (
+          <Tag color="blue">{t('pages.settings.webhooks.allEvents')}</Tag>
+        ),
+    },
+    {
+      title: t('enable'),
+      key: 'enable',
+      render: (_, hook) => (
+        <Switch size="small" checked={hook.enable} onChange={(v) => toggleEnable(hook, v)} />
+      ),
+    },
+    {
+      key: 'actions',
+      render: (_, hook) => (
+        <Space size={4}>
+          <Tooltip title={t('pages.settings.webhooks.test')}>
+            <Button size="small" icon={<SendOutlined />} onClick={() => test(hook.id)} />
+          </Tooltip>
+          <Tooltip title={t('edit')}>
+            <Button size="small" icon={<EditOutlined />} onClick={() => openModal(hook)} />
+          </Tooltip>
+          <Popconfirm
+            title={t('pages.settings.webhooks.deleteConfirm')}
+            okText={t('delete')}
+            cancelText={t('cancel')}
+            onConfirm={() => remove(hook.id)}
+          >
+            <Button size="small" danger icon={<DeleteOutlined />} />
+          </Popconfirm>
+        </Space>
+      ),
+    },
+  ];
+
+  const deliveryColumns: TableColumnsType<WebhookDelivery> = [
+    {
+      title: t('pages.settings.webhooks.time'),
+      key: 'time',
+      render: (_, d) => IntlUtil.formatDate(d.createdAt, calendar),
+    },
+    {
+      title: t('pages.settings.webhooks.webhook'),
+      key: 'webhook',
+      render: (_, d) => webhookName(d.webhookId),
+    },
+    { title: t('pages.settings.webhooks.event'), dataIndex: 'event', key: 'event' },
+    {
+      title: t('status'),
+      key: 'status',
+      render: (_, d) => (
+        <Tooltip title={d.error}>
+          <Tag color={statusColor[d.status] ?? 'default'}>
+            {t(`pages.settings.webhooks.status.${d.status}`)}
+          </Tag>
+        </Tooltip>
+      ),
+    },
+    { title: t('pages.settings.webhooks.attempts'), dataIndex: 'attempts', key: 'attempts' },
+    {
+      title: t('pages.settings.webhooks.response'),
+      key: 'response',
+      render: (_, d) => d.responseCode || '—',
+    },
+    {
+      key: 'actions',
+      render: (_, d) => (
+        <Tooltip title={t('pages.settings.webhooks.replay')}>
+          <Button
+            size="small"
+            icon={<RedoOutlined />}
+            disabled={d.status === 'pending'}
+            onClick={() => replay(d.id)}
+          />
+        </Tooltip>
+      ),
+    },
+  ];
+
+  return (
+    <>
+      <Tabs
+        defaultActiveKey="1"
+        items={[
+          {
+            key: '1',
+            label: catTabLabel(<ApiOutlined />, t('pages.settings.webhooks.endpoints'), isMobile),
+            children: (
+              <>
+                <Space style={{ padding: '10px 20px' }}>
+                  <Button type="primary" icon={<PlusOutlined />} onClick={() => openModal(null)}>
+                    {t('pages.settings.webhooks.add')}
+                  </Button>
+                </Space>
+                <Table<Webhook>
+                  rowKey="id"
+                  size="small"
+                  loading={loading && webhooks.length === 0}
+                  columns={columns}
+                  dataSource={webhooks}
+                  pagination={false}
+                  scroll={{ x: 'max-content' }}
+                />
+              </>
+            ),
+          },
+          {
+            key: '2',
+            label: catTabLabel(
+              <HistoryOutlined />,
+              t('pages.settings.webhooks.deliveries'),
+              isMobile,
+            ),
+            children: (
+              <>
+                <Space wrap style={{ padding: '10px 20px' }}>
+                  <Select
+                    allowClear
+                    placeholder={t('pages.settings.webhooks.webhook')}
+                    value={params.webhookId}
+                    onChange={(webhookId) => setParams((p) => ({ ...p, webhookId, page: 1 }))}
+                    options={webhooks.map((w) => ({ value: w.id, label: w.name }))}
+                    style={{ width: 180 }}
+                  />
+                  <Select
+                    allowClear
+                    placeholder={t('status')}
+                    value={params.status}
+                    onChange={(status) => setParams((p) => ({ ...p, status, page: 1 }))}
+                    options={['pending', 'success', 'failed'].map((s) => ({
+                      value: s,
+                      label: t(`pages.settings.webhooks.status.${s}`),
+                    }))}
+                    style={{ width: 140 }}
+                  />
+                </Space>
+                <Table<WebhookDelivery>
+                  rowKey="id"
+                  size="small"
+                  loading={deliveries.loading}
+                  columns={deliveryColumns}
+                  dataSource={deliveries.items}
+                  scroll={{ x: 'max-content' }}
+                  pagination={{
+                    current: params.page,
+                    pageSize: params.pageSize,
+                    total: deliveries.total,
+                    showSizeChanger: true,
+                    onChange: (page, pageSize) => setParams((p) => ({ ...p, page, pageSize })),
+                  }}
+                  expandable={{
+                    expandedRowRender: (d) => (
+                      <pre style={{ margin: 0, whiteSpace: 'pre-wrap' }}>
+                        {prettyPayload(d.payload)}
+                      </pre>
+                    ),
+                  }}
+                />
+              </>
+            ),
+          },
+          {
+            key: '3',
+            label: catTabLabel(
+              <SettingOutlined />,
+              t('pages.settings.webhooks.thresholds'),
+              isMobile,
+            ),
+            children: (
+              <>
+                <SettingListItem
+                  paddings="small"
+                  title={t('pages.settings.eventCPUHigh')}
+                  description={t('pages.settings.webhooks.thresholdDesc')}
+                >
+                  <InputNumber
+                    value={allSetting.webhookCpu}
+                    min={0}
+                    max={100}
+                    style={{ width: '100%' }}
+                    onChange={onNumber((v) => updateSetting({ webhookCpu: v }))}
+                  />
+                </SettingListItem>
+                <SettingListItem
+                  paddings="small"
+                  title={t('pages.settings.eventMemoryHigh')}
+                  description={t('pages.settings.webhooks.thresholdDesc')}
+                >
+                  <InputNumber
+                    value={allSetting.webhookMemory}
+                    min={0}
+                    max={100}
+                    style={{ width: '100%' }}
+                    onChange={onNumber((v) => updateSetting({ webhookMemory: v }))}
+                  />
+                </SettingListItem>
+              </>
+            ),
+          },
+        ]}
+      />
+
+      <Modal
+        open={open}
+        title={editing ? t('pages.settings.webhooks.edit') : t('pages.settings.webhooks.add')}
+        okText={editing ? t('save') : t('create')}
+        cancelText={t('cancel')}
+        confirmLoading={submitting}
+        onOk={submit}
+        onCancel={() => setOpen(false)}
+        destroyOnHidden
+      >
+        <Form<WebhookFormValues> form={form} layout="vertical">
+          <Form.Item
+            name="name"
+            label={t('pages.settings.webhooks.name')}
+            rules={[{ required: true, max: 64 }]}
+          >
+            <Input />
+          </Form.Item>
+          <Form.Item
+            name="url"
+            label={t('pages.settings.webhooks.url')}
+            rules={[{ required: true, type: 'url' }]}
+          >
+            <Input placeholder="https://hooks.example.com/3x-ui" />
+          </Form.Item>
+          <Form.Item
+            label={t('pages.settings.webhooks.secret')}
+            required={!editing}
+            extra={
+              editing
+                ? t('pages.settings.webhooks.secretKeep')
+                : t('pages.settings.webhooks.secretDesc')
+            }
+          >
+            <Space.Compact style={{ width: '100%' }}>
+              <Form.Item name="secret" noStyle rules={[{ required: !editing }]}>
+                <Input />
+              </Form.Item>
+              <Button onClick={() => form.setFieldValue('secret', RandomUtil.randomSeq(32))}>
+                {t('regenerate')}
+              </Button>
+            </Space.Compact>
+          </Form.Item>
+          <Form.Item
+            name="events"
+            label={t('pages.settings.webhooks.events')}
+            extra={t('pages.settings.webhooks.eventsDesc')}
+          >
+            <Select mode="multiple" allowClear options={eventOptions} />
+          </Form.Item>
+          <Form.Item name="enable" label={t('enable')} valuePropName="checked">
+            <Switch />
+          </Form.Item>
+          <Form.Item
+            name="allowPrivate"
+            label={t('pages.settings.webhooks.allowPrivate')}
+            extra={t('pages.settings.webhooks.allowPrivateDesc')}
+            valuePropName="checked"
+          >
+            <Switch />
+          </Form.Item>
+        </Form>
+      </Modal>
+    </>
+  );
+}
@@ -32,14 +32,16 @@ import {
   TranslationOutlined,
 } from '@ant-design/icons';
 
-import { ClipboardManager, IntlUtil, LanguageManager } from '@/utils';
+import { ClipboardManager, IntlUtil, LanguageManager, SizeFormatter } from '@/utils';
 import { isPostQuantumLink, wireguardConfigFromLink } from '@/lib/xray/inbound-link';
 import { LinkTags, parseLinkParts } from '@/lib/xray/link-label';
 import ConfigBlock from '@/components/clients/ConfigBlock';
+import { Sparkline } from '@/components/viz';
 import { setMessageInstance } from '@/utils/messageBus';
 import { pauseAnimationsUntilLeave, useTheme } from '@/hooks/useTheme';
 import { useMediaQuery } from '@/hooks/useMediaQuery';
 import SubUsageSummary from './SubUsageSummary';
+import SubRedeemForm, { type RedeemResult } from './SubRedeemForm';
 import './SubPage.css';
 
 const QR_SIZE = 240;
@@ -59,12 +61,23 @@ const lastOnlineMs = Number(subData.lastOnline || 0);
 const subUrl = subData.subUrl || '';
 const subJsonUrl = subData.subJsonUrl || '';
 const subClashUrl = subData.subClashUrl || '';
+const subSingboxUrl = subData.subSingboxUrl || '';
 const subTitle = subData.subTitle || '';
 const links: string[] = Array.isArray(subData.links) ? subData.links : [];
 const linkEmails: string[] = Array.isArray(subData.emails) ? subData.emails : [];
 const subEmail = [...new Set(linkEmails.filter(Boolean))].join(', ');
 const datepicker = subData.datepicker || 'gregorian';
 const announce = subData.announce || '';
+const usage = Array.isArray(subData.usage) ? subData.usage : [];
+const redeemUrl = subData.redeemUrl || '';
+const redeemOnly = !!subData.redeemOnly;
+const hasUsage = usage.some((p) => p.up > 0 || p.down > 0);
+const usageLabels = usage.map((p) =>
+  new Date(p.t * 1000).toLocaleDateString(datepicker === 'jalalian' ? 'fa-IR' : undefined, {
+    month: '2-digit',
+    day: '2-digit',
+  }),
+);
 
 const appendRawView = (url: string) => `${url}${url.includes('?') ? '&' : '?'}view=raw`;
 
@@ -90,6 +103,7 @@ export default function SubPage() {
   }, [messageApi]);
   const { isMobile } = useMediaQuery(576);
   const [lang, setLang] = useState<string>(() => LanguageManager.getLanguage());
+  const [redeemed, setRedeemed] = useState<RedeemResult | null>(null);
 
   const onLangChange = useCallback((next: string) => {
     setLang(next);
@@ -293,6 +307,61 @@ export default function SubPage() {
     </Space>
   );
 
+  const onToppedUp = useCallback(() => {
+    messageApi.success(t('subscription.redeem.toppedUp'));
+    // The page is rendered server-side from the client record; reload to
+    // pick up the new quota and expiry.
+    window.setTimeout(() => window.location.reload(), 1200);
+  }, [t, messageApi]);
+
+  if (redeemOnly) {
+    return (
+      <ConfigProvider theme={antdThemeConfig}>
+        {messageContextHolder}
+        <Layout className={pageClass}>
+          <Layout.Content className="content">
+            <Row justify="center">
+              <Col xs={24} sm={22} md={18} lg={14} xl={12}>
+                <Card
+                  hoverable
+                  className="subscription-card"
+                  title={subTitle || t('subscription.redeem.title')}
+                  extra={cardExtra}
+                >
+                  {redeemed ? (
+                    <Space orientation="vertical" align="center" style={{ width: '100%' }}>
+                      <Alert type="success" showIcon title={t('subscription.redeem.created')} />
+                      <QRCode
+                        value={redeemed.subUrl}
+                        size={QR_SIZE}
+                        type="svg"
+                        color="#000000"
+                        bgColor="#ffffff"
+                      />
+                      <Space.Compact style={{ width: '100%' }}>
+                        <Button block onClick={() => copy(redeemed.subUrl)}>
+                          <CopyOutlined /> {t('copy')}
+                        </Button>
+                        <Button block type="primary" onClick={() => open(redeemed.subUrl)}>
+                          {t('subscription.redeem.open')}
+                        </Button>
+                      </Space.Compact>
+                    </Space>
+                  ) : (
+                    <Space orientation="vertical" style={{ width: '100%' }}>
+                      <span>{t('subscription.redeem.newDesc')}</span>
+                      <SubRedeemForm redeemUrl={redeemUrl} subId="" onRedeemed={setRedeemed} />
+                    </Space>
+                  )}
+                </Card>
+              </Col>
+            </Row>
+          </Layout.Content>
+        </Layout>
+      </ConfigProvider>
+    );
+  }
+
   return (
     <ConfigProvider theme={antdThemeConfig}>
       {messageContextHolder}
@@ -325,7 +394,27 @@ export default function SubPage() {
                   isActive={isActive}
                 />
 
-                {(subUrl || subJsonUrl || subClashUrl) && (
+                {hasUsage && (
+                  <>
+                    <Divider>{t('subscription.dailyUsage')}</Divider>
+                    <Sparkline
+                      data={usage.map((p) => p.down)}
+                      data2={usage.map((p) => p.up)}
+                      labels={usageLabels}
+                      height={140}
+                      maxPoints={usage.length}
+                      fillOpacity={0.18}
+                      showTooltip
+                      valueMax={null}
+                      name1={t('subscription.downloaded')}
+                      name2={t('subscription.uploaded')}
+                      yFormatter={SizeFormatter.sizeFormat}
+                      tooltipFormatter={SizeFormatter.sizeFormat}
+                    />
+                  </>
+                )}
+
+                {(subUrl || subJsonUrl || subClashUrl || subSingboxUrl) && (
                   <>
                     <Divider>{t('subscription.title')}</Divider>
                     <div className="links-section">
@@ -505,6 +594,69 @@ export default function SubPage() {
                           </div>
                         </div>
                       )}
+                      {subSingboxUrl && (
+                        <div className="sub-link-row">
+                          <Tooltip title="sing-box / Hiddify / NekoBox">
+                            <Tag color="cyan" className="sub-link-tag">
+                              SING-BOX
+                            </Tag>
+                          </Tooltip>
+                          <a
+                            href={subSingboxUrl}
+                            target="_blank"
+                            rel="noopener noreferrer"
+                            className="sub-link-title sub-link-anchor"
+                            title={subSingboxUrl}
+                          >
+                            {sId}
+                          </a>
+                          <div className="sub-link-actions">
+                            <Button
+                              size="small"
+                              href={appendRawView(subSingboxUrl)}
+                              target="_blank"
+                              rel="noopener noreferrer"
+                              icon={<DownloadOutlined />}
+                              aria-label={t('download')}
+                              title={t('download')}
+                            />
+                            <Button
+                              size="small"
+                              icon={<CopyOutlined />}
+                              onClick={() => copy(subSingboxUrl)}
+                              aria-label={t('copy')}
+                              title={t('copy')}
+                            />
+                            <Popover
+                              trigger="click"
+                              placement="left"
+                              destroyOnHidden
+                              content={
+                                <div className="sub-link-qr-popover">
+                                  <Tag color="cyan" className="qr-tag">
+                                    sing-box
+                                  </Tag>
+                                  <QRCode
+                                    value={subSingboxUrl}
+                                    size={QR_SIZE}
+                                    type="svg"
+                                    bordered={false}
+                                    color="#000000"
+                                    bgColor="#ffffff"
+                                  />
+                                </div>
+                              }
+                            >
+                              <Button
+                                size="small"
+                                icon={<QrcodeOutlined />}
+                                aria-label="QR"
+                                title="QR"
+                              />
+                            </Popover>
+                          </div>
+                        </div>
+                      )}
                     </div>
                   </>
                 )}
@@ -597,6 +749,13 @@ export default function SubPage() {
                   </>
                 )}
 
+                {redeemUrl && (
+                  <>
+                    <Divider>{t('subscription.redeem.title')}</Divider>
+                    <SubRedeemForm redeemUrl={redeemUrl} subId={sId} onRedeemed={onToppedUp} />
+                  </>
+                )}
+
                 <Row gutter={[8, 8]} justify="center" className="apps-row">
                   <Col xs={24} sm={12} className="app-col">
                     <Dropdown trigger={['click']} menu={{ items: androidMenuItems }}>
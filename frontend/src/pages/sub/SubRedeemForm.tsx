@@ -0,0 +1,73 @@
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { Alert, Button, Input, Space } from 'antd';
+import { GiftOutlined } from '@ant-design/icons';
+
+export interface RedeemResult {
+  created: boolean;
+  subId: string;
+  subUrl: string;
+}
+
+interface SubRedeemFormProps {
+  redeemUrl: string;
+  // Empty on the standalone redeem page: the voucher then creates a client.
+  subId: string;
+  onRedeemed: (result: RedeemResult) => void;
+}
+
+// The server answers with a short error code rather than a message so the
+// page can translate it; unknown codes fall back to the generic one.
+const ERROR_CODES = ['invalid', 'rateLimited', 'notApplicable', 'failed'];
+
+export default function SubRedeemForm({ redeemUrl, subId, onRedeemed }: SubRedeemFormProps) {
+  const { t } = useTranslation();
+  const [code, setCode] = useState('');
+  const [busy, setBusy] = useState(false);
+  const [error, setError] = useState('');
+
+  async function submit() {
+    const trimmed = code.trim();
+    if (!trimmed || busy) return;
+    setBusy(true);
+    setError('');
+    try {
+      const resp = await fetch(redeemUrl, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ code: trimmed, subId }),
+      });
+      const msg = await resp.json();
+      if (msg?.success && msg.obj) {
+        setCode('');
+        onRedeemed(msg.obj as RedeemResult);
+        return;
+      }
+      setError(ERROR_CODES.includes(msg?.msg) ? msg.msg : 'failed');
+    } catch {
+      setError('failed');
+    } finally {
+      setBusy(false);
+    }
+  }
+
+  return (
+    <Space orientation="vertical" style={{ width: '100%' }}>
+      <Space.Compact style={{ width: '100%' }}>
+        <Input
+          value={code}
+          maxLength={64}
+          autoComplete="off"
+          placeholder={t('subscription.redeem.placeholder')}
+          prefix={<GiftOutlined />}
+          onChange={(e) => setCode(e.target.value)}
+          onPressEnter={submit}
+        />
+        <Button type="primary" loading={busy} disabled={!code.trim()} onClick={submit}>
+          {t('subscription.redeem.submit')}
+        </Button>
+      </Space.Compact>
+      {error && <Alert type="error" showIcon title={t(`subscription.redeem.errors.${error}`)} />}
+    </Space>
+  );
+}
@@ -0,0 +1,37 @@
+import { z } from 'zod';
+
+export const AcmeChallengeSchema = z.enum(['http-01', 'tls-alpn-01', 'dns-01']);
+export type AcmeChallenge = z.infer<typeof AcmeChallengeSchema>;
+
+export const AcmeCertificateSchema = z
+  .object({
+    id: z.number(),
+    // Backend serializes a nil []string as null.
+    domains: z.array(z.string()).nullish(),
+    challenge: z.string().optional(),
+    dnsProvider: z.string().optional(),
+    hasDnsConfig: z.boolean().optional(),
+    autoRenew: z.boolean().optional(),
+    status: z.enum(['pending', 'valid', 'error']).or(z.string()).optional(),
+    notBefore: z.number().optional(),
+    notAfter: z.number().optional(),
+    lastError: z.string().optional(),
+    lastAttempt: z.number().optional(),
+    certFile: z.string().optional(),
+    keyFile: z.string().optional(),
+  })
+  .loose();
+
+export type AcmeCertificate = z.infer<typeof AcmeCertificateSchema>;
+
+export const AcmeCertificateListSchema = z.array(AcmeCertificateSchema);
+
+export const AcmeIssueFormSchema = z.object({
+  domains: z.array(z.string().trim().min(1)).min(1),
+  challenge: AcmeChallengeSchema,
+  dnsProvider: z.string().default(''),
+  dnsConfig: z.record(z.string(), z.string()).default({}),
+  autoRenew: z.boolean().default(true),
+});
+
+export type AcmeIssueValues = z.infer<typeof AcmeIssueFormSchema>;
@@ -6,7 +6,7 @@ export const HostSecuritySchema = z.enum(['same', 'tls', 'none', 'reality']);
 export type HostSecurity = z.infer<typeof HostSecuritySchema>;
 
 export const MihomoIpVersionSchema = z.enum(['dual', 'ipv4', 'ipv6', 'ipv4-prefer', 'ipv6-prefer']);
-export const SubTypeSchema = z.enum(['raw', 'json', 'clash']);
+export const SubTypeSchema = z.enum(['raw', 'json', 'clash', 'singbox']);
 
 const HostTagSchema = z
   .string()
@@ -0,0 +1,49 @@
+import { z } from 'zod';
+
+export const AUDIT_TARGETS = [
+  'inbound',
+  'client',
+  'host',
+  'node',
+  'setting',
+  'xray',
+  'webhook',
+  'plan',
+  'voucher',
+] as const;
+
+export const AuditChangeSchema = z.object({
+  before: z.unknown().optional(),
+  after: z.unknown().optional(),
+});
+
+export type AuditChange = z.infer<typeof AuditChangeSchema>;
+
+export const AuditLogSchema = z
+  .object({
+    id: z.number(),
+    createdAt: z.number(),
+    actor: z.string().optional(),
+    actorType: z.string().optional(),
+    sourceIp: z.string().optional(),
+    method: z.string().optional(),
+    route: z.string().optional(),
+    targetType: z.string().optional(),
+    targetId: z.string().optional(),
+    success: z.boolean().optional(),
+    error: z.string().optional(),
+    // Backend serializes a nil map as null.
+    changes: z.record(z.string(), AuditChangeSchema).nullish(),
+  })
+  .loose();
+
+export type AuditLog = z.infer<typeof AuditLogSchema>;
+
+export const AuditPageSchema = z.object({
+  items: z.array(AuditLogSchema),
+  total: z.number(),
+  page: z.number(),
+  pageSize: z.number(),
+});
+
+export type AuditPage = z.infer<typeof AuditPageSchema>;
@@ -0,0 +1,19 @@
+import { z } from 'zod';
+
+export const BackupManifestSchema = z
+  .object({
+    name: z.string(),
+    target: z.enum(['local', 's3']).or(z.string()),
+    database: z.string().optional(),
+    version: z.string().optional(),
+    size: z.number(),
+    sha256: z.string().optional(),
+    encrypted: z.boolean().optional(),
+    createdAt: z.number(),
+  })
+  .loose();
+
+export type BackupManifest = z.infer<typeof BackupManifestSchema>;
+
+// The backend serializes a nil slice as null when no target is configured.
+export const BackupListSchema = z.array(BackupManifestSchema).nullish();
@@ -37,6 +37,8 @@ export const ClientRecordSchema = z
     limitHwid: z.number().optional(),
     tgId: z.union([z.number(), z.string()]).optional(),
     group: z.string().optional(),
+    egress: z.string().optional(),
+    schedule: z.string().optional(),
     comment: z.string().optional(),
     enable: z.boolean().optional(),
     reset: z.number().optional(),
@@ -224,6 +226,8 @@ export const GroupSummarySchema = z.object({
     .number()
     .nullable()
     .transform((v) => v ?? 0),
+  egress: z.string().optional(),
+  schedule: z.string().optional(),
 });
 
 export const GroupSummaryListSchema = z
@@ -265,6 +269,8 @@ export const ClientFormSchema = z.object({
   limitHwid: z.number().int().min(0),
   tgId: z.number().int().min(0),
   group: z.string(),
+  egress: z.string(),
+  schedule: z.string(),
   comment: z.string(),
   enable: z.boolean(),
   inboundIds: z.array(z.number()),
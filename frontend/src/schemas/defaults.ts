@@ -12,6 +12,8 @@ export const DefaultsPayloadSchema = z
     subJsonEnable: z.boolean().optional(),
     subClashURI: z.string().optional(),
     subClashEnable: z.boolean().optional(),
+    subSingboxURI: z.string().optional(),
+    subSingboxEnable: z.boolean().optional(),
     pageSize: z.number().optional(),
     datepicker: z.enum(['gregorian', 'jalalian']).optional(),
     ipLimitEnable: z.boolean().optional(),
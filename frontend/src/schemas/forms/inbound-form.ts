@@ -28,6 +28,8 @@ export const InboundDbFieldsSchema = z.object({
   shareAddr: z.string().default(''),
   subSortIndex: z.number().int().min(1).default(1),
   disableFlow: z.boolean().default(false),
+  trafficCoefficient: z.number().min(0).max(100).default(1),
+  standbyNodeId: z.number().int().nullable().optional(),
 });
 export type InboundDbFields = z.infer<typeof InboundDbFieldsSchema>;
 
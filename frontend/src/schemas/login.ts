@@ -6,8 +6,6 @@ export const LoginFormSchema = z.object({
   twoFactorCode: z.string().optional(),
 });
 
-export const TwoFactorCodeSchema = z.string().min(1, 'twoFactorCode');
-
 export const TotpCodeSchema = z
   .string()
   .regex(/^\d{6}$/, 'pages.settings.security.twoFactorModalError');
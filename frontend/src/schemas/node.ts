@@ -25,6 +25,8 @@ export const NodeRecordSchema = z
     activeCount: z.number().optional(),
     disabledCount: z.number().optional(),
     depletedCount: z.number().optional(),
+    // Runtime operations queued for the node while it was unreachable.
+    outboxCount: z.number().optional(),
     lastHeartbeat: z.number().optional(),
     lastError: z.string().optional(),
     // Xray state captured from the remote node's own /panel/api/server/status.
@@ -39,6 +41,7 @@ export const NodeRecordSchema = z
     // Backend serializes a nil []string as null for nodes saved before #5178.
     inboundTags: z.array(z.string()).nullish(),
     outboundTag: z.string().optional(),
+    trafficCoefficient: z.number().optional(),
     // Multi-hop node tree (#4983): a node's stable GUID, its parent's GUID, and
     // whether it's a read-only transitive sub-node surfaced from a downstream node.
     guid: z.string().optional(),
@@ -86,6 +89,7 @@ export const NodeFormSchema = z
       .nullish()
       .transform((tags) => tags ?? []),
     outboundTag: z.string().optional(),
+    trafficCoefficient: z.number().min(0).max(100),
   })
   .superRefine((val, ctx) => {
     if (val.tlsVerifyMode !== 'mtls' && val.apiToken.length === 0 && !val.hasStoredToken) {
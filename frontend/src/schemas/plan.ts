@@ -0,0 +1,58 @@
+import { z } from 'zod';
+
+import { TRAFFIC_RESETS } from '@/schemas/primitives';
+
+// Must match the PlanRenew* constants in internal/database/model/model.go.
+export const PLAN_RENEW_MODES = ['extend', 'addQuota', 'reset'] as const;
+
+export type PlanRenewMode = (typeof PLAN_RENEW_MODES)[number];
+
+export const PlanSchema = z
+  .object({
+    id: z.number(),
+    name: z.string(),
+    comment: z.string().optional(),
+    // Bytes; 0 means unlimited.
+    totalGB: z.number(),
+    durationDays: z.number(),
+    startAfterFirstUse: z.boolean().optional(),
+    limitIp: z.number().optional(),
+    limitHwid: z.number().optional(),
+    trafficReset: z.string().optional(),
+    trafficResetDay: z.number().optional(),
+    group: z.string().optional(),
+    // Backend serializes a nil []int as null.
+    inboundIds: z.array(z.number()).nullish(),
+    renewMode: z.enum(PLAN_RENEW_MODES).or(z.string()),
+    createdAt: z.number().optional(),
+    updatedAt: z.number().optional(),
+  })
+  .loose();
+
+export type Plan = z.infer<typeof PlanSchema>;
+
+export const PlanListSchema = z.array(PlanSchema);
+
+export const PlanFormSchema = z.object({
+  name: z.string().trim().min(1).max(64),
+  comment: z.string().trim().max(256).default(''),
+  totalGB: z.number().int().min(0).default(0),
+  durationDays: z.number().int().min(0).max(36500).default(30),
+  startAfterFirstUse: z.boolean().default(false),
+  limitIp: z.number().int().min(0).default(0),
+  limitHwid: z.number().int().min(0).default(0),
+  trafficReset: z.enum(TRAFFIC_RESETS).default('never'),
+  trafficResetDay: z.number().int().min(1).max(31).default(1),
+  group: z.string().trim().max(64).default(''),
+  inboundIds: z.array(z.number()).default([]),
+  renewMode: z.enum(PLAN_RENEW_MODES).default('extend'),
+});
+
+export type PlanFormValues = z.infer<typeof PlanFormSchema>;
+
+export const PlanRenewResultSchema = z.object({
+  renewed: z.number(),
+  skipped: z.array(z.object({ email: z.string(), reason: z.string() })).nullish(),
+});
+
+export type PlanRenewResult = z.infer<typeof PlanRenewResultSchema>;
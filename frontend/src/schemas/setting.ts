@@ -30,10 +30,10 @@ export const AllSettingSchema = z
     tgRunTime: z.string().optional(),
     tgBotBackup: z.boolean().optional(),
     tgCpu: z.number().int().min(0).max(100).optional(),
+    webhookCpu: z.number().int().min(0).max(100).optional(),
+    webhookMemory: z.number().int().min(0).max(100).optional(),
     outboundDownThreshold: z.number().int().min(1).max(100).optional(),
     tgLang: z.string().optional(),
-    twoFactorEnable: z.boolean().optional(),
-    twoFactorToken: z.string().optional(),
     xrayTemplateConfig: z.string().optional(),
     subEnable: z.boolean().optional(),
     subJsonEnable: z.boolean().optional(),
@@ -42,6 +42,8 @@ export const AllSettingSchema = z
     subJsonUserAgentRegex: z.string().max(2048).optional(),
     subClashAutoDetect: z.boolean().optional(),
     subClashUserAgentRegex: z.string().max(2048).optional(),
+    subSingboxAutoDetect: z.boolean().optional(),
+    subSingboxUserAgentRegex: z.string().max(2048).optional(),
     subTitle: z.string().optional(),
     subSupportUrl: z.string().optional(),
     subProfileUrl: z.string().optional(),
@@ -56,6 +58,8 @@ export const AllSettingSchema = z
     subJsonPath: absolutePath.optional(),
     subClashEnable: z.boolean().optional(),
     subClashPath: absolutePath.optional(),
+    subSingboxEnable: z.boolean().optional(),
+    subSingboxPath: absolutePath.optional(),
     subDomain: z.string().optional(),
     externalTrafficInformEnable: z.boolean().optional(),
     externalTrafficInformURI: z.string().optional(),
@@ -69,6 +73,9 @@ export const AllSettingSchema = z
     subClashURI: z.string().optional(),
     subClashEnableRouting: z.boolean().optional(),
     subClashRules: z.string().optional(),
+    subSingboxURI: z.string().optional(),
+    subSingboxMux: z.string().optional(),
+    subSingboxRules: z.string().optional(),
     subJsonMux: z.string().optional(),
     subJsonRules: z.string().optional(),
     subJsonFinalMask: z.string().optional(),
@@ -95,13 +102,31 @@ export const AllSettingSchema = z
     ldapDefaultTotalGB: nonNegativeInt.optional(),
     ldapDefaultExpiryDays: nonNegativeInt.optional(),
     ldapDefaultLimitIP: nonNegativeInt.optional(),
+    acmeEmail: z.string().optional(),
+    acmeDirectoryUrl: z.string().optional(),
+    acmeHttpPort: nonNegativeInt.max(65535).optional(),
+    acmeTlsAlpnPort: nonNegativeInt.max(65535).optional(),
+    acmeRenewDays: z.number().int().min(1).max(89).optional(),
+    auditRetentionDays: nonNegativeInt.optional(),
+    metricsClientLabels: z.boolean().optional(),
+    trafficHistoryHourlyDays: z.number().int().min(1).max(90).optional(),
+    trafficHistoryDays: nonNegativeInt.optional(),
+    backupEnable: z.boolean().optional(),
+    backupCron: z.string().optional(),
+    backupKeep: nonNegativeInt.optional(),
+    backupLocalDir: z.string().optional(),
+    backupS3Endpoint: z.string().optional(),
+    backupS3Bucket: z.string().optional(),
+    backupS3PathStyle: z.boolean().optional(),
     hasTgBotToken: z.boolean().optional(),
-    hasTwoFactorToken: z.boolean().optional(),
     hasLdapPassword: z.boolean().optional(),
     hasApiToken: z.boolean().optional(),
     hasWarpSecret: z.boolean().optional(),
     hasNordSecret: z.boolean().optional(),
     hasSmtpPassword: z.boolean().optional(),
+    hasBackupPassphrase: z.boolean().optional(),
+    hasBackupS3SecretKey: z.boolean().optional(),
+    unreadableSecrets: z.array(z.string()).nullish(),
   })
   .loose();
 
@@ -114,6 +139,7 @@ export type AllSettingInput = z.infer<typeof AllSettingSchema>;
 export const AllSettingResponseSchema = AllSettingSchema.extend({
   subJsonUserAgentRegex: z.string().optional(),
   subClashUserAgentRegex: z.string().optional(),
+  subSingboxUserAgentRegex: z.string().optional(),
 });
 
 export const FactoryDefaultsSchema = z.record(z.string(), z.string());
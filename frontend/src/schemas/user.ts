@@ -0,0 +1,47 @@
+import { z } from 'zod';
+
+export const UserRoleSchema = z.enum(['owner', 'admin', 'reseller', 'read-only']);
+export type UserRole = z.infer<typeof UserRoleSchema>;
+
+// Route groups and access levels; must match internal/database/model/user_role.go.
+export const PERM_GROUPS = ['inbounds', 'clients', 'nodes', 'settings', 'xray'] as const;
+export type PermGroup = (typeof PERM_GROUPS)[number];
+
+export const AccessLevelSchema = z.enum(['none', 'read', 'write']);
+export type AccessLevel = z.infer<typeof AccessLevelSchema>;
+
+export const PanelUserSchema = z
+  .object({
+    id: z.number(),
+    username: z.string(),
+    role: UserRoleSchema.or(z.string()),
+    // Backend serializes a nil map as null.
+    permissions: z.record(z.string(), z.string()).nullish(),
+    effective: z.record(z.string(), z.string()).nullish(),
+    twoFactorEnable: z.boolean().optional(),
+    primary: z.boolean().optional(),
+    // Reseller limits; 0 is unlimited. usage is only sent for resellers.
+    allowedInbounds: z.array(z.number()).nullish(),
+    maxClients: z.number().optional(),
+    maxTrafficGB: z.number().optional(),
+    maxExpiryDays: z.number().optional(),
+    usage: z.object({ clients: z.number(), totalGB: z.number() }).nullish(),
+  })
+  .loose();
+
+export type PanelUser = z.infer<typeof PanelUserSchema>;
+
+export const PanelUserListSchema = z.array(PanelUserSchema);
+
+export const UserAccountFormSchema = z.object({
+  username: z.string().trim().min(1).max(64),
+  password: z.string().max(128).default(''),
+  role: UserRoleSchema,
+  permissions: z.record(z.string(), AccessLevelSchema).default({}),
+  allowedInbounds: z.array(z.number().int()).default([]),
+  maxClients: z.number().int().min(0).default(0),
+  maxTrafficGB: z.number().int().min(0).default(0),
+  maxExpiryDays: z.number().int().min(0).default(0),
+});
+
+export type UserAccountValues = z.infer<typeof UserAccountFormSchema>;
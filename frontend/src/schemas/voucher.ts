@@ -0,0 +1,53 @@
+import { z } from 'zod';
+
+// Must match the VoucherKind* constants in internal/database/model/model.go.
+export const VOUCHER_KINDS = ['any', 'create', 'topUp'] as const;
+
+export type VoucherKind = (typeof VOUCHER_KINDS)[number];
+
+export const VoucherSchema = z
+  .object({
+    id: z.number(),
+    code: z.string(),
+    planId: z.number(),
+    kind: z.enum(VOUCHER_KINDS).or(z.string()),
+    // 0 means unlimited.
+    maxUses: z.number(),
+    uses: z.number(),
+    // Unix ms; 0 means never.
+    expiresAt: z.number(),
+    enable: z.boolean(),
+    comment: z.string().optional(),
+    createdAt: z.number().optional(),
+    updatedAt: z.number().optional(),
+  })
+  .loose();
+
+export type Voucher = z.infer<typeof VoucherSchema>;
+
+export const VoucherListSchema = z.array(VoucherSchema);
+
+export const VoucherGenerateFormSchema = z.object({
+  planId: z.number().int().positive(),
+  count: z.number().int().min(1).max(500).default(1),
+  kind: z.enum(VOUCHER_KINDS).default('any'),
+  maxUses: z.number().int().min(0).default(1),
+  expiresAt: z.number().int().min(0).default(0),
+  prefix: z
+    .string()
+    .trim()
+    .max(16)
+    .regex(/^[A-Za-z0-9]*$/)
+    .default(''),
+  comment: z.string().trim().max(256).default(''),
+});
+
+export type VoucherGenerateFormValues = z.infer<typeof VoucherGenerateFormSchema>;
+
+export interface VoucherUpdateValues {
+  kind: VoucherKind;
+  maxUses: number;
+  expiresAt: number;
+  enable: boolean;
+  comment: string;
+}
@@ -0,0 +1,70 @@
+import { z } from 'zod';
+
+// Event bus events a webhook may subscribe to; must match webhookEventTypes in
+// internal/web/service/webhook.go. Labels reuse the notification event keys.
+export const WEBHOOK_EVENTS = [
+  { key: 'outbound.down', label: 'eventOutboundDown' },
+  { key: 'outbound.up', label: 'eventOutboundUp' },
+  { key: 'xray.crash', label: 'eventXrayCrash' },
+  { key: 'node.down', label: 'eventNodeDown' },
+  { key: 'node.up', label: 'eventNodeUp' },
+  { key: 'cpu.high', label: 'eventCPUHigh' },
+  { key: 'memory.high', label: 'eventMemoryHigh' },
+  { key: 'login.attempt', label: 'eventLoginAttempt' },
+] as const;
+
+export const WebhookSchema = z
+  .object({
+    id: z.number(),
+    name: z.string(),
+    url: z.string(),
+    // Backend serializes a nil []string as null; empty means every event.
+    events: z.array(z.string()).nullish(),
+    enable: z.boolean().optional(),
+    allowPrivate: z.boolean().optional(),
+    createdAt: z.number().optional(),
+    updatedAt: z.number().optional(),
+  })
+  .loose();
+
+export type Webhook = z.infer<typeof WebhookSchema>;
+
+export const WebhookListSchema = z.array(WebhookSchema);
+
+export const WebhookDeliverySchema = z
+  .object({
+    id: z.number(),
+    webhookId: z.number(),
+    event: z.string(),
+    payload: z.string().optional(),
+    status: z.enum(['pending', 'success', 'failed']).or(z.string()),
+    attempts: z.number().optional(),
+    nextAttemptAt: z.number().optional(),
+    responseCode: z.number().optional(),
+    error: z.string().optional(),
+    createdAt: z.number(),
+  })
+  .loose();
+
+export type WebhookDelivery = z.infer<typeof WebhookDeliverySchema>;
+
+export const WebhookDeliveryPageSchema = z.object({
+  items: z.array(WebhookDeliverySchema),
+  total: z.number(),
+  page: z.number(),
+  pageSize: z.number(),
+});
+
+export type WebhookDeliveryPage = z.infer<typeof WebhookDeliveryPageSchema>;
+
+export const WebhookFormSchema = z.object({
+  name: z.string().trim().min(1).max(64),
+  url: z.string().trim().min(1).max(2048),
+  // Blank on edit keeps the stored secret.
+  secret: z.string().trim().max(256).default(''),
+  events: z.array(z.string()).default([]),
+  enable: z.boolean().default(true),
+  allowPrivate: z.boolean().default(false),
+});
+
+export type WebhookFormValues = z.infer<typeof WebhookFormSchema>;
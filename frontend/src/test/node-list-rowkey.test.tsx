@@ -29,9 +29,11 @@ describe('NodeList desktop table row keys', () => {
         onSelectionChange={noop}
         onAdd={noop}
         onMtls={noop}
+        onJoinTokens={noop}
         onEdit={noop}
         onDelete={noop}
         onProbe={noop}
+        onOutbox={noop}
         onToggleEnable={noop}
         onUpdateNode={noop}
         onUpdateSelected={noop}
@@ -0,0 +1,330 @@
+// Package acme issues and renews TLS certificates over RFC 8555 using the
+// HTTP-01, TLS-ALPN-01 or a pluggable DNS-01 challenge.
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+const (
+	ChallengeHTTP01    = "http-01"
+	ChallengeTLSALPN01 = "tls-alpn-01"
+	ChallengeDNS01     = "dns-01"
+
+	LetsEncryptDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+	defaultDNSPropagationTimeout = 2 * time.Minute
+	dnsPropagationPollInterval   = 5 * time.Second
+)
+
+// Certificate is an issued chain (leaf first) with its private key, both PEM.
+type Certificate struct {
+	CertPEM   []byte
+	KeyPEM    []byte
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// Request describes one order. DNS is required for dns-01 and ignored otherwise.
+type Request struct {
+	Domains   []string
+	Challenge string
+	DNS       DNSProvider
+}
+
+// Issuer drives orders against one ACME directory with one account key.
+type Issuer struct {
+	DirectoryURL string
+	Email        string
+	AccountKey   crypto.Signer
+	HTTPClient   *http.Client
+
+	// Responder answers http-01/tls-alpn-01 challenges; nil uses DefaultResponder.
+	Responder *Responder
+	// HTTPPort and TLSALPNPort bind standalone challenge listeners for the
+	// order's lifetime; 0 relies on the panel/sub listeners sharing the Responder.
+	HTTPPort    int
+	TLSALPNPort int
+
+	DNSPropagationTimeout time.Duration
+}
+
+// Obtain runs a full order: account registration, authorizations, finalize.
+func (i *Issuer) Obtain(ctx context.Context, req Request) (*Certificate, error) {
+	domains, err := NormalizeDomains(req.Domains)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkChallenge(req, domains); err != nil {
+		return nil, err
+	}
+	if i.AccountKey == nil {
+		return nil, errors.New("acme: account key is required")
+	}
+
+	client := &acme.Client{
+		Key:          i.AccountKey,
+		DirectoryURL: i.directoryURL(),
+		HTTPClient:   i.HTTPClient,
+		UserAgent:    "3x-ui",
+	}
+	account := &acme.Account{}
+	if i.Email != "" {
+		account.Contact = []string{"mailto:" + i.Email}
+	}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, fmt.Errorf("acme: register account: %w", err)
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(domains...))
+	if err != nil {
+		return nil, fmt.Errorf("acme: create order: %w", err)
+	}
+	stop := i.startChallengeListener(req.Challenge)
+	defer stop()
+	for _, authzURL := range order.AuthzURLs {
+		if err := i.authorize(ctx, client, authzURL, req); err != nil {
+			return nil, err
+		}
+	}
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, fmt.Errorf("acme: wait order: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+	der, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, fmt.Errorf("acme: finalize order: %w", err)
+	}
+	return encodeCertificate(der, key)
+}
+
+func (i *Issuer) directoryURL() string {
+	if i.DirectoryURL != "" {
+		return i.DirectoryURL
+	}
+	return LetsEncryptDirectoryURL
+}
+
+func (i *Issuer) responder() *Responder {
+	if i.Responder != nil {
+		return i.Responder
+	}
+	return DefaultResponder
+}
+
+func (i *Issuer) authorize(ctx context.Context, client *acme.Client, authzURL string, req Request) error {
+	authz, err := client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return fmt.Errorf("acme: get authorization: %w", err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+	domain := authz.Identifier.Value
+	var chal *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == req.Challenge {
+			chal = c
+			break
+		}
+	}
+	if chal == nil {
+		return fmt.Errorf("acme: CA offers no %s challenge for %s", req.Challenge, domain)
+	}
+
+	switch req.Challenge {
+	case ChallengeHTTP01:
+		keyAuth, err := client.HTTP01ChallengeResponse(chal.Token)
+		if err != nil {
+			return err
+		}
+		i.responder().putToken(chal.Token, keyAuth)
+		defer i.responder().deleteToken(chal.Token)
+	case ChallengeTLSALPN01:
+		cert, err := client.TLSALPN01ChallengeCert(chal.Token, domain)
+		if err != nil {
+			return err
+		}
+		i.responder().putALPNCert(domain, &cert)
+		defer i.responder().deleteALPNCert(domain)
+	case ChallengeDNS01:
+		value, err := client.DNS01ChallengeRecord(chal.Token)
+		if err != nil {
+			return err
+		}
+		fqdn := DNS01RecordName(domain)
+		if err := req.DNS.Present(ctx, fqdn, value); err != nil {
+			return fmt.Errorf("acme: publish TXT record for %s: %w", domain, err)
+		}
+		defer func() { _ = req.DNS.CleanUp(context.WithoutCancel(ctx), fqdn, value) }()
+		i.waitForTXT(ctx, fqdn, value)
+	}
+
+	if _, err := client.Accept(ctx, chal); err != nil {
+		return fmt.Errorf("acme: accept %s challenge for %s: %w", req.Challenge, domain, err)
+	}
+	if _, err := client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("acme: authorization for %s failed: %w", domain, err)
+	}
+	return nil
+}
+
+// waitForTXT polls public DNS until the record is visible or the timeout
+// passes; the CA makes the final call either way.
+func (i *Issuer) waitForTXT(ctx context.Context, fqdn, value string) {
+	timeout := i.DNSPropagationTimeout
+	if timeout == 0 {
+		timeout = defaultDNSPropagationTimeout
+	}
+	if timeout < 0 {
+		return
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		lookupCtx, cancel := context.WithTimeout(ctx, dnsPropagationPollInterval)
+		records, _ := net.DefaultResolver.LookupTXT(lookupCtx, fqdn)
+		cancel()
+		if slices.Contains(records, value) || time.Now().After(deadline) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(dnsPropagationPollInterval):
+		}
+	}
+}
+
+// DNS01RecordName is the TXT owner name for a domain; wildcard orders are
+// validated on their base domain.
+func DNS01RecordName(domain string) string {
+	return "_acme-challenge." + strings.TrimSuffix(strings.TrimPrefix(domain, "*."), ".") + "."
+}
+
+// NormalizeDomains lowercases, trims and de-duplicates names, keeping order.
+func NormalizeDomains(domains []string) ([]string, error) {
+	out := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d), "."))
+		if d == "" || slices.Contains(out, d) {
+			continue
+		}
+		if !validDomain(d) {
+			return nil, fmt.Errorf("acme: invalid domain %q", d)
+		}
+		out = append(out, d)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("acme: at least one domain is required")
+	}
+	return out, nil
+}
+
+func validDomain(d string) bool {
+	d = strings.TrimPrefix(d, "*.")
+	if len(d) > 253 || !strings.Contains(d, ".") || net.ParseIP(d) != nil {
+		return false
+	}
+	for label := range strings.SplitSeq(d, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func checkChallenge(req Request, domains []string) error {
+	switch req.Challenge {
+	case ChallengeHTTP01, ChallengeTLSALPN01:
+		for _, d := range domains {
+			if strings.HasPrefix(d, "*.") {
+				return fmt.Errorf("acme: wildcard %s needs the %s challenge", d, ChallengeDNS01)
+			}
+		}
+	case ChallengeDNS01:
+		if req.DNS == nil {
+			return errors.New("acme: dns-01 needs a DNS provider")
+		}
+	default:
+		return fmt.Errorf("acme: unsupported challenge %q", req.Challenge)
+	}
+	return nil
+}
+
+func encodeCertificate(der [][]byte, key *ecdsa.PrivateKey) (*Certificate, error) {
+	if len(der) == 0 {
+		return nil, errors.New("acme: CA returned an empty certificate chain")
+	}
+	leaf, err := x509.ParseCertificate(der[0])
+	if err != nil {
+		return nil, err
+	}
+	var chain []byte
+	for _, block := range der {
+		chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block})...)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Certificate{
+		CertPEM:   chain,
+		KeyPEM:    pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+	}, nil
+}
+
+// NewAccountKey returns a fresh P-256 account key as PEM.
+func NewAccountKey() (string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})), nil
+}
+
+// ParseAccountKey reads a key produced by NewAccountKey.
+func ParseAccountKey(keyPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("acme: account key is not PEM")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
@@ -0,0 +1,80 @@
+package acme
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeDomains(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "trims, lowercases and dedupes", in: []string{" Example.COM. ", "www.example.com", "example.com"}, want: []string{"example.com", "www.example.com"}},
+		{name: "wildcard kept", in: []string{"*.example.com"}, want: []string{"*.example.com"}},
+		{name: "empty list", in: []string{" ", ""}, wantErr: true},
+		{name: "bare label", in: []string{"localhost"}, wantErr: true},
+		{name: "ip address", in: []string{"203.0.113.1"}, wantErr: true},
+		{name: "invalid character", in: []string{"exa_mple.com"}, wantErr: true},
+		{name: "leading hyphen", in: []string{"-bad.example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeDomains(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NormalizeDomains(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeDomains(%q): %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("NormalizeDomains(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckChallenge(t *testing.T) {
+	if err := checkChallenge(Request{Challenge: ChallengeHTTP01}, []string{"*.example.com"}); err == nil {
+		t.Fatal("http-01 cannot validate a wildcard")
+	}
+	if err := checkChallenge(Request{Challenge: ChallengeTLSALPN01}, []string{"example.com"}); err != nil {
+		t.Fatalf("tls-alpn-01 for a plain name: %v", err)
+	}
+	if err := checkChallenge(Request{Challenge: ChallengeDNS01}, []string{"*.example.com"}); err == nil {
+		t.Fatal("dns-01 without a provider must be rejected")
+	}
+	if err := checkChallenge(Request{Challenge: "tls-sni-01"}, []string{"example.com"}); err == nil {
+		t.Fatal("unknown challenge type must be rejected")
+	}
+}
+
+func TestDNS01RecordName(t *testing.T) {
+	for in, want := range map[string]string{
+		"example.com":    "_acme-challenge.example.com.",
+		"*.example.com":  "_acme-challenge.example.com.",
+		"a.example.com.": "_acme-challenge.a.example.com.",
+	} {
+		if got := DNS01RecordName(in); got != want {
+			t.Errorf("DNS01RecordName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAccountKeyRoundTrip(t *testing.T) {
+	keyPEM, err := NewAccountKey()
+	if err != nil {
+		t.Fatalf("NewAccountKey: %v", err)
+	}
+	if _, err := ParseAccountKey(keyPEM); err != nil {
+		t.Fatalf("ParseAccountKey: %v", err)
+	}
+	if _, err := ParseAccountKey("not pem"); err == nil {
+		t.Fatal("ParseAccountKey accepted garbage")
+	}
+}
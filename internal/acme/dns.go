@@ -0,0 +1,56 @@
+package acme
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DNSProvider publishes and removes the dns-01 TXT record. fqdn is the
+// absolute owner name ("_acme-challenge.example.com.").
+type DNSProvider interface {
+	Present(ctx context.Context, fqdn, value string) error
+	CleanUp(ctx context.Context, fqdn, value string) error
+}
+
+// DNSProviderFactory builds a provider from its operator-supplied settings.
+type DNSProviderFactory func(config map[string]string) (DNSProvider, error)
+
+var (
+	dnsProvidersMu sync.RWMutex
+	dnsProviders   = map[string]DNSProviderFactory{}
+)
+
+func init() {
+	RegisterDNSProvider("cloudflare", newCloudflareProvider)
+}
+
+// RegisterDNSProvider makes a provider selectable by name; a later
+// registration under the same name replaces the earlier one.
+func RegisterDNSProvider(name string, factory DNSProviderFactory) {
+	dnsProvidersMu.Lock()
+	defer dnsProvidersMu.Unlock()
+	dnsProviders[name] = factory
+}
+
+func NewDNSProvider(name string, config map[string]string) (DNSProvider, error) {
+	dnsProvidersMu.RLock()
+	factory, ok := dnsProviders[name]
+	dnsProvidersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("acme: unknown DNS provider %q", name)
+	}
+	return factory(config)
+}
+
+func DNSProviderNames() []string {
+	dnsProvidersMu.RLock()
+	defer dnsProvidersMu.RUnlock()
+	names := make([]string, 0, len(dnsProviders))
+	for name := range dnsProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,92 @@
+package acme
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCloudflareProviderPresentAndCleanUp(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	created := cloudflareRecord{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		reply := func(result any) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "result": result})
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones":
+			if r.URL.Query().Get("name") == "example.com" {
+				reply([]map[string]string{{"id": "zone1"}})
+			} else {
+				reply([]any{})
+			}
+		case r.Method == http.MethodPost && r.URL.Path == "/zones/zone1/dns_records":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			reply(map[string]string{"id": "rec1"})
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone1/dns_records":
+			reply([]map[string]string{{"id": "rec1"}})
+		case r.Method == http.MethodDelete && r.URL.Path == "/zones/zone1/dns_records/rec1":
+			reply(map[string]string{"id": "rec1"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": false, "errors": []map[string]any{{"code": 7003, "message": "no route"}}})
+		}
+	}))
+	defer srv.Close()
+
+	provider, err := NewDNSProvider("cloudflare", map[string]string{"apiToken": "tok"})
+	if err != nil {
+		t.Fatalf("NewDNSProvider: %v", err)
+	}
+	cf := provider.(*cloudflareProvider)
+	cf.baseURL = srv.URL
+
+	ctx := context.Background()
+	if err := cf.Present(ctx, "_acme-challenge.www.example.com.", "digest"); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+	if created.Type != "TXT" || created.Name != "_acme-challenge.www.example.com" || created.Content != "digest" {
+		t.Fatalf("created record = %+v", created)
+	}
+	if err := cf.CleanUp(ctx, "_acme-challenge.www.example.com.", "digest"); err != nil {
+		t.Fatalf("CleanUp: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls[len(calls)-1] != "DELETE /zones/zone1/dns_records/rec1" {
+		t.Fatalf("last call = %q, want the TXT record delete; calls: %v", calls[len(calls)-1], calls)
+	}
+}
+
+func TestCloudflareProviderSurfacesAPIErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": false, "errors": []map[string]any{{"code": 10000, "message": "Authentication error"}}})
+	}))
+	defer srv.Close()
+
+	cf := &cloudflareProvider{token: "bad", zoneID: "zone1", baseURL: srv.URL, client: srv.Client()}
+	err := cf.Present(context.Background(), "_acme-challenge.example.com.", "v")
+	if err == nil || err.Error() != "cloudflare: 10000 Authentication error" {
+		t.Fatalf("Present err = %v, want the Cloudflare error text", err)
+	}
+}
+
+func TestNewDNSProviderUnknown(t *testing.T) {
+	if _, err := NewDNSProvider("nope", nil); err == nil {
+		t.Fatal("unknown provider must be rejected")
+	}
+	if _, err := NewDNSProvider("cloudflare", map[string]string{}); err == nil {
+		t.Fatal("cloudflare without apiToken must be rejected")
+	}
+}
@@ -0,0 +1,76 @@
+package acme
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestObtainAgainstPebble needs `pebble -config test/config/pebble-config.json
+// -dnsserver 127.0.0.1:8053` and pebble-challtestsrv resolving names to 127.0.0.1.
+func TestObtainAgainstPebble(t *testing.T) {
+	directory := os.Getenv("XUI_ACME_TEST_DIRECTORY")
+	if directory == "" {
+		t.Skip("set XUI_ACME_TEST_DIRECTORY (e.g. https://localhost:14000/dir) to run against Pebble")
+	}
+	domain := envOr("XUI_ACME_TEST_DOMAIN", "panel.3x-ui.test")
+	httpPort, _ := strconv.Atoi(envOr("XUI_ACME_TEST_HTTP_PORT", "5002"))
+	tlsPort, _ := strconv.Atoi(envOr("XUI_ACME_TEST_TLS_PORT", "5001"))
+
+	keyPEM, err := NewAccountKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseAccountKey(keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &Issuer{
+		DirectoryURL: directory,
+		Email:        "admin@" + domain,
+		AccountKey:   key,
+		// Pebble's directory is served with its own throwaway CA.
+		HTTPClient:  &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}},
+		Responder:   NewResponder(),
+		HTTPPort:    httpPort,
+		TLSALPNPort: tlsPort,
+	}
+
+	for _, challenge := range []string{ChallengeHTTP01, ChallengeTLSALPN01} {
+		t.Run(challenge, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+			cert, err := issuer.Obtain(ctx, Request{Domains: []string{domain}, Challenge: challenge})
+			if err != nil {
+				t.Fatalf("Obtain: %v", err)
+			}
+			if _, err := tls.X509KeyPair(cert.CertPEM, cert.KeyPEM); err != nil {
+				t.Fatalf("issued pair does not load: %v", err)
+			}
+			block, _ := pem.Decode(cert.CertPEM)
+			leaf, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := leaf.VerifyHostname(domain); err != nil {
+				t.Fatalf("leaf does not cover %s: %v", domain, err)
+			}
+			if !cert.NotAfter.Equal(leaf.NotAfter) {
+				t.Fatalf("NotAfter = %v, leaf says %v", cert.NotAfter, leaf.NotAfter)
+			}
+		})
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,145 @@
+package acme
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/network"
+
+	"golang.org/x/crypto/acme"
+)
+
+// HTTPChallengePrefix is the well-known path http-01 tokens are served under.
+const HTTPChallengePrefix = "/.well-known/acme-challenge/"
+
+// Responder is shared by the panel and subscription listeners, so a challenge
+// still validates when one of them already owns port 80 or 443.
+type Responder struct {
+	mu     sync.RWMutex
+	tokens map[string]string
+	certs  map[string]*tls.Certificate
+}
+
+var DefaultResponder = NewResponder()
+
+func NewResponder() *Responder {
+	return &Responder{
+		tokens: map[string]string{},
+		certs:  map[string]*tls.Certificate{},
+	}
+}
+
+func (r *Responder) putToken(token, keyAuth string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token] = keyAuth
+}
+
+func (r *Responder) deleteToken(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tokens, token)
+}
+
+func (r *Responder) putALPNCert(domain string, cert *tls.Certificate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.certs[domain] = cert
+}
+
+func (r *Responder) deleteALPNCert(domain string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.certs, domain)
+}
+
+// ServeHTTP answers http-01 validation requests and 404s everything else.
+func (r *Responder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	token, ok := strings.CutPrefix(req.URL.Path, HTTPChallengePrefix)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	r.mu.RLock()
+	keyAuth, found := r.tokens[token]
+	r.mu.RUnlock()
+	if !found {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(keyAuth))
+}
+
+// GetConfigForClient serves the tls-alpn-01 certificate to validation
+// handshakes and returns nil for every other client so the caller's config applies.
+func (r *Responder) GetConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	if !slices.Contains(hello.SupportedProtos, acme.ALPNProto) {
+		return nil, nil
+	}
+	r.mu.RLock()
+	cert := r.certs[strings.ToLower(hello.ServerName)]
+	r.mu.RUnlock()
+	if cert == nil {
+		return nil, nil
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{*cert},
+		NextProtos:   []string{acme.ALPNProto},
+	}, nil
+}
+
+// startChallengeListener binds a standalone listener for the challenge type.
+// A busy port is not fatal: the panel or sub server may be answering there.
+func (i *Issuer) startChallengeListener(challenge string) func() {
+	var port int
+	switch challenge {
+	case ChallengeHTTP01:
+		port = i.HTTPPort
+	case ChallengeTLSALPN01:
+		port = i.TLSALPNPort
+	}
+	if port <= 0 {
+		return func() {}
+	}
+	addr := net.JoinHostPort("", strconv.Itoa(port))
+	listener, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		logger.Warningf("acme: %s listener on %s unavailable, relying on panel/sub listeners: %v", challenge, addr, err)
+		return func() {}
+	}
+
+	if challenge == ChallengeHTTP01 {
+		server := &http.Server{Handler: i.responder(), ReadHeaderTimeout: 5 * time.Second}
+		go network.ServeHTTP(server, listener, "ACME http-01 listener")
+		return func() { _ = server.Close() }
+	}
+
+	tlsListener := tls.NewListener(listener, &tls.Config{GetConfigForClient: i.responder().GetConfigForClient})
+	go func() {
+		for {
+			conn, err := tlsListener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					logger.Debug("acme: tls-alpn-01 accept:", err)
+				}
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+				_ = conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	return func() { _ = tlsListener.Close() }
+}
@@ -0,0 +1,49 @@
+package acme
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/acme"
+)
+
+func TestResponderServesHTTPToken(t *testing.T) {
+	r := NewResponder()
+	r.putToken("tok", "tok.thumb")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HTTPChallengePrefix+"tok", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "tok.thumb" {
+		t.Fatalf("token response = %d %q, want 200 tok.thumb", rec.Code, rec.Body.String())
+	}
+
+	r.deleteToken("tok")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HTTPChallengePrefix+"tok", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("removed token status = %d, want 404", rec.Code)
+	}
+}
+
+func TestResponderALPNOnlyForValidationHandshakes(t *testing.T) {
+	r := NewResponder()
+	cert := &tls.Certificate{Certificate: [][]byte{{1}}}
+	r.putALPNCert("example.com", cert)
+
+	cfg, err := r.GetConfigForClient(&tls.ClientHelloInfo{ServerName: "Example.com", SupportedProtos: []string{acme.ALPNProto}})
+	if err != nil || cfg == nil {
+		t.Fatalf("validation handshake got cfg=%v err=%v, want challenge config", cfg, err)
+	}
+	if len(cfg.NextProtos) != 1 || cfg.NextProtos[0] != acme.ALPNProto {
+		t.Fatalf("NextProtos = %v, want [%s]", cfg.NextProtos, acme.ALPNProto)
+	}
+
+	if cfg, _ := r.GetConfigForClient(&tls.ClientHelloInfo{ServerName: "example.com", SupportedProtos: []string{"h2", "http/1.1"}}); cfg != nil {
+		t.Fatal("a browser handshake must fall through to the listener's own config")
+	}
+	if cfg, _ := r.GetConfigForClient(&tls.ClientHelloInfo{ServerName: "other.com", SupportedProtos: []string{acme.ALPNProto}}); cfg != nil {
+		t.Fatal("an unknown SNI must fall through to the listener's own config")
+	}
+}
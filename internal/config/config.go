@@ -193,6 +193,25 @@ func GetDBDSN() string {
 	return strings.TrimSpace(os.Getenv("XUI_DB_DSN"))
 }
 
+// GetHAEnable reports whether XUI_HA turns on active/passive leader election.
+// It only takes effect with a PostgreSQL database shared by both panels.
+func GetHAEnable() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("XUI_HA")))
+	return v == "1" || v == "true" || v == "yes" || v == "on"
+}
+
+// GetHANodeName returns the name this panel reports in the HA status,
+// XUI_HA_NODE_NAME or the hostname.
+func GetHANodeName() string {
+	if v := strings.TrimSpace(os.Getenv("XUI_HA_NODE_NAME")); v != "" {
+		return v
+	}
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
+	}
+	return "x-ui"
+}
+
 // GetNodeTokenEncryptionMode returns off, migration, or required. Explicit
 // policy prevents a missing key from silently downgrading encrypted storage.
 func GetNodeTokenEncryptionMode() string {
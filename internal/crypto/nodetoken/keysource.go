@@ -1,12 +1,16 @@
 package nodetoken
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 // KeySource loads a startup keyring from a protected file or environment.
@@ -113,3 +117,65 @@ func (e EnvKeySource) Load() (*Keyring, error) {
 	}
 	return parseKeyring("env", map[string]string{"env": v})
 }
+
+// Rotated keeps the previous keys in the ring, so existing ciphertext still
+// decrypts until it has been re-encrypted.
+func (kr *Keyring) Rotated(now time.Time) (*Keyring, error) {
+	id := "k" + now.UTC().Format("20060102150405")
+	if _, taken := kr.Keys[id]; taken {
+		return nil, fmt.Errorf("nodetoken: key id %q already exists", id)
+	}
+	var key [keyLen]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, err
+	}
+	next := &Keyring{ActiveID: id, Keys: make(map[string][keyLen]byte, len(kr.Keys)+1)}
+	maps.Copy(next.Keys, kr.Keys)
+	next.Keys[id] = key
+	return next, nil
+}
+
+// Pruned returns a copy of kr holding only the active key.
+func (kr *Keyring) Pruned() *Keyring {
+	return &Keyring{ActiveID: kr.ActiveID, Keys: map[string][keyLen]byte{kr.ActiveID: kr.Keys[kr.ActiveID]}}
+}
+
+// WriteKeyFile stores kr in the FileKeySource format with mode 0600. The file
+// is replaced atomically so a crash never leaves a truncated keyring behind.
+func WriteKeyFile(path string, kr *Keyring) error {
+	kf := keyFile{Active: kr.ActiveID, Keys: make(map[string]string, len(kr.Keys))}
+	for id, key := range kr.Keys {
+		kf.Keys[id] = base64.StdEncoding.EncodeToString(key[:])
+	}
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("nodetoken: create key dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".node_token_key-*")
+	if err != nil {
+		return fmt.Errorf("nodetoken: create key file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("nodetoken: replace key file %s: %w", path, err)
+	}
+	return nil
+}
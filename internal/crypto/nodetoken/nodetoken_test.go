@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func testRing(t *testing.T, activeID string, ids ...string) *Keyring {
@@ -241,3 +242,44 @@ func TestEnvKeySource(t *testing.T) {
 		t.Fatal("env-sourced key failed round trip")
 	}
 }
+
+func TestRotatedKeyFileRoundTrip(t *testing.T) {
+	old := testRing(t, "k1", "k1")
+	c1, _ := NewCodec(ModeRequired, old)
+	enc, _ := c1.Encrypt(3, "tok")
+
+	next, err := old.Rotated(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ActiveID != "k20260102030405" || len(next.Keys) != 2 || len(old.Keys) != 1 {
+		t.Fatalf("unexpected rotated ring %s/%d (old %d)", next.ActiveID, len(next.Keys), len(old.Keys))
+	}
+	if next.Keys[next.ActiveID] == old.Keys["k1"] {
+		t.Fatal("rotated key must be freshly generated")
+	}
+
+	p := filepath.Join(t.TempDir(), "keys", "k.json")
+	if err := WriteKeyFile(p, next); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := (FileKeySource{Path: p}).Load()
+	if err != nil {
+		t.Fatalf("written key file should load: %v", err)
+	}
+	c2, _ := NewCodec(ModeRequired, loaded)
+	if pt, err := c2.Decrypt(3, enc); err != nil || pt != "tok" {
+		t.Fatalf("old ciphertext after rotation: %q %v", pt, err)
+	}
+	if c2.EncryptedWithActive(enc) {
+		t.Fatal("old ciphertext must not count as current after rotation")
+	}
+
+	if err := WriteKeyFile(p, loaded.Pruned()); err != nil {
+		t.Fatal(err)
+	}
+	pruned, _ := (FileKeySource{Path: p}).Load()
+	if len(pruned.Keys) != 1 || pruned.ActiveID != next.ActiveID {
+		t.Fatalf("unexpected pruned ring %+v", pruned)
+	}
+}
@@ -0,0 +1,175 @@
+// Package releasesig checks release artifacts against SHA256SUMS and its
+// Ed25519 signature, SHA256SUMS.sig, made with a key pinned into the binary.
+package releasesig
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	// ManifestName and SignatureName are the release asset names.
+	ManifestName  = "SHA256SUMS"
+	SignatureName = "SHA256SUMS.sig"
+
+	// MaxManifestBytes caps how much of a manifest or signature is read.
+	MaxManifestBytes = 64 << 10
+)
+
+var (
+	ErrNoTrustedKeys    = errors.New("releasesig: this build pins no release signing key")
+	ErrBadSignature     = errors.New("releasesig: manifest signature does not match any pinned key")
+	ErrNotInManifest    = errors.New("releasesig: artifact is not listed in the signed manifest")
+	ErrChecksumMismatch = errors.New("releasesig: artifact checksum does not match the signed manifest")
+)
+
+//go:embed trusted_keys.txt
+var trustedKeys string
+
+// Verifier checks manifest signatures against a fixed set of public keys.
+type Verifier struct {
+	keys map[string]ed25519.PublicKey
+}
+
+// Pinned returns a verifier for the keys compiled into this binary. A malformed
+// pinned key is a build error, so it panics rather than silently dropping it.
+func Pinned() *Verifier {
+	v, err := ParseKeys(trustedKeys)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// ParseKeys reads one base64 Ed25519 public key per line; blank lines and
+// lines starting with # are ignored.
+func ParseKeys(text string) (*Verifier, error) {
+	v := &Verifier{keys: map[string]ed25519.PublicKey{}}
+	for line := range strings.SplitSeq(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("releasesig: malformed public key %q", line)
+		}
+		v.Add(ed25519.PublicKey(raw))
+	}
+	return v, nil
+}
+
+// NewVerifier returns a verifier trusting exactly the given keys.
+func NewVerifier(keys ...ed25519.PublicKey) *Verifier {
+	v := &Verifier{keys: map[string]ed25519.PublicKey{}}
+	for _, k := range keys {
+		v.Add(k)
+	}
+	return v
+}
+
+// Add trusts one more key.
+func (v *Verifier) Add(key ed25519.PublicKey) {
+	v.keys[KeyID(key)] = key
+}
+
+// KeyIDs lists the fingerprints of the trusted keys, sorted.
+func (v *Verifier) KeyIDs() []string {
+	ids := make([]string, 0, len(v.keys))
+	for id := range v.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// KeyID is a short fingerprint of a public key: the first 8 bytes of its
+// SHA-256, in hex.
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Verify checks sig (base64 of the raw 64-byte signature) over manifest and
+// returns the parsed manifest plus the ID of the key that signed it.
+func (v *Verifier) Verify(manifest, sig []byte) (Manifest, string, error) {
+	if len(v.keys) == 0 {
+		return nil, "", ErrNoTrustedKeys
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || len(raw) != ed25519.SignatureSize {
+		return nil, "", fmt.Errorf("releasesig: malformed signature: %w", ErrBadSignature)
+	}
+	keyID := ""
+	for _, id := range v.KeyIDs() {
+		if ed25519.Verify(v.keys[id], manifest, raw) {
+			keyID = id
+			break
+		}
+	}
+	if keyID == "" {
+		return nil, "", ErrBadSignature
+	}
+	m, err := ParseManifest(manifest)
+	if err != nil {
+		return nil, "", err
+	}
+	return m, keyID, nil
+}
+
+// Manifest maps an artifact name to its lowercase hex SHA-256.
+type Manifest map[string]string
+
+// ParseManifest reads sha256sum output: "<hex>  <name>" or "<hex> *<name>".
+func ParseManifest(data []byte) (Manifest, error) {
+	m := Manifest{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sum, name, ok := strings.Cut(line, " ")
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		if _, err := hex.DecodeString(sum); !ok || err != nil || len(sum) != 64 || name == "" {
+			return nil, fmt.Errorf("releasesig: malformed manifest line %q", line)
+		}
+		if _, dup := m[name]; dup {
+			return nil, fmt.Errorf("releasesig: %s is listed twice in the manifest", name)
+		}
+		m[name] = strings.ToLower(sum)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return nil, errors.New("releasesig: manifest is empty")
+	}
+	return m, nil
+}
+
+// Check hashes r and compares it with the manifest entry for name.
+func (m Manifest) Check(name string, r io.Reader) error {
+	want, ok := m[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotInManifest, name)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: %s (expected %s, got %s)", ErrChecksumMismatch, name, want, got)
+	}
+	return nil
+}
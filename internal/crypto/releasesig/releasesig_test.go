@@ -0,0 +1,93 @@
+package releasesig
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func signManifest(t *testing.T, priv ed25519.PrivateKey, manifest string) []byte {
+	t.Helper()
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(manifest))) + "\n")
+}
+
+func TestVerifyManifest(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, otherPriv, _ := ed25519.GenerateKey(nil)
+
+	sum := sha256.Sum256([]byte("tarball"))
+	manifest := hex.EncodeToString(sum[:]) + "  x-ui-linux-amd64.tar.gz\n" +
+		strings.Repeat("a", 64) + " *update.sh\n"
+	sig := signManifest(t, priv, manifest)
+
+	v := NewVerifier(otherPub, pub)
+	m, keyID, err := v.Verify([]byte(manifest), sig)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if keyID != KeyID(pub) {
+		t.Fatalf("keyID = %s, want %s", keyID, KeyID(pub))
+	}
+	if err := m.Check("x-ui-linux-amd64.tar.gz", strings.NewReader("tarball")); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if err := m.Check("x-ui-linux-amd64.tar.gz", strings.NewReader("tampered")); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("tampered artifact: %v", err)
+	}
+	if err := m.Check("x-ui-linux-arm64.tar.gz", strings.NewReader("tarball")); !errors.Is(err, ErrNotInManifest) {
+		t.Fatalf("unlisted artifact: %v", err)
+	}
+	if m["update.sh"] != strings.Repeat("a", 64) {
+		t.Fatalf("binary-mode entry not parsed: %v", m)
+	}
+
+	if _, _, err := v.Verify([]byte(manifest+"\n"), sig); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("edited manifest: %v", err)
+	}
+	if _, _, err := NewVerifier(pub).Verify([]byte(manifest), signManifest(t, otherPriv, manifest)); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("unpinned signer: %v", err)
+	}
+	if _, _, err := NewVerifier().Verify([]byte(manifest), sig); !errors.Is(err, ErrNoTrustedKeys) {
+		t.Fatalf("empty verifier: %v", err)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	v, err := ParseKeys("# comment\n\n" + base64.StdEncoding.EncodeToString(pub) + "\n")
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	if ids := v.KeyIDs(); len(ids) != 1 || ids[0] != KeyID(pub) {
+		t.Fatalf("KeyIDs = %v", ids)
+	}
+	if _, err := ParseKeys("bm90IGEga2V5\n"); err == nil {
+		t.Fatal("short key accepted")
+	}
+	Pinned()
+}
+
+func TestParseManifestRejectsGarbage(t *testing.T) {
+	for _, in := range []string{"", "deadbeef  file\n", strings.Repeat("z", 64) + "  file\n", strings.Repeat("a", 64) + "\n"} {
+		if _, err := ParseManifest([]byte(in)); err == nil {
+			t.Fatalf("ParseManifest(%q) accepted", in)
+		}
+	}
+	dup := strings.Repeat("a", 64) + "  f\n" + strings.Repeat("b", 64) + "  f\n"
+	if _, err := ParseManifest([]byte(dup)); err == nil {
+		t.Fatal("duplicate entry accepted")
+	}
+}
+
+func TestPinnedTrustsAReleaseKey(t *testing.T) {
+	if ids := Pinned().KeyIDs(); len(ids) == 0 {
+		t.Fatal("trusted_keys.txt pins no key, so every web update would be refused")
+	}
+}
@@ -0,0 +1,61 @@
+// Package secrets seals panel secrets with the node-token keyring, so one key
+// file and one rotate-key run cover everything; values are bound to their row.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/nodetoken"
+)
+
+var (
+	// ErrNoKey is returned when an encrypted value is read while encryption is off.
+	ErrNoKey = errors.New("secrets: value is encrypted but NODE_TOKEN_ENCRYPTION is off")
+	// ErrUnreadable wraps every Open failure: the value was sealed under a key
+	// this keyring lacks, or was altered, and has to be entered again.
+	ErrUnreadable = errors.New("cannot be opened with this keyring")
+)
+
+// SettingScope binds a value to its row in the settings table.
+func SettingScope(key string) []byte { return []byte("settings/" + key) }
+
+// UserTwoFactorScope binds a TOTP seed to its account.
+func UserTwoFactorScope(userID int) []byte {
+	return fmt.Appendf(nil, "users/two_factor_token/%d", userID)
+}
+
+// AcmeScope binds an ACME certificate column (dns_config, key_pem) to its row.
+func AcmeScope(column string, certID int) []byte {
+	return fmt.Appendf(nil, "acme_certificates/%s/%d", column, certID)
+}
+
+// WebhookSecretScope binds a webhook's signing key to its row.
+func WebhookSecretScope(webhookID int) []byte {
+	return fmt.Appendf(nil, "webhooks/secret/%d", webhookID)
+}
+
+// Seal encrypts plaintext under the active key. With encryption off it is
+// returned unchanged, and an empty value is never encrypted.
+func Seal(scope []byte, plaintext string) (string, error) {
+	return nodetoken.EncryptBound(scope, plaintext)
+}
+
+// Open decrypts a stored value. Values written before encryption was enabled
+// pass through as plaintext.
+func Open(scope []byte, stored string) (string, error) {
+	if nodetoken.IsEncrypted(stored) && !nodetoken.Enabled() {
+		return "", fmt.Errorf("%w: %w", ErrNoKey, ErrUnreadable)
+	}
+	pt, err := nodetoken.DecryptBound(scope, stored)
+	if err != nil {
+		return "", fmt.Errorf("secrets: %s %w: %w", scope, ErrUnreadable, err)
+	}
+	return pt, nil
+}
+
+// Stale reports whether a stored value should be rewritten: it is plaintext or
+// sealed under a key that is no longer active.
+func Stale(stored string) bool {
+	return nodetoken.Enabled() && stored != "" && !nodetoken.Active().EncryptedWithActive(stored)
+}
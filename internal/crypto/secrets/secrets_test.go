@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/nodetoken"
+)
+
+func useCodec(t *testing.T, mode nodetoken.Mode) {
+	t.Helper()
+	var ring *nodetoken.Keyring
+	if mode != nodetoken.ModeOff {
+		var k [32]byte
+		for i := range k {
+			k[i] = byte(i + 7)
+		}
+		ring = &nodetoken.Keyring{ActiveID: "s1", Keys: map[string][32]byte{"s1": k}}
+	}
+	codec, err := nodetoken.NewCodec(mode, ring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodetoken.Init(codec)
+	t.Cleanup(func() {
+		off, _ := nodetoken.NewCodec(nodetoken.ModeOff, nil)
+		nodetoken.Init(off)
+	})
+}
+
+func TestSealOpenBindsScope(t *testing.T) {
+	useCodec(t, nodetoken.ModeRequired)
+	enc, err := Seal(SettingScope("smtpPassword"), "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enc, "enc:v1:s1:") {
+		t.Fatalf("unexpected ciphertext %q", enc)
+	}
+	if pt, err := Open(SettingScope("smtpPassword"), enc); err != nil || pt != "hunter2" {
+		t.Fatalf("round trip: %q %v", pt, err)
+	}
+	if _, err := Open(SettingScope("ldapPassword"), enc); err == nil {
+		t.Fatal("ciphertext moved to another setting must not decrypt")
+	}
+	if _, err := Open(UserTwoFactorScope(2), enc); err == nil {
+		t.Fatal("setting ciphertext must not decrypt as a 2FA seed")
+	}
+	if Stale(enc) || !Stale("plaintext") || Stale("") {
+		t.Fatal("Stale misreports sealed, plaintext or empty values")
+	}
+}
+
+func TestOpenWithoutKey(t *testing.T) {
+	useCodec(t, nodetoken.ModeRequired)
+	enc, _ := Seal(SettingScope("tgBotToken"), "123:abc")
+
+	useCodec(t, nodetoken.ModeOff)
+	if _, err := Open(SettingScope("tgBotToken"), enc); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("encrypted value with encryption off: %v", err)
+	}
+	if pt, err := Open(SettingScope("tgBotToken"), "123:abc"); err != nil || pt != "123:abc" {
+		t.Fatalf("plaintext with encryption off: %q %v", pt, err)
+	}
+	if out, _ := Seal(SettingScope("tgBotToken"), "123:abc"); out != "123:abc" {
+		t.Fatalf("Seal with encryption off must store plaintext, got %q", out)
+	}
+	if Stale("123:abc") {
+		t.Fatal("nothing is stale while encryption is off")
+	}
+}
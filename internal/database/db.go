@@ -14,6 +14,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"slices"
 	"strconv"
@@ -73,17 +74,27 @@ func allModels() []any {
 		&model.HistoryOfSeeders{},
 		&model.Node{},
 		&model.ApiToken{},
+		&model.NodeJoinToken{},
+		&model.NodeOutboxEntry{},
+		&model.NodeRollout{},
+		&model.NodeRolloutStep{},
 		&model.ClientRecord{},
 		&model.ClientInbound{},
 		&model.ClientHwid{},
 		&model.ClientExternalLink{},
 		&model.ClientGroup{},
+		&model.Plan{},
+		&model.Voucher{},
 		&model.InboundFallback{},
 		&model.Host{},
 		&model.NodeClientTraffic{},
+		&model.ClientTrafficHistory{},
 		&model.NodeClientIp{},
 		&model.ClientGlobalTraffic{},
 		&model.OutboundSubscription{},
+		&model.AcmeCertificate{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
 	}
 }
 
@@ -125,6 +136,9 @@ func initModels() error {
 	if err := migrateApiTokenScopeAndExpiry(); err != nil {
 		return err
 	}
+	if err := migrateUserRolesAndTwoFactor(); err != nil {
+		return err
+	}
 	if err := dropLegacyForeignKeys(); err != nil {
 		return err
 	}
@@ -170,8 +184,11 @@ func initModels() error {
 	if err := migrateClientEmailLowerIndex(); err != nil {
 		return err
 	}
+	if err := runSchemaMigrations(db); err != nil {
+		return err
+	}
 	if IsPostgres() {
-		if err := resyncPostgresSequences(db, models); err != nil {
+		if err := resyncPostgresSequences(db, append(models, schemaMigratedModels()...)); err != nil {
 			log.Printf("Error resyncing postgres sequences: %v", err)
 			return err
 		}
@@ -361,6 +378,115 @@ func migrateClientEmailLowerIndex() error {
 	return db.Exec("CREATE INDEX IF NOT EXISTS idx_clients_email_lower ON clients (LOWER(email))").Error
 }
 
+// schemaMigration is a hand-written change for a table AutoMigrate does not
+// manage; schema_migrations records applied versions so each runs once.
+type schemaMigration struct {
+	version int
+	name    string
+	up      func(tx *gorm.DB) error
+}
+
+// schemaMigrations must only ever be appended to; a shipped step is never
+// edited, a later version changes what it built instead.
+var schemaMigrations = []schemaMigration{
+	{version: 1, name: "create audit_logs", up: createAuditLogsTable},
+}
+
+// schemaMigratedModels are the models whose tables schemaMigrations builds.
+// They are left out of allModels but still copied by migrate-db.
+func schemaMigratedModels() []any {
+	return []any{&model.AuditLog{}}
+}
+
+func isSchemaMigrated(mdl any) bool {
+	name := reflect.TypeOf(mdl).Elem().Name()
+	for _, m := range schemaMigratedModels() {
+		if reflect.TypeOf(m).Elem().Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runSchemaMigrations applies the schemaMigrations conn has not seen yet, each
+// in its own transaction together with its schema_migrations row.
+func runSchemaMigrations(conn *gorm.DB) error {
+	if err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at BIGINT NOT NULL
+	)`).Error; err != nil {
+		return err
+	}
+	var applied []int
+	if err := conn.Raw("SELECT version FROM schema_migrations").Scan(&applied).Error; err != nil {
+		return err
+	}
+	for _, m := range schemaMigrations {
+		if slices.Contains(applied, m.version) {
+			continue
+		}
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				m.version, m.name, time.Now().Unix()).Error
+		})
+		if err != nil {
+			return fmt.Errorf("schema migration %d (%s): %w", m.version, m.name, err)
+		}
+		log.Printf("Applied schema migration %d: %s", m.version, m.name)
+	}
+	return nil
+}
+
+// createAuditLogsTable is all IF NOT EXISTS: panels whose AutoMigrate already
+// created audit_logs match this layout.
+func createAuditLogsTable(tx *gorm.DB) error {
+	table := `CREATE TABLE IF NOT EXISTS audit_logs (
+		id integer PRIMARY KEY AUTOINCREMENT,
+		created_at integer,
+		actor text,
+		actor_type text,
+		source_ip text,
+		method text,
+		route text,
+		target_type text,
+		target_id text,
+		success numeric,
+		error text,
+		changes text
+	)`
+	if tx.Name() == DialectPostgres {
+		table = `CREATE TABLE IF NOT EXISTS audit_logs (
+			id bigserial PRIMARY KEY,
+			created_at bigint,
+			actor text,
+			actor_type text,
+			source_ip text,
+			method text,
+			route text,
+			target_type text,
+			target_id text,
+			success boolean,
+			error text,
+			changes text
+		)`
+	}
+	for _, stmt := range []string{
+		table,
+		"CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at)",
+		"CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs (actor)",
+		"CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs (target_type, target_id, id)",
+	} {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func migrateHostVerifyPeerCertByNameColumn() error {
 	if !db.Migrator().HasColumn(&model.Host{}, "verify_peer_cert_by_name") {
 		return nil
@@ -1162,6 +1288,7 @@ func initUser() error {
 		user := &model.User{
 			Username: defaultUsername,
 			Password: hashedPassword,
+			Role:     model.UserRoleOwner,
 		}
 		return db.Create(user).Error
 	}
@@ -1468,7 +1595,7 @@ func clearLegacyProxySettings() error {
 }
 
 func normalizeSettingPaths() error {
-	pathKeys := []string{"webBasePath", "subPath", "subJsonPath", "subClashPath"}
+	pathKeys := []string{"webBasePath", "subPath", "subJsonPath", "subClashPath", "subSingboxPath"}
 	var rows []model.Setting
 	if err := db.Where("key IN ?", pathKeys).Find(&rows).Error; err != nil {
 		return err
@@ -2166,6 +2293,42 @@ func migrateApiTokenScopeAndExpiry() error {
 		Updates(map[string]any{"scope": model.ApiScopeAdmin, "expires_at": 0}).Error
 }
 
+// migrateUserRolesAndTwoFactor makes pre-existing accounts owners and moves the
+// panel-wide 2FA settings onto the original account, which is the one they guarded.
+func migrateUserRolesAndTwoFactor() error {
+	if err := db.Model(&model.User{}).Where("role IS NULL OR TRIM(role) = ''").
+		Update("role", model.UserRoleOwner).Error; err != nil {
+		return err
+	}
+	var rows []model.Setting
+	if err := db.Where("key IN ?", []string{"twoFactorEnable", "twoFactorToken"}).Find(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	values := map[string]string{}
+	for _, row := range rows {
+		values[row.Key] = row.Value
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if token := strings.TrimSpace(values["twoFactorToken"]); token != "" {
+			first := &model.User{}
+			if err := tx.Order("id asc").First(first).Error; err == nil {
+				if err := tx.Model(first).Updates(map[string]any{
+					"two_factor_enable": values["twoFactorEnable"] == "true",
+					"two_factor_token":  token,
+				}).Error; err != nil {
+					return err
+				}
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		return tx.Where("key IN ?", []string{"twoFactorEnable", "twoFactorToken"}).Delete(&model.Setting{}).Error
+	})
+}
+
 // openPostgresWithRetry retries the initial PostgreSQL connection with
 // backoff so a database that starts slower than the panel (or drops out
 // briefly) does not immediately kill the process and trip systemd's
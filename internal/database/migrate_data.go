@@ -42,6 +42,10 @@ func migrationModels() []any {
 		&model.HistoryOfSeeders{},
 		&model.Node{},
 		&model.ApiToken{},
+		&model.NodeJoinToken{},
+		&model.NodeOutboxEntry{},
+		&model.NodeRollout{},
+		&model.NodeRolloutStep{},
 		&model.Inbound{},
 		&xray.ClientTraffic{},
 		&model.OutboundTraffics{},
@@ -51,12 +55,19 @@ func migrationModels() []any {
 		&model.ClientHwid{},
 		&model.ClientExternalLink{},
 		&model.ClientGroup{},
+		&model.Plan{},
+		&model.Voucher{},
 		&model.InboundFallback{},
 		&model.Host{},
 		&model.NodeClientTraffic{},
+		&model.ClientTrafficHistory{},
 		&model.NodeClientIp{},
 		&model.ClientGlobalTraffic{},
 		&model.OutboundSubscription{},
+		&model.AcmeCertificate{},
+		&model.AuditLog{}, // built by a schemaMigration, not allModels()
+		&model.Webhook{},
+		&model.WebhookDelivery{},
 	}
 }
 
@@ -101,10 +112,16 @@ func MigrateData(srcPath, dstDSN string) error {
 
 	log.Println("Creating destination schema...")
 	for _, m := range migrationModels() {
+		if isSchemaMigrated(m) {
+			continue
+		}
 		if err := dst.AutoMigrate(m); err != nil {
 			return fmt.Errorf("AutoMigrate %T: %w", m, err)
 		}
 	}
+	if err := runSchemaMigrations(dst); err != nil {
+		return err
+	}
 
 	totalRows := 0
 	txErr := dst.Transaction(func(tx *gorm.DB) error {
@@ -194,10 +211,16 @@ func ExportPostgresToSQLite(srcDSN, dstPath string) error {
 // from src to dst in FK-safe order. src/dst may be any gorm backend.
 func copyAllModels(src, dst *gorm.DB) error {
 	for _, m := range migrationModels() {
+		if isSchemaMigrated(m) {
+			continue
+		}
 		if err := dst.AutoMigrate(m); err != nil {
 			return fmt.Errorf("AutoMigrate %T: %w", m, err)
 		}
 	}
+	if err := runSchemaMigrations(dst); err != nil {
+		return err
+	}
 	for _, m := range migrationModels() {
 		if _, err := copyTable(src, dst, m); err != nil {
 			return fmt.Errorf("copy %T: %w", m, err)
@@ -338,9 +361,15 @@ func PrepareSQLiteForMigration(dbPath string) error {
 		}
 	}
 	for _, m := range migrationModels() {
+		if isSchemaMigrated(m) {
+			continue
+		}
 		if err := gdb.AutoMigrate(m); err != nil && !isIgnorableDuplicateColumnErr(gdb, err, m) {
 			return fmt.Errorf("upgrade panel schema for %T: %w", m, err)
 		}
 	}
+	if err := runSchemaMigrations(gdb); err != nil {
+		return fmt.Errorf("upgrade panel schema: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,21 @@
+package model
+
+// Bucket widths of client_traffic_history rows, in seconds.
+const (
+	TrafficHistoryHourly = 3600
+	TrafficHistoryDaily  = 86400
+)
+
+// ClientTrafficHistory rows are per source: NodeId 0 is this panel's own Xray,
+// so readers sum across NodeId. Old hourly rows fold into local-midnight days.
+type ClientTrafficHistory struct {
+	Id         int64  `json:"-" gorm:"primaryKey;autoIncrement"`
+	Email      string `json:"-" gorm:"uniqueIndex:idx_traffic_history_bucket,priority:1;not null"`
+	NodeId     int    `json:"-" gorm:"uniqueIndex:idx_traffic_history_bucket,priority:2;not null"`
+	Resolution int    `json:"-" gorm:"uniqueIndex:idx_traffic_history_bucket,priority:3;index:idx_traffic_history_age,priority:1;not null"`
+	Bucket     int64  `json:"-" gorm:"uniqueIndex:idx_traffic_history_bucket,priority:4;index:idx_traffic_history_age,priority:2;not null"` // unix seconds
+	Up         int64  `json:"-"`
+	Down       int64  `json:"-"`
+}
+
+func (ClientTrafficHistory) TableName() string { return "client_traffic_history" }
@@ -35,11 +35,21 @@ const (
 )
 
 // User represents a user account in the 3x-ui panel.
+// Role and Permissions decide what the account may do; owners ignore Permissions.
 type User struct {
-	Id         int    `json:"id" gorm:"primaryKey;autoIncrement"`
-	Username   string `json:"username"`
-	Password   string `json:"password"`
-	LoginEpoch int64  `json:"-" gorm:"default:0"`
+	Id              int               `json:"id" gorm:"primaryKey;autoIncrement"`
+	Username        string            `json:"username"`
+	Password        string            `json:"password"`
+	LoginEpoch      int64             `json:"-" gorm:"default:0"`
+	Role            string            `json:"role" gorm:"not null;default:owner"`
+	Permissions     map[string]string `json:"permissions" gorm:"serializer:json"`
+	TwoFactorEnable bool              `json:"twoFactorEnable"`
+	TwoFactorToken  string            `json:"-"`
+	// Reseller limits. A reseller only sees AllowedInbounds; a zero cap is unlimited.
+	AllowedInbounds []int `json:"allowedInbounds" gorm:"serializer:json"`
+	MaxClients      int   `json:"maxClients" gorm:"default:0"`
+	MaxTrafficGB    int   `json:"maxTrafficGB" gorm:"column:max_traffic_gb;default:0"`
+	MaxExpiryDays   int   `json:"maxExpiryDays" gorm:"default:0"`
 }
 
 // Inbound represents an Xray inbound configuration with traffic statistics and settings.
@@ -72,6 +82,15 @@ type Inbound struct {
 
 	DisableFlow bool `json:"disableFlow" form:"disableFlow" gorm:"column:disable_flow;default:false" example:"false"`
 
+	// TrafficCoefficient weighs the traffic of this inbound's clients against
+	// their quotas: 2 counts double, 0 is free. Unset counts 1:1.
+	TrafficCoefficient *float64 `json:"trafficCoefficient,omitempty" form:"trafficCoefficient" gorm:"column:traffic_coefficient" validate:"omitempty,gte=0,lte=100" example:"1"`
+
+	// StandbyNodeID is where a node-hosted inbound fails over while its node is
+	// down; FailoverOf marks the standby copy there, never edited or listed.
+	StandbyNodeID *int `json:"standbyNodeId,omitempty" form:"standbyNodeId" gorm:"column:standby_node_id;index"`
+	FailoverOf    *int `json:"failoverOf,omitempty" form:"-" gorm:"column:failover_of;index"`
+
 	// OriginNodeGuid is the panelGuid of the node that physically hosts this
 	// inbound, propagated up across hops (#4983). Empty for an inbound that
 	// lives on this panel's own xray; set to the originating node's GUID when
@@ -88,6 +107,14 @@ type Inbound struct {
 	FallbackParent *FallbackParentInfo `json:"fallbackParent,omitempty" gorm:"-"`
 }
 
+// TrafficWeight returns a traffic coefficient, 1 when unset.
+func TrafficWeight(coefficient *float64) float64 {
+	if coefficient == nil {
+		return 1
+	}
+	return *coefficient
+}
+
 // FallbackParentInfo carries everything the frontend needs to rewrite a
 // child inbound's client link: where to connect (the master's address
 // and port) and which path matched on the master's fallbacks array.
@@ -789,6 +816,9 @@ type Node struct {
 	InboundSyncMode     string   `json:"inboundSyncMode" form:"inboundSyncMode" gorm:"column:inbound_sync_mode;default:all" validate:"omitempty,oneof=all selected"`
 	InboundTags         []string `json:"inboundTags" form:"inboundTags" gorm:"serializer:json;column:inbound_tags"`
 	OutboundTag         string   `json:"outboundTag" form:"outboundTag" gorm:"column:outbound_tag"`
+	// TrafficCoefficient weighs the traffic clients use on this node, on top
+	// of the inbound's. Unset counts 1:1.
+	TrafficCoefficient *float64 `json:"trafficCoefficient,omitempty" form:"trafficCoefficient" gorm:"column:traffic_coefficient" validate:"omitempty,gte=0,lte=100" example:"1"`
 
 	// Guid is the remote panel's stable self-identifier (its panelGuid),
 	// learned from each heartbeat. It is the globally stable node identity used
@@ -831,6 +861,8 @@ type Node struct {
 	ActiveCount   int `json:"activeCount" gorm:"-" example:"23"`
 	DisabledCount int `json:"disabledCount" gorm:"-" example:"3"`
 	DepletedCount int `json:"depletedCount" gorm:"-" example:"1"`
+	// OutboxCount is how many runtime operations are queued for the node.
+	OutboxCount int `json:"outboxCount" gorm:"-" example:"0"`
 
 	// ParentGuid + Transitive are set only when a node is surfaced as part of a
 	// node tree (#4983): direct nodes carry the master panel's own GUID, a
@@ -843,6 +875,86 @@ type Node struct {
 	UpdatedAt int64 `json:"updatedAt" gorm:"autoUpdateTime:milli" example:"1700000000"`
 }
 
+// NodeJoinToken is a short-lived, single-use secret for `x-ui join`. Only its
+// SHA-256 is stored; the plaintext is shown once, when minted.
+type NodeJoinToken struct {
+	Id    int    `json:"id" gorm:"primaryKey;autoIncrement" example:"1"`
+	Token string `json:"-" gorm:"uniqueIndex;not null"`
+	// Name is the node name to register; empty uses the name the node sends.
+	Name                string `json:"name" example:"de-fra-2"`
+	AllowPrivateAddress bool   `json:"allowPrivateAddress" gorm:"column:allow_private_address;default:false"`
+	ExpiresAt           int64  `json:"expiresAt" gorm:"column:expires_at;not null" example:"1736003600000"` // unix ms
+	UsedAt              int64  `json:"usedAt" gorm:"column:used_at;default:0" example:"0"`                  // unix ms; 0 = unused
+	NodeId              int    `json:"nodeId" gorm:"column:node_id;default:0" example:"0"`
+	CreatedAt           int64  `json:"createdAt" gorm:"autoCreateTime:milli"`
+}
+
+func (NodeJoinToken) TableName() string { return "node_join_tokens" }
+
+// NodeOutboxEntry is replayed in Id order once the node is back. Payload is
+// the arguments as queued, so replay reproduces the same sequence of states.
+type NodeOutboxEntry struct {
+	Id     int    `json:"id" gorm:"primaryKey;autoIncrement" example:"1"`
+	NodeId int    `json:"nodeId" gorm:"column:node_id;index;not null" example:"1"`
+	Op     string `json:"op" gorm:"not null" example:"updateUser"`
+	// Key identifies the operation and its arguments; an entry is not queued
+	// again while the node's newest pending entry carries the same key.
+	Key           string `json:"key" gorm:"not null" example:"3f2a..."`
+	Tag           string `json:"tag" example:"inbound-443"`
+	Email         string `json:"email" example:"alice"`
+	Payload       string `json:"-" gorm:"type:text"`
+	Attempts      int    `json:"attempts" gorm:"default:0" example:"0"`
+	NextAttemptAt int64  `json:"nextAttemptAt" gorm:"column:next_attempt_at;default:0" example:"1736003600000"` // unix ms
+	LastError     string `json:"lastError" gorm:"column:last_error"`
+	// Status is "pending", or "failed" once automatic retries gave up.
+	Status    string `json:"status" gorm:"default:pending" example:"pending"`
+	CreatedAt int64  `json:"createdAt" gorm:"autoCreateTime:milli"`
+	UpdatedAt int64  `json:"updatedAt" gorm:"autoUpdateTime:milli"`
+}
+
+func (NodeOutboxEntry) TableName() string { return "node_outbox" }
+
+// NodeRollout upgrades the panel or Xray across nodes batch by batch, canary first,
+// advancing only once the heartbeat shows the target running; a failure aborts it.
+type NodeRollout struct {
+	Id   int    `json:"id" gorm:"primaryKey;autoIncrement" example:"1"`
+	Kind string `json:"kind" gorm:"not null" example:"panel"` // panel|xray
+	// TargetVersion is the version every node must report when done: a panel
+	// release tag, "dev+<commit>" on the dev channel, or an Xray release tag.
+	TargetVersion string `json:"targetVersion" gorm:"column:target_version" example:"v3.6.1"`
+	Dev           bool   `json:"dev" gorm:"default:false"`
+	CanarySize    int    `json:"canarySize" gorm:"column:canary_size" example:"1"`
+	BatchSize     int    `json:"batchSize" gorm:"column:batch_size" example:"5"`
+	// Status is "running", then "succeeded", "aborted" after a failed node,
+	// or "cancelled" by an operator.
+	Status     string            `json:"status" gorm:"default:running;index" example:"running"`
+	Error      string            `json:"error,omitempty"`
+	Steps      []NodeRolloutStep `json:"steps" gorm:"foreignKey:RolloutId;references:Id"`
+	CreatedAt  int64             `json:"createdAt" gorm:"autoCreateTime:milli"`
+	UpdatedAt  int64             `json:"updatedAt" gorm:"autoUpdateTime:milli"`
+	FinishedAt int64             `json:"finishedAt" gorm:"column:finished_at;default:0"` // unix ms; 0 = running
+}
+
+func (NodeRollout) TableName() string { return "node_rollouts" }
+
+// NodeRolloutStep is one node's place in a rollout.
+type NodeRolloutStep struct {
+	Id        int    `json:"id" gorm:"primaryKey;autoIncrement" example:"1"`
+	RolloutId int    `json:"rolloutId" gorm:"column:rollout_id;index;not null" example:"1"`
+	NodeId    int    `json:"nodeId" gorm:"column:node_id;not null" example:"3"`
+	NodeName  string `json:"nodeName" gorm:"column:node_name" example:"de-fra-1"`
+	Batch     int    `json:"batch" gorm:"not null" example:"0"` // 0 is the canary batch
+	// State is "pending", "upgrading" once the node was told to upgrade,
+	// "done", "failed", or "skipped" for a node that was never upgraded.
+	State       string `json:"state" gorm:"default:pending" example:"pending"`
+	FromVersion string `json:"fromVersion" gorm:"column:from_version" example:"v3.6.0"`
+	Error       string `json:"error,omitempty"`
+	StartedAt   int64  `json:"startedAt" gorm:"column:started_at;default:0"`   // unix ms
+	FinishedAt  int64  `json:"finishedAt" gorm:"column:finished_at;default:0"` // unix ms
+}
+
+func (NodeRolloutStep) TableName() string { return "node_rollout_steps" }
+
 // NodeSummary is the read-only identity of a node as published one hop up: the
 // view a panel exposes about the nodes it directly manages, so a master can
 // surface transitive sub-nodes in a chained topology (#4983). Counts are
@@ -899,8 +1011,14 @@ type Client struct {
 	// Per-client traffic reset cycle, independent of the inbound's own (#5497).
 	TrafficReset    string `json:"trafficReset,omitempty" form:"trafficReset" validate:"omitempty,oneof=never hourly daily weekly monthly"`
 	TrafficResetDay int    `json:"trafficResetDay,omitempty" form:"trafficResetDay" validate:"omitempty,gte=1,lte=31"`
-	CreatedAt       int64  `json:"created_at,omitempty"` // Creation timestamp
-	UpdatedAt       int64  `json:"updated_at,omitempty"` // Last update timestamp
+	// Outbound or balancer tag the client's traffic leaves through; overrides
+	// the group's.
+	Egress string `json:"egress,omitempty" form:"egress"`
+	// Weekly windows the client may connect in, like "mon-fri 09:00-18:00",
+	// read in the panel's time zone; overrides the group's. Empty is always.
+	Schedule  string `json:"schedule,omitempty" form:"schedule"`
+	CreatedAt int64  `json:"created_at,omitempty"` // Creation timestamp
+	UpdatedAt int64  `json:"updated_at,omitempty"` // Last update timestamp
 }
 
 type ClientRecord struct {
@@ -927,12 +1045,15 @@ type ClientRecord struct {
 	Enable          bool   `json:"enable" gorm:"default:true"`
 	TgID            int64  `json:"tgId" gorm:"column:tg_id;index:idx_clients_tg_id"`
 	Group           string `json:"group" gorm:"column:group_name;default:'';index:idx_client_record_group"`
+	Egress          string `json:"egress" gorm:"column:egress;default:''"`     // overrides the group's egress
+	Schedule        string `json:"schedule" gorm:"column:schedule;default:''"` // overrides the group's schedule
 	Comment         string `json:"comment"`
 	Reset           int    `json:"reset" gorm:"default:0"`
 	ResetDay        int    `json:"resetDay" gorm:"column:reset_day;default:0"`
 	ResetMax        int    `json:"resetMax" gorm:"column:reset_max;default:0"`
 	TrafficReset    string `json:"trafficReset" gorm:"column:traffic_reset;default:never;index:idx_clients_traffic_reset"`
 	TrafficResetDay int    `json:"trafficResetDay" gorm:"column:traffic_reset_day;default:1"`
+	OwnerId         int    `json:"ownerId" gorm:"column:owner_id;default:0;index:idx_clients_owner"` // reseller that created it; 0 = panel
 	CreatedAt       int64  `json:"createdAt" gorm:"autoCreateTime:milli"`
 	UpdatedAt       int64  `json:"updatedAt" gorm:"autoUpdateTime:milli"`
 	// Owned solely by the node-snapshot sweep, which soft-orphans instead of
@@ -947,12 +1068,66 @@ type ClientGroup struct {
 	Name      string `json:"name" gorm:"uniqueIndex;not null"`
 	ResetUp   int64  `json:"resetUp" gorm:"column:reset_up;default:0"`
 	ResetDown int64  `json:"resetDown" gorm:"column:reset_down;default:0"`
+	Egress    string `json:"egress" gorm:"column:egress;default:''"`     // for members without their own
+	Schedule  string `json:"schedule" gorm:"column:schedule;default:''"` // for members without their own
 	CreatedAt int64  `json:"createdAt" gorm:"autoCreateTime:milli"`
 	UpdatedAt int64  `json:"updatedAt" gorm:"autoUpdateTime:milli"`
 }
 
 func (ClientGroup) TableName() string { return "client_groups" }
 
+const (
+	PlanRenewExtend   = "extend"
+	PlanRenewAddQuota = "addQuota"
+	PlanRenewReset    = "reset"
+)
+
+// Plan provisions and renews clients. RenewMode picks what a renewal does:
+// extend the expiry by DurationDays, add TotalGB, or reset usage for a new period.
+type Plan struct {
+	Id                 int    `json:"id" gorm:"primaryKey;autoIncrement" example:"1"`
+	Name               string `json:"name" gorm:"uniqueIndex;not null" example:"monthly-50"`
+	Comment            string `json:"comment"`
+	TotalGB            int64  `json:"totalGB" gorm:"column:total_gb" example:"53687091200"`  // bytes; 0 = unlimited
+	DurationDays       int    `json:"durationDays" gorm:"column:duration_days" example:"30"` // 0 = never expires
+	StartAfterFirstUse bool   `json:"startAfterFirstUse" gorm:"column:start_after_first_use"`
+	LimitIP            int    `json:"limitIp" gorm:"column:limit_ip"`
+	LimitHwid          int    `json:"limitHwid" gorm:"column:limit_hwid"`
+	TrafficReset       string `json:"trafficReset" gorm:"column:traffic_reset;default:never" example:"never"`
+	TrafficResetDay    int    `json:"trafficResetDay" gorm:"column:traffic_reset_day;default:1"`
+	Group              string `json:"group" gorm:"column:group_name;default:''"`
+	InboundIds         []int  `json:"inboundIds" gorm:"column:inbound_ids;serializer:json" example:"1,2"`
+	RenewMode          string `json:"renewMode" gorm:"column:renew_mode;default:extend" example:"extend"`
+	CreatedAt          int64  `json:"createdAt" gorm:"autoCreateTime:milli"`
+	UpdatedAt          int64  `json:"updatedAt" gorm:"autoUpdateTime:milli"`
+}
+
+func (Plan) TableName() string { return "plans" }
+
+const (
+	VoucherKindAny    = "any"
+	VoucherKindCreate = "create"
+	VoucherKindTopUp  = "topUp"
+)
+
+// Voucher provisions a client from a plan or tops a subscription up by its
+// renew mode; Kind limits a code to one of the two (invites are create-only).
+type Voucher struct {
+	Id        int    `json:"id" gorm:"primaryKey;autoIncrement" example:"1"`
+	Code      string `json:"code" gorm:"uniqueIndex;not null" example:"K7QM-9XPT-4RAZ"`
+	PlanId    int    `json:"planId" gorm:"column:plan_id;index;not null" example:"1"`
+	Kind      string `json:"kind" gorm:"default:any" example:"any"`
+	MaxUses   int    `json:"maxUses" gorm:"column:max_uses;default:1" example:"1"` // 0 = unlimited
+	Uses      int    `json:"uses" gorm:"default:0" example:"0"`
+	ExpiresAt int64  `json:"expiresAt" gorm:"column:expires_at;default:0" example:"0"` // unix ms; 0 = never
+	Enable    bool   `json:"enable" gorm:"default:true" example:"true"`
+	Comment   string `json:"comment"`
+	CreatedAt int64  `json:"createdAt" gorm:"autoCreateTime:milli"`
+	UpdatedAt int64  `json:"updatedAt" gorm:"autoUpdateTime:milli"`
+}
+
+func (Voucher) TableName() string { return "vouchers" }
+
 // MarshalJSON emits the reverse column as a nested JSON object rather than an
 // escaped JSON-text string, matching the same convention Inbound uses for its
 // JSON-text columns. Empty storage renders as null.
@@ -1117,6 +1292,8 @@ func (c *Client) ToRecord() *ClientRecord {
 		Enable:          c.Enable,
 		TgID:            c.TgID,
 		Group:           c.Group,
+		Egress:          c.Egress,
+		Schedule:        c.Schedule,
 		Comment:         c.Comment,
 		Reset:           c.Reset,
 		ResetDay:        c.ResetDay,
@@ -1174,6 +1351,8 @@ func (r *ClientRecord) ToClient() *Client {
 		Enable:          r.Enable,
 		TgID:            r.TgID,
 		Group:           r.Group,
+		Egress:          r.Egress,
+		Schedule:        r.Schedule,
 		Comment:         r.Comment,
 		Reset:           r.Reset,
 		ResetDay:        r.ResetDay,
@@ -1227,6 +1406,103 @@ type OutboundSubscription struct {
 	OutboundCount        int    `json:"outboundCount" gorm:"-"`
 }
 
+const (
+	AcmeStatusPending = "pending"
+	AcmeStatusValid   = "valid"
+	AcmeStatusError   = "error"
+)
+
+// AcmeCertificate is a certificate issued by the built-in ACME client. The DB
+// row is the source of truth; CertFile/KeyFile are rewritten from it.
+type AcmeCertificate struct {
+	Id          int      `json:"id" form:"id" gorm:"primaryKey;autoIncrement"`
+	Domains     []string `json:"domains" form:"domains" gorm:"serializer:json"`
+	Challenge   string   `json:"challenge" form:"challenge"`
+	DnsProvider string   `json:"dnsProvider" form:"dnsProvider" gorm:"column:dns_provider"`
+	DnsConfig   string   `json:"-" gorm:"type:text;column:dns_config"`
+	AutoRenew   bool     `json:"autoRenew" form:"autoRenew" gorm:"column:auto_renew"`
+	Status      string   `json:"status" form:"status"`
+	CertPem     string   `json:"-" gorm:"type:text;column:cert_pem"`
+	KeyPem      string   `json:"-" gorm:"type:text;column:key_pem"`
+	NotBefore   int64    `json:"notBefore" gorm:"column:not_before"`
+	NotAfter    int64    `json:"notAfter" gorm:"column:not_after"`
+	LastError   string   `json:"lastError" gorm:"type:text;column:last_error"`
+	LastAttempt int64    `json:"lastAttempt" gorm:"column:last_attempt"`
+	CreatedAt   int64    `json:"createdAt" gorm:"autoCreateTime:milli"`
+	UpdatedAt   int64    `json:"updatedAt" gorm:"autoUpdateTime:milli"`
+
+	CertFile     string `json:"certFile" gorm:"-"`
+	KeyFile      string `json:"keyFile" gorm:"-"`
+	HasDnsConfig bool   `json:"hasDnsConfig" gorm:"-"`
+}
+
+func (AcmeCertificate) TableName() string { return "acme_certificates" }
+
+// AuditLog is one state-changing panel API call. Changes maps dotted field
+// paths of the target to their values before and after the call.
+type AuditLog struct {
+	Id         int                    `json:"id" gorm:"primaryKey;autoIncrement" example:"1"`
+	CreatedAt  int64                  `json:"createdAt" gorm:"autoCreateTime:milli;index" example:"1767225600000"`
+	Actor      string                 `json:"actor" gorm:"index" example:"admin"`
+	ActorType  string                 `json:"actorType" gorm:"column:actor_type" example:"user"`
+	SourceIP   string                 `json:"sourceIp" gorm:"column:source_ip" example:"203.0.113.7"`
+	Method     string                 `json:"method" example:"POST"`
+	Route      string                 `json:"route" example:"/inbounds/update/:id"`
+	TargetType string                 `json:"targetType" gorm:"column:target_type" example:"inbound"`
+	TargetId   string                 `json:"targetId" gorm:"column:target_id" example:"3"`
+	Success    bool                   `json:"success" example:"true"`
+	Error      string                 `json:"error" gorm:"type:text"`
+	Changes    map[string]AuditChange `json:"changes" gorm:"type:text;serializer:json"`
+}
+
+func (AuditLog) TableName() string { return "audit_logs" }
+
+// AuditChange is one changed field; a nil side means the field was absent.
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+const (
+	WebhookDeliveryPending = "pending"
+	WebhookDeliverySuccess = "success"
+	WebhookDeliveryFailed  = "failed"
+)
+
+// Webhook is an HTTP endpoint that receives signed JSON for the event bus
+// events it subscribes to. An empty Events list subscribes to all of them.
+type Webhook struct {
+	Id           int      `json:"id" form:"id" gorm:"primaryKey;autoIncrement" example:"1"`
+	Name         string   `json:"name" form:"name" example:"ops"`
+	Url          string   `json:"url" form:"url" example:"https://hooks.example.com/3x-ui"`
+	Secret       string   `json:"-" gorm:"type:text"`
+	Events       []string `json:"events" form:"events" gorm:"serializer:json" example:"node.down,xray.crash"`
+	Enable       bool     `json:"enable" form:"enable" example:"true"`
+	AllowPrivate bool     `json:"allowPrivate" form:"allowPrivate" gorm:"column:allow_private"`
+	CreatedAt    int64    `json:"createdAt" gorm:"autoCreateTime:milli"`
+	UpdatedAt    int64    `json:"updatedAt" gorm:"autoUpdateTime:milli"`
+}
+
+func (Webhook) TableName() string { return "webhooks" }
+
+// WebhookDelivery is one payload sent, or still to be sent, to a webhook.
+// Pending rows are retried with backoff once NextAttemptAt has passed.
+type WebhookDelivery struct {
+	Id            int    `json:"id" gorm:"primaryKey;autoIncrement" example:"1"`
+	WebhookId     int    `json:"webhookId" gorm:"index;column:webhook_id" example:"1"`
+	Event         string `json:"event" example:"node.down"`
+	Payload       string `json:"payload" gorm:"type:text"`
+	Status        string `json:"status" gorm:"index" example:"success"`
+	Attempts      int    `json:"attempts" example:"1"`
+	NextAttemptAt int64  `json:"nextAttemptAt" gorm:"column:next_attempt_at"`
+	ResponseCode  int    `json:"responseCode" gorm:"column:response_code" example:"200"`
+	Error         string `json:"error" gorm:"type:text"`
+	CreatedAt     int64  `json:"createdAt" gorm:"autoCreateTime:milli;index"`
+	UpdatedAt     int64  `json:"updatedAt" gorm:"autoUpdateTime:milli"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_deliveries" }
+
 func MergeClientRecord(existing *ClientRecord, incoming *ClientRecord) []ClientMergeConflict {
 	var conflicts []ClientMergeConflict
 	keep := func(field string, oldV, newV, kept any) {
@@ -1406,6 +1682,18 @@ func MergeClientRecord(existing *ClientRecord, incoming *ClientRecord) []ClientM
 			existing.Group = incoming.Group
 		}
 	}
+	if existing.Egress != incoming.Egress && incoming.Egress != "" {
+		if incomingNewer || existing.Egress == "" {
+			keep("egress", existing.Egress, incoming.Egress, incoming.Egress)
+			existing.Egress = incoming.Egress
+		}
+	}
+	if existing.Schedule != incoming.Schedule && incoming.Schedule != "" {
+		if incomingNewer || existing.Schedule == "" {
+			keep("schedule", existing.Schedule, incoming.Schedule, incoming.Schedule)
+			existing.Schedule = incoming.Schedule
+		}
+	}
 	if existing.Enable != incoming.Enable {
 		if incoming.Enable {
 			if !existing.Enable {
@@ -0,0 +1,104 @@
+package model
+
+const (
+	UserRoleOwner    = "owner"
+	UserRoleAdmin    = "admin"
+	UserRoleReseller = "reseller"
+	UserRoleReadOnly = "read-only"
+)
+
+// Route groups a panel account can be granted access to.
+const (
+	PermInbounds = "inbounds"
+	PermClients  = "clients"
+	PermNodes    = "nodes"
+	PermSettings = "settings"
+	PermXray     = "xray"
+)
+
+// Access levels, ordered: write implies read.
+const (
+	AccessNone  = "none"
+	AccessRead  = "read"
+	AccessWrite = "write"
+)
+
+var PermGroups = []string{PermInbounds, PermClients, PermNodes, PermSettings, PermXray}
+
+// rolePresets are the permissions a role starts with; per-account entries
+// in User.Permissions override them group by group.
+var rolePresets = map[string]map[string]string{
+	UserRoleAdmin: {
+		PermInbounds: AccessWrite,
+		PermClients:  AccessWrite,
+		PermNodes:    AccessWrite,
+		PermSettings: AccessWrite,
+		PermXray:     AccessWrite,
+	},
+	UserRoleReseller: {
+		PermInbounds: AccessRead,
+		PermClients:  AccessWrite,
+		PermNodes:    AccessNone,
+		PermSettings: AccessNone,
+		PermXray:     AccessNone,
+	},
+	UserRoleReadOnly: {
+		PermInbounds: AccessRead,
+		PermClients:  AccessRead,
+		PermNodes:    AccessRead,
+		PermSettings: AccessRead,
+		PermXray:     AccessRead,
+	},
+}
+
+func IsKnownUserRole(role string) bool {
+	return role == UserRoleOwner || rolePresets[role] != nil
+}
+
+func IsKnownPermGroup(group string) bool {
+	for _, g := range PermGroups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+func IsKnownAccess(level string) bool {
+	return level == AccessNone || level == AccessRead || level == AccessWrite
+}
+
+// IsOwner treats a blank role as owner: rows written before roles existed
+// belonged to the single administrator.
+func (u *User) IsOwner() bool {
+	return u.Role == UserRoleOwner || u.Role == ""
+}
+
+// Access returns the effective level for a route group.
+func (u *User) Access(group string) string {
+	if u.IsOwner() {
+		return AccessWrite
+	}
+	if level, ok := u.Permissions[group]; ok && IsKnownAccess(level) {
+		return level
+	}
+	if level, ok := rolePresets[u.Role][group]; ok {
+		return level
+	}
+	return AccessNone
+}
+
+// Can reports whether the account holds at least the given level on group.
+func (u *User) Can(group, level string) bool {
+	have := u.Access(group)
+	return have == AccessWrite || (have == AccessRead && level == AccessRead)
+}
+
+// EffectivePermissions resolves every route group for display.
+func (u *User) EffectivePermissions() map[string]string {
+	out := make(map[string]string, len(PermGroups))
+	for _, g := range PermGroups {
+		out[g] = u.Access(g)
+	}
+	return out
+}
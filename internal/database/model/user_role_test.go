@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+func TestUserAccess(t *testing.T) {
+	cases := []struct {
+		name  string
+		user  User
+		group string
+		want  string
+	}{
+		{"legacy blank role is owner", User{}, PermSettings, AccessWrite},
+		{"owner ignores overrides", User{Role: UserRoleOwner, Permissions: map[string]string{PermXray: AccessNone}}, PermXray, AccessWrite},
+		{"reseller preset", User{Role: UserRoleReseller}, PermClients, AccessWrite},
+		{"reseller has no settings", User{Role: UserRoleReseller}, PermSettings, AccessNone},
+		{"override beats preset", User{Role: UserRoleReadOnly, Permissions: map[string]string{PermClients: AccessWrite}}, PermClients, AccessWrite},
+		{"bad override falls back", User{Role: UserRoleReadOnly, Permissions: map[string]string{PermNodes: "all"}}, PermNodes, AccessRead},
+		{"unknown role gets nothing", User{Role: "guest"}, PermInbounds, AccessNone},
+	}
+	for _, c := range cases {
+		if got := c.user.Access(c.group); got != c.want {
+			t.Errorf("%s: Access(%s) = %q, want %q", c.name, c.group, got, c.want)
+		}
+	}
+}
+
+func TestUserCan(t *testing.T) {
+	ro := &User{Role: UserRoleReadOnly}
+	if !ro.Can(PermInbounds, AccessRead) || ro.Can(PermInbounds, AccessWrite) {
+		t.Fatal("read-only must read but not write")
+	}
+	admin := &User{Role: UserRoleAdmin}
+	if !admin.Can(PermXray, AccessRead) || !admin.Can(PermXray, AccessWrite) {
+		t.Fatal("write must imply read")
+	}
+}
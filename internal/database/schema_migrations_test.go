@@ -0,0 +1,78 @@
+package database
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+func openSchemaTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	return conn
+}
+
+// The audit_logs migration must record its version so a restart skips it,
+// and build a table the AuditLog model reads and writes.
+func TestSchemaMigrationsCreateAuditLogs(t *testing.T) {
+	conn := openSchemaTestDB(t)
+	for range 2 {
+		if err := runSchemaMigrations(conn); err != nil {
+			t.Fatalf("runSchemaMigrations: %v", err)
+		}
+	}
+	var applied int64
+	if err := conn.Raw("SELECT COUNT(*) FROM schema_migrations").Scan(&applied).Error; err != nil {
+		t.Fatalf("count schema_migrations: %v", err)
+	}
+	if applied != int64(len(schemaMigrations)) {
+		t.Fatalf("schema_migrations has %d rows, want %d", applied, len(schemaMigrations))
+	}
+	for _, idx := range []string{"idx_audit_logs_created_at", "idx_audit_logs_actor", "idx_audit_logs_target"} {
+		if !conn.Migrator().HasIndex(&model.AuditLog{}, idx) {
+			t.Errorf("index %q missing", idx)
+		}
+	}
+
+	entry := model.AuditLog{Actor: "admin", Route: "/inbounds/add", TargetType: "inbound", TargetId: "3", Success: true,
+		Changes: map[string]model.AuditChange{"remark": {Before: nil, After: "new"}}}
+	if err := conn.Create(&entry).Error; err != nil {
+		t.Fatalf("insert audit log: %v", err)
+	}
+	var got model.AuditLog
+	if err := conn.First(&got, entry.Id).Error; err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if got.CreatedAt == 0 || !got.Success || got.Changes["remark"].After != "new" {
+		t.Fatalf("round trip lost data: %+v", got)
+	}
+}
+
+// A panel upgraded from a release that AutoMigrated audit_logs keeps its rows;
+// the migration only fills in what is missing.
+func TestSchemaMigrationsAdoptAutoMigratedAuditLogs(t *testing.T) {
+	conn := openSchemaTestDB(t)
+	if err := conn.AutoMigrate(&model.AuditLog{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	if err := conn.Create(&model.AuditLog{Actor: "admin"}).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if err := runSchemaMigrations(conn); err != nil {
+		t.Fatalf("runSchemaMigrations: %v", err)
+	}
+	var rows int64
+	if err := conn.Model(&model.AuditLog{}).Count(&rows).Error; err != nil || rows != 1 {
+		t.Fatalf("rows = %d (%v), want the existing row kept", rows, err)
+	}
+	if !conn.Migrator().HasIndex(&model.AuditLog{}, "idx_audit_logs_target") {
+		t.Fatal("idx_audit_logs_target missing after adopting the table")
+	}
+}
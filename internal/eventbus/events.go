@@ -35,31 +35,31 @@ type Event struct {
 
 // OutboundHealthData carries observatory details for outbound events.
 type OutboundHealthData struct {
-	Delay int64  // last measured delay in ms, 0 if unknown
-	Error string // last error if probe failed, empty if up
+	Delay int64  `json:"delay"` // last measured delay in ms, 0 if unknown
+	Error string `json:"error"` // last error if probe failed, empty if up
 }
 
 // NodeHealthData carries heartbeat details for node events.
 type NodeHealthData struct {
-	NodeId    int
-	LatencyMs int
-	CpuPct    float64
-	MemPct    float64
-	XrayState string // "running", "stopped", etc.
-	XrayError string
+	NodeId    int     `json:"nodeId"`
+	LatencyMs int     `json:"latencyMs"`
+	CpuPct    float64 `json:"cpuPct"`
+	MemPct    float64 `json:"memPct"`
+	XrayState string  `json:"xrayState"` // "running", "stopped", etc.
+	XrayError string  `json:"xrayError"`
 }
 
 // LoginEventData carries login attempt details.
 type LoginEventData struct {
-	Username string
-	IP       string
-	Time     string
-	Status   string // "success" or "fail"
-	Reason   string
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+	Time     string `json:"time"`
+	Status   string `json:"status"` // "success" or "fail"
+	Reason   string `json:"reason"`
 }
 
 // SystemMetricData carries raw system metric values for threshold-based events.
 type SystemMetricData struct {
-	Percent   float64 // current usage percentage
-	Threshold int     // configured threshold
+	Percent   float64 `json:"percent"`   // current usage percentage
+	Threshold int     `json:"threshold"` // configured threshold
 }
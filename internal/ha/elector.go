@@ -0,0 +1,144 @@
+// Package ha pairs two panels on one PostgreSQL database: an advisory lock picks
+// the leader that runs Xray, jobs and node dispatch; the standby serves reads.
+package ha
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+)
+
+// Role is the part a panel currently plays in the pair.
+type Role string
+
+const (
+	RoleLeader  Role = "leader"
+	RoleStandby Role = "standby"
+)
+
+// DefaultInterval bounds takeover: a crashed leader's session ends with its
+// process, so the standby gets the lock within about one interval.
+const DefaultInterval = time.Second
+
+// Locker is the leadership lock. TryAcquire must not block while another
+// process holds it; Check fails once the lock can no longer be proven held.
+type Locker interface {
+	TryAcquire(ctx context.Context) (bool, error)
+	Check(ctx context.Context) error
+	Release()
+	Holder(ctx context.Context) (string, error)
+}
+
+// Elector runs both callbacks on its own goroutine, so a demotion never
+// overlaps an unfinished promotion.
+type Elector struct {
+	lock      Locker
+	interval  time.Duration
+	onPromote func()
+	onDemote  func()
+
+	leader atomic.Bool
+	since  atomic.Int64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewElector returns an elector that has not started campaigning yet.
+func NewElector(lock Locker, interval time.Duration, onPromote, onDemote func()) *Elector {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	e := &Elector{lock: lock, interval: interval, onPromote: onPromote, onDemote: onDemote}
+	e.since.Store(time.Now().Unix())
+	return e
+}
+
+// Start campaigns in the background until Stop.
+func (e *Elector) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+	go e.run(ctx)
+}
+
+// Stop gives up leadership, demoting first if needed, and waits for the
+// elector goroutine to exit.
+func (e *Elector) Stop() {
+	e.once.Do(func() {
+		if e.cancel == nil {
+			return
+		}
+		e.cancel()
+		<-e.done
+	})
+}
+
+// IsLeader reports whether this process currently holds the lock.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Since is the unix time of the last role change.
+func (e *Elector) Since() int64 {
+	return e.since.Load()
+}
+
+func (e *Elector) run(ctx context.Context) {
+	defer close(e.done)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		e.step(ctx)
+		select {
+		case <-ctx.Done():
+			if e.leader.Load() {
+				e.demote("panel is stopping")
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) step(ctx context.Context) {
+	// Answer well inside the time a partitioned database needs to drop our
+	// session, so the old leader steps down before the standby takes over.
+	stepCtx, cancel := context.WithTimeout(ctx, 2*e.interval)
+	defer cancel()
+	if e.leader.Load() {
+		if err := e.lock.Check(stepCtx); err != nil {
+			e.demote(err.Error())
+		}
+		return
+	}
+	ok, err := e.lock.TryAcquire(stepCtx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Debug("HA: leader lock attempt failed:", err)
+		}
+		return
+	}
+	if ok {
+		e.leader.Store(true)
+		e.since.Store(time.Now().Unix())
+		logger.Info("HA: this panel is now the leader")
+		if e.onPromote != nil {
+			e.onPromote()
+		}
+	}
+}
+
+func (e *Elector) demote(reason string) {
+	e.lock.Release()
+	e.leader.Store(false)
+	e.since.Store(time.Now().Unix())
+	logger.Warning("HA: stepping down to standby:", reason)
+	if e.onDemote != nil {
+		e.onDemote()
+	}
+}
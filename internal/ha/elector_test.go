@@ -0,0 +1,138 @@
+package ha
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/op/go-logging"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger(logging.ERROR)
+	m.Run()
+}
+
+// memLock is an in-process stand-in for the advisory lock shared by several
+// lockers, one per simulated panel.
+type memLock struct {
+	mu     sync.Mutex
+	holder string
+}
+
+type memLocker struct {
+	shared *memLock
+	node   string
+	broken atomic.Bool // simulates a lost database session
+}
+
+func (l *memLocker) TryAcquire(context.Context) (bool, error) {
+	if l.broken.Load() {
+		return false, errors.New("connection refused")
+	}
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+	if l.shared.holder == "" || l.shared.holder == l.node {
+		l.shared.holder = l.node
+		return true, nil
+	}
+	return false, nil
+}
+
+func (l *memLocker) Check(context.Context) error {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+	if l.broken.Load() || l.shared.holder != l.node {
+		return errLockLost
+	}
+	return nil
+}
+
+func (l *memLocker) Release() {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+	if l.shared.holder == l.node {
+		l.shared.holder = ""
+	}
+}
+
+func (l *memLocker) Holder(context.Context) (string, error) {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+	return l.shared.holder, nil
+}
+
+const testInterval = 10 * time.Millisecond
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(testInterval / 2)
+	}
+}
+
+func TestElectorSingleLeaderAndFailover(t *testing.T) {
+	shared := &memLock{}
+	var promotedA, promotedB, demotedA atomic.Int32
+	a := NewElector(&memLocker{shared: shared, node: "a"}, testInterval,
+		func() { promotedA.Add(1) }, func() { demotedA.Add(1) })
+	a.Start()
+	waitFor(t, "a to lead", a.IsLeader)
+
+	b := NewElector(&memLocker{shared: shared, node: "b"}, testInterval,
+		func() { promotedB.Add(1) }, nil)
+	b.Start()
+	defer b.Stop()
+	time.Sleep(5 * testInterval)
+	if b.IsLeader() || promotedB.Load() != 0 {
+		t.Fatal("standby promoted while the leader still holds the lock")
+	}
+
+	a.Stop()
+	if a.IsLeader() || demotedA.Load() != 1 {
+		t.Fatalf("stopped leader: leader=%v demotions=%d", a.IsLeader(), demotedA.Load())
+	}
+	waitFor(t, "b to take over", b.IsLeader)
+	if promotedA.Load() != 1 || promotedB.Load() != 1 {
+		t.Fatalf("promotions a=%d b=%d, want 1 each", promotedA.Load(), promotedB.Load())
+	}
+}
+
+func TestElectorDemotesWhenLockIsLost(t *testing.T) {
+	shared := &memLock{}
+	l := &memLocker{shared: shared, node: "a"}
+	var demoted atomic.Int32
+	e := NewElector(l, testInterval, nil, func() { demoted.Add(1) })
+	e.Start()
+	defer e.Stop()
+	waitFor(t, "a to lead", e.IsLeader)
+
+	l.broken.Store(true)
+	waitFor(t, "a to step down", func() bool { return !e.IsLeader() })
+	if demoted.Load() != 1 {
+		t.Fatalf("demotions = %d, want 1", demoted.Load())
+	}
+	if holder, _ := l.Holder(context.Background()); holder != "" {
+		t.Fatalf("demoted leader still holds the lock: %q", holder)
+	}
+
+	l.broken.Store(false)
+	waitFor(t, "a to lead again", e.IsLeader)
+}
+
+func TestElectorStopWithoutStart(t *testing.T) {
+	e := NewElector(&memLocker{shared: &memLock{}, node: "a"}, testInterval, nil, nil)
+	e.Stop()
+	if e.IsLeader() {
+		t.Fatal("unstarted elector reports leadership")
+	}
+}
@@ -0,0 +1,131 @@
+package ha
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// HAStatus is the HA state reported by the panel API.
+type HAStatus struct {
+	Enabled bool   `json:"enabled" example:"true"`
+	Role    Role   `json:"role" example:"leader"`
+	Node    string `json:"node" example:"panel-a"`
+	Leader  string `json:"leader,omitempty" example:"panel-a"`
+	Since   int64  `json:"since" example:"1735689600"`
+}
+
+var (
+	mu           sync.RWMutex
+	elector      *Elector
+	notifier     *Notifier
+	locker       *pgLocker
+	listenCancel context.CancelFunc
+	nodeName     string
+)
+
+// Start leaves this panel a standby until the first promotion; onPromote and
+// onDemote run on role changes.
+func Start(dsn, node string, onPromote, onDemote func()) error {
+	l, err := newPGLocker(dsn, node)
+	if err != nil {
+		return err
+	}
+	n := NewNotifier(node, func(ctx context.Context, payload string) error {
+		_, err := l.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", notifyChannel, payload)
+		return err
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	e := NewElector(l, DefaultInterval, onPromote, onDemote)
+
+	mu.Lock()
+	elector, notifier, locker, listenCancel, nodeName = e, n, l, cancel, node
+	mu.Unlock()
+
+	go n.listen(ctx, dsn)
+	e.Start()
+	return nil
+}
+
+// Stop steps down if leading and releases the database connections. It is a
+// no-op when HA was never started.
+func Stop() {
+	mu.Lock()
+	e, l, cancel := elector, locker, listenCancel
+	mu.Unlock()
+	if e == nil {
+		return
+	}
+	e.Stop()
+	cancel()
+	_ = l.Close()
+
+	mu.Lock()
+	elector, notifier, locker, listenCancel = nil, nil, nil, nil
+	mu.Unlock()
+}
+
+// Enabled reports whether this panel takes part in leader election.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return elector != nil
+}
+
+// IsLeader reports whether this panel should run jobs, Xray and node
+// dispatch. A panel without HA is always the leader.
+func IsLeader() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return elector == nil || elector.IsLeader()
+}
+
+// IsStandby reports whether this panel must stay read-only.
+func IsStandby() bool {
+	return !IsLeader()
+}
+
+// Publish tells the other panel that topic changed. Only the leader writes,
+// so a standby (or a panel without HA) publishes nothing.
+func Publish(topic string) {
+	mu.RLock()
+	n, e := notifier, elector
+	mu.RUnlock()
+	if n != nil && e.IsLeader() {
+		n.Publish(topic)
+	}
+}
+
+// Subscribe runs fn when the other panel publishes topic. It is a no-op
+// without HA.
+func Subscribe(topic string, fn func()) {
+	mu.RLock()
+	n := notifier
+	mu.RUnlock()
+	if n != nil {
+		n.Subscribe(topic, fn)
+	}
+}
+
+// CurrentStatus reports this panel's role and, when reachable, which node
+// holds the leader lock.
+func CurrentStatus(ctx context.Context) HAStatus {
+	mu.RLock()
+	e, l, node := elector, locker, nodeName
+	mu.RUnlock()
+	if e == nil {
+		return HAStatus{Role: RoleLeader}
+	}
+	st := HAStatus{Enabled: true, Role: RoleStandby, Node: node, Since: e.Since()}
+	if e.IsLeader() {
+		st.Role = RoleLeader
+		st.Leader = node
+		return st
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if holder, err := l.Holder(ctx); err == nil {
+		st.Leader = holder
+	}
+	return st
+}
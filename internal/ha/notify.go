@@ -0,0 +1,169 @@
+package ha
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+)
+
+// notifyChannel carries cache invalidations from the leader to the standby.
+const notifyChannel = "xui_invalidate"
+
+// notifyFlushDelay batches the bursts of changes a single admin action or
+// traffic cycle produces into one NOTIFY.
+const notifyFlushDelay = 200 * time.Millisecond
+
+// notification is the NOTIFY payload: the sending node and the topics that
+// changed. Payloads stay far below PostgreSQL's 8000-byte limit.
+type notification struct {
+	Node   string   `json:"n"`
+	Topics []string `json:"t"`
+}
+
+// Notifier batches published topics into NOTIFY payloads and dispatches the
+// ones other nodes send to local handlers.
+type Notifier struct {
+	node string
+	send func(ctx context.Context, payload string) error
+
+	mu       sync.Mutex
+	pending  map[string]struct{}
+	timer    *time.Timer
+	handlers map[string][]func()
+}
+
+// NewNotifier returns a notifier that sends payloads through send.
+func NewNotifier(node string, send func(ctx context.Context, payload string) error) *Notifier {
+	return &Notifier{
+		node:     node,
+		send:     send,
+		pending:  map[string]struct{}{},
+		handlers: map[string][]func(){},
+	}
+}
+
+// Subscribe runs fn whenever another node publishes topic, and after the
+// listener reconnects, since notifications sent meanwhile are lost.
+func (n *Notifier) Subscribe(topic string, fn func()) {
+	n.mu.Lock()
+	n.handlers[topic] = append(n.handlers[topic], fn)
+	n.mu.Unlock()
+}
+
+// Publish queues topic for the next batched NOTIFY.
+func (n *Notifier) Publish(topic string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending[topic] = struct{}{}
+	if n.timer == nil {
+		n.timer = time.AfterFunc(notifyFlushDelay, n.flush)
+	}
+}
+
+func (n *Notifier) flush() {
+	n.mu.Lock()
+	topics := make([]string, 0, len(n.pending))
+	for t := range n.pending {
+		topics = append(topics, t)
+	}
+	n.pending = map[string]struct{}{}
+	n.timer = nil
+	n.mu.Unlock()
+	if len(topics) == 0 {
+		return
+	}
+	sort.Strings(topics)
+	payload, err := json.Marshal(notification{Node: n.node, Topics: topics})
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.send(ctx, string(payload)); err != nil {
+		logger.Debug("HA: send invalidation failed:", err)
+	}
+}
+
+// dispatch runs the handlers for a payload received from another node.
+func (n *Notifier) dispatch(payload string) {
+	var msg notification
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil || msg.Node == n.node {
+		return
+	}
+	for _, t := range msg.Topics {
+		n.run(t)
+	}
+}
+
+// dispatchAll runs every handler once.
+func (n *Notifier) dispatchAll() {
+	n.mu.Lock()
+	topics := make([]string, 0, len(n.handlers))
+	for t := range n.handlers {
+		topics = append(topics, t)
+	}
+	n.mu.Unlock()
+	for _, t := range topics {
+		n.run(t)
+	}
+}
+
+func (n *Notifier) run(topic string) {
+	n.mu.Lock()
+	fns := append([]func(){}, n.handlers[topic]...)
+	n.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// listen keeps a dedicated connection LISTENing until ctx ends, reconnecting
+// with backoff.
+func (n *Notifier) listen(ctx context.Context, dsn string) {
+	connected := false
+	backoff := time.Second
+	for ctx.Err() == nil {
+		err := n.listenOnce(ctx, dsn, func() {
+			if connected {
+				n.dispatchAll()
+			}
+			connected = true
+			backoff = time.Second
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warning("HA: invalidation listener disconnected:", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func (n *Notifier) listenOnce(ctx context.Context, dsn string, onListening func()) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	onListening()
+	for {
+		msg, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		n.dispatch(msg.Payload)
+	}
+}
@@ -0,0 +1,62 @@
+package ha
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotifierBatchesPublishes(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	n := NewNotifier("a", func(_ context.Context, payload string) error {
+		mu.Lock()
+		sent = append(sent, payload)
+		mu.Unlock()
+		return nil
+	})
+	n.Publish("inbounds")
+	n.Publish("clients")
+	n.Publish("inbounds")
+
+	waitFor(t, "the batched notify", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sent) > 0
+	})
+	time.Sleep(notifyFlushDelay)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d notifications, want 1", len(sent))
+	}
+	var msg notification
+	if err := json.Unmarshal([]byte(sent[0]), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Node != "a" || len(msg.Topics) != 2 || msg.Topics[0] != "clients" || msg.Topics[1] != "inbounds" {
+		t.Fatalf("payload = %+v", msg)
+	}
+}
+
+func TestNotifierDispatch(t *testing.T) {
+	n := NewNotifier("b", nil)
+	var inbounds, clients atomic.Int32
+	n.Subscribe("inbounds", func() { inbounds.Add(1) })
+	n.Subscribe("clients", func() { clients.Add(1) })
+
+	n.dispatch(`{"n":"a","t":["inbounds","unknown"]}`)
+	n.dispatch(`{"n":"b","t":["clients"]}`) // own echo
+	n.dispatch(`not json`)
+	if inbounds.Load() != 1 || clients.Load() != 0 {
+		t.Fatalf("inbounds=%d clients=%d, want 1 and 0", inbounds.Load(), clients.Load())
+	}
+
+	n.dispatchAll()
+	if inbounds.Load() != 2 || clients.Load() != 1 {
+		t.Fatalf("after reconnect inbounds=%d clients=%d, want 2 and 1", inbounds.Load(), clients.Load())
+	}
+}
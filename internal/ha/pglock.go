@@ -0,0 +1,140 @@
+package ha
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	// Registers the "pgx" database/sql driver.
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// advisoryLockKey is "xuih" as an int; it sits below 2^32, so pg_locks shows
+// it with classid 0 and objid equal to the key.
+const advisoryLockKey int64 = 0x78756968
+
+const applicationNamePrefix = "x-ui-ha:"
+
+var errLockLost = errors.New("leader lock is no longer held by this session")
+
+// pgLocker pins one connection because advisory locks belong to the session:
+// a crashed leader releases at once, a partitioned one once keepalives give up.
+type pgLocker struct {
+	db   *sql.DB
+	node string
+	conn *sql.Conn
+}
+
+func newPGLocker(dsn, node string) (*pgLocker, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(2)
+	db.SetMaxIdleConns(2)
+	return &pgLocker{db: db, node: node}, nil
+}
+
+func (l *pgLocker) session(ctx context.Context) (*sql.Conn, error) {
+	if l.conn != nil {
+		return l.conn, nil
+	}
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The application name lets the standby report who leads; the keepalives
+	// bound how long a dead leader's session can keep holding the lock.
+	_, err = conn.ExecContext(ctx, `SELECT set_config('application_name', $1, false),
+		set_config('tcp_keepalives_idle', '5', false),
+		set_config('tcp_keepalives_interval', '1', false),
+		set_config('tcp_keepalives_count', '3', false)`, applicationNamePrefix+l.node)
+	if err != nil {
+		discardConn(conn)
+		return nil, err
+	}
+	l.conn = conn
+	return conn, nil
+}
+
+func (l *pgLocker) TryAcquire(ctx context.Context) (bool, error) {
+	conn, err := l.session(ctx)
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", advisoryLockKey).Scan(&ok); err != nil {
+		l.drop()
+		return false, err
+	}
+	return ok, nil
+}
+
+func (l *pgLocker) Check(ctx context.Context) error {
+	if l.conn == nil {
+		return errLockLost
+	}
+	var held bool
+	err := l.conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_locks
+		WHERE locktype = 'advisory' AND pid = pg_backend_pid()
+		AND classid = 0 AND objid::bigint = $1 AND objsubid = 1 AND granted)`, advisoryLockKey).Scan(&held)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return errLockLost
+	}
+	return nil
+}
+
+// Release unlocks best-effort and always throws the connection away, so a
+// session that might still hold the lock never goes back to a pool.
+func (l *pgLocker) Release() {
+	if l.conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _ = l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock_all()")
+	l.drop()
+}
+
+func (l *pgLocker) drop() {
+	if l.conn != nil {
+		discardConn(l.conn)
+		l.conn = nil
+	}
+}
+
+func (l *pgLocker) Holder(ctx context.Context) (string, error) {
+	var name sql.NullString
+	err := l.db.QueryRowContext(ctx, `SELECT a.application_name FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.classid = 0 AND l.objid::bigint = $1
+		AND l.objsubid = 1 AND l.granted LIMIT 1`, advisoryLockKey).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if node, ok := strings.CutPrefix(name.String, applicationNamePrefix); ok {
+		return node, nil
+	}
+	return name.String, nil
+}
+
+func (l *pgLocker) Close() error {
+	l.Release()
+	return l.db.Close()
+}
+
+// discardConn drops the connection instead of pooling it; database/sql does
+// that when a Raw callback reports driver.ErrBadConn.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
@@ -0,0 +1,48 @@
+package ha
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// TestPGLockerExclusive runs against a real PostgreSQL when XUI_TEST_PG_DSN
+// is set.
+func TestPGLockerExclusive(t *testing.T) {
+	dsn := os.Getenv("XUI_TEST_PG_DSN")
+	if dsn == "" {
+		t.Skip("XUI_TEST_PG_DSN not set")
+	}
+	ctx := context.Background()
+	a, err := newPGLocker(dsn, "panel-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := newPGLocker(dsn, "panel-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if ok, err := a.TryAcquire(ctx); err != nil || !ok {
+		t.Fatalf("a.TryAcquire = %v, %v", ok, err)
+	}
+	if ok, err := b.TryAcquire(ctx); err != nil || ok {
+		t.Fatalf("b.TryAcquire while a holds = %v, %v", ok, err)
+	}
+	if err := a.Check(ctx); err != nil {
+		t.Fatalf("a.Check: %v", err)
+	}
+	if holder, err := b.Holder(ctx); err != nil || holder != "panel-a" {
+		t.Fatalf("Holder = %q, %v", holder, err)
+	}
+
+	a.Release()
+	if err := a.Check(ctx); err == nil {
+		t.Fatal("Check passed after Release")
+	}
+	if ok, err := b.TryAcquire(ctx); err != nil || !ok {
+		t.Fatalf("b.TryAcquire after release = %v, %v", ok, err)
+	}
+}
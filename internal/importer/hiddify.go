@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+const gib = 1 << 30
+
+// hiddifyResets maps a Hiddify user's mode onto client traffic reset cycles.
+var hiddifyResets = map[string]string{
+	"no_reset": "never",
+	"daily":    "daily",
+	"weekly":   "weekly",
+	"monthly":  "monthly",
+}
+
+// hiddifyBackup is the part of a Hiddify Manager backup file read here. Older
+// releases count traffic in GB, newer ones in bytes.
+type hiddifyBackup struct {
+	Users []struct {
+		UUID           string  `json:"uuid"`
+		Name           string  `json:"name"`
+		Enable         *bool   `json:"enable"`
+		Comment        string  `json:"comment"`
+		TelegramId     any     `json:"telegram_id"`
+		Mode           string  `json:"mode"`
+		PackageDays    int64   `json:"package_days"`
+		StartDate      string  `json:"start_date"`
+		UsageLimitGB   float64 `json:"usage_limit_GB"`
+		CurrentUsageGB float64 `json:"current_usage_GB"`
+		UsageLimit     *int64  `json:"usage_limit"`
+		CurrentUsage   *int64  `json:"current_usage"`
+	} `json:"users"`
+}
+
+// readHiddify reads a Hiddify Manager backup. The backup holds no inbounds and
+// one UUID serves as id, password and subId, so users land on portless inbounds.
+func readHiddify(data []byte) (*Bundle, error) {
+	var backup hiddifyBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("hiddify backup: %w", err)
+	}
+	b := &Bundle{Source: SourceHiddify}
+	b.Inbounds = syntheticInbounds([]model.Protocol{model.VLESS, model.VMESS, model.Trojan})
+
+	taken := map[string]bool{}
+	renamed := 0
+	for _, u := range backup.Users {
+		if u.UUID == "" {
+			continue
+		}
+		email := clientName(u.Name)
+		if email == "" || taken[strings.ToLower(email)] {
+			email = strings.TrimLeft(email+"-"+u.UUID[:min(8, len(u.UUID))], "-")
+			renamed++
+		}
+		taken[strings.ToLower(email)] = true
+
+		c := Client{Client: model.Client{
+			ID:       u.UUID,
+			Password: u.UUID,
+			Email:    email,
+			SubID:    u.UUID,
+			Enable:   u.Enable == nil || *u.Enable,
+			Comment:  u.Comment,
+			TgID:     num(u.TelegramId),
+		}}
+		if u.UsageLimit != nil {
+			c.TotalGB = *u.UsageLimit
+		} else {
+			c.TotalGB = int64(u.UsageLimitGB * gib)
+		}
+		if u.CurrentUsage != nil {
+			c.Down = *u.CurrentUsage
+		} else {
+			c.Down = int64(u.CurrentUsageGB * gib)
+		}
+		if u.PackageDays > 0 {
+			days := u.PackageDays * 24 * 60 * 60 * 1000
+			if start := timeMs(u.StartDate); start > 0 {
+				c.ExpiryTime = start + days
+			} else {
+				// Not started yet: the package runs from first use.
+				c.ExpiryTime = -days
+			}
+		}
+		c.TrafficReset = hiddifyResets[u.Mode]
+		for _, ib := range b.Inbounds {
+			c.Inbounds = append(c.Inbounds, ib.Tag)
+		}
+		b.Clients = append(b.Clients, c)
+	}
+	if renamed > 0 {
+		b.warnf("%d user(s) had an empty or repeated name and were named after their UUID", renamed)
+	}
+	return b, nil
+}
@@ -0,0 +1,299 @@
+// Package importer maps other proxy panels' users and inbounds onto a Bundle;
+// the service layer writes it through the normal inbound and client paths.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+// Supported sources.
+const (
+	SourceMarzban   = "marzban"
+	SourceHiddify   = "hiddify"
+	SourceRemnawave = "remnawave"
+	SourceXUI       = "x-ui"
+)
+
+// Sources lists the source names Read accepts.
+var Sources = []string{SourceMarzban, SourceHiddify, SourceRemnawave, SourceXUI}
+
+// Bundle is what a reader found in a source panel.
+type Bundle struct {
+	Source   string
+	Inbounds []Inbound
+	Clients  []Client
+	Warnings []string
+}
+
+func (b *Bundle) warnf(format string, args ...any) {
+	b.Warnings = append(b.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Inbound is an inbound of the source panel, its Settings without clients. One
+// without a Port has no usable definition and must be mapped onto an inbound.
+type Inbound struct {
+	Tag            string
+	Remark         string
+	Protocol       model.Protocol
+	Listen         string
+	Port           int
+	Settings       string
+	StreamSettings string
+	Sniffing       string
+	Enable         bool
+	Total          int64
+	ExpiryTime     int64
+}
+
+// Client is a user of the source panel with the traffic it has used so far.
+// Inbounds names the source inbounds (by tag) it is attached to.
+type Client struct {
+	model.Client
+	LimitHwid int
+	Up        int64
+	Down      int64
+	Inbounds  []string
+}
+
+// Input locates the source: Path is a SQLite database or backup file, DSN a
+// PostgreSQL connection that wins over Path, XrayConfig Marzban's inbounds.
+type Input struct {
+	Path       string
+	DSN        string
+	XrayConfig string
+}
+
+// Read loads the bundle of the named source.
+func Read(source string, in Input) (*Bundle, error) {
+	switch source {
+	case SourceHiddify:
+		if in.Path == "" {
+			return nil, fmt.Errorf("%s: a backup file is required", source)
+		}
+		data, err := os.ReadFile(in.Path)
+		if err != nil {
+			return nil, err
+		}
+		return readHiddify(data)
+	case SourceMarzban, SourceRemnawave, SourceXUI:
+	default:
+		return nil, fmt.Errorf("unknown source %q (want one of %s)", source, strings.Join(Sources, ", "))
+	}
+
+	db, err := openSource(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	switch source {
+	case SourceMarzban:
+		var xrayConfig []byte
+		if in.XrayConfig != "" {
+			if xrayConfig, err = os.ReadFile(in.XrayConfig); err != nil {
+				return nil, err
+			}
+		}
+		return readMarzban(db, xrayConfig)
+	case SourceRemnawave:
+		return readRemnawave(db)
+	default:
+		return readXUI(db)
+	}
+}
+
+func openSource(in Input) (*gorm.DB, error) {
+	cfg := &gorm.Config{Logger: logger.Discard}
+	if in.DSN != "" {
+		return gorm.Open(postgres.Open(in.DSN), cfg)
+	}
+	if in.Path == "" {
+		return nil, fmt.Errorf("a database file or DSN is required")
+	}
+	if _, err := os.Stat(in.Path); err != nil {
+		return nil, err
+	}
+	return gorm.Open(sqlite.Open("file:"+in.Path+"?mode=ro"), cfg)
+}
+
+// rows reads a whole table as column maps, which keeps the readers working
+// across the schema versions of each source.
+func rows(db *gorm.DB, table string) ([]map[string]any, error) {
+	var out []map[string]any
+	if err := db.Table(table).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("read %s: %w", table, err)
+	}
+	return out, nil
+}
+
+func str(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+func num(v any) int64 {
+	switch t := v.(type) {
+	case int64:
+		return t
+	case int32:
+		return int64(t)
+	case int:
+		return int64(t)
+	case float64:
+		return int64(t)
+	case float32:
+		return int64(t)
+	case bool:
+		if t {
+			return 1
+		}
+		return 0
+	case string, []byte:
+		s := strings.TrimSpace(str(t))
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return int64(f)
+		}
+	}
+	return 0
+}
+
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case string, []byte:
+		s := strings.ToLower(str(t))
+		return s == "1" || s == "true" || s == "t"
+	}
+	return num(v) != 0
+}
+
+// timeMs converts a timestamp column to Unix milliseconds, 0 when unset.
+func timeMs(v any) int64 {
+	switch t := v.(type) {
+	case nil:
+		return 0
+	case time.Time:
+		if t.IsZero() {
+			return 0
+		}
+		return t.UnixMilli()
+	}
+	s := str(v)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UnixMilli()
+		}
+	}
+	return 0
+}
+
+// clientName makes name usable as a client email: whitespace and slashes are
+// not allowed there.
+func clientName(name string) string {
+	name = strings.TrimSpace(name)
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 || r == 0x7f || unicode.IsSpace(r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// xrayInbound reads one inbound of an Xray config. One not listening on a port
+// (a fallback on a unix socket, say) comes back without one.
+func xrayInbound(raw map[string]any) (Inbound, bool) {
+	tag := str(raw["tag"])
+	protocol := model.Protocol(strings.ToLower(str(raw["protocol"])))
+	if tag == "" || protocol == "" {
+		return Inbound{}, false
+	}
+	ib := Inbound{Tag: tag, Remark: tag, Protocol: protocol, Listen: str(raw["listen"]), Enable: true}
+	if port, err := strconv.Atoi(str(raw["port"])); err == nil && port > 0 && port <= 65535 {
+		ib.Port = port
+	}
+	if strings.HasPrefix(ib.Listen, "@") || strings.HasPrefix(ib.Listen, "/") {
+		ib.Port, ib.Listen = 0, ""
+	}
+	ib.Settings = withoutClients(raw["settings"])
+	ib.StreamSettings = jsonText(raw["streamSettings"])
+	ib.Sniffing = jsonText(raw["sniffing"])
+	return ib, true
+}
+
+// withoutClients renders inbound settings with an empty clients list.
+func withoutClients(v any) string {
+	m, ok := v.(map[string]any)
+	if !ok {
+		if s := str(v); s != "" && json.Unmarshal([]byte(s), &m) != nil {
+			return s
+		}
+	}
+	if m == nil {
+		m = map[string]any{}
+	}
+	if _, ok := m["clients"]; ok {
+		m["clients"] = []any{}
+	}
+	return jsonText(m)
+}
+
+func jsonText(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// syntheticInbounds returns one portless inbound per protocol, for sources
+// that hand out credentials without keeping inbound definitions.
+func syntheticInbounds(protocols []model.Protocol) []Inbound {
+	out := make([]Inbound, 0, len(protocols))
+	for _, p := range protocols {
+		out = append(out, Inbound{Tag: string(p), Remark: string(p), Protocol: p, Enable: true})
+	}
+	return out
+}
+
+func addTag(tags []string, tag string) []string {
+	if slices.Contains(tags, tag) {
+		return tags
+	}
+	return append(tags, tag)
+}
@@ -0,0 +1,244 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+// sourceDB writes a SQLite database built by stmts and returns its path.
+func sourceDB(t *testing.T, stmts ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "source.db")
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+	return path
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func clientByEmail(t *testing.T, b *Bundle, email string) Client {
+	t.Helper()
+	i := slices.IndexFunc(b.Clients, func(c Client) bool { return c.Email == email })
+	if i < 0 {
+		t.Fatalf("no client %s in %+v", email, b.Clients)
+	}
+	return b.Clients[i]
+}
+
+func TestReadMarzban(t *testing.T) {
+	path := sourceDB(t,
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, username TEXT, status TEXT, used_traffic BIGINT,
+			data_limit BIGINT, data_limit_reset_strategy TEXT, expire INTEGER, note TEXT,
+			on_hold_expire_duration BIGINT)`,
+		`CREATE TABLE proxies (id INTEGER PRIMARY KEY, user_id INTEGER, type TEXT, settings TEXT)`,
+		`CREATE TABLE exclude_inbounds_association (proxy_id INTEGER, inbound_tag TEXT)`,
+		`INSERT INTO users VALUES (1, 'alice', 'active', 500, 1000, 'month', 1700000000, 'vip', NULL)`,
+		`INSERT INTO users VALUES (2, 'bob', 'on_hold', 0, 0, 'year', NULL, '', 86400)`,
+		`INSERT INTO users VALUES (3, 'carol', 'disabled', 0, 0, 'no_reset', NULL, '', NULL)`,
+		`INSERT INTO proxies VALUES (1, 1, 'vless', '{"id":"11111111-1111-1111-1111-111111111111","flow":"xtls-rprx-vision"}')`,
+		`INSERT INTO proxies VALUES (2, 1, 'trojan', '{"password":"alice-pass"}')`,
+		`INSERT INTO proxies VALUES (3, 2, 'vless', '{"id":"22222222-2222-2222-2222-222222222222"}')`,
+		`INSERT INTO proxies VALUES (4, 3, 'vless', '{"id":"33333333-3333-3333-3333-333333333333"}')`,
+		`INSERT INTO exclude_inbounds_association VALUES (3, 'VLESS WS')`,
+	)
+	xrayConfig := writeFile(t, "xray_config.json", `{"inbounds":[
+		{"tag":"VLESS TCP","protocol":"vless","port":443,"settings":{"clients":[],"decryption":"none"},
+			"streamSettings":{"network":"tcp"}},
+		{"tag":"VLESS WS","protocol":"vless","port":8443,"settings":{"clients":[]}},
+		{"tag":"TROJAN","protocol":"trojan","port":2083,"settings":{"clients":[]}},
+		{"tag":"FALLBACK","protocol":"vless","listen":"@vless-ws","settings":{"clients":[]}}
+	]}`)
+
+	b, err := Read(SourceMarzban, Input{Path: path, XrayConfig: xrayConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Inbounds) != 4 || b.Inbounds[0].Port != 443 || b.Inbounds[3].Port != 0 {
+		t.Fatalf("inbounds = %+v", b.Inbounds)
+	}
+	if b.Inbounds[0].Settings != `{"clients":[],"decryption":"none"}` {
+		t.Fatalf("settings = %s", b.Inbounds[0].Settings)
+	}
+
+	alice := clientByEmail(t, b, "alice")
+	if alice.ID != "11111111-1111-1111-1111-111111111111" || alice.Password != "alice-pass" || alice.Flow != "xtls-rprx-vision" {
+		t.Fatalf("alice credentials = %+v", alice.Client)
+	}
+	if alice.SubID != "alice" || alice.TotalGB != 1000 || alice.Down != 500 || alice.ExpiryTime != 1700000000000 || alice.TrafficReset != "monthly" || !alice.Enable {
+		t.Fatalf("alice = %+v", alice)
+	}
+	if !slices.Equal(alice.Inbounds, []string{"VLESS TCP", "VLESS WS", "FALLBACK", "TROJAN"}) {
+		t.Fatalf("alice inbounds = %v", alice.Inbounds)
+	}
+	bob := clientByEmail(t, b, "bob")
+	if bob.ExpiryTime != -86400000 || slices.Contains(bob.Inbounds, "VLESS WS") {
+		t.Fatalf("bob = %+v", bob)
+	}
+	if clientByEmail(t, b, "carol").Enable {
+		t.Fatal("disabled user imported enabled")
+	}
+	if len(b.Warnings) < 2 {
+		t.Fatalf("warnings = %v, want the yearly reset and subscription notes", b.Warnings)
+	}
+}
+
+func TestReadMarzbanWithoutXrayConfig(t *testing.T) {
+	path := sourceDB(t,
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, username TEXT, status TEXT)`,
+		`CREATE TABLE proxies (id INTEGER PRIMARY KEY, user_id INTEGER, type TEXT, settings TEXT)`,
+		`INSERT INTO users VALUES (1, 'alice', 'active')`,
+		`INSERT INTO proxies VALUES (1, 1, 'vmess', '{"id":"11111111-1111-1111-1111-111111111111"}')`,
+	)
+	b, err := Read(SourceMarzban, Input{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Inbounds) != 1 || b.Inbounds[0].Tag != "vmess" || b.Inbounds[0].Port != 0 {
+		t.Fatalf("inbounds = %+v", b.Inbounds)
+	}
+	if got := clientByEmail(t, b, "alice").Inbounds; !slices.Equal(got, []string{"vmess"}) {
+		t.Fatalf("inbounds = %v", got)
+	}
+}
+
+func TestReadXUI(t *testing.T) {
+	path := sourceDB(t,
+		`CREATE TABLE inbounds (id INTEGER PRIMARY KEY, remark TEXT, enable INTEGER, protocol TEXT, listen TEXT,
+			port INTEGER, tag TEXT, settings TEXT, stream_settings TEXT, sniffing TEXT, total BIGINT,
+			expiry_time BIGINT)`,
+		`CREATE TABLE client_traffics (id INTEGER PRIMARY KEY, email TEXT, up BIGINT, down BIGINT)`,
+		`INSERT INTO inbounds VALUES (1, 'main', 1, 'vless', '', 443, 'inbound-443',
+			'{"clients":[{"id":"11111111-1111-1111-1111-111111111111","email":"Alice","subId":"s1","totalGB":5,"expiryTime":99}],"decryption":"none"}',
+			'{"network":"tcp"}', '{}', 0, 0)`,
+		`INSERT INTO inbounds VALUES (2, 'old', 0, 'trojan', '', 2083, '',
+			'{"clients":[{"password":"p","email":"alice"},{"password":"nomail"}]}', '{}', '', 0, 0)`,
+		`INSERT INTO client_traffics VALUES (1, 'alice', 10, 20)`,
+	)
+	b, err := Read(SourceXUI, Input{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Inbounds) != 2 || b.Inbounds[1].Tag != "inbound-2083" || b.Inbounds[1].Enable {
+		t.Fatalf("inbounds = %+v", b.Inbounds)
+	}
+	if b.Inbounds[0].Settings != `{"clients":[],"decryption":"none"}` {
+		t.Fatalf("settings = %s", b.Inbounds[0].Settings)
+	}
+	if len(b.Clients) != 2 {
+		t.Fatalf("clients = %+v", b.Clients)
+	}
+	alice := clientByEmail(t, b, "Alice")
+	if alice.SubID != "s1" || alice.TotalGB != 5 || alice.ExpiryTime != 99 || alice.Up != 10 || alice.Down != 20 {
+		t.Fatalf("alice = %+v", alice)
+	}
+	if !slices.Equal(alice.Inbounds, []string{"inbound-443", "inbound-2083"}) {
+		t.Fatalf("alice inbounds = %v", alice.Inbounds)
+	}
+	clientByEmail(t, b, "inbound-2083-nomail")
+}
+
+func TestReadRemnawave(t *testing.T) {
+	path := sourceDB(t,
+		`CREATE TABLE users (uuid TEXT, t_id INTEGER, username TEXT, short_uuid TEXT, status TEXT,
+			traffic_limit_bytes BIGINT, traffic_limit_strategy TEXT, expire_at TEXT, telegram_id BIGINT,
+			description TEXT, hwid_device_limit INTEGER, vless_uuid TEXT, trojan_password TEXT, ss_password TEXT)`,
+		`CREATE TABLE user_traffic (t_id INTEGER, used_traffic_bytes BIGINT)`,
+		`CREATE TABLE config_profile_inbounds (uuid TEXT, tag TEXT, raw_inbound TEXT)`,
+		`CREATE TABLE internal_squad_inbounds (internal_squad_uuid TEXT, inbound_uuid TEXT)`,
+		`CREATE TABLE internal_squad_members (internal_squad_uuid TEXT, user_uuid TEXT)`,
+		`INSERT INTO config_profile_inbounds VALUES ('i1', 'VLESS', '{"tag":"VLESS","protocol":"vless","port":443,"settings":{"clients":[]}}')`,
+		`INSERT INTO config_profile_inbounds VALUES ('i2', 'SS', '{"tag":"SS","protocol":"shadowsocks","port":8388,"settings":{"method":"chacha20-ietf-poly1305"}}')`,
+		`INSERT INTO internal_squad_inbounds VALUES ('sq1', 'i1'), ('sq2', 'i2')`,
+		`INSERT INTO internal_squad_members VALUES ('sq1', 'u1'), ('sq2', 'u2')`,
+		`INSERT INTO users VALUES ('u1', 1, 'alice', 'short1', 'ACTIVE', 1000, 'MONTH', '2030-01-01T00:00:00Z', 42,
+			'note', 3, '11111111-1111-1111-1111-111111111111', 'tpass', 'sspass')`,
+		`INSERT INTO users VALUES ('u2', 2, 'bob', 'short2', 'DISABLED', 0, 'NO_RESET', NULL, NULL,
+			'', 0, '22222222-2222-2222-2222-222222222222', 'tpass2', 'sspass2')`,
+		`INSERT INTO user_traffic VALUES (1, 700)`,
+	)
+	b, err := Read(SourceRemnawave, Input{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Inbounds) != 2 {
+		t.Fatalf("inbounds = %+v", b.Inbounds)
+	}
+	alice := clientByEmail(t, b, "alice")
+	if alice.ID != "11111111-1111-1111-1111-111111111111" || alice.Password != "tpass" || alice.SubID != "short1" {
+		t.Fatalf("alice credentials = %+v", alice.Client)
+	}
+	if alice.Down != 700 || alice.TotalGB != 1000 || alice.TrafficReset != "monthly" || alice.TgID != 42 || alice.LimitHwid != 3 {
+		t.Fatalf("alice = %+v", alice)
+	}
+	if alice.ExpiryTime != 1893456000000 || !slices.Equal(alice.Inbounds, []string{"VLESS"}) {
+		t.Fatalf("alice expiry %d, inbounds %v", alice.ExpiryTime, alice.Inbounds)
+	}
+	bob := clientByEmail(t, b, "bob")
+	if bob.Enable || bob.Password != "sspass2" || !slices.Equal(bob.Inbounds, []string{"SS"}) {
+		t.Fatalf("bob = %+v", bob)
+	}
+}
+
+func TestReadHiddify(t *testing.T) {
+	path := writeFile(t, "backup.json", `{"users":[
+		{"uuid":"11111111-1111-1111-1111-111111111111","name":"alice smith","usage_limit_GB":2,
+			"current_usage_GB":0.5,"package_days":30,"start_date":"2024-01-01","mode":"monthly","telegram_id":"42"},
+		{"uuid":"22222222-2222-2222-2222-222222222222","name":"alice smith","enable":false,"package_days":10},
+		{"uuid":"","name":"ghost"}
+	]}`)
+	b, err := Read(SourceHiddify, Input{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Inbounds) != 3 || len(b.Clients) != 2 {
+		t.Fatalf("bundle = %+v", b)
+	}
+	alice := clientByEmail(t, b, "alice_smith")
+	if alice.ID != alice.SubID || alice.Password != alice.ID || alice.TotalGB != 2*gib || alice.Down != gib/2 || alice.TgID != 42 {
+		t.Fatalf("alice = %+v", alice)
+	}
+	if alice.ExpiryTime != 1704067200000+30*24*60*60*1000 || alice.TrafficReset != "monthly" {
+		t.Fatalf("alice expiry %d reset %q", alice.ExpiryTime, alice.TrafficReset)
+	}
+	second := clientByEmail(t, b, "alice_smith-22222222")
+	if second.Enable || second.ExpiryTime != -10*24*60*60*1000 {
+		t.Fatalf("second = %+v", second)
+	}
+	if !slices.Equal(second.Inbounds, []string{string(model.VLESS), string(model.VMESS), string(model.Trojan)}) {
+		t.Fatalf("inbounds = %v", second.Inbounds)
+	}
+}
+
+func TestReadRejectsUnknownSource(t *testing.T) {
+	if _, err := Read("3x-ui", Input{Path: "x"}); err == nil {
+		t.Fatal("accepted an unknown source")
+	}
+	if _, err := Read(SourceXUI, Input{}); err == nil {
+		t.Fatal("accepted no input")
+	}
+}
@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+// marzbanProtocols maps Marzban's proxy types onto protocols here.
+var marzbanProtocols = map[string]model.Protocol{
+	"vmess":       model.VMESS,
+	"vless":       model.VLESS,
+	"trojan":      model.Trojan,
+	"shadowsocks": model.Shadowsocks,
+}
+
+// marzbanResets maps data_limit_reset_strategy onto client traffic reset
+// cycles. Yearly resets have no counterpart.
+var marzbanResets = map[string]string{
+	"no_reset": "never",
+	"day":      "daily",
+	"week":     "weekly",
+	"month":    "monthly",
+}
+
+// readMarzban reads a Marzban database; without xray_config.json every proxy
+// type is a portless inbound. The username becomes the subId, so old links break.
+func readMarzban(db *gorm.DB, xrayConfig []byte) (*Bundle, error) {
+	b := &Bundle{Source: SourceMarzban}
+	users, err := rows(db, "users")
+	if err != nil {
+		return nil, err
+	}
+	proxies, err := rows(db, "proxies")
+	if err != nil {
+		return nil, err
+	}
+	excluded := map[int64][]string{}
+	if db.Migrator().HasTable("exclude_inbounds_association") {
+		assoc, err := rows(db, "exclude_inbounds_association")
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range assoc {
+			excluded[num(a["proxy_id"])] = append(excluded[num(a["proxy_id"])], str(a["inbound_tag"]))
+		}
+	}
+
+	var protocols []model.Protocol
+	if len(xrayConfig) > 0 {
+		var cfg struct {
+			Inbounds []map[string]any `json:"inbounds"`
+		}
+		if err := json.Unmarshal(xrayConfig, &cfg); err != nil {
+			return nil, fmt.Errorf("xray config: %w", err)
+		}
+		for _, raw := range cfg.Inbounds {
+			ib, ok := xrayInbound(raw)
+			if !ok {
+				continue
+			}
+			if ib.Port == 0 {
+				b.warnf("inbound %s has no port of its own; map it onto an inbound here", ib.Tag)
+			}
+			b.Inbounds = append(b.Inbounds, ib)
+		}
+	}
+
+	byUser := map[int64][]map[string]any{}
+	for _, p := range proxies {
+		byUser[num(p["user_id"])] = append(byUser[num(p["user_id"])], p)
+		if protocol, ok := marzbanProtocols[strings.ToLower(str(p["type"]))]; ok && !slices.Contains(protocols, protocol) {
+			protocols = append(protocols, protocol)
+		}
+	}
+	if len(xrayConfig) == 0 {
+		b.Inbounds = syntheticInbounds(protocols)
+		b.warnf("no xray_config.json given; map each protocol onto an inbound here")
+	}
+
+	yearly := 0
+	for _, u := range users {
+		username := str(u["username"])
+		c := Client{Client: model.Client{
+			Email:   username,
+			SubID:   username,
+			Enable:  str(u["status"]) != "disabled",
+			TotalGB: num(u["data_limit"]),
+			Comment: str(u["note"]),
+		}}
+		c.Down = num(u["used_traffic"])
+		if expire := num(u["expire"]); expire > 0 {
+			c.ExpiryTime = expire * 1000
+		} else if str(u["status"]) == "on_hold" {
+			// Counted from first use, which a negative expiry means here.
+			c.ExpiryTime = -num(u["on_hold_expire_duration"]) * 1000
+		}
+		switch strategy := str(u["data_limit_reset_strategy"]); {
+		case marzbanResets[strategy] != "":
+			c.TrafficReset = marzbanResets[strategy]
+		case strategy == "year":
+			yearly++
+		}
+
+		for _, p := range byUser[num(u["id"])] {
+			protocol, ok := marzbanProtocols[strings.ToLower(str(p["type"]))]
+			if !ok {
+				continue
+			}
+			var settings struct {
+				Id       string `json:"id"`
+				Password string `json:"password"`
+				Flow     string `json:"flow"`
+			}
+			_ = json.Unmarshal([]byte(str(p["settings"])), &settings)
+			switch protocol {
+			case model.VMESS, model.VLESS:
+				c.ID = settings.Id
+			default:
+				if c.Password == "" || protocol == model.Trojan {
+					c.Password = settings.Password
+				}
+			}
+			if protocol == model.VLESS && settings.Flow != "" {
+				c.Flow = settings.Flow
+			}
+			skip := excluded[num(p["id"])]
+			for _, ib := range b.Inbounds {
+				if ib.Protocol == protocol && !slices.Contains(skip, ib.Tag) {
+					c.Inbounds = addTag(c.Inbounds, ib.Tag)
+				}
+			}
+		}
+		b.Clients = append(b.Clients, c)
+	}
+	if yearly > 0 {
+		b.warnf("%d user(s) reset traffic yearly, which has no counterpart here; they import without a reset cycle", yearly)
+	}
+	b.warnf("Marzban subscription links are signed tokens; imported clients use their username as subId")
+	return b, nil
+}
@@ -0,0 +1,156 @@
+package importer
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+// remnawaveResets maps traffic_limit_strategy onto client traffic reset cycles.
+var remnawaveResets = map[string]string{
+	"NO_RESET": "never",
+	"DAY":      "daily",
+	"WEEK":     "weekly",
+	"MONTH":    "monthly",
+}
+
+// readRemnawave reads a Remnawave database. Users reach profile inbounds through
+// internal squads when present; traffic is read from users and user_traffic.
+func readRemnawave(db *gorm.DB) (*Bundle, error) {
+	b := &Bundle{Source: SourceRemnawave}
+	users, err := rows(db, "users")
+	if err != nil {
+		return nil, err
+	}
+
+	tagByUUID := map[string]string{}
+	if db.Migrator().HasTable("config_profile_inbounds") {
+		inbounds, err := rows(db, "config_profile_inbounds")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range inbounds {
+			var raw map[string]any
+			if err := json.Unmarshal([]byte(str(row["raw_inbound"])), &raw); err != nil {
+				b.warnf("inbound %s: unreadable definition: %v", str(row["tag"]), err)
+				continue
+			}
+			ib, ok := xrayInbound(raw)
+			if !ok {
+				continue
+			}
+			tagByUUID[str(row["uuid"])] = ib.Tag
+			if hasInbound(b, ib.Tag) {
+				b.warnf("inbound %s appears in several config profiles; the first is imported", ib.Tag)
+				continue
+			}
+			b.Inbounds = append(b.Inbounds, ib)
+		}
+	}
+
+	// squad uuid -> inbound tags, user uuid -> squad uuids
+	var squadTags, userSquads map[string][]string
+	if db.Migrator().HasTable("internal_squad_inbounds") && db.Migrator().HasTable("internal_squad_members") {
+		squadTags, userSquads = map[string][]string{}, map[string][]string{}
+		links, err := rows(db, "internal_squad_inbounds")
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range links {
+			squad := str(l["internal_squad_uuid"])
+			if tag, ok := tagByUUID[str(l["inbound_uuid"])]; ok {
+				squadTags[squad] = addTag(squadTags[squad], tag)
+			}
+		}
+		members, err := rows(db, "internal_squad_members")
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			user := str(m["user_uuid"])
+			userSquads[user] = append(userSquads[user], str(m["internal_squad_uuid"]))
+		}
+	} else if len(b.Inbounds) > 0 {
+		b.warnf("no internal squads found; every user is attached to every inbound")
+	}
+
+	traffic := map[int64]int64{}
+	if !db.Migrator().HasColumn("users", "used_traffic_bytes") && db.Migrator().HasTable("user_traffic") {
+		usage, err := rows(db, "user_traffic")
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range usage {
+			traffic[num(t["t_id"])] = num(t["used_traffic_bytes"])
+		}
+	}
+
+	sharedPassword := false
+	for _, u := range users {
+		c := Client{Client: model.Client{
+			ID:           str(u["vless_uuid"]),
+			Password:     str(u["trojan_password"]),
+			Email:        clientName(str(u["username"])),
+			SubID:        str(u["short_uuid"]),
+			Enable:       str(u["status"]) != "DISABLED",
+			TotalGB:      num(u["traffic_limit_bytes"]),
+			ExpiryTime:   timeMs(u["expire_at"]),
+			TgID:         num(u["telegram_id"]),
+			Comment:      str(u["description"]),
+			TrafficReset: remnawaveResets[str(u["traffic_limit_strategy"])],
+		}}
+		c.LimitHwid = int(num(u["hwid_device_limit"]))
+		if _, ok := u["used_traffic_bytes"]; ok {
+			c.Down = num(u["used_traffic_bytes"])
+		} else {
+			c.Down = traffic[num(u["t_id"])]
+		}
+
+		if userSquads != nil {
+			for _, squad := range userSquads[str(u["uuid"])] {
+				for _, tag := range squadTags[squad] {
+					c.Inbounds = addTag(c.Inbounds, tag)
+				}
+			}
+		} else {
+			for _, ib := range b.Inbounds {
+				c.Inbounds = append(c.Inbounds, ib.Tag)
+			}
+		}
+		// One password serves Trojan and Shadowsocks here; Trojan's wins.
+		onTrojan, onShadowsocks := false, false
+		for _, tag := range c.Inbounds {
+			switch protocolOf(b, tag) {
+			case model.Trojan:
+				onTrojan = true
+			case model.Shadowsocks:
+				onShadowsocks = true
+			}
+		}
+		if onShadowsocks && !onTrojan {
+			c.Password = str(u["ss_password"])
+		} else if onShadowsocks {
+			sharedPassword = true
+		}
+		b.Clients = append(b.Clients, c)
+	}
+	if sharedPassword {
+		b.warnf("users on both Trojan and Shadowsocks inbounds keep their Trojan password for both")
+	}
+	return b, nil
+}
+
+func hasInbound(b *Bundle, tag string) bool {
+	return protocolOf(b, tag) != ""
+}
+
+func protocolOf(b *Bundle, tag string) model.Protocol {
+	for _, ib := range b.Inbounds {
+		if ib.Tag == tag {
+			return ib.Protocol
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package importer
+
+import (
+	"encoding/json"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+// readXUI reads x-ui databases from before clients got their own table; a client
+// listed on several inbounds is imported once, attached to all of them.
+func readXUI(db *gorm.DB) (*Bundle, error) {
+	b := &Bundle{Source: SourceXUI}
+	inbounds, err := rows(db, "inbounds")
+	if err != nil {
+		return nil, err
+	}
+	type usage struct{ up, down int64 }
+	traffic := map[string]usage{}
+	if db.Migrator().HasTable("client_traffics") {
+		stats, err := rows(db, "client_traffics")
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range stats {
+			traffic[strings.ToLower(str(s["email"]))] = usage{num(s["up"]), num(s["down"])}
+		}
+	}
+
+	byEmail := map[string]int{}
+	unnamed := 0
+	for _, row := range inbounds {
+		tag := str(row["tag"])
+		if tag == "" {
+			tag = "inbound-" + str(row["port"])
+		}
+		ib := Inbound{
+			Tag:            tag,
+			Remark:         str(row["remark"]),
+			Protocol:       model.Protocol(str(row["protocol"])),
+			Listen:         str(row["listen"]),
+			Port:           int(num(row["port"])),
+			Settings:       withoutClients(str(row["settings"])),
+			StreamSettings: str(row["stream_settings"]),
+			Sniffing:       str(row["sniffing"]),
+			Enable:         truthy(row["enable"]),
+			Total:          num(row["total"]),
+			ExpiryTime:     num(row["expiry_time"]),
+		}
+		b.Inbounds = append(b.Inbounds, ib)
+
+		var settings struct {
+			Clients []model.Client `json:"clients"`
+		}
+		_ = json.Unmarshal([]byte(str(row["settings"])), &settings)
+		for _, client := range settings.Clients {
+			if client.Email == "" {
+				// Early x-ui releases had no emails; name the client after
+				// its credentials.
+				id := client.ID + client.Password
+				client.Email = tag + "-" + id[:min(8, len(id))]
+				unnamed++
+			}
+			key := strings.ToLower(client.Email)
+			if i, ok := byEmail[key]; ok {
+				b.Clients[i].Inbounds = addTag(b.Clients[i].Inbounds, tag)
+				continue
+			}
+			client.CreatedAt, client.UpdatedAt = 0, 0
+			u := traffic[key]
+			byEmail[key] = len(b.Clients)
+			b.Clients = append(b.Clients, Client{Client: client, Up: u.up, Down: u.down, Inbounds: []string{tag}})
+		}
+	}
+	if unnamed > 0 {
+		b.warnf("%d client(s) had no email and were named after their inbound and id", unnamed)
+	}
+	return b, nil
+}
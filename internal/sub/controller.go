@@ -19,6 +19,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
 )
@@ -61,6 +62,10 @@ type SUBController struct {
 	subClashPath       string
 	subClashAutoDetect bool
 	clashUserAgent     *regexp.Regexp
+	subSingboxPath     string
+	singboxAutoDetect  bool
+	singboxUserAgent   *regexp.Regexp
+	singboxEnabled     bool
 	jsonAutoDetect     bool
 	jsonUserAgent      *regexp.Regexp
 	jsonAlwaysArray    bool
@@ -69,20 +74,26 @@ type SUBController struct {
 	subEncrypt         bool
 	updateInterval     string
 
-	subService      *SubService
-	subJsonService  *SubJsonService
-	subClashService *SubClashService
-	clientService   service.ClientService
-	settingService  service.SettingService
+	subService        *SubService
+	subJsonService    *SubJsonService
+	subClashService   *SubClashService
+	subSingboxService *SubSingboxService
+	clientService     service.ClientService
+	settingService    service.SettingService
+	historyService    service.TrafficHistoryService
+	voucherService    service.VoucherService
+	inboundService    service.InboundService
+	xrayService       service.XrayService
 
 	subTemplateMu    sync.RWMutex
 	subTemplateCache map[string]*cachedSubTemplate
 }
 
 type subControllerConfig struct {
-	subPath      string
-	subJsonPath  string
-	subClashPath string
+	subPath        string
+	subJsonPath    string
+	subClashPath   string
+	subSingboxPath string
 
 	subClashAutoDetect     bool
 	subClashUserAgentRegex string
@@ -92,6 +103,12 @@ type subControllerConfig struct {
 	subJsonEnabled         bool
 	subClashEnabled        bool
 
+	subSingboxAutoDetect     bool
+	subSingboxUserAgentRegex string
+	subSingboxEnabled        bool
+	subSingboxMux            string
+	subSingboxRules          string
+
 	subEncrypt     bool
 	remarkTemplate string
 	updateInterval string
@@ -136,6 +153,30 @@ func WithSUBClashUserAgentRegex(value string) SUBControllerOption {
 	return func(config *subControllerConfig) { config.subClashUserAgentRegex = value }
 }
 
+func WithSUBSingboxPath(value string) SUBControllerOption {
+	return func(config *subControllerConfig) { config.subSingboxPath = value }
+}
+
+func WithSUBSingboxAutoDetect(value bool) SUBControllerOption {
+	return func(config *subControllerConfig) { config.subSingboxAutoDetect = value }
+}
+
+func WithSUBSingboxUserAgentRegex(value string) SUBControllerOption {
+	return func(config *subControllerConfig) { config.subSingboxUserAgentRegex = value }
+}
+
+func WithSUBSingboxEnabled(value bool) SUBControllerOption {
+	return func(config *subControllerConfig) { config.subSingboxEnabled = value }
+}
+
+func WithSUBSingboxMux(value string) SUBControllerOption {
+	return func(config *subControllerConfig) { config.subSingboxMux = value }
+}
+
+func WithSUBSingboxRules(value string) SUBControllerOption {
+	return func(config *subControllerConfig) { config.subSingboxRules = value }
+}
+
 func WithSUBJsonAutoDetect(value bool) SUBControllerOption {
 	return func(config *subControllerConfig) { config.subJsonAutoDetect = value }
 }
@@ -229,6 +270,7 @@ func defaultSUBControllerConfig() subControllerConfig {
 		subPath:        "/sub/",
 		subJsonPath:    "/json/",
 		subClashPath:   "/clash/",
+		subSingboxPath: "/singbox/",
 		subEncrypt:     true,
 		remarkTemplate: service.DefaultRemarkTemplate,
 		updateInterval: "12",
@@ -260,6 +302,10 @@ func NewSUBController(g *gin.RouterGroup, options ...SUBControllerOption) *SUBCo
 		subClashPath:       config.subClashPath,
 		subClashAutoDetect: config.subClashAutoDetect,
 		clashUserAgent:     compileUserAgentRegex("Clash/Mihomo", config.subClashUserAgentRegex, service.DefaultSubClashUserAgentRegex),
+		subSingboxPath:     config.subSingboxPath,
+		singboxAutoDetect:  config.subSingboxAutoDetect,
+		singboxUserAgent:   compileUserAgentRegex("sing-box", config.subSingboxUserAgentRegex, service.DefaultSubSingboxUserAgentRegex),
+		singboxEnabled:     config.subSingboxEnabled,
 		jsonAutoDetect:     config.subJsonAutoDetect,
 		jsonUserAgent:      compileUserAgentRegex("Xray JSON", config.subJsonUserAgentRegex, service.DefaultSubJsonUserAgentRegex),
 		jsonAlwaysArray:    config.subJsonAlwaysArray,
@@ -268,9 +314,10 @@ func NewSUBController(g *gin.RouterGroup, options ...SUBControllerOption) *SUBCo
 		subEncrypt:         config.subEncrypt,
 		updateInterval:     config.updateInterval,
 
-		subService:      sub,
-		subJsonService:  NewSubJsonService(config.subJsonMux, config.subJsonRules, config.subJsonFinalMask, sub),
-		subClashService: NewSubClashService(config.subClashEnableRouting, config.subClashRules, sub),
+		subService:        sub,
+		subJsonService:    NewSubJsonService(config.subJsonMux, config.subJsonRules, config.subJsonFinalMask, sub),
+		subClashService:   NewSubClashService(config.subClashEnableRouting, config.subClashRules, sub),
+		subSingboxService: NewSubSingboxService(config.subSingboxMux, config.subSingboxRules, sub),
 
 		subTemplateCache: map[string]*cachedSubTemplate{},
 	}
@@ -284,6 +331,8 @@ func (a *SUBController) initRouter(g *gin.RouterGroup) {
 	gLink := g.Group(a.subPath)
 	gLink.GET(":subid", a.subs)
 	gLink.HEAD(":subid", a.subs)
+	gLink.GET("redeem", a.redeemPage)
+	gLink.POST("redeem", a.redeem)
 	if a.jsonEnabled {
 		gJson := g.Group(a.subJsonPath)
 		gJson.GET(":subid", a.subJsons)
@@ -294,6 +343,11 @@ func (a *SUBController) initRouter(g *gin.RouterGroup) {
 		gClash.GET(":subid", a.subClashs)
 		gClash.HEAD(":subid", a.subClashs)
 	}
+	if a.singboxEnabled {
+		gSingbox := g.Group(a.subSingboxPath)
+		gSingbox.GET(":subid", a.subSingboxs)
+		gSingbox.HEAD(":subid", a.subSingboxs)
+	}
 }
 
 // maybeServeSubPage renders the HTML info page when the request comes from a
@@ -357,6 +411,9 @@ func (a *SUBController) buildSubPageData(c *gin.Context) (PageData, bool) {
 	metadata := a.metadataForSubRequest(func() *SubService { return subReq }, subId, "")
 	page := subReq.BuildPageData(subId, hostHeader, traffic, lastOnline, subs, emails, subURL, subJsonURL, subClashURL, basePathStr, metadata.Title, metadata.SupportURL)
 	page.SubAnnounce = metadata.Announce
+	if a.singboxEnabled {
+		page.SubSingboxUrl = subReq.BuildSingboxURL(a.subSingboxPath, subId)
+	}
 	return page, true
 }
 
@@ -395,6 +452,11 @@ func (a *SUBController) subs(c *gin.Context) {
 		logSubscriptionRoute(userAgent, "clash")
 		return
 	}
+	if shouldAutoServeFormat(a.singboxAutoDetect, a.singboxEnabled, false, userAgent, a.singboxUserAgent) && a.serveSingboxBody(c, false) {
+		a.recordSubscriptionFetch(c)
+		logSubscriptionRoute(userAgent, "singbox")
+		return
+	}
 	if shouldAutoServeJson(a.jsonAutoDetect, a.jsonEnabled, false, userAgent, a.jsonUserAgent) && a.serveJsonBody(c, true, "application/json; charset=utf-8", false) {
 		a.recordSubscriptionFetch(c)
 		logSubscriptionRoute(userAgent, "json")
@@ -505,27 +567,6 @@ func compileUserAgentRegex(name, pattern, defaultPattern string) *regexp.Regexp
 // page's static asset references resolve correctly when the panel runs
 // behind a URL prefix.
 func (a *SUBController) serveSubPage(c *gin.Context, basePath string, page PageData) {
-	var body []byte
-	if diskBody, diskErr := os.ReadFile("internal/web/dist/subpage.html"); diskErr == nil {
-		body = diskBody
-	} else {
-		readBody, err := fs.ReadFile(distFS, "dist/subpage.html")
-		if err != nil {
-			c.String(http.StatusInternalServerError, "missing embedded subpage")
-			return
-		}
-		body = readBody
-	}
-
-	// Vite emits absolute asset URLs (`/assets/...`); when the panel is
-	// installed under a custom URL prefix, rewrite them so the bundle
-	// loads from `<basePath>assets/...` where the static handler is
-	// actually mounted.
-	if basePath != "/" && basePath != "" {
-		body = bytes.ReplaceAll(body, []byte(`src="/assets/`), []byte(`src="`+basePath+`assets/`))
-		body = bytes.ReplaceAll(body, []byte(`href="/assets/`), []byte(`href="`+basePath+`assets/`))
-	}
-
 	subData := a.subPageContext(page)
 
 	// When an admin has configured a custom subscription theme, render it
@@ -549,6 +590,33 @@ func (a *SUBController) serveSubPage(c *gin.Context, basePath string, page PageD
 		}
 	}
 
+	writeSubPageSPA(c, basePath, subData)
+}
+
+// writeSubPageSPA serves the Vite-built sub page with subData injected as
+// window.__SUB_PAGE_DATA__.
+func writeSubPageSPA(c *gin.Context, basePath string, subData map[string]any) {
+	var body []byte
+	if diskBody, diskErr := os.ReadFile("internal/web/dist/subpage.html"); diskErr == nil {
+		body = diskBody
+	} else {
+		readBody, err := fs.ReadFile(distFS, "dist/subpage.html")
+		if err != nil {
+			c.String(http.StatusInternalServerError, "missing embedded subpage")
+			return
+		}
+		body = readBody
+	}
+
+	// Vite emits absolute asset URLs (`/assets/...`); when the panel is
+	// installed under a custom URL prefix, rewrite them so the bundle
+	// loads from `<basePath>assets/...` where the static handler is
+	// actually mounted.
+	if basePath != "/" && basePath != "" {
+		body = bytes.ReplaceAll(body, []byte(`src="/assets/`), []byte(`src="`+basePath+`assets/`))
+		body = bytes.ReplaceAll(body, []byte(`href="/assets/`), []byte(`href="`+basePath+`assets/`))
+	}
+
 	subDataJSON, err := json.Marshal(subData)
 	if err != nil {
 		subDataJSON = []byte("{}")
@@ -585,6 +653,12 @@ func (a *SUBController) subPageContext(page PageData) map[string]any {
 	if datepicker == "" {
 		datepicker = "gregorian"
 	}
+	// Daily usage for the last month, summed over every email and node.
+	usage, err := a.historyService.History(dedupeEmails(page.Emails), model.TrafficHistoryDaily, 30)
+	if err != nil {
+		logger.Warning("sub: load traffic history:", err)
+		usage = []service.TrafficHistoryPoint{}
+	}
 
 	return map[string]any{
 		"sId":           page.SId,
@@ -603,12 +677,15 @@ func (a *SUBController) subPageContext(page PageData) map[string]any {
 		"subUrl":        page.SubUrl,
 		"subJsonUrl":    page.SubJsonUrl,
 		"subClashUrl":   page.SubClashUrl,
+		"subSingboxUrl": page.SubSingboxUrl,
 		"subTitle":      page.SubTitle,
 		"subSupportUrl": page.SubSupportUrl,
 		"links":         page.Result,
 		"emails":        page.Emails,
 		"datepicker":    datepicker,
 		"announce":      page.SubAnnounce,
+		"usage":         usage,
+		"redeemUrl":     a.subPath + "redeem",
 	}
 }
 
@@ -801,6 +878,53 @@ func (a *SUBController) serveClashBody(c *gin.Context, rawDownload bool) bool {
 	return true
 }
 
+func (a *SUBController) subSingboxs(c *gin.Context) {
+	if strings.EqualFold(c.Query("view"), "raw") {
+		if !a.serveSingboxBody(c, true) {
+			writeSubError(c, nil)
+		}
+		a.recordSubscriptionFetch(c)
+		return
+	}
+	if a.maybeServeSubPage(c) {
+		return
+	}
+	if !a.enforceHwid(c) {
+		return
+	}
+	if !a.serveSingboxBody(c, false) {
+		writeSubError(c, nil)
+	}
+	a.recordSubscriptionFetch(c)
+}
+
+func (a *SUBController) serveSingboxBody(c *gin.Context, rawDownload bool) bool {
+	subId := c.Param("subid")
+	scheme, host, hostWithPort, _ := a.subService.ResolveRequest(c)
+	singboxSub, header, err := a.subSingboxService.GetSingbox(subId, host)
+	if err != nil {
+		writeSubError(c, err)
+		return true
+	}
+	if len(singboxSub) == 0 {
+		return false
+	}
+	profileURL := fmt.Sprintf("%s://%s%s", scheme, hostWithPort, c.Request.RequestURI)
+	var subReq *SubService
+	metadata := a.metadataForSubRequest(func() *SubService {
+		if subReq == nil {
+			subReq = a.subService.ForRequest(host)
+		}
+		return subReq
+	}, subId, profileURL)
+	a.ApplyCommonHeaders(c, header, a.updateInterval, metadata.Title, metadata.SupportURL, metadata.ProfileURL, metadata.Announce, a.subEnableRouting, a.subRoutingRules, a.subHideSettings)
+	if rawDownload {
+		c.Writer.Header().Set("Content-Disposition", `attachment; filename="subscription-singbox.json"`)
+	}
+	c.Data(200, "application/json; charset=utf-8", []byte(singboxSub))
+	return true
+}
+
 // ApplyCommonHeaders sets common HTTP headers for subscription responses including user info, update interval, and profile title.
 func (a *SUBController) ApplyCommonHeaders(
 	c *gin.Context,
@@ -0,0 +1,85 @@
+package sub
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+// Hosts pinned to the down node follow the failed-over inbound; hosts naming
+// no node or the standby stay as written, and the copy itself is never listed.
+func TestSub_FailedOverInboundUsesStandbyNode(t *testing.T) {
+	seedSubDB(t)
+	db := database.GetDB()
+	primaryNode := &model.Node{Name: "a", Address: "a.example.com", Port: 2053, Guid: "guid-a", Status: "offline"}
+	standbyNode := &model.Node{Name: "b", Address: "b.example.com", Port: 2053, Guid: "guid-b", Status: "online"}
+	for _, n := range []*model.Node{primaryNode, standbyNode} {
+		if err := db.Create(n).Error; err != nil {
+			t.Fatalf("seed node: %v", err)
+		}
+	}
+
+	ib := seedSubInbound(t, "s1", "f", 4441, 1, `{"network":"tcp","security":"none"}`)
+	if err := db.Model(ib).Updates(map[string]any{"node_id": primaryNode.Id, "listen": ""}).Error; err != nil {
+		t.Fatalf("move inbound to node: %v", err)
+	}
+	seedHost(t, &model.Host{InboundId: ib.Id, SortOrder: 1, Remark: "PINNED", Address: "a.cdn.com", NodeGuids: []string{"guid-a"}})
+	seedHost(t, &model.Host{InboundId: ib.Id, SortOrder: 2, Remark: "BOTH", Address: "rr.cdn.com", NodeGuids: []string{"guid-a", "guid-b"}})
+	seedHost(t, &model.Host{InboundId: ib.Id, SortOrder: 3, Remark: "FREE", Address: "free.cdn.com"})
+
+	render := func() string {
+		t.Helper()
+		links, _, _, _, err := NewSubService("").GetSubs("s1", "req.example.com")
+		if err != nil {
+			t.Fatalf("GetSubs: %v", err)
+		}
+		return strings.Join(links, "\n")
+	}
+
+	before := render()
+	if !strings.Contains(before, "a.cdn.com:4441") {
+		t.Fatalf("before failover the pinned host keeps its address: %s", before)
+	}
+
+	primaryID := ib.Id
+	standbyID := standbyNode.Id
+	copyIb := &model.Inbound{
+		UserId: 1, Tag: "f-standby", Enable: true, Port: 4441, Protocol: model.VLESS,
+		Settings: ib.Settings, StreamSettings: ib.StreamSettings, NodeID: &standbyID, FailoverOf: &primaryID,
+	}
+	if err := db.Create(copyIb).Error; err != nil {
+		t.Fatalf("seed standby copy: %v", err)
+	}
+	var rec model.ClientRecord
+	if err := db.Where("email = ?", "f@e").First(&rec).Error; err != nil {
+		t.Fatalf("load client: %v", err)
+	}
+	if err := db.Create(&model.ClientInbound{ClientId: rec.Id, InboundId: copyIb.Id}).Error; err != nil {
+		t.Fatalf("attach client to copy: %v", err)
+	}
+
+	after := render()
+	parts := strings.Split(after, "\n")
+	if len(parts) != 3 {
+		t.Fatalf("want the primary's 3 host links and nothing for the copy, got %d: %s", len(parts), after)
+	}
+	if strings.Contains(after, "a.cdn.com") || !strings.Contains(parts[0], "b.example.com:4441") {
+		t.Fatalf("a host pinned to the down node must move to the standby: %s", parts[0])
+	}
+	if !strings.Contains(parts[1], "rr.cdn.com:4441") {
+		t.Fatalf("a host that also resolves to the standby stays: %s", parts[1])
+	}
+	if !strings.Contains(parts[2], "free.cdn.com:4441") {
+		t.Fatalf("a host naming no node stays as written: %s", parts[2])
+	}
+
+	// Without hosts the inbound's own address is the standby node's.
+	if err := db.Where("inbound_id = ?", ib.Id).Delete(&model.Host{}).Error; err != nil {
+		t.Fatalf("drop hosts: %v", err)
+	}
+	if got := render(); !strings.Contains(got, "b.example.com:4441") || strings.Contains(got, "\n") {
+		t.Fatalf("failed-over inbound link = %s, want one link to the standby node", got)
+	}
+}
@@ -90,3 +90,25 @@ func remoteAddrInCIDRs(remoteAddr, cidrs string) bool {
 	}
 	return false
 }
+
+// clientIP honours X-Real-IP only from a trusted proxy, so a direct caller
+// cannot dodge the per-client rate limit with a fresh address per request.
+func (s *SubService) clientIP(c *gin.Context) string {
+	remote := c.Request.RemoteAddr
+	host := remote
+	if h, _, err := net.SplitHostPort(remote); err == nil {
+		host = h
+	}
+	realIP := strings.TrimSpace(c.GetHeader("X-Real-IP"))
+	if realIP == "" {
+		return host
+	}
+	trusted := service.DefaultTrustedProxyCIDRs
+	if configured, err := s.settingService.GetTrustedProxyCIDRs(); err == nil && strings.TrimSpace(configured) != "" {
+		trusted = configured
+	}
+	if remoteAddrInCIDRs(remote, trusted) {
+		return realIP
+	}
+	return host
+}
@@ -11,11 +11,11 @@ import (
 )
 
 // hostEndpoints loads an inbound's enabled hosts for the given subscription
-// format ("raw"|"json"|"clash") and returns them as externalProxy-shaped maps so
-// the existing per-format renderers can fan out one link/proxy per host. Returns
-// nil when the inbound has no applicable host — the caller then uses the legacy
-// inbound/externalProxy path, preserving byte-identical output for zero-host
-// inbounds.
+// format ("raw"|"json"|"clash"|"singbox") and returns them as externalProxy-
+// shaped maps so the existing per-format renderers can fan out one link/proxy
+// per host. Returns nil when the inbound has no applicable host — the caller
+// then uses the legacy inbound/externalProxy path, preserving byte-identical
+// output for zero-host inbounds.
 func (s *SubService) hostEndpoints(inbound *model.Inbound, format string) []map[string]any {
 	var hosts []*model.Host
 	if err := database.GetDB().
@@ -29,16 +29,28 @@ func (s *SubService) hostEndpoints(inbound *model.Inbound, format string) []map[
 		return nil
 	}
 	defaultDest := s.resolveInboundAddress(inbound)
+	standby := s.standbyByInbound[inbound.Id]
 	eps := make([]map[string]any, 0, len(hosts))
 	for _, h := range hosts {
 		if slices.Contains(h.ExcludeFromSubTypes, format) {
 			continue
 		}
+		if standby != nil && failedOverHost(h, standby) {
+			moved := *h
+			moved.Address = ""
+			h = &moved
+		}
 		eps = append(eps, hostToExternalProxyMap(h, defaultDest, inbound.Port))
 	}
 	return eps
 }
 
+// failedOverHost: a host resolving only to nodes other than the standby must
+// move; a host that names no nodes is kept as the admin wrote it.
+func failedOverHost(h *model.Host, standby *model.Node) bool {
+	return len(h.NodeGuids) > 0 && (standby.Guid == "" || !slices.Contains(h.NodeGuids, standby.Guid))
+}
+
 // hostToExternalProxyMap projects a Host onto the externalProxy entry shape the
 // raw/json/clash renderers already consume. Address/port fall back to the
 // inbound's own when the host leaves them blank (override-only host).
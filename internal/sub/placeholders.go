@@ -94,11 +94,41 @@ func (a *SUBController) metadataForSubRequest(getSubReq func() *SubService, subI
 		profileURL = renderSubPlaceholders(profileURL, subPlaceholderData{SubID: subID, Context: context, HasCtx: hasContext, Escape: true})
 	}
 	data := subPlaceholderData{SubID: subID, Context: context, HasCtx: hasContext}
+	announce := renderSubPlaceholders(a.subAnnounce, data)
+	if notice := a.accessNotice(subID); notice != "" {
+		announce = strings.TrimSpace(notice + "\n" + announce)
+	}
 	return renderedSubMetadata{
 		Title:      renderSubPlaceholders(a.subTitle, data),
 		SupportURL: renderSubPlaceholders(a.subSupportUrl, subPlaceholderData{SubID: subID, Context: context, HasCtx: hasContext, Escape: true}),
 		ProfileURL: profileURL,
-		Announce:   renderSubPlaceholders(a.subAnnounce, data),
+		Announce:   announce,
+	}
+}
+
+// accessNotice tells a subscriber outside its access window when it can
+// connect again, ahead of the configured announcement; "" while it can.
+func (a *SUBController) accessNotice(subID string) string {
+	if subID == "" {
+		return ""
+	}
+	var emails []string
+	if err := database.GetDB().Model(&model.ClientRecord{}).Where("sub_id = ?", subID).
+		Order("id ASC").Limit(1).Pluck("email", &emails).Error; err != nil || len(emails) == 0 {
+		return ""
+	}
+	paused, next, err := a.clientService.ClientAccess(emails[0])
+	if err != nil {
+		logger.Warning("sub: read access schedule:", err)
+		return ""
+	}
+	switch {
+	case !paused:
+		return ""
+	case next.IsZero():
+		return "Access is paused by schedule."
+	default:
+		return "Outside access hours until " + next.Format("Mon 15:04") + "."
 	}
 }
 
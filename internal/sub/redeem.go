@@ -0,0 +1,93 @@
+package sub
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/ha"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/websocket"
+)
+
+// redeemErrorCode maps a redeem failure to the code the sub page translates.
+// Anything unexpected is "failed" so internal errors never reach the caller.
+func redeemErrorCode(err error) string {
+	switch {
+	case errors.Is(err, service.ErrVoucherRateLimited):
+		return "rateLimited"
+	case errors.Is(err, service.ErrVoucherInvalid):
+		return "invalid"
+	case errors.Is(err, service.ErrVoucherNotApplicable):
+		return "notApplicable"
+	default:
+		return "failed"
+	}
+}
+
+// redeemPage serves the sub page in redeem-only mode, for people who have a
+// code but no subscription yet.
+func (a *SUBController) redeemPage(c *gin.Context) {
+	basePath, exists := c.Get("base_path")
+	if !exists {
+		basePath = "/"
+	}
+	title := a.subTitle
+	if title == "" {
+		title, _ = a.settingService.GetSubTitle()
+	}
+	writeSubPageSPA(c, basePath.(string), map[string]any{
+		"redeemOnly": true,
+		"redeemUrl":  a.subPath + "redeem",
+		"subTitle":   title,
+	})
+}
+
+// redeem takes JSON bodies only, so a cross-site form post cannot redeem on a
+// visitor's behalf. A subId in the body tops that subscription up.
+func (a *SUBController) redeem(c *gin.Context) {
+	setNoCacheHeaders(c)
+	if c.ContentType() != "application/json" {
+		c.Status(http.StatusUnsupportedMediaType)
+		return
+	}
+	// The HA standby serves subscriptions but must not write; a retry that the
+	// load balancer sends to the leader succeeds.
+	if ha.IsStandby() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "msg": "failed"})
+		return
+	}
+	var req entity.VoucherRedeemRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Code) == "" || len(req.Code) > 64 || len(req.SubId) > 128 {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": "invalid"})
+		return
+	}
+
+	ip := a.subService.clientIP(c)
+	res, err := a.voucherService.Redeem(&a.inboundService, service.VoucherRedemption{
+		Code:     req.Code,
+		SubId:    req.SubId,
+		Source:   service.VoucherSourceSub,
+		Key:      "ip:" + ip,
+		SourceIP: ip,
+	})
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": redeemErrorCode(err)})
+		return
+	}
+	if res.NeedRestart {
+		a.xrayService.SetToNeedRestart()
+	}
+	websocket.BroadcastInvalidate(websocket.MessageTypeClients)
+
+	_, host, _, _ := a.subService.ResolveRequest(c)
+	subURL, _, _ := a.subService.ForRequest(host).BuildURLs(a.subPath, a.subJsonPath, a.subClashPath, res.SubId)
+	c.JSON(http.StatusOK, gin.H{"success": true, "obj": gin.H{
+		"created": res.Created,
+		"subId":   res.SubId,
+		"subUrl":  subURL,
+	}})
+}
@@ -0,0 +1,49 @@
+package sub
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedeemRoutesBesideSubID(t *testing.T) {
+	router, subID := initHwidSubRouter(t, 0)
+
+	rec := requestSub(t, router, http.MethodGet, "/sub/redeem", "", "text/html")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"redeemOnly":true`) {
+		t.Fatalf("redeem page status = %d, body=%q", rec.Code, rec.Body.String())
+	}
+	rec = requestSub(t, router, http.MethodGet, "/sub/"+subID, "", "text/html")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"redeemUrl":"/sub/redeem"`) {
+		t.Fatalf("sub page status = %d, body=%q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRedeemRequiresJSON(t *testing.T) {
+	router, _ := initHwidSubRouter(t, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/sub/redeem", strings.NewReader("code=ABCD"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("form post status = %d, want 415", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/sub/redeem", strings.NewReader(`{"code":"NOPE-NOPE-NOPE"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var msg struct {
+		Success bool   `json:"success"`
+		Msg     string `json:"msg"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &msg); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+	if msg.Success || msg.Msg != "invalid" {
+		t.Fatalf("unknown code response = %+v", msg)
+	}
+}
@@ -52,6 +52,9 @@ type SubService struct {
 	// inbound whose NodeID is set. Keeps the per-link host derivation
 	// O(1) instead of O(N) DB hits.
 	nodesByID map[int]*model.Node
+	// standbyByInbound points a failed-over inbound's links at the standby
+	// node until its own node is back. Loaded with nodesByID.
+	standbyByInbound map[int]*model.Node
 	// statsByEmail maps a client email to its traffic row across ALL inbounds
 	// loaded for the request. client_traffics.email is globally unique, so this
 	// lets statsForClient resolve usage for a client even on an inbound that
@@ -485,6 +488,7 @@ func (s *SubService) getInboundsBySubId(subId string) ([]*model.Inbound, error)
 		WHERE
 			inbounds.protocol in ('vmess','vless','trojan','shadowsocks','hysteria','wireguard','mtproto')
 			AND clients.sub_id = ? AND inbounds.enable = ?
+			AND inbounds.failover_of IS NULL
 	)`, subId, true).Order("sub_sort_index ASC").Order("id ASC").Find(&inbounds).Error
 	if err != nil {
 		return nil, err
@@ -1141,6 +1145,27 @@ func (s *SubService) loadNodes() {
 		m[n.Id] = n
 	}
 	s.nodesByID = m
+
+	s.standbyByInbound = nil
+	var copies []struct {
+		FailoverOf int
+		NodeId     int
+	}
+	if err := db.Model(&model.Inbound{}).
+		Select("failover_of, node_id").
+		Where("failover_of IS NOT NULL AND node_id IS NOT NULL").
+		Scan(&copies).Error; err != nil {
+		logger.Warning("subscription: load standby copies failed:", err)
+		return
+	}
+	for _, c := range copies {
+		if n, ok := m[c.NodeId]; ok {
+			if s.standbyByInbound == nil {
+				s.standbyByInbound = make(map[int]*model.Node, len(copies))
+			}
+			s.standbyByInbound[c.FailoverOf] = n
+		}
+	}
 }
 
 // resolveInboundAddress picks the host an external client should connect to,
@@ -1160,6 +1185,11 @@ func (s *SubService) loadNodes() {
 // listen is a server-side detail and is never advertised; External Proxy still
 // overrides everything upstream of this call.
 func (s *SubService) resolveInboundAddress(inbound *model.Inbound) string {
+	// A failed-over inbound is reachable only through its standby node, whatever
+	// its share address strategy names.
+	if standby := s.standbyByInbound[inbound.Id]; standby != nil {
+		return standby.Address
+	}
 	var nodeAddr string
 	if inbound.NodeID != nil && s.nodesByID != nil {
 		if n, ok := s.nodesByID[*inbound.NodeID]; ok {
@@ -2496,6 +2526,7 @@ type PageData struct {
 	SubUrl        string
 	SubJsonUrl    string
 	SubClashUrl   string
+	SubSingboxUrl string
 	SubTitle      string
 	SubSupportUrl string
 	SubAnnounce   string
@@ -2593,6 +2624,23 @@ func (s *SubService) BuildURLs(subPath, subJsonPath, subClashPath, subId string)
 	return subURL, subJsonURL, subClashURL
 }
 
+// BuildSingboxURL builds the sing-box subscription URL on the same base
+// BuildURLs uses for the JSON and Clash formats.
+func (s *SubService) BuildSingboxURL(subSingboxPath, subId string) string {
+	if subId == "" {
+		return ""
+	}
+	configuredSubURI, _ := s.settingService.GetSubURI()
+	configuredSubSingboxURI, _ := s.settingService.GetSubSingboxURI()
+	base := s.settingService.BuildSubURIBase(s.address)
+	if configuredSubURI != "" {
+		if derived := s.extractBaseFromURI(configuredSubURI); derived != "" {
+			base = derived
+		}
+	}
+	return s.buildSingleURL(configuredSubSingboxURI, base, subSingboxPath, subId)
+}
+
 // extractBaseFromURI extracts scheme://host from a configured URI.
 // e.g., "https://example.com/sub-xxx/" → "https://example.com".
 // Returns "" when the URI is empty or lacks a scheme/host, so callers can
@@ -0,0 +1,852 @@
+package sub
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	wgutil "github.com/mhsanaei/3x-ui/v3/internal/util/wireguard"
+)
+
+//go:embed default_singbox.json
+var defaultSingboxJson string
+
+// singboxReservedTags are the group/outbound tags the base config owns; a
+// generated proxy tag never takes one of them.
+var singboxReservedTags = []string{"proxy", "auto", "direct"}
+
+// SubSingboxService renders a full sing-box (1.12+) client config from the same
+// inbounds, Host rows and external links the Clash and JSON formats use.
+type SubSingboxService struct {
+	mux        map[string]any
+	rules      []any
+	ruleSet    []any
+	routeFinal string
+	SubService *SubService
+}
+
+// NewSubSingboxService ignores malformed values. rules is a JSON array of route
+// rules or an object with "rules", "rule_set" and "final".
+func NewSubSingboxService(mux string, rules string, subService *SubService) *SubSingboxService {
+	s := &SubSingboxService{SubService: subService}
+	if strings.TrimSpace(mux) != "" {
+		var muxObj map[string]any
+		if json.Unmarshal([]byte(mux), &muxObj) == nil && len(muxObj) > 0 {
+			s.mux = muxObj
+		}
+	}
+	s.rules, s.ruleSet, s.routeFinal = parseSingboxRules(rules)
+	return s
+}
+
+// parseSingboxRules accepts the two shapes subSingboxRules may hold and
+// returns the route rules, rule sets and final outbound it carries.
+func parseSingboxRules(raw string) ([]any, []any, string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil, ""
+	}
+	var custom any
+	if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+		return nil, nil, ""
+	}
+	switch typed := custom.(type) {
+	case []any:
+		return typed, nil, ""
+	case map[string]any:
+		rules, _ := typed["rules"].([]any)
+		ruleSet, _ := typed["rule_set"].([]any)
+		final, _ := typed["final"].(string)
+		return rules, ruleSet, strings.TrimSpace(final)
+	default:
+		return nil, nil, ""
+	}
+}
+
+// GetSingbox generates the sing-box config for the given subscription ID and
+// host, along with the Subscription-Userinfo header value.
+func (s *SubSingboxService) GetSingbox(subId string, host string) (string, string, error) {
+	subReq := s.SubService.ForRequest(host)
+	subReq.subscriptionBody = true
+	inbounds, err := subReq.getInboundsBySubId(subId)
+	if err != nil {
+		return "", "", err
+	}
+	externalLinks, err := subReq.getClientExternalLinksBySubId(subId)
+	if err != nil {
+		return "", "", err
+	}
+	if len(inbounds) == 0 && len(externalLinks) == 0 {
+		return "", "", nil
+	}
+
+	var outbounds, endpoints []map[string]any
+
+	seenEmails := make(map[string]struct{})
+	for _, inbound := range inbounds {
+		clients := subReq.matchingClients(inbound, subId)
+		if len(clients) == 0 {
+			continue
+		}
+		subReq.projectThroughFallbackMaster(inbound)
+		if hostEps := subReq.hostEndpoints(inbound, "singbox"); len(hostEps) > 0 {
+			injectExternalProxy(inbound, hostEps)
+		}
+		for _, client := range clients {
+			seenEmails[client.Email] = struct{}{}
+			for _, ob := range s.getOutbounds(subReq, inbound, client, host) {
+				if ob["type"] == "wireguard" {
+					endpoints = append(endpoints, ob)
+				} else {
+					outbounds = append(outbounds, ob)
+				}
+			}
+		}
+	}
+	for _, ext := range externalLinks {
+		for _, el := range expandEntry(ext) {
+			tag := el.Name
+			if tag == "" {
+				tag = ext.Email
+			}
+			ob := s.singboxFromExternal(el.Link, tag)
+			if ob == nil {
+				continue
+			}
+			seenEmails[ext.Email] = struct{}{}
+			if ob["type"] == "wireguard" {
+				endpoints = append(endpoints, ob)
+			} else {
+				outbounds = append(outbounds, ob)
+			}
+		}
+	}
+
+	if len(outbounds) == 0 && len(endpoints) == 0 {
+		return "", "", nil
+	}
+
+	tags := ensureUniqueSingboxTags(outbounds, endpoints)
+
+	emails := make([]string, 0, len(seenEmails))
+	for e := range seenEmails {
+		emails = append(emails, e)
+	}
+	traffic, _ := subReq.AggregateTrafficByEmails(emails)
+
+	config := s.buildConfig(tags, outbounds, endpoints)
+	finalJson, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+
+	header := fmt.Sprintf("upload=%d; download=%d; total=%d; expire=%d", traffic.Up, traffic.Down, traffic.Total, traffic.ExpiryTime/1000)
+	return string(finalJson), header, nil
+}
+
+// buildConfig inserts operator rules right after the sniff/hijack-dns actions
+// so they see sniffed domains.
+func (s *SubSingboxService) buildConfig(tags []string, outbounds, endpoints []map[string]any) map[string]any {
+	var config map[string]any
+	_ = json.Unmarshal([]byte(defaultSingboxJson), &config)
+
+	selectorTags := make([]string, 0, len(tags)+2)
+	selectorTags = append(selectorTags, "auto")
+	selectorTags = append(selectorTags, tags...)
+	selectorTags = append(selectorTags, "direct")
+
+	allOutbounds := make([]any, 0, len(outbounds)+3)
+	allOutbounds = append(allOutbounds,
+		map[string]any{
+			"type":      "selector",
+			"tag":       "proxy",
+			"outbounds": selectorTags,
+			"default":   "auto",
+		},
+		map[string]any{
+			"type":      "urltest",
+			"tag":       "auto",
+			"outbounds": tags,
+			"url":       "https://www.gstatic.com/generate_204",
+			"interval":  "3m",
+		},
+	)
+	for _, ob := range outbounds {
+		allOutbounds = append(allOutbounds, ob)
+	}
+	baseOutbounds, _ := config["outbounds"].([]any)
+	config["outbounds"] = append(allOutbounds, baseOutbounds...)
+	if len(endpoints) > 0 {
+		config["endpoints"] = endpoints
+	}
+
+	route, _ := config["route"].(map[string]any)
+	if route == nil {
+		return config
+	}
+	if len(s.rules) > 0 {
+		baseRules, _ := route["rules"].([]any)
+		at := 0
+		for at < len(baseRules) {
+			rule, _ := baseRules[at].(map[string]any)
+			if action, _ := rule["action"].(string); action != "sniff" && action != "hijack-dns" {
+				break
+			}
+			at++
+		}
+		merged := make([]any, 0, len(baseRules)+len(s.rules))
+		merged = append(merged, baseRules[:at]...)
+		merged = append(merged, s.rules...)
+		merged = append(merged, baseRules[at:]...)
+		route["rules"] = merged
+	}
+	if len(s.ruleSet) > 0 {
+		route["rule_set"] = s.ruleSet
+	}
+	if s.routeFinal != "" {
+		route["final"] = s.routeFinal
+	}
+	return config
+}
+
+// ensureUniqueSingboxTags dedupes across both lists, since sing-box shares one
+// tag namespace; reserved group tags are never handed out.
+func ensureUniqueSingboxTags(outbounds, endpoints []map[string]any) []string {
+	seen := make(map[string]struct{}, len(outbounds)+len(endpoints)+len(singboxReservedTags))
+	for _, tag := range singboxReservedTags {
+		seen[tag] = struct{}{}
+	}
+	tags := make([]string, 0, len(outbounds)+len(endpoints))
+	idx := 0
+	for _, list := range [][]map[string]any{outbounds, endpoints} {
+		for _, ob := range list {
+			base, _ := ob["tag"].(string)
+			if base == "" {
+				typ, _ := ob["type"].(string)
+				base = fmt.Sprintf("%s-%d", typ, idx+1)
+			}
+			tag := base
+			for n := 2; ; n++ {
+				if _, dup := seen[tag]; !dup {
+					break
+				}
+				tag = fmt.Sprintf("%s-%d", base, n)
+			}
+			seen[tag] = struct{}{}
+			ob["tag"] = tag
+			tags = append(tags, tag)
+			idx++
+		}
+	}
+	return tags
+}
+
+func (s *SubSingboxService) getOutbounds(subReq *SubService, inbound *model.Inbound, client model.Client, host string) []map[string]any {
+	stream := singboxStreamData(inbound.StreamSettings)
+	defaultDest := subReq.resolveInboundAddress(inbound)
+	if defaultDest == "" {
+		defaultDest = host
+	}
+	externalProxies, ok := stream["externalProxy"].([]any)
+	hasExternalProxy := ok && len(externalProxies) > 0
+	if !hasExternalProxy {
+		externalProxies = []any{map[string]any{
+			"forceTls": "same",
+			"dest":     defaultDest,
+			"port":     float64(inbound.Port),
+			"remark":   "",
+		}}
+	}
+	delete(stream, "externalProxy")
+	network, _ := stream["network"].(string)
+
+	outbounds := make([]map[string]any, 0, len(externalProxies))
+	for _, ep := range externalProxies {
+		extPrxy, ok := ep.(map[string]any)
+		if !ok {
+			continue
+		}
+		subReq.renderHostRemark(inbound, client, extPrxy, network)
+		workingInbound := *inbound
+		workingInbound.Listen, _ = extPrxy["dest"].(string)
+		if port, ok := extPrxy["port"].(float64); ok {
+			workingInbound.Port = int(port)
+		}
+		workingStream := cloneStreamForExternalProxy(stream)
+		if rs, ok := workingStream["realitySettings"].(map[string]any); ok {
+			workingStream["realitySettings"] = cloneMap(rs)
+		}
+
+		forceTls, _ := extPrxy["forceTls"].(string)
+		switch forceTls {
+		case "tls":
+			if workingStream["security"] != "tls" {
+				workingStream["security"] = "tls"
+				workingStream["tlsSettings"] = map[string]any{}
+			}
+		case "none":
+			if workingStream["security"] != "none" {
+				workingStream["security"] = "none"
+				delete(workingStream, "tlsSettings")
+				delete(workingStream, "realitySettings")
+			}
+		}
+		security, _ := workingStream["security"].(string)
+		if hasExternalProxy {
+			applyExternalProxyTLSToStream(extPrxy, workingStream, security)
+		}
+		applyHostStreamOverrides(extPrxy, workingStream)
+
+		if ob := s.buildOutbound(subReq, &workingInbound, client, workingStream, extPrxy); len(ob) > 0 {
+			outbounds = append(outbounds, ob)
+		}
+	}
+	return outbounds
+}
+
+// buildOutbound returns nil for combinations sing-box cannot express, such as
+// xhttp or VLESS encryption; WireGuard becomes an endpoint.
+func (s *SubSingboxService) buildOutbound(subReq *SubService, inbound *model.Inbound, client model.Client, stream map[string]any, ep map[string]any) map[string]any {
+	network, _ := stream["network"].(string)
+	ob := map[string]any{
+		"tag":         subReq.endpointRemark(inbound, client.Email, ep, network),
+		"server":      inbound.Listen,
+		"server_port": inbound.Port,
+	}
+
+	switch inbound.Protocol {
+	case model.VMESS:
+		ob["type"] = "vmess"
+		ob["uuid"] = client.ID
+		ob["security"] = normalizeVmessSecurity(client.Security)
+		ob["alter_id"] = 0
+	case model.VLESS:
+		inboundSettings := subReq.linkSettings(inbound)
+		if encryption, _ := inboundSettings["encryption"].(string); strings.TrimSpace(encryption) != "" && strings.TrimSpace(encryption) != "none" {
+			return nil
+		}
+		ob["type"] = "vless"
+		ob["uuid"] = applyVlessRoute(client.ID, hostVlessRoute(ep))
+		ob["packet_encoding"] = "xudp"
+		security, _ := stream["security"].(string)
+		if client.Flow != "" && !inbound.DisableFlow && vlessFlowAllowed(network, security, inboundSettings) {
+			ob["flow"] = client.Flow
+		}
+	case model.Trojan:
+		ob["type"] = "trojan"
+		ob["password"] = client.Password
+	case model.Shadowsocks:
+		inboundSettings := subReq.linkSettings(inbound)
+		method, _ := inboundSettings["method"].(string)
+		if method == "" {
+			return nil
+		}
+		ob["type"] = "shadowsocks"
+		ob["method"] = method
+		ob["password"] = client.Password
+		if strings.HasPrefix(method, "2022") {
+			if serverPassword, ok := inboundSettings["password"].(string); ok && serverPassword != "" {
+				ob["password"] = fmt.Sprintf("%s:%s", serverPassword, client.Password)
+			}
+		}
+	case model.Hysteria:
+		if v, ok := subReq.linkSettings(inbound)["version"].(float64); ok && int(v) == 1 {
+			return nil
+		}
+		ob["type"] = "hysteria2"
+		ob["password"] = client.Auth
+	case model.WireGuard:
+		return s.buildWireguardEndpoint(subReq, inbound, client, ep)
+	default:
+		return nil
+	}
+
+	if !s.applyStream(ob, stream) {
+		return nil
+	}
+	return ob
+}
+
+// buildWireguardEndpoint mirrors the Clash WireGuard proxy: the peer public
+// key is derived from the inbound secretKey, the rest comes from the client.
+func (s *SubSingboxService) buildWireguardEndpoint(subReq *SubService, inbound *model.Inbound, client model.Client, ep map[string]any) map[string]any {
+	if client.PrivateKey == "" {
+		return nil
+	}
+	var inboundSettings map[string]any
+	_ = json.Unmarshal([]byte(inbound.Settings), &inboundSettings)
+
+	peer := map[string]any{
+		"address":     inbound.Listen,
+		"port":        inbound.Port,
+		"allowed_ips": []string{"0.0.0.0/0", "::/0"},
+	}
+	if secretKey, _ := inboundSettings["secretKey"].(string); secretKey != "" {
+		if pub, err := wgutil.PublicKeyFromPrivate(secretKey); err == nil {
+			peer["public_key"] = pub
+		}
+	}
+	if client.PreSharedKey != "" {
+		peer["pre_shared_key"] = client.PreSharedKey
+	}
+	if client.KeepAlive > 0 {
+		peer["persistent_keepalive_interval"] = client.KeepAlive
+	}
+
+	endpoint := map[string]any{
+		"type":        "wireguard",
+		"tag":         subReq.endpointRemark(inbound, client.Email, ep, ""),
+		"address":     singboxWireguardAddresses(client.AllowedIPs),
+		"private_key": client.PrivateKey,
+		"peers":       []any{peer},
+	}
+	if mtu, ok := inboundSettings["mtu"].(float64); ok && mtu > 0 {
+		endpoint["mtu"] = int(mtu)
+	}
+	return endpoint
+}
+
+// singboxWireguardAddresses turns client tunnel addresses into the prefix
+// form sing-box requires, defaulting bare IPs to a host route.
+func singboxWireguardAddresses(addrs []string) []string {
+	out := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if !strings.Contains(addr, "/") {
+			if strings.Contains(addr, ":") {
+				addr += "/128"
+			} else {
+				addr += "/32"
+			}
+		}
+		out = append(out, addr)
+	}
+	return out
+}
+
+// applyStream adds transport, TLS and multiplex blocks to a partially built
+// outbound. It reports false when the stream has no sing-box equivalent.
+func (s *SubSingboxService) applyStream(ob map[string]any, stream map[string]any) bool {
+	network, _ := stream["network"].(string)
+	security, _ := stream["security"].(string)
+
+	switch ob["type"] {
+	case "hysteria2":
+		tls := singboxTLS(stream, "tls")
+		if tls == nil {
+			tls = map[string]any{"enabled": true}
+		}
+		delete(tls, "utls")
+		ob["tls"] = tls
+		if password := singboxSalamanderPassword(stream); password != "" {
+			ob["obfs"] = map[string]any{"type": "salamander", "password": password}
+		}
+		if ports := singboxServerPorts(hysteriaHopPorts(stream)); len(ports) > 0 {
+			ob["server_ports"] = ports
+		}
+		return true
+	case "shadowsocks":
+		// sing-box shadowsocks has no v2ray transport or TLS layer.
+		if transport, ok := singboxTransport(network, stream); !ok || transport != nil {
+			return false
+		}
+		if security != "" && security != "none" {
+			return false
+		}
+		s.applyMux(ob)
+		return true
+	}
+
+	transport, ok := singboxTransport(network, stream)
+	if !ok {
+		return false
+	}
+	if transport != nil {
+		ob["transport"] = transport
+	}
+	switch security {
+	case "", "none":
+	case "tls", "reality":
+		tls := singboxTLS(stream, security)
+		if tls == nil {
+			return false
+		}
+		ob["tls"] = tls
+	default:
+		return false
+	}
+	if _, hasFlow := ob["flow"]; !hasFlow {
+		s.applyMux(ob)
+	}
+	return true
+}
+
+func (s *SubSingboxService) applyMux(ob map[string]any) {
+	if len(s.mux) == 0 {
+		return
+	}
+	ob["multiplex"] = cloneMap(s.mux)
+}
+
+// singboxTransport converts an xray stream network into a sing-box V2Ray
+// transport. A nil map with true means plain TCP (no transport block).
+func singboxTransport(network string, stream map[string]any) (map[string]any, bool) {
+	switch network {
+	case "", "tcp", "raw":
+		tcp, _ := stream["tcpSettings"].(map[string]any)
+		if tcp == nil {
+			tcp, _ = stream["rawSettings"].(map[string]any)
+		}
+		if header, _ := tcp["header"].(map[string]any); header != nil {
+			if typeStr, _ := header["type"].(string); typeStr != "" && typeStr != "none" {
+				return nil, false
+			}
+		}
+		return nil, true
+	case "ws":
+		ws, _ := stream["wsSettings"].(map[string]any)
+		transport := map[string]any{"type": "ws"}
+		path, _ := ws["path"].(string)
+		if path, earlyData := splitWsEarlyData(path); path != "" || earlyData > 0 {
+			if path != "" {
+				transport["path"] = path
+			}
+			if earlyData > 0 {
+				transport["max_early_data"] = earlyData
+				transport["early_data_header_name"] = "Sec-WebSocket-Protocol"
+			}
+		}
+		if host := singboxTransportHost(ws); host != "" {
+			transport["headers"] = map[string]any{"Host": host}
+		}
+		return transport, true
+	case "grpc":
+		grpc, _ := stream["grpcSettings"].(map[string]any)
+		transport := map[string]any{"type": "grpc"}
+		if serviceName, _ := grpc["serviceName"].(string); serviceName != "" {
+			transport["service_name"] = serviceName
+		}
+		return transport, true
+	case "httpupgrade":
+		hu, _ := stream["httpupgradeSettings"].(map[string]any)
+		transport := map[string]any{"type": "httpupgrade"}
+		if path, _ := hu["path"].(string); path != "" {
+			transport["path"] = path
+		}
+		if host := singboxTransportHost(hu); host != "" {
+			transport["host"] = host
+		}
+		return transport, true
+	default:
+		return nil, false
+	}
+}
+
+func singboxTransportHost(settings map[string]any) string {
+	if host, _ := settings["host"].(string); host != "" {
+		return host
+	}
+	if headers, ok := settings["headers"].(map[string]any); ok {
+		return searchHost(headers)
+	}
+	return ""
+}
+
+// splitWsEarlyData strips xray's "?ed=N" early-data marker from a WebSocket
+// path; sing-box takes it as max_early_data instead.
+func splitWsEarlyData(path string) (string, int) {
+	before, query, ok := strings.Cut(path, "?")
+	if !ok {
+		return path, 0
+	}
+	rest := make([]string, 0, 1)
+	earlyData := 0
+	for param := range strings.SplitSeq(query, "&") {
+		if v, found := strings.CutPrefix(param, "ed="); found {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				earlyData = n
+				continue
+			}
+		}
+		if param != "" {
+			rest = append(rest, param)
+		}
+	}
+	if len(rest) > 0 {
+		before += "?" + strings.Join(rest, "&")
+	}
+	return before, earlyData
+}
+
+// singboxTLS builds the outbound "tls" block for tls or reality security.
+// It reads both the flattened inbound shape and the share-link outbound shape.
+func singboxTLS(stream map[string]any, security string) map[string]any {
+	switch security {
+	case "tls":
+		tlsSettings, _ := stream["tlsSettings"].(map[string]any)
+		tls := map[string]any{"enabled": true}
+		if serverName, _ := tlsSettings["serverName"].(string); serverName != "" {
+			tls["server_name"] = serverName
+		}
+		if alpn := clashStringList(tlsSettings["alpn"]); len(alpn) > 0 {
+			tls["alpn"] = alpn
+		}
+		inner, _ := tlsSettings["settings"].(map[string]any)
+		if insecure, _ := tlsSettings["allowInsecure"].(bool); insecure {
+			tls["insecure"] = true
+		} else if insecure, _ := inner["allowInsecure"].(bool); insecure {
+			tls["insecure"] = true
+		}
+		if fp, _ := tlsSettings["fingerprint"].(string); fp != "" {
+			tls["utls"] = map[string]any{"enabled": true, "fingerprint": fp}
+		}
+		return tls
+	case "reality":
+		realitySettings, _ := stream["realitySettings"].(map[string]any)
+		publicKey, _ := realitySettings["publicKey"].(string)
+		if publicKey == "" {
+			return nil
+		}
+		tls := map[string]any{"enabled": true}
+		if serverName, _ := realitySettings["serverName"].(string); serverName != "" {
+			tls["server_name"] = serverName
+		}
+		fp, _ := realitySettings["fingerprint"].(string)
+		if fp == "" {
+			fp = "chrome"
+		}
+		tls["utls"] = map[string]any{"enabled": true, "fingerprint": fp}
+		reality := map[string]any{"enabled": true, "public_key": publicKey}
+		if shortID, _ := realitySettings["shortId"].(string); shortID != "" {
+			reality["short_id"] = shortID
+		}
+		tls["reality"] = reality
+		return tls
+	default:
+		return nil
+	}
+}
+
+// singboxSalamanderPassword reads the Hysteria2 salamander obfs password from
+// the stream's finalmask, the same block the share-link generator uses.
+func singboxSalamanderPassword(stream map[string]any) string {
+	finalmask, _ := stream["finalmask"].(map[string]any)
+	udpMasks, _ := finalmask["udp"].([]any)
+	for _, m := range udpMasks {
+		mask, _ := m.(map[string]any)
+		if mask == nil || mask["type"] != "salamander" {
+			continue
+		}
+		settings, _ := mask["settings"].(map[string]any)
+		if pw, _ := settings["password"].(string); pw != "" {
+			return pw
+		}
+	}
+	return ""
+}
+
+// singboxServerPorts converts an xray hop range ("20000-30000,443") into
+// sing-box server_ports entries ("20000:30000", "443:443").
+func singboxServerPorts(hopPorts string) []string {
+	if hopPorts == "" {
+		return nil
+	}
+	var out []string
+	for part := range strings.SplitSeq(hopPorts, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if from, to, ok := strings.Cut(part, "-"); ok {
+			out = append(out, strings.TrimSpace(from)+":"+strings.TrimSpace(to))
+		} else {
+			out = append(out, part+":"+part)
+		}
+	}
+	return out
+}
+
+// singboxStreamData parses an inbound's stream settings and flattens the
+// client-facing tls/reality fields, like SubClashService.streamData.
+func singboxStreamData(stream string) map[string]any {
+	var streamSettings map[string]any
+	if err := json.Unmarshal([]byte(stream), &streamSettings); err != nil || streamSettings == nil {
+		streamSettings = map[string]any{}
+	}
+	security, _ := streamSettings["security"].(string)
+	switch security {
+	case "tls":
+		if tlsSettings, ok := streamSettings["tlsSettings"].(map[string]any); ok {
+			inner, _ := tlsSettings["settings"].(map[string]any)
+			flat := map[string]any{
+				"serverName": tlsSettings["serverName"],
+				"alpn":       tlsSettings["alpn"],
+			}
+			if fingerprint, ok := inner["fingerprint"].(string); ok {
+				flat["fingerprint"] = fingerprint
+			}
+			if insecure, ok := inner["allowInsecure"].(bool); ok && insecure {
+				flat["allowInsecure"] = true
+			}
+			streamSettings["tlsSettings"] = flat
+		}
+	case "reality":
+		if realitySettings, ok := streamSettings["realitySettings"].(map[string]any); ok {
+			inner, _ := realitySettings["settings"].(map[string]any)
+			flat := map[string]any{}
+			if publicKey, ok := inner["publicKey"].(string); ok {
+				flat["publicKey"] = publicKey
+			}
+			if fingerprint, ok := inner["fingerprint"].(string); ok {
+				flat["fingerprint"] = fingerprint
+			}
+			if serverNames, ok := realitySettings["serverNames"].([]any); ok && len(serverNames) > 0 {
+				flat["serverName"] = fmt.Sprint(serverNames[0])
+			}
+			if shortIDs, ok := realitySettings["shortIds"].([]any); ok && len(shortIDs) > 0 {
+				flat["shortId"] = fmt.Sprint(shortIDs[0])
+			}
+			streamSettings["realitySettings"] = flat
+		}
+	}
+	delete(streamSettings, "sockopt")
+	return streamSettings
+}
+
+// singboxFromExternal converts a pasted share link into a sing-box outbound
+// tagged `tag`, or nil when sing-box cannot represent it.
+func (s *SubSingboxService) singboxFromExternal(rawLink, tag string) map[string]any {
+	parsed := parseExternalLink(rawLink)
+	if parsed == nil {
+		return nil
+	}
+	protocol, _ := parsed["protocol"].(string)
+	settings, _ := parsed["settings"].(map[string]any)
+	stream, _ := parsed["streamSettings"].(map[string]any)
+	if settings == nil {
+		return nil
+	}
+	if stream == nil {
+		stream = map[string]any{}
+	}
+
+	ob := map[string]any{"tag": tag}
+	switch protocol {
+	case "vmess":
+		vnext, _ := settings["vnext"].([]any)
+		if len(vnext) == 0 {
+			return nil
+		}
+		vn, _ := vnext[0].(map[string]any)
+		users, _ := vn["users"].([]any)
+		if vn == nil || len(users) == 0 {
+			return nil
+		}
+		user, _ := users[0].(map[string]any)
+		ob["type"] = "vmess"
+		ob["server"] = fmt.Sprint(vn["address"])
+		ob["server_port"] = clashInt(vn["port"])
+		ob["uuid"] = fmt.Sprint(user["id"])
+		security, _ := user["security"].(string)
+		ob["security"] = normalizeVmessSecurity(security)
+		ob["alter_id"] = 0
+	case "vless":
+		if encryption, _ := settings["encryption"].(string); encryption != "" && encryption != "none" {
+			return nil
+		}
+		ob["type"] = "vless"
+		ob["server"] = fmt.Sprint(settings["address"])
+		ob["server_port"] = clashInt(settings["port"])
+		ob["uuid"] = fmt.Sprint(settings["id"])
+		ob["packet_encoding"] = "xudp"
+		if flow, _ := settings["flow"].(string); flow != "" {
+			ob["flow"] = flow
+		}
+	case "trojan":
+		server := firstServer(settings)
+		if server == nil {
+			return nil
+		}
+		ob["type"] = "trojan"
+		ob["server"] = fmt.Sprint(server["address"])
+		ob["server_port"] = clashInt(server["port"])
+		ob["password"] = fmt.Sprint(server["password"])
+	case "shadowsocks":
+		server := firstServer(settings)
+		if server == nil {
+			server = settings
+		}
+		method, _ := server["method"].(string)
+		if method == "" {
+			return nil
+		}
+		ob["type"] = "shadowsocks"
+		ob["server"] = fmt.Sprint(server["address"])
+		ob["server_port"] = clashInt(server["port"])
+		ob["method"] = method
+		ob["password"] = fmt.Sprint(server["password"])
+	case "hysteria":
+		hy, _ := stream["hysteriaSettings"].(map[string]any)
+		auth, _ := hy["auth"].(string)
+		if auth == "" {
+			return nil
+		}
+		ob["type"] = "hysteria2"
+		ob["server"] = fmt.Sprint(settings["address"])
+		ob["server_port"] = clashInt(settings["port"])
+		ob["password"] = auth
+	case "wireguard":
+		return singboxWireguardFromExternal(settings, tag)
+	default:
+		return nil
+	}
+
+	if !s.applyStream(ob, stream) {
+		return nil
+	}
+	return ob
+}
+
+func singboxWireguardFromExternal(settings map[string]any, tag string) map[string]any {
+	peers, _ := settings["peers"].([]any)
+	if len(peers) == 0 {
+		return nil
+	}
+	peerSettings, _ := peers[0].(map[string]any)
+	if peerSettings == nil {
+		return nil
+	}
+	host, port := splitClashHostPort(fmt.Sprint(peerSettings["endpoint"]))
+	privateKey, _ := settings["secretKey"].(string)
+	if host == "" || port == 0 || privateKey == "" {
+		return nil
+	}
+	peer := map[string]any{
+		"address":     host,
+		"port":        port,
+		"allowed_ips": []string{"0.0.0.0/0", "::/0"},
+	}
+	if pk, _ := peerSettings["publicKey"].(string); pk != "" {
+		peer["public_key"] = pk
+	}
+	if psk, _ := peerSettings["preSharedKey"].(string); psk != "" {
+		peer["pre_shared_key"] = psk
+	}
+	endpoint := map[string]any{
+		"type":        "wireguard",
+		"tag":         tag,
+		"address":     singboxWireguardAddresses(clashStringList(settings["address"])),
+		"private_key": privateKey,
+		"peers":       []any{peer},
+	}
+	if mtu := clashInt(settings["mtu"]); mtu > 0 {
+		endpoint["mtu"] = mtu
+	}
+	return endpoint
+}
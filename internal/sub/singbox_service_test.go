@@ -0,0 +1,202 @@
+package sub
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+func TestBuildOutbound_VLESSRealityForSingbox(t *testing.T) {
+	svc := NewSubSingboxService("", "", &SubService{})
+	inbound := &model.Inbound{Listen: "203.0.113.1", Port: 443, Protocol: model.VLESS, Remark: "r", Settings: `{"encryption":"none"}`}
+	client := model.Client{ID: "11111111-2222-4333-8444-555555555555", Flow: "xtls-rprx-vision"}
+	stream := map[string]any{
+		"network":         "tcp",
+		"security":        "reality",
+		"realitySettings": map[string]any{"serverName": "reality.example.com", "publicKey": "PBKvalue", "shortId": "ab12"},
+	}
+
+	ob := svc.buildOutbound(svc.SubService, inbound, client, stream, nil)
+	if ob == nil {
+		t.Fatal("buildOutbound returned nil for a valid reality stream")
+	}
+	if ob["type"] != "vless" || ob["flow"] != "xtls-rprx-vision" || ob["packet_encoding"] != "xudp" {
+		t.Fatalf("vless fields = %v", ob)
+	}
+	if _, ok := ob["transport"]; ok {
+		t.Fatalf("plain tcp must not emit a transport block: %v", ob["transport"])
+	}
+	tls, _ := ob["tls"].(map[string]any)
+	if tls["server_name"] != "reality.example.com" {
+		t.Fatalf("server_name = %v", tls["server_name"])
+	}
+	utls, _ := tls["utls"].(map[string]any)
+	if utls["fingerprint"] != "chrome" {
+		t.Fatalf("reality must default the utls fingerprint to chrome, got %v", utls["fingerprint"])
+	}
+	reality, _ := tls["reality"].(map[string]any)
+	if reality["public_key"] != "PBKvalue" || reality["short_id"] != "ab12" {
+		t.Fatalf("reality = %v", reality)
+	}
+}
+
+func TestBuildOutbound_VLESSPostQuantumSkipped(t *testing.T) {
+	svc := NewSubSingboxService("", "", &SubService{})
+	inbound := &model.Inbound{Listen: "203.0.113.1", Port: 443, Protocol: model.VLESS, Settings: `{"encryption":"mlkem768x25519plus.native.0rtt.abc"}`}
+	if ob := svc.buildOutbound(svc.SubService, inbound, model.Client{ID: "u"}, map[string]any{"network": "tcp"}, nil); ob != nil {
+		t.Fatalf("sing-box has no VLESS encryption; expected nil, got %v", ob)
+	}
+}
+
+func TestBuildOutbound_Shadowsocks2022Password(t *testing.T) {
+	svc := NewSubSingboxService("", "", &SubService{})
+	inbound := &model.Inbound{Listen: "203.0.113.1", Port: 8388, Protocol: model.Shadowsocks, Settings: `{"method":"2022-blake3-aes-128-gcm","password":"server"}`}
+	ob := svc.buildOutbound(svc.SubService, inbound, model.Client{Password: "client"}, map[string]any{"network": "tcp"}, nil)
+	if ob == nil {
+		t.Fatal("buildOutbound returned nil for shadowsocks over tcp")
+	}
+	if ob["password"] != "server:client" || ob["method"] != "2022-blake3-aes-128-gcm" {
+		t.Fatalf("shadowsocks fields = %v", ob)
+	}
+
+	ws := map[string]any{"network": "ws", "wsSettings": map[string]any{"path": "/"}}
+	if ob := svc.buildOutbound(svc.SubService, inbound, model.Client{Password: "client"}, ws, nil); ob != nil {
+		t.Fatalf("shadowsocks has no v2ray transport in sing-box; expected nil, got %v", ob)
+	}
+}
+
+func TestBuildOutbound_Hysteria2ObfsAndPorts(t *testing.T) {
+	svc := NewSubSingboxService("", "", &SubService{})
+	inbound := &model.Inbound{Listen: "203.0.113.1", Port: 443, Protocol: model.Hysteria, Settings: `{"version":2}`}
+	stream := map[string]any{
+		"network":     "hysteria",
+		"security":    "tls",
+		"tlsSettings": map[string]any{"serverName": "hy.example.com", "fingerprint": "chrome"},
+		"finalmask": map[string]any{
+			"udp":        []any{map[string]any{"type": "salamander", "settings": map[string]any{"password": "obfs-pw"}}},
+			"quicParams": map[string]any{"udpHop": map[string]any{"ports": "20000-30000,443"}},
+		},
+	}
+
+	ob := svc.buildOutbound(svc.SubService, inbound, model.Client{Auth: "auth"}, stream, nil)
+	if ob == nil {
+		t.Fatal("buildOutbound returned nil for hysteria2")
+	}
+	if ob["type"] != "hysteria2" || ob["password"] != "auth" {
+		t.Fatalf("hysteria2 fields = %v", ob)
+	}
+	tls, _ := ob["tls"].(map[string]any)
+	if tls["server_name"] != "hy.example.com" {
+		t.Fatalf("server_name = %v", tls["server_name"])
+	}
+	if _, ok := tls["utls"]; ok {
+		t.Fatal("hysteria2 is QUIC; utls must be dropped")
+	}
+	obfs, _ := ob["obfs"].(map[string]any)
+	if obfs["type"] != "salamander" || obfs["password"] != "obfs-pw" {
+		t.Fatalf("obfs = %v", obfs)
+	}
+	if got, want := ob["server_ports"], []string{"20000:30000", "443:443"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("server_ports = %v, want %v", got, want)
+	}
+
+	inbound.Settings = `{"version":1}`
+	if ob := svc.buildOutbound(svc.SubService, inbound, model.Client{Auth: "auth"}, stream, nil); ob != nil {
+		t.Fatalf("hysteria v1 must be skipped, got %v", ob)
+	}
+}
+
+func TestSingboxTransport(t *testing.T) {
+	ws := map[string]any{"wsSettings": map[string]any{"path": "/ws?ed=2048", "headers": map[string]any{"Host": "cdn.example.com"}}}
+	transport, ok := singboxTransport("ws", ws)
+	if !ok {
+		t.Fatal("ws must be supported")
+	}
+	want := map[string]any{
+		"type":                   "ws",
+		"path":                   "/ws",
+		"max_early_data":         2048,
+		"early_data_header_name": "Sec-WebSocket-Protocol",
+		"headers":                map[string]any{"Host": "cdn.example.com"},
+	}
+	if !reflect.DeepEqual(transport, want) {
+		t.Fatalf("ws transport = %v, want %v", transport, want)
+	}
+
+	if _, ok := singboxTransport("tcp", map[string]any{"tcpSettings": map[string]any{"header": map[string]any{"type": "http"}}}); ok {
+		t.Fatal("tcp http header obfuscation has no sing-box equivalent")
+	}
+	if _, ok := singboxTransport("xhttp", map[string]any{}); ok {
+		t.Fatal("xhttp has no sing-box equivalent")
+	}
+}
+
+func TestBuildConfig_RulesAfterSniff(t *testing.T) {
+	svc := NewSubSingboxService(`{"enabled":true,"protocol":"h2mux"}`,
+		`{"rules":[{"domain_suffix":["example.ir"],"outbound":"direct"}],"rule_set":[{"tag":"geo","type":"remote","format":"binary","url":"https://example.com/geo.srs"}],"final":"direct"}`,
+		&SubService{})
+	outbounds := []map[string]any{{"type": "vless", "tag": "node"}}
+	tags := ensureUniqueSingboxTags(outbounds, nil)
+
+	config := svc.buildConfig(tags, outbounds, nil)
+	raw, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("marshal config: %v", err)
+	}
+	var parsed struct {
+		Outbounds []map[string]any `json:"outbounds"`
+		Route     struct {
+			Rules   []map[string]any `json:"rules"`
+			RuleSet []map[string]any `json:"rule_set"`
+			Final   string           `json:"final"`
+		} `json:"route"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		t.Fatalf("unmarshal config: %v", err)
+	}
+
+	if len(parsed.Outbounds) != 4 || parsed.Outbounds[0]["tag"] != "proxy" || parsed.Outbounds[1]["tag"] != "auto" || parsed.Outbounds[3]["tag"] != "direct" {
+		t.Fatalf("outbounds = %v", parsed.Outbounds)
+	}
+	if len(parsed.Route.Rules) != 4 || parsed.Route.Rules[2]["outbound"] != "direct" || parsed.Route.Rules[2]["domain_suffix"] == nil {
+		t.Fatalf("operator rules must sit after sniff/hijack-dns: %v", parsed.Route.Rules)
+	}
+	if len(parsed.Route.RuleSet) != 1 || parsed.Route.Final != "direct" {
+		t.Fatalf("rule_set/final = %v, %q", parsed.Route.RuleSet, parsed.Route.Final)
+	}
+}
+
+func TestEnsureUniqueSingboxTags(t *testing.T) {
+	outbounds := []map[string]any{{"tag": "proxy"}, {"tag": "node"}, {"tag": "node"}, {"type": "vmess"}}
+	endpoints := []map[string]any{{"tag": "node"}}
+	got := ensureUniqueSingboxTags(outbounds, endpoints)
+	want := []string{"proxy-2", "node", "node-2", "vmess-4", "node-3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tags = %v, want %v", got, want)
+	}
+}
+
+func TestSub_SingboxHostsAndExclude(t *testing.T) {
+	seedSubDB(t)
+	ib := seedSubInbound(t, "s1", "sb", 4460, 1, wsTLSStream)
+	seedHost(t, &model.Host{InboundId: ib.Id, SortOrder: 0, Remark: "A", Address: "a.cdn.com", Port: 8443, Security: "tls"})
+	seedHost(t, &model.Host{InboundId: ib.Id, SortOrder: 1, Remark: "B", Address: "b.cdn.com", Port: 8443, Security: "tls", ExcludeFromSubTypes: []string{"singbox"}})
+
+	out, _, err := NewSubSingboxService("", "", NewSubService("")).GetSingbox("s1", "req.example.com")
+	if err != nil {
+		t.Fatalf("GetSingbox: %v", err)
+	}
+	if !strings.Contains(out, `"a.cdn.com"`) {
+		t.Fatalf("host endpoint missing from sing-box config: %s", out)
+	}
+	if strings.Contains(out, "b.cdn.com") {
+		t.Fatalf("host excluded from singbox must not appear: %s", out)
+	}
+	var config map[string]any
+	if err := json.Unmarshal([]byte(out), &config); err != nil {
+		t.Fatalf("sing-box config is not valid JSON: %v", err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mhsanaei/3x-ui/v3/internal/acme"
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
 	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/locale"
@@ -64,6 +65,10 @@ func (s *Server) initRouter() (*gin.Engine, error) {
 		engine.Use(middleware.DomainValidatorMiddleware(subDomain))
 	}
 
+	// ACME http-01 challenges, for certificates ordered while the sub server
+	// owns port 80.
+	engine.GET(acme.HTTPChallengePrefix+":token", gin.WrapH(acme.DefaultResponder))
+
 	LinksPath, err := s.settingService.GetSubPath()
 	if err != nil {
 		return nil, err
@@ -114,6 +119,26 @@ func (s *Server) initRouter() (*gin.Engine, error) {
 		subClashUserAgentRegex = service.DefaultSubClashUserAgentRegex
 	}
 
+	SingboxPath, err := s.settingService.GetSubSingboxPath()
+	if err != nil {
+		return nil, err
+	}
+
+	subSingboxEnable, err := s.settingService.GetSubSingboxEnable()
+	if err != nil {
+		return nil, err
+	}
+
+	subSingboxAutoDetect, err := s.settingService.GetSubSingboxAutoDetect()
+	if err != nil {
+		subSingboxAutoDetect = false
+	}
+
+	subSingboxUserAgentRegex, err := s.settingService.GetSubSingboxUserAgentRegex()
+	if err != nil {
+		subSingboxUserAgentRegex = service.DefaultSubSingboxUserAgentRegex
+	}
+
 	// Set base_path based on LinksPath for template rendering
 	// Ensure LinksPath ends with "/" for proper asset URL generation
 	basePath := LinksPath
@@ -165,6 +190,16 @@ func (s *Server) initRouter() (*gin.Engine, error) {
 		SubClashRules = ""
 	}
 
+	SubSingboxMux, err := s.settingService.GetSubSingboxMux()
+	if err != nil {
+		SubSingboxMux = ""
+	}
+
+	SubSingboxRules, err := s.settingService.GetSubSingboxRules()
+	if err != nil {
+		SubSingboxRules = ""
+	}
+
 	SubTitle, err := s.settingService.GetSubTitle()
 	if err != nil {
 		SubTitle = ""
@@ -283,6 +318,12 @@ func (s *Server) initRouter() (*gin.Engine, error) {
 		WithSUBJsonFinalMask(SubJsonFinalMask),
 		WithSUBClashEnableRouting(SubClashEnableRouting),
 		WithSUBClashRules(SubClashRules),
+		WithSUBSingboxPath(SingboxPath),
+		WithSUBSingboxEnabled(subSingboxEnable),
+		WithSUBSingboxAutoDetect(subSingboxAutoDetect),
+		WithSUBSingboxUserAgentRegex(subSingboxUserAgentRegex),
+		WithSUBSingboxMux(SubSingboxMux),
+		WithSUBSingboxRules(SubSingboxRules),
 		WithSUBTitle(SubTitle),
 		WithSUBSupportURL(SubSupportUrl),
 		WithSUBProfileURL(SubProfileUrl),
@@ -343,10 +384,11 @@ func (s *Server) Start() (err error) {
 	}
 
 	if certFile != "" || keyFile != "" {
-		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		reloader, err := network.NewCertReloader(certFile, keyFile)
 		if err == nil {
 			c := &tls.Config{
-				Certificates: []tls.Certificate{cert},
+				GetCertificate:     reloader.GetCertificate,
+				GetConfigForClient: acme.DefaultResponder.GetConfigForClient,
 			}
 			listener = network.NewAutoHttpsListener(listener)
 			listener = tls.NewListener(listener, c)
@@ -0,0 +1,210 @@
+// Package schedule parses weekly access windows such as
+// "mon-fri 09:00-18:00; sat,sun 10:00-14:00" and tells whether a moment is inside.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+var dayNames = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Window is one time range on a set of weekdays. An End at or before Start runs
+// past midnight, still counting as the starting weekday.
+type Window struct {
+	Days  [7]bool // indexed by time.Weekday
+	Start int     // minutes after midnight
+	End   int     // minutes after midnight, up to 24:00
+}
+
+// Schedule is a set of windows. The zero Schedule allows every moment.
+type Schedule []Window
+
+// Parse reads ";"-separated windows like "mon-fri 09:00-12:00,13:00-18:00"; days
+// are listed, ranged ("fri-mon" wraps) or "daily". Blank is the zero Schedule.
+func Parse(s string) (Schedule, error) {
+	var out Schedule
+	for part := range strings.SplitSeq(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("window %q: want days and time ranges, like mon-fri 09:00-18:00", part)
+		}
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		for r := range strings.SplitSeq(fields[1], ",") {
+			from, to, ok := strings.Cut(r, "-")
+			if !ok {
+				return nil, fmt.Errorf("window %q: time range %q needs a start and an end", part, r)
+			}
+			start, err := parseClock(from, false)
+			if err != nil {
+				return nil, fmt.Errorf("window %q: %w", part, err)
+			}
+			end, err := parseClock(to, true)
+			if err != nil {
+				return nil, fmt.Errorf("window %q: %w", part, err)
+			}
+			if start == end%minutesPerDay && end != minutesPerDay {
+				return nil, fmt.Errorf("window %q: time range %q is empty", part, r)
+			}
+			out = append(out, Window{Days: days, Start: start, End: end})
+		}
+	}
+	return out, nil
+}
+
+func parseDays(spec string) ([7]bool, error) {
+	var days [7]bool
+	if strings.EqualFold(spec, "daily") {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	for item := range strings.SplitSeq(spec, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, err := parseDay(from)
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseDay(to); err != nil {
+				return days, err
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseDay(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, name := range dayNames {
+		if s == name || s == strings.ToLower(time.Weekday(i).String()) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", s)
+}
+
+func parseClock(s string, allowMidnightEnd bool) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	h, errH := strconv.Atoi(hh)
+	m, errM := strconv.Atoi(mm)
+	if !ok || errH != nil || errM != nil || len(mm) != 2 || h < 0 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("bad time %q, want HH:MM", s)
+	}
+	if h == 24 && m == 0 && allowMidnightEnd {
+		return minutesPerDay, nil
+	}
+	if h > 23 {
+		return 0, fmt.Errorf("bad time %q, want HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+// String renders the schedule in the form Parse reads, one window per range.
+func (s Schedule) String() string {
+	parts := make([]string, 0, len(s))
+	for _, w := range s {
+		parts = append(parts, formatDays(w.Days)+" "+formatClock(w.Start)+"-"+formatClock(w.End))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatDays writes the days Monday first, folding runs of three or more.
+func formatDays(days [7]bool) string {
+	all := true
+	for _, on := range days {
+		all = all && on
+	}
+	if all {
+		return "daily"
+	}
+	order := [7]int{1, 2, 3, 4, 5, 6, 0}
+	var items []string
+	for i := 0; i < 7; {
+		if !days[order[i]] {
+			i++
+			continue
+		}
+		j := i
+		for j+1 < 7 && days[order[j+1]] {
+			j++
+		}
+		switch {
+		case j-i >= 2:
+			items = append(items, dayNames[order[i]]+"-"+dayNames[order[j]])
+		default:
+			for k := i; k <= j; k++ {
+				items = append(items, dayNames[order[k]])
+			}
+		}
+		i = j + 1
+	}
+	return strings.Join(items, ",")
+}
+
+func formatClock(m int) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+// Allows reports whether t, read in its own location, falls inside a window.
+func (s Schedule) Allows(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+	day := int(t.Weekday())
+	prev := (day + 6) % 7
+	m := t.Hour()*60 + t.Minute()
+	for _, w := range s {
+		if w.End > w.Start {
+			if w.Days[day] && m >= w.Start && m < w.End {
+				return true
+			}
+			continue
+		}
+		// Past midnight: the evening of its own day, the morning after.
+		if (w.Days[day] && m >= w.Start) || (w.Days[prev] && m < w.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the first window start after t, in t's location. ok is
+// false for a schedule without any window.
+func (s Schedule) NextOpen(t time.Time) (next time.Time, ok bool) {
+	for _, w := range s {
+		for offset := 0; offset <= 7; offset++ {
+			day := t.AddDate(0, 0, offset)
+			if !w.Days[day.Weekday()] {
+				continue
+			}
+			start := time.Date(day.Year(), day.Month(), day.Day(), w.Start/60, w.Start%60, 0, 0, t.Location())
+			if start.After(t) {
+				if !ok || start.Before(next) {
+					next, ok = start, true
+				}
+				break
+			}
+		}
+	}
+	return next, ok
+}
@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// 2026-10-12 is a Monday.
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, 12+day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseAndString(t *testing.T) {
+	cases := map[string]string{
+		"":                         "",
+		"mon-fri 09:00-18:00":      "mon-fri 09:00-18:00",
+		"Saturday,sun 10:00-14:00": "sat,sun 10:00-14:00",
+		"daily 22:00-06:00":        "daily 22:00-06:00",
+		"fri-mon 00:00-24:00":      "mon,fri-sun 00:00-24:00",
+		"mon 09:00-12:00,13:00-18:00; tue 08:00-09:00": "mon 09:00-12:00; mon 13:00-18:00; tue 08:00-09:00",
+	}
+	for in, want := range cases {
+		s, err := Parse(in)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", in, err)
+		}
+		if got := s.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+	for _, bad := range []string{"mon", "mon 9-18", "mon 09:00", "xyz 09:00-10:00", "mon 09:00-09:00", "mon 24:00-25:00", "mon 09:60-10:00"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	office, _ := Parse("mon-fri 09:00-18:00")
+	night, _ := Parse("fri 22:00-06:00")
+	cases := []struct {
+		s    Schedule
+		t    time.Time
+		want bool
+	}{
+		{nil, at(0, 3, 0), true},
+		{office, at(0, 9, 0), true},
+		{office, at(0, 17, 59), true},
+		{office, at(0, 18, 0), false},
+		{office, at(5, 12, 0), false}, // Saturday
+		{night, at(4, 23, 0), true},   // Friday evening
+		{night, at(5, 5, 59), true},   // the morning after
+		{night, at(5, 23, 0), false},  // Saturday evening
+		{night, at(4, 5, 0), false},   // Friday morning belongs to Thursday
+	}
+	for i, c := range cases {
+		if got := c.s.Allows(c.t); got != c.want {
+			t.Errorf("case %d: Allows(%v) = %v, want %v", i, c.t, got, c.want)
+		}
+	}
+}
+
+func TestNextOpen(t *testing.T) {
+	office, _ := Parse("mon-fri 09:00-18:00")
+	next, ok := office.NextOpen(at(4, 19, 0)) // Friday evening
+	if !ok || !next.Equal(at(7, 9, 0)) {
+		t.Fatalf("NextOpen = %v, %v, want next Monday 09:00", next, ok)
+	}
+	if _, ok := Schedule(nil).NextOpen(at(0, 0, 0)); ok {
+		t.Fatal("empty schedule has a next opening")
+	}
+}
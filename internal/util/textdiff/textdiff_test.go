@@ -0,0 +1,65 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	lines := func(s ...string) string { return strings.Join(s, "\n") + "\n" }
+	cases := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", lines("a", "b"), lines("a", "b"), ""},
+		{
+			"changed line keeps context",
+			lines("1", "2", "3", "4", "5", "6", "7", "8"),
+			lines("1", "2", "3", "4", "five", "6", "7", "8"),
+			"--- old\n+++ new\n@@ -3,5 +3,5 @@\n 3\n 4\n-5\n+five\n 6\n 7\n",
+		},
+		{
+			"distant changes get separate hunks",
+			lines("a", "1", "2", "3", "4", "5", "6", "b"),
+			lines("A", "1", "2", "3", "4", "5", "6", "B"),
+			"--- old\n+++ new\n@@ -1,3 +1,3 @@\n-a\n+A\n 1\n 2\n@@ -6,3 +6,3 @@\n 5\n 6\n-b\n+B\n",
+		},
+		{
+			"insert into empty",
+			"",
+			lines("x"),
+			"--- old\n+++ new\n@@ -0,0 +1 @@\n+x\n",
+		},
+	}
+	for _, tc := range cases {
+		if got := Unified("old", "new", tc.a, tc.b, 2); got != tc.want {
+			t.Errorf("%s:\n got %q\nwant %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+	edits := 0
+	var gotA, gotB []string
+	for _, o := range diffLines(a, b) {
+		if o.kind != opEqual {
+			edits++
+		}
+		if o.kind != opInsert {
+			gotA = append(gotA, o.line)
+		}
+		if o.kind != opDelete {
+			gotB = append(gotB, o.line)
+		}
+	}
+	if strings.Join(gotA, " ") != strings.Join(a, " ") || strings.Join(gotB, " ") != strings.Join(b, " ") {
+		t.Fatalf("script does not reproduce its inputs: %v / %v", gotA, gotB)
+	}
+	// The classic example from Myers' paper has an edit distance of 5.
+	if edits != 5 {
+		t.Fatalf("want 5 edits, got %d", edits)
+	}
+}
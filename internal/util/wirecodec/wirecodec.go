@@ -20,6 +20,13 @@ const (
 	EncodingZstd = "zstd"
 	// CapZstd is the capability token advertised in CapsHeader.
 	CapZstd = "zstd"
+	// CapTelemetry advertises the node's push telemetry stream.
+	CapTelemetry = "telemetry"
+	// WriteSeqHeader carries a node's "epoch:seq" stamp of an API write; its
+	// traffic frames later report that stamp as settled.
+	WriteSeqHeader = "X-3x-Node-Write"
+	// NodeCaps is the full CapsHeader value a node sends.
+	NodeCaps = CapZstd + "," + CapTelemetry
 
 	// maxDecodeBytes bounds in-memory decompression to defuse a zstd bomb from
 	// an (authenticated) node-API caller.
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/acme"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/middleware"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AcmeController manages certificates issued by the built-in ACME client.
+type AcmeController struct {
+	acmeService service.AcmeService
+}
+
+func NewAcmeController(g *gin.RouterGroup) *AcmeController {
+	a := &AcmeController{}
+	a.initRouter(g)
+	return a
+}
+
+func (a *AcmeController) initRouter(g *gin.RouterGroup) {
+	g.GET("/list", a.list)
+	g.GET("/providers", a.providers)
+
+	g.POST("/issue", a.issue)
+	g.POST("/renew/:id", a.renew)
+	g.POST("/del/:id", a.del)
+}
+
+func (a *AcmeController) list(c *gin.Context) {
+	certs, err := a.acmeService.List()
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.acme.toasts.list"), err)
+		return
+	}
+	jsonObj(c, certs, nil)
+}
+
+func (a *AcmeController) providers(c *gin.Context) {
+	jsonObj(c, acme.DNSProviderNames(), nil)
+}
+
+func (a *AcmeController) issue(c *gin.Context) {
+	req, ok := middleware.BindJSONAndValidate[entity.AcmeIssueRequest](c)
+	if !ok {
+		return
+	}
+	cert, err := a.acmeService.Issue(req)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.acme.toasts.issue"), err)
+		return
+	}
+	jsonMsgObj(c, I18nWeb(c, "pages.settings.acme.toasts.issue"), cert, nil)
+}
+
+func (a *AcmeController) renew(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.acme.toasts.renew"), err)
+		return
+	}
+	jsonMsg(c, I18nWeb(c, "pages.settings.acme.toasts.renew"), a.acmeService.Renew(id))
+}
+
+func (a *AcmeController) del(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.acme.toasts.delete"), err)
+		return
+	}
+	jsonMsg(c, I18nWeb(c, "pages.settings.acme.toasts.delete"), a.acmeService.Delete(id))
+}
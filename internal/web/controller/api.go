@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/ha"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/middleware"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service/panel"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service/tgbot"
@@ -56,6 +57,7 @@ func (a *APIController) checkAPIAuth(c *gin.Context) {
 			}
 			c.Set("api_authed", true)
 			c.Set("api_token_scope", row.Scope)
+			c.Set("api_token_name", row.Name)
 			c.Next()
 			return
 		}
@@ -84,6 +86,7 @@ var monitorScopeAllow = map[string]struct{}{
 	"/server/getXrayVersion":                      {},
 	"/server/getPanelUpdateInfo":                  {},
 	"/nodes/history/:id/:metric/:bucket":          {},
+	"/metrics":                                    {},
 }
 
 // nodeSyncScopeAllow is the node-sync route/method allowlist relative to
@@ -101,6 +104,7 @@ var nodeSyncScopeAllow = map[string]map[string]struct{}{
 	"/server/restartXrayService":   {http.MethodPost: {}},
 	"/server/getWebCertFiles":      {http.MethodGet: {}},
 	"/server/descendants":          {http.MethodGet: {}},
+	"/server/telemetry":            {http.MethodPost: {}},
 	"/clients/resetTraffic/:email": {http.MethodPost: {}},
 	"/inbounds/resetAllTraffics":   {http.MethodPost: {}},
 	"/inbounds/:id/resetTraffic":   {http.MethodPost: {}},
@@ -113,12 +117,20 @@ var nodeSyncScopeAllow = map[string]map[string]struct{}{
 	"/hosts/list":                  {http.MethodGet: {}},
 }
 
-// enforceTokenScope applies explicit allowlists to monitor and node-sync tokens.
-// Admin tokens and session-login users retain their existing behavior.
+// enforceTokenScope applies explicit allowlists to monitor and node-sync tokens
+// and role permissions to session-login users. Admin tokens pass unchanged.
 func (a *APIController) enforceTokenScope(c *gin.Context) {
 	scopeVal, ok := c.Get("api_token_scope")
 	if !ok {
-		c.Next()
+		user := session.GetLoginUser(c)
+		if user == nil || userMayAccess(user, c.Request.Method, relAPIPath(c.FullPath())) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"msg":     "your account is not permitted to access this endpoint",
+		})
 		return
 	}
 	scope, _ := scopeVal.(string)
@@ -153,6 +165,20 @@ func (a *APIController) enforceTokenScope(c *gin.Context) {
 	deny()
 }
 
+// rejectOnStandby: the standby's jobs and Xray are stopped, so a write there
+// would never apply. Reads and dry runs of dryRunRoutes pass.
+func rejectOnStandby(c *gin.Context) {
+	if !ha.IsStandby() || isPreview(c) ||
+		routeAccessLevel(c.Request.Method, relAPIPath(c.FullPath())) == model.AccessRead {
+		c.Next()
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+		"success": false,
+		"msg":     "this panel is the HA standby and is read-only; make changes on the leader",
+	})
+}
+
 func relAPIPath(fullPath string) string {
 	const marker = "/panel/api"
 	i := strings.Index(fullPath, marker)
@@ -168,10 +194,14 @@ func (a *APIController) initRouter(g *gin.RouterGroup) {
 	api := g.Group("/panel/api")
 	api.Use(a.checkAPIAuth)
 	api.Use(a.enforceTokenScope)
+	api.Use(rejectUnsupportedDryRun)
+	api.Use(rejectOnStandby)
 	// Decode + verify the node config envelope (zstd + X-Config-Sha256) and
 	// advertise support, before CSRF/handlers read the body.
 	api.Use(middleware.ConfigEnvelopeMiddleware())
 	api.Use(middleware.CSRFMiddleware())
+	api.Use(stampLocalWrite)
+	api.Use(auditTrail)
 
 	api.GET("/openapi.json", ServeOpenAPISpec)
 
@@ -182,11 +212,16 @@ func (a *APIController) initRouter(g *gin.RouterGroup) {
 	clients := api.Group("/clients")
 	NewClientController(clients)
 	NewGroupController(clients)
+	NewPlanController(clients)
+	NewVoucherController(clients)
 
 	// Server API
 	server := api.Group("/server")
 	a.serverController = NewServerController(server)
 
+	// Prometheus/OpenMetrics scrape target, readable by monitor-scope tokens
+	api.GET("/metrics", a.serverController.metrics)
+
 	// Nodes API — multi-panel management
 	nodes := api.Group("/nodes")
 	a.nodeController = NewNodeController(nodes)
@@ -195,6 +230,27 @@ func (a *APIController) initRouter(g *gin.RouterGroup) {
 	hosts := api.Group("/hosts")
 	a.hostController = NewHostController(hosts)
 
+	// ACME API — certificates for the panel, subscription and inbound TLS
+	NewAcmeController(api.Group("/acme"))
+
+	// Panel accounts — owners manage them, everyone reads /users/me
+	NewUserController(api.Group("/users"))
+
+	// Audit log of state-changing calls, owners only
+	NewAuditController(api.Group("/audit"))
+
+	// Webhooks — event bus notifications over signed HTTP POSTs
+	NewWebhookController(api.Group("/webhooks"))
+
+	// Scheduled backups to local disk and S3-compatible storage, owners only
+	NewBackupController(api.Group("/backups"))
+
+	// Declarative state export and apply, owners only
+	NewStateController(api.Group("/state"))
+
+	// Importing users and inbounds from other panels, owners only
+	NewPanelImportController(api.Group("/importer"))
+
 	// Settings + Xray config management live under the API surface too, so the
 	// same API token drives them. Paths are /panel/api/setting/* and
 	// /panel/api/xray/*.
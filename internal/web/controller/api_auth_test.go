@@ -154,6 +154,7 @@ func TestNodeSyncScopeAllowlistMatchesRemoteInventory(t *testing.T) {
 		"/server/restartXrayService":   {http.MethodPost: {}},
 		"/server/getWebCertFiles":      {http.MethodGet: {}},
 		"/server/descendants":          {http.MethodGet: {}},
+		"/server/telemetry":            {http.MethodPost: {}},
 		"/clients/resetTraffic/:email": {http.MethodPost: {}},
 		"/inbounds/resetAllTraffics":   {http.MethodPost: {}},
 		"/inbounds/:id/resetTraffic":   {http.MethodPost: {}},
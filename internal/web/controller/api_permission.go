@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+// permOwnerOnly marks routes no role preset or override can reach: account
+// management, API tokens (which act as the primary owner) and raw database I/O.
+const permOwnerOnly = "owner"
+
+// permOpen marks routes every signed-in account may call: its own profile
+// and the read-only dashboard/generator data the whole SPA depends on.
+const permOpen = "open"
+
+// routePermGroupPrefixes maps a route prefix relative to /panel/api to the
+// permission group that guards it. Longest match wins.
+var routePermGroupPrefixes = []struct {
+	prefix string
+	group  string
+}{
+	{"/inbounds/", model.PermInbounds},
+	{"/hosts/", model.PermInbounds},
+	{"/clients/", model.PermClients},
+	{"/nodes/", model.PermNodes},
+	{"/setting/apiTokens", permOwnerOnly},
+	{"/setting/", model.PermSettings},
+	{"/acme/", model.PermSettings},
+	{"/webhooks/", model.PermSettings},
+	{"/xray/", model.PermXray},
+	{"/users/", permOwnerOnly},
+	{"/audit/", permOwnerOnly},
+	{"/backups/", permOwnerOnly},
+	{"/state/", permOwnerOnly},
+	{"/importer/", permOwnerOnly},
+}
+
+// routePermGroupExact overrides the prefix table for individual routes.
+var routePermGroupExact = map[string]string{
+	"/openapi.json":                               permOpen,
+	"/users/me":                                   permOpen,
+	"/users/me/twoFactor":                         permOpen,
+	"/setting/updateUser":                         permOpen,
+	"/setting/defaultSettings":                    permOpen,
+	"/backuptotgbot":                              model.PermSettings,
+	"/metrics":                                    model.PermSettings,
+	"/server/status":                              permOpen,
+	"/server/cpuHistory/:bucket":                  permOpen,
+	"/server/history/:metric/:bucket":             permOpen,
+	"/server/xrayMetricsState":                    permOpen,
+	"/server/xrayMetricsHistory/:metric/:bucket":  permOpen,
+	"/server/xrayObservatory":                     permOpen,
+	"/server/xrayObservatoryHistory/:tag/:bucket": permOpen,
+	"/server/getXrayVersion":                      permOpen,
+	"/server/getPanelUpdateInfo":                  permOpen,
+	"/server/getUpdateStatus":                     permOpen,
+	"/server/haStatus":                            permOpen,
+	"/server/getNewUUID":                          permOpen,
+	"/server/getNewX25519Cert":                    permOpen,
+	"/server/getNewmldsa65":                       permOpen,
+	"/server/getNewmlkem768":                      permOpen,
+	"/server/getNewVlessEnc":                      permOpen,
+	"/server/getNewEchCert":                       permOpen,
+	"/server/descendants":                         permOpen,
+	"/server/fail2banStatus":                      permOpen,
+	"/server/getConfigJson":                       model.PermXray,
+	"/server/stopXrayService":                     model.PermXray,
+	"/server/restartXrayService":                  model.PermXray,
+	"/server/installXray/:version":                model.PermXray,
+	"/server/updateGeofile":                       model.PermXray,
+	"/server/updateGeofile/:fileName":             model.PermXray,
+	"/server/logs/:count":                         model.PermXray,
+	"/server/xraylogs/:count":                     model.PermXray,
+	"/server/getCertHash":                         model.PermInbounds,
+	"/server/getRemoteCertHash":                   model.PermInbounds,
+	"/server/scanRealityTarget":                   model.PermInbounds,
+	"/server/scanRealityTargets":                  model.PermInbounds,
+	"/server/clientIps":                           model.PermClients,
+	"/server/getWebCertFiles":                     model.PermSettings,
+	"/server/updatePanel":                         model.PermSettings,
+	"/server/setUpdateChannel":                    model.PermSettings,
+	"/server/getDb":                               permOwnerOnly,
+	"/server/getMigration":                        permOwnerOnly,
+	"/server/importDB":                            permOwnerOnly,
+}
+
+// readOnlyPostRoutes are POST handlers that only fetch or preview data, so
+// read access is enough to call them.
+var readOnlyPostRoutes = map[string]struct{}{
+	"/setting/all":               {},
+	"/setting/defaultSettings":   {},
+	"/setting/factoryDefaults":   {},
+	"/setting/validateRegex":     {},
+	"/xray/":                     {},
+	"/xray/balancerStatus":       {},
+	"/xray/routeTest":            {},
+	"/xray/testOutbound":         {},
+	"/xray/testOutbounds":        {},
+	"/xray/geodata/validate":     {},
+	"/xray/outbound-subs/parse":  {},
+	"/clients/ips/:email":        {},
+	"/clients/hwids/:email":      {},
+	"/clients/onlines":           {},
+	"/clients/onlinesByGuid":     {},
+	"/clients/clientIpsByGuid":   {},
+	"/clients/activeInbounds":    {},
+	"/clients/lastOnline":        {},
+	"/nodes/test":                {},
+	"/nodes/certFingerprint":     {},
+	"/nodes/inbounds":            {},
+	"/nodes/probe/:id":           {},
+	"/server/logs/:count":        {},
+	"/server/xraylogs/:count":    {},
+	"/server/getNewEchCert":      {},
+	"/server/getCertHash":        {},
+	"/server/getRemoteCertHash":  {},
+	"/server/scanRealityTarget":  {},
+	"/server/scanRealityTargets": {},
+}
+
+// resellerBlockedPrefixes reach past a reseller's tenancy or, like traffic
+// resets, past MaxTrafficGB, which caps only allocated totals.
+var resellerBlockedPrefixes = []string{
+	"/clients/groups",
+	"/clients/plans/add",
+	"/clients/plans/update",
+	"/clients/plans/del",
+	"/clients/vouchers",
+	"/clients/delOrphans",
+	"/clients/resetAllTraffics",
+	"/clients/delDepleted",
+	"/clients/resetTraffic/",
+	"/clients/updateTraffic/",
+	"/clients/bulkResetTraffic",
+	"/clients/onlinesByGuid",
+	"/clients/clientIpsByGuid",
+	"/clients/activeInbounds",
+	"/inbounds/allLinks",
+	"/inbounds/:id/fallbacks",
+	"/server/clientIps",
+	"/metrics",
+}
+
+// resellerMayAccess keeps a reseller inside its tenancy: client handlers scope
+// what it sees, and everything that cannot be scoped is refused here.
+func resellerMayAccess(method, rel string) bool {
+	for _, p := range resellerBlockedPrefixes {
+		if strings.HasPrefix(rel, p) {
+			return false
+		}
+	}
+	if routePermGroup(rel) == model.PermInbounds {
+		return routeAccessLevel(method, rel) == model.AccessRead
+	}
+	return true
+}
+
+// routePermGroup classifies a route pattern relative to /panel/api. An empty
+// result means the route is unclassified and only owners may call it.
+func routePermGroup(rel string) string {
+	if group, ok := routePermGroupExact[rel]; ok {
+		return group
+	}
+	best, group := 0, ""
+	for _, p := range routePermGroupPrefixes {
+		if strings.HasPrefix(rel, p.prefix) && len(p.prefix) > best {
+			best, group = len(p.prefix), p.group
+		}
+	}
+	return group
+}
+
+// routeAccessLevel is the level a request needs on its route group.
+func routeAccessLevel(method, rel string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return model.AccessRead
+	}
+	if _, ok := readOnlyPostRoutes[rel]; ok && method == http.MethodPost {
+		return model.AccessRead
+	}
+	return model.AccessWrite
+}
+
+// userMayAccess reports whether a signed-in account may call the route.
+func userMayAccess(user *model.User, method, rel string) bool {
+	if user.IsOwner() {
+		return true
+	}
+	if user.Role == model.UserRoleReseller && !resellerMayAccess(method, rel) {
+		return false
+	}
+	switch group := routePermGroup(rel); group {
+	case permOpen:
+		return true
+	case permOwnerOnly, "":
+		return false
+	default:
+		return user.Can(group, routeAccessLevel(method, rel))
+	}
+}
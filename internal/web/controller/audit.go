@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys a handler's response and annotations leave for auditTrail.
+const (
+	auditTargetKey  = "audit_target"
+	auditSuccessKey = "audit_success"
+	auditErrorKey   = "audit_error"
+)
+
+// auditTargetPrefixes maps a route prefix relative to /panel/api to the entity
+// it changes and the path parameter naming it. Longest match wins.
+var auditTargetPrefixes = []struct {
+	prefix string
+	target string
+	param  string
+}{
+	{"/inbounds/", service.AuditTargetInbound, "id"},
+	{"/clients/", service.AuditTargetClient, "email"},
+	{"/clients/groups/", "group", ""},
+	{"/clients/plans/", service.AuditTargetPlan, "id"},
+	{"/clients/vouchers/", service.AuditTargetVoucher, "id"},
+	{"/hosts/", service.AuditTargetHost, "groupId"},
+	{"/nodes/", service.AuditTargetNode, "id"},
+	{"/setting/", service.AuditTargetSetting, ""},
+	{"/setting/apiTokens/", "apiToken", "id"},
+	{"/xray/", service.AuditTargetXray, ""},
+	{"/acme/", "certificate", "id"},
+	{"/webhooks/", service.AuditTargetWebhook, "id"},
+	{"/webhooks/deliveries/", "webhookDelivery", "id"},
+	{"/users/", "user", "id"},
+	{"/backups/", "backup", ""},
+	{"/server/", "server", ""},
+}
+
+// auditSingletonRoutes rewrite a whole singleton target, so they are diffed
+// without an id. Other setting and xray routes are recorded without a diff.
+var auditSingletonRoutes = map[string]struct{}{
+	"/setting/update": {},
+	"/xray/update":    {},
+}
+
+// auditSkipRoutes change state but are node-sync traffic or connectivity
+// tests rather than operator actions.
+var auditSkipRoutes = map[string]struct{}{
+	"/inbounds/pushClientTraffics": {},
+	"/server/clientIps":            {},
+	"/server/getNewEchCert":        {},
+	"/setting/testSmtp":            {},
+	"/setting/testTgBot":           {},
+	"/webhooks/test/:id":           {},
+	"/backuptotgbot":               {},
+}
+
+func auditTargetFor(rel string) (target, param string) {
+	best := 0
+	for _, p := range auditTargetPrefixes {
+		if strings.HasPrefix(rel, p.prefix) && len(p.prefix) > best {
+			best, target, param = len(p.prefix), p.target, p.param
+		}
+	}
+	return target, param
+}
+
+func auditedRoute(method, rel string) bool {
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions || rel == "" {
+		return false
+	}
+	if _, skip := auditSkipRoutes[rel]; skip {
+		return false
+	}
+	_, readOnly := readOnlyPostRoutes[rel]
+	return !readOnly
+}
+
+// setAuditTarget names the entity a create handler produced, so the audit
+// entry can diff it even though the route carries no id.
+func setAuditTarget(c *gin.Context, id string) {
+	c.Set(auditTargetKey, id)
+}
+
+func auditActor(c *gin.Context) (string, string) {
+	if c.GetBool("api_authed") {
+		if name := c.GetString("api_token_name"); name != "" {
+			return name, service.AuditActorToken
+		}
+		if tls := c.Request.TLS; tls != nil && len(tls.VerifiedChains) > 0 && len(tls.VerifiedChains[0]) > 0 {
+			return tls.VerifiedChains[0][0].Subject.CommonName, service.AuditActorMTLS
+		}
+	}
+	if user := session.GetLoginUser(c); user != nil {
+		return user.Username, service.AuditActorUser
+	}
+	return "", ""
+}
+
+// auditTrail records every state-changing call that passed authentication,
+// with a before/after diff of the entity when one can be loaded.
+func auditTrail(c *gin.Context) {
+	rel := relAPIPath(c.FullPath())
+	// A dry run of a route that implements one changes nothing, so there is
+	// nothing to record.
+	if !auditedRoute(c.Request.Method, rel) || isPreview(c) {
+		c.Next()
+		return
+	}
+	auditService := service.AuditService{}
+	target, param := auditTargetFor(rel)
+	targetId := ""
+	if param != "" {
+		targetId = c.Param(param)
+	}
+	_, singleton := auditSingletonRoutes[rel]
+	snapshot := singleton || targetId != ""
+	var before any
+	if snapshot {
+		before = auditService.Snapshot(target, targetId)
+	}
+
+	c.Next()
+
+	if id := c.GetString(auditTargetKey); id != "" {
+		targetId = id
+		snapshot = true
+	}
+	var after any
+	if snapshot {
+		after = auditService.Snapshot(target, targetId)
+	}
+	success := c.Writer.Status() < http.StatusBadRequest
+	if v, ok := c.Get(auditSuccessKey); ok {
+		success, _ = v.(bool)
+	}
+	actor, actorType := auditActor(c)
+	err := auditService.Record(&service.AuditEntry{
+		Actor:      actor,
+		ActorType:  actorType,
+		SourceIP:   getRemoteIp(c),
+		Method:     c.Request.Method,
+		Route:      rel,
+		TargetType: target,
+		TargetId:   targetId,
+		Success:    success,
+		Error:      c.GetString(auditErrorKey),
+		Before:     before,
+		After:      after,
+	})
+	if err != nil {
+		logger.Warning("audit log: record", rel, "failed:", err)
+	}
+}
+
+// AuditController serves the audit log to panel owners.
+type AuditController struct {
+	auditService service.AuditService
+}
+
+func NewAuditController(g *gin.RouterGroup) *AuditController {
+	a := &AuditController{}
+	a.initRouter(g)
+	return a
+}
+
+func (a *AuditController) initRouter(g *gin.RouterGroup) {
+	g.GET("/list", a.list)
+	g.GET("/export", a.export)
+}
+
+func (a *AuditController) list(c *gin.Context) {
+	var params service.AuditPageParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	page, err := a.auditService.List(params)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, page, nil)
+}
+
+// export downloads the filtered log as CSV. A failure after the header is
+// written can only truncate the file, so it is logged rather than reported.
+func (a *AuditController) export(c *gin.Context) {
+	var params service.AuditPageParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	filename := "audit-" + time.Now().UTC().Format("20060102-150405") + ".csv"
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	if err := a.auditService.WriteCSV(c.Writer, params); err != nil {
+		logger.Warning("audit log: export failed:", err)
+	}
+}
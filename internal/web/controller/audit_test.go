@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service/panel"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/session"
+)
+
+func TestAuditTrailRecordsInboundChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbDir := t.TempDir()
+	t.Setenv("XUI_DB_FOLDER", dbDir)
+	if err := database.InitDB(filepath.Join(dbDir, "x-ui.db")); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseDB() })
+	db := database.GetDB()
+	inbound := &model.Inbound{UserId: 1, Tag: "in-a", Remark: "before", Port: 40001, Protocol: model.VLESS, Settings: `{"clients":[]}`}
+	if err := db.Create(inbound).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	engine := gin.New()
+	engine.Use(sessions.Sessions("3x-ui", cookie.NewStore([]byte("audit-test-secret"))))
+	engine.GET("/test-login/:id", func(c *gin.Context) {
+		u, err := (&panel.UserService{}).GetFirstUser()
+		if err != nil || session.SetLoginUser(c, u) != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	a := &APIController{}
+	api := engine.Group("/panel/api")
+	api.Use(a.checkAPIAuth)
+	api.Use(auditTrail)
+	api.POST("/inbounds/update/:id", func(c *gin.Context) {
+		err := db.Model(&model.Inbound{}).Where("id = ?", c.Param("id")).Update("remark", "after").Error
+		jsonMsg(c, "", err)
+	})
+	api.POST("/inbounds/:id/resetTraffic", func(c *gin.Context) {
+		jsonMsg(c, "", errors.New("reset failed"))
+	})
+	api.POST("/clients/onlines", func(c *gin.Context) { jsonObj(c, []string{}, nil) })
+
+	ts := httptest.NewServer(engine)
+	t.Cleanup(ts.Close)
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar}
+	loginAs(t, ts, client, 1)
+
+	callAPI(t, ts, client, http.MethodPost, "/panel/api/inbounds/update/1", "")
+	callAPI(t, ts, client, http.MethodPost, "/panel/api/inbounds/1/resetTraffic", "")
+	callAPI(t, ts, client, http.MethodPost, "/panel/api/clients/onlines", "")
+
+	var rows []model.AuditLog
+	if err := db.Order("id asc").Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("audit rows = %d, want the two mutations and not the read-only POST", len(rows))
+	}
+	got := rows[0]
+	if got.Actor != "admin" || got.ActorType != "user" || got.Route != "/inbounds/update/:id" ||
+		got.TargetType != "inbound" || got.TargetId != "1" || !got.Success {
+		t.Fatalf("update row = %+v", got)
+	}
+	if c := got.Changes["remark"]; c.Before != "before" || c.After != "after" || len(got.Changes) != 1 {
+		t.Fatalf("update changes = %+v, want only the remark", got.Changes)
+	}
+	if rows[1].Success || rows[1].Error == "" || len(rows[1].Changes) != 0 {
+		t.Fatalf("failed call row = %+v, want it recorded as a failure without changes", rows[1])
+	}
+}
+
+// Only routes that implement ?dryRun=1 may skip the audit trail with it; on any
+// other route the flag is refused before the handler applies the change.
+func TestDryRunOnlySkipsAuditOnPreviewRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbDir := t.TempDir()
+	t.Setenv("XUI_DB_FOLDER", dbDir)
+	if err := database.InitDB(filepath.Join(dbDir, "x-ui.db")); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseDB() })
+	db := database.GetDB()
+
+	engine := gin.New()
+	engine.Use(sessions.Sessions("3x-ui", cookie.NewStore([]byte("audit-test-secret"))))
+	api := engine.Group("/panel/api")
+	api.Use(rejectUnsupportedDryRun)
+	api.Use(auditTrail)
+	applied := 0
+	handler := func(c *gin.Context) {
+		if !isDryRun(c) {
+			applied++
+		}
+		jsonMsg(c, "", nil)
+	}
+	api.POST("/inbounds/update/:id", handler)
+	api.POST("/clients/bulkResetTraffic", func(c *gin.Context) {
+		applied++
+		jsonMsg(c, "", nil)
+	})
+	ts := httptest.NewServer(engine)
+	t.Cleanup(ts.Close)
+
+	if code, _ := callAPI(t, ts, ts.Client(), http.MethodPost, "/panel/api/inbounds/update/1?dryRun=1", ""); code != http.StatusOK {
+		t.Fatalf("preview route with dryRun: status %d", code)
+	}
+	if code, _ := callAPI(t, ts, ts.Client(), http.MethodPost, "/panel/api/clients/bulkResetTraffic?dryRun=1", ""); code != http.StatusBadRequest {
+		t.Fatalf("dryRun on a route that ignores it: status %d, want 400", code)
+	}
+	callAPI(t, ts, ts.Client(), http.MethodPost, "/panel/api/clients/bulkResetTraffic", "")
+
+	var rows []model.AuditLog
+	if err := db.Order("id asc").Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if applied != 1 || len(rows) != 1 || rows[0].Route != "/clients/bulkResetTraffic" {
+		t.Fatalf("applied=%d rows=%+v, want only the real bulkResetTraffic applied and audited", applied, rows)
+	}
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/middleware"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BackupController lists, runs and restores scheduled database backups.
+type BackupController struct {
+	backupService service.BackupService
+}
+
+func NewBackupController(g *gin.RouterGroup) *BackupController {
+	a := &BackupController{}
+	a.initRouter(g)
+	return a
+}
+
+func (a *BackupController) initRouter(g *gin.RouterGroup) {
+	g.GET("/list", a.list)
+
+	g.POST("/run", a.run)
+	g.POST("/restore", a.restore)
+}
+
+func (a *BackupController) list(c *gin.Context) {
+	backups, err := a.backupService.List()
+	if err != nil {
+		// An unreachable target must not hide the backups the others hold.
+		jsonMsgObj(c, I18nWeb(c, "pages.settings.backups.toasts.list"), backups, err)
+		return
+	}
+	jsonObj(c, backups, nil)
+}
+
+func (a *BackupController) run(c *gin.Context) {
+	written, err := a.backupService.Run()
+	jsonMsgObj(c, I18nWeb(c, "pages.settings.backups.toasts.run"), written, err)
+}
+
+func (a *BackupController) restore(c *gin.Context) {
+	req, ok := middleware.BindJSONAndValidate[entity.BackupRestoreRequest](c)
+	if !ok {
+		return
+	}
+	setAuditTarget(c, req.Name)
+	// Like importDB, keep this machine's addresses and certificates unless the
+	// caller asks to clone the backed-up machine wholesale.
+	keepHostSettings := req.KeepHostSettings == nil || *req.KeepHostSettings
+	if err := a.backupService.Restore(req.Target, req.Name, keepHostSettings); err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.index.importDatabaseError"), err)
+		return
+	}
+	jsonObj(c, I18nWeb(c, "pages.index.importDatabaseSuccess"), nil)
+}
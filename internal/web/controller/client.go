@@ -2,11 +2,13 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/session"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/websocket"
 
 	"github.com/gin-gonic/gin"
@@ -31,11 +33,17 @@ func parseInboundIdsQuery(raw string) []int {
 	return ids
 }
 
+// clientScope confines a reseller to its own clients; nil for every other account.
+func clientScope(c *gin.Context) *service.ClientScope {
+	return service.ClientScopeFor(session.GetLoginUser(c))
+}
+
 type ClientController struct {
-	clientService  service.ClientService
-	inboundService service.InboundService
-	xrayService    service.XrayService
-	settingService service.SettingService
+	clientService         service.ClientService
+	inboundService        service.InboundService
+	xrayService           service.XrayService
+	settingService        service.SettingService
+	trafficHistoryService service.TrafficHistoryService
 }
 
 func NewClientController(g *gin.RouterGroup) *ClientController {
@@ -50,6 +58,7 @@ func (a *ClientController) initRouter(g *gin.RouterGroup) {
 	g.GET("/get/:email", a.get)
 	g.GET("/get/tgId/:tgId", a.getByTgId)
 	g.GET("/traffic/:email", a.getTrafficByEmail)
+	g.GET("/history/:email/:bucket", a.getTrafficHistory)
 	g.GET("/subLinks/:subId", a.getSubLinks)
 	g.GET("/links/:email", a.getClientLinks)
 
@@ -87,7 +96,7 @@ func (a *ClientController) initRouter(g *gin.RouterGroup) {
 }
 
 func (a *ClientController) list(c *gin.Context) {
-	rows, err := a.clientService.List()
+	rows, err := a.clientService.List(clientScope(c).Owner())
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
 		return
@@ -101,6 +110,7 @@ func (a *ClientController) listPaged(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
 		return
 	}
+	params.OwnerId = clientScope(c).Owner()
 	resp, err := a.clientService.ListPaged(&a.inboundService, &a.settingService, params)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
@@ -137,6 +147,10 @@ func (a *ClientController) buildClientPayload(rec *model.ClientRecord) (gin.H, e
 
 func (a *ClientController) get(c *gin.Context) {
 	email := c.Param("email")
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
+		return
+	}
 	rec, err := a.clientService.GetRecordByEmail(nil, email)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
@@ -162,8 +176,12 @@ func (a *ClientController) getByTgId(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
 		return
 	}
+	owner := clientScope(c).Owner()
 	results := make([]gin.H, 0, len(records))
 	for _, rec := range records {
+		if owner > 0 && rec.OwnerId != owner {
+			continue
+		}
 		payload, err := a.buildClientPayload(rec)
 		if err != nil {
 			jsonMsg(c, I18nWeb(c, "get"), err)
@@ -180,11 +198,23 @@ func (a *ClientController) create(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	scope := clientScope(c)
+	if err := a.clientService.CheckCreate(scope, []service.ClientCreatePayload{payload}); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	payload.Scope = scope
+	if isDryRun(c) {
+		preview, err := a.clientService.PreviewCreate(&a.inboundService, &payload)
+		jsonObj(c, preview, err)
+		return
+	}
 	needRestart, err := a.clientService.Create(&a.inboundService, &payload)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	setAuditTarget(c, payload.Client.Email)
 	jsonMsgObj(c, I18nWeb(c, "pages.inbounds.toasts.inboundClientAddSuccess"), pendingNodeObj(a.inboundService.AnyNodePending(payload.InboundIds)), nil)
 	if needRestart {
 		a.xrayService.SetToNeedRestart()
@@ -202,12 +232,25 @@ func (a *ClientController) update(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if err := a.clientService.CheckUpdate(clientScope(c), email, req.Client); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
 	inboundFilter := parseInboundIdsQuery(c.Query("inboundIds"))
-	needRestart, err := a.clientService.UpdateByEmail(&a.inboundService, email, req.Client, req.LimitHwid, inboundFilter...)
+	if isDryRun(c) {
+		preview, err := a.clientService.PreviewUpdateByEmail(&a.inboundService, email, req.Client, inboundFilter...)
+		jsonObj(c, preview, err)
+		return
+	}
+	needRestart, err := a.clientService.UpdateByEmail(&a.inboundService, clientScope(c), email, req.Client, req.LimitHwid, inboundFilter...)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if req.Email != "" {
+		// A rename moves the record; diff against it under the new email.
+		setAuditTarget(c, req.Email)
+	}
 	jsonMsgObj(c, I18nWeb(c, "pages.inbounds.toasts.inboundClientUpdateSuccess"), pendingNodeObj(a.clientService.HasPendingNode(&a.inboundService, email)), nil)
 	if needRestart {
 		a.xrayService.SetToNeedRestart()
@@ -218,6 +261,15 @@ func (a *ClientController) update(c *gin.Context) {
 func (a *ClientController) delete(c *gin.Context) {
 	email := c.Param("email")
 	keepTraffic := c.Query("keepTraffic") == "1"
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if isDryRun(c) {
+		preview, err := a.clientService.PreviewDeleteByEmail(email)
+		jsonObj(c, preview, err)
+		return
+	}
 	needRestart, err := a.clientService.DeleteByEmail(&a.inboundService, email, keepTraffic)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -245,6 +297,15 @@ func (a *ClientController) attach(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	scope := clientScope(c)
+	if err := a.clientService.CheckOwned(scope, email); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if err := a.clientService.CheckInbounds(scope, body.InboundIds); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
 	needRestart, err := a.clientService.AttachByEmail(&a.inboundService, email, body.InboundIds)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -264,6 +325,10 @@ func (a *ClientController) setExternalLinks(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
 	if err := a.clientService.SetExternalLinksByEmail(email, body.ExternalLinks); err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
@@ -298,7 +363,16 @@ func (a *ClientController) bulkAdjust(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
-	result, needRestart, err := a.clientService.BulkAdjust(&a.inboundService, req.Emails, req.AddDays, req.AddBytes, req.Flow)
+	if err := a.clientService.CheckAdjust(clientScope(c), req.Emails, req.AddDays, req.AddBytes); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if isDryRun(c) {
+		preview, err := a.clientService.PreviewBulkAdjust(&a.inboundService, req.Emails, req.AddDays, req.AddBytes, req.Flow)
+		jsonObj(c, preview, err)
+		return
+	}
+	result, needRestart, err := a.clientService.BulkAdjust(&a.inboundService, clientScope(c), req.Emails, req.AddDays, req.AddBytes, req.Flow)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
@@ -326,6 +400,20 @@ func (a *ClientController) bulkAttach(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	scope := clientScope(c)
+	if err := a.clientService.CheckOwned(scope, req.Emails...); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if err := a.clientService.CheckInbounds(scope, req.InboundIds); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if isDryRun(c) {
+		preview, err := a.clientService.PreviewBulkAttach(&a.inboundService, req.Emails, req.InboundIds)
+		jsonObj(c, preview, err)
+		return
+	}
 	result, needRestart, err := a.clientService.BulkAttach(&a.inboundService, req.Emails, req.InboundIds)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -349,6 +437,15 @@ func (a *ClientController) bulkDetach(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if err := a.clientService.CheckOwned(clientScope(c), req.Emails...); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if isDryRun(c) {
+		preview, err := a.clientService.PreviewBulkDetach(req.Emails, req.InboundIds)
+		jsonObj(c, preview, err)
+		return
+	}
 	result, needRestart, err := a.clientService.BulkDetach(&a.inboundService, req.Emails, req.InboundIds)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -367,6 +464,15 @@ func (a *ClientController) bulkDelete(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if err := a.clientService.CheckOwned(clientScope(c), req.Emails...); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if isDryRun(c) {
+		preview, err := a.clientService.PreviewBulkDelete(req.Emails)
+		jsonObj(c, preview, err)
+		return
+	}
 	result, needRestart, err := a.clientService.BulkDelete(&a.inboundService, req.Emails, req.KeepTraffic)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -397,6 +503,15 @@ func (a *ClientController) bulkSetEnable(c *gin.Context, enable bool) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if err := a.clientService.CheckOwned(clientScope(c), req.Emails...); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if isDryRun(c) {
+		preview, err := a.clientService.PreviewBulkSetEnable(req.Emails, enable)
+		jsonObj(c, preview, err)
+		return
+	}
 	result, needRestart, err := a.clientService.BulkSetEnable(&a.inboundService, req.Emails, enable)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -415,6 +530,19 @@ func (a *ClientController) bulkCreate(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	scope := clientScope(c)
+	if err := a.clientService.CheckCreate(scope, payloads); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	for i := range payloads {
+		payloads[i].Scope = scope
+	}
+	if isDryRun(c) {
+		preview, err := a.clientService.PreviewBulkCreate(&a.inboundService, payloads)
+		jsonObj(c, preview, err)
+		return
+	}
 	result, needRestart, err := a.clientService.BulkCreate(&a.inboundService, payloads)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -444,7 +572,7 @@ func (a *ClientController) delDepleted(c *gin.Context) {
 // envelope. The frontend renders it in a read-only CodeMirror viewer (Copy /
 // Download), so this hands back data rather than streaming a file attachment.
 func (a *ClientController) export(c *gin.Context) {
-	items, err := a.clientService.ExportAll()
+	items, err := a.clientService.ExportAll(clientScope(c).Owner())
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
@@ -470,6 +598,14 @@ func (a *ClientController) importClients(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	scope := clientScope(c)
+	if err := a.clientService.CheckCreate(scope, items); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	for i := range items {
+		items[i].Scope = scope
+	}
 	result, needRestart, err := a.clientService.ImportClients(&a.inboundService, items)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -494,6 +630,10 @@ func (a *ClientController) delOrphans(c *gin.Context) {
 
 func (a *ClientController) resetTrafficByEmail(c *gin.Context) {
 	email := c.Param("email")
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
 	needRestart, err := a.clientService.ResetTrafficByEmail(&a.inboundService, email)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -518,6 +658,10 @@ func (a *ClientController) updateTrafficByEmail(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
 	if err := a.inboundService.UpdateClientTrafficByEmail(email, req.Upload, req.Download); err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
@@ -528,6 +672,10 @@ func (a *ClientController) updateTrafficByEmail(c *gin.Context) {
 
 func (a *ClientController) getIps(c *gin.Context) {
 	email := c.Param("email")
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonObj(c, nil, err)
+		return
+	}
 	infos, err := a.inboundService.GetClientIpsWithNodes(email)
 	jsonObj(c, infos, err)
 }
@@ -539,6 +687,10 @@ func (a *ClientController) clientIpsByGuid(c *gin.Context) {
 
 func (a *ClientController) clearIps(c *gin.Context) {
 	email := c.Param("email")
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.updateSuccess"), err)
+		return
+	}
 	if err := a.inboundService.ClearClientIps(email); err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.updateSuccess"), err)
 		return
@@ -547,11 +699,19 @@ func (a *ClientController) clearIps(c *gin.Context) {
 }
 
 func (a *ClientController) getHwids(c *gin.Context) {
+	if err := a.clientService.CheckOwned(clientScope(c), c.Param("email")); err != nil {
+		jsonObj(c, nil, err)
+		return
+	}
 	infos, err := a.clientService.ListClientHwids(c.Param("email"))
 	jsonObj(c, infos, err)
 }
 
 func (a *ClientController) clearHwids(c *gin.Context) {
+	if err := a.clientService.CheckOwned(clientScope(c), c.Param("email")); err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.updateSuccess"), err)
+		return
+	}
 	if err := a.clientService.ClearClientHwids(c.Param("email")); err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.updateSuccess"), err)
 		return
@@ -565,6 +725,10 @@ func (a *ClientController) deleteHwid(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if err := a.clientService.CheckOwned(clientScope(c), c.Param("email")); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
 	if err := a.clientService.DeleteClientHwid(c.Param("email"), id); err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
@@ -573,7 +737,19 @@ func (a *ClientController) deleteHwid(c *gin.Context) {
 }
 
 func (a *ClientController) onlines(c *gin.Context) {
-	jsonObj(c, a.inboundService.GetOnlineClients(), nil)
+	onlines := a.inboundService.GetOnlineClients()
+	owned, err := a.clientService.OwnedEmails(clientScope(c))
+	if err != nil || owned == nil {
+		jsonObj(c, onlines, err)
+		return
+	}
+	mine := make([]string, 0, len(onlines))
+	for _, email := range onlines {
+		if _, ok := owned[email]; ok {
+			mine = append(mine, email)
+		}
+	}
+	jsonObj(c, mine, nil)
 }
 
 func (a *ClientController) onlinesByGuid(c *gin.Context) {
@@ -586,11 +762,27 @@ func (a *ClientController) activeInbounds(c *gin.Context) {
 
 func (a *ClientController) lastOnline(c *gin.Context) {
 	data, err := a.inboundService.GetClientsLastOnline()
+	if err != nil {
+		jsonObj(c, nil, err)
+		return
+	}
+	owned, err := a.clientService.OwnedEmails(clientScope(c))
+	if owned != nil {
+		for email := range data {
+			if _, ok := owned[email]; !ok {
+				delete(data, email)
+			}
+		}
+	}
 	jsonObj(c, data, err)
 }
 
 func (a *ClientController) getTrafficByEmail(c *gin.Context) {
 	email := c.Param("email")
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.trafficGetError"), err)
+		return
+	}
 	traffic, err := a.inboundService.GetClientTrafficByEmail(email)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.trafficGetError"), err)
@@ -599,7 +791,33 @@ func (a *ClientController) getTrafficByEmail(c *gin.Context) {
 	jsonObj(c, traffic, nil)
 }
 
+// getTrafficHistory returns a client's traffic per hour (bucket 3600) or per
+// day (bucket 86400), summed across the panel and every node.
+func (a *ClientController) getTrafficHistory(c *gin.Context) {
+	email := c.Param("email")
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.trafficGetError"), err)
+		return
+	}
+	bucket, err := strconv.Atoi(c.Param("bucket"))
+	if err != nil || (bucket != model.TrafficHistoryHourly && bucket != model.TrafficHistoryDaily) {
+		jsonMsg(c, "invalid bucket", fmt.Errorf("unsupported bucket"))
+		return
+	}
+	count, _ := strconv.Atoi(c.Query("count"))
+	points, err := a.trafficHistoryService.History([]string{email}, bucket, count)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.trafficGetError"), err)
+		return
+	}
+	jsonObj(c, points, nil)
+}
+
 func (a *ClientController) getSubLinks(c *gin.Context) {
+	if err := a.clientService.CheckOwnedSubID(clientScope(c), c.Param("subId")); err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
+		return
+	}
 	links, err := a.inboundService.GetSubLinks(resolveHost(c), c.Param("subId"))
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
@@ -609,6 +827,10 @@ func (a *ClientController) getSubLinks(c *gin.Context) {
 }
 
 func (a *ClientController) getClientLinks(c *gin.Context) {
+	if err := a.clientService.CheckOwned(clientScope(c), c.Param("email")); err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
+		return
+	}
 	links, err := a.inboundService.GetAllClientLinks(resolveHost(c), c.Param("email"))
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
@@ -624,6 +846,10 @@ func (a *ClientController) detach(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if err := a.clientService.CheckOwned(clientScope(c), email); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
 	needRestart, err := a.clientService.DetachByEmailMany(&a.inboundService, email, body.InboundIds)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -646,6 +872,10 @@ func (a *ClientController) bulkResetTraffic(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	if err := a.clientService.CheckOwned(clientScope(c), req.Emails...); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
 	affected, err := a.clientService.BulkResetTraffic(&a.inboundService, req.Emails)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -27,6 +27,8 @@ func (a *GroupController) initRouter(g *gin.RouterGroup) {
 	g.POST("/groups/rename", a.rename)
 	g.POST("/groups/delete", a.delete)
 	g.POST("/groups/resetTraffic", a.resetTraffic)
+	g.POST("/groups/egress", a.setEgress)
+	g.POST("/groups/schedule", a.setSchedule)
 	g.POST("/groups/bulkAdd", a.bulkAdd)
 	g.POST("/groups/bulkRemove", a.bulkRemove)
 }
@@ -127,6 +129,50 @@ func (a *GroupController) resetTraffic(c *gin.Context) {
 	notifyClientsChanged()
 }
 
+type groupEgressBody struct {
+	Name   string `json:"name"`
+	Egress string `json:"egress"`
+}
+
+// setEgress routes the group's members through an outbound or balancer tag;
+// an empty egress releases them.
+func (a *GroupController) setEgress(c *gin.Context) {
+	var body groupEgressBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if err := a.clientService.SetGroupEgress(body.Name, body.Egress); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	a.xrayService.SetToNeedRestart()
+	jsonObj(c, gin.H{"name": body.Name, "egress": body.Egress}, nil)
+	notifyClientsChanged()
+}
+
+type groupScheduleBody struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+}
+
+// setSchedule limits the group's members to weekly access windows; an empty
+// schedule lifts the limit. The schedule job applies it within a minute.
+func (a *GroupController) setSchedule(c *gin.Context) {
+	var body groupScheduleBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	sched, err := a.clientService.SetGroupSchedule(body.Name, body.Schedule)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, gin.H{"name": body.Name, "schedule": sched}, nil)
+	notifyClientsChanged()
+}
+
 type bulkAddToGroupRequest struct {
 	Emails []string `json:"emails"`
 	Group  string   `json:"group"`
@@ -148,6 +194,8 @@ func (a *GroupController) bulkAdd(c *gin.Context) {
 		return
 	}
 	jsonObj(c, gin.H{"affected": affected}, nil)
+	// Moving clients between groups can change the egress they are pinned to.
+	a.xrayService.SetToNeedRestart()
 	notifyClientsChanged()
 }
 
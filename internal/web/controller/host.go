@@ -88,6 +88,9 @@ func (a *HostController) add(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "pages.hosts.toasts.add"), err)
 		return
 	}
+	if len(created) > 0 {
+		setAuditTarget(c, created[0].GroupId)
+	}
 	jsonMsgObj(c, I18nWeb(c, "pages.hosts.toasts.add"), created, nil)
 }
 
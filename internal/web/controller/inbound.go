@@ -7,8 +7,10 @@ import (
 	"strings"
 
 	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/middleware"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service/panel"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/session"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/websocket"
 	"github.com/mhsanaei/3x-ui/v3/internal/xray"
@@ -22,6 +24,7 @@ type InboundController struct {
 	clientService   service.ClientService
 	xrayService     service.XrayService
 	fallbackService service.FallbackService
+	userService     panel.UserService
 }
 
 // NewInboundController creates a new InboundController and sets up its routes.
@@ -59,6 +62,15 @@ func (a *InboundController) broadcastInboundsUpdate(userId int) {
 	websocket.BroadcastInbounds(inbounds)
 }
 
+// inboundOwnerId is the account inbounds are stored under. Every operator
+// works on the primary account's inbounds; roles only gate what they may do.
+func (a *InboundController) inboundOwnerId(c *gin.Context) int {
+	if id, err := a.userService.PrimaryUserId(); err == nil {
+		return id
+	}
+	return session.GetLoginUser(c).Id
+}
+
 // initRouter initializes the routes for inbound-related operations.
 func (a *InboundController) initRouter(g *gin.RouterGroup) {
 	g.GET("/list", a.getInbounds)
@@ -84,8 +96,11 @@ func (a *InboundController) initRouter(g *gin.RouterGroup) {
 
 // getInbounds retrieves the list of inbounds for the logged-in user.
 func (a *InboundController) getInbounds(c *gin.Context) {
-	user := session.GetLoginUser(c)
-	inbounds, err := a.inboundService.GetInbounds(user.Id)
+	ownerId := a.inboundOwnerId(c)
+	inbounds, err := a.inboundService.GetInbounds(ownerId)
+	if err == nil {
+		inbounds, err = a.clientService.ScopeInbounds(clientScope(c), inbounds)
+	}
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
 		return
@@ -96,8 +111,11 @@ func (a *InboundController) getInbounds(c *gin.Context) {
 // getInboundsSlim is the list-page variant that strips full client
 // payloads from settings.clients[]. Detail-view flows still use /get/:id.
 func (a *InboundController) getInboundsSlim(c *gin.Context) {
-	user := session.GetLoginUser(c)
-	inbounds, err := a.inboundService.GetInboundsSlim(user.Id)
+	ownerId := a.inboundOwnerId(c)
+	inbounds, err := a.inboundService.GetInboundsSlim(ownerId)
+	if err == nil {
+		inbounds, err = a.clientService.ScopeInbounds(clientScope(c), inbounds)
+	}
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
 		return
@@ -109,8 +127,8 @@ func (a *InboundController) getInboundsSlim(c *gin.Context) {
 // rendered through the same subscription engine the client pages use so the
 // remark template (name-only display part) is applied consistently.
 func (a *InboundController) getAllInboundLinks(c *gin.Context) {
-	user := session.GetLoginUser(c)
-	links, err := a.inboundService.GetAllInboundLinks(resolveHost(c), user.Id)
+	ownerId := a.inboundOwnerId(c)
+	links, err := a.inboundService.GetAllInboundLinks(resolveHost(c), ownerId)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
 		return
@@ -122,12 +140,21 @@ func (a *InboundController) getAllInboundLinks(c *gin.Context) {
 // (id, remark, protocol, port, tlsFlowCapable) for pickers in the clients UI.
 // Avoids shipping per-client settings and traffic stats just to fill a dropdown.
 func (a *InboundController) getInboundOptions(c *gin.Context) {
-	user := session.GetLoginUser(c)
-	options, err := a.inboundService.GetInboundOptions(user.Id)
+	ownerId := a.inboundOwnerId(c)
+	options, err := a.inboundService.GetInboundOptions(ownerId)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
 		return
 	}
+	if scope := clientScope(c); scope != nil {
+		allowed := make([]service.InboundOption, 0, len(options))
+		for _, o := range options {
+			if scope.AllowsInbound(o.Id) {
+				allowed = append(allowed, o)
+			}
+		}
+		options = allowed
+	}
 	jsonObj(c, options, nil)
 }
 
@@ -138,7 +165,18 @@ func (a *InboundController) getInbound(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "get"), err)
 		return
 	}
+	scope := clientScope(c)
+	if !scope.AllowsInbound(id) {
+		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), common.NewError("inbound not found"))
+		return
+	}
 	inbound, err := a.inboundService.GetInboundDetail(id)
+	if err == nil {
+		var scoped []*model.Inbound
+		if scoped, err = a.clientService.ScopeInbounds(scope, []*model.Inbound{inbound}); err == nil {
+			inbound = scoped[0]
+		}
+	}
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.obtain"), err)
 		return
@@ -152,8 +190,8 @@ func (a *InboundController) addInbound(c *gin.Context) {
 	if !ok {
 		return
 	}
-	user := session.GetLoginUser(c)
-	inbound.UserId = user.Id
+	ownerId := a.inboundOwnerId(c)
+	inbound.UserId = ownerId
 	// Treat NodeID=0 as "no node" — gin's *int form binding can land on
 	// 0 when the field is absent or empty, and 0 is never a valid Node
 	// row id. Without this normalization the runtime layer would try to
@@ -161,17 +199,23 @@ func (a *InboundController) addInbound(c *gin.Context) {
 	if inbound.NodeID != nil && *inbound.NodeID == 0 {
 		inbound.NodeID = nil
 	}
+	if isDryRun(c) {
+		preview, err := a.inboundService.PreviewAddInbound(inbound)
+		jsonObj(c, preview, err)
+		return
+	}
 
 	inbound, needRestart, err := a.inboundService.AddInbound(inbound)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	setAuditTarget(c, strconv.Itoa(inbound.Id))
 	jsonMsgObj(c, I18nWeb(c, "pages.inbounds.toasts.inboundCreateSuccess"), inbound, nil)
 	if needRestart {
 		a.xrayService.SetToNeedRestart()
 	}
-	a.broadcastInboundsUpdate(user.Id)
+	a.broadcastInboundsUpdate(ownerId)
 	notifyClientsChanged()
 }
 
@@ -182,6 +226,11 @@ func (a *InboundController) delInbound(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "pages.inbounds.toasts.inboundDeleteSuccess"), err)
 		return
 	}
+	if isDryRun(c) {
+		preview, err := a.inboundService.PreviewDelInbound(id)
+		jsonObj(c, preview, err)
+		return
+	}
 	needRestart, err := a.inboundService.DelInbound(id)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -191,8 +240,8 @@ func (a *InboundController) delInbound(c *gin.Context) {
 	if needRestart {
 		a.xrayService.SetToNeedRestart()
 	}
-	user := session.GetLoginUser(c)
-	a.broadcastInboundsUpdate(user.Id)
+	ownerId := a.inboundOwnerId(c)
+	a.broadcastInboundsUpdate(ownerId)
 	notifyClientsChanged()
 }
 
@@ -217,8 +266,8 @@ func (a *InboundController) bulkDelInbounds(c *gin.Context) {
 	if needRestart {
 		a.xrayService.SetToNeedRestart()
 	}
-	user := session.GetLoginUser(c)
-	a.broadcastInboundsUpdate(user.Id)
+	ownerId := a.inboundOwnerId(c)
+	a.broadcastInboundsUpdate(ownerId)
 	notifyClientsChanged()
 }
 
@@ -242,6 +291,11 @@ func (a *InboundController) updateInbound(c *gin.Context) {
 	if inbound.NodeID != nil && *inbound.NodeID == 0 {
 		inbound.NodeID = nil
 	}
+	if isDryRun(c) {
+		preview, err := a.inboundService.PreviewUpdateInbound(inbound)
+		jsonObj(c, preview, err)
+		return
+	}
 	inbound, needRestart, err := a.inboundService.UpdateInbound(inbound)
 	if err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
@@ -251,8 +305,8 @@ func (a *InboundController) updateInbound(c *gin.Context) {
 	if needRestart {
 		a.xrayService.SetToNeedRestart()
 	}
-	user := session.GetLoginUser(c)
-	a.broadcastInboundsUpdate(user.Id)
+	ownerId := a.inboundOwnerId(c)
+	a.broadcastInboundsUpdate(ownerId)
 	notifyClientsChanged()
 }
 
@@ -357,8 +411,8 @@ func (a *InboundController) delAllInboundClients(c *gin.Context) {
 	if needRestart {
 		a.xrayService.SetToNeedRestart()
 	}
-	user := session.GetLoginUser(c)
-	a.broadcastInboundsUpdate(user.Id)
+	ownerId := a.inboundOwnerId(c)
+	a.broadcastInboundsUpdate(ownerId)
 	notifyClientsChanged()
 }
 
@@ -400,9 +454,9 @@ func (a *InboundController) importInbound(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
-	user := session.GetLoginUser(c)
+	ownerId := a.inboundOwnerId(c)
 	inbound.Id = 0
-	inbound.UserId = user.Id
+	inbound.UserId = ownerId
 	// Node IDs are panel-local and not portable across panels. Drop a node
 	// reference that is zero or that points to a node which doesn't exist on
 	// this panel, so a cross-panel export imports as a local inbound instead of
@@ -425,11 +479,12 @@ func (a *InboundController) importInbound(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
 	}
+	setAuditTarget(c, strconv.Itoa(inbound.Id))
 	jsonMsgObj(c, I18nWeb(c, "pages.inbounds.toasts.inboundCreateSuccess"), inbound, nil)
 	if needRestart {
 		a.xrayService.SetToNeedRestart()
 	}
-	a.broadcastInboundsUpdate(user.Id)
+	a.broadcastInboundsUpdate(ownerId)
 	notifyClientsChanged()
 }
 
@@ -160,8 +160,9 @@ func (a *IndexController) csrfToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "obj": token})
 }
 
-// getTwoFactorEnable retrieves the current status of two-factor authentication.
+// getTwoFactorEnable reports whether any account uses two-factor authentication,
+// so the login form knows to show the code field.
 func (a *IndexController) getTwoFactorEnable(c *gin.Context) {
-	status, err := a.settingService.GetTwoFactorEnable()
+	status, err := a.userService.AnyTwoFactorEnabled()
 	jsonObj(c, status, err)
 }
@@ -11,13 +11,17 @@ import (
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/middleware"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service/panel"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/websocket"
 
 	"github.com/gin-gonic/gin"
 )
 
 type NodeController struct {
-	nodeService service.NodeService
-	xrayService service.XrayService
+	nodeService   service.NodeService
+	xrayService   service.XrayService
+	serverService service.ServerService
+	panelService  panel.PanelService
 }
 
 func NewNodeController(g *gin.RouterGroup) *NodeController {
@@ -41,10 +45,84 @@ func (a *NodeController) initRouter(g *gin.RouterGroup) {
 	g.POST("/inbounds", a.inbounds)
 	g.POST("/probe/:id", a.probe)
 	g.POST("/updatePanel", a.updatePanel)
+	g.POST("/updateXray", a.updateXray)
+	g.GET("/rollouts", a.rollouts)
+	g.POST("/rollouts/cancel/:id", a.cancelRollout)
 	g.GET("/history/:id/:metric/:bucket", a.history)
 	g.POST("/mtls/ca", a.mtlsCa)
 	g.POST("/mtls/trustCA", a.setMtlsTrustCA)
 	g.POST("/mtls/reloadClient", a.reloadMtlsClient)
+	g.GET("/joinTokens", a.joinTokens)
+	g.POST("/joinTokens/add", a.addJoinToken)
+	g.POST("/joinTokens/del/:id", a.delJoinToken)
+	g.GET("/outbox/:id", a.outbox)
+	g.POST("/outbox/retry/:id", a.retryOutbox)
+	g.POST("/outbox/discard/:id", a.discardOutbox)
+}
+
+func (a *NodeController) joinTokens(c *gin.Context) {
+	tokens, err := a.nodeService.ListJoinTokens()
+	jsonObj(c, tokens, err)
+}
+
+// addJoinToken mints a single-use token a new node redeems with
+// `x-ui join <master-url> <token>`. The plaintext is returned only here.
+func (a *NodeController) addJoinToken(c *gin.Context) {
+	req, ok := middleware.BindAndValidate[service.NodeJoinTokenRequest](c)
+	if !ok {
+		return
+	}
+	created, err := a.nodeService.CreateJoinToken(req)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.nodes.toasts.joinToken"), err)
+		return
+	}
+	setAuditTarget(c, strconv.Itoa(created.Id))
+	jsonObj(c, created, nil)
+}
+
+func (a *NodeController) delJoinToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "get"), err)
+		return
+	}
+	jsonMsg(c, I18nWeb(c, "pages.nodes.toasts.joinToken"), a.nodeService.DeleteJoinToken(id))
+}
+
+// outbox lists the runtime operations queued for a node, in delivery order.
+func (a *NodeController) outbox(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "get"), err)
+		return
+	}
+	entries, err := a.nodeService.ListNodeOutbox(id)
+	jsonObj(c, entries, err)
+}
+
+// retryOutbox makes a queued operation due on the next node sync, including
+// one whose automatic retries gave up.
+func (a *NodeController) retryOutbox(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "get"), err)
+		return
+	}
+	setAuditTarget(c, strconv.Itoa(id))
+	jsonMsg(c, I18nWeb(c, "pages.nodes.toasts.outbox"), a.nodeService.RetryNodeOutboxEntry(id))
+}
+
+// discardOutbox drops a queued operation; the node is reconciled in full
+// instead.
+func (a *NodeController) discardOutbox(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "get"), err)
+		return
+	}
+	setAuditTarget(c, strconv.Itoa(id))
+	jsonMsg(c, I18nWeb(c, "pages.nodes.toasts.outbox"), a.nodeService.DiscardNodeOutboxEntry(id))
 }
 
 // reloadMtlsClient validates the credential currently stored by the master and
@@ -155,6 +233,7 @@ func (a *NodeController) add(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "pages.nodes.toasts.add"), err)
 		return
 	}
+	setAuditTarget(c, strconv.Itoa(view.Id))
 	if n.OutboundTag != "" {
 		if err := a.xrayService.RestartXray(false); err != nil {
 			logger.Warning("apply node outbound bridge failed:", err)
@@ -332,11 +411,10 @@ func (a *NodeController) probe(c *gin.Context) {
 	jsonObj(c, patch.ToUI(probeErr == nil), nil)
 }
 
+// updatePanel starts a rolling panel upgrade of the given nodes to the latest
+// release of the chosen channel.
 func (a *NodeController) updatePanel(c *gin.Context) {
-	var req struct {
-		Ids []int `json:"ids"`
-		Dev bool  `json:"dev"`
-	}
+	var req service.NodeRolloutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
 		return
@@ -345,8 +423,63 @@ func (a *NodeController) updatePanel(c *gin.Context) {
 		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), fmt.Errorf("no nodes selected"))
 		return
 	}
-	results, err := a.nodeService.UpdatePanels(req.Ids, req.Dev)
-	jsonMsgObj(c, I18nWeb(c, "pages.nodes.toasts.updateStarted"), results, err)
+	target, err := a.panelService.LatestVersion(req.Dev)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.nodes.toasts.rolloutStarted"), fmt.Errorf("resolve the latest panel release: %w", err))
+		return
+	}
+	a.startRollout(c, service.RolloutKindPanel, target, req)
+}
+
+// updateXray starts a rolling switch of the given nodes to the Xray release
+// named by version.
+func (a *NodeController) updateXray(c *gin.Context) {
+	var req service.NodeRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	versions, err := a.serverService.GetXrayVersionsCached()
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "getVersion"), err)
+		return
+	}
+	if !slices.Contains(versions, req.Version) {
+		jsonMsg(c, I18nWeb(c, "pages.nodes.toasts.rolloutStarted"), fmt.Errorf("xray version %q is not in the release list", req.Version))
+		return
+	}
+	a.startRollout(c, service.RolloutKindXray, req.Version, req)
+}
+
+func (a *NodeController) startRollout(c *gin.Context, kind, target string, req service.NodeRolloutRequest) {
+	rollout, err := a.nodeService.StartNodeRollout(kind, target, req)
+	if err == nil {
+		setAuditTarget(c, strconv.Itoa(rollout.Id))
+		websocket.BroadcastNodeRollout(rollout)
+	}
+	jsonMsgObj(c, I18nWeb(c, "pages.nodes.toasts.rolloutStarted"), rollout, err)
+}
+
+// rollouts lists the most recent node rollouts with each node's progress.
+func (a *NodeController) rollouts(c *gin.Context) {
+	list, err := a.nodeService.ListNodeRollouts()
+	jsonObj(c, list, err)
+}
+
+// cancelRollout stops a running rollout before its next node; nodes already
+// upgrading finish on their own.
+func (a *NodeController) cancelRollout(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "get"), err)
+		return
+	}
+	setAuditTarget(c, strconv.Itoa(id))
+	rollout, err := a.nodeService.CancelNodeRollout(id)
+	if err == nil {
+		websocket.BroadcastNodeRollout(rollout)
+	}
+	jsonMsgObj(c, I18nWeb(c, "pages.nodes.toasts.rolloutCancelled"), rollout, err)
 }
 
 func (a *NodeController) history(c *gin.Context) {
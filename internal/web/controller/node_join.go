@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// joinLimiter slows down guessing join tokens the same way logins are.
+var joinLimiter = newLoginLimiter(loginLimitMaxFailures, loginLimitWindow, loginLimitCooldown)
+
+// NodeJoinController accepts join requests from nodes enrolling with
+// `x-ui join`. It sits outside /panel/api: the join token is the credential.
+type NodeJoinController struct {
+	nodeService service.NodeService
+}
+
+// NewNodeJoinController creates a NodeJoinController and registers its route.
+func NewNodeJoinController(g *gin.RouterGroup) *NodeJoinController {
+	a := &NodeJoinController{}
+	g.POST("/"+service.NodeJoinPath, rejectOnStandby, a.join)
+	return a
+}
+
+func (a *NodeJoinController) join(c *gin.Context) {
+	remoteIP := getRemoteIp(c)
+	if _, ok := joinLimiter.allow(remoteIP, "join"); !ok {
+		jsonMsg(c, "node join", errors.New("too many failed attempts, try again later"))
+		return
+	}
+	var req service.NodeJoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		jsonMsg(c, "node join", err)
+		return
+	}
+	resp, err := a.nodeService.Join(c.Request.Context(), &req, remoteIP)
+	if errors.Is(err, service.ErrJoinTokenInvalid) {
+		if blockedUntil, blocked := joinLimiter.registerFailure(remoteIP, "join"); blocked {
+			logger.Warningf("node join: IP %q blocked until %s", remoteIP, blockedUntil.Format(time.RFC3339))
+		}
+	}
+	if err != nil {
+		jsonMsg(c, "node join", err)
+		return
+	}
+	joinLimiter.registerSuccess(remoteIP, "join")
+	jsonObj(c, resp, nil)
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// telemetryLimiter slows down guessing node tokens the same way logins are.
+var telemetryLimiter = newLoginLimiter(loginLimitMaxFailures, loginLimitWindow, loginLimitCooldown)
+
+// NodeTelemetryReceiver applies node n's frames as next yields them;
+// interrupt unblocks a pending next when the receiver stops the stream.
+type NodeTelemetryReceiver func(n *model.Node, next func() (runtime.TelemetryFrame, error), interrupt func()) error
+
+// NodeTelemetryController sits outside /panel/api: a node authenticates its
+// stream with the API token this panel calls it with.
+type NodeTelemetryController struct {
+	nodeService service.NodeService
+	receive     NodeTelemetryReceiver
+}
+
+// NewNodeTelemetryController creates a NodeTelemetryController and registers
+// its route.
+func NewNodeTelemetryController(g *gin.RouterGroup, receive NodeTelemetryReceiver) *NodeTelemetryController {
+	a := &NodeTelemetryController{receive: receive}
+	g.POST("/"+runtime.TelemetryStreamPath, rejectOnStandby, a.stream)
+	return a
+}
+
+func (a *NodeTelemetryController) stream(c *gin.Context) {
+	// Answer straight away when refusing, instead of first draining a body
+	// that never ends.
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.EnableFullDuplex()
+
+	remoteIP := getRemoteIp(c)
+	if _, ok := telemetryLimiter.allow(remoteIP, "telemetry"); !ok {
+		c.AbortWithStatus(http.StatusTooManyRequests)
+		return
+	}
+	nodeID, _ := strconv.Atoi(c.GetHeader(runtime.TelemetryNodeHeader))
+	token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	n, err := a.nodeService.AuthorizeTelemetry(nodeID, token)
+	if err != nil {
+		if blockedUntil, blocked := telemetryLimiter.registerFailure(remoteIP, "telemetry"); blocked {
+			logger.Warningf("node telemetry: IP %q blocked until %s", remoteIP, blockedUntil.Format(time.RFC3339))
+		}
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	telemetryLimiter.registerSuccess(remoteIP, "telemetry")
+
+	// The stream outlives the server's write timeout and is only read while
+	// frames keep coming.
+	_ = rc.SetWriteDeadline(time.Time{})
+	reader := runtime.NewTelemetryReader(c.Request.Body)
+	next := func() (runtime.TelemetryFrame, error) {
+		_ = rc.SetReadDeadline(time.Now().Add(runtime.TelemetryIdleTimeout))
+		return reader.Next()
+	}
+	interrupt := func() { _ = rc.SetReadDeadline(time.Now()) }
+	err = a.receive(n, next, interrupt)
+	if err != nil && !errors.Is(err, io.EOF) {
+		logger.Debugf("node telemetry: stream from %s ended: %v", n.Name, err)
+	}
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+)
+
+// TestNodeTelemetryStream: only the node's own API token gets its frames to
+// the receiver; any other token is refused before a frame is read.
+func TestNodeTelemetryStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbDir := t.TempDir()
+	t.Setenv("XUI_DB_FOLDER", dbDir)
+	if err := database.InitDB(filepath.Join(dbDir, "x-ui.db")); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseDB() })
+	node := &model.Node{Name: "n1", Scheme: "http", Address: "127.0.0.1", Port: 2053, BasePath: "/", ApiToken: "tok", Enable: true}
+	if err := database.GetDB().Create(node).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var gotNode int
+	var got []string
+	engine := gin.New()
+	NewNodeTelemetryController(engine.Group("/xui"), func(n *model.Node, next func() (runtime.TelemetryFrame, error), _ func()) error {
+		gotNode = n.Id
+		for {
+			f, err := next()
+			if err != nil {
+				return err
+			}
+			got = append(got, f.Type)
+		}
+	})
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	offer := &runtime.TelemetryOffer{NodeId: node.Id, Scheme: "http", Port: port, BasePath: "/xui/"}
+
+	push := func(token string) error {
+		return runtime.PushTelemetry(context.Background(), offer, "127.0.0.1", token, func(send func(runtime.TelemetryFrame) error) error {
+			if err := send(runtime.TelemetryFrame{Type: runtime.TelemetryStatus}); err != nil {
+				return err
+			}
+			return send(runtime.TelemetryFrame{Type: runtime.TelemetryPing})
+		})
+	}
+	if err := push("wrong"); err == nil {
+		t.Fatal("a stream with the wrong token was accepted")
+	}
+	if len(got) != 0 {
+		t.Fatalf("frames of a refused stream reached the receiver: %v", got)
+	}
+	if err := push("tok"); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if gotNode != node.Id || len(got) != 2 || got[0] != runtime.TelemetryStatus || got[1] != runtime.TelemetryPing {
+		t.Fatalf("receiver saw node %d frames %v", gotNode, got)
+	}
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/importer"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PanelImportController imports the users and inbounds of another panel, the
+// API side of the import command.
+type PanelImportController struct {
+	panelImportService service.PanelImportService
+	xrayService        service.XrayService
+}
+
+func NewPanelImportController(g *gin.RouterGroup) *PanelImportController {
+	a := &PanelImportController{}
+	a.initRouter(g)
+	return a
+}
+
+func (a *PanelImportController) initRouter(g *gin.RouterGroup) {
+	g.POST("/import", a.importPanel)
+}
+
+// importPanel imports the uploaded file (or dsn for PostgreSQL). map is a JSON
+// object of source inbound tags onto existing tags; dryRun returns the report only.
+func (a *PanelImportController) importPanel(c *gin.Context) {
+	source := c.PostForm("source")
+	if !slices.Contains(importer.Sources, source) {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), common.NewErrorf("unknown source %q", source))
+		return
+	}
+	var mapping map[string]string
+	if raw := c.PostForm("map"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			jsonMsg(c, I18nWeb(c, "somethingWentWrong"), common.NewErrorf("map: %v", err))
+			return
+		}
+	}
+
+	in := importer.Input{DSN: c.PostForm("dsn")}
+	var err error
+	if in.Path, err = saveUpload(c, "file"); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	defer removeUpload(in.Path)
+	if in.XrayConfig, err = saveUpload(c, "xrayConfig"); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	defer removeUpload(in.XrayConfig)
+	if in.Path == "" && in.DSN == "" {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), common.NewError("a file or a dsn is required"))
+		return
+	}
+
+	bundle, err := importer.Read(source, in)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	dryRun := isDryRun(c)
+	report, needRestart, err := a.panelImportService.Import(bundle, mapping, dryRun)
+	if !dryRun {
+		if needRestart {
+			a.xrayService.SetToNeedRestart()
+		}
+		websocket.BroadcastInvalidate(websocket.MessageTypeInbounds)
+		notifyClientsChanged()
+	}
+	if err != nil {
+		jsonMsgObj(c, I18nWeb(c, "somethingWentWrong"), report, err)
+		return
+	}
+	jsonObj(c, report, nil)
+}
+
+// saveUpload copies the multipart file in field to a temporary file and
+// returns its path, or "" when the field is absent.
+func saveUpload(c *gin.Context, field string) (string, error) {
+	fh, err := c.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	dst, err := os.CreateTemp("", "x-ui-import-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+func removeUpload(path string) {
+	if path != "" {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/middleware"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlanController manages the client plan catalog and provisions or renews
+// clients from it.
+type PlanController struct {
+	clientService  service.ClientService
+	inboundService service.InboundService
+	xrayService    service.XrayService
+}
+
+func NewPlanController(g *gin.RouterGroup) *PlanController {
+	a := &PlanController{}
+	a.initRouter(g)
+	return a
+}
+
+func (a *PlanController) initRouter(g *gin.RouterGroup) {
+	g.GET("/plans", a.list)
+	g.POST("/plans/add", a.add)
+	g.POST("/plans/update/:id", a.update)
+	g.POST("/plans/del/:id", a.del)
+	g.POST("/plans/create/:id", a.create)
+	g.POST("/plans/renew/:id", a.renew)
+}
+
+func (a *PlanController) list(c *gin.Context) {
+	plans, err := a.clientService.ListPlans()
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, plans, nil)
+}
+
+func (a *PlanController) add(c *gin.Context) {
+	req, ok := middleware.BindJSONAndValidate[entity.PlanRequest](c)
+	if !ok {
+		return
+	}
+	plan, err := a.clientService.AddPlan(req)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	setAuditTarget(c, strconv.Itoa(plan.Id))
+	jsonObj(c, plan, nil)
+}
+
+func (a *PlanController) update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	req, ok := middleware.BindJSONAndValidate[entity.PlanRequest](c)
+	if !ok {
+		return
+	}
+	plan, err := a.clientService.UpdatePlan(id, req)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, plan, nil)
+}
+
+func (a *PlanController) del(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if err := a.clientService.DeletePlan(id); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, nil, nil)
+}
+
+func (a *PlanController) create(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	req, ok := middleware.BindJSONAndValidate[entity.PlanCreateRequest](c)
+	if !ok {
+		return
+	}
+	result, needRestart, err := a.clientService.CreateFromPlan(&a.inboundService, clientScope(c), id, req.Clients)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, result, nil)
+	if needRestart {
+		a.xrayService.SetToNeedRestart()
+	}
+	notifyClientsChanged()
+}
+
+func (a *PlanController) renew(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	req, ok := middleware.BindJSONAndValidate[entity.PlanRenewRequest](c)
+	if !ok {
+		return
+	}
+	result, needRestart, err := a.clientService.RenewWithPlan(&a.inboundService, clientScope(c), id, req.Emails)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, result, nil)
+	if needRestart {
+		a.xrayService.SetToNeedRestart()
+	}
+	notifyClientsChanged()
+}
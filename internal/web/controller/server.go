@@ -1,17 +1,21 @@
 package controller
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"regexp"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/ha"
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/global"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service/panel"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/websocket"
@@ -29,6 +33,7 @@ type ServerController struct {
 	settingService     service.SettingService
 	panelService       panel.PanelService
 	xrayMetricsService service.XrayMetricsService
+	metricsService     service.MetricsService
 }
 
 // NewServerController creates a new ServerController, initializes routes, and starts background tasks.
@@ -52,12 +57,14 @@ func (a *ServerController) initRouter(g *gin.RouterGroup) {
 	g.GET("/getXrayVersion", a.getXrayVersion)
 	g.GET("/getPanelUpdateInfo", a.getPanelUpdateInfo)
 	g.GET("/getUpdateStatus", a.getUpdateStatus)
+	g.GET("/haStatus", a.getHAStatus)
 	g.GET("/getConfigJson", a.getConfigJson)
 	g.GET("/getDb", a.getDb)
 	g.GET("/getMigration", a.getMigration)
 	g.GET("/getNewUUID", a.getNewUUID)
 	g.GET("/getWebCertFiles", a.getWebCertFiles)
 	g.GET("/descendants", a.descendants)
+	g.POST("/telemetry", a.telemetry)
 	g.GET("/getNewX25519Cert", a.getNewX25519Cert)
 	g.GET("/getNewmldsa65", a.getNewmldsa65)
 	g.GET("/getNewmlkem768", a.getNewmlkem768)
@@ -107,6 +114,17 @@ func (a *ServerController) startTask() {
 // status returns the current server status information.
 func (a *ServerController) status(c *gin.Context) { jsonObj(c, a.serverService.LastStatus(), nil) }
 
+// metrics serves the panel's counters and gauges in the OpenMetrics text format.
+func (a *ServerController) metrics(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := a.metricsService.Write(&buf, a.serverService.LastStatus(), &a.xrayMetricsService); err != nil {
+		logger.Warning("metrics scrape failed:", err)
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Data(http.StatusOK, service.OpenMetricsContentType, buf.Bytes())
+}
+
 func (a *ServerController) getFail2banStatus(c *gin.Context) {
 	jsonObj(c, a.serverService.GetFail2banStatus(), nil)
 }
@@ -240,6 +258,11 @@ func (a *ServerController) getUpdateStatus(c *gin.Context) {
 	jsonObj(c, a.panelService.GetUpdateStatus(), nil)
 }
 
+// getHAStatus reports this panel's role in an HA pair and which node leads.
+func (a *ServerController) getHAStatus(c *gin.Context) {
+	jsonObj(c, ha.CurrentStatus(c.Request.Context()), nil)
+}
+
 // setUpdateChannel toggles whether self-update tracks the rolling dev release.
 func (a *ServerController) setUpdateChannel(c *gin.Context) {
 	dev, err := strconv.ParseBool(c.PostForm("dev"))
@@ -394,6 +417,24 @@ func (a *ServerController) descendants(c *gin.Context) {
 	jsonObj(c, data, err)
 }
 
+// telemetry accepts a master's offer: this panel dials the master back with
+// the same API token and pushes what the master would otherwise poll.
+func (a *ServerController) telemetry(c *gin.Context) {
+	var offer runtime.TelemetryOffer
+	if err := c.ShouldBindJSON(&offer); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	ownerID, err := (&panel.UserService{}).PrimaryUserId()
+	if err != nil {
+		jsonObj(c, nil, err)
+		return
+	}
+	token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	err = a.serverService.AcceptTelemetryOffer(&offer, token, getRemoteIp(c), ownerID)
+	jsonObj(c, nil, err)
+}
+
 // getWebCertFiles returns this panel's own web TLS certificate and key file
 // paths. The central panel calls it on a node (via the node's API token) so
 // "Set Cert from Panel" can fill a node-assigned inbound with paths that exist
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"io"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StateController exports the panel as a declarative state document and
+// applies one back, the API side of the export-state and apply commands.
+type StateController struct {
+	stateService service.StateService
+	xrayService  service.XrayService
+}
+
+func NewStateController(g *gin.RouterGroup) *StateController {
+	a := &StateController{}
+	a.initRouter(g)
+	return a
+}
+
+func (a *StateController) initRouter(g *gin.RouterGroup) {
+	g.GET("/export", a.export)
+
+	g.POST("/apply", a.apply)
+}
+
+// export downloads the state document, as YAML unless format=json.
+func (a *StateController) export(c *gin.Context) {
+	asJSON := c.Query("format") == "json"
+	doc, err := a.stateService.Export()
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	data, err := service.MarshalStateDocument(doc, asJSON)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	filename := "x-ui-state-" + time.Now().Format("20060102-150405") + ".yaml"
+	contentType := "application/yaml"
+	if asJSON {
+		filename = filename[:len(filename)-len(".yaml")] + ".json"
+		contentType = "application/json"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	_, _ = c.Writer.Write(data)
+}
+
+// apply reads a YAML or JSON state document from the request body. With
+// dryRun it only returns the plan.
+func (a *StateController) apply(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	doc, err := service.ParseStateDocument(body)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if isDryRun(c) {
+		plan, err := a.stateService.Plan(doc)
+		jsonObj(c, plan, err)
+		return
+	}
+
+	result, err := a.stateService.Apply(doc)
+	if result != nil && result.Applied > 0 {
+		if result.NeedRestart {
+			a.xrayService.SetToNeedRestart()
+		}
+		for _, t := range websocket.ReplicatedTypes {
+			websocket.BroadcastInvalidate(t)
+		}
+	}
+	if err != nil {
+		jsonMsgObj(c, I18nWeb(c, "somethingWentWrong"), result, err)
+		return
+	}
+	jsonObj(c, result, nil)
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/web/middleware"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service/panel"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserController manages panel accounts. Everything except /me is owner-only;
+// enforceTokenScope applies that before these handlers run.
+type UserController struct {
+	userService panel.UserService
+}
+
+type twoFactorForm struct {
+	Enable bool   `json:"enable"`
+	Token  string `json:"token" validate:"max=64"`
+	Code   string `json:"code" validate:"max=16"`
+}
+
+func NewUserController(g *gin.RouterGroup) *UserController {
+	a := &UserController{}
+	a.initRouter(g)
+	return a
+}
+
+func (a *UserController) initRouter(g *gin.RouterGroup) {
+	g.GET("/me", a.me)
+	g.POST("/me/twoFactor", a.setMyTwoFactor)
+
+	g.GET("/list", a.list)
+	g.POST("/add", a.add)
+	g.POST("/update/:id", a.update)
+	g.POST("/del/:id", a.del)
+	g.POST("/resetTwoFactor/:id", a.resetTwoFactor)
+}
+
+func (a *UserController) me(c *gin.Context) {
+	view, err := a.userService.View(session.GetLoginUser(c))
+	jsonObj(c, view, err)
+}
+
+// setMyTwoFactor binds or removes the caller's authenticator. Turning it off
+// or rebinding it needs a current code, as does any other credential change.
+func (a *UserController) setMyTwoFactor(c *gin.Context) {
+	form, ok := middleware.BindJSONAndValidate[twoFactorForm](c)
+	if !ok {
+		return
+	}
+	msg := I18nWeb(c, "pages.settings.toasts.modifySettings")
+	user := session.GetLoginUser(c)
+	if user.TwoFactorEnable {
+		if err := a.userService.VerifyTwoFactorCode(user, form.Code); err != nil {
+			jsonMsg(c, msg, err)
+			return
+		}
+	}
+	if err := a.userService.SetTwoFactor(user.Id, form.Enable, form.Token); err != nil {
+		jsonMsg(c, msg, err)
+		return
+	}
+	// SetTwoFactor signed out every session; keep this one.
+	updated, err := a.userService.GetUser(user.Id)
+	if err == nil {
+		err = session.SetLoginUser(c, updated)
+	}
+	jsonMsg(c, msg, err)
+}
+
+func (a *UserController) list(c *gin.Context) {
+	users, err := a.userService.ListUsers()
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.users.toasts.list"), err)
+		return
+	}
+	jsonObj(c, users, nil)
+}
+
+func (a *UserController) add(c *gin.Context) {
+	req, ok := middleware.BindJSONAndValidate[panel.UserAccountRequest](c)
+	if !ok {
+		return
+	}
+	view, err := a.userService.CreateUser(req)
+	jsonMsgObj(c, I18nWeb(c, "pages.settings.users.toasts.add"), view, err)
+}
+
+func (a *UserController) update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.users.toasts.update"), err)
+		return
+	}
+	req, ok := middleware.BindJSONAndValidate[panel.UserAccountRequest](c)
+	if !ok {
+		return
+	}
+	if id == session.GetLoginUser(c).Id && strings.TrimSpace(req.Password) != "" {
+		// Own credentials go through /setting/updateUser, which checks the old password.
+		jsonMsg(c, I18nWeb(c, "pages.settings.users.toasts.update"), errors.New("change your own password from the security tab"))
+		return
+	}
+	view, err := a.userService.UpdateAccount(id, req)
+	jsonMsgObj(c, I18nWeb(c, "pages.settings.users.toasts.update"), view, err)
+}
+
+func (a *UserController) del(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.users.toasts.delete"), err)
+		return
+	}
+	jsonMsg(c, I18nWeb(c, "pages.settings.users.toasts.delete"), a.userService.DeleteUser(id, session.GetLoginUser(c).Id))
+}
+
+func (a *UserController) resetTwoFactor(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.users.toasts.resetTwoFactor"), err)
+		return
+	}
+	jsonMsg(c, I18nWeb(c, "pages.settings.users.toasts.resetTwoFactor"), a.userService.ResetUserTwoFactor(id))
+}
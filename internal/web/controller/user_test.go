@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/xlzd/gotp"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service/panel"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/session"
+)
+
+// newRoleTestServer mirrors the API auth wiring with stub handlers plus the
+// real /users routes. /test-login/:id signs the cookie jar in as that account.
+func newRoleTestServer(t *testing.T) (*httptest.Server, *http.Client) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	dbDir := t.TempDir()
+	t.Setenv("XUI_DB_FOLDER", dbDir)
+	if err := database.InitDB(filepath.Join(dbDir, "x-ui.db")); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseDB() })
+
+	engine := gin.New()
+	engine.Use(sessions.Sessions("3x-ui", cookie.NewStore([]byte("role-test-secret"))))
+	a := &APIController{}
+	engine.GET("/test-login/:id", func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		u, err := (&panel.UserService{}).GetUser(id)
+		if err != nil || session.SetLoginUser(c, u) != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	api := engine.Group("/panel/api")
+	api.Use(a.checkAPIAuth)
+	api.Use(a.enforceTokenScope)
+	reached := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true}) }
+	api.GET("/inbounds/list", reached)
+	api.POST("/inbounds/add", reached)
+	api.POST("/clients/add", reached)
+	api.POST("/clients/onlines", reached)
+	api.POST("/clients/delDepleted", reached)
+	api.POST("/clients/resetTraffic/:email", reached)
+	api.POST("/clients/updateTraffic/:email", reached)
+	api.POST("/clients/bulkResetTraffic", reached)
+	api.POST("/setting/all", reached)
+	api.GET("/server/status", reached)
+	api.GET("/server/getDb", reached)
+	NewUserController(api.Group("/users"))
+
+	ts := httptest.NewServer(engine)
+	t.Cleanup(ts.Close)
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts, &http.Client{Jar: jar}
+}
+
+func loginAs(t *testing.T, ts *httptest.Server, client *http.Client, id int) {
+	t.Helper()
+	resp, err := client.Get(ts.URL + "/test-login/" + strconv.Itoa(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login as %d: status %d", id, resp.StatusCode)
+	}
+}
+
+func callAPI(t *testing.T, ts *httptest.Server, client *http.Client, method, path, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(raw)
+}
+
+func TestRolePermissionsGateSessionRequests(t *testing.T) {
+	ts, client := newRoleTestServer(t)
+	users := &panel.UserService{}
+	readOnly, err := users.CreateUser(&panel.UserAccountRequest{Username: "viewer", Password: "pw", Role: model.UserRoleReadOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reseller, err := users.CreateUser(&panel.UserAccountRequest{Username: "seller", Password: "pw", Role: model.UserRoleReseller})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		userId int
+		method string
+		path   string
+		want   int
+	}{
+		{"read-only lists inbounds", readOnly.Id, http.MethodGet, "/panel/api/inbounds/list", http.StatusOK},
+		{"read-only may not add inbounds", readOnly.Id, http.MethodPost, "/panel/api/inbounds/add", http.StatusForbidden},
+		{"read-only may call read-only POST", readOnly.Id, http.MethodPost, "/panel/api/clients/onlines", http.StatusOK},
+		{"read-only sees the dashboard", readOnly.Id, http.MethodGet, "/panel/api/server/status", http.StatusOK},
+		{"read-only may not list accounts", readOnly.Id, http.MethodGet, "/panel/api/users/list", http.StatusForbidden},
+		{"read-only reads own profile", readOnly.Id, http.MethodGet, "/panel/api/users/me", http.StatusOK},
+		{"reseller adds clients", reseller.Id, http.MethodPost, "/panel/api/clients/add", http.StatusOK},
+		{"reseller has no settings", reseller.Id, http.MethodPost, "/panel/api/setting/all", http.StatusForbidden},
+		{"reseller may not export the database", reseller.Id, http.MethodGet, "/panel/api/server/getDb", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		loginAs(t, ts, client, c.userId)
+		if code, body := callAPI(t, ts, client, c.method, c.path, ""); code != c.want {
+			t.Errorf("%s: status = %d, want %d; body=%s", c.name, code, c.want, body)
+		}
+	}
+}
+
+func TestAdminOverrideRaisesGroupAccess(t *testing.T) {
+	ts, client := newRoleTestServer(t)
+	u, err := (&panel.UserService{}).CreateUser(&panel.UserAccountRequest{
+		Username:    "inbound-editor",
+		Password:    "pw",
+		Role:        model.UserRoleReadOnly,
+		Permissions: map[string]string{model.PermInbounds: model.AccessWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loginAs(t, ts, client, u.Id)
+	if code, body := callAPI(t, ts, client, http.MethodPost, "/panel/api/inbounds/add", ""); code != http.StatusOK {
+		t.Fatalf("override to write: status = %d; body=%s", code, body)
+	}
+	if code, _ := callAPI(t, ts, client, http.MethodPost, "/panel/api/clients/add", ""); code != http.StatusForbidden {
+		t.Fatalf("groups without an override must keep the preset: status = %d", code)
+	}
+}
+
+func TestResellerCannotReachPanelWideActions(t *testing.T) {
+	ts, client := newRoleTestServer(t)
+	u, err := (&panel.UserService{}).CreateUser(&panel.UserAccountRequest{
+		Username:    "seller",
+		Password:    "pw",
+		Role:        model.UserRoleReseller,
+		Permissions: map[string]string{model.PermInbounds: model.AccessWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loginAs(t, ts, client, u.Id)
+	if code, _ := callAPI(t, ts, client, http.MethodGet, "/panel/api/inbounds/list", ""); code != http.StatusOK {
+		t.Fatalf("reseller listing inbounds: status = %d", code)
+	}
+	if code, _ := callAPI(t, ts, client, http.MethodPost, "/panel/api/inbounds/add", ""); code != http.StatusForbidden {
+		t.Fatalf("an inbounds override must not let a reseller edit inbounds: status = %d", code)
+	}
+	if code, _ := callAPI(t, ts, client, http.MethodPost, "/panel/api/clients/delDepleted", ""); code != http.StatusForbidden {
+		t.Fatalf("reseller deleting depleted clients panel-wide: status = %d", code)
+	}
+}
+
+// TestResellerCannotResetClientTraffic: MaxTrafficGB caps allocated totals, so
+// resetting or rewriting usage would let a reseller hand out unlimited traffic.
+func TestResellerCannotResetClientTraffic(t *testing.T) {
+	ts, client := newRoleTestServer(t)
+	users := &panel.UserService{}
+	seller, err := users.CreateUser(&panel.UserAccountRequest{Username: "seller", Password: "pw", Role: model.UserRoleReseller})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := users.CreateUser(&panel.UserAccountRequest{Username: "operator", Password: "pw", Role: model.UserRoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := []string{
+		"/panel/api/clients/resetTraffic/a@x",
+		"/panel/api/clients/updateTraffic/a@x",
+		"/panel/api/clients/bulkResetTraffic",
+	}
+	for _, path := range routes {
+		loginAs(t, ts, client, seller.Id)
+		if code, _ := callAPI(t, ts, client, http.MethodPost, path, ""); code != http.StatusForbidden {
+			t.Errorf("reseller %s: status = %d, want 403", path, code)
+		}
+		loginAs(t, ts, client, admin.Id)
+		if code, body := callAPI(t, ts, client, http.MethodPost, path, ""); code != http.StatusOK {
+			t.Errorf("admin %s: status = %d; body=%s", path, code, body)
+		}
+	}
+}
+
+// GHSA-xqqw-jqqv-99h6: with 2FA enabled, the authenticator must not be
+// rebound without presenting a current code.
+func TestRebindTwoFactorRequiresCurrentCode(t *testing.T) {
+	ts, client := newRoleTestServer(t)
+	users := &panel.UserService{}
+	first, err := users.GetFirstUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const original = "ORIGINALSECRET234567"
+	if err := users.SetTwoFactor(first.Id, true, original); err != nil {
+		t.Fatal(err)
+	}
+	loginAs(t, ts, client, first.Id)
+
+	_, body := callAPI(t, ts, client, http.MethodPost, "/panel/api/users/me/twoFactor", `{"enable":true,"token":"ATTACKERSECRET567890"}`)
+	if !strings.Contains(body, `"success":false`) {
+		t.Fatalf("rebind without a 2FA code was accepted: %s", body)
+	}
+	stored, err := users.GetUser(first.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.TwoFactorToken != original {
+		t.Fatalf("stored 2FA secret = %q, want it unchanged", stored.TwoFactorToken)
+	}
+
+	code := gotp.NewDefaultTOTP(original).Now()
+	_, body = callAPI(t, ts, client, http.MethodPost, "/panel/api/users/me/twoFactor", `{"enable":false,"code":"`+code+`"}`)
+	if !strings.Contains(body, `"success":true`) {
+		t.Fatalf("disabling with a valid code was rejected: %s", body)
+	}
+	// The session that made the change must survive the epoch bump.
+	if status, body := callAPI(t, ts, client, http.MethodGet, "/panel/api/users/me", ""); status != http.StatusOK || !strings.Contains(body, `"twoFactorEnable":false`) {
+		t.Fatalf("me after disabling 2FA: status = %d; body=%s", status, body)
+	}
+}
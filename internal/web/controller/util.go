@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/wirecodec"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
 
@@ -179,6 +180,10 @@ func jsonMsgObj(c *gin.Context, msg string, obj any, err error) {
 			logger.Warningf("%s %s %s", ctx, m.Msg, fail)
 		}
 	}
+	c.Set(auditSuccessKey, m.Success)
+	if !m.Success {
+		c.Set(auditErrorKey, m.Msg)
+	}
 	c.JSON(http.StatusOK, m)
 }
 
@@ -204,3 +209,67 @@ func pureJsonMsg(c *gin.Context, statusCode int, success bool, msg string) {
 func isAjax(c *gin.Context) bool {
 	return c.GetHeader("X-Requested-With") == "XMLHttpRequest"
 }
+
+// isDryRun reports whether a mutation was asked to only preview its effect
+// (?dryRun=1) instead of applying it.
+func isDryRun(c *gin.Context) bool {
+	v := c.Query("dryRun")
+	return v == "1" || v == "true"
+}
+
+// dryRunRoutes honour ?dryRun=1 and write nothing. Only these may skip the
+// audit trail or pass the HA standby; every other route rejects the flag.
+var dryRunRoutes = map[string]struct{}{
+	"/inbounds/add":          {},
+	"/inbounds/update/:id":   {},
+	"/inbounds/del/:id":      {},
+	"/clients/add":           {},
+	"/clients/update/:email": {},
+	"/clients/del/:email":    {},
+	"/clients/bulkCreate":    {},
+	"/clients/bulkAdjust":    {},
+	"/clients/bulkAttach":    {},
+	"/clients/bulkDetach":    {},
+	"/clients/bulkDel":       {},
+	"/clients/bulkEnable":    {},
+	"/clients/bulkDisable":   {},
+	"/xray/update":           {},
+	"/state/apply":           {},
+	"/importer/import":       {},
+}
+
+// isPreview reports whether the request is a dry run of a route that
+// implements one, so it is known to change nothing.
+func isPreview(c *gin.Context) bool {
+	if !isDryRun(c) {
+		return false
+	}
+	_, ok := dryRunRoutes[relAPIPath(c.FullPath())]
+	return ok
+}
+
+// rejectUnsupportedDryRun refuses ?dryRun=1 on a route that would ignore it and
+// apply the change for real, instead of letting the caller believe it did not.
+func rejectUnsupportedDryRun(c *gin.Context) {
+	if isDryRun(c) && !isPreview(c) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"msg":     "dryRun is not supported on this route",
+		})
+		return
+	}
+	c.Next()
+}
+
+// stampLocalWrite numbers every API write and returns the number on the
+// answer, so a master can tell when this panel's telemetry frames include it.
+func stampLocalWrite(c *gin.Context) {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		c.Next()
+		return
+	}
+	stamp, done := service.BeginLocalWrite()
+	defer done()
+	c.Header(wirecodec.WriteSeqHeader, stamp)
+	c.Next()
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/middleware"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VoucherController mints and manages voucher codes redeemed on the
+// subscription page and through the Telegram bot.
+type VoucherController struct {
+	voucherService service.VoucherService
+}
+
+func NewVoucherController(g *gin.RouterGroup) *VoucherController {
+	a := &VoucherController{}
+	a.initRouter(g)
+	return a
+}
+
+func (a *VoucherController) initRouter(g *gin.RouterGroup) {
+	g.GET("/vouchers", a.list)
+	g.POST("/vouchers/generate", a.generate)
+	g.POST("/vouchers/update/:id", a.update)
+	g.POST("/vouchers/del/:id", a.del)
+}
+
+func (a *VoucherController) list(c *gin.Context) {
+	vouchers, err := a.voucherService.List()
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, vouchers, nil)
+}
+
+func (a *VoucherController) generate(c *gin.Context) {
+	req, ok := middleware.BindJSONAndValidate[entity.VoucherGenerateRequest](c)
+	if !ok {
+		return
+	}
+	vouchers, err := a.voucherService.Generate(req)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, vouchers, nil)
+}
+
+func (a *VoucherController) update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	req, ok := middleware.BindJSONAndValidate[entity.VoucherUpdateRequest](c)
+	if !ok {
+		return
+	}
+	v, err := a.voucherService.Update(id, req)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, v, nil)
+}
+
+func (a *VoucherController) del(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	if err := a.voucherService.Delete(id); err != nil {
+		jsonMsg(c, I18nWeb(c, "somethingWentWrong"), err)
+		return
+	}
+	jsonObj(c, nil, nil)
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/middleware"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookController manages outbound webhooks and their delivery log.
+type WebhookController struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookController(g *gin.RouterGroup) *WebhookController {
+	a := &WebhookController{}
+	a.initRouter(g)
+	return a
+}
+
+func (a *WebhookController) initRouter(g *gin.RouterGroup) {
+	g.GET("/list", a.list)
+	g.GET("/deliveries", a.deliveries)
+
+	g.POST("/add", a.add)
+	g.POST("/update/:id", a.update)
+	g.POST("/del/:id", a.del)
+	g.POST("/test/:id", a.test)
+	g.POST("/deliveries/replay/:id", a.replay)
+}
+
+func (a *WebhookController) list(c *gin.Context) {
+	hooks, err := a.webhookService.List()
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.webhooks.toasts.list"), err)
+		return
+	}
+	jsonObj(c, hooks, nil)
+}
+
+func (a *WebhookController) deliveries(c *gin.Context) {
+	var params service.WebhookDeliveryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.webhooks.toasts.list"), err)
+		return
+	}
+	page, err := a.webhookService.Deliveries(params)
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.webhooks.toasts.list"), err)
+		return
+	}
+	jsonObj(c, page, nil)
+}
+
+func (a *WebhookController) add(c *gin.Context) {
+	req, ok := middleware.BindJSONAndValidate[entity.WebhookRequest](c)
+	if !ok {
+		return
+	}
+	hook, err := a.webhookService.Add(req)
+	if err == nil {
+		setAuditTarget(c, strconv.Itoa(hook.Id))
+	}
+	jsonMsgObj(c, I18nWeb(c, "pages.settings.webhooks.toasts.add"), hook, err)
+}
+
+func (a *WebhookController) update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.webhooks.toasts.update"), err)
+		return
+	}
+	req, ok := middleware.BindJSONAndValidate[entity.WebhookRequest](c)
+	if !ok {
+		return
+	}
+	hook, err := a.webhookService.Update(id, req)
+	jsonMsgObj(c, I18nWeb(c, "pages.settings.webhooks.toasts.update"), hook, err)
+}
+
+func (a *WebhookController) del(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.webhooks.toasts.delete"), err)
+		return
+	}
+	jsonMsg(c, I18nWeb(c, "pages.settings.webhooks.toasts.delete"), a.webhookService.Delete(id))
+}
+
+// test sends a webhook.test payload once; the delivery is returned either way
+// so the UI can show the endpoint's response code.
+func (a *WebhookController) test(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.webhooks.toasts.test"), err)
+		return
+	}
+	d, err := a.webhookService.Test(id)
+	jsonMsgObj(c, I18nWeb(c, "pages.settings.webhooks.toasts.test"), d, deliveryErr(d, err))
+}
+
+func (a *WebhookController) replay(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		jsonMsg(c, I18nWeb(c, "pages.settings.webhooks.toasts.replay"), err)
+		return
+	}
+	d, err := a.webhookService.Replay(id)
+	jsonMsgObj(c, I18nWeb(c, "pages.settings.webhooks.toasts.replay"), d, deliveryErr(d, err))
+}
+
+// deliveryErr reports a send that did not succeed as the request's error.
+func deliveryErr(d *model.WebhookDelivery, err error) error {
+	if err != nil || d.Status == model.WebhookDeliverySuccess {
+		return err
+	}
+	return errors.New(d.Error)
+}
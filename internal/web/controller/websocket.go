@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service/panel"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/session"
@@ -68,6 +69,11 @@ func (w *WebSocketController) HandleWebSocket(c *gin.Context) {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
+	// The feed broadcasts every inbound and client; resellers poll their scoped REST views instead.
+	if user := session.GetLoginUser(c); user != nil && user.Role == model.UserRoleReseller {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
 
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
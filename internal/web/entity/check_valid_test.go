@@ -31,6 +31,22 @@ func TestCheckValidSmtpFrom(t *testing.T) {
 	}
 }
 
+func TestCheckValidAcmeAccount(t *testing.T) {
+	ok := &AllSetting{WebPort: 2053, SubPort: 2096, AcmeEmail: "ops@example.com", AcmeDirectoryUrl: "https://localhost:14000/dir"}
+	if err := ok.CheckValid(); err != nil {
+		t.Fatalf("valid ACME settings rejected: %v", err)
+	}
+	for _, s := range []*AllSetting{
+		{WebPort: 2053, SubPort: 2096, AcmeEmail: "ops"},
+		{WebPort: 2053, SubPort: 2096, AcmeDirectoryUrl: "ftp://ca.example.com/dir"},
+		{WebPort: 2053, SubPort: 2096, AcmeDirectoryUrl: "https://"},
+	} {
+		if err := s.CheckValid(); err == nil {
+			t.Errorf("CheckValid(email=%q, directory=%q): want error, got nil", s.AcmeEmail, s.AcmeDirectoryUrl)
+		}
+	}
+}
+
 func TestCheckValidWildcardListenPortConflict(t *testing.T) {
 	s := &AllSetting{WebPort: 2053, SubPort: 2053, WebListen: "0.0.0.0", SubListen: ""}
 	if err := s.CheckValid(); err == nil {
@@ -6,9 +6,11 @@ import (
 	"net"
 	"net/mail"
 	"net/netip"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
 	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
 )
 
@@ -61,12 +63,12 @@ type AllSetting struct {
 	SmtpEnabledEvents  string `json:"smtpEnabledEvents" form:"smtpEnabledEvents"`
 	SmtpCpu            int    `json:"smtpCpu" form:"smtpCpu" validate:"gte=0,lte=100"`
 	SmtpMemory         int    `json:"smtpMemory" form:"smtpMemory" validate:"gte=0,lte=100"`
+	WebhookCpu         int    `json:"webhookCpu" form:"webhookCpu" validate:"gte=0,lte=100"`
+	WebhookMemory      int    `json:"webhookMemory" form:"webhookMemory" validate:"gte=0,lte=100"`
 
 	OutboundDownThreshold int `json:"outboundDownThreshold" form:"outboundDownThreshold" validate:"gte=1,lte=100"`
 
-	TimeLocation    string `json:"timeLocation" form:"timeLocation"`
-	TwoFactorEnable bool   `json:"twoFactorEnable" form:"twoFactorEnable"`
-	TwoFactorToken  string `json:"twoFactorToken" form:"twoFactorToken"`
+	TimeLocation string `json:"timeLocation" form:"timeLocation"`
 
 	SubEnable                   bool   `json:"subEnable" form:"subEnable"`
 	SubJsonEnable               bool   `json:"subJsonEnable" form:"subJsonEnable"`
@@ -75,6 +77,8 @@ type AllSetting struct {
 	SubJsonUserAgentRegex       string `json:"subJsonUserAgentRegex" form:"subJsonUserAgentRegex"`
 	SubClashAutoDetect          bool   `json:"subClashAutoDetect" form:"subClashAutoDetect"`
 	SubClashUserAgentRegex      string `json:"subClashUserAgentRegex" form:"subClashUserAgentRegex"`
+	SubSingboxAutoDetect        bool   `json:"subSingboxAutoDetect" form:"subSingboxAutoDetect"`
+	SubSingboxUserAgentRegex    string `json:"subSingboxUserAgentRegex" form:"subSingboxUserAgentRegex"`
 	SubTitle                    string `json:"subTitle" form:"subTitle"`
 	SubSupportUrl               string `json:"subSupportUrl" form:"subSupportUrl"`
 	SubProfileUrl               string `json:"subProfileUrl" form:"subProfileUrl"`
@@ -102,6 +106,11 @@ type AllSetting struct {
 	SubClashURI                 string `json:"subClashURI" form:"subClashURI"`
 	SubClashEnableRouting       bool   `json:"subClashEnableRouting" form:"subClashEnableRouting"`
 	SubClashRules               string `json:"subClashRules" form:"subClashRules"`
+	SubSingboxEnable            bool   `json:"subSingboxEnable" form:"subSingboxEnable"`
+	SubSingboxPath              string `json:"subSingboxPath" form:"subSingboxPath"`
+	SubSingboxURI               string `json:"subSingboxURI" form:"subSingboxURI"`
+	SubSingboxMux               string `json:"subSingboxMux" form:"subSingboxMux"`
+	SubSingboxRules             string `json:"subSingboxRules" form:"subSingboxRules"`
 	SubJsonMux                  string `json:"subJsonMux" form:"subJsonMux"`
 	SubJsonRules                string `json:"subJsonRules" form:"subJsonRules"`
 	SubJsonFinalMask            string `json:"subJsonFinalMask" form:"subJsonFinalMask"`
@@ -131,18 +140,50 @@ type AllSetting struct {
 	LdapDefaultLimitIP     int    `json:"ldapDefaultLimitIP" form:"ldapDefaultLimitIP" validate:"gte=0"`
 
 	WarpUpdateInterval int `json:"warpUpdateInterval" form:"warpUpdateInterval" validate:"gte=0"`
+
+	AcmeEmail        string `json:"acmeEmail" form:"acmeEmail"`
+	AcmeDirectoryUrl string `json:"acmeDirectoryUrl" form:"acmeDirectoryUrl"`
+	AcmeHttpPort     int    `json:"acmeHttpPort" form:"acmeHttpPort" validate:"gte=0,lte=65535"`
+	AcmeTlsAlpnPort  int    `json:"acmeTlsAlpnPort" form:"acmeTlsAlpnPort" validate:"gte=0,lte=65535"`
+	AcmeRenewDays    int    `json:"acmeRenewDays" form:"acmeRenewDays" validate:"gte=1,lte=89"`
+
+	AuditRetentionDays  int  `json:"auditRetentionDays" form:"auditRetentionDays" validate:"gte=0"`
+	MetricsClientLabels bool `json:"metricsClientLabels" form:"metricsClientLabels"`
+
+	TrafficHistoryHourlyDays int `json:"trafficHistoryHourlyDays" form:"trafficHistoryHourlyDays" validate:"gte=1,lte=90"`
+	TrafficHistoryDays       int `json:"trafficHistoryDays" form:"trafficHistoryDays" validate:"gte=0"`
+
+	// Scheduled backups
+	BackupEnable      bool   `json:"backupEnable" form:"backupEnable"`
+	BackupCron        string `json:"backupCron" form:"backupCron"`
+	BackupKeep        int    `json:"backupKeep" form:"backupKeep" validate:"gte=0"`
+	BackupPassphrase  string `json:"backupPassphrase" form:"backupPassphrase"`
+	BackupLocalDir    string `json:"backupLocalDir" form:"backupLocalDir"`
+	BackupS3Endpoint  string `json:"backupS3Endpoint" form:"backupS3Endpoint"`
+	BackupS3Region    string `json:"backupS3Region" form:"backupS3Region"`
+	BackupS3Bucket    string `json:"backupS3Bucket" form:"backupS3Bucket"`
+	BackupS3Prefix    string `json:"backupS3Prefix" form:"backupS3Prefix"`
+	BackupS3AccessKey string `json:"backupS3AccessKey" form:"backupS3AccessKey"`
+	BackupS3SecretKey string `json:"backupS3SecretKey" form:"backupS3SecretKey"`
+	BackupS3PathStyle bool   `json:"backupS3PathStyle" form:"backupS3PathStyle"`
 }
 
 type AllSettingView struct {
 	AllSetting
 
-	HasTgBotToken     bool `json:"hasTgBotToken"`
-	HasTwoFactorToken bool `json:"hasTwoFactorToken"`
-	HasLdapPassword   bool `json:"hasLdapPassword"`
-	HasApiToken       bool `json:"hasApiToken"`
-	HasWarpSecret     bool `json:"hasWarpSecret"`
-	HasNordSecret     bool `json:"hasNordSecret"`
-	HasSmtpPassword   bool `json:"hasSmtpPassword"`
+	HasTgBotToken   bool `json:"hasTgBotToken"`
+	HasLdapPassword bool `json:"hasLdapPassword"`
+	HasApiToken     bool `json:"hasApiToken"`
+	HasWarpSecret   bool `json:"hasWarpSecret"`
+	HasNordSecret   bool `json:"hasNordSecret"`
+	HasSmtpPassword bool `json:"hasSmtpPassword"`
+
+	HasBackupPassphrase  bool `json:"hasBackupPassphrase"`
+	HasBackupS3SecretKey bool `json:"hasBackupS3SecretKey"`
+
+	// UnreadableSecrets names, by scope, the sealed values this panel's keyring
+	// cannot open. They must be entered again.
+	UnreadableSecrets []string `json:"unreadableSecrets"`
 }
 
 func pathHasForbiddenChar(s string) bool {
@@ -239,6 +280,7 @@ func (s *AllSetting) CheckValid() error {
 		{"subscription path", s.SubPath},
 		{"subscription JSON path", s.SubJsonPath},
 		{"subscription Clash path", s.SubClashPath},
+		{"subscription sing-box path", s.SubSingboxPath},
 	} {
 		if pathHasForbiddenChar(p.value) {
 			return common.NewError("URI path contains an invalid character:", p.name)
@@ -272,6 +314,13 @@ func (s *AllSetting) CheckValid() error {
 		s.SubClashPath += "/"
 	}
 
+	if !strings.HasPrefix(s.SubSingboxPath, "/") {
+		s.SubSingboxPath = "/" + s.SubSingboxPath
+	}
+	if !strings.HasSuffix(s.SubSingboxPath, "/") {
+		s.SubSingboxPath += "/"
+	}
+
 	if err := checkIPOrCIDRList(s.TrustedProxyCIDRs, "trusted proxy CIDR is not valid:"); err != nil {
 		return err
 	}
@@ -293,6 +342,18 @@ func (s *AllSetting) CheckValid() error {
 		}
 	}
 
+	if s.AcmeEmail != "" {
+		if _, err := mail.ParseAddress(s.AcmeEmail); err != nil {
+			return common.NewError("ACME account email is not valid:", s.AcmeEmail)
+		}
+	}
+	if s.AcmeDirectoryUrl != "" {
+		u, err := url.Parse(s.AcmeDirectoryUrl)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return common.NewError("ACME directory URL is not valid:", s.AcmeDirectoryUrl)
+		}
+	}
+
 	return nil
 }
 
@@ -351,3 +412,88 @@ type HostGroup struct {
 	MihomoX25519           bool     `json:"mihomoX25519"`
 	ShuffleHost            bool     `json:"shuffleHost"`
 }
+
+// AcmeIssueRequest orders a new certificate. DnsConfig holds the provider's
+// credentials and is only read for the dns-01 challenge.
+type AcmeIssueRequest struct {
+	Domains     []string          `json:"domains" validate:"required,min=1,max=100"`
+	Challenge   string            `json:"challenge" validate:"required,oneof=http-01 tls-alpn-01 dns-01"`
+	DnsProvider string            `json:"dnsProvider"`
+	DnsConfig   map[string]string `json:"dnsConfig"`
+	AutoRenew   bool              `json:"autoRenew"`
+}
+
+// BackupRestoreRequest names a stored backup to restore. KeepHostSettings
+// defaults to true, as for an uploaded import.
+type BackupRestoreRequest struct {
+	Target           string `json:"target" validate:"required,oneof=local s3"`
+	Name             string `json:"name" validate:"required,max=200"`
+	KeepHostSettings *bool  `json:"keepHostSettings"`
+}
+
+// WebhookRequest creates or edits a webhook. A blank Secret on update keeps
+// the stored one; an empty Events list subscribes to every event.
+type WebhookRequest struct {
+	Name         string   `json:"name" validate:"required,max=64"`
+	Url          string   `json:"url" validate:"required,max=2048"`
+	Secret       string   `json:"secret" validate:"max=256"`
+	Events       []string `json:"events" validate:"max=32"`
+	Enable       bool     `json:"enable"`
+	AllowPrivate bool     `json:"allowPrivate"`
+}
+
+// PlanRequest creates or edits a client plan. TotalGB is in bytes and a zero
+// DurationDays means clients made from the plan never expire.
+type PlanRequest struct {
+	Name               string `json:"name" validate:"required,max=64"`
+	Comment            string `json:"comment" validate:"max=256"`
+	TotalGB            int64  `json:"totalGB" validate:"gte=0"`
+	DurationDays       int    `json:"durationDays" validate:"gte=0,lte=36500"`
+	StartAfterFirstUse bool   `json:"startAfterFirstUse"`
+	LimitIP            int    `json:"limitIp" validate:"gte=0"`
+	LimitHwid          int    `json:"limitHwid" validate:"gte=0"`
+	TrafficReset       string `json:"trafficReset" validate:"omitempty,oneof=never hourly daily weekly monthly"`
+	TrafficResetDay    int    `json:"trafficResetDay" validate:"omitempty,gte=1,lte=31"`
+	Group              string `json:"group" validate:"max=64"`
+	InboundIds         []int  `json:"inboundIds" validate:"max=256"`
+	RenewMode          string `json:"renewMode" validate:"omitempty,oneof=extend addQuota reset"`
+}
+
+// PlanCreateRequest provisions clients from a plan. Each client needs an
+// email; the plan supplies the limits and inbounds.
+type PlanCreateRequest struct {
+	Clients []model.Client `json:"clients" validate:"required,min=1,max=1000"`
+}
+
+// PlanRenewRequest renews existing clients with a plan.
+type PlanRenewRequest struct {
+	Emails []string `json:"emails" validate:"required,min=1,max=1000"`
+}
+
+// VoucherGenerateRequest mints Count codes for a plan. Prefix is prepended to
+// every code; a zero MaxUses makes the codes reusable without limit.
+type VoucherGenerateRequest struct {
+	PlanId    int    `json:"planId" validate:"required,gt=0"`
+	Count     int    `json:"count" validate:"required,min=1,max=500"`
+	Kind      string `json:"kind" validate:"omitempty,oneof=any create topUp"`
+	MaxUses   int    `json:"maxUses" validate:"gte=0"`
+	ExpiresAt int64  `json:"expiresAt" validate:"gte=0"`
+	Prefix    string `json:"prefix" validate:"omitempty,max=16,alphanum"`
+	Comment   string `json:"comment" validate:"max=256"`
+}
+
+// VoucherUpdateRequest edits the mutable fields of a voucher.
+type VoucherUpdateRequest struct {
+	Kind      string `json:"kind" validate:"omitempty,oneof=any create topUp"`
+	MaxUses   int    `json:"maxUses" validate:"gte=0"`
+	ExpiresAt int64  `json:"expiresAt" validate:"gte=0"`
+	Enable    bool   `json:"enable"`
+	Comment   string `json:"comment" validate:"max=256"`
+}
+
+// VoucherRedeemRequest is the public redeem form. A blank SubId creates a new
+// client; otherwise that subscription is topped up.
+type VoucherRedeemRequest struct {
+	Code  string `json:"code" validate:"required,max=64"`
+	SubId string `json:"subId" validate:"max=128"`
+}
@@ -0,0 +1,31 @@
+package job
+
+import (
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+)
+
+// AccessScheduleJob takes clients out of Xray when they leave their weekly
+// access window and puts them back when it opens again.
+type AccessScheduleJob struct {
+	inboundService service.InboundService
+	xrayService    service.XrayService
+	location       *time.Location
+}
+
+// NewAccessScheduleJob creates the job; windows are read in location.
+func NewAccessScheduleJob(location *time.Location) *AccessScheduleJob {
+	return &AccessScheduleJob{location: location}
+}
+
+func (j *AccessScheduleJob) Run() {
+	needRestart, err := j.inboundService.ApplyAccessSchedules(time.Now().In(j.location))
+	if err != nil {
+		logger.Warning("apply access schedules failed:", err)
+	}
+	if needRestart {
+		j.xrayService.SetToNeedRestart()
+	}
+}
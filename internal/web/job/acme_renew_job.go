@@ -0,0 +1,30 @@
+package job
+
+import (
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+)
+
+// AcmeRenewJob restores missing certificate files and renews auto-renewing
+// ACME certificates once they enter the renewal window.
+type AcmeRenewJob struct {
+	acmeService service.AcmeService
+}
+
+func NewAcmeRenewJob() *AcmeRenewJob {
+	return &AcmeRenewJob{}
+}
+
+func (j *AcmeRenewJob) Run() {
+	if err := j.acmeService.EnsureFiles(); err != nil {
+		logger.Warning("ACME: restore certificate files:", err)
+	}
+	count, err := j.acmeService.RenewDue()
+	if err != nil {
+		logger.Warning("ACME renewal error:", err)
+		return
+	}
+	if count > 0 {
+		logger.Infof("Renewed %d ACME certificate(s)", count)
+	}
+}
@@ -0,0 +1,25 @@
+package job
+
+import (
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+)
+
+// BackupJob writes a scheduled database backup to every configured target.
+type BackupJob struct {
+	backupService service.BackupService
+}
+
+func NewBackupJob() *BackupJob {
+	return new(BackupJob)
+}
+
+func (j *BackupJob) Run() {
+	written, err := j.backupService.Run()
+	for _, m := range written {
+		logger.Infof("backup: wrote %s to %s (%d bytes)", m.Name, m.Target, m.Size)
+	}
+	if err != nil {
+		logger.Warning("scheduled backup failed:", err)
+	}
+}
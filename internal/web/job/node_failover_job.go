@@ -0,0 +1,42 @@
+package job
+
+import (
+	"sync"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/websocket"
+)
+
+// NodeFailoverJob moves inbounds to their standby node and back; the node
+// reconcile then pushes each change to the nodes involved.
+type NodeFailoverJob struct {
+	failoverService service.FailoverService
+	running         sync.Mutex
+	// lastErr keeps a failover that cannot go through (say, the port is taken
+	// on the standby node) from logging the same warning every tick.
+	lastErr string
+}
+
+func NewNodeFailoverJob() *NodeFailoverJob {
+	return &NodeFailoverJob{}
+}
+
+func (j *NodeFailoverJob) Run() {
+	if !j.running.TryLock() {
+		return
+	}
+	defer j.running.Unlock()
+
+	changed, err := j.failoverService.Reconcile()
+	if err != nil && err.Error() != j.lastErr {
+		logger.Warning("node failover:", err)
+	}
+	j.lastErr = ""
+	if err != nil {
+		j.lastErr = err.Error()
+	}
+	if changed {
+		websocket.BroadcastInvalidate(websocket.MessageTypeInbounds)
+	}
+}
@@ -0,0 +1,35 @@
+package job
+
+import (
+	"sync"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/websocket"
+)
+
+// NodeRolloutJob drives running node rollouts, holding each batch until the
+// heartbeat shows the previous one healthy on the target version.
+type NodeRolloutJob struct {
+	nodeService service.NodeService
+	running     sync.Mutex
+}
+
+func NewNodeRolloutJob() *NodeRolloutJob {
+	return &NodeRolloutJob{}
+}
+
+func (j *NodeRolloutJob) Run() {
+	if !j.running.TryLock() {
+		return
+	}
+	defer j.running.Unlock()
+
+	changed, err := j.nodeService.AdvanceNodeRollouts()
+	if err != nil {
+		logger.Warning("node rollout:", err)
+	}
+	for _, r := range changed {
+		websocket.BroadcastNodeRollout(r)
+	}
+}
@@ -0,0 +1,355 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+)
+
+const (
+	nodeTelemetryConcurrency = 8
+	// nodeTelemetryOfferRetry is how long a node that was offered a stream
+	// has to open it before it is offered again.
+	nodeTelemetryOfferRetry = 15 * time.Second
+)
+
+// nodeTelemetryFeed is one node's live telemetry stream and the traffic view
+// its frames have built up.
+type nodeTelemetryFeed struct {
+	remote *runtime.Remote
+	stop   func()
+
+	mu      sync.Mutex
+	status  string
+	traffic runtime.TrafficState
+	// held is the latest traffic frame whose view has not been merged yet,
+	// because the node had not settled every write this panel made to it.
+	held   *runtime.TrafficFrame
+	active []string
+}
+
+type telemetryOfferAt struct {
+	at      time.Time
+	latency time.Duration
+}
+
+var (
+	telemetryFeedsMu sync.Mutex
+	telemetryFeeds   = map[int]*nodeTelemetryFeed{}
+	// telemetryOffers is when each node was last offered a stream, and how
+	// long the offer took to answer: the node's latency while it streams.
+	telemetryOffers = map[int]telemetryOfferAt{}
+	// telemetryStructural is set when a pushed traffic frame changed inbounds
+	// or clients; the traffic sync job broadcasts the invalidation.
+	telemetryStructural atomicBool
+	// nodeMergeLocks keeps one node's traffic merges, and the outbox replay
+	// and reconcile that must come before them, from running concurrently.
+	nodeMergeLocks sync.Map
+)
+
+func nodeMergeLock(nodeID int) *sync.Mutex {
+	l, _ := nodeMergeLocks.LoadOrStore(nodeID, &sync.Mutex{})
+	return l.(*sync.Mutex)
+}
+
+// liveTelemetry returns the node's feed while its stream is open and still
+// bound to rt, the node's current Remote; nil means poll the node instead.
+func liveTelemetry(nodeID int, rt *runtime.Remote) *nodeTelemetryFeed {
+	telemetryFeedsMu.Lock()
+	defer telemetryFeedsMu.Unlock()
+	f := telemetryFeeds[nodeID]
+	if f == nil || (rt != nil && f.remote != rt) {
+		return nil
+	}
+	return f
+}
+
+// offerLatency is how long the node took to answer its last telemetry offer.
+func offerLatency(nodeID int) time.Duration {
+	telemetryFeedsMu.Lock()
+	defer telemetryFeedsMu.Unlock()
+	return telemetryOffers[nodeID].latency
+}
+
+// StopNodeTelemetry ends every node telemetry stream. Called when this panel
+// stops running the node jobs.
+func StopNodeTelemetry() {
+	telemetryFeedsMu.Lock()
+	defer telemetryFeedsMu.Unlock()
+	for id, f := range telemetryFeeds {
+		f.stop()
+		delete(telemetryFeeds, id)
+	}
+	clear(telemetryOffers)
+}
+
+// ReceiveNodeTelemetry applies node n's frames as they arrive, in place of the
+// heartbeat and traffic polls, until next fails or the stream is stopped.
+func ReceiveNodeTelemetry(n *model.Node, next func() (runtime.TelemetryFrame, error), interrupt func()) error {
+	mgr := runtime.GetManager()
+	if mgr == nil {
+		return errors.New("node runtime is not running")
+	}
+	rt, err := mgr.RemoteFor(n)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := &nodeTelemetryFeed{remote: rt, status: n.Status, stop: func() { cancel(); interrupt() }}
+
+	telemetryFeedsMu.Lock()
+	if prev := telemetryFeeds[n.Id]; prev != nil {
+		prev.stop()
+	}
+	telemetryFeeds[n.Id] = f
+	telemetryFeedsMu.Unlock()
+	defer func() {
+		telemetryFeedsMu.Lock()
+		if telemetryFeeds[n.Id] == f {
+			delete(telemetryFeeds, n.Id)
+		}
+		telemetryFeedsMu.Unlock()
+	}()
+
+	for ctx.Err() == nil {
+		fr, err := next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := f.apply(n, fr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply handles one frame from node n's stream.
+func (f *nodeTelemetryFeed) apply(n *model.Node, fr runtime.TelemetryFrame) error {
+	nodeService := service.NodeService{}
+	inboundService := service.InboundService{}
+	switch fr.Type {
+	case runtime.TelemetryStatus:
+		patch, err := service.HeartbeatFromTelemetry(fr.Data, time.Now(), offerLatency(n.Id))
+		if err != nil {
+			return err
+		}
+		if err := nodeService.UpdateHeartbeat(n.Id, patch); err != nil {
+			logger.Warning("node telemetry: update node", n.Id, "failed:", err)
+		}
+		f.mu.Lock()
+		prevStatus := f.status
+		f.status = patch.Status
+		f.mu.Unlock()
+		publishNodeTransition(n, prevStatus, patch)
+	case runtime.TelemetryDescendants:
+		var summaries []model.NodeSummary
+		if err := json.Unmarshal(fr.Data, &summaries); err != nil {
+			return fmt.Errorf("decode descendants frame: %w", err)
+		}
+		nodeService.SetDescendants(n.Id, summaries)
+	case runtime.TelemetryTraffic:
+		var tf runtime.TrafficFrame
+		if err := json.Unmarshal(fr.Data, &tf); err != nil {
+			return fmt.Errorf("decode traffic frame: %w", err)
+		}
+		f.mu.Lock()
+		err := f.traffic.Apply(&tf)
+		f.held = &tf
+		f.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		f.mergeHeld(n.Id)
+	case runtime.TelemetryClientIps:
+		var ips []model.InboundClientIps
+		if err := json.Unmarshal(fr.Data, &ips); err != nil {
+			return fmt.Errorf("decode client ips frame: %w", err)
+		}
+		if len(ips) > 0 {
+			if err := inboundService.MergeInboundClientIps(ips); err != nil {
+				logger.Warningf("node telemetry: merge client ips from %s failed: %v", n.Name, err)
+			}
+		}
+	case runtime.TelemetryClientIpsByGuid:
+		var trees map[string]map[string][]model.ClientIpEntry
+		if err := json.Unmarshal(fr.Data, &trees); err != nil {
+			return fmt.Errorf("decode client ip attribution frame: %w", err)
+		}
+		if len(trees) > 0 {
+			if err := inboundService.MergeClientIpsByGuid(n, trees); err != nil {
+				logger.Warningf("node telemetry: merge client ip attribution from %s failed: %v", n.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeHeld merges only once the node reports every write of ours settled; an
+// older view could roll one back, so it stays held for the next frame or tick.
+func (f *nodeTelemetryFeed) mergeHeld(nodeID int) {
+	lock := nodeMergeLock(nodeID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	f.mu.Lock()
+	held := f.held
+	if held == nil || !f.remote.TrafficFrameCurrent(held, time.Now()) {
+		f.mu.Unlock()
+		return
+	}
+	snap := f.traffic.Snapshot()
+	f.mu.Unlock()
+
+	// Merge against the node as it is now, and only while the stream still
+	// speaks for it.
+	n, err := (&service.NodeService{}).GetById(nodeID)
+	if err != nil || !n.Enable {
+		return
+	}
+	mgr := runtime.GetManager()
+	if mgr == nil {
+		return
+	}
+	if rt, err := mgr.RemoteFor(n); err != nil || rt != f.remote {
+		return
+	}
+	active, changed, err := mergeNodeTraffic(f.remote, n, snap)
+	if err != nil {
+		logger.Warningf("node telemetry: merge for %s failed: %v", n.Name, err)
+		return
+	}
+	if changed {
+		telemetryStructural.set()
+	}
+	f.mu.Lock()
+	if f.held == held {
+		f.held = nil
+	}
+	f.active = active
+	f.mu.Unlock()
+}
+
+// activeEmails returns the emails online on the node as of its last merged
+// traffic frame.
+func (f *nodeTelemetryFeed) activeEmails() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.active
+}
+
+// NodeTelemetryJob offers a stream to online nodes with the capability; the
+// polling jobs skip a node only while its stream is live.
+type NodeTelemetryJob struct {
+	nodeService service.NodeService
+	running     sync.Mutex
+}
+
+func NewNodeTelemetryJob() *NodeTelemetryJob {
+	return &NodeTelemetryJob{}
+}
+
+func (j *NodeTelemetryJob) Run() {
+	if !j.running.TryLock() {
+		return
+	}
+	defer j.running.Unlock()
+
+	mgr := runtime.GetManager()
+	if mgr == nil {
+		return
+	}
+	nodes, err := j.nodeService.GetAll()
+	if err != nil {
+		logger.Warning("node telemetry: load nodes failed:", err)
+		return
+	}
+
+	now := time.Now()
+	enabled := make(map[int]struct{}, len(nodes))
+	var offer []*model.Node
+	var remotes []*runtime.Remote
+	telemetryFeedsMu.Lock()
+	for _, n := range nodes {
+		if !n.Enable {
+			continue
+		}
+		enabled[n.Id] = struct{}{}
+		// The stream authenticates with the node's API token, so a node
+		// reached by client certificate alone keeps being polled.
+		if n.Status != "online" || n.ApiToken == "" {
+			continue
+		}
+		rt, err := mgr.RemoteFor(n)
+		if err != nil || !rt.SupportsTelemetry() {
+			continue
+		}
+		if f := telemetryFeeds[n.Id]; f != nil && f.remote == rt {
+			continue
+		}
+		if now.Sub(telemetryOffers[n.Id].at) < nodeTelemetryOfferRetry {
+			continue
+		}
+		telemetryOffers[n.Id] = telemetryOfferAt{at: now}
+		offer = append(offer, n)
+		remotes = append(remotes, rt)
+	}
+	for id, f := range telemetryFeeds {
+		if _, ok := enabled[id]; !ok {
+			f.stop()
+			delete(telemetryFeeds, id)
+		}
+	}
+	telemetryFeedsMu.Unlock()
+	if len(offer) == 0 {
+		return
+	}
+
+	sem := make(chan struct{}, nodeTelemetryConcurrency)
+	var wg sync.WaitGroup
+	for i, n := range offer {
+		wg.Add(1)
+		sem <- struct{}{}
+		rt := remotes[i]
+		common.GoRecover("node-telemetry:"+n.Name, func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			j.offerOne(n, rt)
+		})
+	}
+	wg.Wait()
+}
+
+func (j *NodeTelemetryJob) offerOne(n *model.Node, rt *runtime.Remote) {
+	o, err := j.nodeService.TelemetryOffer(n.Id)
+	if err != nil {
+		logger.Debugf("node telemetry: build offer for %s failed: %v", n.Name, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), nodeHeartbeatRequestTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := rt.OfferTelemetry(ctx, o); err != nil {
+		logger.Debugf("node telemetry: offer to %s failed: %v", n.Name, err)
+		return
+	}
+	latency := time.Since(start)
+	telemetryFeedsMu.Lock()
+	if at, ok := telemetryOffers[n.Id]; ok {
+		at.latency = latency
+		telemetryOffers[n.Id] = at
+	}
+	telemetryFeedsMu.Unlock()
+}
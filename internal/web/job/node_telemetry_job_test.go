@@ -0,0 +1,106 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/wirecodec"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+)
+
+// TestReceiveNodeTelemetry: a view built before a write settled is held, not
+// merged, so it cannot roll that write back.
+func TestReceiveNodeTelemetry(t *testing.T) {
+	setupIntegrationDB(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set(wirecodec.WriteSeqHeader, "e:1")
+		}
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	node := &model.Node{
+		Name: "n1", Scheme: "http", Address: u.Hostname(), Port: port, BasePath: "/",
+		Enable: true, Status: "online", TlsVerifyMode: "mtls", AllowPrivateAddress: true,
+	}
+	if err := database.GetDB().Create(node).Error; err != nil {
+		t.Fatal(err)
+	}
+	prev := runtime.GetManager()
+	runtime.SetManager(runtime.NewManager(runtime.LocalDeps{APIPort: func() int { return 0 }, SetNeedRestart: func() {}}))
+	t.Cleanup(func() { runtime.SetManager(prev) })
+	t.Cleanup(StopNodeTelemetry)
+	rt, err := runtime.GetManager().RemoteFor(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := make(chan runtime.TelemetryFrame)
+	done := make(chan error, 1)
+	go func() {
+		done <- ReceiveNodeTelemetry(node, func() (runtime.TelemetryFrame, error) {
+			f, ok := <-frames
+			if !ok {
+				return runtime.TelemetryFrame{}, io.EOF
+			}
+			return f, nil
+		}, func() {})
+	}()
+	// Frames are applied in order; sending the next one means the previous
+	// one was handled.
+	send := func(tf runtime.TrafficFrame) {
+		t.Helper()
+		data, _ := json.Marshal(tf)
+		frames <- runtime.TelemetryFrame{Type: runtime.TelemetryTraffic, Data: data}
+		frames <- runtime.TelemetryFrame{Type: runtime.TelemetryPing}
+	}
+	remark := func() string {
+		t.Helper()
+		var ib model.Inbound
+		if err := database.GetDB().Where("node_id = ?", node.Id).First(&ib).Error; err != nil {
+			t.Fatalf("load node inbound: %v", err)
+		}
+		return ib.Remark
+	}
+	inbound := func(remark string) []*model.Inbound {
+		return []*model.Inbound{{Id: 4, Tag: "in-a", Remark: remark, Port: 443, Protocol: model.VLESS, Enable: true, Settings: `{"clients":[]}`}}
+	}
+
+	send(runtime.TrafficFrame{Full: true, Epoch: "e", Inbounds: inbound("one")})
+	if liveTelemetry(node.Id, rt) == nil {
+		t.Fatal("the stream is not live")
+	}
+	if got := remark(); got != "one" {
+		t.Fatalf("pushed inbound merged with remark %q, want one", got)
+	}
+
+	if err := rt.DeleteClient(context.Background(), "bob"); err != nil {
+		t.Fatal(err)
+	}
+	send(runtime.TrafficFrame{Epoch: "e", Inbounds: inbound("two")})
+	if got := remark(); got != "one" {
+		t.Fatalf("a view from before the write was merged: remark %q", got)
+	}
+	send(runtime.TrafficFrame{Epoch: "e", Settled: 1})
+	if got := remark(); got != "two" {
+		t.Fatalf("the held view was not merged once the write settled: remark %q", got)
+	}
+
+	close(frames)
+	if err := <-done; err != io.EOF {
+		t.Fatalf("receiver ended with %v, want EOF", err)
+	}
+	if liveTelemetry(node.Id, nil) != nil {
+		t.Fatal("the node still counts as streaming after its stream ended")
+	}
+}
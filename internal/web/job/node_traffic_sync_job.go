@@ -231,7 +231,8 @@ func (j *NodeTrafficSyncJob) Run() {
 		websocket.BroadcastClientStats(clientStats)
 	}
 
-	if j.structural.takeAndReset() {
+	pushed := telemetryStructural.takeAndReset()
+	if j.structural.takeAndReset() || pushed {
 		websocket.BroadcastInvalidate(websocket.MessageTypeInbounds)
 		websocket.BroadcastInvalidate(websocket.MessageTypeClients)
 	}
@@ -353,9 +354,8 @@ func (j *NodeTrafficSyncJob) maybePushGlobals(mgr *runtime.Manager, nodes []*mod
 	wg.Wait()
 }
 
-// syncOne pulls one node's traffic snapshot and merges it. It returns the
-// emails online on that node this tick, feeding the delta broadcast above the
-// snapshot threshold; nil on any failure path.
+// syncOne returns the emails online on the node this tick, or nil on failure.
+// A node with a live telemetry stream has its traffic merged as it arrives.
 func (j *NodeTrafficSyncJob) syncOne(mgr *runtime.Manager, n *model.Node, doIpSync bool) []string {
 	rt, err := mgr.RemoteFor(n)
 	if err != nil {
@@ -363,7 +363,93 @@ func (j *NodeTrafficSyncJob) syncOne(mgr *runtime.Manager, n *model.Node, doIpSy
 		return nil
 	}
 
-	if n.ConfigDirty {
+	lock := nodeMergeLock(n.Id)
+	lock.Lock()
+	feed := liveTelemetry(n.Id, rt)
+	active, ok := j.syncTraffic(rt, n, feed == nil)
+	lock.Unlock()
+	if feed != nil {
+		// Merge a pushed view that was held back for a write the node had not
+		// settled when it arrived.
+		feed.mergeHeld(n.Id)
+		active, ok = feed.activeEmails(), true
+	}
+	if !ok {
+		return nil
+	}
+
+	if !doIpSync {
+		return active
+	}
+
+	ipCtx, ipCancel := context.WithTimeout(context.Background(), nodeClientIpSyncTimeout)
+	defer ipCancel()
+
+	if feed == nil {
+		nodeIps, err := rt.FetchAllClientIps(ipCtx)
+		if err == nil && len(nodeIps) > 0 {
+			if err := j.inboundService.MergeInboundClientIps(nodeIps); err != nil {
+				logger.Warningf("node traffic sync: merge client ips from %s failed: %v", n.Name, err)
+			}
+		} else if err != nil {
+			logger.Warningf("node traffic sync: fetch client ips from %s failed: %v", n.Name, err)
+		}
+	}
+
+	masterIps, err := j.inboundService.GetAllInboundClientIps()
+	if err != nil {
+		logger.Warningf("node traffic sync: load client ips for push to %s failed: %v", n.Name, err)
+		return active
+	}
+	if len(masterIps) > 0 {
+		if err := rt.PushAllClientIps(ipCtx, masterIps); err != nil {
+			logger.Warningf("node traffic sync: push client ips to %s failed: %v", n.Name, err)
+		}
+	}
+	if feed != nil {
+		return active
+	}
+
+	// Per-node IP attribution: pull the node's guid-keyed subtree (its own
+	// observations plus any descendants) so the master can tell which node each
+	// IP is on. Old nodes without the endpoint return HTTP 404 every cycle — note
+	// it once per node (re-armed on recovery) instead of flooding the log.
+	if guidTrees, err := rt.FetchClientIpsByGuid(ipCtx); err != nil {
+		if strings.Contains(err.Error(), "HTTP 404") {
+			if _, seen := j.noGuidIpEndpoint.LoadOrStore(n.Id, true); !seen {
+				logger.Debugf("node traffic sync: node %s has no client-IP attribution endpoint (old build)", n.Name)
+			}
+		} else {
+			logger.Debugf("node traffic sync: fetch client ip attribution from %s failed: %v", n.Name, err)
+		}
+	} else {
+		j.noGuidIpEndpoint.Delete(n.Id)
+		if len(guidTrees) > 0 {
+			if err := j.inboundService.MergeClientIpsByGuid(n, guidTrees); err != nil {
+				logger.Warningf("node traffic sync: merge client ip attribution from %s failed: %v", n.Name, err)
+			}
+		}
+	}
+	return active
+}
+
+// syncTraffic always replays or reconciles the outbox first; only then may a
+// snapshot be merged. The caller holds the node's merge lock.
+func (j *NodeTrafficSyncJob) syncTraffic(rt *runtime.Remote, n *model.Node, fetch bool) ([]string, bool) {
+	// Replay before anything else: a reconcile now would push current state
+	// that the older queued operations would then roll back.
+	replayCtx, replayCancel := context.WithTimeout(context.Background(), nodeReconcileTimeout)
+	queued, replayErr := j.nodeService.ReplayNodeOutbox(replayCtx, rt, n.Id)
+	replayCancel()
+	if replayErr != nil {
+		logger.Warningf("node traffic sync: outbox replay for %s stopped: %v", n.Name, replayErr)
+	}
+	// An entry the node refuses falls back to a full reconcile, which pushes
+	// the state the queue was leading to and so settles every entry before it.
+	fallback := replayErr != nil && !runtime.IsNodeUnreachable(replayErr)
+
+	if (n.ConfigDirty && !queued) || fallback {
+		watermark, _ := j.nodeService.NodeOutboxWatermark(n.Id)
 		reconcileCtx, reconcileCancel := context.WithTimeout(context.Background(), nodeReconcileTimeout)
 		reconcileErr := j.inboundService.ReconcileNode(reconcileCtx, rt, n)
 		reconcileCancel()
@@ -376,9 +462,17 @@ func (j *NodeTrafficSyncJob) syncOne(mgr *runtime.Manager, n *model.Node, doIpSy
 			if clearErr := j.nodeService.ClearNodeDirty(n.Id, n.ConfigDirtyAt); clearErr != nil {
 				logger.Warningf("node traffic sync: clear dirty for %s failed: %v", n.Name, clearErr)
 			}
+			if fallback {
+				if dropErr := j.nodeService.DropNodeOutbox(n.Id, watermark); dropErr != nil {
+					logger.Warningf("node traffic sync: drop settled outbox for %s failed: %v", n.Name, dropErr)
+				}
+			}
 			j.structural.set()
 		}
 	}
+	if !fetch {
+		return nil, true
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), nodeTrafficSyncRequestTimeout)
 	defer cancel()
@@ -387,14 +481,30 @@ func (j *NodeTrafficSyncJob) syncOne(mgr *runtime.Manager, n *model.Node, doIpSy
 	if err != nil {
 		logger.Warningf("node traffic sync: fetch from %s failed: %v", n.Name, err)
 		j.inboundService.ClearNodeOnlineClients(n.Id)
-		return nil
+		return nil, false
 	}
+	active, changed, err := mergeNodeTraffic(rt, n, snap)
+	if err != nil {
+		logger.Warningf("node traffic sync: merge for %s failed: %v", n.Name, err)
+		return nil, false
+	}
+	if changed {
+		j.structural.set()
+	}
+	return active, true
+}
+
+// mergeNodeTraffic returns the emails online on the node and whether the
+// merge changed inbounds or clients.
+func mergeNodeTraffic(rt *runtime.Remote, n *model.Node, snap *runtime.TrafficSnapshot) ([]string, bool, error) {
+	nodeService := service.NodeService{}
+	inboundService := service.InboundService{}
 	snap.ManagedAliases = rt.AdoptedInboundAliases()
 	syncCanAdopt := syncCanAdoptInbounds(n, snap.ManagedAliases)
 	service.FilterNodeSnapshot(n, snap)
-	_, _, dirty, _, _ := j.nodeService.NodeSyncState(n.Id)
+	_, _, dirty, _, _ := nodeService.NodeSyncState(n.Id)
 	if !dirty {
-		if pending, checkErr := j.inboundService.SnapshotHasUnadoptedInbounds(n.Id, snap); checkErr != nil {
+		if pending, checkErr := inboundService.SnapshotHasUnadoptedInbounds(n.Id, snap); checkErr != nil {
 			logger.Warningf("node traffic sync: unadopted-inbound check for %s failed: %v", n.Name, checkErr)
 		} else if pending {
 			hostCtx, hostCancel := context.WithTimeout(context.Background(), nodeTrafficSyncRequestTimeout)
@@ -407,16 +517,12 @@ func (j *NodeTrafficSyncJob) syncOne(mgr *runtime.Manager, n *model.Node, doIpSy
 			}
 		}
 	}
-	changed, err := j.inboundService.SetRemoteTraffic(n.Id, snap, dirty)
+	changed, err := inboundService.SetRemoteTraffic(n.Id, snap, dirty)
 	if err != nil {
-		logger.Warningf("node traffic sync: merge for %s failed: %v", n.Name, err)
-		return nil
-	}
-	if changed {
-		j.structural.set()
+		return nil, false, err
 	}
 	if !dirty && n.InboundsAdoptedAt == 0 && syncCanAdopt {
-		if markErr := j.nodeService.MarkNodeInboundsAdopted(n.Id); markErr != nil {
+		if markErr := nodeService.MarkNodeInboundsAdopted(n.Id); markErr != nil {
 			logger.Warningf("node traffic sync: mark inbounds adopted for %s failed: %v", n.Name, markErr)
 		}
 	}
@@ -426,55 +532,7 @@ func (j *NodeTrafficSyncJob) syncOne(mgr *runtime.Manager, n *model.Node, doIpSy
 	for _, emails := range snap.OnlineTree {
 		active = append(active, emails...)
 	}
-
-	if !doIpSync {
-		return active
-	}
-
-	ipCtx, ipCancel := context.WithTimeout(context.Background(), nodeClientIpSyncTimeout)
-	defer ipCancel()
-
-	nodeIps, err := rt.FetchAllClientIps(ipCtx)
-	if err == nil && len(nodeIps) > 0 {
-		if err := j.inboundService.MergeInboundClientIps(nodeIps); err != nil {
-			logger.Warningf("node traffic sync: merge client ips from %s failed: %v", n.Name, err)
-		}
-	} else if err != nil {
-		logger.Warningf("node traffic sync: fetch client ips from %s failed: %v", n.Name, err)
-	}
-
-	masterIps, err := j.inboundService.GetAllInboundClientIps()
-	if err != nil {
-		logger.Warningf("node traffic sync: load client ips for push to %s failed: %v", n.Name, err)
-		return active
-	}
-	if len(masterIps) > 0 {
-		if err := rt.PushAllClientIps(ipCtx, masterIps); err != nil {
-			logger.Warningf("node traffic sync: push client ips to %s failed: %v", n.Name, err)
-		}
-	}
-
-	// Per-node IP attribution: pull the node's guid-keyed subtree (its own
-	// observations plus any descendants) so the master can tell which node each
-	// IP is on. Old nodes without the endpoint return HTTP 404 every cycle — note
-	// it once per node (re-armed on recovery) instead of flooding the log.
-	if guidTrees, err := rt.FetchClientIpsByGuid(ipCtx); err != nil {
-		if strings.Contains(err.Error(), "HTTP 404") {
-			if _, seen := j.noGuidIpEndpoint.LoadOrStore(n.Id, true); !seen {
-				logger.Debugf("node traffic sync: node %s has no client-IP attribution endpoint (old build)", n.Name)
-			}
-		} else {
-			logger.Debugf("node traffic sync: fetch client ip attribution from %s failed: %v", n.Name, err)
-		}
-	} else {
-		j.noGuidIpEndpoint.Delete(n.Id)
-		if len(guidTrees) > 0 {
-			if err := j.inboundService.MergeClientIpsByGuid(n, guidTrees); err != nil {
-				logger.Warningf("node traffic sync: merge client ip attribution from %s failed: %v", n.Name, err)
-			}
-		}
-	}
-	return active
+	return active, changed, nil
 }
 
 // Whether this sync can perform the "first clean adoption" that
@@ -0,0 +1,32 @@
+package job
+
+import (
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+)
+
+// PruneAuditLogsJob deletes audit entries older than the configured retention.
+type PruneAuditLogsJob struct {
+	auditService   service.AuditService
+	settingService service.SettingService
+}
+
+func NewPruneAuditLogsJob() *PruneAuditLogsJob {
+	return new(PruneAuditLogsJob)
+}
+
+func (j *PruneAuditLogsJob) Run() {
+	days, err := j.settingService.GetAuditRetentionDays()
+	if err != nil {
+		logger.Warning("prune audit log: read retention:", err)
+		return
+	}
+	pruned, err := j.auditService.Prune(days)
+	if err != nil {
+		logger.Warning("prune audit log failed:", err)
+		return
+	}
+	if pruned > 0 {
+		logger.Infof("prune audit log: removed %d entries older than %d days", pruned, days)
+	}
+}
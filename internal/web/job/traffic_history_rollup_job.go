@@ -0,0 +1,27 @@
+package job
+
+import (
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+)
+
+// TrafficHistoryRollupJob folds aged hourly client traffic into daily buckets
+// and drops daily buckets past their retention.
+type TrafficHistoryRollupJob struct {
+	trafficHistoryService service.TrafficHistoryService
+}
+
+func NewTrafficHistoryRollupJob() *TrafficHistoryRollupJob {
+	return new(TrafficHistoryRollupJob)
+}
+
+func (j *TrafficHistoryRollupJob) Run() {
+	removed, err := j.trafficHistoryService.Rollup()
+	if err != nil {
+		logger.Warning("traffic history rollup failed:", err)
+		return
+	}
+	if removed > 0 {
+		logger.Debugf("traffic history rollup: compacted %d rows", removed)
+	}
+}
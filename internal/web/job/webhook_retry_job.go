@@ -0,0 +1,25 @@
+package job
+
+import (
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+)
+
+// WebhookRetryJob resends webhook deliveries whose backoff has elapsed and
+// drops settled ones past the log's retention.
+type WebhookRetryJob struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookRetryJob() *WebhookRetryJob {
+	return new(WebhookRetryJob)
+}
+
+func (j *WebhookRetryJob) Run() {
+	if _, err := j.webhookService.RetryDue(); err != nil {
+		logger.Warning("webhook retry failed:", err)
+	}
+	if _, err := j.webhookService.PruneDeliveries(); err != nil {
+		logger.Warning("prune webhook deliveries failed:", err)
+	}
+}
@@ -0,0 +1,76 @@
+package network
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+)
+
+const certReloadInterval = 10 * time.Second
+
+// CertReloader serves a key pair from disk and picks up replaced files on a
+// later handshake, so a renewed certificate applies without a restart.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// NewCertReloader loads the pair once and fails like tls.LoadX509KeyPair would.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, interval: certReloadInterval}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	r.cert = &cert
+	r.modTime = r.latestModTime()
+	r.checked = time.Now()
+	return r, nil
+}
+
+// GetCertificate is a tls.Config.GetCertificate callback.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) >= r.interval {
+		r.checked = time.Now()
+		r.reloadIfChanged()
+	}
+	return r.cert, nil
+}
+
+// reloadIfChanged keeps serving the old pair when the new one does not load
+// (e.g. only one file replaced so far) and retries on the next check.
+func (r *CertReloader) reloadIfChanged() {
+	modTime := r.latestModTime()
+	if modTime.Equal(r.modTime) {
+		return
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		logger.Warning("Reload certificate", r.certFile, "failed, keeping the current one:", err)
+		return
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	logger.Info("Reloaded certificate", r.certFile)
+}
+
+func (r *CertReloader) latestModTime() time.Time {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
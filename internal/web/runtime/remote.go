@@ -76,6 +76,20 @@ type remoteAPIError struct{ msg string }
 
 func (e *remoteAPIError) Error() string { return "remote: " + e.msg }
 
+// remoteUnreachableError marks a request the node never answered: a network
+// failure, a timeout, or a gateway in front of it reporting the node down.
+type remoteUnreachableError struct{ err error }
+
+func (e *remoteUnreachableError) Error() string { return e.err.Error() }
+func (e *remoteUnreachableError) Unwrap() error { return e.err }
+
+// IsNodeUnreachable reports whether err means the node could not be reached,
+// as opposed to the node answering and refusing the request.
+func IsNodeUnreachable(err error) bool {
+	var u *remoteUnreachableError
+	return errors.As(err, &u)
+}
+
 type Remote struct {
 	node *model.Node
 
@@ -90,6 +104,12 @@ type Remote struct {
 	// seen, config pushes to this node are zstd-compressed. Old nodes never set
 	// it, so they keep receiving plain bodies (mixed-version safe).
 	supportsZstd bool
+	// supportsTelemetry is learned the same way; such a node can push its
+	// status, traffic and client IPs over one stream instead of being polled.
+	supportsTelemetry bool
+	// lastWrite is the last call that may have changed the node's inbounds or
+	// clients; see TrafficFrameCurrent.
+	lastWrite nodeWrite
 
 	// Per-node client honoring the TLS verify mode, built once and reused; a
 	// node config change drops the cached Remote so the next one rebuilds it.
@@ -130,14 +150,44 @@ func (r *Remote) nodeSupportsZstd() bool {
 // recordCaps learns the node's capabilities from a response header so later
 // pushes can use the negotiated envelope.
 func (r *Remote) recordCaps(h http.Header) {
-	if !strings.Contains(h.Get(wirecodec.CapsHeader), wirecodec.CapZstd) {
+	caps := h.Get(wirecodec.CapsHeader)
+	zstd := strings.Contains(caps, wirecodec.CapZstd)
+	telemetry := strings.Contains(caps, wirecodec.CapTelemetry)
+	if !zstd && !telemetry {
 		return
 	}
 	r.mu.Lock()
-	r.supportsZstd = true
+	r.supportsZstd = r.supportsZstd || zstd
+	r.supportsTelemetry = r.supportsTelemetry || telemetry
 	r.mu.Unlock()
 }
 
+// snapshotNeutralPaths are node calls that leave the inbounds and clients a
+// traffic snapshot reports untouched: POST reads and pushes of display data.
+var snapshotNeutralPaths = map[string]struct{}{
+	"panel/api/clients/onlinesByGuid":       {},
+	"panel/api/clients/onlines":             {},
+	"panel/api/clients/lastOnline":          {},
+	"panel/api/clients/activeInbounds":      {},
+	"panel/api/clients/clientIpsByGuid":     {},
+	"panel/api/inbounds/pushClientTraffics": {},
+	"panel/api/server/clientIps":            {},
+	TelemetryOfferPath:                      {},
+}
+
+// authorize attaches the node's bearer token, if it has one.
+func (r *Remote) authorize(req *http.Request) error {
+	if r.node.ApiToken == "" {
+		return nil
+	}
+	token, err := nodetoken.Decrypt(r.node.Id, r.node.ApiToken)
+	if err != nil {
+		return fmt.Errorf("decrypt node token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
 // httpClient lazily builds and caches the per-node client honoring the TLS
 // verify mode, so Remote ops don't fall back to system CA on skip/pin (#5264).
 func (r *Remote) httpClient() (*http.Client, error) {
@@ -229,12 +279,8 @@ func (r *Remote) do(ctx context.Context, method, path string, body any) (*envelo
 	if err != nil {
 		return nil, err
 	}
-	if r.node.ApiToken != "" {
-		token, err := nodetoken.Decrypt(r.node.Id, r.node.ApiToken)
-		if err != nil {
-			return nil, fmt.Errorf("decrypt node token: %w", err)
-		}
-		req.Header.Set("Authorization", "Bearer "+token)
+	if err := r.authorize(req); err != nil {
+		return nil, err
 	}
 	req.Header.Set("Accept", "application/json")
 	if contentType != "" {
@@ -251,24 +297,41 @@ func (r *Remote) do(ctx context.Context, method, path string, body any) (*envelo
 	if err != nil {
 		return nil, err
 	}
+	// Record writes, failed ones included: the node may have applied a change
+	// whose answer never made it back.
+	_, neutral := snapshotNeutralPaths[path]
+	isWrite := method != http.MethodGet && !neutral
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("%s %s: %w", method, path, err)
+		if isWrite {
+			r.recordWrite("")
+		}
+		return nil, &remoteUnreachableError{fmt.Errorf("%s %s: %w", method, path, err)}
 	}
 	defer resp.Body.Close()
 	r.recordCaps(resp.Header)
+	if stamp := writeStampOf(resp.Header); isWrite && stamp != "" {
+		r.recordWrite(stamp)
+	}
 
 	// Validate status before reading a success payload: a non-OK response's
 	// body is never used beyond a short diagnostic, so don't let a node force us
 	// to buffer a large body just to return an HTTP error.
 	if resp.StatusCode != http.StatusOK {
 		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, errBodyDiagBytes))
+		var statusErr error
 		if msg := bytes.TrimSpace(snippet); len(msg) > 0 {
 			// %q quotes/escapes the untrusted node body so control characters or
 			// newlines in it can't garble or inject into the error/log output.
-			return nil, fmt.Errorf("%s %s: HTTP %d: %q", method, path, resp.StatusCode, msg)
+			statusErr = fmt.Errorf("%s %s: HTTP %d: %q", method, path, resp.StatusCode, msg)
+		} else {
+			statusErr = fmt.Errorf("%s %s: HTTP %d", method, path, resp.StatusCode)
+		}
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return nil, &remoteUnreachableError{statusErr}
 		}
-		return nil, fmt.Errorf("%s %s: HTTP %d", method, path, resp.StatusCode)
+		return nil, statusErr
 	}
 
 	// Fast-fail on an honestly-declared oversize body; the LimitReader below is
@@ -410,7 +473,7 @@ func (r *Remote) refreshRemoteIDs(ctx context.Context) error {
 }
 
 func (r *Remote) AddInbound(ctx context.Context, ib *model.Inbound) error {
-	payload := wireInbound(ib, r.node.Id)
+	payload := r.wire(ib)
 	env, err := r.do(ctx, http.MethodPost, "panel/api/inbounds/add", payload)
 	if err != nil {
 		return err
@@ -446,7 +509,7 @@ func (r *Remote) UpdateInbound(ctx context.Context, oldIb, newIb *model.Inbound)
 	if err != nil {
 		return r.AddInbound(ctx, newIb)
 	}
-	payload := wireInbound(newIb, r.node.Id)
+	payload := r.wire(newIb)
 	if _, err := r.do(ctx, http.MethodPost, "panel/api/inbounds/update/"+strconv.Itoa(id), payload); err != nil {
 		return err
 	}
@@ -474,7 +537,7 @@ func (r *Remote) SetInboundSubSortIndex(ctx context.Context, ib *model.Inbound,
 // whether a push actually happened. This turns a full-fleet reconcile from "send
 // every inbound's full settings" into "send only what changed".
 func (r *Remote) ReconcileInbound(ctx context.Context, ib *model.Inbound, existsOnNode bool) (bool, error) {
-	fp := wireFingerprint(wireInbound(ib, r.node.Id))
+	fp := wireFingerprint(r.wire(ib))
 	if existsOnNode {
 		r.mu.RLock()
 		prev, ok := r.pushedFP[ib.Tag]
@@ -492,7 +555,7 @@ func (r *Remote) ReconcileInbound(ctx context.Context, ib *model.Inbound, exists
 // recordPushedInbound stamps the fingerprint after a full-payload push — the
 // only operation that proves the node holds the entire wire payload.
 func (r *Remote) recordPushedInbound(ib *model.Inbound) {
-	fp := wireFingerprint(wireInbound(ib, r.node.Id))
+	fp := wireFingerprint(r.wire(ib))
 	r.mu.Lock()
 	r.pushedFP[ib.Tag] = fp
 	r.mu.Unlock()
@@ -511,7 +574,7 @@ func (r *Remote) AdoptInboundAlias(ib *model.Inbound, remote RemoteInboundOption
 	r.remoteIDByTag[remote.Tag] = remote.Id
 	r.remoteIDByTag[ib.Tag] = remote.Id
 	r.adoptedAliases[ib.Tag] = remote.Tag
-	r.pushedFP[ib.Tag] = wireFingerprint(wireInbound(ib, r.node.Id))
+	r.pushedFP[ib.Tag] = wireFingerprint(r.wire(ib))
 	r.mu.Unlock()
 }
 
@@ -531,8 +594,8 @@ func (r *Remote) AdoptedInboundAliases() []string {
 // node held the exact pre-edit state; otherwise the stale fingerprint stays and
 // the next reconcile re-sends the full inbound.
 func (r *Remote) AdvancePushedInbound(prevIb, ib *model.Inbound) {
-	prevFP := wireFingerprint(wireInbound(prevIb, r.node.Id))
-	nextFP := wireFingerprint(wireInbound(ib, r.node.Id))
+	prevFP := wireFingerprint(r.wire(prevIb))
+	nextFP := wireFingerprint(r.wire(ib))
 	r.mu.Lock()
 	if r.pushedFP[ib.Tag] == prevFP {
 		r.pushedFP[ib.Tag] = nextFP
@@ -559,6 +622,9 @@ func (r *Remote) AddClient(ctx context.Context, ib *model.Inbound, client model.
 	if err != nil {
 		return fmt.Errorf("remote AddClient: resolve tag %q: %w", ib.Tag, err)
 	}
+	if r.quotaWeighted(ib) {
+		client.TotalGB = 0
+	}
 	payload := map[string]any{
 		"client":     client,
 		"inboundIds": []int{id},
@@ -617,6 +683,9 @@ func (r *Remote) UpdateUser(ctx context.Context, ib *model.Inbound, oldEmail str
 	if err != nil {
 		return err
 	}
+	if r.quotaWeighted(ib) {
+		payload.TotalGB = 0
+	}
 	path := "panel/api/clients/update/" + url.PathEscape(oldEmail) +
 		"?inboundIds=" + strconv.Itoa(id)
 	if _, err := r.do(ctx, http.MethodPost, path, payload); err != nil {
@@ -643,6 +712,13 @@ func (r *Remote) UpdatePanel(ctx context.Context, dev bool) error {
 	return err
 }
 
+// InstallXray asks the node to install the given Xray release tag. A slow download
+// can outlast the request, so a timeout means "not known yet" rather than failed.
+func (r *Remote) InstallXray(ctx context.Context, version string) error {
+	_, err := r.do(ctx, http.MethodPost, "panel/api/server/installXray/"+url.PathEscape(version), nil)
+	return err
+}
+
 // WebCertFiles holds a node's own web TLS certificate and key file paths.
 type WebCertFiles struct {
 	WebCertFile string `json:"webCertFile"`
@@ -793,6 +869,47 @@ func (r *Remote) PushGlobalClientTraffics(ctx context.Context, masterGuid string
 	return err
 }
 
+// wire is the payload pushed for ib, with the quotas of weighted clients
+// stripped so only this panel enforces them.
+func (r *Remote) wire(ib *model.Inbound) url.Values {
+	v := wireInbound(ib, r.node.Id)
+	if r.quotaWeighted(ib) {
+		v.Set("settings", settingsWithoutQuota(ib.Settings))
+	}
+	return v
+}
+
+// quotaWeighted reports whether ib's traffic is weighed by a coefficient. The
+// node counts raw bytes, so it must not cut such clients off at their quota.
+func (r *Remote) quotaWeighted(ib *model.Inbound) bool {
+	return model.TrafficWeight(r.node.TrafficCoefficient)*model.TrafficWeight(ib.TrafficCoefficient) != 1
+}
+
+// settingsWithoutQuota zeroes every client's totalGB; unparsable settings are
+// returned as they are.
+func settingsWithoutQuota(settings string) string {
+	var parsed map[string]any
+	if err := json.Unmarshal([]byte(settings), &parsed); err != nil {
+		return settings
+	}
+	clients, ok := parsed["clients"].([]any)
+	if !ok {
+		return settings
+	}
+	for _, c := range clients {
+		if entry, ok := c.(map[string]any); ok {
+			if _, has := entry["totalGB"]; has {
+				entry["totalGB"] = 0
+			}
+		}
+	}
+	out, err := json.Marshal(parsed)
+	if err != nil {
+		return settings
+	}
+	return string(out)
+}
+
 func wireInbound(ib *model.Inbound, remoteNodeID int) url.Values {
 	v := url.Values{}
 	v.Set("total", strconv.FormatInt(ib.Total, 10))
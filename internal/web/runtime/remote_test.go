@@ -35,6 +35,31 @@ func TestRemoteDo_RejectsOversizeResponse(t *testing.T) {
 	}
 }
 
+// TestRemoteDo_ClassifiesUnreachable: a node that never answers, or a gateway
+// reporting it down, is unreachable; a node that answers and refuses is not.
+func TestRemoteDo_ClassifiesUnreachable(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gateway" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":false,"msg":"refused"}`))
+	}))
+	r := NewRemote(nodeForServer(t, srv, "skip", ""), nil)
+
+	_, err := r.do(context.Background(), http.MethodGet, "/probe", nil)
+	if err == nil || IsNodeUnreachable(err) {
+		t.Fatalf("refusal: err = %v, want a reachable-node error", err)
+	}
+	if _, err := r.do(context.Background(), http.MethodGet, "/gateway", nil); !IsNodeUnreachable(err) {
+		t.Fatalf("502: err = %v, want unreachable", err)
+	}
+	srv.Close()
+	if _, err := r.do(context.Background(), http.MethodGet, "/probe", nil); !IsNodeUnreachable(err) {
+		t.Fatalf("closed server: err = %v, want unreachable", err)
+	}
+}
+
 // TestRemoteDo_AcceptsNormalResponse confirms the cap does not break a normal
 // under-limit envelope.
 func TestRemoteDo_AcceptsNormalResponse(t *testing.T) {
@@ -196,6 +221,29 @@ func TestWireInboundCarriesDisableFlow(t *testing.T) {
 	}
 }
 
+// A node counts raw bytes, so it must not hold the quota of a client whose
+// traffic the master weighs; only the master can cut such a client off.
+func TestRemoteWireStripsQuotaOnWeightedInbounds(t *testing.T) {
+	double := 2.0
+	settings := `{"clients":[{"email":"a","totalGB":1000}]}`
+	plain := NewRemote(&model.Node{Id: 1}, nil)
+	if got := plain.wire(&model.Inbound{Settings: settings}).Get("settings"); got != settings {
+		t.Fatalf("unweighted settings = %s, want them untouched", got)
+	}
+	for name, r := range map[string]*Remote{
+		"node":    NewRemote(&model.Node{Id: 1, TrafficCoefficient: &double}, nil),
+		"inbound": plain,
+	} {
+		ib := &model.Inbound{Settings: settings}
+		if name == "inbound" {
+			ib.TrafficCoefficient = &double
+		}
+		if got := r.wire(ib).Get("settings"); !strings.Contains(got, `"totalGB":0`) {
+			t.Errorf("%s coefficient: settings = %s, want totalGB 0", name, got)
+		}
+	}
+}
+
 func TestRemoteHTTPClientEgressProxy(t *testing.T) {
 	// OutboundTag + a resolver → a dedicated proxy client (not the shared default).
 	withTag := NewRemote(&model.Node{Id: 1, Scheme: "https", TlsVerifyMode: "verify", OutboundTag: "warp"}, stubEgress{url: "socks5://127.0.0.1:1080"})
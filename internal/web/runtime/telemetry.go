@@ -0,0 +1,533 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/netsafe"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/wirecodec"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+)
+
+// Frames carry what the matching poll endpoint returns, except traffic, which
+// carries only what changed since the previous traffic frame.
+const (
+	TelemetryStatus          = "status"
+	TelemetryTraffic         = "traffic"
+	TelemetryClientIps       = "clientIps"
+	TelemetryClientIpsByGuid = "clientIpsByGuid"
+	TelemetryDescendants     = "descendants"
+	// TelemetryPing is an empty keepalive sent when nothing else changed.
+	TelemetryPing = "ping"
+)
+
+// TelemetryIdleTimeout is how long the master waits for any frame, pings
+// included, before treating the stream as dead. Nodes ping well inside it.
+const TelemetryIdleTimeout = 30 * time.Second
+
+const (
+	// TelemetryOfferPath is the node endpoint a master posts its offer to.
+	TelemetryOfferPath = "panel/api/server/telemetry"
+	// TelemetryStreamPath sits outside /panel/api: the node authenticates with
+	// the API token the master calls it with.
+	TelemetryStreamPath = "panel/nodes/telemetry"
+	// TelemetryNodeHeader carries the id the master knows the streaming node by.
+	TelemetryNodeHeader = "X-3x-Node-Id"
+)
+
+// unansweredWriteSettle holds frames back after a write whose answer never
+// came, since it may or may not have been applied.
+const unansweredWriteSettle = 10 * time.Second
+
+// TelemetryOffer travels through the node's Remote, so it is authorized like
+// any other call; the node dials back with the same API token.
+type TelemetryOffer struct {
+	NodeId int    `json:"nodeId" example:"3"`
+	Scheme string `json:"scheme" example:"https"`
+	// Address is empty when the master has no web domain set; the node then
+	// dials the address the offer came from.
+	Address  string `json:"address" example:""`
+	Port     int    `json:"port" example:"2053"`
+	BasePath string `json:"basePath" example:"/"`
+	// CertSha256 pins the master's web certificate; empty when it is publicly
+	// trusted.
+	CertSha256 string `json:"certSha256" example:""`
+}
+
+// StreamURL is where the node dials the master, with callerIP standing in for
+// an empty Address.
+func (o *TelemetryOffer) StreamURL(callerIP string) (string, error) {
+	if o.Scheme != "http" && o.Scheme != "https" {
+		return "", fmt.Errorf("invalid master scheme %q", o.Scheme)
+	}
+	if o.Port <= 0 || o.Port > 65535 {
+		return "", fmt.Errorf("invalid master port %d", o.Port)
+	}
+	addr := strings.TrimSpace(o.Address)
+	if addr == "" {
+		if net.ParseIP(callerIP) == nil {
+			return "", errors.New("master address is unknown")
+		}
+		addr = callerIP
+	}
+	host, err := netsafe.NormalizeHost(addr)
+	if err != nil {
+		return "", err
+	}
+	bp := o.BasePath
+	if !strings.HasPrefix(bp, "/") {
+		bp = "/" + bp
+	}
+	if !strings.HasSuffix(bp, "/") {
+		bp += "/"
+	}
+	u := &url.URL{
+		Scheme: o.Scheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(o.Port)),
+		Path:   bp + TelemetryStreamPath,
+	}
+	return u.String(), nil
+}
+
+// TelemetryFrame is one newline-delimited JSON record on the stream.
+type TelemetryFrame struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// TrafficFrame lists only what changed unless Full; a nil tree means that tree
+// did not change.
+type TrafficFrame struct {
+	Full bool `json:"full,omitempty"`
+	// Epoch and Settled place the frame after the node's API writes: every
+	// write numbered up to Settled in Epoch had finished when it was built.
+	Epoch   string `json:"epoch"`
+	Settled uint64 `json:"settled"`
+	// Inbounds holds each changed inbound with only its changed clients in
+	// ClientStats.
+	Inbounds          []*model.Inbound    `json:"inbounds,omitempty"`
+	RemovedInbounds   []int               `json:"removedInbounds,omitempty"`
+	RemovedClients    map[int][]string    `json:"removedClients,omitempty"` // by inbound id
+	OnlineTree        map[string][]string `json:"onlineTree"`
+	ActiveInboundTree map[string][]string `json:"activeInboundTree"`
+	// LastOnline holds the entries that changed.
+	LastOnline map[string]int64 `json:"lastOnline,omitempty"`
+}
+
+// TrafficDiffer turns successive full traffic views of a node into the
+// traffic frames of one stream.
+type TrafficDiffer struct {
+	started    bool
+	epoch      string
+	settled    uint64
+	inbounds   map[int][]byte
+	clients    map[int]map[string][]byte
+	online     []byte
+	active     []byte
+	lastOnline map[string]int64
+}
+
+// Next returns nil when nothing changed. A frame that only moves Settled is
+// still sent, so the master learns the view it holds is current.
+func (d *TrafficDiffer) Next(view *TrafficSnapshot, epoch string, settled uint64) (*TrafficFrame, error) {
+	f := &TrafficFrame{Full: !d.started, Epoch: epoch, Settled: settled}
+	changed := f.Full || epoch != d.epoch || settled != d.settled
+
+	inbounds := make(map[int][]byte, len(view.Inbounds))
+	clients := make(map[int]map[string][]byte, len(view.Inbounds))
+	for _, ib := range view.Inbounds {
+		bare := *ib
+		bare.ClientStats = nil
+		raw, err := json.Marshal(&bare)
+		if err != nil {
+			return nil, err
+		}
+		inbounds[ib.Id] = raw
+		stats := make(map[string][]byte, len(ib.ClientStats))
+		var changedStats []xray.ClientTraffic
+		for _, ct := range ib.ClientStats {
+			rawCt, err := json.Marshal(ct)
+			if err != nil {
+				return nil, err
+			}
+			stats[ct.Email] = rawCt
+			if prev, ok := d.clients[ib.Id][ct.Email]; !ok || string(prev) != string(rawCt) {
+				changedStats = append(changedStats, ct)
+			}
+		}
+		clients[ib.Id] = stats
+		if prev, ok := d.inbounds[ib.Id]; ok && string(prev) == string(raw) && len(changedStats) == 0 {
+			continue
+		}
+		bare.ClientStats = changedStats
+		f.Inbounds = append(f.Inbounds, &bare)
+	}
+	for id, stats := range d.clients {
+		if _, ok := inbounds[id]; !ok {
+			f.RemovedInbounds = append(f.RemovedInbounds, id)
+			continue
+		}
+		for email := range stats {
+			if _, ok := clients[id][email]; !ok {
+				if f.RemovedClients == nil {
+					f.RemovedClients = map[int][]string{}
+				}
+				f.RemovedClients[id] = append(f.RemovedClients[id], email)
+			}
+		}
+	}
+	slices.Sort(f.RemovedInbounds)
+	for _, emails := range f.RemovedClients {
+		slices.Sort(emails)
+	}
+
+	online, err := json.Marshal(view.OnlineTree)
+	if err != nil {
+		return nil, err
+	}
+	active, err := json.Marshal(view.ActiveInboundTree)
+	if err != nil {
+		return nil, err
+	}
+	if f.Full || string(online) != string(d.online) {
+		f.OnlineTree = nonNilTree(view.OnlineTree)
+	}
+	if f.Full || string(active) != string(d.active) {
+		f.ActiveInboundTree = nonNilTree(view.ActiveInboundTree)
+	}
+	for email, at := range view.LastOnlineMap {
+		if prev, ok := d.lastOnline[email]; !ok || prev != at {
+			if f.LastOnline == nil {
+				f.LastOnline = map[string]int64{}
+			}
+			f.LastOnline[email] = at
+		}
+	}
+
+	changed = changed || len(f.Inbounds) > 0 || len(f.RemovedInbounds) > 0 || len(f.RemovedClients) > 0 ||
+		f.OnlineTree != nil || f.ActiveInboundTree != nil || len(f.LastOnline) > 0
+	d.started, d.epoch, d.settled = true, epoch, settled
+	d.inbounds, d.clients, d.online, d.active = inbounds, clients, online, active
+	d.lastOnline = view.LastOnlineMap
+	if !changed {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// nonNilTree keeps an empty tree distinguishable from an unchanged one on the
+// wire.
+func nonNilTree(tree map[string][]string) map[string][]string {
+	if tree == nil {
+		return map[string][]string{}
+	}
+	return tree
+}
+
+// TrafficState rebuilds a node's traffic view from the traffic frames of its
+// stream, on the master.
+type TrafficState struct {
+	started    bool
+	inbounds   map[int]*model.Inbound
+	clients    map[int]map[string]xray.ClientTraffic
+	online     map[string][]string
+	active     map[string][]string
+	lastOnline map[string]int64
+}
+
+// Apply folds f into the view. A stream must open with a full frame.
+func (s *TrafficState) Apply(f *TrafficFrame) error {
+	if f.Full {
+		*s = TrafficState{
+			started:    true,
+			inbounds:   map[int]*model.Inbound{},
+			clients:    map[int]map[string]xray.ClientTraffic{},
+			lastOnline: map[string]int64{},
+		}
+	} else if !s.started {
+		return errors.New("telemetry: traffic delta before the full frame")
+	}
+	for _, ib := range f.Inbounds {
+		bare := *ib
+		bare.ClientStats = nil
+		s.inbounds[ib.Id] = &bare
+		stats := s.clients[ib.Id]
+		if stats == nil {
+			stats = map[string]xray.ClientTraffic{}
+			s.clients[ib.Id] = stats
+		}
+		for _, ct := range ib.ClientStats {
+			stats[ct.Email] = ct
+		}
+	}
+	for _, id := range f.RemovedInbounds {
+		delete(s.inbounds, id)
+		delete(s.clients, id)
+	}
+	for id, emails := range f.RemovedClients {
+		for _, email := range emails {
+			delete(s.clients[id], email)
+		}
+	}
+	if f.OnlineTree != nil {
+		s.online = f.OnlineTree
+	}
+	if f.ActiveInboundTree != nil {
+		s.active = f.ActiveInboundTree
+	}
+	for email, at := range f.LastOnline {
+		s.lastOnline[email] = at
+	}
+	return nil
+}
+
+// Snapshot returns the view in the shape the traffic merge consumes, as a
+// copy the merge is free to modify.
+func (s *TrafficState) Snapshot() *TrafficSnapshot {
+	snap := &TrafficSnapshot{
+		Inbounds:          make([]*model.Inbound, 0, len(s.inbounds)),
+		OnlineTree:        s.online,
+		ActiveInboundTree: s.active,
+		LastOnlineMap:     make(map[string]int64, len(s.lastOnline)),
+	}
+	for _, ib := range s.inbounds {
+		cp := *ib
+		cp.ClientStats = make([]xray.ClientTraffic, 0, len(s.clients[ib.Id]))
+		for _, ct := range s.clients[ib.Id] {
+			cp.ClientStats = append(cp.ClientStats, ct)
+		}
+		slices.SortFunc(cp.ClientStats, func(a, b xray.ClientTraffic) int {
+			if a.Id != b.Id {
+				return a.Id - b.Id
+			}
+			return strings.Compare(a.Email, b.Email)
+		})
+		snap.Inbounds = append(snap.Inbounds, &cp)
+	}
+	slices.SortFunc(snap.Inbounds, func(a, b *model.Inbound) int { return a.Id - b.Id })
+	for email, at := range s.lastOnline {
+		snap.LastOnlineMap[email] = at
+	}
+	return snap
+}
+
+// WriteClock numbers a node's API writes. The epoch changes with every
+// process, since the numbering restarts with it.
+type WriteClock struct {
+	epoch string
+
+	mu       sync.Mutex
+	last     uint64
+	inflight map[uint64]struct{}
+}
+
+func NewWriteClock() *WriteClock {
+	return &WriteClock{
+		epoch:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		inflight: map[uint64]struct{}{},
+	}
+}
+
+// Begin numbers a write about to run and returns its stamp for the
+// WriteSeqHeader; done must be called once the write has finished.
+func (w *WriteClock) Begin() (stamp string, done func()) {
+	w.mu.Lock()
+	w.last++
+	seq := w.last
+	w.inflight[seq] = struct{}{}
+	w.mu.Unlock()
+	return w.epoch + ":" + strconv.FormatUint(seq, 10), func() {
+		w.mu.Lock()
+		delete(w.inflight, seq)
+		w.mu.Unlock()
+	}
+}
+
+// Settled returns the epoch and the number at or below which every write has
+// finished.
+func (w *WriteClock) Settled() (string, uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	settled := w.last
+	for seq := range w.inflight {
+		settled = min(settled, seq-1)
+	}
+	return w.epoch, settled
+}
+
+func parseWriteStamp(stamp string) (string, uint64, bool) {
+	epoch, num, ok := strings.Cut(stamp, ":")
+	if !ok || epoch == "" {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(num, 10, 64)
+	return epoch, seq, err == nil
+}
+
+// nodeWrite is the last write this panel made to a node.
+type nodeWrite struct {
+	epoch      string
+	seq        uint64
+	unanswered time.Time // when a write last went unanswered
+}
+
+// recordWrite notes a call that may have changed the node's inbounds or
+// clients, from the stamp its answer carried; "" means no answer came back.
+func (r *Remote) recordWrite(stamp string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stamp == "" {
+		r.lastWrite.unanswered = time.Now()
+		return
+	}
+	if epoch, seq, ok := parseWriteStamp(stamp); ok {
+		if epoch != r.lastWrite.epoch || seq > r.lastWrite.seq {
+			r.lastWrite.epoch, r.lastWrite.seq = epoch, seq
+		}
+	}
+}
+
+// TrafficFrameCurrent reports whether f was built after every write this panel
+// made to the node; merging an older view could roll one back.
+func (r *Remote) TrafficFrameCurrent(f *TrafficFrame, receivedAt time.Time) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	w := r.lastWrite
+	if w.epoch != "" && w.epoch == f.Epoch && f.Settled < w.seq {
+		return false
+	}
+	return w.unanswered.IsZero() || receivedAt.After(w.unanswered.Add(unansweredWriteSettle))
+}
+
+// SupportsTelemetry reports whether the node advertised the telemetry stream
+// on an earlier response. Until it has, the node is polled.
+func (r *Remote) SupportsTelemetry() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.supportsTelemetry
+}
+
+// OfferTelemetry asks the node to open its telemetry stream to this panel.
+func (r *Remote) OfferTelemetry(ctx context.Context, offer *TelemetryOffer) error {
+	_, err := r.do(ctx, http.MethodPost, TelemetryOfferPath, offer)
+	return err
+}
+
+// PushTelemetry dials the master with the TLS policy used for nodes: system
+// roots, or the offer's pin. token is the one the master calls this node with.
+func PushTelemetry(ctx context.Context, offer *TelemetryOffer, callerIP, token string,
+	produce func(send func(TelemetryFrame) error) error) error {
+	target, err := offer.StreamURL(callerIP)
+	if err != nil {
+		return err
+	}
+	mode := "verify"
+	if offer.CertSha256 != "" {
+		mode = "pin"
+	}
+	client, err := HTTPClientForNode(&model.Node{Scheme: offer.Scheme, TlsVerifyMode: mode, PinnedCertSha256: offer.CertSha256}, "")
+	if err != nil {
+		return err
+	}
+	// Share the transport but drop any whole-request timeout, which would cut
+	// the stream off.
+	streamClient := *client
+	streamClient.Timeout = 0
+
+	// The master is often on the same private network as its nodes.
+	sctx, cancel := context.WithCancel(netsafe.ContextWithAllowPrivate(ctx, true))
+	defer cancel()
+	pr, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(sctx, http.MethodPost, target, pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set(TelemetryNodeHeader, strconv.Itoa(offer.NodeId))
+	// A compressing writer would hide the connection the master needs to
+	// manage the stream's deadlines.
+	req.Header.Set("Accept-Encoding", "identity")
+
+	// The master answers a stream it accepts only once it stops reading, and a
+	// refusal straight away; either way the producer is cut off.
+	answered := make(chan struct{})
+	var refused error
+	go func() {
+		defer close(answered)
+		resp, err := streamClient.Do(req)
+		if err == nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, errBodyDiagBytes))
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				refused = fmt.Errorf("POST %s: HTTP %d", TelemetryStreamPath, resp.StatusCode)
+				err = refused
+			} else {
+				err = errors.New("master ended the telemetry stream")
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+
+	enc := json.NewEncoder(pw)
+	err = produce(func(f TelemetryFrame) error { return enc.Encode(f) })
+	if err != nil {
+		cancel()
+	}
+	// A producer that finished ends the stream cleanly and lets the master
+	// read it to the end.
+	pw.Close()
+	<-answered
+	if err == nil {
+		err = refused
+	}
+	return err
+}
+
+// TelemetryReader reads the frames of a telemetry stream.
+type TelemetryReader struct {
+	scanner *bufio.Scanner
+}
+
+func NewTelemetryReader(r io.Reader) *TelemetryReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxRemoteResponseBytes)
+	return &TelemetryReader{scanner: scanner}
+}
+
+// Next blocks for the next frame; io.EOF means the node closed the stream.
+func (t *TelemetryReader) Next() (TelemetryFrame, error) {
+	for t.scanner.Scan() {
+		line := t.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var f TelemetryFrame
+		if err := json.Unmarshal(line, &f); err != nil {
+			return TelemetryFrame{}, fmt.Errorf("decode telemetry frame: %w", err)
+		}
+		return f, nil
+	}
+	if err := t.scanner.Err(); err != nil {
+		return TelemetryFrame{}, err
+	}
+	return TelemetryFrame{}, io.EOF
+}
+
+// writeStampOf returns the write stamp a node's answer carries.
+func writeStampOf(h http.Header) string {
+	return h.Get(wirecodec.WriteSeqHeader)
+}
@@ -0,0 +1,248 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/wirecodec"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+)
+
+// TestPushTelemetry: the node dials the offer's master, or the caller's
+// address, and the master reads the frames in order.
+func TestPushTelemetry(t *testing.T) {
+	var gotPath, gotAuth, gotNode string
+	var got []TelemetryFrame
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		gotNode = r.Header.Get(TelemetryNodeHeader)
+		reader := NewTelemetryReader(r.Body)
+		for {
+			f, err := reader.Next()
+			if err != nil {
+				break
+			}
+			got = append(got, f)
+		}
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	offer := &TelemetryOffer{NodeId: 7, Scheme: "http", Port: port, BasePath: "/xui"}
+	err := PushTelemetry(context.Background(), offer, u.Hostname(), "tok", func(send func(TelemetryFrame) error) error {
+		if err := send(TelemetryFrame{Type: TelemetryStatus, Data: json.RawMessage(`{"cpu":12.5}`)}); err != nil {
+			return err
+		}
+		return send(TelemetryFrame{Type: TelemetryPing})
+	})
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if gotPath != "/xui/"+TelemetryStreamPath || gotAuth != "Bearer tok" || gotNode != "7" {
+		t.Fatalf("stream request: path=%q auth=%q node=%q", gotPath, gotAuth, gotNode)
+	}
+	if len(got) != 2 || got[0].Type != TelemetryStatus || string(got[0].Data) != `{"cpu":12.5}` || got[1].Type != TelemetryPing {
+		t.Fatalf("frames = %+v", got)
+	}
+}
+
+// TestPushTelemetryRefused: a refusal ends the producer with the master's
+// answer instead of leaving it blocked on the stream.
+func TestPushTelemetryRefused(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// As the master does: answer without draining the stream first.
+		_ = http.NewResponseController(w).EnableFullDuplex()
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	offer := &TelemetryOffer{NodeId: 7, Scheme: "http", Address: u.Hostname(), Port: port, BasePath: "/"}
+	done := make(chan error, 1)
+	go func() {
+		done <- PushTelemetry(context.Background(), offer, "", "tok", func(send func(TelemetryFrame) error) error {
+			for {
+				if err := send(TelemetryFrame{Type: TelemetryPing}); err != nil {
+					return err
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("refused stream reported success")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("producer kept running after the master refused the stream")
+	}
+}
+
+// TestTrafficDelta: later frames carry only what changed, and folding them
+// into a TrafficState rebuilds the node's current view.
+func TestTrafficDelta(t *testing.T) {
+	view := func(up int64, emails ...string) *TrafficSnapshot {
+		ib := &model.Inbound{Id: 1, Tag: "in-1", Up: up}
+		for i, e := range emails {
+			ib.ClientStats = append(ib.ClientStats, xray.ClientTraffic{Id: i + 1, InboundId: 1, Email: e, Up: up})
+		}
+		return &TrafficSnapshot{
+			Inbounds:      []*model.Inbound{ib, {Id: 2, Tag: "in-2"}},
+			OnlineTree:    map[string][]string{"g": emails},
+			LastOnlineMap: map[string]int64{"a": 1},
+		}
+	}
+	var d TrafficDiffer
+	var s TrafficState
+
+	first, err := d.Next(view(1, "a", "b"), "e", 0)
+	if err != nil || first == nil || !first.Full || len(first.Inbounds) != 2 {
+		t.Fatalf("first frame = %+v, %v", first, err)
+	}
+	if err := s.Apply(first); err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := d.Next(view(1, "a", "b"), "e", 0); f != nil {
+		t.Fatalf("unchanged view produced %+v", f)
+	}
+	if f, _ := d.Next(view(1, "a", "b"), "e", 3); f == nil || len(f.Inbounds) != 0 || f.Settled != 3 {
+		t.Fatalf("settle-only frame = %+v", f)
+	}
+
+	next := view(5, "a")
+	next.Inbounds = next.Inbounds[:1]
+	delta, err := d.Next(next, "e", 3)
+	if err != nil || delta == nil || delta.Full {
+		t.Fatalf("delta = %+v, %v", delta, err)
+	}
+	if len(delta.Inbounds) != 1 || len(delta.Inbounds[0].ClientStats) != 1 ||
+		!reflect.DeepEqual(delta.RemovedInbounds, []int{2}) ||
+		!reflect.DeepEqual(delta.RemovedClients, map[int][]string{1: {"b"}}) ||
+		delta.OnlineTree == nil || delta.ActiveInboundTree != nil || delta.LastOnline != nil {
+		t.Fatalf("delta = %+v", delta)
+	}
+	if err := s.Apply(delta); err != nil {
+		t.Fatal(err)
+	}
+	got := s.Snapshot()
+	if len(got.Inbounds) != 1 || got.Inbounds[0].Up != 5 || len(got.Inbounds[0].ClientStats) != 1 ||
+		got.Inbounds[0].ClientStats[0].Up != 5 || !reflect.DeepEqual(got.OnlineTree, next.OnlineTree) ||
+		got.LastOnlineMap["a"] != 1 {
+		t.Fatalf("rebuilt view = %+v", got)
+	}
+
+	var fresh TrafficState
+	if err := fresh.Apply(delta); err == nil {
+		t.Fatal("a delta was applied without the full frame")
+	}
+}
+
+// TestWriteClockSettled: a write counts as settled only once it and every
+// write numbered before it have finished.
+func TestWriteClockSettled(t *testing.T) {
+	w := NewWriteClock()
+	_, done1 := w.Begin()
+	stamp2, done2 := w.Begin()
+	done2()
+	if _, settled := w.Settled(); settled != 0 {
+		t.Fatalf("settled = %d with write 1 in flight", settled)
+	}
+	done1()
+	epoch, settled := w.Settled()
+	if settled != 2 || stamp2 != epoch+":2" {
+		t.Fatalf("settled = %d, stamp = %q, epoch = %q", settled, stamp2, epoch)
+	}
+}
+
+// TestRemoteTrafficFrameCurrent: reads, POST reads included, are never waited
+// for; an unanswered write holds frames back only for a while.
+func TestRemoteTrafficFrameCurrent(t *testing.T) {
+	seq := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(wirecodec.CapsHeader, wirecodec.NodeCaps)
+		if r.Method != http.MethodGet {
+			seq++
+			w.Header().Set(wirecodec.WriteSeqHeader, "e:"+strconv.Itoa(seq))
+		}
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+	r := NewRemote(nodeForPlainServer(t, srv, "verify", "tok"), nil)
+	ctx := context.Background()
+	now := time.Now()
+
+	if r.SupportsTelemetry() {
+		t.Fatal("telemetry assumed before the node advertised it")
+	}
+	if _, err := r.do(ctx, http.MethodPost, "panel/api/clients/onlinesByGuid", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !r.SupportsTelemetry() {
+		t.Fatal("caps header not recorded")
+	}
+	if !r.TrafficFrameCurrent(&TrafficFrame{Epoch: "e"}, now) {
+		t.Fatal("a read was waited for")
+	}
+	if _, err := r.do(ctx, http.MethodPost, "panel/api/clients/del/bob", nil); err != nil {
+		t.Fatal(err)
+	}
+	if r.TrafficFrameCurrent(&TrafficFrame{Epoch: "e", Settled: 1}, now) {
+		t.Fatal("a frame from before the write counted as current")
+	}
+	if !r.TrafficFrameCurrent(&TrafficFrame{Epoch: "e", Settled: 2}, now) {
+		t.Fatal("a frame after the write was held back")
+	}
+	if !r.TrafficFrameCurrent(&TrafficFrame{Epoch: "restarted"}, now) {
+		t.Fatal("a frame from a restarted node was held back")
+	}
+
+	srv.Close()
+	if _, err := r.do(ctx, http.MethodPost, "panel/api/clients/del/carol", nil); err == nil {
+		t.Fatal("call to a closed node succeeded")
+	}
+	if r.TrafficFrameCurrent(&TrafficFrame{Epoch: "e", Settled: 2}, time.Now()) {
+		t.Fatal("an unanswered write did not hold frames back")
+	}
+	if !r.TrafficFrameCurrent(&TrafficFrame{Epoch: "e", Settled: 2}, time.Now().Add(unansweredWriteSettle+time.Second)) {
+		t.Fatal("an unanswered write held frames back for good")
+	}
+}
+
+// TestRemoteOfferTelemetry: the offer reaches the node's endpoint with the
+// node's credentials and is not mistaken for a write.
+func TestRemoteOfferTelemetry(t *testing.T) {
+	var gotPath, gotAuth string
+	var got TelemetryOffer
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+	r := NewRemote(nodeForPlainServer(t, srv, "verify", "tok"), nil)
+
+	offer := &TelemetryOffer{NodeId: 1, Scheme: "https", Address: "master.example.com", Port: 2053, BasePath: "/"}
+	if err := r.OfferTelemetry(context.Background(), offer); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/"+TelemetryOfferPath || gotAuth != "Bearer tok" || got != *offer {
+		t.Fatalf("offer request: path=%q auth=%q body=%+v", gotPath, gotAuth, got)
+	}
+	if !r.lastWrite.unanswered.IsZero() || r.lastWrite.seq != 0 {
+		t.Fatal("the offer was recorded as a write")
+	}
+}
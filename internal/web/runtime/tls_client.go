@@ -238,31 +238,47 @@ func tlsConfigForNode(n *model.Node) (*tls.Config, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &tls.Config{
+		tlsCfg := &tls.Config{
 			Certificates: []tls.Certificate{cert},
 			MinVersion:   tls.VersionTLS12,
-		}, nil
+		}
+		if strings.TrimSpace(n.PinnedCertSha256) != "" { // nodes enrolled with a join token
+			if err := pinServerCert(tlsCfg, n.PinnedCertSha256); err != nil {
+				return nil, err
+			}
+		}
+		return tlsCfg, nil
 	}
 	tlsCfg := &tls.Config{InsecureSkipVerify: true} // lgtm[go/disabled-certificate-check]
 	if n.TlsVerifyMode == "pin" {
-		want, err := DecodeCertPin(n.PinnedCertSha256)
-		if err != nil {
+		if err := pinServerCert(tlsCfg, n.PinnedCertSha256); err != nil {
 			return nil, err
 		}
-		tlsCfg.VerifyConnection = func(cs tls.ConnectionState) error {
-			if len(cs.PeerCertificates) == 0 {
-				return common.NewError("node presented no certificate")
-			}
-			sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
-			if subtle.ConstantTimeCompare(sum[:], want) != 1 {
-				return common.NewError("node certificate does not match pinned SHA-256")
-			}
-			return nil
-		}
 	}
 	return tlsCfg, nil
 }
 
+// pinServerCert makes cfg accept only a server whose leaf certificate hashes
+// to pin, in place of chain verification.
+func pinServerCert(cfg *tls.Config, pin string) error {
+	want, err := DecodeCertPin(pin)
+	if err != nil {
+		return err
+	}
+	cfg.InsecureSkipVerify = true // lgtm[go/disabled-certificate-check]
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return common.NewError("node presented no certificate")
+		}
+		sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+		if subtle.ConstantTimeCompare(sum[:], want) != 1 {
+			return common.NewError("node certificate does not match pinned SHA-256")
+		}
+		return nil
+	}
+	return nil
+}
+
 // DecodeCertPin decodes a SHA-256 cert pin given as base64 (Xray's
 // pinnedPeerCertSha256 form) or hex with optional colons into 32 raw bytes.
 func DecodeCertPin(s string) ([]byte, error) {
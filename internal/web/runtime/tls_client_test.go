@@ -369,6 +369,28 @@ func TestRemoteHonorsTLSVerifyMode(t *testing.T) {
 	}
 }
 
+// A join-token mtls node carries a pin: the master presents its client cert and
+// checks the self-signed node against the pin instead of the system roots.
+func TestRemoteMTLSHonorsPin(t *testing.T) {
+	cert := masterCertForTest(t)
+	SetMasterClientCertProvider(func() (tls.Certificate, error) { return cert, nil })
+	t.Cleanup(func() { SetMasterClientCertProvider(nil) })
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true,"obj":[]}`))
+	}))
+	defer srv.Close()
+
+	if _, err := NewRemote(nodeForServer(t, srv, "mtls", leafPinBase64(srv)), nil).ListInboundOptions(context.Background()); err != nil {
+		t.Fatalf("mtls with the matching pin: %v", err)
+	}
+	wrongPin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	if _, err := NewRemote(nodeForServer(t, srv, "mtls", wrongPin), nil).ListInboundOptions(context.Background()); err == nil {
+		t.Fatal("mtls with a mismatched pin reached the node")
+	}
+}
+
 // The lazily-built client is cached for the Remote's lifetime so repeated
 // operations reuse one pooled transport rather than rebuilding TLS each call.
 func TestRemoteClientCached(t *testing.T) {
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/acme"
+	"github.com/mhsanaei/3x-ui/v3/internal/config"
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/secrets"
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+
+	"gorm.io/gorm"
+)
+
+const (
+	acmeOrderTimeout = 5 * time.Minute
+	// acmeRetryBackoff keeps a failing order from burning through the CA's
+	// failed-validation rate limit on every job run or panel restart.
+	acmeRetryBackoff = 6 * time.Hour
+)
+
+var (
+	acmeInFlightMu sync.Mutex
+	acmeInFlight   = map[int]bool{}
+)
+
+// AcmeService orders certificates through the built-in ACME client. The DB row
+// holds the PEM pair; the files under <db folder>/acme are rewritten from it.
+type AcmeService struct {
+	settingService SettingService
+	inboundService InboundService
+	xrayService    XrayService
+}
+
+// acmeCertPaths names the files a certificate is materialized to. The panel,
+// subscription and inbound settings reference these paths, so they never change.
+func acmeCertPaths(cert *model.AcmeCertificate) (string, string) {
+	name := "cert-" + fmt.Sprint(cert.Id)
+	if len(cert.Domains) > 0 {
+		name = strings.ReplaceAll(cert.Domains[0], "*", "_")
+	}
+	dir := filepath.Join(config.GetDBFolderPath(), "acme", name)
+	return filepath.Join(dir, "fullchain.pem"), filepath.Join(dir, "privkey.pem")
+}
+
+func fillAcmeView(cert *model.AcmeCertificate) {
+	cert.CertFile, cert.KeyFile = acmeCertPaths(cert)
+	cert.HasDnsConfig = strings.TrimSpace(cert.DnsConfig) != ""
+}
+
+func (s *AcmeService) List() ([]*model.AcmeCertificate, error) {
+	var certs []*model.AcmeCertificate
+	if err := database.GetDB().Order("id asc").Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		fillAcmeView(cert)
+	}
+	return certs, nil
+}
+
+func (s *AcmeService) get(id int) (*model.AcmeCertificate, error) {
+	cert := &model.AcmeCertificate{}
+	if err := database.GetDB().First(cert, id).Error; err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// Issue stores the order and runs it in the background; the row's status
+// reports the outcome.
+func (s *AcmeService) Issue(req *entity.AcmeIssueRequest) (*model.AcmeCertificate, error) {
+	domains, err := acme.NormalizeDomains(req.Domains)
+	if err != nil {
+		return nil, err
+	}
+	cert := &model.AcmeCertificate{
+		Domains:   domains,
+		Challenge: req.Challenge,
+		AutoRenew: req.AutoRenew,
+		Status:    model.AcmeStatusPending,
+	}
+	switch req.Challenge {
+	case acme.ChallengeHTTP01, acme.ChallengeTLSALPN01:
+		for _, d := range domains {
+			if strings.HasPrefix(d, "*.") {
+				return nil, common.NewErrorf("wildcard %s needs the %s challenge", d, acme.ChallengeDNS01)
+			}
+		}
+	case acme.ChallengeDNS01:
+		// Built once here so a missing credential fails the request, not the order.
+		if _, err := acme.NewDNSProvider(req.DnsProvider, req.DnsConfig); err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(req.DnsConfig)
+		if err != nil {
+			return nil, err
+		}
+		cert.DnsProvider = req.DnsProvider
+		cert.DnsConfig = string(raw)
+	default:
+		return nil, common.NewErrorf("unsupported challenge %q", req.Challenge)
+	}
+
+	db := database.GetDB()
+	var existing []*model.AcmeCertificate
+	if err := db.Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	for _, other := range existing {
+		if len(other.Domains) > 0 && other.Domains[0] == domains[0] {
+			return nil, common.NewErrorf("a certificate for %s already exists, renew it instead", domains[0])
+		}
+	}
+	if err := createAcmeCertificate(db, cert); err != nil {
+		return nil, err
+	}
+	s.runAsync(cert.Id)
+	fillAcmeView(cert)
+	return cert, nil
+}
+
+// createAcmeCertificate seals the DNS credentials once the row id that binds
+// them exists, like NodeService.Create; plaintext never reaches the table.
+func createAcmeCertificate(db *gorm.DB, cert *model.AcmeCertificate) error {
+	dnsConfig := cert.DnsConfig
+	cert.DnsConfig = ""
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(cert).Error; err != nil {
+			return err
+		}
+		if dnsConfig == "" {
+			return nil
+		}
+		enc, err := secrets.Seal(secrets.AcmeScope("dns_config", cert.Id), dnsConfig)
+		if err != nil {
+			return err
+		}
+		cert.DnsConfig = enc
+		return tx.Model(cert).Update("dns_config", enc).Error
+	})
+}
+
+// Renew re-orders a certificate now, regardless of its expiry.
+func (s *AcmeService) Renew(id int) error {
+	if _, err := s.get(id); err != nil {
+		return err
+	}
+	if acmeOrderRunning(id) {
+		return common.NewError("an order for this certificate is already running")
+	}
+	s.runAsync(id)
+	return nil
+}
+
+func (s *AcmeService) runAsync(id int) {
+	common.GoRecover("acme-order", func() {
+		if err := s.order(id); err != nil {
+			logger.Warningf("ACME: certificate %d: %v", id, err)
+		}
+	})
+}
+
+// Delete refuses while the panel, subscription listener or any inbound still
+// points at the certificate: they would fail to start on the next restart.
+func (s *AcmeService) Delete(id int) error {
+	cert, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	certFile, keyFile := acmeCertPaths(cert)
+	for _, use := range []struct{ key, file string }{
+		{"webCertFile", certFile},
+		{"webKeyFile", keyFile},
+		{"subCertFile", certFile},
+		{"subKeyFile", keyFile},
+	} {
+		if value, _ := s.settingService.getString(use.key); value == use.file {
+			return common.NewErrorf("certificate is in use by %s", use.key)
+		}
+	}
+	tags, err := inboundsUsingAcmeFiles(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	if len(tags) > 0 {
+		return common.NewErrorf("certificate is in use by inbounds %s", strings.Join(tags, ", "))
+	}
+	if err := database.GetDB().Delete(&model.AcmeCertificate{}, id).Error; err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Dir(certFile)); err != nil {
+		logger.Warning("ACME: remove certificate files:", err)
+	}
+	return nil
+}
+
+// RenewDue orders every auto-renewing certificate that is missing or inside the
+// renewal window. It runs synchronously for the cron job.
+func (s *AcmeService) RenewDue() (int, error) {
+	renewDays, err := s.settingService.GetAcmeRenewDays()
+	if err != nil {
+		return 0, err
+	}
+	var certs []*model.AcmeCertificate
+	if err := database.GetDB().Where("auto_renew = ?", true).Find(&certs).Error; err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	renewed := 0
+	for _, cert := range certs {
+		if !acmeRenewalDue(cert, now, renewDays) {
+			continue
+		}
+		if err := s.order(cert.Id); err != nil {
+			logger.Warningf("ACME: renew %s failed: %v", strings.Join(cert.Domains, ","), err)
+			continue
+		}
+		renewed++
+	}
+	return renewed, nil
+}
+
+func acmeRenewalDue(cert *model.AcmeCertificate, now time.Time, renewDays int) bool {
+	if cert.Status == model.AcmeStatusError && now.Sub(time.UnixMilli(cert.LastAttempt)) < acmeRetryBackoff {
+		return false
+	}
+	if cert.NotAfter == 0 {
+		return true
+	}
+	return time.UnixMilli(cert.NotAfter).Sub(now) <= time.Duration(renewDays)*24*time.Hour
+}
+
+// EnsureFiles rewrites missing certificate files from the DB, e.g. after the
+// database was restored onto a fresh machine.
+func (s *AcmeService) EnsureFiles() error {
+	var certs []*model.AcmeCertificate
+	if err := database.GetDB().Where("cert_pem <> ''").Find(&certs).Error; err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		certFile, keyFile := acmeCertPaths(cert)
+		if _, err := os.Stat(certFile); err == nil {
+			if _, err := os.Stat(keyFile); err == nil {
+				continue
+			}
+		}
+		key, err := secrets.Open(secrets.AcmeScope("key_pem", cert.Id), cert.KeyPem)
+		if err != nil {
+			return err
+		}
+		cert.KeyPem = key
+		if err := writeAcmeFiles(cert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func acmeOrderRunning(id int) bool {
+	acmeInFlightMu.Lock()
+	defer acmeInFlightMu.Unlock()
+	return acmeInFlight[id]
+}
+
+func (s *AcmeService) order(id int) error {
+	acmeInFlightMu.Lock()
+	if acmeInFlight[id] {
+		acmeInFlightMu.Unlock()
+		return nil
+	}
+	acmeInFlight[id] = true
+	acmeInFlightMu.Unlock()
+	defer func() {
+		acmeInFlightMu.Lock()
+		delete(acmeInFlight, id)
+		acmeInFlightMu.Unlock()
+	}()
+
+	cert, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	db := database.GetDB()
+	db.Model(cert).Updates(map[string]any{"status": model.AcmeStatusPending, "last_attempt": time.Now().UnixMilli()})
+
+	issued, err := s.obtain(cert)
+	if err != nil {
+		db.Model(cert).Updates(map[string]any{"status": model.AcmeStatusError, "last_error": err.Error()})
+		return err
+	}
+	sealedKey, err := secrets.Seal(secrets.AcmeScope("key_pem", cert.Id), string(issued.KeyPEM))
+	if err != nil {
+		db.Model(cert).Updates(map[string]any{"status": model.AcmeStatusError, "last_error": err.Error()})
+		return err
+	}
+	cert.CertPem = string(issued.CertPEM)
+	cert.KeyPem = string(issued.KeyPEM)
+	cert.NotBefore = issued.NotBefore.UnixMilli()
+	cert.NotAfter = issued.NotAfter.UnixMilli()
+	cert.Status = model.AcmeStatusValid
+	cert.LastError = ""
+	if err := db.Model(cert).Updates(map[string]any{
+		"cert_pem":   cert.CertPem,
+		"key_pem":    sealedKey,
+		"not_before": cert.NotBefore,
+		"not_after":  cert.NotAfter,
+		"status":     cert.Status,
+		"last_error": "",
+	}).Error; err != nil {
+		return err
+	}
+	if err := writeAcmeFiles(cert); err != nil {
+		return err
+	}
+	logger.Infof("ACME: issued certificate for %s, valid until %s", strings.Join(cert.Domains, ","), issued.NotAfter.Format(time.DateOnly))
+
+	certFile, _ := acmeCertPaths(cert)
+	s.reloadInbounds(certFile)
+	return nil
+}
+
+func (s *AcmeService) obtain(cert *model.AcmeCertificate) (*acme.Certificate, error) {
+	keyPem, err := s.settingService.EnsureAcmeAccountKey()
+	if err != nil {
+		return nil, err
+	}
+	key, err := acme.ParseAccountKey(keyPem)
+	if err != nil {
+		return nil, err
+	}
+	email, _ := s.settingService.GetAcmeEmail()
+	directory, _ := s.settingService.GetAcmeDirectoryUrl()
+	httpPort, _ := s.settingService.GetAcmeHttpPort()
+	tlsPort, _ := s.settingService.GetAcmeTlsAlpnPort()
+	issuer := &acme.Issuer{
+		DirectoryURL: directory,
+		Email:        email,
+		AccountKey:   key,
+		HTTPPort:     httpPort,
+		TLSALPNPort:  tlsPort,
+	}
+
+	req := acme.Request{Domains: cert.Domains, Challenge: cert.Challenge}
+	if cert.Challenge == acme.ChallengeDNS01 {
+		dnsConfig := map[string]string{}
+		if cert.DnsConfig != "" {
+			raw, err := secrets.Open(secrets.AcmeScope("dns_config", cert.Id), cert.DnsConfig)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal([]byte(raw), &dnsConfig); err != nil {
+				return nil, err
+			}
+		}
+		if req.DNS, err = acme.NewDNSProvider(cert.DnsProvider, dnsConfig); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), acmeOrderTimeout)
+	defer cancel()
+	return issuer.Obtain(ctx, req)
+}
+
+// writeAcmeFiles swaps each file in with a rename so the listeners' reloader
+// never reads a half-written PEM.
+func writeAcmeFiles(cert *model.AcmeCertificate) error {
+	if cert.CertPem == "" || cert.KeyPem == "" {
+		return errors.New("certificate has not been issued yet")
+	}
+	certFile, keyFile := acmeCertPaths(cert)
+	if err := os.MkdirAll(filepath.Dir(certFile), 0o700); err != nil {
+		return err
+	}
+	if err := replaceFile(keyFile, []byte(cert.KeyPem), 0o600); err != nil {
+		return err
+	}
+	return replaceFile(certFile, []byte(cert.CertPem), 0o644)
+}
+
+func replaceFile(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// reloadInbounds re-applies local inbounds serving certFile so Xray picks up
+// the new chain; a failed apply falls back to the next scheduled restart.
+func (s *AcmeService) reloadInbounds(certFile string) {
+	db := database.GetDB()
+	var inbounds []*model.Inbound
+	if err := db.Where("node_id IS NULL AND enable = ? AND stream_settings LIKE ?", true, "%"+certFile+"%").Find(&inbounds).Error; err != nil {
+		logger.Warning("ACME: look up inbounds for reload:", err)
+		return
+	}
+	for _, ib := range inbounds {
+		if !inboundUsesCertFile(ib.StreamSettings, certFile) {
+			continue
+		}
+		rt, push, _, err := s.inboundService.nodePushPlan(ib)
+		if err != nil || !push {
+			s.xrayService.SetToNeedRestart()
+			continue
+		}
+		payload, err := s.inboundService.buildInboundForLocalRuntime(db, ib)
+		if err == nil {
+			err = rt.UpdateInbound(context.Background(), ib, payload)
+		}
+		if err != nil {
+			logger.Warning("ACME: reload inbound", ib.Tag, "failed:", err)
+			s.xrayService.SetToNeedRestart()
+		}
+	}
+}
+
+func inboundUsesCertFile(streamSettings, certFile string) bool {
+	return inboundUsesAcmeFiles(streamSettings, certFile, "")
+}
+
+// inboundUsesAcmeFiles matches either path; an empty keyFile matches nothing.
+func inboundUsesAcmeFiles(streamSettings, certFile, keyFile string) bool {
+	var stream struct {
+		TlsSettings struct {
+			Certificates []struct {
+				CertificateFile string `json:"certificateFile"`
+				KeyFile         string `json:"keyFile"`
+			} `json:"certificates"`
+		} `json:"tlsSettings"`
+	}
+	if json.Unmarshal([]byte(streamSettings), &stream) != nil {
+		return false
+	}
+	for _, c := range stream.TlsSettings.Certificates {
+		if c.CertificateFile == certFile || (keyFile != "" && c.KeyFile == keyFile) {
+			return true
+		}
+	}
+	return false
+}
+
+// inboundsUsingAcmeFiles lists the tags of local and node-hosted inbounds whose
+// TLS settings reference the certificate or key file.
+func inboundsUsingAcmeFiles(certFile, keyFile string) ([]string, error) {
+	var inbounds []*model.Inbound
+	if err := database.GetDB().Select("tag", "stream_settings").
+		Where("stream_settings LIKE ?", "%"+filepath.Dir(certFile)+"%").
+		Order("id").Find(&inbounds).Error; err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, ib := range inbounds {
+		if inboundUsesAcmeFiles(ib.StreamSettings, certFile, keyFile) {
+			tags = append(tags, ib.Tag)
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+
+	"gorm.io/gorm"
+)
+
+// Audit target kinds. Only these have snapshots, so only their changes are diffed.
+const (
+	AuditTargetInbound = "inbound"
+	AuditTargetClient  = "client"
+	AuditTargetHost    = "host"
+	AuditTargetNode    = "node"
+	AuditTargetSetting = "setting"
+	AuditTargetXray    = "xray"
+	AuditTargetWebhook = "webhook"
+	AuditTargetPlan    = "plan"
+	AuditTargetVoucher = "voucher"
+)
+
+// Actor kinds recorded with each entry.
+const (
+	AuditActorUser  = "user"
+	AuditActorToken = "token"
+	AuditActorMTLS  = "mtls"
+	// A public voucher redemption; Actor holds the voucher id, never the code.
+	AuditActorVoucher = "voucher"
+)
+
+const (
+	auditPageDefaultSize = 50
+	auditPageMaxSize     = 500
+	auditRedacted        = "[redacted]"
+)
+
+// auditIgnoredFields churn on their own (heartbeats, autoUpdateTime) and would
+// bury the change an operator actually made.
+var auditIgnoredFields = map[string]struct{}{
+	"updatedAt":     {},
+	"lastHeartbeat": {},
+	"latencyMs":     {},
+	"cpuPct":        {},
+	"memPct":        {},
+	"uptimeSecs":    {},
+	"netUp":         {},
+	"netDown":       {},
+}
+
+// AuditEntry is one API call as the controller saw it, before it is diffed.
+type AuditEntry struct {
+	Actor      string
+	ActorType  string
+	SourceIP   string
+	Method     string
+	Route      string
+	TargetType string
+	TargetId   string
+	Success    bool
+	Error      string
+	Before     any
+	After      any
+}
+
+// AuditPageParams are the query params accepted by /panel/api/audit/list and
+// /export. From and To are unix milliseconds; zero leaves that end open.
+type AuditPageParams struct {
+	Page       int    `form:"page"`
+	PageSize   int    `form:"pageSize"`
+	Actor      string `form:"actor"`
+	TargetType string `form:"targetType"`
+	TargetId   string `form:"targetId"`
+	Search     string `form:"search"`
+	From       int64  `form:"from"`
+	To         int64  `form:"to"`
+}
+
+// AuditPage is one page of audit entries, newest first.
+type AuditPage struct {
+	Items    []model.AuditLog `json:"items"`
+	Total    int64            `json:"total" example:"120"`
+	Page     int              `json:"page" example:"1"`
+	PageSize int              `json:"pageSize" example:"50"`
+}
+
+// AuditService records state-changing API calls and serves them back.
+type AuditService struct {
+	inboundService InboundService
+	clientService  ClientService
+	hostService    HostService
+	nodeService    NodeService
+	settingService SettingService
+	webhookService WebhookService
+	voucherService VoucherService
+}
+
+// Snapshot loads the current state of a target, or nil when the kind has no
+// snapshot or the target does not exist (before a create, after a delete).
+func (s *AuditService) Snapshot(targetType, targetId string) any {
+	switch targetType {
+	case AuditTargetInbound:
+		if id, err := strconv.Atoi(targetId); err == nil {
+			if inbound, err := s.inboundService.GetInbound(id); err == nil {
+				return inbound
+			}
+		}
+	case AuditTargetClient:
+		if targetId != "" {
+			if rec, err := s.clientService.GetRecordByEmail(nil, targetId); err == nil {
+				return rec
+			}
+		}
+	case AuditTargetHost:
+		if targetId != "" {
+			if group, err := s.hostService.GetHostGroup(targetId); err == nil {
+				return group
+			}
+		}
+	case AuditTargetNode:
+		if id, err := strconv.Atoi(targetId); err == nil {
+			if node, err := s.nodeService.GetById(id); err == nil {
+				return node
+			}
+		}
+	case AuditTargetSetting:
+		if all, err := s.settingService.GetAllSetting(); err == nil {
+			return all
+		}
+	case AuditTargetXray:
+		if raw, err := s.settingService.GetXrayConfigTemplate(); err == nil {
+			return raw
+		}
+	case AuditTargetWebhook:
+		if id, err := strconv.Atoi(targetId); err == nil {
+			if hook, err := s.webhookService.Get(id); err == nil {
+				return hook
+			}
+		}
+	case AuditTargetPlan:
+		if id, err := strconv.Atoi(targetId); err == nil {
+			if plan, err := s.clientService.GetPlan(id); err == nil {
+				return plan
+			}
+		}
+	case AuditTargetVoucher:
+		if id, err := strconv.Atoi(targetId); err == nil {
+			if v, err := s.voucherService.Get(id); err == nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// Record diffs the entry's snapshots and stores it.
+func (s *AuditService) Record(e *AuditEntry) error {
+	row := &model.AuditLog{
+		Actor:      e.Actor,
+		ActorType:  e.ActorType,
+		SourceIP:   e.SourceIP,
+		Method:     e.Method,
+		Route:      e.Route,
+		TargetType: e.TargetType,
+		TargetId:   e.TargetId,
+		Success:    e.Success,
+		Error:      e.Error,
+		Changes:    auditDiff(e.Before, e.After),
+	}
+	return database.GetDB().Create(row).Error
+}
+
+func (s *AuditService) filtered(p AuditPageParams) *gorm.DB {
+	q := database.GetDB().Model(&model.AuditLog{})
+	if v := strings.TrimSpace(p.Actor); v != "" {
+		q = q.Where("actor = ?", v)
+	}
+	if v := strings.TrimSpace(p.TargetType); v != "" {
+		q = q.Where("target_type = ?", v)
+	}
+	if v := strings.TrimSpace(p.TargetId); v != "" {
+		q = q.Where("target_id = ?", v)
+	}
+	if p.From > 0 {
+		q = q.Where("created_at >= ?", p.From)
+	}
+	if p.To > 0 {
+		q = q.Where("created_at <= ?", p.To)
+	}
+	if v := strings.ToLower(strings.TrimSpace(p.Search)); v != "" {
+		pattern := "%" + escapeLikeLiteral(v) + "%"
+		q = q.Where(`(LOWER(route) LIKE ? ESCAPE '\' OR LOWER(actor) LIKE ? ESCAPE '\'
+			OR LOWER(target_id) LIKE ? ESCAPE '\' OR LOWER(source_ip) LIKE ? ESCAPE '\')`,
+			pattern, pattern, pattern, pattern)
+	}
+	return q
+}
+
+// List returns one page of entries matching p, newest first.
+func (s *AuditService) List(p AuditPageParams) (*AuditPage, error) {
+	if p.PageSize <= 0 {
+		p.PageSize = auditPageDefaultSize
+	}
+	p.PageSize = min(p.PageSize, auditPageMaxSize)
+	p.Page = max(p.Page, 1)
+
+	page := &AuditPage{Items: []model.AuditLog{}, Page: p.Page, PageSize: p.PageSize}
+	if err := s.filtered(p).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	err := s.filtered(p).Order("id desc").
+		Offset((p.Page - 1) * p.PageSize).Limit(p.PageSize).
+		Find(&page.Items).Error
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// WriteCSV streams every entry matching p, oldest first; paging is ignored.
+func (s *AuditService) WriteCSV(w io.Writer, p AuditPageParams) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{
+		"id", "time", "actor", "actorType", "sourceIp", "method", "route",
+		"targetType", "targetId", "success", "error", "changes",
+	}); err != nil {
+		return err
+	}
+	var batch []model.AuditLog
+	err := s.filtered(p).FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, row := range batch {
+			changes := ""
+			if len(row.Changes) > 0 {
+				raw, err := json.Marshal(row.Changes)
+				if err != nil {
+					return err
+				}
+				changes = string(raw)
+			}
+			if err := cw.Write([]string{
+				strconv.Itoa(row.Id),
+				time.UnixMilli(row.CreatedAt).UTC().Format(time.RFC3339),
+				row.Actor, row.ActorType, row.SourceIP, row.Method, row.Route,
+				row.TargetType, row.TargetId, strconv.FormatBool(row.Success),
+				row.Error, changes,
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}).Error
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Prune deletes entries older than days; zero or less keeps everything.
+func (s *AuditService) Prune(days int) (int64, error) {
+	if days <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().UnixMilli() - int64(days)*msPerDay
+	res := database.GetDB().Where("created_at < ?", cutoff).Delete(&model.AuditLog{})
+	return res.RowsAffected, res.Error
+}
+
+// auditDiff compares two snapshots field by field. Objects are walked down to
+// their leaves; arrays and scalars are compared whole.
+func auditDiff(before, after any) map[string]model.AuditChange {
+	changes := map[string]model.AuditChange{}
+	diffAuditValues(changes, "", auditTree(before), auditTree(after))
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// auditTree turns a snapshot into plain JSON values, unpacking the JSON the
+// panel stores in string columns (inbound settings, the xray template).
+func auditTree(v any) any {
+	if v == nil {
+		return nil
+	}
+	if str, ok := v.(string); ok {
+		return expandAuditJSON(str)
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return expandAuditJSON(out)
+}
+
+func expandAuditJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			t[k] = expandAuditJSON(child)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = expandAuditJSON(child)
+		}
+	case string:
+		trimmed := strings.TrimSpace(t)
+		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			var parsed any
+			if err := json.Unmarshal([]byte(trimmed), &parsed); err == nil {
+				return expandAuditJSON(parsed)
+			}
+		}
+	}
+	return v
+}
+
+func diffAuditValues(out map[string]model.AuditChange, path string, before, after any) {
+	bm, bIsMap := before.(map[string]any)
+	am, aIsMap := after.(map[string]any)
+	if (bIsMap || before == nil) && (aIsMap || after == nil) && (bIsMap || aIsMap) {
+		keys := make([]string, 0, len(bm)+len(am))
+		for k := range bm {
+			keys = append(keys, k)
+		}
+		for k := range am {
+			if _, ok := bm[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, ignored := auditIgnoredFields[k]; ignored && path == "" {
+				continue
+			}
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			diffAuditValues(out, child, bm[k], am[k])
+		}
+		return
+	}
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	key := path[strings.LastIndex(path, ".")+1:]
+	out[path] = model.AuditChange{
+		Before: redactAuditValue(key, before),
+		After:  redactAuditValue(key, after),
+	}
+}
+
+// auditSensitiveKey matches the credential fields of inbounds, clients, nodes
+// and every setting sealed at rest, so an exported log never carries them.
+func auditSensitiveKey(key string) bool {
+	if secretSettingKeys[key] {
+		return true
+	}
+	k := strings.ToLower(key)
+	switch k {
+	case "auth", "pass", "psk":
+		return true
+	}
+	for _, part := range []string{"password", "secret", "token", "privatekey", "presharedkey"} {
+		if strings.Contains(k, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAuditValue keeps the shape of a value but masks every credential in
+// it; an empty credential stays empty so setting or clearing one still shows.
+func redactAuditValue(key string, v any) any {
+	if auditSensitiveKey(key) {
+		if v == nil || v == "" {
+			return v
+		}
+		return auditRedacted
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, child := range t {
+			out[k] = redactAuditValue(k, child)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, child := range t {
+			out[i] = redactAuditValue("", child)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+func TestAuditDiffWalksStoredJSONAndRedactsSecrets(t *testing.T) {
+	before := map[string]any{
+		"remark":         "old",
+		"updatedAt":      1,
+		"settings":       `{"clients":[{"email":"a@x","password":"p1"}],"decryption":"none"}`,
+		"streamSettings": `{"realitySettings":{"privateKey":"k1","dest":"a.com:443"}}`,
+	}
+	after := map[string]any{
+		"remark":         "new",
+		"updatedAt":      2,
+		"settings":       `{"clients":[{"email":"a@x","password":"p2"}],"decryption":"none"}`,
+		"streamSettings": `{"realitySettings":{"privateKey":"k2","dest":"a.com:443"}}`,
+	}
+	changes := auditDiff(before, after)
+
+	if len(changes) != 3 {
+		t.Fatalf("changes = %v, want remark, settings.clients and the private key", changes)
+	}
+	if c := changes["remark"]; c.Before != "old" || c.After != "new" {
+		t.Fatalf("remark = %+v", c)
+	}
+	if c := changes["streamSettings.realitySettings.privateKey"]; c.Before != auditRedacted || c.After != auditRedacted {
+		t.Fatalf("private key leaked: %+v", c)
+	}
+	clients, ok := changes["settings.clients"].After.([]any)
+	if !ok || len(clients) != 1 {
+		t.Fatalf("settings.clients = %+v", changes["settings.clients"])
+	}
+	if pw := clients[0].(map[string]any)["password"]; pw != auditRedacted {
+		t.Fatalf("nested password = %v, want it redacted", pw)
+	}
+}
+
+func TestAuditDiffCreateAndDelete(t *testing.T) {
+	created := auditDiff(nil, map[string]any{"remark": "r", "token": ""})
+	if c := created["remark"]; c.Before != nil || c.After != "r" {
+		t.Fatalf("create remark = %+v", c)
+	}
+	if c, ok := created["token"]; !ok || c.After != "" {
+		t.Fatalf("an empty secret must stay visible as empty: %+v", c)
+	}
+	deleted := auditDiff(map[string]any{"remark": "r"}, nil)
+	if c := deleted["remark"]; c.Before != "r" || c.After != nil {
+		t.Fatalf("delete remark = %+v", c)
+	}
+	if auditDiff(map[string]any{"a": 1}, map[string]any{"a": 1}) != nil {
+		t.Fatal("identical snapshots must produce no changes")
+	}
+}
+
+// TestAuditRedactsSealedSettings: a secret setting whose name no substring
+// rule catches must still never reach audit_logs in the clear.
+func TestAuditRedactsSealedSettings(t *testing.T) {
+	setupBulkDB(t)
+	settings := &SettingService{}
+	svc := &AuditService{}
+	if err := settings.setString("backupPassphrase", "old horse"); err != nil {
+		t.Fatal(err)
+	}
+	before := svc.Snapshot(AuditTargetSetting, "")
+	if err := settings.setString("backupPassphrase", "new horse"); err != nil {
+		t.Fatal(err)
+	}
+	after := svc.Snapshot(AuditTargetSetting, "")
+	if err := svc.Record(&AuditEntry{Actor: "admin", Route: "/setting/update", TargetType: AuditTargetSetting,
+		Success: true, Before: before, After: after}); err != nil {
+		t.Fatal(err)
+	}
+
+	var raw string
+	if err := database.GetDB().Raw("SELECT changes FROM audit_logs").Scan(&raw).Error; err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(raw, "horse") {
+		t.Fatalf("stored diff leaks the passphrase: %s", raw)
+	}
+	page, err := svc.List(AuditPageParams{})
+	if err != nil || page.Total != 1 {
+		t.Fatalf("List = %+v, %v", page, err)
+	}
+	if c, ok := page.Items[0].Changes["backupPassphrase"]; !ok || c.Before != auditRedacted || c.After != auditRedacted {
+		t.Fatalf("backupPassphrase change = %+v, want it recorded and redacted", c)
+	}
+}
+
+func TestAuditListExportAndPrune(t *testing.T) {
+	setupBulkDB(t)
+	svc := &AuditService{}
+	entries := []*AuditEntry{
+		{Actor: "admin", ActorType: AuditActorUser, Method: "POST", Route: "/inbounds/update/:id", TargetType: AuditTargetInbound, TargetId: "1", Success: true,
+			Before: map[string]any{"remark": "a"}, After: map[string]any{"remark": "b"}},
+		{Actor: "ci", ActorType: AuditActorToken, Method: "POST", Route: "/clients/del/:email", TargetType: AuditTargetClient, TargetId: "bob@x", Success: true},
+		{Actor: "admin", ActorType: AuditActorUser, Method: "POST", Route: "/setting/update", TargetType: AuditTargetSetting, Error: "invalid port"},
+	}
+	for _, e := range entries {
+		if err := svc.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-40 * 24 * time.Hour).UnixMilli()
+	if err := database.GetDB().Model(&model.AuditLog{}).Where("target_id = ?", "bob@x").
+		UpdateColumn("created_at", old).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := svc.List(AuditPageParams{Actor: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || page.Items[0].Route != "/setting/update" || page.Items[1].Changes["remark"].After != "b" {
+		t.Fatalf("admin page = %+v, want both admin entries newest first", page)
+	}
+	if page, _ := svc.List(AuditPageParams{Search: "BOB"}); page.Total != 1 {
+		t.Fatalf("search total = %d, want 1", page.Total)
+	}
+	if page, _ := svc.List(AuditPageParams{To: old + 1}); page.Total != 1 {
+		t.Fatalf("time-bounded total = %d, want 1", page.Total)
+	}
+
+	var buf bytes.Buffer
+	if err := svc.WriteCSV(&buf, AuditPageParams{}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][0] != "id" || rows[3][10] != "invalid port" {
+		t.Fatalf("csv = %v", rows)
+	}
+
+	pruned, err := svc.Prune(30)
+	if err != nil || pruned != 1 {
+		t.Fatalf("Prune = %d, %v; want the 40-day-old entry removed", pruned, err)
+	}
+	if pruned, _ := svc.Prune(0); pruned != 0 {
+		t.Fatal("retention 0 must keep everything")
+	}
+}
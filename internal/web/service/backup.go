@@ -0,0 +1,516 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/config"
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Backup targets a dump can be written to.
+const (
+	BackupTargetLocal = "local"
+	BackupTargetS3    = "s3"
+)
+
+const (
+	backupManifestSuffix = ".manifest.json"
+	backupEncSuffix      = ".enc"
+	// backupEncMagic heads an encrypted dump: magic | salt | nonce | AES-GCM.
+	backupEncMagic     = "XUIBAK1\n"
+	backupSaltSize     = 16
+	backupKDFRounds    = 600_000
+	backupNameMaxBytes = 200
+)
+
+var backupNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-.]+$`)
+
+// backupRunMu keeps a scheduled run and a manual one from dumping at once.
+var backupRunMu sync.Mutex
+
+// BackupManifest is stored next to every dump and describes it; Sha256 and
+// Size cover the stored (possibly encrypted) bytes.
+type BackupManifest struct {
+	Name      string `json:"name" example:"panel.example.com_2026-01-02_030000.sql.enc"`
+	Target    string `json:"target,omitempty" example:"s3"`
+	Database  string `json:"database" example:"sqlite"`
+	Version   string `json:"version" example:"3.0.0"`
+	Size      int64  `json:"size" example:"524288"`
+	Sha256    string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Encrypted bool   `json:"encrypted" example:"true"`
+	CreatedAt int64  `json:"createdAt" example:"1767322800000"`
+}
+
+// BackupService writes scheduled database dumps to local disk and
+// S3-compatible storage, prunes them by count and restores them.
+type BackupService struct {
+	settingService SettingService
+	serverService  ServerService
+}
+
+// backupStore is one place dumps are kept. Names are flat object names.
+type backupStore interface {
+	target() string
+	put(name string, data []byte) error
+	get(name string) ([]byte, error)
+	list() ([]string, error)
+	remove(name string) error
+}
+
+type localBackupStore struct {
+	dir string
+}
+
+func (l localBackupStore) target() string { return BackupTargetLocal }
+
+func (l localBackupStore) put(name string, data []byte) error {
+	if err := os.MkdirAll(l.dir, 0o700); err != nil {
+		return err
+	}
+	tmp := filepath.Join(l.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(l.dir, name))
+}
+
+func (l localBackupStore) get(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(l.dir, name))
+}
+
+func (l localBackupStore) list() ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (l localBackupStore) remove(name string) error {
+	err := os.Remove(filepath.Join(l.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+type s3BackupStore struct {
+	client *s3Client
+	prefix string
+}
+
+func (s s3BackupStore) target() string { return BackupTargetS3 }
+
+func (s s3BackupStore) put(name string, data []byte) error {
+	return s.client.Put(s.prefix+name, data)
+}
+
+func (s s3BackupStore) get(name string) ([]byte, error) {
+	return s.client.Get(s.prefix + name)
+}
+
+func (s s3BackupStore) list() ([]string, error) {
+	keys, err := s.client.List(s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		// Deeper keys belong to someone else sharing the prefix.
+		if name := strings.TrimPrefix(k, s.prefix); !strings.Contains(name, "/") {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (s s3BackupStore) remove(name string) error {
+	return s.client.Delete(s.prefix + name)
+}
+
+// backupCronParser matches the panel scheduler: six fields, seconds first, or a descriptor.
+var backupCronParser = cron.NewParser(
+	cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+func validateBackupSettings(allSetting *entity.AllSetting) error {
+	allSetting.BackupCron = strings.TrimSpace(allSetting.BackupCron)
+	if allSetting.BackupEnable {
+		if _, err := backupCronParser.Parse(allSetting.BackupCron); err != nil {
+			return common.NewError("backup schedule is not a valid cron expression:", err)
+		}
+	}
+	allSetting.BackupLocalDir = strings.TrimSpace(allSetting.BackupLocalDir)
+	if dir := allSetting.BackupLocalDir; dir != "" && !filepath.IsAbs(dir) {
+		return common.NewError("backup directory must be an absolute path")
+	}
+	if endpoint := strings.TrimSpace(allSetting.BackupS3Endpoint); endpoint != "" {
+		u, err := SanitizeHTTPURL(endpoint)
+		if err != nil {
+			return common.NewError("S3 endpoint is invalid:", err)
+		}
+		if strings.TrimSpace(allSetting.BackupS3Bucket) == "" {
+			return common.NewError("S3 bucket is required when an S3 endpoint is set")
+		}
+		allSetting.BackupS3Endpoint = u
+	}
+	return nil
+}
+
+// stores returns the configured backup targets; a target without a
+// directory or bucket is simply off.
+func (s *BackupService) stores() ([]backupStore, error) {
+	var out []backupStore
+	dir, err := s.settingService.GetBackupLocalDir()
+	if err != nil {
+		return nil, err
+	}
+	if dir = strings.TrimSpace(dir); dir != "" {
+		out = append(out, localBackupStore{dir: dir})
+	}
+	store, err := s.s3Store()
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		out = append(out, *store)
+	}
+	return out, nil
+}
+
+func (s *BackupService) s3Store() (*s3BackupStore, error) {
+	endpoint, err := s.settingService.GetBackupS3Endpoint()
+	if err != nil || strings.TrimSpace(endpoint) == "" {
+		return nil, err
+	}
+	region, _ := s.settingService.GetBackupS3Region()
+	bucket, _ := s.settingService.GetBackupS3Bucket()
+	accessKey, _ := s.settingService.GetBackupS3AccessKey()
+	secretKey, _ := s.settingService.GetBackupS3SecretKey()
+	pathStyle, _ := s.settingService.GetBackupS3PathStyle()
+	prefix, _ := s.settingService.GetBackupS3Prefix()
+	client, err := newS3Client(endpoint, strings.TrimSpace(region), strings.TrimSpace(bucket), accessKey, secretKey, pathStyle)
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.TrimLeft(strings.TrimSpace(prefix), "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &s3BackupStore{client: client, prefix: prefix}, nil
+}
+
+func (s *BackupService) storeFor(target string) (backupStore, error) {
+	stores, err := s.stores()
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range stores {
+		if st.target() == target {
+			return st, nil
+		}
+	}
+	return nil, common.NewErrorf("backup target %q is not configured", target)
+}
+
+// dump returns a logical dump of the live database and its file extension.
+func (s *BackupService) dump() ([]byte, string, error) {
+	if database.IsPostgres() {
+		data, err := s.serverService.exportPostgresDB()
+		return data, ".dump", err
+	}
+	backupPath, cleanup, err := s.serverService.backupSQLite()
+	if err != nil {
+		return nil, "", err
+	}
+	defer cleanup()
+	data, err := database.DumpSQLiteToBytes(backupPath)
+	return data, ".sql", err
+}
+
+// Run dumps the database once for every target; a failing target does not
+// stop the others, and their errors are joined.
+func (s *BackupService) Run() ([]BackupManifest, error) {
+	backupRunMu.Lock()
+	defer backupRunMu.Unlock()
+
+	stores, err := s.stores()
+	if err != nil {
+		return nil, err
+	}
+	if len(stores) == 0 {
+		return nil, common.NewError("no backup target is configured")
+	}
+	data, ext, err := s.dump()
+	if err != nil {
+		return nil, err
+	}
+	dbKind := "sqlite"
+	if database.IsPostgres() {
+		dbKind = "postgres"
+	}
+	manifest := BackupManifest{
+		Name:      s.serverService.backupHost("") + backupDateSuffix(time.Now()) + ext,
+		Database:  dbKind,
+		Version:   config.GetPanelVersion(),
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	passphrase, err := s.settingService.GetBackupPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	if passphrase != "" {
+		if data, err = encryptBackup(data, passphrase); err != nil {
+			return nil, err
+		}
+		manifest.Name += backupEncSuffix
+		manifest.Encrypted = true
+	}
+	sum := sha256.Sum256(data)
+	manifest.Sha256 = hex.EncodeToString(sum[:])
+	manifest.Size = int64(len(data))
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	keep, _ := s.settingService.GetBackupKeep()
+	var written []BackupManifest
+	var errs []error
+	for _, st := range stores {
+		// The manifest goes last so a listed backup always has its data.
+		if err := st.put(manifest.Name, data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", st.target(), err))
+			continue
+		}
+		if err := st.put(manifest.Name+backupManifestSuffix, manifestJSON); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", st.target(), err))
+			continue
+		}
+		m := manifest
+		m.Target = st.target()
+		written = append(written, m)
+		if err := s.prune(st, keep); err != nil {
+			logger.Warningf("backup: prune %s: %v", st.target(), err)
+		}
+	}
+	return written, errors.Join(errs...)
+}
+
+// manifests reads every manifest in a store, newest first. Unreadable ones
+// are logged and skipped so one bad file cannot hide the rest.
+func (s *BackupService) manifests(st backupStore) ([]BackupManifest, error) {
+	names, err := st.list()
+	if err != nil {
+		return nil, err
+	}
+	var out []BackupManifest
+	for _, name := range names {
+		if !strings.HasSuffix(name, backupManifestSuffix) {
+			continue
+		}
+		raw, err := st.get(name)
+		if err != nil {
+			logger.Warningf("backup: read %s manifest %s: %v", st.target(), name, err)
+			continue
+		}
+		var m BackupManifest
+		if err := json.Unmarshal(raw, &m); err != nil || m.Name+backupManifestSuffix != name {
+			logger.Warningf("backup: skip malformed %s manifest %s", st.target(), name)
+			continue
+		}
+		m.Target = st.target()
+		out = append(out, m)
+	}
+	slices.SortFunc(out, func(a, b BackupManifest) int {
+		return cmp.Or(cmp.Compare(b.CreatedAt, a.CreatedAt), strings.Compare(b.Name, a.Name))
+	})
+	return out, nil
+}
+
+func (s *BackupService) prune(st backupStore, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	list, err := s.manifests(st)
+	if err != nil || len(list) <= keep {
+		return err
+	}
+	for _, m := range list[keep:] {
+		if err := st.remove(m.Name); err != nil {
+			return err
+		}
+		if err := st.remove(m.Name + backupManifestSuffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the backups of every configured target, newest first.
+func (s *BackupService) List() ([]BackupManifest, error) {
+	stores, err := s.stores()
+	if err != nil {
+		return nil, err
+	}
+	out := []BackupManifest{}
+	var errs []error
+	for _, st := range stores {
+		list, err := s.manifests(st)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", st.target(), err))
+			continue
+		}
+		out = append(out, list...)
+	}
+	slices.SortStableFunc(out, func(a, b BackupManifest) int {
+		return cmp.Compare(b.CreatedAt, a.CreatedAt)
+	})
+	return out, errors.Join(errs...)
+}
+
+// Load fetches a backup and checks it against its manifest, returning the
+// decrypted dump.
+func (s *BackupService) Load(target, name string) ([]byte, error) {
+	if len(name) > backupNameMaxBytes || !backupNameRegex.MatchString(name) || strings.HasSuffix(name, backupManifestSuffix) {
+		return nil, common.NewError("invalid backup name")
+	}
+	st, err := s.storeFor(target)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := st.get(name + backupManifestSuffix)
+	if err != nil {
+		return nil, common.NewErrorf("backup %s not found on %s: %v", name, target, err)
+	}
+	var m BackupManifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, common.NewErrorf("backup manifest is corrupt: %v", err)
+	}
+	data, err := st.get(name)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if int64(len(data)) != m.Size || hex.EncodeToString(sum[:]) != m.Sha256 {
+		return nil, common.NewError("backup checksum does not match its manifest; the file is damaged or was modified")
+	}
+	if !m.Encrypted {
+		return data, nil
+	}
+	passphrase, err := s.settingService.GetBackupPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, common.NewError("this backup is encrypted; set the backup passphrase it was made with")
+	}
+	return decryptBackup(data, passphrase)
+}
+
+// Restore replaces the live database with a stored backup, the same way an
+// uploaded file is imported.
+func (s *BackupService) Restore(target, name string, keepHostSettings bool) error {
+	data, err := s.Load(target, name)
+	if err != nil {
+		return err
+	}
+	return s.serverService.ImportDB(memFile{bytes.NewReader(data)}, keepHostSettings)
+}
+
+// memFile lets an in-memory dump pass where an uploaded multipart file is expected.
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error { return nil }
+
+func backupKey(passphrase string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, salt, backupKDFRounds, 32)
+}
+
+func encryptBackup(plain []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, backupSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := backupKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(backupEncMagic)+len(salt)+len(nonce)+len(plain)+gcm.Overhead())
+	out = append(out, backupEncMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plain, []byte(backupEncMagic)), nil
+}
+
+func decryptBackup(data []byte, passphrase string) ([]byte, error) {
+	const nonceSize = 12
+	if !bytes.HasPrefix(data, []byte(backupEncMagic)) || len(data) < len(backupEncMagic)+backupSaltSize+nonceSize {
+		return nil, common.NewError("not an encrypted panel backup")
+	}
+	rest := data[len(backupEncMagic):]
+	salt, rest := rest[:backupSaltSize], rest[backupSaltSize:]
+	nonce, sealed := rest[:nonceSize], rest[nonceSize:]
+	key, err := backupKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, nonce, sealed, []byte(backupEncMagic))
+	if err != nil {
+		return nil, common.NewError("cannot decrypt backup: wrong passphrase or damaged file")
+	}
+	return plain, nil
+}
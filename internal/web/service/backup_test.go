@@ -0,0 +1,202 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible bucket that
+// checks every request carries a Signature V4 header and a matching payload hash.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+		r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, "<Error><Code>SignatureDoesNotMatch</Code><Message>bad</Message></Error>")
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		type content struct {
+			Key string `xml:"Key"`
+		}
+		var out struct {
+			XMLName  xml.Name  `xml:"ListBucketResult"`
+			Contents []content `xml:"Contents"`
+		}
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				out.Contents = append(out.Contents, content{Key: k})
+			}
+		}
+		_ = xml.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+	case r.Method == http.MethodGet:
+		data, found := f.objects[key]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>")
+			return
+		}
+		_, _ = w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestBackupRunListLoadAndPrune(t *testing.T) {
+	setupBulkDB(t)
+	s3 := &fakeS3{bucket: "backups", objects: map[string][]byte{}}
+	srv := httptest.NewServer(s3)
+	defer srv.Close()
+	dir := t.TempDir()
+
+	settings := &SettingService{}
+	for k, v := range map[string]string{
+		"webDomain":         "panel.test",
+		"backupLocalDir":    dir,
+		"backupS3Endpoint":  srv.URL,
+		"backupS3Bucket":    "backups",
+		"backupS3AccessKey": "AKID",
+		"backupS3SecretKey": "secret",
+		"backupPassphrase":  "correct horse",
+		"backupKeep":        "2",
+	} {
+		if err := settings.setString(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := &BackupService{}
+	written, err := svc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 2 || !written[0].Encrypted || !strings.HasSuffix(written[0].Name, ".sql.enc") {
+		t.Fatalf("want an encrypted dump on both targets, got %+v", written)
+	}
+	name := written[0].Name
+	if _, ok := s3.objects["x-ui/"+name+backupManifestSuffix]; !ok {
+		t.Fatalf("manifest missing from bucket: %v", slices.Collect(maps.Keys(s3.objects)))
+	}
+
+	for _, target := range []string{BackupTargetLocal, BackupTargetS3} {
+		plain, err := svc.Load(target, name)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if !strings.Contains(string(plain), "CREATE TABLE") {
+			t.Fatalf("%s: decrypted backup is not a SQL dump", target)
+		}
+	}
+
+	// A modified file must fail its manifest checksum.
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("tampered"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Load(BackupTargetLocal, name); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("tampered backup loaded: %v", err)
+	}
+	if _, err := svc.Load(BackupTargetLocal, "../x-ui.db"); err == nil {
+		t.Fatal("path traversal in a backup name must be rejected")
+	}
+
+	// Seed two older backups; retention keeps the newest two.
+	store := s3BackupStore{client: mustS3(t, srv.URL), prefix: "x-ui/"}
+	for i, old := range []string{"old-a.sql", "old-b.sql"} {
+		m, _ := json.Marshal(BackupManifest{Name: old, CreatedAt: int64(i + 1)})
+		_ = store.put(old, []byte("x"))
+		_ = store.put(old+backupManifestSuffix, m)
+	}
+	if err := svc.prune(store, 2); err != nil {
+		t.Fatal(err)
+	}
+	list, err := svc.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s3Names []string
+	for _, m := range list {
+		if m.Target == BackupTargetS3 {
+			s3Names = append(s3Names, m.Name)
+		}
+	}
+	if !slices.Equal(s3Names, []string{name, "old-b.sql"}) {
+		t.Fatalf("retention kept %v", s3Names)
+	}
+	if _, ok := s3.objects["x-ui/old-a.sql"]; ok {
+		t.Fatal("pruned backup data left behind")
+	}
+}
+
+func mustS3(t *testing.T, endpoint string) *s3Client {
+	t.Helper()
+	c, err := newS3Client(endpoint, "", "backups", "AKID", "secret", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestBackupEncryptionRejectsWrongPassphrase(t *testing.T) {
+	sealed, err := encryptBackup([]byte("PRAGMA foreign_keys=OFF;"), "one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decryptBackup(sealed, "two"); err == nil {
+		t.Fatal("decrypted with the wrong passphrase")
+	}
+	plain, err := decryptBackup(sealed, "one")
+	if err != nil || string(plain) != "PRAGMA foreign_keys=OFF;" {
+		t.Fatalf("round trip = %q, %v", plain, err)
+	}
+}
+
+func TestValidateBackupSettings(t *testing.T) {
+	cases := []struct {
+		name string
+		in   entity.AllSetting
+		ok   bool
+	}{
+		{"descriptor", entity.AllSetting{BackupEnable: true, BackupCron: "@daily"}, true},
+		{"six fields", entity.AllSetting{BackupEnable: true, BackupCron: "0 30 3 * * *"}, true},
+		{"five fields", entity.AllSetting{BackupEnable: true, BackupCron: "30 3 * * *"}, false},
+		{"relative dir", entity.AllSetting{BackupLocalDir: "backups"}, false},
+		{"endpoint without bucket", entity.AllSetting{BackupS3Endpoint: "https://s3.example.com"}, false},
+	}
+	for _, tc := range cases {
+		if err := validateBackupSettings(&tc.in); (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+	}
+}
@@ -69,6 +69,8 @@ type ClientCreatePayload struct {
 	Client     model.Client `json:"client"`
 	InboundIds []int        `json:"inboundIds"`
 	LimitHwid  int          `json:"-"`
+	// Scope holds the write to a reseller's caps and stamps it as the owner.
+	Scope *ClientScope `json:"-"`
 }
 
 const sqlInChunk = 400
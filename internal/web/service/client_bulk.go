@@ -83,6 +83,10 @@ func (s *ClientService) BulkAttach(inboundSvc *InboundService, emails []string,
 				result.Skipped = append(result.Skipped, rec.Email)
 				continue
 			}
+			if err := clientScheduleOnNodes(nil, rec.Email, rec.Group, rec.Schedule, []int{ibId}); err != nil {
+				recordErr("%s -> inbound %d: %v", rec.Email, ibId, err)
+				continue
+			}
 			client := *rec.ToClient()
 			client.UpdatedAt = time.Now().UnixMilli()
 			if err := s.fillProtocolDefaults(&client, inbound); err != nil {
@@ -253,6 +257,16 @@ var bulkFlowAllowed = map[string]struct{}{
 	"xtls-rprx-vision-udp443": {},
 }
 
+// normalizeBulkFlow maps a flow directive outside bulkFlowAllowed to "",
+// which leaves flow untouched.
+func normalizeBulkFlow(flow string) string {
+	flow = strings.TrimSpace(flow)
+	if _, ok := bulkFlowAllowed[flow]; !ok {
+		return ""
+	}
+	return flow
+}
+
 // BulkAdjust shifts ExpiryTime by addDays (days) and TotalGB by addBytes
 // for every email in the list. Clients whose corresponding field is
 // unlimited (0) are skipped — bulk extend should not accidentally
@@ -260,113 +274,23 @@ var bulkFlowAllowed = map[string]struct{}{
 //
 // Like BulkDelete, the work is grouped by inbound so each inbound's
 // settings JSON is parsed and written exactly once regardless of how
-// many target emails it contains.
-func (s *ClientService) BulkAdjust(inboundSvc *InboundService, emails []string, addDays int, addBytes int64, flow string) (BulkAdjustResult, bool, error) {
+// many target emails it contains. A non-nil scope holds each inbound's
+// write to the reseller's caps inside its transaction.
+func (s *ClientService) BulkAdjust(inboundSvc *InboundService, sc *ClientScope, emails []string, addDays int, addBytes int64, flow string) (BulkAdjustResult, bool, error) {
 	result := BulkAdjustResult{}
 	if len(emails) == 0 {
 		return result, false, nil
 	}
-	flow = strings.TrimSpace(flow)
-	if _, ok := bulkFlowAllowed[flow]; !ok {
-		flow = "" // ignore unknown directives — "" means "leave flow untouched"
-	}
+	flow = normalizeBulkFlow(flow)
 	adjustFlow := flow != ""
 	if addDays == 0 && addBytes == 0 && !adjustFlow {
 		return result, false, common.NewError("no adjustment specified")
 	}
 
-	addExpiryMs := int64(addDays) * 24 * 60 * 60 * 1000
-
-	seen := map[string]struct{}{}
-	cleanEmails := make([]string, 0, len(emails))
-	for _, e := range emails {
-		e = strings.TrimSpace(e)
-		if e == "" {
-			continue
-		}
-		if _, ok := seen[e]; ok {
-			continue
-		}
-		seen[e] = struct{}{}
-		cleanEmails = append(cleanEmails, e)
-	}
-	if len(cleanEmails) == 0 {
-		return result, false, nil
-	}
-
 	db := database.GetDB()
-
-	var records []model.ClientRecord
-	for _, batch := range chunkStrings(cleanEmails, sqlInChunk) {
-		var rows []model.ClientRecord
-		if err := db.Where("email IN ?", batch).Find(&rows).Error; err != nil {
-			return result, false, err
-		}
-		records = append(records, rows...)
-	}
-	recordsByEmail := make(map[string]*model.ClientRecord, len(records))
-	for i := range records {
-		recordsByEmail[records[i].Email] = &records[i]
-	}
-
-	skippedReasons := map[string]string{}
-	for _, email := range cleanEmails {
-		if _, ok := recordsByEmail[email]; !ok {
-			skippedReasons[email] = "client not found"
-		}
-	}
-
-	plan := map[string]*bulkAdjustEntry{}
-	for email, rec := range recordsByEmail {
-		entry := &bulkAdjustEntry{record: rec}
-		if addDays != 0 {
-			switch {
-			case rec.ExpiryTime == 0:
-				if _, exists := skippedReasons[email]; !exists {
-					skippedReasons[email] = "unlimited expiry"
-				}
-			case rec.ExpiryTime > 0:
-				next := rec.ExpiryTime + addExpiryMs
-				if next <= 0 {
-					if _, exists := skippedReasons[email]; !exists {
-						skippedReasons[email] = "reduction exceeds remaining time"
-					}
-				} else {
-					entry.applyExpiry = true
-					entry.newExpiry = next
-				}
-			default:
-				next := rec.ExpiryTime - addExpiryMs
-				if next >= 0 {
-					if _, exists := skippedReasons[email]; !exists {
-						skippedReasons[email] = "reduction exceeds delay window"
-					}
-				} else {
-					entry.applyExpiry = true
-					entry.newExpiry = next
-				}
-			}
-		}
-		if addBytes != 0 {
-			if rec.TotalGB == 0 {
-				if _, exists := skippedReasons[email]; !exists {
-					skippedReasons[email] = "unlimited traffic"
-				}
-			} else {
-				next := rec.TotalGB + addBytes
-				if next <= 0 {
-					if _, exists := skippedReasons[email]; !exists {
-						skippedReasons[email] = "reduction exceeds remaining quota"
-					}
-				} else {
-					entry.applyTotal = true
-					entry.newTotal = next
-				}
-			}
-		}
-		if entry.applyExpiry || entry.applyTotal || adjustFlow {
-			plan[email] = entry
-		}
+	plan, skippedReasons, err := planBulkAdjust(db, emails, addDays, addBytes, adjustFlow)
+	if err != nil {
+		return result, false, err
 	}
 
 	if len(plan) == 0 {
@@ -405,7 +329,7 @@ func (s *ClientService) BulkAdjust(inboundSvc *InboundService, emails []string,
 	flowIneligible := map[string]bool{}
 	execFailed := map[string]bool{}
 	for inboundId, ibEmails := range emailsByInbound {
-		ibRes := s.bulkAdjustInboundClients(inboundSvc, inboundId, ibEmails, plan, flow)
+		ibRes := s.bulkAdjustInboundClients(inboundSvc, inboundId, ibEmails, plan, flow, sc.guard(ibEmails...))
 		if ibRes.needRestart {
 			needRestart = true
 		}
@@ -524,6 +448,111 @@ func (s *ClientService) BulkAdjust(inboundSvc *InboundService, emails []string,
 	return result, needRestart, nil
 }
 
+// planBulkAdjust resolves the new expiry and quota of each client BulkAdjust
+// changes, and why the others are skipped.
+func planBulkAdjust(db *gorm.DB, emails []string, addDays int, addBytes int64, adjustFlow bool) (map[string]*bulkAdjustEntry, map[string]string, error) {
+	addExpiryMs := int64(addDays) * 24 * 60 * 60 * 1000
+
+	seen := map[string]struct{}{}
+	cleanEmails := make([]string, 0, len(emails))
+	for _, e := range emails {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if _, ok := seen[e]; ok {
+			continue
+		}
+		seen[e] = struct{}{}
+		cleanEmails = append(cleanEmails, e)
+	}
+	if len(cleanEmails) == 0 {
+		return nil, nil, nil
+	}
+
+	var records []model.ClientRecord
+	for _, batch := range chunkStrings(cleanEmails, sqlInChunk) {
+		var rows []model.ClientRecord
+		if err := db.Where("email IN ?", batch).Find(&rows).Error; err != nil {
+			return nil, nil, err
+		}
+		records = append(records, rows...)
+	}
+	recordsByEmail := make(map[string]*model.ClientRecord, len(records))
+	for i := range records {
+		recordsByEmail[records[i].Email] = &records[i]
+	}
+
+	skippedReasons := map[string]string{}
+	for _, email := range cleanEmails {
+		if _, ok := recordsByEmail[email]; !ok {
+			skippedReasons[email] = "client not found"
+		}
+	}
+
+	plan := map[string]*bulkAdjustEntry{}
+	for email, rec := range recordsByEmail {
+		entry := &bulkAdjustEntry{record: rec}
+		if addDays != 0 {
+			switch {
+			case rec.ExpiryTime == 0:
+				if _, exists := skippedReasons[email]; !exists {
+					skippedReasons[email] = "unlimited expiry"
+				}
+			case rec.ExpiryTime > 0:
+				next := rec.ExpiryTime + addExpiryMs
+				if next <= 0 {
+					if _, exists := skippedReasons[email]; !exists {
+						skippedReasons[email] = "reduction exceeds remaining time"
+					}
+				} else {
+					entry.applyExpiry = true
+					entry.newExpiry = next
+				}
+			default:
+				next := rec.ExpiryTime - addExpiryMs
+				if next >= 0 {
+					if _, exists := skippedReasons[email]; !exists {
+						skippedReasons[email] = "reduction exceeds delay window"
+					}
+				} else {
+					entry.applyExpiry = true
+					entry.newExpiry = next
+				}
+			}
+		}
+		if addBytes != 0 {
+			if rec.TotalGB == 0 {
+				if _, exists := skippedReasons[email]; !exists {
+					skippedReasons[email] = "unlimited traffic"
+				}
+			} else {
+				next := rec.TotalGB + addBytes
+				if next <= 0 {
+					if _, exists := skippedReasons[email]; !exists {
+						skippedReasons[email] = "reduction exceeds remaining quota"
+					}
+				} else {
+					entry.applyTotal = true
+					entry.newTotal = next
+				}
+			}
+		}
+		if entry.applyExpiry || entry.applyTotal || adjustFlow {
+			plan[email] = entry
+		}
+	}
+	return plan, skippedReasons, nil
+}
+
+// bulkFlowEligible reports whether inbound honors the flow directive. Clearing
+// flow is always allowed; setting a vision flow needs an inbound that can carry it.
+func bulkFlowEligible(inbound *model.Inbound, flow string) bool {
+	return flow == bulkFlowClear ||
+		(!inbound.DisableFlow &&
+			inboundCanEnableTlsFlow(string(inbound.Protocol), inbound.StreamSettings, inbound.Settings))
+}
+
 type bulkInboundAdjustResult struct {
 	perEmailSkipped map[string]string
 	flowHonored     map[string]bool
@@ -547,6 +576,7 @@ func (s *ClientService) bulkAdjustInboundClients(
 	emails []string,
 	plan map[string]*bulkAdjustEntry,
 	flow string,
+	guard *scopeGuard,
 ) bulkInboundAdjustResult {
 	res := bulkInboundAdjustResult{perEmailSkipped: map[string]string{}, flowHonored: map[string]bool{}, flowIneligible: map[string]bool{}}
 
@@ -581,11 +611,8 @@ func (s *ClientService) bulkAdjustInboundClients(
 	}
 
 	// Flow eligibility is a property of the inbound (protocol + transport), so
-	// resolve it once. Clearing flow is always allowed; setting a vision flow
-	// is only honored on an inbound that can carry it.
-	flowEligible := flow == bulkFlowClear ||
-		(!oldInbound.DisableFlow &&
-			inboundCanEnableTlsFlow(string(oldInbound.Protocol), oldInbound.StreamSettings, oldInbound.Settings))
+	// resolve it once.
+	flowEligible := bulkFlowEligible(oldInbound, flow)
 
 	interfaceClients, _ := settings["clients"].([]any)
 	foundEmails := map[string]bool{}
@@ -659,6 +686,9 @@ func (s *ClientService) bulkAdjustInboundClients(
 	// Serialize against the traffic poll to avoid the cross-transaction
 	// lock-order deadlock on inbounds/client_records (runSerializedTx).
 	txErr := runSerializedTx(func(tx *gorm.DB) error {
+		if err := guard.begin(tx); err != nil {
+			return err
+		}
 		if err := tx.Save(oldInbound).Error; err != nil {
 			return err
 		}
@@ -669,6 +699,9 @@ func (s *ClientService) bulkAdjustInboundClients(
 		if err := s.SyncInbound(tx, inboundId, finalClients); err != nil {
 			return err
 		}
+		if err := guard.finish(tx); err != nil {
+			return err
+		}
 		if oldInbound.NodeID != nil {
 			return (&NodeService{}).MarkNodeDirtyTx(tx, *oldInbound.NodeID)
 		}
@@ -867,7 +900,12 @@ func (s *ClientService) BulkDelete(inboundSvc *InboundService, emails []string,
 	for email, reason := range skippedReasons {
 		result.Skipped = append(result.Skipped, BulkDeleteReport{Email: email, Reason: reason})
 	}
-	return result, needRestart, nil
+	deleted := make([]model.ClientRecord, 0, len(successEmails))
+	for _, email := range successEmails {
+		deleted = append(deleted, *recordsByEmail[email])
+	}
+	routed, err := egressRouted(nil, deleted...)
+	return result, needRestart || routed, err
 }
 
 type bulkInboundDeleteResult struct {
@@ -1111,12 +1149,86 @@ func (s *ClientService) BulkCreate(inboundSvc *InboundService, payloads []Client
 		result.Skipped = append(result.Skipped, BulkCreateReport{Email: email, Reason: reason})
 	}
 
-	type prepared struct {
-		client     model.Client
-		inboundIds []int
-		limitHwid  int
+	plan, err := s.planBulkCreate(inboundSvc, payloads, skip)
+	if err != nil || plan == nil {
+		return result, false, err
+	}
+	prep, failed, reason := plan.prep, plan.failed, plan.reason
+
+	needRestart := false
+	for _, ibId := range plan.inboundOrder {
+		payload, e := json.Marshal(map[string][]model.Client{"clients": plan.byInbound[ibId]})
+		if e == nil {
+			var nr bool
+			nr, e = s.addInboundClient(inboundSvc, &model.Inbound{Id: ibId, Settings: string(payload)}, plan.guard(ibId))
+			if e == nil && nr {
+				needRestart = true
+			}
+		}
+		if e != nil {
+			for _, idx := range plan.idxByInbound[ibId] {
+				failed[idx] = true
+				if reason[idx] == "" {
+					reason[idx] = e.Error()
+				}
+			}
+		}
+	}
+
+	created := make([]model.ClientRecord, 0, len(prep))
+	for idx := range prep {
+		if failed[idx] {
+			skip(prep[idx].client.Email, reason[idx])
+			continue
+		}
+		if err := s.setClientLimitHwidByEmail(nil, prep[idx].client.Email, prep[idx].limitHwid); err != nil {
+			skip(prep[idx].client.Email, err.Error())
+			continue
+		}
+		result.Created++
+		created = append(created, *prep[idx].client.ToRecord())
 	}
-	prep := make([]prepared, 0, len(payloads))
+	routed, err := egressRouted(nil, created...)
+	return result, needRestart || routed, err
+}
+
+// bulkCreateItem is a BulkCreate payload that passed validation.
+type bulkCreateItem struct {
+	client     model.Client
+	inboundIds []int
+	limitHwid  int
+	scope      *ClientScope
+}
+
+// bulkCreatePlan holds the clients BulkCreate adds to each inbound; items
+// marked failed are reported as skipped.
+type bulkCreatePlan struct {
+	prep         []bulkCreateItem
+	byInbound    map[int][]model.Client
+	idxByInbound map[int][]int
+	inboundOrder []int
+	failed       []bool
+	reason       []string
+}
+
+// guard holds the clients added to ibId to the scope they were created under.
+func (p *bulkCreatePlan) guard(ibId int) *scopeGuard {
+	idxs := p.idxByInbound[ibId]
+	if len(idxs) == 0 {
+		return nil
+	}
+	emails := make([]string, 0, len(idxs))
+	for _, idx := range idxs {
+		emails = append(emails, p.prep[idx].client.Email)
+	}
+	return p.prep[idxs[0]].scope.guard(emails...)
+}
+
+// planBulkCreate validates payloads and resolves them against the stored
+// clients and target inbounds, calling skip for each one it drops. It returns
+// nil when none is left.
+func (s *ClientService) planBulkCreate(inboundSvc *InboundService, payloads []ClientCreatePayload, skip func(email, reason string)) (*bulkCreatePlan, error) {
+	prep := make([]bulkCreateItem, 0, len(payloads))
 	emails := make([]string, 0, len(payloads))
 	subIDs := make([]string, 0, len(payloads))
 	seenEmail := make(map[string]struct{}, len(payloads))
@@ -1149,12 +1261,19 @@ func (s *ClientService) BulkCreate(inboundSvc *InboundService, payloads []Client
 			skip(email, verr.Error())
 			continue
 		}
+		sched, verr := normalizeClientSchedule(client.Schedule)
+		if verr != nil {
+			skip(email, verr.Error())
+			continue
+		}
 		if len(payloads[i].InboundIds) == 0 {
 			skip(email, "at least one inbound is required")
 			continue
 		}
 
 		client.Email = email
+		client.Egress = strings.TrimSpace(client.Egress)
+		client.Schedule = sched
 		if client.SubID == "" {
 			client.SubID = uuid.NewString()
 		}
@@ -1179,13 +1298,13 @@ func (s *ClientService) BulkCreate(inboundSvc *InboundService, payloads []Client
 		seenEmail[le] = struct{}{}
 		seenSubID[client.SubID] = le
 
-		prep = append(prep, prepared{client: client, inboundIds: payloads[i].InboundIds, limitHwid: payloads[i].LimitHwid})
+		prep = append(prep, bulkCreateItem{client: client, inboundIds: payloads[i].InboundIds, limitHwid: payloads[i].LimitHwid, scope: payloads[i].Scope})
 		emails = append(emails, email)
 		subIDs = append(subIDs, client.SubID)
 	}
 
 	if len(prep) == 0 {
-		return result, false, nil
+		return nil, nil
 	}
 
 	db := database.GetDB()
@@ -1195,7 +1314,7 @@ func (s *ClientService) BulkCreate(inboundSvc *InboundService, payloads []Client
 		end := min(start+lookupChunk, len(emails))
 		var rows []model.ClientRecord
 		if e := db.Where("email IN ?", emails[start:end]).Find(&rows).Error; e != nil {
-			return result, false, e
+			return nil, e
 		}
 		for i := range rows {
 			existingByEmail[strings.ToLower(rows[i].Email)] = rows[i]
@@ -1206,7 +1325,7 @@ func (s *ClientService) BulkCreate(inboundSvc *InboundService, payloads []Client
 		end := min(start+lookupChunk, len(subIDs))
 		var rows []model.ClientRecord
 		if e := db.Where("sub_id IN ?", subIDs[start:end]).Find(&rows).Error; e != nil {
-			return result, false, e
+			return nil, e
 		}
 		for i := range rows {
 			existingSubOwner[rows[i].SubID] = strings.ToLower(rows[i].Email)
@@ -1287,39 +1406,8 @@ func (s *ClientService) BulkCreate(inboundSvc *InboundService, payloads []Client
 			idxByInbound[ibId] = append(idxByInbound[ibId], idx)
 		}
 	}
-
-	needRestart := false
-	for _, ibId := range inboundOrder {
-		payload, e := json.Marshal(map[string][]model.Client{"clients": byInbound[ibId]})
-		if e == nil {
-			var nr bool
-			nr, e = s.AddInboundClient(inboundSvc, &model.Inbound{Id: ibId, Settings: string(payload)})
-			if e == nil && nr {
-				needRestart = true
-			}
-		}
-		if e != nil {
-			for _, idx := range idxByInbound[ibId] {
-				failed[idx] = true
-				if reason[idx] == "" {
-					reason[idx] = e.Error()
-				}
-			}
-		}
-	}
-
-	for idx := range prep {
-		if failed[idx] {
-			skip(prep[idx].client.Email, reason[idx])
-			continue
-		}
-		if err := s.setClientLimitHwidByEmail(nil, prep[idx].client.Email, prep[idx].limitHwid); err != nil {
-			skip(prep[idx].client.Email, err.Error())
-			continue
-		}
-		result.Created++
-	}
-	return result, needRestart, nil
+	return &bulkCreatePlan{prep: prep, byInbound: byInbound, idxByInbound: idxByInbound,
+		inboundOrder: inboundOrder, failed: failed, reason: reason}, nil
 }
 
 func (s *ClientService) DelDepleted(inboundSvc *InboundService) (int, bool, error) {
@@ -115,32 +115,43 @@ func (s *ClientService) GetClientsByTrafficReset(period string) ([]ClientResetCy
 	return cycles, nil
 }
 
-func (s *ClientService) Create(inboundSvc *InboundService, payload *ClientCreatePayload) (bool, error) {
+// prepareClientCreate validates a create payload and returns its client with
+// defaults filled in and any stored credentials of the same identity reused.
+func (s *ClientService) prepareClientCreate(payload *ClientCreatePayload) (model.Client, error) {
 	if payload == nil {
-		return false, common.NewError("empty payload")
+		return model.Client{}, common.NewError("empty payload")
 	}
 	client := payload.Client
 	if strings.TrimSpace(client.Email) == "" {
-		return false, common.NewError("client email is required")
+		return model.Client{}, common.NewError("client email is required")
 	}
 	if err := validateClientEmail(client.Email); err != nil {
-		return false, err
+		return model.Client{}, err
 	}
 	if err := validateClientSubID(client.SubID); err != nil {
-		return false, err
+		return model.Client{}, err
 	}
 	if err := validateClientResetDay(client.ResetDay); err != nil {
-		return false, err
+		return model.Client{}, err
 	}
 	if err := validateClientResetMax(client.ResetMax); err != nil {
-		return false, err
+		return model.Client{}, err
 	}
 	if err := validateClientTrafficReset(client.TrafficReset, client.TrafficResetDay); err != nil {
-		return false, err
+		return model.Client{}, err
 	}
 	normalizeClientTrafficReset(&client)
+	client.Egress = strings.TrimSpace(client.Egress)
+	sched, serr := normalizeClientSchedule(client.Schedule)
+	if serr != nil {
+		return model.Client{}, serr
+	}
+	client.Schedule = sched
 	if len(payload.InboundIds) == 0 {
-		return false, common.NewError("at least one inbound is required")
+		return model.Client{}, common.NewError("at least one inbound is required")
+	}
+	if err := clientScheduleOnNodes(nil, client.Email, client.Group, client.Schedule, payload.InboundIds); err != nil {
+		return model.Client{}, err
 	}
 
 	if client.SubID == "" {
@@ -158,12 +169,12 @@ func (s *ClientService) Create(inboundSvc *InboundService, payload *ClientCreate
 	existing := &model.ClientRecord{}
 	err := database.GetDB().Where("email = ?", client.Email).First(existing).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return false, err
+		return model.Client{}, err
 	}
 	emailTaken := !errors.Is(err, gorm.ErrRecordNotFound)
 	if emailTaken {
 		if existing.SubID == "" || existing.SubID != client.SubID {
-			return false, common.NewError("email already in use:", client.Email)
+			return model.Client{}, common.NewError("email already in use:", client.Email)
 		}
 		// Reuse stored credentials when re-adding an existing identity, or
 		// fillProtocolDefaults mints a fresh UUID that desyncs other inbounds.
@@ -186,12 +197,20 @@ func (s *ClientService) Create(inboundSvc *InboundService, payload *ClientCreate
 		if err := database.GetDB().Model(&model.ClientRecord{}).
 			Where("sub_id = ? AND email <> ?", client.SubID, client.Email).
 			Count(&subTaken).Error; err != nil {
-			return false, err
+			return model.Client{}, err
 		}
 		if subTaken > 0 {
-			return false, common.NewError("subId already in use:", client.SubID)
+			return model.Client{}, common.NewError("subId already in use:", client.SubID)
 		}
 	}
+	return client, nil
+}
+
+func (s *ClientService) Create(inboundSvc *InboundService, payload *ClientCreatePayload) (bool, error) {
+	client, err := s.prepareClientCreate(payload)
+	if err != nil {
+		return false, err
+	}
 
 	needRestart := false
 	for _, ibId := range payload.InboundIds {
@@ -206,10 +225,10 @@ func (s *ClientService) Create(inboundSvc *InboundService, payload *ClientCreate
 		if mErr != nil {
 			return needRestart, mErr
 		}
-		nr, addErr := s.AddInboundClient(inboundSvc, &model.Inbound{
+		nr, addErr := s.addInboundClient(inboundSvc, &model.Inbound{
 			Id:       ibId,
 			Settings: string(settingsPayload),
-		})
+		}, payload.Scope.guard(client.Email))
 		if addErr != nil {
 			return needRestart, addErr
 		}
@@ -220,7 +239,8 @@ func (s *ClientService) Create(inboundSvc *InboundService, payload *ClientCreate
 	if err := s.setClientLimitHwidByEmail(nil, client.Email, payload.LimitHwid); err != nil {
 		return needRestart, err
 	}
-	return needRestart, nil
+	routed, err := egressRouted(nil, *client.ToRecord())
+	return needRestart || routed, err
 }
 
 func (s *ClientService) fillProtocolDefaults(c *model.Client, ib *model.Inbound) error {
@@ -389,15 +409,18 @@ func applyShadowsocksClientMethod(clients []any, settings map[string]any) {
 	}
 }
 
-func (s *ClientService) Update(inboundSvc *InboundService, id int, updated model.Client, limitHwid int, inboundFilter ...int) (bool, error) {
+// prepareClientUpdate validates an edit of client id, merges the stored
+// credentials into updated and returns the record with the inbounds to touch.
+func (s *ClientService) prepareClientUpdate(id int, updated *model.Client, inboundFilter []int) (*model.ClientRecord, []int, error) {
 	existing, err := s.GetByID(id)
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
 	inboundIds, err := s.GetInboundIdsForRecord(id)
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
+	attachedIds := inboundIds
 	if len(inboundFilter) > 0 {
 		allow := make(map[int]struct{}, len(inboundFilter))
 		for _, fid := range inboundFilter {
@@ -413,24 +436,33 @@ func (s *ClientService) Update(inboundSvc *InboundService, id int, updated model
 	}
 
 	if strings.TrimSpace(updated.Email) == "" {
-		return false, common.NewError("client email is required")
+		return nil, nil, common.NewError("client email is required")
 	}
 	if err := validateClientEmail(updated.Email); err != nil {
-		return false, err
+		return nil, nil, err
 	}
 	if err := validateClientSubID(updated.SubID); err != nil {
-		return false, err
+		return nil, nil, err
 	}
 	if err := validateClientResetDay(updated.ResetDay); err != nil {
-		return false, err
+		return nil, nil, err
 	}
 	if err := validateClientResetMax(updated.ResetMax); err != nil {
-		return false, err
+		return nil, nil, err
 	}
 	if err := validateClientTrafficReset(updated.TrafficReset, updated.TrafficResetDay); err != nil {
-		return false, err
+		return nil, nil, err
+	}
+	normalizeClientTrafficReset(updated)
+	updated.Egress = strings.TrimSpace(updated.Egress)
+	sched, err := normalizeClientSchedule(updated.Schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	updated.Schedule = sched
+	if err := clientScheduleOnNodes(nil, updated.Email, updated.Group, updated.Schedule, attachedIds); err != nil {
+		return nil, nil, err
 	}
-	normalizeClientTrafficReset(&updated)
 	if updated.SubID == "" {
 		updated.SubID = existing.SubID
 	}
@@ -464,10 +496,10 @@ func (s *ClientService) Update(inboundSvc *InboundService, id int, updated model
 		if err := database.GetDB().Model(&model.ClientRecord{}).
 			Where("email = ? AND id <> ?", updated.Email, id).
 			Count(&collisionCount).Error; err != nil {
-			return false, err
+			return nil, nil, err
 		}
 		if collisionCount > 0 {
-			return false, common.NewError("Duplicate email:", updated.Email)
+			return nil, nil, common.NewError("Duplicate email:", updated.Email)
 		}
 	}
 
@@ -476,12 +508,29 @@ func (s *ClientService) Update(inboundSvc *InboundService, id int, updated model
 		if err := database.GetDB().Model(&model.ClientRecord{}).
 			Where("sub_id = ? AND id <> ?", updated.SubID, id).
 			Count(&subCollision).Error; err != nil {
-			return false, err
+			return nil, nil, err
 		}
 		if subCollision > 0 {
-			return false, common.NewError("Duplicate subId:", updated.SubID)
+			return nil, nil, common.NewError("Duplicate subId:", updated.SubID)
 		}
 	}
+	return existing, inboundIds, nil
+}
+
+// Update rewrites a client everywhere it is attached. A non-nil scope holds
+// the write to the reseller's caps inside each transaction that makes it.
+func (s *ClientService) Update(inboundSvc *InboundService, sc *ClientScope, id int, updated model.Client, limitHwid int, inboundFilter ...int) (bool, error) {
+	return s.update(inboundSvc, sc, id, updated, limitHwid, false, inboundFilter)
+}
+
+// update is Update that, on resetTraffic, also zeroes the client's usage in
+// the transactions that write it.
+func (s *ClientService) update(inboundSvc *InboundService, sc *ClientScope, id int, updated model.Client, limitHwid int, resetTraffic bool, inboundFilter []int) (bool, error) {
+	existing, inboundIds, err := s.prepareClientUpdate(id, &updated, inboundFilter)
+	if err != nil {
+		return false, err
+	}
+	guard := sc.guard(existing.Email, updated.Email)
 
 	needRestart := false
 	for _, ibId := range inboundIds {
@@ -507,10 +556,10 @@ func (s *ClientService) Update(inboundSvc *InboundService, id int, updated model
 		if mErr != nil {
 			return needRestart, mErr
 		}
-		nr, upErr := s.UpdateInboundClient(inboundSvc, &model.Inbound{
+		nr, upErr := s.updateInboundClient(inboundSvc, &model.Inbound{
 			Id:       ibId,
 			Settings: string(settingsPayload),
-		}, existing.Email)
+		}, existing.Email, guard, resetTraffic)
 		if upErr != nil {
 			return needRestart, upErr
 		}
@@ -532,32 +581,45 @@ func (s *ClientService) Update(inboundSvc *InboundService, id int, updated model
 	if len(inboundIds) == 0 {
 		merged := *existing
 		applyClientRecordMerge(&merged, updated.ToRecord())
-		if err := database.GetDB().Model(&model.ClientRecord{}).
-			Where("id = ?", id).
-			Updates(map[string]any{
-				"sub_id":            merged.SubID,
-				"uuid":              merged.UUID,
-				"password":          merged.Password,
-				"auth":              merged.Auth,
-				"secret":            merged.Secret,
-				"flow":              merged.Flow,
-				"security":          merged.Security,
-				"wg_private_key":    merged.PrivateKey,
-				"wg_public_key":     merged.PublicKey,
-				"wg_allowed_ips":    merged.AllowedIPs,
-				"wg_pre_shared_key": merged.PreSharedKey,
-				"wg_keep_alive":     merged.KeepAlive,
-				"limit_ip":          merged.LimitIP,
-				"total_gb":          merged.TotalGB,
-				"expiry_time":       merged.ExpiryTime,
-				"tg_id":             merged.TgID,
-				"comment":           merged.Comment,
-				"reset":             merged.Reset,
-				"reset_day":         merged.ResetDay,
-				"reset_max":         merged.ResetMax,
-				"traffic_reset":     merged.TrafficReset,
-				"traffic_reset_day": merged.TrafficResetDay,
-			}).Error; err != nil {
+		if err := runSerializedTx(func(tx *gorm.DB) error {
+			if err := guard.begin(tx); err != nil {
+				return err
+			}
+			if err := tx.Model(&model.ClientRecord{}).
+				Where("id = ?", id).
+				Updates(map[string]any{
+					"sub_id":            merged.SubID,
+					"uuid":              merged.UUID,
+					"password":          merged.Password,
+					"auth":              merged.Auth,
+					"secret":            merged.Secret,
+					"flow":              merged.Flow,
+					"security":          merged.Security,
+					"wg_private_key":    merged.PrivateKey,
+					"wg_public_key":     merged.PublicKey,
+					"wg_allowed_ips":    merged.AllowedIPs,
+					"wg_pre_shared_key": merged.PreSharedKey,
+					"wg_keep_alive":     merged.KeepAlive,
+					"limit_ip":          merged.LimitIP,
+					"total_gb":          merged.TotalGB,
+					"expiry_time":       merged.ExpiryTime,
+					"tg_id":             merged.TgID,
+					"comment":           merged.Comment,
+					"reset":             merged.Reset,
+					"reset_day":         merged.ResetDay,
+					"reset_max":         merged.ResetMax,
+					"traffic_reset":     merged.TrafficReset,
+					"traffic_reset_day": merged.TrafficResetDay,
+				}).Error; err != nil {
+				return err
+			}
+			if resetTraffic {
+				if err := resetClientUsage(tx, 0, existing.Email); err != nil {
+					return err
+				}
+			}
+			return guard.finish(tx)
+		}); err != nil {
 			return needRestart, err
 		}
 	}
@@ -586,6 +648,23 @@ func (s *ClientService) Update(inboundSvc *InboundService, id int, updated model
 		return needRestart, err
 	}
 
+	// The egress and schedule are applied the same way, so clearing them takes
+	// effect too.
+	if err := database.GetDB().Model(&model.ClientRecord{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]any{"egress": updated.Egress, "schedule": updated.Schedule}).Error; err != nil {
+		return needRestart, err
+	}
+	// The generated routing rules match clients by email and resolve group
+	// egresses, so a rename or regroup of a routed client changes them too.
+	if existing.Egress != updated.Egress || existing.Group != updated.Group || existing.Email != updated.Email {
+		routed, err := egressRouted(nil, *existing, *updated.ToRecord())
+		if err != nil {
+			return needRestart, err
+		}
+		needRestart = needRestart || routed
+	}
+
 	// Same shape as the group write above: SyncInbound keeps a stored ad-tag
 	// when the incoming settings carry none, so clearing the override must be
 	// applied here, where the editor always round-trips the field.
@@ -695,13 +774,17 @@ func (s *ClientService) Delete(inboundSvc *InboundService, id int, keepTraffic b
 			if err := tx.Where("email = ?", existing.Email).Delete(&model.NodeClientTraffic{}).Error; err != nil {
 				return err
 			}
+			if err := deleteClientTrafficHistory(tx, existing.Email); err != nil {
+				return err
+			}
 		}
 		return tx.Delete(&model.ClientRecord{}, id).Error
 	}); err != nil {
 		withdrawClientTombstones(existing.Email)
 		return needRestart, err
 	}
-	return needRestart, nil
+	routed, err := egressRouted(nil, *existing)
+	return needRestart || routed, err
 }
 
 func (s *ClientService) Attach(inboundSvc *InboundService, id int, inboundIds []int) (bool, error) {
@@ -717,6 +800,9 @@ func (s *ClientService) Attach(inboundSvc *InboundService, id int, inboundIds []
 	for _, x := range currentIds {
 		have[x] = struct{}{}
 	}
+	if err := clientScheduleOnNodes(nil, existing.Email, existing.Group, existing.Schedule, inboundIds); err != nil {
+		return false, err
+	}
 
 	clientWire := existing.ToClient()
 	flow, ffErr := s.EffectiveFlow(nil, id)
@@ -847,11 +933,14 @@ func (s *ClientService) DeleteByEmail(inboundSvc *InboundService, email string,
 		if err := db.Where("email = ?", email).Delete(&model.NodeClientTraffic{}).Error; err != nil {
 			return needRestart, err
 		}
+		if err := deleteClientTrafficHistory(db, email); err != nil {
+			return needRestart, err
+		}
 	}
 	return needRestart, nil
 }
 
-func (s *ClientService) UpdateByEmail(inboundSvc *InboundService, email string, updated model.Client, limitHwid int, inboundFilter ...int) (bool, error) {
+func (s *ClientService) UpdateByEmail(inboundSvc *InboundService, sc *ClientScope, email string, updated model.Client, limitHwid int, inboundFilter ...int) (bool, error) {
 	if email == "" {
 		return false, common.NewError("client email is required")
 	}
@@ -859,7 +948,7 @@ func (s *ClientService) UpdateByEmail(inboundSvc *InboundService, email string,
 	if err != nil {
 		return false, err
 	}
-	return s.Update(inboundSvc, rec.Id, updated, limitHwid, inboundFilter...)
+	return s.Update(inboundSvc, sc, rec.Id, updated, limitHwid, inboundFilter...)
 }
 
 func (s *ClientService) Detach(inboundSvc *InboundService, id int, inboundIds []int) (bool, error) {
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+
+	"gorm.io/gorm"
+)
+
+// ClientEgressRoute is one generated routing rule: the clients, by email, whose
+// traffic leaves through Outbound (an outbound or balancer tag).
+type ClientEgressRoute struct {
+	Outbound string
+	Emails   []string
+}
+
+// EgressRoutes resolves each client's egress, its own or its group's, into one
+// route per target, sorted so an unchanged panel yields identical routing.
+func (s *ClientService) EgressRoutes() ([]ClientEgressRoute, error) {
+	return egressRoutes(database.GetDB(), nil)
+}
+
+// egressRoutes is EgressRoutes with the pending client rows of ov laid over
+// the stored ones.
+func egressRoutes(tx *gorm.DB, ov *configOverlay) ([]ClientEgressRoute, error) {
+	var rows []model.ClientRecord
+	if err := tx.Model(&model.ClientRecord{}).
+		Select("email, egress, group_name").
+		Where("egress <> '' OR group_name <> ''").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	groups, err := groupColumnValues(tx, "egress")
+	if err != nil {
+		return nil, err
+	}
+	byOutbound := map[string][]string{}
+	for _, r := range ov.applyRecords(rows) {
+		tag := r.Egress
+		if tag == "" {
+			tag = groups[r.Group]
+		}
+		if tag == "" {
+			continue
+		}
+		byOutbound[tag] = append(byOutbound[tag], r.Email)
+	}
+	routes := make([]ClientEgressRoute, 0, len(byOutbound))
+	for tag, emails := range byOutbound {
+		sort.Strings(emails)
+		routes = append(routes, ClientEgressRoute{Outbound: tag, Emails: emails})
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Outbound < routes[j].Outbound })
+	return routes, nil
+}
+
+// SetGroupEgress pins the members of a group without an egress of their own to
+// outbound; an empty outbound releases them.
+func (s *ClientService) SetGroupEgress(name, outbound string) error {
+	return setGroupColumn(name, "egress", strings.TrimSpace(outbound))
+}
+
+// groupEgress returns the egress of group, "" for none.
+func groupEgress(tx *gorm.DB, group string) (string, error) {
+	if group == "" {
+		return "", nil
+	}
+	if tx == nil {
+		tx = database.GetDB()
+	}
+	var egress []string
+	if err := tx.Model(&model.ClientGroup{}).Where("name = ?", group).Pluck("egress", &egress).Error; err != nil {
+		return "", err
+	}
+	if len(egress) == 0 {
+		return "", nil
+	}
+	return egress[0], nil
+}
+
+// egressRouted reports whether any of the clients has an egress, its own or
+// its group's, so changing them changes the generated routing rules.
+func egressRouted(tx *gorm.DB, clients ...model.ClientRecord) (bool, error) {
+	var groups []string
+	for _, c := range clients {
+		if c.Egress != "" {
+			return true, nil
+		}
+		if c.Group != "" && !slices.Contains(groups, c.Group) {
+			groups = append(groups, c.Group)
+		}
+	}
+	if len(groups) == 0 {
+		return false, nil
+	}
+	if tx == nil {
+		tx = database.GetDB()
+	}
+	var count int64
+	for _, batch := range chunkStrings(groups, sqlInChunk) {
+		var n int64
+		if err := tx.Model(&model.ClientGroup{}).Where("name IN ? AND egress <> ''", batch).Count(&n).Error; err != nil {
+			return false, err
+		}
+		count += n
+	}
+	return count > 0, nil
+}
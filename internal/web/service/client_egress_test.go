@@ -0,0 +1,83 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+func TestClientEgressRoutes(t *testing.T) {
+	setupBulkDB(t)
+	svc := &ClientService{}
+	inboundSvc := &InboundService{}
+
+	source := []model.Client{
+		{Email: "alice", ID: "aaaaaaaa-0000-0000-0000-000000000031", SubID: "alice", Enable: true},
+		{Email: "bob", ID: "aaaaaaaa-0000-0000-0000-000000000032", SubID: "bob", Enable: true},
+		{Email: "carol", ID: "aaaaaaaa-0000-0000-0000-000000000033", SubID: "carol", Enable: true},
+	}
+	ib := mkInbound(t, 22031, model.VLESS, clientsSettings(t, source))
+	if err := svc.SyncInbound(nil, ib.Id, source); err != nil {
+		t.Fatalf("seed linkage: %v", err)
+	}
+
+	aliceRec := lookupClientRecord(t, "alice")
+	alice := aliceRec.ToClient()
+	if routed, err := egressRouted(nil, aliceRec); err != nil || routed {
+		t.Fatalf("unrouted client reported routed: %v, %v", routed, err)
+	}
+	alice.Egress = "warp"
+	if nr, err := svc.Update(inboundSvc, nil, aliceRec.Id, *alice, 0); err != nil || !nr {
+		t.Fatalf("egress update = %v, %v; want a restart", nr, err)
+	}
+
+	if _, err := svc.AddToGroup([]string{"bob", "carol"}, "premium"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetGroupEgress("premium", "nord"); err != nil {
+		t.Fatal(err)
+	}
+	if routed, err := egressRouted(nil, model.ClientRecord{Email: "x", Group: "premium"}); err != nil || !routed {
+		t.Fatalf("member of a routed group reported unrouted: %v, %v", routed, err)
+	}
+	// carol's own egress wins over the group's.
+	carolRec := lookupClientRecord(t, "carol")
+	carol := carolRec.ToClient()
+	carol.Egress = "warp"
+	if _, err := svc.Update(inboundSvc, nil, carolRec.Id, *carol, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	routes, err := svc.EgressRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ClientEgressRoute{
+		{Outbound: "nord", Emails: []string{"bob"}},
+		{Outbound: "warp", Emails: []string{"alice", "carol"}},
+	}
+	if !slices.EqualFunc(routes, want, func(a, b ClientEgressRoute) bool {
+		return a.Outbound == b.Outbound && slices.Equal(a.Emails, b.Emails)
+	}) {
+		t.Fatalf("routes = %+v, want %+v", routes, want)
+	}
+
+	// Renaming a client pinned through its group moves the generated rule.
+	bobRec := lookupClientRecord(t, "bob")
+	bob := bobRec.ToClient()
+	bob.Email = "robert"
+	if nr, err := svc.Update(inboundSvc, nil, bobRec.Id, *bob, 0); err != nil || !nr {
+		t.Fatalf("rename of routed client = %v, %v; want a restart", nr, err)
+	}
+	if err := svc.SetGroupEgress("nobody", "nord"); err == nil {
+		t.Fatal("egress set on a group that does not exist")
+	}
+	routes, err = svc.EgressRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || !slices.Equal(routes[0].Emails, []string{"robert"}) {
+		t.Fatalf("routes after rename = %+v", routes)
+	}
+}
@@ -18,6 +18,8 @@ type GroupSummary struct {
 	TrafficUsed int64  `json:"trafficUsed"`
 	Up          int64  `json:"up"`
 	Down        int64  `json:"down"`
+	Egress      string `json:"egress,omitempty"`
+	Schedule    string `json:"schedule,omitempty"`
 }
 
 func (s *ClientService) ListGroups() ([]GroupSummary, error) {
@@ -44,11 +46,15 @@ func (s *ClientService) ListGroups() ([]GroupSummary, error) {
 	}
 	baseUp := make(map[string]int64, len(stored))
 	baseDown := make(map[string]int64, len(stored))
+	egress := make(map[string]string, len(stored))
+	schedules := make(map[string]string, len(stored))
 	merged := make(map[string]groupAgg, len(derived)+len(stored))
 	for _, g := range stored {
 		merged[g.Name] = groupAgg{}
 		baseUp[g.Name] = g.ResetUp
 		baseDown[g.Name] = g.ResetDown
+		egress[g.Name] = g.Egress
+		schedules[g.Name] = g.Schedule
 	}
 	for _, g := range derived {
 		merged[g.Name] = groupAgg{count: g.ClientCount, up: g.Up, down: g.Down}
@@ -57,7 +63,7 @@ func (s *ClientService) ListGroups() ([]GroupSummary, error) {
 	for name, agg := range merged {
 		up := max(agg.up-baseUp[name], 0)
 		down := max(agg.down-baseDown[name], 0)
-		out = append(out, GroupSummary{Name: name, ClientCount: agg.count, TrafficUsed: up + down, Up: up, Down: down})
+		out = append(out, GroupSummary{Name: name, ClientCount: agg.count, TrafficUsed: up + down, Up: up, Down: down, Egress: egress[name], Schedule: schedules[name]})
 	}
 	sort.Slice(out, func(i, j int) bool {
 		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
@@ -231,6 +237,26 @@ func (s *ClientService) AddToGroup(emails []string, group string) (int, error) {
 		}
 	}
 
+	if group != "" {
+		var sched []string
+		if err := db.Model(&model.ClientGroup{}).Where("name = ?", group).Pluck("schedule", &sched).Error; err != nil {
+			return 0, err
+		}
+		if len(sched) > 0 && sched[0] != "" {
+			var hosted []string
+			for _, batch := range chunkStrings(emails, sqlInChunk) {
+				found, err := nodeHostedClients(db, "clients.email IN ? AND clients.schedule = ''", batch)
+				if err != nil {
+					return 0, err
+				}
+				hosted = append(hosted, found...)
+			}
+			if err := groupScheduleOnNodes(group, hosted); err != nil {
+				return 0, err
+			}
+		}
+	}
+
 	var records []model.ClientRecord
 	for _, batch := range chunkStrings(emails, sqlInChunk) {
 		var rows []model.ClientRecord
@@ -440,3 +466,48 @@ func (s *ClientService) replaceGroupValue(oldName, newName string) (int, error)
 	}
 	return len(records), nil
 }
+
+// setGroupColumn stores a group-wide setting, creating the row of a group only
+// its clients carry so far.
+func setGroupColumn(name, column, value string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return common.NewError("group name is required")
+	}
+	db := database.GetDB()
+	res := db.Model(&model.ClientGroup{}).Where("name = ?", name).Update(column, value)
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	var members int64
+	if err := db.Model(&model.ClientRecord{}).Where("group_name = ?", name).Count(&members).Error; err != nil {
+		return err
+	}
+	if members == 0 {
+		return common.NewError("group not found")
+	}
+	group := &model.ClientGroup{Name: name}
+	if err := db.Create(group).Error; err != nil {
+		return err
+	}
+	return db.Model(group).Update(column, value).Error
+}
+
+// groupColumnValues maps every group whose column is set to its value.
+func groupColumnValues(tx *gorm.DB, column string) (map[string]string, error) {
+	var rows []struct {
+		Name  string
+		Value string
+	}
+	if err := tx.Model(&model.ClientGroup{}).
+		Select("name, " + column + " AS value").
+		Where(column + " <> ''").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(rows))
+	for _, r := range rows {
+		out[r.Name] = r.Value
+	}
+	return out, nil
+}
@@ -32,13 +32,13 @@ func sameClientConfigExceptUpdatedAt(a, b map[string]any) bool {
 // advancePushedInbound advances the node's reconcile-skip fingerprint from the
 // pre-edit settings to the saved ones after every per-client push succeeded.
 func advancePushedInbound(rt runtime.Runtime, prevSettings string, ib *model.Inbound) {
-	rem, ok := rt.(*runtime.Remote)
+	adv, ok := rt.(pushedInboundAdvancer)
 	if !ok {
 		return
 	}
 	prev := *ib
 	prev.Settings = prevSettings
-	rem.AdvancePushedInbound(&prev, ib)
+	adv.AdvancePushedInbound(&prev, ib)
 }
 
 // delInboundClients removes several clients from a single inbound in one pass:
@@ -269,6 +269,12 @@ func (s *ClientService) checkEmailsExistForClients(inboundSvc *InboundService, c
 }
 
 func (s *ClientService) AddInboundClient(inboundSvc *InboundService, data *model.Inbound) (bool, error) {
+	return s.addInboundClient(inboundSvc, data, nil)
+}
+
+// addInboundClient is AddInboundClient with guard checked in the transaction
+// that writes the clients.
+func (s *ClientService) addInboundClient(inboundSvc *InboundService, data *model.Inbound, guard *scopeGuard) (bool, error) {
 	defer lockInbound(data.Id).Unlock()
 
 	clients, err := inboundSvc.GetClients(data)
@@ -430,6 +436,9 @@ func (s *ClientService) AddInboundClient(inboundSvc *InboundService, data *model
 	// Persist client stats + inbound atomically, serialized against the traffic
 	// poll to avoid the cross-transaction lock-order deadlock (runSerializedTx).
 	if txErr := runSerializedTx(func(tx *gorm.DB) error {
+		if e := guard.begin(tx); e != nil {
+			return e
+		}
 		for i := range clients {
 			if len(clients[i].Email) == 0 {
 				continue
@@ -444,6 +453,9 @@ func (s *ClientService) AddInboundClient(inboundSvc *InboundService, data *model
 		if err := s.ApplyInboundClientDelta(tx, oldInbound.Id, addedClients, nil); err != nil {
 			return err
 		}
+		if err := guard.finish(tx); err != nil {
+			return err
+		}
 		if oldInbound.NodeID != nil {
 			return (&NodeService{}).MarkNodeDirtyTx(tx, *oldInbound.NodeID)
 		}
@@ -517,6 +529,12 @@ func (s *ClientService) AddInboundClient(inboundSvc *InboundService, data *model
 }
 
 func (s *ClientService) UpdateInboundClient(inboundSvc *InboundService, data *model.Inbound, oldEmail string) (bool, error) {
+	return s.updateInboundClient(inboundSvc, data, oldEmail, nil, false)
+}
+
+// updateInboundClient is UpdateInboundClient with guard checked in the
+// transaction that writes the client, which also resets its usage on resetTraffic.
+func (s *ClientService) updateInboundClient(inboundSvc *InboundService, data *model.Inbound, oldEmail string, guard *scopeGuard, resetTraffic bool) (bool, error) {
 	defer lockInbound(data.Id).Unlock()
 
 	clients, err := inboundSvc.GetClients(data)
@@ -754,6 +772,9 @@ func (s *ClientService) UpdateInboundClient(inboundSvc *InboundService, data *mo
 	// Persist client stats + inbound atomically, serialized against the traffic
 	// poll to avoid the cross-transaction lock-order deadlock (runSerializedTx).
 	if txErr := runSerializedTx(func(tx *gorm.DB) error {
+		if e := guard.begin(tx); e != nil {
+			return e
+		}
 		if len(clients[0].Email) > 0 {
 			if len(oldEmail) > 0 {
 				emailUnchanged := strings.EqualFold(oldEmail, clients[0].Email)
@@ -770,6 +791,9 @@ func (s *ClientService) UpdateInboundClient(inboundSvc *InboundService, data *mo
 					if e := inboundSvc.UpdateClientIPs(tx, oldEmail, clients[0].Email); e != nil {
 						return e
 					}
+					if e := renameClientTrafficHistory(tx, oldEmail, clients[0].Email); e != nil {
+						return e
+					}
 				} else {
 					stillUsed, sErr := inboundSvc.emailUsedByOtherInbounds(oldEmail, data.Id)
 					if sErr != nil {
@@ -830,6 +854,14 @@ func (s *ClientService) UpdateInboundClient(inboundSvc *InboundService, data *mo
 		if err := s.ApplyInboundClientDelta(tx, oldInbound.Id, changedClients, detachEmails); err != nil {
 			return err
 		}
+		if resetTraffic && len(clients[0].Email) > 0 {
+			if err := resetClientUsage(tx, oldInbound.Id, clients[0].Email); err != nil {
+				return err
+			}
+		}
+		if err := guard.finish(tx); err != nil {
+			return err
+		}
 		if oldInbound.NodeID != nil {
 			return (&NodeService{}).MarkNodeDirtyTx(tx, *oldInbound.NodeID)
 		}
@@ -837,6 +869,9 @@ func (s *ClientService) UpdateInboundClient(inboundSvc *InboundService, data *mo
 	}); txErr != nil {
 		return false, txErr
 	}
+	if resetTraffic && len(clients[0].Email) > 0 {
+		inboundSvc.propagateClientReset(oldInbound, clients[0].Email)
+	}
 
 	// Apply to the running runtime after the DB is committed — outside the
 	// serialized writer so a slow node call can't stall traffic accounting.
@@ -197,6 +197,51 @@ func dedupeSettingsClients(settings string) (string, bool) {
 	return string(b), true
 }
 
+// restoreSettingsQuota copies each client's totalGB from the central settings
+// onto a node snapshot's, whose weighted inbounds carry no quota. Returns the
+// patched JSON and whether anything changed.
+func restoreSettingsQuota(settings, central string) (string, bool) {
+	if settings == "" || central == "" {
+		return settings, false
+	}
+	var parsed, own map[string]any
+	if json.Unmarshal([]byte(settings), &parsed) != nil || json.Unmarshal([]byte(central), &own) != nil {
+		return settings, false
+	}
+	ownClients, _ := own["clients"].([]any)
+	quota := make(map[string]any, len(ownClients))
+	for _, c := range ownClients {
+		if cm, ok := c.(map[string]any); ok {
+			if email, _ := cm["email"].(string); email != "" {
+				quota[strings.ToLower(email)] = cm["totalGB"]
+			}
+		}
+	}
+	clients, _ := parsed["clients"].([]any)
+	changed := false
+	for _, c := range clients {
+		cm, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		email, _ := cm["email"].(string)
+		total, known := quota[strings.ToLower(email)]
+		if !known || total == nil || cm["totalGB"] == total {
+			continue
+		}
+		cm["totalGB"] = total
+		changed = true
+	}
+	if !changed {
+		return settings, false
+	}
+	b, err := json.MarshalIndent(parsed, "", "  ")
+	if err != nil {
+		return settings, false
+	}
+	return string(b), true
+}
+
 // stripTombstonedClients drops just-deleted client entries from a node
 // snapshot's settings JSON so adopting a stale snapshot can't re-add them to
 // the central inbound while the delete tombstone is live. Returns the filtered
@@ -133,10 +133,11 @@ func (s *ClientService) GetInboundIdsForRecord(id int) ([]int, error) {
 	return ids, nil
 }
 
-func (s *ClientService) List() ([]ClientWithAttachments, error) {
+// List returns every client, or only a reseller's when ownerId is non-zero.
+func (s *ClientService) List(ownerId int) ([]ClientWithAttachments, error) {
 	db := database.GetDB()
 	var rows []model.ClientRecord
-	if err := db.Order("id ASC").Find(&rows).Error; err != nil {
+	if err := scopeClientOwner(db, ownerId).Order("id ASC").Find(&rows).Error; err != nil {
 		return nil, err
 	}
 	if len(rows) == 0 {
@@ -61,6 +61,9 @@ type ClientPageParams struct {
 	HasTgID    string `form:"hasTgId"`
 	HasComment string `form:"hasComment"`
 	Group      string `form:"group"`
+
+	// OwnerId is set by the controller for reseller accounts, never from the query.
+	OwnerId int `form:"-"`
 }
 
 // ClientPageResponse is the shape returned by ListPaged. `Total` is the
@@ -130,6 +133,7 @@ type clientQuery struct {
 	db               *gorm.DB
 	joins            []clientQueryJoin
 	usedExpr         string
+	ownerId          int
 	nowMs            int64
 	expireDiffMs     int64
 	trafficDiffBytes int64
@@ -174,6 +178,9 @@ func (q clientQuery) from() *gorm.DB {
 	for _, j := range q.joins {
 		tx = tx.Joins(j.sql, j.args...)
 	}
+	if q.ownerId > 0 {
+		tx = tx.Where("c.owner_id = ?", q.ownerId)
+	}
 	return tx
 }
 
@@ -361,9 +368,10 @@ func (s *ClientService) ListPaged(inboundSvc *InboundService, settingSvc *Settin
 
 	onlines := inboundSvc.GetOnlineClients()
 	q := newClientQuery(db, time.Now().UnixMilli(), expireDiffMs, trafficDiffBytes)
+	q.ownerId = params.OwnerId
 
 	var total int64
-	if err := db.Model(&model.ClientRecord{}).Count(&total).Error; err != nil {
+	if err := scopeClientOwner(db.Model(&model.ClientRecord{}), params.OwnerId).Count(&total).Error; err != nil {
 		return nil, err
 	}
 
@@ -388,7 +396,7 @@ func (s *ClientService) ListPaged(inboundSvc *InboundService, settingSvc *Settin
 		}
 	}
 
-	groups, err := s.listGroupNames()
+	groups, err := s.listGroupNames(params.OwnerId)
 	if err != nil {
 		return nil, err
 	}
@@ -542,7 +550,7 @@ func (q clientQuery) onlineEmails(onlines []string) ([]string, int, error) {
 	count := 0
 	for _, batch := range chunkStrings(onlines, sqlInChunk) {
 		var page []string
-		if err := q.db.Model(&model.ClientRecord{}).
+		if err := scopeClientOwner(q.db.Model(&model.ClientRecord{}), q.ownerId).
 			Where("COALESCE(enable, FALSE) = TRUE AND email IN ?", batch).
 			Order("id ASC").
 			Pluck("email", &page).Error; err != nil {
@@ -560,14 +568,16 @@ func (q clientQuery) onlineEmails(onlines []string) ([]string, int, error) {
 // the stored groups plus any name a client still carries. ListGroups also sums
 // per-client traffic per group, which this page never reads and which costs a
 // full join over client_traffics on every poll.
-func (s *ClientService) listGroupNames() ([]string, error) {
+func (s *ClientService) listGroupNames(ownerId int) ([]string, error) {
 	db := database.GetDB()
 	var stored []string
-	if err := db.Model(&model.ClientGroup{}).Pluck("name", &stored).Error; err != nil {
-		return nil, err
+	if ownerId <= 0 {
+		if err := db.Model(&model.ClientGroup{}).Pluck("name", &stored).Error; err != nil {
+			return nil, err
+		}
 	}
 	var used []string
-	if err := db.Model(&model.ClientRecord{}).
+	if err := scopeClientOwner(db.Model(&model.ClientRecord{}), ownerId).
 		Where("group_name <> ''").
 		Distinct().
 		Pluck("group_name", &used).Error; err != nil {
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+)
+
+// PlanRenewResult reports which clients a plan renewal touched.
+type PlanRenewResult struct {
+	Renewed int                `json:"renewed"`
+	Skipped []BulkAdjustReport `json:"skipped,omitempty"`
+}
+
+func (s *ClientService) ListPlans() ([]*model.Plan, error) {
+	var plans []*model.Plan
+	if err := database.GetDB().Order("name asc").Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+func (s *ClientService) GetPlan(id int) (*model.Plan, error) {
+	plan := &model.Plan{}
+	if err := database.GetDB().First(plan, id).Error; err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *ClientService) AddPlan(req *entity.PlanRequest) (*model.Plan, error) {
+	plan := &model.Plan{}
+	if err := applyPlanRequest(plan, req); err != nil {
+		return nil, err
+	}
+	if err := checkPlanNameFree(plan.Name, 0); err != nil {
+		return nil, err
+	}
+	if err := database.GetDB().Create(plan).Error; err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *ClientService) UpdatePlan(id int, req *entity.PlanRequest) (*model.Plan, error) {
+	plan, err := s.GetPlan(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPlanRequest(plan, req); err != nil {
+		return nil, err
+	}
+	if err := checkPlanNameFree(plan.Name, id); err != nil {
+		return nil, err
+	}
+	if err := database.GetDB().Save(plan).Error; err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// DeletePlan removes a plan. Clients made from it keep their limits; a plan
+// that vouchers still redeem against cannot be removed.
+func (s *ClientService) DeletePlan(id int) error {
+	var vouchers int64
+	if err := database.GetDB().Model(&model.Voucher{}).
+		Where("plan_id = ?", id).Count(&vouchers).Error; err != nil {
+		return err
+	}
+	if vouchers > 0 {
+		return common.NewError("plan is used by vouchers:", vouchers)
+	}
+	res := database.GetDB().Delete(&model.Plan{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return common.NewError("plan not found")
+	}
+	return nil
+}
+
+func checkPlanNameFree(name string, id int) error {
+	var count int64
+	if err := database.GetDB().Model(&model.Plan{}).
+		Where("name = ? AND id <> ?", name, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return common.NewError("plan already exists:", name)
+	}
+	return nil
+}
+
+func applyPlanRequest(plan *model.Plan, req *entity.PlanRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return common.NewError("plan name is required")
+	}
+	if err := validateClientTrafficReset(req.TrafficReset, req.TrafficResetDay); err != nil {
+		return err
+	}
+	renewMode := req.RenewMode
+	switch renewMode {
+	case "":
+		renewMode = model.PlanRenewExtend
+	case model.PlanRenewExtend, model.PlanRenewAddQuota, model.PlanRenewReset:
+	default:
+		return common.NewError("plan renewMode must be extend, addQuota or reset, got:", renewMode)
+	}
+
+	inboundIds := slices.Clone(req.InboundIds)
+	slices.Sort(inboundIds)
+	inboundIds = slices.Compact(inboundIds)
+	if len(inboundIds) > 0 {
+		var found int64
+		if err := database.GetDB().Model(&model.Inbound{}).
+			Where("id IN ?", inboundIds).Count(&found).Error; err != nil {
+			return err
+		}
+		if int(found) != len(inboundIds) {
+			return common.NewError("plan references an inbound that does not exist")
+		}
+	}
+
+	plan.Name = name
+	plan.Comment = req.Comment
+	plan.TotalGB = req.TotalGB
+	plan.DurationDays = req.DurationDays
+	plan.StartAfterFirstUse = req.StartAfterFirstUse
+	plan.LimitIP = req.LimitIP
+	plan.LimitHwid = req.LimitHwid
+	plan.TrafficReset = req.TrafficReset
+	plan.TrafficResetDay = req.TrafficResetDay
+	plan.Group = strings.TrimSpace(req.Group)
+	plan.InboundIds = inboundIds
+	plan.RenewMode = renewMode
+	return nil
+}
+
+// planExpiry is the expiry a fresh period of the plan starts with: absolute
+// from nowMs, or negative (counted from first use) for delayed-start plans.
+func planExpiry(plan *model.Plan, nowMs int64) int64 {
+	if plan.DurationDays <= 0 {
+		return 0
+	}
+	d := int64(plan.DurationDays) * msPerDay
+	if plan.StartAfterFirstUse {
+		return -d
+	}
+	return nowMs + d
+}
+
+// ApplyPlan overwrites the limits of c with those of plan. Identity fields
+// such as email, UUID and subId are left alone.
+func ApplyPlan(c *model.Client, plan *model.Plan, nowMs int64) {
+	c.TotalGB = plan.TotalGB
+	c.ExpiryTime = planExpiry(plan, nowMs)
+	c.LimitIP = plan.LimitIP
+	c.TrafficReset = plan.TrafficReset
+	c.TrafficResetDay = plan.TrafficResetDay
+	c.Group = plan.Group
+}
+
+// PlanPayloads builds create payloads for clients provisioned from a plan.
+// Provisioned clients always start enabled.
+func (s *ClientService) PlanPayloads(planId int, clients []model.Client) ([]ClientCreatePayload, error) {
+	plan, err := s.GetPlan(planId)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.InboundIds) == 0 {
+		return nil, common.NewError("plan has no inbounds")
+	}
+	nowMs := time.Now().UnixMilli()
+	payloads := make([]ClientCreatePayload, 0, len(clients))
+	for _, c := range clients {
+		ApplyPlan(&c, plan, nowMs)
+		c.Enable = true
+		payloads = append(payloads, ClientCreatePayload{
+			Client:     c,
+			InboundIds: slices.Clone(plan.InboundIds),
+			LimitHwid:  plan.LimitHwid,
+		})
+	}
+	return payloads, nil
+}
+
+// CreateFromPlan provisions clients with the limits and inbounds of a plan.
+// A non-nil scope holds them to the reseller's caps first.
+func (s *ClientService) CreateFromPlan(inboundSvc *InboundService, sc *ClientScope, planId int, clients []model.Client) (BulkCreateResult, bool, error) {
+	payloads, err := s.PlanPayloads(planId, clients)
+	if err != nil {
+		return BulkCreateResult{}, false, err
+	}
+	if err := s.CheckCreate(sc, payloads); err != nil {
+		return BulkCreateResult{}, false, err
+	}
+	for i := range payloads {
+		payloads[i].Scope = sc
+	}
+	return s.BulkCreate(inboundSvc, payloads)
+}
+
+// renewedClient applies a plan's renewal rule to rec. It reports false with a
+// reason when the rule has nothing to change for this client.
+func renewedClient(plan *model.Plan, rec *model.ClientRecord, nowMs int64) (*model.Client, bool, string) {
+	c := rec.ToClient()
+	c.Enable = true
+	switch plan.RenewMode {
+	case model.PlanRenewAddQuota:
+		if rec.TotalGB == 0 {
+			return nil, false, "unlimited traffic"
+		}
+		if plan.TotalGB == 0 {
+			return nil, false, "plan has no traffic quota"
+		}
+		c.TotalGB = rec.TotalGB + plan.TotalGB
+	case model.PlanRenewReset:
+		ApplyPlan(c, plan, nowMs)
+	default:
+		if plan.DurationDays <= 0 {
+			return nil, false, "plan has no duration"
+		}
+		d := int64(plan.DurationDays) * msPerDay
+		switch {
+		case rec.ExpiryTime == 0:
+			return nil, false, "unlimited expiry"
+		case rec.ExpiryTime < 0:
+			// Not started yet: lengthen the delayed-start window instead.
+			c.ExpiryTime = rec.ExpiryTime - d
+		default:
+			c.ExpiryTime = max(rec.ExpiryTime, nowMs) + d
+		}
+	}
+	return c, true, ""
+}
+
+// RenewWithPlan re-enables every renewed client; extend counts from now or the
+// current expiry if later. A non-nil scope skips clients outside it.
+func (s *ClientService) RenewWithPlan(inboundSvc *InboundService, sc *ClientScope, planId int, emails []string) (PlanRenewResult, bool, error) {
+	result := PlanRenewResult{}
+	plan, err := s.GetPlan(planId)
+	if err != nil {
+		return result, false, err
+	}
+	skip := func(email, reason string) {
+		result.Skipped = append(result.Skipped, BulkAdjustReport{Email: email, Reason: reason})
+	}
+
+	nowMs := time.Now().UnixMilli()
+	needRestart := false
+	for _, email := range cleanEmailList(emails) {
+		rec, err := s.GetRecordByEmail(nil, email)
+		if err != nil {
+			skip(email, "client not found")
+			continue
+		}
+		updated, ok, reason := renewedClient(plan, rec, nowMs)
+		if !ok {
+			skip(email, reason)
+			continue
+		}
+		if err := s.CheckUpdate(sc, email, *updated); err != nil {
+			skip(email, err.Error())
+			continue
+		}
+		limitHwid := rec.LimitHwid
+		if plan.RenewMode == model.PlanRenewReset {
+			limitHwid = plan.LimitHwid
+		}
+		// A reset renewal zeroes usage in the same transactions as the new quota.
+		nr, err := s.update(inboundSvc, sc, rec.Id, *updated, limitHwid, plan.RenewMode == model.PlanRenewReset, nil)
+		if nr {
+			needRestart = true
+		}
+		if err != nil {
+			skip(email, err.Error())
+			continue
+		}
+		result.Renewed++
+	}
+	return result, needRestart, nil
+}
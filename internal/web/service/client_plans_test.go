@@ -0,0 +1,182 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+)
+
+func TestCreateFromPlan(t *testing.T) {
+	setupBulkDB(t)
+	svc := &ClientService{}
+	inboundSvc := &InboundService{}
+
+	ib := mkInbound(t, 21001, model.VLESS, `{"clients":[]}`)
+	plan, err := svc.AddPlan(&entity.PlanRequest{
+		Name:         "monthly",
+		TotalGB:      10 * bytesPerGB,
+		DurationDays: 30,
+		LimitIP:      2,
+		LimitHwid:    3,
+		Group:        "customers",
+		InboundIds:   []int{ib.Id, ib.Id},
+	})
+	if err != nil {
+		t.Fatalf("AddPlan: %v", err)
+	}
+	if plan.RenewMode != model.PlanRenewExtend || len(plan.InboundIds) != 1 {
+		t.Fatalf("plan defaults not applied: %+v", plan)
+	}
+	if _, err := svc.AddPlan(&entity.PlanRequest{Name: "monthly"}); err == nil {
+		t.Fatalf("duplicate plan name was accepted")
+	}
+
+	before := time.Now().UnixMilli()
+	res, _, err := svc.CreateFromPlan(inboundSvc, nil, plan.Id, []model.Client{{Email: "alice@plan"}})
+	if err != nil {
+		t.Fatalf("CreateFromPlan: %v", err)
+	}
+	if res.Created != 1 {
+		t.Fatalf("expected 1 created, got %+v", res)
+	}
+	rec, err := svc.GetRecordByEmail(nil, "alice@plan")
+	if err != nil {
+		t.Fatalf("GetRecordByEmail: %v", err)
+	}
+	if !rec.Enable || rec.TotalGB != plan.TotalGB || rec.LimitIP != 2 || rec.LimitHwid != 3 || rec.Group != "customers" {
+		t.Fatalf("plan limits not applied: %+v", rec)
+	}
+	if want := before + 30*msPerDay; rec.ExpiryTime < want {
+		t.Fatalf("expiry %d earlier than %d", rec.ExpiryTime, want)
+	}
+	list, err := svc.ListForInbound(nil, ib.Id)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("client not attached to plan inbound: %v %v", list, err)
+	}
+}
+
+func TestRenewWithPlan(t *testing.T) {
+	setupBulkDB(t)
+	svc := &ClientService{}
+	inboundSvc := &InboundService{}
+
+	nowMs := time.Now().UnixMilli()
+	clients := []model.Client{
+		{Email: "past@plan", ID: "11111111-1111-1111-1111-111111111111", SubID: "sp", TotalGB: bytesPerGB, ExpiryTime: nowMs - msPerDay},
+		{Email: "future@plan", ID: "22222222-2222-2222-2222-222222222222", SubID: "sf", Enable: true, TotalGB: bytesPerGB, ExpiryTime: nowMs + 10*msPerDay},
+		{Email: "forever@plan", ID: "33333333-3333-3333-3333-333333333333", SubID: "sv", Enable: true},
+	}
+	ib := mkInbound(t, 21002, model.VLESS, clientsSettings(t, clients))
+	if err := svc.SyncInbound(nil, ib.Id, clients); err != nil {
+		t.Fatalf("seed linkage: %v", err)
+	}
+	for _, c := range clients {
+		mkTraffic(t, ib.Id, c.Email, 10, 20, c.TotalGB, c.ExpiryTime, c.Enable)
+	}
+	emails := []string{"past@plan", "future@plan", "forever@plan"}
+
+	extend, err := svc.AddPlan(&entity.PlanRequest{Name: "extend", TotalGB: 5 * bytesPerGB, DurationDays: 30})
+	if err != nil {
+		t.Fatalf("AddPlan: %v", err)
+	}
+	res, _, err := svc.RenewWithPlan(inboundSvc, nil, extend.Id, emails)
+	if err != nil {
+		t.Fatalf("RenewWithPlan(extend): %v", err)
+	}
+	if res.Renewed != 2 || len(res.Skipped) != 1 || res.Skipped[0].Email != "forever@plan" {
+		t.Fatalf("extend: unexpected result %+v", res)
+	}
+	past, _ := svc.GetRecordByEmail(nil, "past@plan")
+	if !past.Enable || past.ExpiryTime < nowMs+30*msPerDay {
+		t.Fatalf("expired client should restart from now and be re-enabled: %+v", past)
+	}
+	future, _ := svc.GetRecordByEmail(nil, "future@plan")
+	if future.ExpiryTime != nowMs+40*msPerDay {
+		t.Fatalf("active client should extend from its expiry, got %d", future.ExpiryTime)
+	}
+
+	addQuota, err := svc.AddPlan(&entity.PlanRequest{Name: "topup", TotalGB: 5 * bytesPerGB, RenewMode: model.PlanRenewAddQuota})
+	if err != nil {
+		t.Fatalf("AddPlan: %v", err)
+	}
+	res, _, err = svc.RenewWithPlan(inboundSvc, nil, addQuota.Id, emails)
+	if err != nil {
+		t.Fatalf("RenewWithPlan(addQuota): %v", err)
+	}
+	if res.Renewed != 2 || len(res.Skipped) != 1 {
+		t.Fatalf("addQuota: unexpected result %+v", res)
+	}
+	future, _ = svc.GetRecordByEmail(nil, "future@plan")
+	if future.TotalGB != 6*bytesPerGB {
+		t.Fatalf("addQuota: expected 6GB, got %d", future.TotalGB)
+	}
+
+	reset, err := svc.AddPlan(&entity.PlanRequest{Name: "reset", TotalGB: 2 * bytesPerGB, RenewMode: model.PlanRenewReset, LimitIP: 1})
+	if err != nil {
+		t.Fatalf("AddPlan: %v", err)
+	}
+	res, _, err = svc.RenewWithPlan(inboundSvc, nil, reset.Id, emails)
+	if err != nil {
+		t.Fatalf("RenewWithPlan(reset): %v", err)
+	}
+	if res.Renewed != 3 {
+		t.Fatalf("reset: unexpected result %+v", res)
+	}
+	forever, _ := svc.GetRecordByEmail(nil, "forever@plan")
+	if forever.TotalGB != 2*bytesPerGB || forever.ExpiryTime != 0 || forever.LimitIP != 1 {
+		t.Fatalf("reset: plan limits not applied: %+v", forever)
+	}
+	if tr := trafficOf(t, "forever@plan"); tr.Up != 0 || tr.Down != 0 {
+		t.Fatalf("reset: expected zeroed usage, got up=%d down=%d", tr.Up, tr.Down)
+	}
+}
+
+// A reset renewal writes the new quota and the zeroed usage together, so a
+// failed reset leaves the old plan and usage in place.
+func TestRenewWithPlanResetIsOneWrite(t *testing.T) {
+	setupBulkDB(t)
+	svc := &ClientService{}
+	inboundSvc := &InboundService{}
+
+	nowMs := time.Now().UnixMilli()
+	client := model.Client{Email: "atomic@plan", ID: "44444444-4444-4444-4444-444444444444", SubID: "sa", Enable: true, TotalGB: bytesPerGB, ExpiryTime: nowMs + msPerDay}
+	ib := mkInbound(t, 21003, model.VLESS, clientsSettings(t, []model.Client{client}))
+	if err := svc.SyncInbound(nil, ib.Id, []model.Client{client}); err != nil {
+		t.Fatalf("seed linkage: %v", err)
+	}
+	mkTraffic(t, ib.Id, client.Email, 10, 20, client.TotalGB, client.ExpiryTime, true)
+	reset, err := svc.AddPlan(&entity.PlanRequest{Name: "reset", TotalGB: 2 * bytesPerGB, RenewMode: model.PlanRenewReset})
+	if err != nil {
+		t.Fatalf("AddPlan: %v", err)
+	}
+
+	if err := database.GetDB().Migrator().DropTable(&model.NodeClientTraffic{}); err != nil {
+		t.Fatalf("drop node baselines: %v", err)
+	}
+	res, _, err := svc.RenewWithPlan(inboundSvc, nil, reset.Id, []string{client.Email})
+	if err != nil {
+		t.Fatalf("RenewWithPlan: %v", err)
+	}
+	if res.Renewed != 0 || len(res.Skipped) != 1 {
+		t.Fatalf("renewal with a failing reset reported %+v", res)
+	}
+	if rec, _ := svc.GetRecordByEmail(nil, client.Email); rec.TotalGB != bytesPerGB {
+		t.Fatalf("quota = %d after the failed reset, want the old %d", rec.TotalGB, bytesPerGB)
+	}
+	if tr := trafficOf(t, client.Email); tr.Up != 10 || tr.Down != 20 || tr.Total != bytesPerGB {
+		t.Fatalf("traffic = %+v after the failed reset, want it untouched", tr)
+	}
+
+	if err := database.GetDB().AutoMigrate(&model.NodeClientTraffic{}); err != nil {
+		t.Fatalf("restore node baselines: %v", err)
+	}
+	if res, _, err = svc.RenewWithPlan(inboundSvc, nil, reset.Id, []string{client.Email}); err != nil || res.Renewed != 1 {
+		t.Fatalf("RenewWithPlan = %+v, %v", res, err)
+	}
+	if tr := trafficOf(t, client.Email); tr.Up != 0 || tr.Down != 0 || tr.Total != 2*bytesPerGB {
+		t.Fatalf("traffic = %+v, want zeroed usage under the 2GB quota", tr)
+	}
+}
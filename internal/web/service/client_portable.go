@@ -17,10 +17,10 @@ import (
 // /add and /bulkCreate accept, so an exported file round-trips straight back
 // through Import. Clients with no inbound attachment are included with an empty
 // inboundIds list so an export taken before DeleteOrphans can restore them.
-func (s *ClientService) ExportAll() ([]ClientCreatePayload, error) {
+func (s *ClientService) ExportAll(ownerId int) ([]ClientCreatePayload, error) {
 	db := database.GetDB()
 	var rows []model.ClientRecord
-	if err := db.Order("id ASC").Find(&rows).Error; err != nil {
+	if err := scopeClientOwner(db, ownerId).Order("id ASC").Find(&rows).Error; err != nil {
 		return nil, err
 	}
 	out := make([]ClientCreatePayload, 0, len(rows))
@@ -166,7 +166,17 @@ func (s *ClientService) ImportClients(inboundSvc *InboundService, items []Client
 
 		rec := client.ToRecord()
 		rec.LimitHwid = orphans[i].LimitHwid
-		if err := db.Create(rec).Error; err != nil {
+		rec.OwnerId = orphans[i].Scope.Owner()
+		guard := orphans[i].Scope.guard(rec.Email)
+		if err := runSerializedTx(func(tx *gorm.DB) error {
+			if err := guard.begin(tx); err != nil {
+				return err
+			}
+			if err := tx.Create(rec).Error; err != nil {
+				return err
+			}
+			return guard.finish(tx)
+		}); err != nil {
 			skip(email, err.Error())
 			continue
 		}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/schedule"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+
+	"gorm.io/gorm"
+)
+
+// accessGate holds the clients kept out of Xray by their schedule; config builds
+// leave them out too so a restart doesn't let them back in.
+var accessGate struct {
+	sync.Mutex
+	paused  map[string]struct{} // nil until first evaluated
+	checked int64               // unix millis of the last evaluation
+}
+
+// normalizeClientSchedule validates an access schedule and returns it in the
+// canonical form it is stored in.
+func normalizeClientSchedule(raw string) (string, error) {
+	sched, err := schedule.Parse(raw)
+	if err != nil {
+		return "", common.NewErrorf("invalid access schedule: %v", err)
+	}
+	return sched.String(), nil
+}
+
+// SetGroupSchedule limits the members of a group without a schedule of their
+// own to its windows. Nodes never see it, so node-hosted members must have one.
+func (s *ClientService) SetGroupSchedule(name, raw string) (string, error) {
+	sched, err := normalizeClientSchedule(raw)
+	if err != nil {
+		return "", err
+	}
+	if sched != "" {
+		hosted, err := nodeHostedClients(database.GetDB(), "clients.group_name = ? AND clients.schedule = ''", strings.TrimSpace(name))
+		if err != nil {
+			return "", err
+		}
+		if err := groupScheduleOnNodes(name, hosted); err != nil {
+			return "", err
+		}
+	}
+	return sched, setGroupColumn(name, "schedule", sched)
+}
+
+// nodeHostedClients returns the emails of the clients matching query that are
+// attached to a node inbound, where only the node's own schedule job runs.
+func nodeHostedClients(tx *gorm.DB, query string, args ...any) ([]string, error) {
+	var emails []string
+	err := tx.Table("clients").
+		Joins("JOIN client_inbounds ON client_inbounds.client_id = clients.id").
+		Joins("JOIN inbounds ON inbounds.id = client_inbounds.inbound_id").
+		Where("inbounds.node_id IS NOT NULL").
+		Where(query, args...).
+		Distinct("clients.email").
+		Order("clients.email").
+		Pluck("clients.email", &emails).Error
+	return emails, err
+}
+
+// groupScheduleOnNodes refuses a group schedule that node-hosted members
+// would silently ignore, naming a few of them.
+func groupScheduleOnNodes(group string, hosted []string) error {
+	if len(hosted) == 0 {
+		return nil
+	}
+	names := strings.Join(hosted[:min(len(hosted), 3)], ", ")
+	if len(hosted) > 3 {
+		names += ", ..."
+	}
+	return common.NewErrorf("group %s has an access schedule, which node inbounds do not apply; "+
+		"clients on node inbounds (%s) need a schedule of their own", group, names)
+}
+
+// clientScheduleOnNodes refuses to leave a client without a schedule of its
+// own on node inbounds while its group has one.
+func clientScheduleOnNodes(tx *gorm.DB, email, group, own string, inboundIds []int) error {
+	group = strings.TrimSpace(group)
+	if own != "" || group == "" || len(inboundIds) == 0 {
+		return nil
+	}
+	if tx == nil {
+		tx = database.GetDB()
+	}
+	var sched []string
+	if err := tx.Model(&model.ClientGroup{}).Where("name = ?", group).Pluck("schedule", &sched).Error; err != nil {
+		return err
+	}
+	if len(sched) == 0 || sched[0] == "" {
+		return nil
+	}
+	var onNodes int64
+	if err := tx.Model(&model.Inbound{}).
+		Where("id IN ? AND node_id IS NOT NULL", inboundIds).
+		Count(&onNodes).Error; err != nil {
+		return err
+	}
+	if onNodes == 0 {
+		return nil
+	}
+	return groupScheduleOnNodes(group, []string{email})
+}
+
+// pausedClients returns the clients outside their access window at now, with
+// their last change time. A client's own schedule overrides its group's.
+func pausedClients(tx *gorm.DB, now time.Time) (map[string]int64, error) {
+	return pausedClientsWith(tx, now, nil)
+}
+
+// pausedClientsWith is pausedClients with the pending client rows of ov laid
+// over the stored ones.
+func pausedClientsWith(tx *gorm.DB, now time.Time, ov *configOverlay) (map[string]int64, error) {
+	var rows []model.ClientRecord
+	if err := tx.Model(&model.ClientRecord{}).
+		Select("email, schedule, group_name, updated_at").
+		Where("schedule <> '' OR group_name <> ''").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	groups, err := groupColumnValues(tx, "schedule")
+	if err != nil {
+		return nil, err
+	}
+	parsed := map[string]schedule.Schedule{}
+	paused := make(map[string]int64)
+	for _, r := range ov.applyRecords(rows) {
+		raw := r.Schedule
+		if raw == "" {
+			raw = groups[r.Group]
+		}
+		if raw == "" {
+			continue
+		}
+		sched, ok := parsed[raw]
+		if !ok {
+			var err error
+			if sched, err = schedule.Parse(raw); err != nil {
+				logger.Warningf("access schedule of %s ignored: %v", r.Email, err)
+			}
+			parsed[raw] = sched
+		}
+		if !sched.Allows(now) {
+			paused[r.Email] = r.UpdatedAt
+		}
+	}
+	return paused, nil
+}
+
+// scheduleNow is the current time in the panel's time zone, the one access
+// schedules are written in.
+func scheduleNow() time.Time {
+	loc, err := (&SettingService{}).GetTimeLocation()
+	if err != nil {
+		loc = time.Local
+	}
+	return time.Now().In(loc)
+}
+
+// accessPaused returns the clients the last check kept out of Xray, checking once
+// if none ran yet. The map is shared; callers must not modify it.
+func accessPaused() map[string]struct{} {
+	accessGate.Lock()
+	defer accessGate.Unlock()
+	if accessGate.paused != nil {
+		return accessGate.paused
+	}
+	now := scheduleNow()
+	paused, err := pausedClients(database.GetDB(), now)
+	if err != nil {
+		logger.Warning("evaluate access schedules failed:", err)
+		return nil
+	}
+	accessGate.paused = make(map[string]struct{}, len(paused))
+	for email := range paused {
+		accessGate.paused[email] = struct{}{}
+	}
+	accessGate.checked = now.UnixMilli()
+	return accessGate.paused
+}
+
+// ClientAccess reports whether the client is outside its access window right
+// now and, if so, when the next window opens (zero when none ever does).
+func (s *ClientService) ClientAccess(email string) (paused bool, next time.Time, err error) {
+	rec, err := s.GetRecordByEmail(nil, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, time.Time{}, nil
+	}
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	raw := rec.Schedule
+	if raw == "" {
+		var group []string
+		if rec.Group != "" {
+			if err := database.GetDB().Model(&model.ClientGroup{}).Where("name = ?", rec.Group).
+				Pluck("schedule", &group).Error; err != nil {
+				return false, time.Time{}, err
+			}
+		}
+		if len(group) == 0 || group[0] == "" {
+			return false, time.Time{}, nil
+		}
+		raw = group[0]
+	}
+	sched, err := schedule.Parse(raw)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	loc, err := (&SettingService{}).GetTimeLocation()
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	now := time.Now().In(loc)
+	if sched.Allows(now) {
+		return false, time.Time{}, nil
+	}
+	next, _ = sched.NextOpen(now)
+	return true, next, nil
+}
+
+// ApplyAccessSchedules syncs this panel's Xray with the clients' access windows,
+// leaving enable flags, quotas and node inbounds alone; true means restart Xray.
+func (s *InboundService) ApplyAccessSchedules(now time.Time) (bool, error) {
+	db := database.GetDB()
+	paused, err := pausedClients(db, now)
+	if err != nil {
+		return false, err
+	}
+
+	accessGate.Lock()
+	prev, since := accessGate.paused, accessGate.checked
+	accessGate.paused = make(map[string]struct{}, len(paused))
+	for email := range paused {
+		accessGate.paused[email] = struct{}{}
+	}
+	accessGate.checked = now.UnixMilli()
+	accessGate.Unlock()
+
+	var remove, restore []string
+	// An edit or renewal since the last check may have put a paused client back.
+	for email, updatedAt := range paused {
+		if _, was := prev[email]; !was || updatedAt >= since {
+			remove = append(remove, email)
+		}
+	}
+	for email := range prev {
+		if _, still := paused[email]; !still {
+			restore = append(restore, email)
+		}
+	}
+	if len(remove) > 0 {
+		logger.Infof("access schedule: pausing %d client(s)", len(remove))
+	}
+	if len(restore) > 0 {
+		logger.Infof("access schedule: resuming %d client(s)", len(restore))
+	}
+
+	needRestart := false
+	for _, batch := range chunkStrings(remove, sqlInChunk) {
+		err := forLocalAttachments(db, batch, func(ib *model.Inbound, email string) {
+			rt, err := s.runtimeFor(ib)
+			if err == nil {
+				err = rt.RemoveUser(context.Background(), ib, email)
+			}
+			if err != nil && !strings.Contains(err.Error(), "not found") {
+				logger.Debug("access schedule: remove", email, "from", ib.Tag, "failed:", err)
+				needRestart = true
+			}
+		})
+		if err != nil {
+			return needRestart, err
+		}
+	}
+	for _, batch := range chunkStrings(restore, sqlInChunk) {
+		// Only clients that are still enabled and within quota go back.
+		var records []model.ClientRecord
+		if err := db.Where("email IN ? AND enable = ?", batch, true).Find(&records).Error; err != nil {
+			return needRestart, err
+		}
+		var depleted []string
+		if err := db.Model(&xray.ClientTraffic{}).Where("email IN ? AND enable = ?", batch, false).
+			Pluck("email", &depleted).Error; err != nil {
+			return needRestart, err
+		}
+		clients := make(map[string]*model.Client, len(records))
+		for i := range records {
+			if !slices.Contains(depleted, records[i].Email) {
+				clients[records[i].Email] = records[i].ToClient()
+			}
+		}
+		err := forLocalAttachments(db, slices.Collect(maps.Keys(clients)), func(ib *model.Inbound, email string) {
+			rt, err := s.runtimeFor(ib)
+			if err == nil {
+				err = rt.AddUser(context.Background(), ib, scheduleUser(ib, clients[email]))
+			}
+			if err != nil && !strings.Contains(err.Error(), "already exists") {
+				logger.Debug("access schedule: add", email, "to", ib.Tag, "failed:", err)
+				needRestart = true
+			}
+		})
+		if err != nil {
+			return needRestart, err
+		}
+	}
+	return needRestart, nil
+}
+
+// forLocalAttachments calls fn for every enabled Xray inbound of this panel
+// that one of the emails is attached to.
+func forLocalAttachments(tx *gorm.DB, emails []string, fn func(ib *model.Inbound, email string)) error {
+	if len(emails) == 0 {
+		return nil
+	}
+	var links []struct {
+		InboundId int
+		Email     string
+	}
+	if err := tx.Table("clients").
+		Select("client_inbounds.inbound_id AS inbound_id, clients.email AS email").
+		Joins("JOIN client_inbounds ON client_inbounds.client_id = clients.id").
+		Joins("JOIN inbounds ON inbounds.id = client_inbounds.inbound_id").
+		Where("clients.email IN ? AND inbounds.node_id IS NULL AND inbounds.enable = ? AND inbounds.protocol <> ?",
+			emails, true, model.MTProto).
+		Scan(&links).Error; err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(links))
+	for _, l := range links {
+		ids = append(ids, l.InboundId)
+	}
+	var inbounds []*model.Inbound
+	if err := tx.Where("id IN ?", ids).Find(&inbounds).Error; err != nil {
+		return err
+	}
+	byId := make(map[int]*model.Inbound, len(inbounds))
+	for _, ib := range inbounds {
+		byId[ib.Id] = ib
+	}
+	for _, l := range links {
+		if ib := byId[l.InboundId]; ib != nil {
+			fn(ib, l.Email)
+		}
+	}
+	return nil
+}
+
+// scheduleUser is the Xray user a resumed client is added back as, shaped
+// like the entry the config generator writes for it.
+func scheduleUser(ib *model.Inbound, c *model.Client) map[string]any {
+	flow := c.Flow
+	if flow == "xtls-rprx-vision-udp443" {
+		flow = "xtls-rprx-vision"
+	}
+	if ib.DisableFlow {
+		flow = ""
+	}
+	cipher := ""
+	if ib.Protocol == model.Shadowsocks {
+		var settings map[string]any
+		if json.Unmarshal([]byte(ib.Settings), &settings) == nil {
+			cipher, _ = settings["method"].(string)
+		}
+	}
+	return map[string]any{
+		"email":        c.Email,
+		"id":           c.ID,
+		"auth":         c.Auth,
+		"security":     c.Security,
+		"flow":         flow,
+		"password":     c.Password,
+		"cipher":       cipher,
+		"publicKey":    c.PublicKey,
+		"allowedIPs":   c.AllowedIPs,
+		"preSharedKey": c.PreSharedKey,
+		"keepAlive":    keepAliveStr(c.KeepAlive),
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+func resetAccessGate(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		accessGate.Lock()
+		accessGate.paused, accessGate.checked = nil, 0
+		accessGate.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestAccessSchedules(t *testing.T) {
+	setupBulkDB(t)
+	resetAccessGate(t)
+	svc := &ClientService{}
+	inboundSvc := &InboundService{}
+
+	source := []model.Client{
+		{Email: "alice", ID: "aaaaaaaa-0000-0000-0000-000000000041", SubID: "alice", Enable: true},
+		{Email: "bob", ID: "aaaaaaaa-0000-0000-0000-000000000042", SubID: "bob", Enable: true},
+		{Email: "carol", ID: "aaaaaaaa-0000-0000-0000-000000000043", SubID: "carol", Enable: true},
+	}
+	ib := mkInbound(t, 22041, model.VLESS, clientsSettings(t, source))
+	if err := svc.SyncInbound(nil, ib.Id, source); err != nil {
+		t.Fatalf("seed linkage: %v", err)
+	}
+
+	aliceRec := lookupClientRecord(t, "alice")
+	alice := aliceRec.ToClient()
+	alice.Schedule = "mon-fri 25:00-18:00"
+	if _, err := svc.Update(inboundSvc, nil, aliceRec.Id, *alice, 0); err == nil {
+		t.Fatal("invalid schedule accepted")
+	}
+	alice.Schedule = "Monday-Friday 09:00-18:00"
+	if _, err := svc.Update(inboundSvc, nil, aliceRec.Id, *alice, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := lookupClientRecord(t, "alice").Schedule; got != "mon-fri 09:00-18:00" {
+		t.Fatalf("stored schedule = %q, want the canonical form", got)
+	}
+	if _, err := svc.AddToGroup([]string{"bob"}, "night"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetGroupSchedule("night", "daily 22:00-06:00"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2026-10-12 is a Monday.
+	monday := time.Date(2026, 10, 12, 12, 0, 0, 0, time.UTC)
+	saturday := monday.AddDate(0, 0, 5)
+	paused, err := pausedClients(database.GetDB(), saturday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Sorted(maps.Keys(paused)); !slices.Equal(got, []string{"alice", "bob"}) {
+		t.Fatalf("paused on Saturday = %v, want [alice bob]", got)
+	}
+
+	if _, err := inboundSvc.ApplyAccessSchedules(saturday); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := (&XrayService{}).genInboundConfig(ib, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := string(cfg.Settings)
+	if strings.Contains(settings, "alice") || strings.Contains(settings, "bob") || !strings.Contains(settings, "carol") {
+		t.Fatalf("config kept paused clients or dropped carol: %s", settings)
+	}
+
+	if _, err := inboundSvc.ApplyAccessSchedules(monday); err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Sorted(maps.Keys(accessPaused())); !slices.Equal(got, []string{"bob"}) {
+		t.Fatalf("paused on Monday = %v, want [bob]", got)
+	}
+	if rec := lookupClientRecord(t, "alice"); !rec.Enable {
+		t.Fatal("the schedule flipped the enable flag")
+	}
+}
+
+// TestGroupScheduleRefusedForNodeMembers: nodes never receive a group schedule,
+// so one that node-hosted members would ignore is refused.
+func TestGroupScheduleRefusedForNodeMembers(t *testing.T) {
+	setupBulkDB(t)
+	svc := &ClientService{}
+
+	local := []model.Client{{Email: "dave", ID: "aaaaaaaa-0000-0000-0000-000000000051", SubID: "dave", Enable: true}}
+	ib := mkInbound(t, 22051, model.VLESS, clientsSettings(t, local))
+	if err := svc.SyncInbound(nil, ib.Id, local); err != nil {
+		t.Fatal(err)
+	}
+	nodeID := 1
+	remote := []model.Client{
+		{Email: "erin", ID: "aaaaaaaa-0000-0000-0000-000000000052", SubID: "erin", Enable: true},
+		{Email: "frank", ID: "aaaaaaaa-0000-0000-0000-000000000053", SubID: "frank", Enable: true, Schedule: "daily 08:00-20:00"},
+	}
+	nodeIb := &model.Inbound{UserId: 1, Tag: "node-sched", Enable: true, Port: 22052, Protocol: model.VLESS,
+		NodeID: &nodeID, Settings: clientsSettings(t, remote)}
+	if err := database.GetDB().Create(nodeIb).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SyncInbound(nil, nodeIb.Id, remote); err != nil {
+		t.Fatal(err)
+	}
+
+	// frank's own schedule overrides the group's, so only erin blocks it.
+	if _, err := svc.AddToGroup([]string{"dave", "frank"}, "day"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetGroupSchedule("day", "mon-fri 09:00-18:00"); err != nil {
+		t.Fatalf("group without node-hosted members refused: %v", err)
+	}
+	if _, err := svc.AddToGroup([]string{"erin"}, "day"); err == nil || !strings.Contains(err.Error(), "erin") {
+		t.Fatalf("AddToGroup of a node-hosted client into a scheduled group = %v, want an error naming erin", err)
+	}
+
+	if _, err := svc.AddToGroup([]string{"erin"}, "night"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetGroupSchedule("night", "daily 22:00-06:00"); err == nil {
+		t.Fatal("group schedule accepted for a group with node-hosted members")
+	}
+	if _, err := svc.SetGroupSchedule("night", ""); err != nil {
+		t.Fatalf("clearing a group schedule refused: %v", err)
+	}
+}
+
+// TestNodeClientWritesRespectGroupSchedule: editing, creating or attaching a
+// client must not leave it on a node inbound under a group schedule alone.
+func TestNodeClientWritesRespectGroupSchedule(t *testing.T) {
+	setupBulkDB(t)
+	svc := &ClientService{}
+	inboundSvc := &InboundService{}
+
+	local := []model.Client{{Email: "dave", ID: "aaaaaaaa-0000-0000-0000-000000000061", SubID: "dave", Enable: true}}
+	ib := mkInbound(t, 22061, model.VLESS, clientsSettings(t, local))
+	if err := svc.SyncInbound(nil, ib.Id, local); err != nil {
+		t.Fatal(err)
+	}
+	nodeID := 1
+	remote := []model.Client{
+		{Email: "erin", ID: "aaaaaaaa-0000-0000-0000-000000000062", SubID: "erin", Enable: true},
+		{Email: "frank", ID: "aaaaaaaa-0000-0000-0000-000000000063", SubID: "frank", Enable: true, Schedule: "daily 08:00-20:00"},
+	}
+	nodeIb := &model.Inbound{UserId: 1, Tag: "node-sched", Enable: true, Port: 22062, Protocol: model.VLESS,
+		NodeID: &nodeID, Settings: clientsSettings(t, remote)}
+	if err := database.GetDB().Create(nodeIb).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SyncInbound(nil, nodeIb.Id, remote); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.AddToGroup([]string{"dave", "frank"}, "day"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetGroupSchedule("day", "mon-fri 09:00-18:00"); err != nil {
+		t.Fatal(err)
+	}
+
+	erinRec := lookupClientRecord(t, "erin")
+	erin := erinRec.ToClient()
+	erin.Group = "day"
+	if _, err := svc.Update(inboundSvc, nil, erinRec.Id, *erin, 0); err == nil || !strings.Contains(err.Error(), "erin") {
+		t.Fatalf("Update moving a node-hosted client into a scheduled group = %v, want an error naming erin", err)
+	}
+	frankRec := lookupClientRecord(t, "frank")
+	frank := frankRec.ToClient()
+	frank.Schedule = ""
+	if _, err := svc.Update(inboundSvc, nil, frankRec.Id, *frank, 0); err == nil {
+		t.Fatal("Update cleared the own schedule of a node-hosted member of a scheduled group")
+	}
+	if got := lookupClientRecord(t, "frank").Schedule; got != "daily 08:00-20:00" {
+		t.Fatalf("frank's schedule = %q after the refused update", got)
+	}
+
+	gina := model.Client{Email: "gina", ID: "aaaaaaaa-0000-0000-0000-000000000064", SubID: "gina", Group: "day", Enable: true}
+	if _, err := svc.Create(inboundSvc, &ClientCreatePayload{Client: gina, InboundIds: []int{nodeIb.Id}}); err == nil {
+		t.Fatal("Create put a scheduled group member without its own schedule on a node inbound")
+	}
+	if _, err := svc.Create(inboundSvc, &ClientCreatePayload{Client: gina, InboundIds: []int{ib.Id}}); err != nil {
+		t.Fatalf("Create on a local inbound refused: %v", err)
+	}
+
+	daveRec := lookupClientRecord(t, "dave")
+	if _, err := svc.Attach(inboundSvc, daveRec.Id, []int{nodeIb.Id}); err == nil {
+		t.Fatal("Attach put a scheduled group member without its own schedule on a node inbound")
+	}
+	res, _, err := svc.BulkAttach(inboundSvc, []string{"dave"}, []int{nodeIb.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Attached) != 0 || len(res.Errors) != 1 {
+		t.Fatalf("BulkAttach = %+v, want dave refused", res)
+	}
+}
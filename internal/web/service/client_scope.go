@@ -0,0 +1,556 @@
+package service
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+
+	"gorm.io/gorm"
+)
+
+// ClientScope confines a reseller to the clients it created, on the inbounds
+// it was granted, within its aggregate caps. nil is the unrestricted view.
+type ClientScope struct {
+	OwnerId       int
+	InboundIds    []int
+	MaxClients    int
+	MaxTrafficGB  int
+	MaxExpiryDays int
+}
+
+// ResellerUsage is what a reseller has handed out against its caps. TotalGB is
+// in bytes, like ClientRecord.TotalGB.
+type ResellerUsage struct {
+	Clients int   `json:"clients" example:"12"`
+	TotalGB int64 `json:"totalGB" example:"128849018880"`
+}
+
+// errClientOutOfScope reads the same as a missing client so a reseller cannot
+// probe which emails other accounts use.
+var errClientOutOfScope = common.NewError("client not found")
+
+var errEgressOutOfScope = common.NewError("egress is not available to this account")
+
+const (
+	bytesPerGB = int64(1073741824)
+	msPerDay   = int64(86400000)
+)
+
+// ClientScopeFor returns the scope of a signed-in account, nil unless it is a reseller.
+func ClientScopeFor(u *model.User) *ClientScope {
+	if u == nil || u.Role != model.UserRoleReseller {
+		return nil
+	}
+	return &ClientScope{
+		OwnerId:       u.Id,
+		InboundIds:    u.AllowedInbounds,
+		MaxClients:    u.MaxClients,
+		MaxTrafficGB:  u.MaxTrafficGB,
+		MaxExpiryDays: u.MaxExpiryDays,
+	}
+}
+
+// Owner is the id new clients are stamped with; 0 for the unrestricted scope.
+func (sc *ClientScope) Owner() int {
+	if sc == nil {
+		return 0
+	}
+	return sc.OwnerId
+}
+
+func (sc *ClientScope) AllowsInbound(id int) bool {
+	return sc == nil || slices.Contains(sc.InboundIds, id)
+}
+
+func (sc *ClientScope) checkInbounds(ids []int) error {
+	for _, id := range ids {
+		if !sc.AllowsInbound(id) {
+			return common.NewErrorf("inbound %d is not available to this account", id)
+		}
+	}
+	return nil
+}
+
+// checkExpiry treats a negative expiry as a delayed-start duration. A capped
+// account may not hand out clients that never expire.
+func (sc *ClientScope) checkExpiry(expiry, nowMs int64) error {
+	if sc.MaxExpiryDays <= 0 {
+		return nil
+	}
+	limit := int64(sc.MaxExpiryDays) * msPerDay
+	switch {
+	case expiry == 0:
+		return common.NewErrorf("an expiry within %d days is required", sc.MaxExpiryDays)
+	case expiry < 0 && -expiry > limit, expiry > 0 && expiry > nowMs+limit:
+		return common.NewErrorf("expiry exceeds the %d-day limit of this account", sc.MaxExpiryDays)
+	}
+	return nil
+}
+
+func (sc *ClientScope) checkTotal(total int64) error {
+	if sc.MaxTrafficGB > 0 && total <= 0 {
+		return common.NewError("a traffic limit is required for this account")
+	}
+	return nil
+}
+
+func (sc *ClientScope) checkTraffic(totalBytes int64) error {
+	if sc.MaxTrafficGB > 0 && totalBytes > int64(sc.MaxTrafficGB)*bytesPerGB {
+		return common.NewErrorf("traffic allowance exceeded (%d GB)", sc.MaxTrafficGB)
+	}
+	return nil
+}
+
+func (sc *ClientScope) checkUsage(usage ResellerUsage) error {
+	if sc.MaxClients > 0 && usage.Clients > sc.MaxClients {
+		return common.NewErrorf("client limit reached (%d)", sc.MaxClients)
+	}
+	return sc.checkTraffic(usage.TotalGB)
+}
+
+// Usage sums the clients a reseller owns against its caps.
+func (s *ClientService) Usage(sc *ClientScope) (ResellerUsage, error) {
+	return resellerUsage(nil, sc)
+}
+
+func resellerUsage(tx *gorm.DB, sc *ClientScope) (ResellerUsage, error) {
+	var usage ResellerUsage
+	if sc == nil {
+		return usage, nil
+	}
+	if tx == nil {
+		tx = database.GetDB()
+	}
+	var row struct {
+		Clients int64
+		Total   int64
+	}
+	err := tx.Model(&model.ClientRecord{}).
+		Select("COUNT(*) AS clients, COALESCE(SUM(total_gb), 0) AS total").
+		Where("owner_id = ?", sc.OwnerId).
+		Scan(&row).Error
+	usage.Clients = int(row.Clients)
+	usage.TotalGB = row.Total
+	return usage, err
+}
+
+func clientRecordsByEmail(tx *gorm.DB, emails []string) (map[string]model.ClientRecord, error) {
+	if tx == nil {
+		tx = database.GetDB()
+	}
+	out := make(map[string]model.ClientRecord, len(emails))
+	for _, batch := range chunkStrings(emails, sqlInChunk) {
+		var rows []model.ClientRecord
+		if err := tx.Where("email IN ?", batch).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			out[r.Email] = r
+		}
+	}
+	return out, nil
+}
+
+func cleanEmailList(emails []string) []string {
+	seen := make(map[string]struct{}, len(emails))
+	out := make([]string, 0, len(emails))
+	for _, e := range emails {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if _, dup := seen[e]; dup {
+			continue
+		}
+		seen[e] = struct{}{}
+		out = append(out, e)
+	}
+	return out
+}
+
+// CheckOwned fails unless the scope owns every listed client.
+func (s *ClientService) CheckOwned(sc *ClientScope, emails ...string) error {
+	if sc == nil {
+		return nil
+	}
+	clean := cleanEmailList(emails)
+	records, err := clientRecordsByEmail(nil, clean)
+	if err != nil {
+		return err
+	}
+	for _, e := range clean {
+		if rec, ok := records[e]; !ok || rec.OwnerId != sc.OwnerId {
+			return errClientOutOfScope
+		}
+	}
+	return nil
+}
+
+// CheckOwnedSubID fails unless the scope owns every client sharing the subscription.
+func (s *ClientService) CheckOwnedSubID(sc *ClientScope, subID string) error {
+	if sc == nil {
+		return nil
+	}
+	var owned, foreign int64
+	db := database.GetDB()
+	if err := db.Model(&model.ClientRecord{}).Where("sub_id = ? AND owner_id = ?", subID, sc.OwnerId).Count(&owned).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&model.ClientRecord{}).Where("sub_id = ? AND owner_id <> ?", subID, sc.OwnerId).Count(&foreign).Error; err != nil {
+		return err
+	}
+	if owned == 0 || foreign > 0 {
+		return errClientOutOfScope
+	}
+	return nil
+}
+
+// CheckInbounds fails when the scope may not place clients on one of the inbounds.
+func (s *ClientService) CheckInbounds(sc *ClientScope, inboundIds []int) error {
+	if sc == nil {
+		return nil
+	}
+	return sc.checkInbounds(inboundIds)
+}
+
+// CheckCreate vets an add, bulk-create or import batch against the scope. An
+// email the reseller already owns is a re-add and only its new quota counts.
+func (s *ClientService) CheckCreate(sc *ClientScope, payloads []ClientCreatePayload) error {
+	if sc == nil {
+		return nil
+	}
+	usage, err := s.Usage(sc)
+	if err != nil {
+		return err
+	}
+	emails := make([]string, 0, len(payloads))
+	for i := range payloads {
+		emails = append(emails, strings.TrimSpace(payloads[i].Client.Email))
+	}
+	existing, err := clientRecordsByEmail(nil, cleanEmailList(emails))
+	if err != nil {
+		return err
+	}
+	nowMs := time.Now().UnixMilli()
+	seen := make(map[string]struct{}, len(payloads))
+	for i := range payloads {
+		if err := sc.checkInbounds(payloads[i].InboundIds); err != nil {
+			return err
+		}
+		client := payloads[i].Client
+		email := emails[i]
+		if _, dup := seen[email]; dup {
+			continue
+		}
+		seen[email] = struct{}{}
+		if rec, ok := existing[email]; ok {
+			if rec.OwnerId != sc.OwnerId {
+				return common.NewError("email already in use:", email)
+			}
+			usage.TotalGB -= rec.TotalGB
+		} else {
+			usage.Clients++
+		}
+		if err := sc.checkTotal(client.TotalGB); err != nil {
+			return err
+		}
+		if err := sc.checkExpiry(client.ExpiryTime, nowMs); err != nil {
+			return err
+		}
+		if err := checkEgress(client.Egress, client.Group); err != nil {
+			return err
+		}
+		usage.TotalGB += client.TotalGB
+	}
+	return sc.checkUsage(usage)
+}
+
+// CheckUpdate vets an edit of an owned client. Only changed limits are held to
+// the caps, so lowering a cap never locks a reseller out of its own clients.
+func (s *ClientService) CheckUpdate(sc *ClientScope, email string, updated model.Client) error {
+	if sc == nil {
+		return nil
+	}
+	records, err := clientRecordsByEmail(nil, []string{email})
+	if err != nil {
+		return err
+	}
+	rec, ok := records[email]
+	if !ok || rec.OwnerId != sc.OwnerId {
+		return errClientOutOfScope
+	}
+	if updated.ExpiryTime != rec.ExpiryTime {
+		if err := sc.checkExpiry(updated.ExpiryTime, time.Now().UnixMilli()); err != nil {
+			return err
+		}
+	}
+	if updated.Egress != rec.Egress || updated.Group != rec.Group {
+		if err := checkEgress(updated.Egress, updated.Group); err != nil {
+			return err
+		}
+	}
+	if updated.TotalGB == rec.TotalGB {
+		return nil
+	}
+	if err := sc.checkTotal(updated.TotalGB); err != nil {
+		return err
+	}
+	if updated.TotalGB < rec.TotalGB {
+		return nil
+	}
+	usage, err := s.Usage(sc)
+	if err != nil {
+		return err
+	}
+	return sc.checkTraffic(usage.TotalGB + updated.TotalGB - rec.TotalGB)
+}
+
+// checkEgress keeps resellers off the egresses the admin sells separately:
+// neither a client's own egress nor a group that carries one.
+func checkEgress(egress, group string) error {
+	if strings.TrimSpace(egress) != "" {
+		return errEgressOutOfScope
+	}
+	routed, err := groupEgress(nil, strings.TrimSpace(group))
+	if err != nil {
+		return err
+	}
+	if routed != "" {
+		return errEgressOutOfScope
+	}
+	return nil
+}
+
+// CheckAdjust vets a bulk extension, mirroring how BulkAdjust moves expiry and
+// quota and which unlimited clients it leaves alone.
+func (s *ClientService) CheckAdjust(sc *ClientScope, emails []string, addDays int, addBytes int64) error {
+	if sc == nil {
+		return nil
+	}
+	if err := s.CheckOwned(sc, emails...); err != nil {
+		return err
+	}
+	records, err := clientRecordsByEmail(nil, cleanEmailList(emails))
+	if err != nil {
+		return err
+	}
+	nowMs := time.Now().UnixMilli()
+	addMs := int64(addDays) * msPerDay
+	var grow int64
+	for _, rec := range records {
+		if addDays > 0 && rec.ExpiryTime != 0 {
+			next := rec.ExpiryTime + addMs
+			if rec.ExpiryTime < 0 {
+				next = rec.ExpiryTime - addMs
+			}
+			if err := sc.checkExpiry(next, nowMs); err != nil {
+				return err
+			}
+		}
+		if addBytes > 0 && rec.TotalGB > 0 {
+			grow += addBytes
+		}
+	}
+	if grow == 0 {
+		return nil
+	}
+	usage, err := s.Usage(sc)
+	if err != nil {
+		return err
+	}
+	return sc.checkTraffic(usage.TotalGB + grow)
+}
+
+// OwnedEmails is the set of clients a reseller owns, nil for the unrestricted scope.
+func (s *ClientService) OwnedEmails(sc *ClientScope) (map[string]struct{}, error) {
+	if sc == nil {
+		return nil, nil
+	}
+	var emails []string
+	if err := database.GetDB().Model(&model.ClientRecord{}).
+		Where("owner_id = ?", sc.OwnerId).
+		Pluck("email", &emails).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]struct{}, len(emails))
+	for _, e := range emails {
+		out[e] = struct{}{}
+	}
+	return out, nil
+}
+
+// ScopeInbounds trims an inbound list to what a reseller may see: the inbounds
+// it was granted, each carrying only the clients it owns.
+func (s *ClientService) ScopeInbounds(sc *ClientScope, inbounds []*model.Inbound) ([]*model.Inbound, error) {
+	if sc == nil {
+		return inbounds, nil
+	}
+	owned, err := s.OwnedEmails(sc)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Inbound, 0, len(inbounds))
+	for _, ib := range inbounds {
+		if ib == nil || !sc.AllowsInbound(ib.Id) {
+			continue
+		}
+		stats := make([]xray.ClientTraffic, 0, len(ib.ClientStats))
+		for _, st := range ib.ClientStats {
+			if _, ok := owned[st.Email]; ok {
+				stats = append(stats, st)
+			}
+		}
+		ib.ClientStats = stats
+		ib.Settings = ownedSettingsClients(ib.Settings, owned)
+		out = append(out, ib)
+	}
+	return out, nil
+}
+
+// ownedSettingsClients drops foreign entries from settings.clients. Settings
+// that cannot be parsed lose their client list rather than leak it.
+func ownedSettingsClients(settings string, owned map[string]struct{}) string {
+	if strings.TrimSpace(settings) == "" {
+		return settings
+	}
+	var parsed map[string]any
+	if err := json.Unmarshal([]byte(settings), &parsed); err != nil {
+		return "{}"
+	}
+	clients, ok := parsed["clients"].([]any)
+	if !ok {
+		return settings
+	}
+	kept := make([]any, 0, len(clients))
+	for _, c := range clients {
+		entry, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		if email, _ := entry["email"].(string); email != "" {
+			if _, mine := owned[email]; mine {
+				kept = append(kept, entry)
+			}
+		}
+	}
+	parsed["clients"] = kept
+	raw, err := json.MarshalIndent(parsed, "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(raw)
+}
+
+// scopeGuard holds a reseller's write to its caps inside the transaction that
+// makes it, so concurrent requests cannot each pass the Check* calls and
+// together exceed them. As in CheckUpdate, only growth is held to the caps.
+type scopeGuard struct {
+	sc     *ClientScope
+	emails []string
+	before ResellerUsage
+	expiry map[int]int64 // by client id, as stored when the transaction began
+}
+
+// guard returns the guard of a write to the clients under emails, nil for the
+// unrestricted scope.
+func (sc *ClientScope) guard(emails ...string) *scopeGuard {
+	if sc == nil {
+		return nil
+	}
+	return &scopeGuard{sc: sc, emails: cleanEmailList(emails)}
+}
+
+// begin records the usage and expiries the transaction starts from.
+func (g *scopeGuard) begin(tx *gorm.DB) error {
+	if g == nil {
+		return nil
+	}
+	usage, err := resellerUsage(tx, g.sc)
+	if err != nil {
+		return err
+	}
+	records, err := clientRecordsByEmail(tx, g.emails)
+	if err != nil {
+		return err
+	}
+	g.before = usage
+	g.expiry = make(map[int]int64, len(records))
+	for _, rec := range records {
+		g.expiry[rec.Id] = rec.ExpiryTime
+	}
+	return nil
+}
+
+// finish stamps the clients the write inserted with their reseller and fails
+// the transaction when the write took the reseller past a cap.
+func (g *scopeGuard) finish(tx *gorm.DB) error {
+	if g == nil {
+		return nil
+	}
+	records, err := clientRecordsByEmail(tx, g.emails)
+	if err != nil {
+		return err
+	}
+	nowMs := time.Now().UnixMilli()
+	for email, rec := range records {
+		prev, existed := g.expiry[rec.Id]
+		if !existed && rec.OwnerId == 0 {
+			if err := tx.Model(&model.ClientRecord{}).Where("id = ?", rec.Id).
+				UpdateColumn("owner_id", g.sc.OwnerId).Error; err != nil {
+				return err
+			}
+			rec.OwnerId = g.sc.OwnerId
+		}
+		if rec.OwnerId != g.sc.OwnerId {
+			return common.NewError("email already in use:", email)
+		}
+		if !existed || extendsExpiry(prev, rec.ExpiryTime) {
+			if err := g.sc.checkExpiry(rec.ExpiryTime, nowMs); err != nil {
+				return err
+			}
+		}
+	}
+	usage, err := resellerUsage(tx, g.sc)
+	if err != nil {
+		return err
+	}
+	if usage.Clients > g.before.Clients && g.sc.MaxClients > 0 && usage.Clients > g.sc.MaxClients {
+		return common.NewErrorf("client limit reached (%d)", g.sc.MaxClients)
+	}
+	if usage.TotalGB > g.before.TotalGB {
+		return g.sc.checkTraffic(usage.TotalGB)
+	}
+	return nil
+}
+
+// extendsExpiry reports whether next grants more time than prev. 0 never
+// expires and a negative expiry is a delayed-start duration.
+func extendsExpiry(prev, next int64) bool {
+	switch {
+	case next == prev:
+		return false
+	case next == 0:
+		return true
+	case prev == 0:
+		return false
+	case next < 0 && prev < 0:
+		return next < prev
+	case next > 0 && prev > 0:
+		return next > prev
+	}
+	return true
+}
+
+// scopeClientOwner narrows a clients query to one reseller; 0 leaves it whole.
+func scopeClientOwner(tx *gorm.DB, ownerId int) *gorm.DB {
+	if ownerId <= 0 {
+		return tx
+	}
+	return tx.Where("owner_id = ?", ownerId)
+}
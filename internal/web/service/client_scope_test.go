@@ -0,0 +1,267 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+)
+
+const scopeOwner = 7
+
+// seedScopedClients hands bravo@x and golf@x to reseller 7 and returns a scope
+// allowed onto the vless inbound only.
+func seedScopedClients(t *testing.T) *ClientScope {
+	t.Helper()
+	seedPagingClients(t)
+	if err := database.GetDB().Model(&model.ClientRecord{}).
+		Where("email IN ?", []string{"bravo@x", "golf@x"}).
+		UpdateColumn("owner_id", scopeOwner).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &ClientScope{OwnerId: scopeOwner, InboundIds: []int{1}}
+}
+
+func TestListPagedScopesToOwner(t *testing.T) {
+	svc, inboundSvc, settingSvc := setupPagingServices(t)
+	seedScopedClients(t)
+
+	resp, err := svc.ListPaged(inboundSvc, settingSvc, ClientPageParams{PageSize: 50, OwnerId: scopeOwner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pagedEmails(resp.Items), []string{"bravo@x", "golf@x"}; !slices.Equal(got, want) {
+		t.Fatalf("items = %v, want %v", got, want)
+	}
+	if resp.Total != 2 || resp.Summary.Total != 2 || resp.Summary.Active != 1 || resp.Summary.ExpiringCount != 1 {
+		t.Fatalf("total=%d summary=%+v, want counts over the owned clients only", resp.Total, resp.Summary)
+	}
+	if len(resp.Groups) != 0 {
+		t.Fatalf("groups = %v, want only groups the reseller's clients carry", resp.Groups)
+	}
+
+	exported, err := svc.ExportAll(scopeOwner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, err := svc.List(scopeOwner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 2 || len(listed) != 2 {
+		t.Fatalf("export=%d list=%d, want 2 each", len(exported), len(listed))
+	}
+}
+
+func TestCheckOwnedHidesForeignClients(t *testing.T) {
+	setupPagingServices(t)
+	scope := seedScopedClients(t)
+	svc := &ClientService{}
+
+	if err := svc.CheckOwned(scope, "bravo@x", "golf@x"); err != nil {
+		t.Fatalf("owned clients rejected: %v", err)
+	}
+	for _, emails := range [][]string{{"alpha@x"}, {"bravo@x", "alpha@x"}, {"missing@x"}} {
+		if err := svc.CheckOwned(scope, emails...); err != errClientOutOfScope {
+			t.Fatalf("CheckOwned(%v) = %v, want errClientOutOfScope", emails, err)
+		}
+	}
+	if err := svc.CheckOwned(nil, "alpha@x"); err != nil {
+		t.Fatalf("the unrestricted scope must pass: %v", err)
+	}
+}
+
+func TestCheckCreateEnforcesCaps(t *testing.T) {
+	setupPagingServices(t)
+	scope := seedScopedClients(t)
+	svc := &ClientService{}
+	now := time.Now().UnixMilli()
+	payload := func(email string, totalGB, expiry int64, inbounds ...int) ClientCreatePayload {
+		return ClientCreatePayload{
+			Client:     model.Client{Email: email, TotalGB: totalGB, ExpiryTime: expiry},
+			InboundIds: inbounds,
+		}
+	}
+
+	scope.MaxClients = 3
+	scope.MaxTrafficGB = 25
+	scope.MaxExpiryDays = 31
+
+	cases := []struct {
+		name     string
+		payloads []ClientCreatePayload
+		wantErr  string
+	}{
+		{"fits every cap", []ClientCreatePayload{payload("new@x", 5*pagingGB, now+30*pagingDay, 1)}, ""},
+		{"delayed start within the cap", []ClientCreatePayload{payload("new@x", 5*pagingGB, -30*pagingDay, 1)}, ""},
+		{"inbound not granted", []ClientCreatePayload{payload("new@x", 5*pagingGB, now+pagingDay, 2)}, "inbound 2"},
+		{"client count", []ClientCreatePayload{payload("a@x", pagingGB, now+pagingDay, 1), payload("b@x", pagingGB, now+pagingDay, 1)}, "client limit"},
+		{"traffic allowance", []ClientCreatePayload{payload("new@x", 6*pagingGB, now+pagingDay, 1)}, "traffic allowance"},
+		{"unlimited traffic", []ClientCreatePayload{payload("new@x", 0, now+pagingDay, 1)}, "traffic limit is required"},
+		{"expiry too far", []ClientCreatePayload{payload("new@x", pagingGB, now+40*pagingDay, 1)}, "31-day"},
+		{"never expires", []ClientCreatePayload{payload("new@x", pagingGB, 0, 1)}, "expiry within"},
+		{"foreign email", []ClientCreatePayload{payload("alpha@x", pagingGB, now+pagingDay, 1)}, "already in use"},
+		{"re-adding an owned client counts its new quota only", []ClientCreatePayload{payload("bravo@x", 15*pagingGB, now+pagingDay, 1)}, ""},
+	}
+	for _, c := range cases {
+		err := svc.CheckCreate(scope, c.payloads)
+		switch {
+		case c.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+			t.Errorf("%s: error = %v, want it to mention %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestScopedWritesRecheckCapsInTheirTransaction(t *testing.T) {
+	svc, inboundSvc, _ := setupPagingServices(t)
+	scope := seedScopedClients(t)
+	now := time.Now().UnixMilli()
+	// bravo and golf hold 2 clients and 20 GB, one client and 5 GB short.
+	scope.MaxClients = 3
+	scope.MaxTrafficGB = 25
+	payload := func(email string) ClientCreatePayload {
+		return ClientCreatePayload{
+			Client:     model.Client{Email: email, Enable: true, TotalGB: 5 * pagingGB, ExpiryTime: now + pagingDay},
+			InboundIds: []int{1},
+			Scope:      scope,
+		}
+	}
+
+	// Both requests pass the pre-check before either one writes.
+	first, second := payload("new1@x"), payload("new2@x")
+	for _, p := range []ClientCreatePayload{first, second} {
+		if err := svc.CheckCreate(scope, []ClientCreatePayload{p}); err != nil {
+			t.Fatalf("pre-check of %s: %v", p.Client.Email, err)
+		}
+	}
+	if _, err := svc.Create(inboundSvc, &first); err != nil {
+		t.Fatalf("create within the caps: %v", err)
+	}
+	if rec, err := svc.GetRecordByEmail(nil, "new1@x"); err != nil || rec.OwnerId != scopeOwner {
+		t.Fatalf("new1@x = %+v, %v; want it owned by the reseller", rec, err)
+	}
+	if _, err := svc.Create(inboundSvc, &second); err == nil || !strings.Contains(err.Error(), "client limit") {
+		t.Fatalf("second create err = %v, want the client limit", err)
+	}
+	if _, err := svc.GetRecordByEmail(nil, "new2@x"); err == nil {
+		t.Fatal("the rejected create left its client behind")
+	}
+
+	rec, err := svc.GetRecordByEmail(nil, "new1@x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	higher := *rec.ToClient()
+	higher.TotalGB += pagingGB
+	if _, err := svc.Update(inboundSvc, scope, rec.Id, higher, 0); err == nil || !strings.Contains(err.Error(), "traffic allowance") {
+		t.Fatalf("update err = %v, want the traffic allowance", err)
+	}
+	res, _, err := svc.BulkAdjust(inboundSvc, scope, []string{"new1@x"}, 0, pagingGB, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Adjusted != 0 || len(res.Skipped) != 1 || !strings.Contains(res.Skipped[0].Reason, "traffic allowance") {
+		t.Fatalf("bulk adjust = %+v, want new1@x skipped over the allowance", res)
+	}
+	usage, err := svc.Usage(scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Clients != 3 || usage.TotalGB != 25*pagingGB {
+		t.Fatalf("usage = %+v, want the rejected writes rolled back", usage)
+	}
+}
+
+func TestCheckUpdateOnlyHoldsChangedLimits(t *testing.T) {
+	setupPagingServices(t)
+	scope := seedScopedClients(t)
+	svc := &ClientService{}
+	// bravo and golf already hold 20 GB, over this cap.
+	scope.MaxTrafficGB = 15
+	scope.MaxExpiryDays = 7
+
+	bravo, err := svc.GetRecordByEmail(nil, "bravo@x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unchanged := *bravo.ToClient()
+	unchanged.Comment = "renamed"
+	if err := svc.CheckUpdate(scope, "bravo@x", unchanged); err != nil {
+		t.Fatalf("an edit that leaves the limits alone was rejected: %v", err)
+	}
+	lower := unchanged
+	lower.TotalGB = 2 * pagingGB
+	if err := svc.CheckUpdate(scope, "bravo@x", lower); err != nil {
+		t.Fatalf("lowering the quota was rejected: %v", err)
+	}
+	higher := unchanged
+	higher.TotalGB = 11 * pagingGB
+	if err := svc.CheckUpdate(scope, "bravo@x", higher); err == nil {
+		t.Fatal("raising the quota past the allowance was accepted")
+	}
+	if err := svc.CheckUpdate(scope, "alpha@x", unchanged); err != errClientOutOfScope {
+		t.Fatalf("editing a foreign client: err = %v", err)
+	}
+}
+
+func TestScopeInboundsDropsForeignClients(t *testing.T) {
+	setupPagingServices(t)
+	scope := seedScopedClients(t)
+	inbounds := []*model.Inbound{
+		{
+			Id:       1,
+			Settings: `{"clients":[{"email":"alpha@x"},{"email":"bravo@x"}],"decryption":"none"}`,
+			ClientStats: []xray.ClientTraffic{
+				{Email: "alpha@x"},
+				{Email: "bravo@x"},
+			},
+		},
+		{Id: 2, Settings: `{"clients":[{"email":"bravo@x"}]}`},
+	}
+	scoped, err := (&ClientService{}).ScopeInbounds(scope, inbounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scoped) != 1 || scoped[0].Id != 1 {
+		t.Fatalf("scoped inbounds = %d, want only the granted inbound", len(scoped))
+	}
+	if strings.Contains(scoped[0].Settings, "alpha@x") || !strings.Contains(scoped[0].Settings, "bravo@x") || !strings.Contains(scoped[0].Settings, "decryption") {
+		t.Fatalf("settings = %s, want only the owned client and the rest untouched", scoped[0].Settings)
+	}
+	if len(scoped[0].ClientStats) != 1 || scoped[0].ClientStats[0].Email != "bravo@x" {
+		t.Fatalf("client stats = %+v, want only bravo@x", scoped[0].ClientStats)
+	}
+}
+
+func TestCheckUpdateKeepsResellersOffEgress(t *testing.T) {
+	setupPagingServices(t)
+	scope := seedScopedClients(t)
+	svc := &ClientService{}
+	if err := svc.CreateGroup("premium"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetGroupEgress("premium", "warp"); err != nil {
+		t.Fatal(err)
+	}
+
+	bravo, err := svc.GetRecordByEmail(nil, "bravo@x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned := *bravo.ToClient()
+	pinned.Egress = "warp"
+	if err := svc.CheckUpdate(scope, "bravo@x", pinned); err != errEgressOutOfScope {
+		t.Fatalf("reseller set an egress: err = %v", err)
+	}
+	regrouped := *bravo.ToClient()
+	regrouped.Group = "premium"
+	if err := svc.CheckUpdate(scope, "bravo@x", regrouped); err != errEgressOutOfScope {
+		t.Fatalf("reseller moved a client into a routed group: err = %v", err)
+	}
+}
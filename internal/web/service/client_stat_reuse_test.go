@@ -40,7 +40,7 @@ func TestAddClientStat_RefreshesStaleRowOnInboundDeleteThenReuse(t *testing.T) {
 		t.Fatalf("initial Create: %v", err)
 	}
 	rec0 := lookupClientRecord(t, email)
-	if _, err := svc.Update(inboundSvc, rec0.Id, model.Client{
+	if _, err := svc.Update(inboundSvc, nil, rec0.Id, model.Client{
 		Email: email, SubID: subID, Enable: false,
 		TotalGB: 0, ExpiryTime: 1000, Reset: 0,
 	}, 0); err != nil {
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	trafficHistoryRollupBatch = 5000
+	trafficHistoryMaxPoints   = 1000
+)
+
+// TrafficHistoryPoint is the traffic of one bucket summed over every source;
+// T is the bucket start in unix seconds.
+type TrafficHistoryPoint struct {
+	T    int64 `json:"t" example:"1735689600"`
+	Up   int64 `json:"up" example:"1048576"`
+	Down int64 `json:"down" example:"2097152"`
+}
+
+// TrafficHistoryService reads and compacts the per-client traffic history the
+// traffic writer records on every local and node poll.
+type TrafficHistoryService struct {
+	settingService SettingService
+}
+
+// recordClientTrafficHistory runs in a savepoint so a failed history write
+// never rolls back the traffic it describes.
+func recordClientTrafficHistory(tx *gorm.DB, nodeID int, deltas map[string]nodeTrafficCounter) {
+	if len(deltas) == 0 {
+		return
+	}
+	bucket := time.Now().Unix() / model.TrafficHistoryHourly * model.TrafficHistoryHourly
+	rows := make([]model.ClientTrafficHistory, 0, len(deltas))
+	// Sorted so concurrent writers take the unique-index locks in one order.
+	for _, email := range slices.Sorted(maps.Keys(deltas)) {
+		d := deltas[email]
+		rows = append(rows, model.ClientTrafficHistory{
+			Email: email, NodeId: nodeID, Resolution: model.TrafficHistoryHourly,
+			Bucket: bucket, Up: d.Up, Down: d.Down,
+		})
+	}
+	if err := tx.Transaction(func(tx *gorm.DB) error {
+		return upsertTrafficHistory(tx, rows)
+	}); err != nil {
+		logger.Warning("record client traffic history:", err)
+	}
+}
+
+func upsertTrafficHistory(tx *gorm.DB, rows []model.ClientTrafficHistory) error {
+	for start := 0; start < len(rows); start += 200 {
+		batch := rows[start:min(start+200, len(rows))]
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "email"}, {Name: "node_id"}, {Name: "resolution"}, {Name: "bucket"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"up":   gorm.Expr("client_traffic_history.up + excluded.up"),
+				"down": gorm.Expr("client_traffic_history.down + excluded.down"),
+			}),
+		}).Create(&batch).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameClientTrafficHistory moves a client's history to its new email,
+// dropping rows a deleted client left behind under that email.
+func renameClientTrafficHistory(tx *gorm.DB, oldEmail, newEmail string) error {
+	if oldEmail == newEmail {
+		return nil
+	}
+	if err := tx.Where("email = ?", newEmail).Delete(&model.ClientTrafficHistory{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.ClientTrafficHistory{}).Where("email = ?", oldEmail).Update("email", newEmail).Error
+}
+
+func deleteClientTrafficHistory(tx *gorm.DB, emails ...string) error {
+	for _, batch := range chunkStrings(emails, sqlInChunk) {
+		if err := tx.Where("email IN ?", batch).Delete(&model.ClientTrafficHistory{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TrafficHistoryService) location() *time.Location {
+	loc, err := s.settingService.GetTimeLocation()
+	if err != nil || loc == nil {
+		return time.Local
+	}
+	return loc
+}
+
+func dayStart(unix int64, loc *time.Location) int64 {
+	t := time.Unix(unix, 0).In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Unix()
+}
+
+// History zero-fills empty buckets, oldest first. Daily buckets also fold in
+// the hourly rows that have not been rolled up yet.
+func (s *TrafficHistoryService) History(emails []string, resolution, count int) ([]TrafficHistoryPoint, error) {
+	if count <= 0 {
+		count = 30
+	}
+	count = min(count, trafficHistoryMaxPoints)
+	loc := s.location()
+	now := time.Now()
+
+	starts := make([]int64, count)
+	if resolution == model.TrafficHistoryDaily {
+		today := time.Unix(dayStart(now.Unix(), loc), 0).In(loc)
+		for i := range starts {
+			starts[i] = today.AddDate(0, 0, i-count+1).Unix()
+		}
+	} else {
+		resolution = model.TrafficHistoryHourly
+		hour := now.Unix() / model.TrafficHistoryHourly * model.TrafficHistoryHourly
+		for i := range starts {
+			starts[i] = hour - int64(count-1-i)*model.TrafficHistoryHourly
+		}
+	}
+	points := make([]TrafficHistoryPoint, count)
+	index := make(map[int64]int, count)
+	for i, t := range starts {
+		points[i].T = t
+		index[t] = i
+	}
+	if len(emails) == 0 {
+		return points, nil
+	}
+
+	var rows []model.ClientTrafficHistory
+	for _, batch := range chunkStrings(emails, sqlInChunk) {
+		var page []model.ClientTrafficHistory
+		q := database.GetDB().Where("email IN ? AND bucket >= ?", batch, starts[0])
+		if resolution == model.TrafficHistoryHourly {
+			q = q.Where("resolution = ?", model.TrafficHistoryHourly)
+		}
+		if err := q.Find(&page).Error; err != nil {
+			return nil, err
+		}
+		rows = append(rows, page...)
+	}
+	for _, r := range rows {
+		bucket := r.Bucket
+		if resolution == model.TrafficHistoryDaily {
+			bucket = dayStart(r.Bucket, loc)
+		}
+		if i, ok := index[bucket]; ok {
+			points[i].Up += r.Up
+			points[i].Down += r.Down
+		}
+	}
+	return points, nil
+}
+
+// Rollup folds hourly rows past the hourly retention into daily buckets and
+// drops daily rows past the daily retention. It returns the rows removed.
+func (s *TrafficHistoryService) Rollup() (int64, error) {
+	hourlyDays, err := s.settingService.GetTrafficHistoryHourlyDays()
+	if err != nil {
+		return 0, err
+	}
+	dailyDays, err := s.settingService.GetTrafficHistoryDays()
+	if err != nil {
+		return 0, err
+	}
+	loc := s.location()
+	today := time.Unix(dayStart(time.Now().Unix(), loc), 0).In(loc)
+	hourlyCutoff := today.AddDate(0, 0, -max(hourlyDays, 1)).Unix()
+
+	db := database.GetDB()
+	var removed int64
+	for {
+		var hourly []model.ClientTrafficHistory
+		if err := db.Where("resolution = ? AND bucket < ?", model.TrafficHistoryHourly, hourlyCutoff).
+			Order("id").Limit(trafficHistoryRollupBatch).Find(&hourly).Error; err != nil {
+			return removed, err
+		}
+		if len(hourly) == 0 {
+			break
+		}
+		type dayKey struct {
+			email  string
+			nodeID int
+			day    int64
+		}
+		days := make(map[dayKey]*model.ClientTrafficHistory)
+		ids := make([]int64, 0, len(hourly))
+		for _, h := range hourly {
+			ids = append(ids, h.Id)
+			k := dayKey{h.Email, h.NodeId, dayStart(h.Bucket, loc)}
+			d := days[k]
+			if d == nil {
+				d = &model.ClientTrafficHistory{Email: k.email, NodeId: k.nodeID, Resolution: model.TrafficHistoryDaily, Bucket: k.day}
+				days[k] = d
+			}
+			d.Up += h.Up
+			d.Down += h.Down
+		}
+		merged := make([]model.ClientTrafficHistory, 0, len(days))
+		for _, d := range days {
+			merged = append(merged, *d)
+		}
+		slices.SortFunc(merged, func(a, b model.ClientTrafficHistory) int {
+			return cmp.Or(strings.Compare(a.Email, b.Email), cmp.Compare(a.NodeId, b.NodeId), cmp.Compare(a.Bucket, b.Bucket))
+		})
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := upsertTrafficHistory(tx, merged); err != nil {
+				return err
+			}
+			for start := 0; start < len(ids); start += sqlInChunk {
+				res := tx.Where("id IN ?", ids[start:min(start+sqlInChunk, len(ids))]).Delete(&model.ClientTrafficHistory{})
+				if res.Error != nil {
+					return res.Error
+				}
+				removed += res.RowsAffected
+			}
+			return nil
+		}); err != nil {
+			return removed, err
+		}
+	}
+
+	if dailyDays > 0 {
+		res := db.Where("resolution = ? AND bucket < ?", model.TrafficHistoryDaily, today.AddDate(0, 0, -dailyDays).Unix()).
+			Delete(&model.ClientTrafficHistory{})
+		if res.Error != nil {
+			return removed, res.Error
+		}
+		removed += res.RowsAffected
+	}
+	return removed, nil
+}
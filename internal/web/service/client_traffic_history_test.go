@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+func TestTrafficHistoryRecordAndRollup(t *testing.T) {
+	setupBulkDB(t)
+	db := database.GetDB()
+	settings := &SettingService{}
+	if err := settings.setString("timeLocation", "UTC"); err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.setInt("trafficHistoryDays", 30); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two local polls land in one hourly row; a node keeps its own row.
+	recordClientTrafficHistory(db, 0, map[string]nodeTrafficCounter{"alice": {Up: 10, Down: 20}})
+	recordClientTrafficHistory(db, 0, map[string]nodeTrafficCounter{"alice": {Up: 10, Down: 20}})
+	recordClientTrafficHistory(db, 3, map[string]nodeTrafficCounter{"alice": {Up: 5, Down: 5}})
+
+	svc := &TrafficHistoryService{}
+	hourly, err := svc.History([]string{"alice"}, model.TrafficHistoryHourly, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly) != 3 {
+		t.Fatalf("want 3 hourly points, got %d", len(hourly))
+	}
+	if last := hourly[2]; last.Up != 25 || last.Down != 45 {
+		t.Fatalf("current hour should sum local and node traffic, got %+v", last)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	aged := today.AddDate(0, 0, -10).Unix()
+	rows := []model.ClientTrafficHistory{
+		{Email: "alice", NodeId: 0, Resolution: model.TrafficHistoryHourly, Bucket: aged + 3600, Up: 1, Down: 2},
+		{Email: "alice", NodeId: 0, Resolution: model.TrafficHistoryHourly, Bucket: aged + 7200, Up: 3, Down: 4},
+		{Email: "alice", NodeId: 3, Resolution: model.TrafficHistoryHourly, Bucket: aged + 3600, Up: 100, Down: 100},
+		{Email: "alice", NodeId: 0, Resolution: model.TrafficHistoryDaily, Bucket: today.AddDate(0, 0, -40).Unix(), Up: 9, Down: 9},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := svc.Rollup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 4 {
+		t.Fatalf("want 3 aged hourly rows and 1 expired daily row removed, got %d", removed)
+	}
+	var daily []model.ClientTrafficHistory
+	db.Where("resolution = ?", model.TrafficHistoryDaily).Order("node_id").Find(&daily)
+	if len(daily) != 2 || daily[0].Bucket != aged || daily[0].Up != 4 || daily[0].Down != 6 || daily[1].Up != 100 {
+		t.Fatalf("aged hours should fold into one daily row per node, got %+v", daily)
+	}
+
+	points, err := svc.History([]string{"alice"}, model.TrafficHistoryDaily, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := points[19]; p.T != aged || p.Up != 104 || p.Down != 106 {
+		t.Fatalf("rolled-up day = %+v", p)
+	}
+	if p := points[29]; p.Up != 25 || p.Down != 45 {
+		t.Fatalf("today should include hourly rows not yet rolled up, got %+v", p)
+	}
+}
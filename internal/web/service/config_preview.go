@@ -0,0 +1,925 @@
+package service
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/textdiff"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+
+	"gorm.io/gorm"
+)
+
+// ConfigPreview is the outcome of a dry-run mutation: the config each affected
+// runtime would end up with, as a diff against what it is generated as now.
+type ConfigPreview struct {
+	Runtimes []RuntimePreview `json:"runtimes"`
+}
+
+// RuntimePreview covers the whole generated config for the local panel, and
+// only the inbounds pushed to it for a node.
+type RuntimePreview struct {
+	Runtime          string   `json:"runtime" example:"local"`
+	NodeId           int      `json:"nodeId,omitempty" example:"0"`
+	Restart          bool     `json:"restart" example:"false"`
+	Diff             string   `json:"diff" example:"--- current\n+++ proposed\n@@ -12,3 +12,7 @@\n"`
+	AddedInbounds    []string `json:"addedInbounds,omitempty"`
+	RemovedInbounds  []string `json:"removedInbounds,omitempty"`
+	AddedUsers       []string `json:"addedUsers,omitempty" example:"inbound-443/alice"`
+	RemovedUsers     []string `json:"removedUsers,omitempty"`
+	AddedOutbounds   []string `json:"addedOutbounds,omitempty"`
+	RemovedOutbounds []string `json:"removedOutbounds,omitempty"`
+	RoutingChanged   bool     `json:"routingChanged,omitempty" example:"false"`
+}
+
+// configOverlay holds the rows a pending mutation would write; the nil overlay
+// reads the stored state unchanged.
+type configOverlay struct {
+	template *string
+	inbounds map[int]*model.Inbound // a nil value deletes the inbound
+	added    *model.Inbound
+	clients  map[int][]model.Client         // keyed by inbound id, 0 for added
+	records  map[string]*model.ClientRecord // keyed by stored email; nil deletes
+}
+
+func (ov *configOverlay) xrayTemplate(ss *SettingService) (string, error) {
+	if ov != nil && ov.template != nil {
+		return *ov.template, nil
+	}
+	return ss.GetXrayConfigTemplate()
+}
+
+func (ov *configOverlay) setTemplate(template string) {
+	ov.template = &template
+}
+
+func (ov *configOverlay) setInbound(ib *model.Inbound) {
+	if ov.inbounds == nil {
+		ov.inbounds = map[int]*model.Inbound{}
+	}
+	ov.inbounds[ib.Id] = ib
+}
+
+func (ov *configOverlay) setClients(inboundId int, clients []model.Client) {
+	if ov.clients == nil {
+		ov.clients = map[int][]model.Client{}
+	}
+	ov.clients[inboundId] = clients
+}
+
+// setRecord replaces the clients row stored under email, or adds one when
+// there is none; a nil rec deletes it.
+func (ov *configOverlay) setRecord(email string, rec *model.ClientRecord) {
+	if ov.records == nil {
+		ov.records = map[string]*model.ClientRecord{}
+	}
+	ov.records[email] = rec
+}
+
+// applyRecords lays the pending client rows over rows read from the clients
+// table.
+func (ov *configOverlay) applyRecords(rows []model.ClientRecord) []model.ClientRecord {
+	if ov == nil || len(ov.records) == 0 {
+		return rows
+	}
+	out := make([]model.ClientRecord, 0, len(rows)+len(ov.records))
+	for _, r := range rows {
+		if _, ok := ov.records[r.Email]; !ok {
+			out = append(out, r)
+		}
+	}
+	for _, rec := range ov.records {
+		if rec != nil {
+			out = append(out, *rec)
+		}
+	}
+	return out
+}
+
+// applyEnable lays the enable flag of the pending client rows over the one
+// read from their traffic rows, which Create and Update write alongside.
+func (ov *configOverlay) applyEnable(enable map[string]bool) {
+	if ov == nil {
+		return
+	}
+	for email, rec := range ov.records {
+		delete(enable, email)
+		if rec != nil {
+			enable[rec.Email] = rec.Enable
+		}
+	}
+}
+
+// pausedClients evaluates pending client rows afresh; every other client keeps
+// the last schedule check, so both sides of a preview agree on it.
+func (ov *configOverlay) pausedClients() map[string]struct{} {
+	paused := accessPaused()
+	if ov == nil || len(ov.records) == 0 {
+		return paused
+	}
+	fresh, err := pausedClientsWith(database.GetDB(), scheduleNow(), ov)
+	if err != nil {
+		logger.Warning("preview: evaluate access schedules failed:", err)
+		return paused
+	}
+	out := make(map[string]struct{}, len(paused)+len(ov.records))
+	maps.Copy(out, paused)
+	for email, rec := range ov.records {
+		delete(out, email)
+		if rec == nil {
+			continue
+		}
+		delete(out, rec.Email)
+		if _, ok := fresh[rec.Email]; ok {
+			out[rec.Email] = struct{}{}
+		}
+	}
+	return out
+}
+
+// egressRoutes returns the client egress routes with the pending client rows
+// applied.
+func (ov *configOverlay) egressRoutes() ([]ClientEgressRoute, error) {
+	return egressRoutes(database.GetDB(), ov)
+}
+
+func (ov *configOverlay) applyInbounds(inbounds []*model.Inbound) []*model.Inbound {
+	if ov == nil {
+		return inbounds
+	}
+	out := make([]*model.Inbound, 0, len(inbounds)+1)
+	for _, ib := range inbounds {
+		next, ok := ov.inbounds[ib.Id]
+		switch {
+		case !ok:
+			out = append(out, ib)
+		case next != nil:
+			// Keep the stored traffic rows; they decide which users are live.
+			cp := *next
+			cp.ClientStats = ib.ClientStats
+			out = append(out, &cp)
+		}
+	}
+	if ov.added != nil {
+		out = append(out, ov.added)
+	}
+	return out
+}
+
+func (ov *configOverlay) clientsFor(cs *ClientService, inboundId int) ([]model.Client, error) {
+	if ov != nil {
+		if clients, ok := ov.clients[inboundId]; ok {
+			return clients, nil
+		}
+	}
+	return cs.ListForInbound(nil, inboundId)
+}
+
+// Preview generates the config of every runtime with ov applied and returns
+// the ones that would change.
+func (s *XrayService) Preview(ov *configOverlay) (*ConfigPreview, error) {
+	// An empty overlay, unlike nil, skips the traffic flush GetXrayConfig does.
+	current, err := s.runtimeConfigs(&configOverlay{})
+	if err != nil {
+		return nil, err
+	}
+	proposed, err := s.runtimeConfigs(ov)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[int]string{0: "local"}
+	if nodes, err := s.nodeService.GetAll(); err == nil {
+		for _, n := range nodes {
+			names[n.Id] = "node:" + n.Name
+		}
+	} else {
+		logger.Warning("preview: read nodes failed:", err)
+	}
+
+	ids := make([]int, 0, len(proposed))
+	for id := range current {
+		ids = append(ids, id)
+	}
+	for id := range proposed {
+		if _, ok := current[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	out := &ConfigPreview{Runtimes: []RuntimePreview{}}
+	for _, id := range ids {
+		oldCfg, newCfg := current[id], proposed[id]
+		if oldCfg == nil {
+			oldCfg = &xray.Config{}
+		}
+		if newCfg == nil {
+			newCfg = &xray.Config{}
+		}
+		rp, err := previewRuntime(oldCfg, newCfg, id != 0)
+		if err != nil {
+			return nil, err
+		}
+		if rp == nil {
+			continue
+		}
+		rp.Runtime = names[id]
+		rp.NodeId = id
+		out.Runtimes = append(out.Runtimes, *rp)
+	}
+	return out, nil
+}
+
+// runtimeConfigs generates the local config (key 0) and, per node, a config
+// holding only the inbounds that node runs.
+func (s *XrayService) runtimeConfigs(ov *configOverlay) (map[int]*xray.Config, error) {
+	local, err := s.buildXrayConfig(ov)
+	if err != nil {
+		return nil, err
+	}
+	out := map[int]*xray.Config{0: local}
+
+	inbounds, err := s.inboundService.GetAllInbounds()
+	if err != nil {
+		return nil, err
+	}
+	for _, inbound := range ov.applyInbounds(inbounds) {
+		if inbound.NodeID == nil || !inbound.Enable || inbound.Protocol == model.MTProto {
+			continue
+		}
+		cfg := out[*inbound.NodeID]
+		if cfg == nil {
+			cfg = &xray.Config{}
+			out[*inbound.NodeID] = cfg
+		}
+		inboundConfig, err := s.genInboundConfig(inbound, ov)
+		if err != nil {
+			return nil, err
+		}
+		cfg.InboundConfigs = append(cfg.InboundConfigs, *inboundConfig)
+	}
+	return out, nil
+}
+
+// renderRuntime is the text a runtime's preview diffs: the whole config, or
+// only its inbounds for a node.
+func renderRuntime(cfg *xray.Config, inboundsOnly bool) (string, error) {
+	var v any = cfg
+	if inboundsOnly {
+		v = cfg.InboundConfigs
+	}
+	bs, err := json.MarshalIndent(v, "", "  ")
+	return string(bs) + "\n", err
+}
+
+// previewRuntime diffs two configs of one runtime, or returns nil when they
+// render the same.
+func previewRuntime(oldCfg, newCfg *xray.Config, inboundsOnly bool) (*RuntimePreview, error) {
+	before, err := renderRuntime(oldCfg, inboundsOnly)
+	if err != nil {
+		return nil, err
+	}
+	after, err := renderRuntime(newCfg, inboundsOnly)
+	if err != nil {
+		return nil, err
+	}
+	diff := textdiff.Unified("current", "proposed", before, after, 3)
+	if diff == "" {
+		return nil, nil
+	}
+
+	rp := &RuntimePreview{Diff: diff}
+	hot, ok := xray.ComputeHotDiff(oldCfg, newCfg)
+	if !ok {
+		rp.Restart = true
+		return rp, nil
+	}
+	rp.AddedInbounds = rawTags(hot.AddedInbounds)
+	rp.RemovedInbounds = hot.RemovedInboundTags
+	rp.AddedOutbounds = rawTags(hot.AddedOutbounds)
+	rp.RemovedOutbounds = hot.RemovedOutboundTags
+	rp.AddedUsers = userOpNames(hot.AddedUsers)
+	rp.RemovedUsers = userOpNames(hot.RemovedUsers)
+	rp.RoutingChanged = hot.RoutingConfig != nil
+	return rp, nil
+}
+
+func rawTags(raws [][]byte) []string {
+	var tags []string
+	for _, raw := range raws {
+		var tagged struct {
+			Tag string `json:"tag"`
+		}
+		_ = json.Unmarshal(raw, &tagged)
+		tags = append(tags, tagged.Tag)
+	}
+	return tags
+}
+
+func userOpNames(ops []xray.UserOp) []string {
+	var names []string
+	for _, op := range ops {
+		names = append(names, op.Tag+"/"+op.Email)
+	}
+	return names
+}
+
+// PreviewAddInbound reports the config change AddInbound would make.
+func (s *InboundService) PreviewAddInbound(inbound *model.Inbound) (*ConfigPreview, error) {
+	clients, err := s.prepareNewInbound(inbound)
+	if err != nil {
+		return nil, err
+	}
+	conflict, err := checkPortConflictTx(database.GetDB(), inbound, 0)
+	if err != nil {
+		return nil, err
+	}
+	if conflict != nil {
+		return nil, common.NewError(conflict.String())
+	}
+	ov := &configOverlay{added: inbound}
+	ov.setClients(0, clients)
+	return (&XrayService{}).Preview(ov)
+}
+
+// PreviewUpdateInbound reports the config change UpdateInbound would make.
+func (s *InboundService) PreviewUpdateInbound(inbound *model.Inbound) (*ConfigPreview, error) {
+	old, err := s.prepareInboundUpdate(inbound)
+	if err != nil {
+		return nil, err
+	}
+	conflict, err := checkPortConflictTx(database.GetDB(), inbound, inbound.Id)
+	if err != nil {
+		return nil, err
+	}
+	if conflict != nil {
+		return nil, common.NewError(conflict.String())
+	}
+	stored, err := s.clientService.ListForInbound(nil, old.Id)
+	if err != nil {
+		return nil, err
+	}
+	oldTag := old.Tag
+	if err := s.applyInboundEdit(database.GetDB(), old, inbound, false); err != nil {
+		return nil, err
+	}
+	clients, err := s.GetClients(old)
+	if err != nil {
+		return nil, err
+	}
+
+	ov := &configOverlay{}
+	ov.setInbound(old)
+	ov.setClients(old.Id, orderClientsLike(stored, clients))
+	if oldTag != old.Tag {
+		if err := ov.rewriteTemplate(func(t string) (string, bool, error) {
+			return renameInboundTagInTemplate(t, oldTag, old.Tag)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// PreviewDelInbound reports the config change DelInbound would make.
+func (s *InboundService) PreviewDelInbound(id int) (*ConfigPreview, error) {
+	ib, err := s.GetInbound(id)
+	if err != nil {
+		return nil, err
+	}
+	ov := &configOverlay{inbounds: map[int]*model.Inbound{id: nil}}
+	if err := ov.rewriteTemplate(func(t string) (string, bool, error) {
+		return removeInboundTagFromTemplate(t, ib.Tag)
+	}); err != nil {
+		return nil, err
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// rewriteTemplate overrides the template with the routing sync an inbound
+// rename or delete runs after commit, stored the way SaveXraySetting would.
+func (ov *configOverlay) rewriteTemplate(rewrite func(string) (string, bool, error)) error {
+	xs := &XraySettingService{}
+	template, err := xs.GetXrayConfigTemplate()
+	if err != nil {
+		return err
+	}
+	updated, changed, err := rewrite(template)
+	if err != nil || !changed {
+		return err
+	}
+	// A rewrite that fails validation is logged and skipped by the real sync.
+	if prepared, err := xs.prepareXrayTemplate(updated); err == nil {
+		ov.setTemplate(prepared)
+	}
+	return nil
+}
+
+// orderClientsLike sorts next into the order of the stored clients, which is
+// the order the config lists them in, with new ones at the end.
+func orderClientsLike(stored, next []model.Client) []model.Client {
+	pos := make(map[string]int, len(stored))
+	for i, c := range stored {
+		pos[c.Email] = i
+	}
+	out := slices.Clone(next)
+	slices.SortStableFunc(out, func(a, b model.Client) int {
+		pa, okA := pos[a.Email]
+		pb, okB := pos[b.Email]
+		switch {
+		case okA && okB:
+			return pa - pb
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return 0
+	})
+	return out
+}
+
+// PreviewXraySetting reports the config change saving template would make.
+func (s *XraySettingService) PreviewXraySetting(template string) (*ConfigPreview, error) {
+	prepared, err := s.prepareXrayTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	ov := &configOverlay{}
+	ov.setTemplate(prepared)
+	return (&XrayService{}).Preview(ov)
+}
+
+// PreviewCreate reports the config change Create would make.
+func (s *ClientService) PreviewCreate(inboundSvc *InboundService, payload *ClientCreatePayload) (*ConfigPreview, error) {
+	client, err := s.prepareClientCreate(payload)
+	if err != nil {
+		return nil, err
+	}
+	ov := &configOverlay{}
+	ov.setRecord(client.Email, client.ToRecord())
+	for _, ibId := range payload.InboundIds {
+		inbound, err := inboundSvc.GetInbound(ibId)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.fillProtocolDefaults(&client, inbound); err != nil {
+			return nil, err
+		}
+		clients, err := s.ListForInbound(nil, ibId)
+		if err != nil {
+			return nil, err
+		}
+		ov.setClients(ibId, append(clients, clientWithInboundFlow(client, inbound)))
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// PreviewUpdateByEmail reports the config change UpdateByEmail would make.
+func (s *ClientService) PreviewUpdateByEmail(inboundSvc *InboundService, email string, updated model.Client, inboundFilter ...int) (*ConfigPreview, error) {
+	rec, err := s.GetRecordByEmail(nil, email)
+	if err != nil {
+		return nil, err
+	}
+	existing, inboundIds, err := s.prepareClientUpdate(rec.Id, &updated, inboundFilter)
+	if err != nil {
+		return nil, err
+	}
+	// The group, egress, schedule and enable flag are written to the clients
+	// row whichever inbounds the update is limited to.
+	ov := &configOverlay{}
+	ov.setRecord(existing.Email, updated.ToRecord())
+	for _, ibId := range inboundIds {
+		inbound, err := inboundSvc.GetInbound(ibId)
+		if err != nil {
+			continue
+		}
+		if err := s.fillProtocolDefaults(&updated, inbound); err != nil {
+			return nil, err
+		}
+		clients, err := s.ListForInbound(nil, ibId)
+		if err != nil {
+			return nil, err
+		}
+		for i := range clients {
+			if clients[i].Email == existing.Email {
+				clients[i] = clientWithInboundFlow(updated, inbound)
+			}
+		}
+		ov.setClients(ibId, clients)
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// PreviewDeleteByEmail reports the config change DeleteByEmail would make.
+func (s *ClientService) PreviewDeleteByEmail(email string) (*ConfigPreview, error) {
+	existing, err := s.GetRecordByEmail(nil, email)
+	if err != nil {
+		return nil, err
+	}
+	inboundIds, err := s.GetInboundIdsForRecord(existing.Id)
+	if err != nil {
+		return nil, err
+	}
+	ov := &configOverlay{}
+	ov.setRecord(existing.Email, nil)
+	for _, ibId := range inboundIds {
+		clients, err := s.ListForInbound(nil, ibId)
+		if err != nil {
+			return nil, err
+		}
+		ov.setClients(ibId, slices.DeleteFunc(clients, func(c model.Client) bool {
+			return c.Email == existing.Email
+		}))
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// inboundClients returns a copy of the clients ov puts on inboundId, for a
+// preview to edit and set back.
+func (ov *configOverlay) inboundClients(cs *ClientService, inboundId int) ([]model.Client, error) {
+	clients, err := ov.clientsFor(cs, inboundId)
+	return slices.Clone(clients), err
+}
+
+// record returns the row ov holds pending for rec, or a copy of rec.
+func (ov *configOverlay) record(rec model.ClientRecord) *model.ClientRecord {
+	if pending := ov.records[rec.Email]; pending != nil {
+		cp := *pending
+		return &cp
+	}
+	return &rec
+}
+
+// editClients applies edit to the clients of inboundId whose email is listed,
+// dropping the ones it returns false for.
+func (ov *configOverlay) editClients(cs *ClientService, inboundId int, emails []string, edit func(*model.Client) bool) error {
+	clients, err := ov.inboundClients(cs, inboundId)
+	if err != nil {
+		return err
+	}
+	out := clients[:0]
+	for _, c := range clients {
+		if slices.Contains(emails, c.Email) && !edit(&c) {
+			continue
+		}
+		out = append(out, c)
+	}
+	ov.setClients(inboundId, out)
+	return nil
+}
+
+// sortByRecordId orders clients the way ListForInbound lists them, by the id
+// of their clients row, with the ones that have no row yet last.
+func sortByRecordId(clients []model.Client) error {
+	emails := make([]string, 0, len(clients))
+	for _, c := range clients {
+		emails = append(emails, c.Email)
+	}
+	ids := make(map[string]int, len(clients))
+	for _, batch := range chunkStrings(emails, sqlInChunk) {
+		var rows []model.ClientRecord
+		if err := database.GetDB().Select("id", "email").Where("email IN ?", batch).Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, r := range rows {
+			ids[r.Email] = r.Id
+		}
+	}
+	slices.SortStableFunc(clients, func(a, b model.Client) int {
+		ia, okA := ids[a.Email]
+		ib, okB := ids[b.Email]
+		switch {
+		case okA && okB:
+			return ia - ib
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return 0
+	})
+	return nil
+}
+
+// recordsByEmails reads the client rows stored under emails, trimmed and
+// deduplicated the way the bulk operations read them.
+func recordsByEmails(emails []string) ([]model.ClientRecord, error) {
+	seen := map[string]struct{}{}
+	clean := make([]string, 0, len(emails))
+	for _, e := range emails {
+		e = strings.TrimSpace(e)
+		if _, ok := seen[e]; ok || e == "" {
+			continue
+		}
+		seen[e] = struct{}{}
+		clean = append(clean, e)
+	}
+	var records []model.ClientRecord
+	for _, batch := range chunkStrings(clean, sqlInChunk) {
+		var rows []model.ClientRecord
+		if err := database.GetDB().Where("email IN ?", batch).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		records = append(records, rows...)
+	}
+	return records, nil
+}
+
+// linkedEmails maps each inbound to the emails of the records attached to it.
+func linkedEmails(records []model.ClientRecord) (map[int][]string, error) {
+	emailById := make(map[int]string, len(records))
+	ids := make([]int, 0, len(records))
+	for _, r := range records {
+		emailById[r.Id] = r.Email
+		ids = append(ids, r.Id)
+	}
+	out := map[int][]string{}
+	for _, batch := range chunkInts(ids, sqlInChunk) {
+		var rows []model.ClientInbound
+		if err := database.GetDB().Where("client_id IN ?", batch).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range rows {
+			out[m.InboundId] = append(out[m.InboundId], emailById[m.ClientId])
+		}
+	}
+	return out, nil
+}
+
+// PreviewBulkCreate reports the config change BulkCreate would make.
+func (s *ClientService) PreviewBulkCreate(inboundSvc *InboundService, payloads []ClientCreatePayload) (*ConfigPreview, error) {
+	ov := &configOverlay{}
+	plan, err := s.planBulkCreate(inboundSvc, payloads, func(string, string) {})
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil {
+		for _, ibId := range plan.inboundOrder {
+			clients, err := ov.inboundClients(s, ibId)
+			if err != nil {
+				return nil, err
+			}
+			clients = append(clients, plan.byInbound[ibId]...)
+			if err := sortByRecordId(clients); err != nil {
+				return nil, err
+			}
+			ov.setClients(ibId, clients)
+		}
+		for idx, item := range plan.prep {
+			if !plan.failed[idx] {
+				ov.setRecord(item.client.Email, item.client.ToRecord())
+			}
+		}
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// PreviewBulkDelete reports the config change BulkDelete would make.
+func (s *ClientService) PreviewBulkDelete(emails []string) (*ConfigPreview, error) {
+	records, err := recordsByEmails(emails)
+	if err != nil {
+		return nil, err
+	}
+	links, err := linkedEmails(records)
+	if err != nil {
+		return nil, err
+	}
+	ov := &configOverlay{}
+	for ibId, ibEmails := range links {
+		if err := ov.editClients(s, ibId, ibEmails, func(*model.Client) bool { return false }); err != nil {
+			return nil, err
+		}
+	}
+	for _, rec := range records {
+		ov.setRecord(rec.Email, nil)
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// PreviewBulkSetEnable reports the config change BulkSetEnable would make.
+func (s *ClientService) PreviewBulkSetEnable(emails []string, enable bool) (*ConfigPreview, error) {
+	records, err := recordsByEmails(emails)
+	if err != nil {
+		return nil, err
+	}
+	ov := &configOverlay{}
+	if err := s.overlayEnable(ov, records, enable); err != nil {
+		return nil, err
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// overlayEnable lays the enable flag BulkSetEnable writes for records over ov.
+func (s *ClientService) overlayEnable(ov *configOverlay, records []model.ClientRecord, enable bool) error {
+	links, err := linkedEmails(records)
+	if err != nil {
+		return err
+	}
+	for ibId, ibEmails := range links {
+		if err := ov.editClients(s, ibId, ibEmails, func(c *model.Client) bool {
+			c.Enable = enable
+			return true
+		}); err != nil {
+			return err
+		}
+	}
+	for _, rec := range records {
+		next := ov.record(rec)
+		next.Enable = enable
+		ov.setRecord(rec.Email, next)
+	}
+	return nil
+}
+
+// PreviewBulkAttach reports the config change BulkAttach would make.
+func (s *ClientService) PreviewBulkAttach(inboundSvc *InboundService, emails []string, inboundIds []int) (*ConfigPreview, error) {
+	var records []*model.ClientRecord
+	seen := map[string]struct{}{}
+	for _, email := range emails {
+		key := strings.ToLower(email)
+		if _, ok := seen[key]; ok || email == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		if rec, err := s.GetRecordByEmail(nil, email); err == nil {
+			records = append(records, rec)
+		}
+	}
+
+	ov := &configOverlay{}
+	for _, ibId := range inboundIds {
+		inbound, err := inboundSvc.GetInbound(ibId)
+		if err != nil {
+			continue
+		}
+		clients, err := ov.inboundClients(s, ibId)
+		if err != nil {
+			return nil, err
+		}
+		added := false
+		for _, rec := range records {
+			if slices.ContainsFunc(clients, func(c model.Client) bool { return strings.EqualFold(c.Email, rec.Email) }) {
+				continue
+			}
+			client := *rec.ToClient()
+			if err := s.fillProtocolDefaults(&client, inbound); err != nil {
+				continue
+			}
+			clients = append(clients, clientWithInboundFlow(client, inbound))
+			added = true
+		}
+		if !added {
+			continue
+		}
+		if err := sortByRecordId(clients); err != nil {
+			return nil, err
+		}
+		ov.setClients(ibId, clients)
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// PreviewBulkDetach reports the config change BulkDetach would make.
+func (s *ClientService) PreviewBulkDetach(emails []string, inboundIds []int) (*ConfigPreview, error) {
+	var records []model.ClientRecord
+	seen := map[string]struct{}{}
+	for _, email := range emails {
+		key := strings.ToLower(email)
+		if _, ok := seen[key]; ok || email == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		if rec, err := s.GetRecordByEmail(nil, email); err == nil {
+			records = append(records, *rec)
+		}
+	}
+	links, err := linkedEmails(records)
+	if err != nil {
+		return nil, err
+	}
+	ov := &configOverlay{}
+	for _, ibId := range inboundIds {
+		if ibEmails, ok := links[ibId]; ok {
+			if err := ov.editClients(s, ibId, ibEmails, func(*model.Client) bool { return false }); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// PreviewBulkAdjust reports the config change BulkAdjust would make: the flow
+// directive, and the depleted clients the new limits enable again.
+func (s *ClientService) PreviewBulkAdjust(inboundSvc *InboundService, emails []string, addDays int, addBytes int64, flow string) (*ConfigPreview, error) {
+	flow = normalizeBulkFlow(flow)
+	adjustFlow := flow != ""
+	if addDays == 0 && addBytes == 0 && !adjustFlow {
+		return nil, common.NewError("no adjustment specified")
+	}
+	db := database.GetDB()
+	plan, _, err := planBulkAdjust(db, emails, addDays, addBytes, adjustFlow)
+	if err != nil {
+		return nil, err
+	}
+
+	ov := &configOverlay{}
+	if adjustFlow {
+		records := make([]model.ClientRecord, 0, len(plan))
+		for _, entry := range plan {
+			records = append(records, *entry.record)
+		}
+		links, err := linkedEmails(records)
+		if err != nil {
+			return nil, err
+		}
+		want := flow
+		if flow == bulkFlowClear {
+			want = ""
+		}
+		for ibId, ibEmails := range links {
+			inbound, err := inboundSvc.GetInbound(ibId)
+			if err != nil || !bulkFlowEligible(inbound, flow) {
+				continue
+			}
+			if err := ov.editClients(s, ibId, ibEmails, func(c *model.Client) bool {
+				c.Flow = want
+				return true
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	reEnable, err := adjustReenables(db, plan)
+	if err != nil {
+		return nil, err
+	}
+	if len(reEnable) > 0 {
+		if err := s.overlayEnable(ov, reEnable, true); err != nil {
+			return nil, err
+		}
+	}
+	return (&XrayService{}).Preview(ov)
+}
+
+// adjustReenables returns the disabled, depleted clients whose planned expiry
+// and quota put them back within their limits, which BulkAdjust enables again.
+func adjustReenables(db *gorm.DB, plan map[string]*bulkAdjustEntry) ([]model.ClientRecord, error) {
+	candidates := make([]string, 0, len(plan))
+	for email, entry := range plan {
+		if entry.applyExpiry || entry.applyTotal {
+			candidates = append(candidates, email)
+		}
+	}
+	cond, condArgs := depletedCond(db)
+	var rows []xray.ClientTraffic
+	globalUsed := map[string]int64{}
+	for _, batch := range chunkStrings(candidates, sqlInChunk) {
+		var part []xray.ClientTraffic
+		if err := db.Model(xray.ClientTraffic{}).
+			Where(cond+" AND enable = ? AND email IN ?", append(append([]any{}, condArgs...), false, batch)...).
+			Find(&part).Error; err != nil {
+			return nil, err
+		}
+		rows = append(rows, part...)
+		if cond != depletedClientsCond {
+			continue
+		}
+		var global []model.ClientGlobalTraffic
+		if err := db.Where("updated_at >= ? AND email IN ?", globalTrafficFreshSince(), batch).
+			Find(&global).Error; err != nil {
+			return nil, err
+		}
+		for _, g := range global {
+			globalUsed[g.Email] = max(globalUsed[g.Email], g.Up+g.Down)
+		}
+	}
+
+	now := time.Now().UnixMilli()
+	var out []model.ClientRecord
+	for _, ct := range rows {
+		entry := plan[ct.Email]
+		total, expiry := ct.Total, ct.ExpiryTime
+		if entry.applyTotal {
+			total = entry.newTotal
+		}
+		if entry.applyExpiry {
+			expiry = entry.newExpiry
+		}
+		depleted := (total > 0 && (ct.Up+ct.Down >= total || globalUsed[ct.Email] >= total)) ||
+			(expiry > 0 && expiry <= now)
+		if !depleted {
+			out = append(out, *entry.record)
+		}
+	}
+	return out, nil
+}
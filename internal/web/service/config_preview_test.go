@@ -0,0 +1,530 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+)
+
+func TestPreviewClientCreateIsHotAndWritesNothing(t *testing.T) {
+	setupBulkDB(t)
+	svc := &ClientService{}
+	inboundSvc := &InboundService{}
+	ib := mkInbound(t, 24001, model.VLESS, `{"clients":[],"decryption":"none"}`)
+
+	preview, err := svc.PreviewCreate(inboundSvc, &ClientCreatePayload{
+		Client:     model.Client{Email: "dry@x", ID: "dddddddd-1111-2222-3333-444444444444", Enable: true},
+		InboundIds: []int{ib.Id},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Runtimes) != 1 {
+		t.Fatalf("want only the local runtime, got %+v", preview.Runtimes)
+	}
+	rp := preview.Runtimes[0]
+	if rp.Runtime != "local" || rp.Restart {
+		t.Fatalf("runtime = %q restart = %v, want a hot local change", rp.Runtime, rp.Restart)
+	}
+	if !slices.Equal(rp.AddedUsers, []string{ib.Tag + "/dry@x"}) {
+		t.Fatalf("added users = %v", rp.AddedUsers)
+	}
+	if !strings.Contains(rp.Diff, `"email": "dry@x"`) {
+		t.Fatalf("diff does not add the client:\n%s", rp.Diff)
+	}
+	if n := countClientRecords(t); n != 0 {
+		t.Fatalf("dry run created %d client records", n)
+	}
+}
+
+func TestPreviewXraySettingClassifiesChanges(t *testing.T) {
+	setupBulkDB(t)
+	const base = `{"log":{"loglevel":"warning"},"inbounds":[],` +
+		`"outbounds":[{"tag":"direct","protocol":"freedom"}],"routing":{"rules":[]}}`
+	xs := &XraySettingService{}
+	if err := xs.SaveXraySetting(base); err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := xs.PreviewXraySetting(strings.Replace(base, "warning", "debug", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Runtimes) != 1 || !preview.Runtimes[0].Restart {
+		t.Fatalf("a log change needs a restart, got %+v", preview.Runtimes)
+	}
+	if !strings.Contains(preview.Runtimes[0].Diff, `+    "loglevel": "debug"`) {
+		t.Fatalf("diff misses the log change:\n%s", preview.Runtimes[0].Diff)
+	}
+
+	withRule := strings.Replace(base, `"rules":[]`,
+		`"rules":[{"type":"field","port":"25","outboundTag":"direct"}]`, 1)
+	preview, err = xs.PreviewXraySetting(withRule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Runtimes) != 1 || preview.Runtimes[0].Restart || !preview.Runtimes[0].RoutingChanged {
+		t.Fatalf("a routing rule applies hot, got %+v", preview.Runtimes)
+	}
+
+	if preview, err = xs.PreviewXraySetting(base); err != nil || len(preview.Runtimes) != 0 {
+		t.Fatalf("unchanged template reported %+v (%v)", preview, err)
+	}
+}
+
+// A client's egress and group live on its clients row, not in the inbound, so
+// the preview has to read them through the overlay to show the rule change.
+func TestPreviewClientEgressChanges(t *testing.T) {
+	setupBulkDB(t)
+	resetAccessGate(t)
+	svc := &ClientService{}
+	inboundSvc := &InboundService{}
+	xs := &XraySettingService{}
+	if err := xs.SaveXraySetting(`{"inbounds":[],"outbounds":[{"tag":"direct","protocol":"freedom"},` +
+		`{"tag":"warp","protocol":"freedom"},{"tag":"nord","protocol":"freedom"}],"routing":{"rules":[]}}`); err != nil {
+		t.Fatal(err)
+	}
+	source := []model.Client{
+		{Email: "alice", ID: "aaaaaaaa-0000-0000-0000-000000000051", SubID: "alice", Enable: true},
+		{Email: "bob", ID: "aaaaaaaa-0000-0000-0000-000000000052", SubID: "bob", Enable: true},
+	}
+	ib := mkInbound(t, 22051, model.VLESS, clientsSettings(t, source))
+	if err := svc.SyncInbound(nil, ib.Id, source); err != nil {
+		t.Fatalf("seed linkage: %v", err)
+	}
+	if _, err := svc.AddToGroup([]string{"bob"}, "premium"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetGroupEgress("premium", "nord"); err != nil {
+		t.Fatal(err)
+	}
+
+	routing := func(preview *ConfigPreview, err error, sign, email string) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(preview.Runtimes) != 1 || !preview.Runtimes[0].RoutingChanged {
+			t.Fatalf("want a routing change, got %+v", preview.Runtimes)
+		}
+		for line := range strings.SplitSeq(preview.Runtimes[0].Diff, "\n") {
+			if strings.HasPrefix(line, sign) && strings.Contains(line, `"`+email+`"`) {
+				return
+			}
+		}
+		t.Fatalf("diff has no %q line for %s:\n%s", sign, email, preview.Runtimes[0].Diff)
+	}
+
+	aliceRec := lookupClientRecord(t, "alice")
+	alice := aliceRec.ToClient()
+	alice.Egress = "warp"
+	preview, err := svc.PreviewUpdateByEmail(inboundSvc, "alice", *alice)
+	routing(preview, err, "+", "alice")
+
+	alice.Egress = ""
+	alice.Group = "premium"
+	preview, err = svc.PreviewUpdateByEmail(inboundSvc, "alice", *alice)
+	routing(preview, err, "+", "alice")
+
+	preview, err = svc.PreviewDeleteByEmail("bob")
+	routing(preview, err, "-", "bob")
+
+	preview, err = svc.PreviewCreate(inboundSvc, &ClientCreatePayload{
+		Client:     model.Client{Email: "carol", ID: "aaaaaaaa-0000-0000-0000-000000000053", Egress: "warp", Enable: true},
+		InboundIds: []int{ib.Id},
+	})
+	routing(preview, err, "+", "carol")
+
+	if rec := lookupClientRecord(t, "alice"); rec.Egress != "" || rec.Group != "" {
+		t.Fatalf("dry run wrote alice: egress %q group %q", rec.Egress, rec.Group)
+	}
+}
+
+// Every dry run must predict exactly the config its real operation leaves
+// behind, so each case previews a change, applies it and diffs the two.
+func TestPreviewMatchesAppliedChange(t *testing.T) {
+	svc := &ClientService{}
+	inboundSvc := &InboundService{}
+	expired := time.Now().Add(-24 * time.Hour).UnixMilli()
+
+	type seeded struct{ vless, trojan *model.Inbound }
+	seed := func(t *testing.T) seeded {
+		t.Helper()
+		setupBulkDB(t)
+		resetAccessGate(t)
+		// The default template routes by geoip, which needs data files tests lack.
+		if err := (&XraySettingService{}).SaveXraySetting(`{"log":{"loglevel":"warning"},"inbounds":[],` +
+			`"outbounds":[{"tag":"direct","protocol":"freedom"}],"routing":{"rules":[]}}`); err != nil {
+			t.Fatal(err)
+		}
+		// Deletes tombstone their emails process-wide; other tests reuse them.
+		t.Cleanup(func() { withdrawClientTombstones("alice", "bob", "carol", "dora") })
+		vlessClients := []model.Client{
+			{Email: "alice", ID: "aaaaaaaa-0000-0000-0000-000000000061", Password: "alice-pass", SubID: "alice", Flow: "xtls-rprx-vision", Enable: true},
+			{Email: "bob", ID: "aaaaaaaa-0000-0000-0000-000000000062", Password: "bob-pass", SubID: "bob", Enable: true},
+			{Email: "dora", ID: "aaaaaaaa-0000-0000-0000-000000000063", SubID: "dora", ExpiryTime: expired},
+		}
+		vless := mkInboundStream(t, 24061, model.VLESS, clientsSettings(t, vlessClients), realityStream)
+		if err := svc.SyncInbound(nil, vless.Id, vlessClients); err != nil {
+			t.Fatalf("seed vless: %v", err)
+		}
+		trojanClients := []model.Client{{Email: "carol", Password: "carol-pass", SubID: "carol", Enable: true}}
+		trojan := mkInbound(t, 24062, model.Trojan, clientsSettings(t, trojanClients))
+		if err := svc.SyncInbound(nil, trojan.Id, trojanClients); err != nil {
+			t.Fatalf("seed trojan: %v", err)
+		}
+		// dora was cut by the traffic loop for running past her expiry.
+		if err := database.GetDB().Create(&xray.ClientTraffic{
+			InboundId: vless.Id, Email: "dora", ExpiryTime: expired,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+		return seeded{vless: vless, trojan: trojan}
+	}
+
+	cases := []struct {
+		name    string
+		preview func(seeded) (*ConfigPreview, error)
+		apply   func(seeded) error
+	}{
+		{
+			name: "create",
+			preview: func(s seeded) (*ConfigPreview, error) {
+				return svc.PreviewCreate(inboundSvc, &ClientCreatePayload{
+					Client:     model.Client{Email: "erin", ID: "aaaaaaaa-0000-0000-0000-000000000064", Password: "erin-pass", SubID: "erin", Enable: true},
+					InboundIds: []int{s.vless.Id, s.trojan.Id},
+				})
+			},
+			apply: func(s seeded) error {
+				_, err := svc.Create(inboundSvc, &ClientCreatePayload{
+					Client:     model.Client{Email: "erin", ID: "aaaaaaaa-0000-0000-0000-000000000064", Password: "erin-pass", SubID: "erin", Enable: true},
+					InboundIds: []int{s.vless.Id, s.trojan.Id},
+				})
+				return err
+			},
+		},
+		{
+			name: "update",
+			preview: func(seeded) (*ConfigPreview, error) {
+				rec, err := svc.GetRecordByEmail(nil, "bob")
+				if err != nil {
+					return nil, err
+				}
+				bob := rec.ToClient()
+				bob.ID = "aaaaaaaa-0000-0000-0000-000000000065"
+				return svc.PreviewUpdateByEmail(inboundSvc, "bob", *bob)
+			},
+			apply: func(seeded) error {
+				rec, err := svc.GetRecordByEmail(nil, "bob")
+				if err != nil {
+					return err
+				}
+				bob := rec.ToClient()
+				bob.ID = "aaaaaaaa-0000-0000-0000-000000000065"
+				_, err = svc.UpdateByEmail(inboundSvc, nil, "bob", *bob, 0)
+				return err
+			},
+		},
+		{
+			name:    "delete",
+			preview: func(seeded) (*ConfigPreview, error) { return svc.PreviewDeleteByEmail("alice") },
+			apply: func(seeded) error {
+				_, err := svc.DeleteByEmail(inboundSvc, "alice", false)
+				return err
+			},
+		},
+		{
+			name: "bulk create",
+			preview: func(s seeded) (*ConfigPreview, error) {
+				return svc.PreviewBulkCreate(inboundSvc, []ClientCreatePayload{
+					{Client: model.Client{Email: "erin", ID: "aaaaaaaa-0000-0000-0000-000000000064", SubID: "erin"}, InboundIds: []int{s.vless.Id}},
+					{Client: model.Client{Email: "fred", Password: "fred-pass", SubID: "fred"}, InboundIds: []int{s.trojan.Id}},
+					{Client: model.Client{Email: "bob"}, InboundIds: []int{s.trojan.Id}},
+				})
+			},
+			apply: func(s seeded) error {
+				_, _, err := svc.BulkCreate(inboundSvc, []ClientCreatePayload{
+					{Client: model.Client{Email: "erin", ID: "aaaaaaaa-0000-0000-0000-000000000064", SubID: "erin"}, InboundIds: []int{s.vless.Id}},
+					{Client: model.Client{Email: "fred", Password: "fred-pass", SubID: "fred"}, InboundIds: []int{s.trojan.Id}},
+					{Client: model.Client{Email: "bob"}, InboundIds: []int{s.trojan.Id}},
+				})
+				return err
+			},
+		},
+		{
+			name: "bulk delete",
+			preview: func(seeded) (*ConfigPreview, error) {
+				return svc.PreviewBulkDelete([]string{"alice", "carol", "nobody"})
+			},
+			apply: func(seeded) error {
+				_, _, err := svc.BulkDelete(inboundSvc, []string{"alice", "carol", "nobody"}, false)
+				return err
+			},
+		},
+		{
+			name: "bulk disable",
+			preview: func(seeded) (*ConfigPreview, error) {
+				return svc.PreviewBulkSetEnable([]string{"alice", "carol"}, false)
+			},
+			apply: func(seeded) error {
+				_, _, err := svc.BulkSetEnable(inboundSvc, []string{"alice", "carol"}, false)
+				return err
+			},
+		},
+		{
+			name:    "bulk enable",
+			preview: func(seeded) (*ConfigPreview, error) { return svc.PreviewBulkSetEnable([]string{"dora"}, true) },
+			apply: func(seeded) error {
+				_, _, err := svc.BulkSetEnable(inboundSvc, []string{"dora"}, true)
+				return err
+			},
+		},
+		{
+			name: "bulk attach",
+			preview: func(s seeded) (*ConfigPreview, error) {
+				return svc.PreviewBulkAttach(inboundSvc, []string{"alice", "bob", "carol"}, []int{s.trojan.Id})
+			},
+			apply: func(s seeded) error {
+				_, _, err := svc.BulkAttach(inboundSvc, []string{"alice", "bob", "carol"}, []int{s.trojan.Id})
+				return err
+			},
+		},
+		{
+			name: "bulk detach",
+			preview: func(s seeded) (*ConfigPreview, error) {
+				return svc.PreviewBulkDetach([]string{"alice", "carol"}, []int{s.vless.Id})
+			},
+			apply: func(s seeded) error {
+				_, _, err := svc.BulkDetach(inboundSvc, []string{"alice", "carol"}, []int{s.vless.Id})
+				return err
+			},
+		},
+		{
+			name: "bulk adjust flow",
+			preview: func(seeded) (*ConfigPreview, error) {
+				return svc.PreviewBulkAdjust(inboundSvc, []string{"alice", "bob", "carol"}, 0, 0, "xtls-rprx-vision-udp443")
+			},
+			apply: func(seeded) error {
+				_, _, err := svc.BulkAdjust(inboundSvc, nil, []string{"alice", "bob", "carol"}, 0, 0, "xtls-rprx-vision-udp443")
+				return err
+			},
+		},
+		{
+			name: "bulk adjust reenables",
+			preview: func(seeded) (*ConfigPreview, error) {
+				return svc.PreviewBulkAdjust(inboundSvc, []string{"dora"}, 30, 0, "")
+			},
+			apply: func(seeded) error {
+				_, _, err := svc.BulkAdjust(inboundSvc, nil, []string{"dora"}, 30, 0, "")
+				return err
+			},
+		},
+		{
+			name: "inbound add",
+			preview: func(seeded) (*ConfigPreview, error) {
+				return inboundSvc.PreviewAddInbound(newPreviewInbound(t))
+			},
+			apply: func(seeded) error {
+				_, _, err := inboundSvc.AddInbound(newPreviewInbound(t))
+				return err
+			},
+		},
+		{
+			name: "inbound update",
+			preview: func(s seeded) (*ConfigPreview, error) {
+				ib, err := inboundSvc.GetInbound(s.trojan.Id)
+				if err != nil {
+					return nil, err
+				}
+				ib.Port = 24064
+				return inboundSvc.PreviewUpdateInbound(ib)
+			},
+			apply: func(s seeded) error {
+				ib, err := inboundSvc.GetInbound(s.trojan.Id)
+				if err != nil {
+					return err
+				}
+				ib.Port = 24064
+				_, _, err = inboundSvc.UpdateInbound(ib)
+				return err
+			},
+		},
+		{
+			name: "xray setting",
+			preview: func(seeded) (*ConfigPreview, error) {
+				template, err := withRoutingRule(t)
+				if err != nil {
+					return nil, err
+				}
+				return (&XraySettingService{}).PreviewXraySetting(template)
+			},
+			apply: func(seeded) error {
+				template, err := withRoutingRule(t)
+				if err != nil {
+					return err
+				}
+				return (&XraySettingService{}).SaveXraySetting(template)
+			},
+		},
+		{
+			name:    "inbound delete",
+			preview: func(s seeded) (*ConfigPreview, error) { return inboundSvc.PreviewDelInbound(s.trojan.Id) },
+			apply: func(s seeded) error {
+				_, err := inboundSvc.DelInbound(s.trojan.Id)
+				return err
+			},
+		},
+	}
+
+	xs := &XrayService{}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := seed(t)
+			before, err := xs.runtimeConfigs(&configOverlay{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			preview, err := tc.preview(s)
+			if err != nil {
+				t.Fatalf("preview: %v", err)
+			}
+			if err := tc.apply(s); err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			after, err := xs.runtimeConfigs(&configOverlay{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(preview.Runtimes) == 0 {
+				t.Fatal("preview reports no change")
+			}
+			previewed := make(map[int]RuntimePreview, len(preview.Runtimes))
+			for _, rp := range preview.Runtimes {
+				previewed[rp.NodeId] = rp
+			}
+			ids := slices.Collect(maps.Keys(before))
+			for id := range after {
+				if _, ok := before[id]; !ok {
+					ids = append(ids, id)
+				}
+			}
+			for _, id := range ids {
+				oldText, err := renderRuntime(orEmptyConfig(before[id]), id != 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				newText, err := renderRuntime(orEmptyConfig(after[id]), id != 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rp, ok := previewed[id]
+				if !ok {
+					if oldText != newText {
+						t.Fatalf("runtime %d changed but the preview left it out", id)
+					}
+					continue
+				}
+				proposed, err := applyUnifiedDiff(oldText, rp.Diff)
+				if err != nil {
+					t.Fatalf("preview of %s: %v", rp.Runtime, err)
+				}
+				if proposed != newText {
+					t.Fatalf("preview of %s differs from the applied config.\npreview:\n%s\napplied:\n%s", rp.Runtime, proposed, newText)
+				}
+			}
+		})
+	}
+}
+
+func orEmptyConfig(cfg *xray.Config) *xray.Config {
+	if cfg == nil {
+		return &xray.Config{}
+	}
+	return cfg
+}
+
+// applyUnifiedDiff patches old with a textdiff.Unified diff, checking every
+// context and removed line against old.
+func applyUnifiedDiff(old, diff string) (string, error) {
+	oldLines := strings.Split(strings.TrimSuffix(old, "\n"), "\n")
+	var out []string
+	next := 0
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+		case strings.HasPrefix(line, "@@ "):
+			var start int
+			if _, err := fmt.Sscanf(line, "@@ -%d", &start); err != nil {
+				return "", fmt.Errorf("bad hunk header %q", line)
+			}
+			// An empty old side names the line before the hunk.
+			if strings.HasPrefix(line, fmt.Sprintf("@@ -%d,0 ", start)) {
+				start++
+			}
+			if start-1 < next || start-1 > len(oldLines) {
+				return "", fmt.Errorf("hunk %q out of order", line)
+			}
+			out = append(out, oldLines[next:start-1]...)
+			next = start - 1
+		case line == "":
+			return "", fmt.Errorf("empty diff line")
+		case line[0] == '+':
+			out = append(out, line[1:])
+		case line[0] == ' ' || line[0] == '-':
+			if next >= len(oldLines) || oldLines[next] != line[1:] {
+				return "", fmt.Errorf("diff line %q does not match the current config", line)
+			}
+			if line[0] == ' ' {
+				out = append(out, line[1:])
+			}
+			next++
+		default:
+			return "", fmt.Errorf("bad diff line %q", line)
+		}
+	}
+	out = append(out, oldLines[next:]...)
+	return strings.Join(out, "\n") + "\n", nil
+}
+
+func newPreviewInbound(t *testing.T) *model.Inbound {
+	t.Helper()
+	return &model.Inbound{
+		Tag:      "trojan-preview",
+		Enable:   true,
+		Port:     24063,
+		Protocol: model.Trojan,
+		Settings: clientsSettings(t, []model.Client{
+			{Email: "gina", Password: "gina-pass", SubID: "gina", Enable: true},
+		}),
+	}
+}
+
+// withRoutingRule is the stored xray template with one more routing rule.
+func withRoutingRule(t *testing.T) (string, error) {
+	t.Helper()
+	template, err := (&SettingService{}).GetXrayConfigTemplate()
+	if err != nil {
+		return "", err
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(template), &cfg); err != nil {
+		return "", err
+	}
+	routing, _ := cfg["routing"].(map[string]any)
+	if routing == nil {
+		routing = map[string]any{}
+		cfg["routing"] = routing
+	}
+	rules, _ := routing["rules"].([]any)
+	routing["rules"] = append(rules, map[string]any{"type": "field", "port": "25", "outboundTag": "direct"})
+	bs, err := json.Marshal(cfg)
+	return string(bs), err
+}
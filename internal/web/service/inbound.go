@@ -909,46 +909,45 @@ func (s *InboundService) normalizeMtprotoXrayPort(inbound *model.Inbound, oldSet
 	return nil
 }
 
-// AddInbound creates a new inbound configuration.
-// It validates port uniqueness, client email uniqueness, and required fields,
-// then saves the inbound to the database and optionally adds it to the running Xray instance.
-// Returns the created inbound, whether Xray needs restart, and any error.
-func (s *InboundService) AddInbound(inbound *model.Inbound) (*model.Inbound, bool, error) {
+// prepareNewInbound normalizes and validates an inbound about to be created
+// and returns its clients. It does not touch the database beyond lookups.
+func (s *InboundService) prepareNewInbound(inbound *model.Inbound) ([]model.Client, error) {
 	inbound.Id = 0
+	inbound.FailoverOf = nil
 	inbound.TrafficResetDay = normalizeTrafficResetDay(inbound.TrafficResetDay)
 	// Normalize streamSettings based on protocol
 	s.normalizeStreamSettings(inbound)
 	if err := validateFinalMaskRealityCombo(inbound.StreamSettings); err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	if err := validateFinalMaskXmcProfiles(inbound.StreamSettings); err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	s.normalizeMtprotoSecret(inbound)
 	if err := s.normalizeMtprotoXrayPort(inbound, ""); err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	inbound.SubSortIndex = normalizeSubSortIndex(inbound.SubSortIndex)
 	if err := normalizeInboundShareAddressStrict(inbound); err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 
 	tag, err := s.resolveInboundTag(inbound, 0)
 	if err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	inbound.Tag = tag
 
 	clients, err := s.GetClients(inbound)
 	if err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	existEmail, err := s.clientService.checkEmailsExistForClients(s, clients)
 	if err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	if existEmail != "" {
-		return inbound, false, common.NewError("Duplicate email:", existEmail)
+		return nil, common.NewError("Duplicate email:", existEmail)
 	}
 
 	if inbound.DisableFlow {
@@ -995,33 +994,45 @@ func (s *InboundService) AddInbound(inbound *model.Inbound) (*model.Inbound, boo
 		switch inbound.Protocol {
 		case "trojan":
 			if client.Password == "" {
-				return inbound, false, common.NewError("empty client ID")
+				return nil, common.NewError("empty client ID")
 			}
 		case "shadowsocks":
 			if client.Email == "" {
-				return inbound, false, common.NewError("empty client ID")
+				return nil, common.NewError("empty client ID")
 			}
 		case "hysteria":
 			if client.Auth == "" {
-				return inbound, false, common.NewError("empty client ID")
+				return nil, common.NewError("empty client ID")
 			}
 		case "wireguard":
 			if client.PublicKey == "" {
-				return inbound, false, common.NewError("wireguard client requires a key")
+				return nil, common.NewError("wireguard client requires a key")
 			}
 		case "mtproto":
 			if client.Secret == "" {
-				return inbound, false, common.NewError("mtproto client requires a secret")
+				return nil, common.NewError("mtproto client requires a secret")
 			}
 			if client.AdTag != "" && !model.ValidMtprotoAdTag(client.AdTag) {
-				return inbound, false, common.NewError("mtproto client ad tag must be 32 hex characters")
+				return nil, common.NewError("mtproto client ad tag must be 32 hex characters")
 			}
 		default:
 			if client.ID == "" {
-				return inbound, false, common.NewError("empty client ID")
+				return nil, common.NewError("empty client ID")
 			}
 		}
 	}
+	return clients, nil
+}
+
+// AddInbound creates a new inbound configuration.
+// It validates port uniqueness, client email uniqueness, and required fields,
+// then saves the inbound to the database and optionally adds it to the running Xray instance.
+// Returns the created inbound, whether Xray needs restart, and any error.
+func (s *InboundService) AddInbound(inbound *model.Inbound) (*model.Inbound, bool, error) {
+	clients, err := s.prepareNewInbound(inbound)
+	if err != nil {
+		return inbound, false, err
+	}
 
 	needRestart := false
 	var postCommitApply func()
@@ -1033,6 +1044,9 @@ func (s *InboundService) AddInbound(inbound *model.Inbound) (*model.Inbound, boo
 		if conflict != nil {
 			return common.NewError(conflict.String())
 		}
+		if err := validateStandbyNode(tx, inbound); err != nil {
+			return err
+		}
 		markDirty := false
 		if err := tx.Omit("ClientStats").Save(inbound).Error; err != nil {
 			return err
@@ -1311,13 +1325,7 @@ func (s *InboundService) SetInboundSubSortIndex(id int, index int) error {
 		return perr
 	}
 	if push {
-		narrow, ok := rt.(interface {
-			SetInboundSubSortIndex(context.Context, *model.Inbound, int) error
-		})
-		if !ok {
-			return fmt.Errorf("runtime %s does not support narrow subscription ordering updates", rt.Name())
-		}
-		if err := narrow.SetInboundSubSortIndex(context.Background(), inbound, index); err != nil {
+		if err := setSubSortIndexOn(context.Background(), rt, inbound, index); err != nil {
 			logger.Warning("SetInboundSubSortIndex: remote metadata update on", rt.Name(), "failed:", err)
 		}
 	}
@@ -1397,182 +1405,217 @@ func (s *InboundService) SetInboundEnable(id int, enable bool) (bool, error) {
 	return needRestart, nil
 }
 
-func (s *InboundService) UpdateInbound(inbound *model.Inbound) (*model.Inbound, bool, error) {
+// prepareInboundUpdate normalizes and validates an inbound edit and returns
+// the stored row it applies to.
+func (s *InboundService) prepareInboundUpdate(inbound *model.Inbound) (*model.Inbound, error) {
 	inbound.TrafficResetDay = normalizeTrafficResetDay(inbound.TrafficResetDay)
 	// Normalize streamSettings based on protocol
 	s.normalizeStreamSettings(inbound)
 	if err := validateFinalMaskRealityCombo(inbound.StreamSettings); err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	if err := validateFinalMaskXmcProfiles(inbound.StreamSettings); err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	s.normalizeMtprotoSecret(inbound)
 	inbound.SubSortIndex = normalizeSubSortIndex(inbound.SubSortIndex)
 
 	clients, err := s.GetClients(inbound)
 	if err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	if inbound.Protocol == model.Hysteria {
 		for _, client := range clients {
 			if client.Auth == "" {
-				return inbound, false, common.NewError("empty client ID")
+				return nil, common.NewError("empty client ID")
 			}
 		}
 	}
 
 	oldInbound, err := s.GetInbound(inbound.Id)
 	if err != nil {
-		return inbound, false, err
+		return nil, err
 	}
 	// Restore the stored NodeID before the port-conflict check so a node inbound
 	// stays scoped to its own node (the payload's nodeId is unreliable, often absent).
 	inbound.NodeID = oldInbound.NodeID
 
-	// Capture the pre-edit protocol and routing state before oldInbound is
-	// overwritten with the new values further down, then ensure a routed
-	// inbound keeps a stable egress port (reusing the one already stored).
-	oldProtocol := oldInbound.Protocol
-	oldRoutedMtproto := mtprotoRoutesThroughXray(oldInbound)
+	// Ensure a routed inbound keeps a stable egress port (reusing the one
+	// already stored).
 	if err := s.normalizeMtprotoXrayPort(inbound, oldInbound.Settings); err != nil {
-		return inbound, false, err
+		return nil, err
 	}
+	return oldInbound, nil
+}
 
-	tag := oldInbound.Tag
-	oldBits := inboundTransports(oldInbound.Protocol, oldInbound.StreamSettings, oldInbound.Settings)
-	oldTagWasAuto := isAutoGeneratedTag(tag, oldInbound.Port, oldInbound.NodeID, oldBits)
-
-	needRestart := false
-	var postCommitApply func()
-
-	txErr := runSerializedTx(func(tx *gorm.DB) error {
-		conflict, cErr := checkPortConflictTx(tx, inbound, inbound.Id)
-		if cErr != nil {
-			return cErr
-		}
-		if conflict != nil {
-			return common.NewError(conflict.String())
-		}
-		if err := s.updateClientTraffics(tx, oldInbound, inbound); err != nil {
-			return err
-		}
-
-		// Ensure created_at and updated_at exist in inbound.Settings clients
-		{
-			var oldSettings map[string]any
-			_ = json.Unmarshal([]byte(oldInbound.Settings), &oldSettings)
-			emailToCreated := map[string]int64{}
-			emailToUpdated := map[string]int64{}
-			if oldSettings != nil {
-				if oc, ok := oldSettings["clients"].([]any); ok {
-					for _, it := range oc {
-						if m, ok2 := it.(map[string]any); ok2 {
-							if email, ok3 := m["email"].(string); ok3 {
-								switch v := m["created_at"].(type) {
-								case float64:
-									emailToCreated[email] = int64(v)
-								case int64:
-									emailToCreated[email] = v
-								}
-								switch v := m["updated_at"].(type) {
-								case float64:
-									emailToUpdated[email] = int64(v)
-								case int64:
-									emailToUpdated[email] = v
-								}
+// applyInboundEdit only reads through tx, so it is safe outside a write. With
+// keepTag the given tag stays even if a port or transport change would rename it.
+func (s *InboundService) applyInboundEdit(tx *gorm.DB, old, inbound *model.Inbound, keepTag bool) error {
+	tag := old.Tag
+	oldBits := inboundTransports(old.Protocol, old.StreamSettings, old.Settings)
+	oldTagWasAuto := isAutoGeneratedTag(tag, old.Port, old.NodeID, oldBits)
+
+	// Ensure created_at and updated_at exist in inbound.Settings clients
+	{
+		var oldSettings map[string]any
+		_ = json.Unmarshal([]byte(old.Settings), &oldSettings)
+		emailToCreated := map[string]int64{}
+		emailToUpdated := map[string]int64{}
+		if oldSettings != nil {
+			if oc, ok := oldSettings["clients"].([]any); ok {
+				for _, it := range oc {
+					if m, ok2 := it.(map[string]any); ok2 {
+						if email, ok3 := m["email"].(string); ok3 {
+							switch v := m["created_at"].(type) {
+							case float64:
+								emailToCreated[email] = int64(v)
+							case int64:
+								emailToCreated[email] = v
+							}
+							switch v := m["updated_at"].(type) {
+							case float64:
+								emailToUpdated[email] = int64(v)
+							case int64:
+								emailToUpdated[email] = v
 							}
 						}
 					}
 				}
 			}
-			var newSettings map[string]any
-			if err2 := json.Unmarshal([]byte(inbound.Settings), &newSettings); err2 == nil && newSettings != nil {
-				now := time.Now().Unix() * 1000
-				if nSlice, ok := newSettings["clients"].([]any); ok {
-					for i := range nSlice {
-						if m, ok2 := nSlice[i].(map[string]any); ok2 {
-							email, _ := m["email"].(string)
-							if _, ok3 := m["created_at"]; !ok3 {
-								if v, ok4 := emailToCreated[email]; ok4 && v > 0 {
-									m["created_at"] = v
-								} else {
-									m["created_at"] = now
-								}
+		}
+		var newSettings map[string]any
+		if err2 := json.Unmarshal([]byte(inbound.Settings), &newSettings); err2 == nil && newSettings != nil {
+			now := time.Now().Unix() * 1000
+			if nSlice, ok := newSettings["clients"].([]any); ok {
+				for i := range nSlice {
+					if m, ok2 := nSlice[i].(map[string]any); ok2 {
+						email, _ := m["email"].(string)
+						if _, ok3 := m["created_at"]; !ok3 {
+							if v, ok4 := emailToCreated[email]; ok4 && v > 0 {
+								m["created_at"] = v
+							} else {
+								m["created_at"] = now
 							}
-							// Preserve client's updated_at if present; do not bump on parent inbound update
-							if _, hasUpdated := m["updated_at"]; !hasUpdated {
-								if v, ok4 := emailToUpdated[email]; ok4 && v > 0 {
-									m["updated_at"] = v
-								}
+						}
+						// Preserve client's updated_at if present; do not bump on parent inbound update
+						if _, hasUpdated := m["updated_at"]; !hasUpdated {
+							if v, ok4 := emailToUpdated[email]; ok4 && v > 0 {
+								m["updated_at"] = v
 							}
-							nSlice[i] = m
 						}
-					}
-					newSettings["clients"] = nSlice
-					if bs, err3 := json.MarshalIndent(newSettings, "", "  "); err3 == nil {
-						inbound.Settings = string(bs)
+						nSlice[i] = m
 					}
 				}
+				newSettings["clients"] = nSlice
+				if bs, err3 := json.MarshalIndent(newSettings, "", "  "); err3 == nil {
+					inbound.Settings = string(bs)
+				}
 			}
 		}
+	}
+
+	// A Shadowsocks-2022 method change resizes the key, but existing client PSKs
+	// keep their old length and would be rejected by xray. Regenerate mismatched
+	// client keys so the inbound stays connectable.
+	if normalized, changed := normalizeShadowsocksClientKeys(inbound.Settings); changed {
+		inbound.Settings = normalized
+		logger.Warning("Shadowsocks inbound", inbound.Id, "method change resized keys; regenerated mismatched client PSK(s)")
+	}
 
-		// A Shadowsocks-2022 method change resizes the key, but existing client PSKs
-		// keep their old length and would be rejected by xray. Regenerate mismatched
-		// client keys so the inbound stays connectable.
-		if normalized, changed := normalizeShadowsocksClientKeys(inbound.Settings); changed {
-			inbound.Settings = normalized
-			logger.Warning("Shadowsocks inbound", inbound.Id, "method change resized keys; regenerated mismatched client PSK(s)")
+	// Re-gate Vision flow now that the new stream/encryption is known: if this
+	// VLESS inbound just became flow-eligible (e.g. vlessenc was enabled on an
+	// XHTTP inbound), restore Vision for clients whose intended flow is Vision
+	// but was stripped while the inbound was ineligible.
+	if !inbound.DisableFlow {
+		if restored, changed := s.restoreVisionFlowForEligibleInbound(tx, inbound.Settings, inbound.StreamSettings, inbound.Protocol); changed {
+			inbound.Settings = restored
+		}
+	} else {
+		if stripped, changed := stripClientFlows(inbound.Settings); changed {
+			inbound.Settings = stripped
 		}
+	}
 
-		// Re-gate Vision flow now that the new stream/encryption is known: if this
-		// VLESS inbound just became flow-eligible (e.g. vlessenc was enabled on an
-		// XHTTP inbound), restore Vision for clients whose intended flow is Vision
-		// but was stripped while the inbound was ineligible.
-		if !inbound.DisableFlow {
-			if restored, changed := s.restoreVisionFlowForEligibleInbound(tx, inbound.Settings, inbound.StreamSettings, inbound.Protocol); changed {
-				inbound.Settings = restored
-			}
-		} else {
-			if stripped, changed := stripClientFlows(inbound.Settings); changed {
-				inbound.Settings = stripped
-			}
+	old.Total = inbound.Total
+	old.Remark = inbound.Remark
+	old.SubSortIndex = inbound.SubSortIndex
+	old.Enable = inbound.Enable
+	old.ExpiryTime = inbound.ExpiryTime
+	old.TrafficReset = inbound.TrafficReset
+	old.TrafficResetDay = inbound.TrafficResetDay
+	old.Listen = inbound.Listen
+	old.Port = inbound.Port
+	old.Protocol = inbound.Protocol
+	old.DisableFlow = inbound.DisableFlow
+	old.TrafficCoefficient = inbound.TrafficCoefficient
+	old.StandbyNodeID = inbound.StandbyNodeID
+	if err := validateStandbyNode(tx, old); err != nil {
+		return err
+	}
+	old.Settings = inbound.Settings
+	old.StreamSettings = inbound.StreamSettings
+	old.Sniffing = inbound.Sniffing
+	if strings.TrimSpace(inbound.ShareAddrStrategy) == "" {
+		normalizeInboundShareAddress(old)
+		inbound.ShareAddrStrategy = old.ShareAddrStrategy
+		inbound.ShareAddr = old.ShareAddr
+	} else {
+		if err := normalizeInboundShareAddressStrict(inbound); err != nil {
+			return err
 		}
+		old.ShareAddrStrategy = inbound.ShareAddrStrategy
+		old.ShareAddr = inbound.ShareAddr
+	}
+	if oldTagWasAuto && inbound.Tag == tag && !keepTag {
+		inbound.Tag = ""
+	}
+	resolvedTag, err := s.resolveInboundTag(inbound, inbound.Id)
+	if err != nil {
+		return err
+	}
+	if keepTag && resolvedTag != inbound.Tag {
+		return common.NewErrorf("inbound tag %q is already in use", inbound.Tag)
+	}
+	old.Tag = resolvedTag
+	inbound.Tag = old.Tag
+	return nil
+}
 
-		oldInbound.Total = inbound.Total
-		oldInbound.Remark = inbound.Remark
-		oldInbound.SubSortIndex = inbound.SubSortIndex
-		oldInbound.Enable = inbound.Enable
-		oldInbound.ExpiryTime = inbound.ExpiryTime
-		oldInbound.TrafficReset = inbound.TrafficReset
-		oldInbound.TrafficResetDay = inbound.TrafficResetDay
-		oldInbound.Listen = inbound.Listen
-		oldInbound.Port = inbound.Port
-		oldInbound.Protocol = inbound.Protocol
-		oldInbound.DisableFlow = inbound.DisableFlow
-		oldInbound.Settings = inbound.Settings
-		oldInbound.StreamSettings = inbound.StreamSettings
-		oldInbound.Sniffing = inbound.Sniffing
-		if strings.TrimSpace(inbound.ShareAddrStrategy) == "" {
-			normalizeInboundShareAddress(oldInbound)
-			inbound.ShareAddrStrategy = oldInbound.ShareAddrStrategy
-			inbound.ShareAddr = oldInbound.ShareAddr
-		} else {
-			if err := normalizeInboundShareAddressStrict(inbound); err != nil {
-				return err
-			}
-			oldInbound.ShareAddrStrategy = inbound.ShareAddrStrategy
-			oldInbound.ShareAddr = inbound.ShareAddr
+func (s *InboundService) UpdateInbound(inbound *model.Inbound) (*model.Inbound, bool, error) {
+	return s.updateInbound(inbound, false)
+}
+
+// updateInbound is UpdateInbound; keepTag is passed on to applyInboundEdit.
+func (s *InboundService) updateInbound(inbound *model.Inbound, keepTag bool) (*model.Inbound, bool, error) {
+	oldInbound, err := s.prepareInboundUpdate(inbound)
+	if err != nil {
+		return inbound, false, err
+	}
+
+	// Capture the pre-edit protocol and routing state before oldInbound is
+	// overwritten with the new values further down.
+	oldProtocol := oldInbound.Protocol
+	oldRoutedMtproto := mtprotoRoutesThroughXray(oldInbound)
+	tag := oldInbound.Tag
+
+	needRestart := false
+	var postCommitApply func()
+
+	txErr := runSerializedTx(func(tx *gorm.DB) error {
+		conflict, cErr := checkPortConflictTx(tx, inbound, inbound.Id)
+		if cErr != nil {
+			return cErr
 		}
-		if oldTagWasAuto && inbound.Tag == tag {
-			inbound.Tag = ""
+		if conflict != nil {
+			return common.NewError(conflict.String())
 		}
-		resolvedTag, err := s.resolveInboundTag(inbound, inbound.Id)
-		if err != nil {
+		if err := s.updateClientTraffics(tx, oldInbound, inbound); err != nil {
+			return err
+		}
+
+		if err := s.applyInboundEdit(tx, oldInbound, inbound, keepTag); err != nil {
 			return err
 		}
-		oldInbound.Tag = resolvedTag
-		inbound.Tag = oldInbound.Tag
 
 		if oldInbound.NodeID == nil {
 			rt, push, _, perr := s.nodePushPlan(oldInbound)
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/eventbus"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+
+	"gorm.io/gorm"
+)
+
+// failoverHysteresis keeps a missed heartbeat or a flapping link from bouncing
+// every subscriber between nodes.
+var failoverHysteresis = struct {
+	down time.Duration
+	up   time.Duration
+}{
+	down: 30 * time.Second,
+	up:   2 * time.Minute,
+}
+
+type nodeStatusMark struct {
+	status string
+	since  time.Time
+}
+
+// nodeStatusSince counts a node with no recorded change from the first pass
+// that saw it, so a restart never shortens the hysteresis.
+var (
+	nodeStatusMu    sync.Mutex
+	nodeStatusSince = map[int]nodeStatusMark{}
+)
+
+func markNodeStatus(nodeID int, status string, at time.Time) {
+	nodeStatusMu.Lock()
+	defer nodeStatusMu.Unlock()
+	if m, ok := nodeStatusSince[nodeID]; ok && m.status == status {
+		return
+	}
+	nodeStatusSince[nodeID] = nodeStatusMark{status: status, since: at}
+}
+
+// nodeStatusFor returns how long n has held its stored status.
+func nodeStatusFor(n *model.Node, now time.Time) time.Duration {
+	nodeStatusMu.Lock()
+	defer nodeStatusMu.Unlock()
+	m, ok := nodeStatusSince[n.Id]
+	if !ok || m.status != n.Status {
+		m = nodeStatusMark{status: n.Status, since: now}
+		nodeStatusSince[n.Id] = m
+	}
+	return now.Sub(m.since)
+}
+
+// validateStandbyNode checks an inbound's standby node: only a node-hosted
+// inbound fails over, and only to another node that exists.
+func validateStandbyNode(tx *gorm.DB, ib *model.Inbound) error {
+	if ib.StandbyNodeID == nil {
+		return nil
+	}
+	if ib.FailoverOf != nil {
+		return common.NewError("a standby copy cannot have a standby node of its own")
+	}
+	if ib.NodeID == nil {
+		return common.NewError("only an inbound hosted on a node can fail over to a standby node")
+	}
+	if *ib.StandbyNodeID == *ib.NodeID {
+		return common.NewError("the standby node must differ from the inbound's own node")
+	}
+	var count int64
+	if err := tx.Model(model.Node{}).Where("id = ?", *ib.StandbyNodeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return common.NewErrorf("standby node %d not found", *ib.StandbyNodeID)
+	}
+	return nil
+}
+
+// FailoverService's standby copies are ordinary node inbounds, so reconcile and
+// traffic sync handle them as usual; subscriptions only swap the endpoint.
+type FailoverService struct {
+	inboundService InboundService
+}
+
+// HandleEvent is the event bus subscriber that times node transitions for
+// the failover hysteresis. It runs for every event; only node ones count.
+func (s *FailoverService) HandleEvent(e eventbus.Event) {
+	data, ok := e.Data.(*eventbus.NodeHealthData)
+	if !ok || data == nil {
+		return
+	}
+	switch e.Type {
+	case eventbus.EventNodeDown:
+		markNodeStatus(data.NodeId, "offline", e.Timestamp)
+	case eventbus.EventNodeUp:
+		markNodeStatus(data.NodeId, "online", e.Timestamp)
+	}
+}
+
+// Reconcile also keeps every active standby copy in step with its primary.
+// changed reports whether a copy was created or removed.
+func (s *FailoverService) Reconcile() (changed bool, err error) {
+	db := database.GetDB()
+	var nodes []*model.Node
+	if err := db.Find(&nodes).Error; err != nil {
+		return false, err
+	}
+	nodesByID := make(map[int]*model.Node, len(nodes))
+	for _, n := range nodes {
+		nodesByID[n.Id] = n
+	}
+	var primaries []*model.Inbound
+	if err := db.Where("standby_node_id IS NOT NULL").Order("id asc").Find(&primaries).Error; err != nil {
+		return false, err
+	}
+	var copies []*model.Inbound
+	if err := db.Where("failover_of IS NOT NULL").Order("id asc").Find(&copies).Error; err != nil {
+		return false, err
+	}
+	copyOf := make(map[int]*model.Inbound, len(copies))
+	var stale []*model.Inbound
+	for _, c := range copies {
+		if _, dup := copyOf[*c.FailoverOf]; dup {
+			stale = append(stale, c)
+			continue
+		}
+		copyOf[*c.FailoverOf] = c
+	}
+
+	now := time.Now()
+	var errs []error
+	for _, p := range primaries {
+		c := copyOf[p.Id]
+		delete(copyOf, p.Id)
+		want := failoverWanted(p, c, nodesByID, now)
+		switch {
+		case want && c == nil:
+			if err := s.activate(p); err != nil {
+				errs = append(errs, fmt.Errorf("fail over inbound %q: %w", p.Tag, err))
+				continue
+			}
+			changed = true
+		case !want && c != nil:
+			if err := s.deactivate(p, c); err != nil {
+				errs = append(errs, fmt.Errorf("restore inbound %q: %w", p.Tag, err))
+				continue
+			}
+			changed = true
+		case want:
+			if err := s.refresh(p, c); err != nil {
+				errs = append(errs, fmt.Errorf("refresh standby copy of %q: %w", p.Tag, err))
+			}
+		}
+	}
+	// A copy whose primary was deleted or no longer names a standby node.
+	for _, c := range copyOf {
+		stale = append(stale, c)
+	}
+	for _, c := range stale {
+		if err := s.deactivate(nil, c); err != nil {
+			errs = append(errs, fmt.Errorf("remove standby copy %q: %w", c.Tag, err))
+			continue
+		}
+		changed = true
+	}
+	return changed, errors.Join(errs...)
+}
+
+// failoverWanted decides whether primary p should be served by its standby
+// node right now; c is its current standby copy, nil when none.
+func failoverWanted(p, c *model.Inbound, nodesByID map[int]*model.Node, now time.Time) bool {
+	if p.NodeID == nil || p.StandbyNodeID == nil || *p.StandbyNodeID == *p.NodeID || !p.Enable {
+		return false
+	}
+	primary, standby := nodesByID[*p.NodeID], nodesByID[*p.StandbyNodeID]
+	if primary == nil || standby == nil || !standby.Enable {
+		return false
+	}
+	// The standby node was changed: drop the copy, the next pass recreates it.
+	if c != nil && (c.NodeID == nil || *c.NodeID != standby.Id) {
+		return false
+	}
+	held := nodeStatusFor(primary, now)
+	if c == nil {
+		return primary.Enable && primary.Status == "offline" && held >= failoverHysteresis.down &&
+			standby.Status == "online"
+	}
+	return primary.Status != "online" || held < failoverHysteresis.up
+}
+
+// standbyCopyOf binds every interface, since the primary's listen address
+// belongs to its host. Limits stay on the primary; disabling it drops the copy.
+func standbyCopyOf(p *model.Inbound) *model.Inbound {
+	standbyID, primaryID := *p.StandbyNodeID, p.Id
+	return &model.Inbound{
+		UserId:             p.UserId,
+		Remark:             p.Remark,
+		SubSortIndex:       p.SubSortIndex,
+		Enable:             true,
+		TrafficReset:       "never",
+		Port:               p.Port,
+		Protocol:           p.Protocol,
+		Settings:           p.Settings,
+		StreamSettings:     p.StreamSettings,
+		Sniffing:           p.Sniffing,
+		Tag:                p.Tag + "-standby",
+		NodeID:             &standbyID,
+		ShareAddrStrategy:  "node",
+		DisableFlow:        p.DisableFlow,
+		TrafficCoefficient: p.TrafficCoefficient,
+		FailoverOf:         &primaryID,
+	}
+}
+
+func (s *FailoverService) activate(p *model.Inbound) error {
+	c := standbyCopyOf(p)
+	clients, err := s.inboundService.GetClients(p)
+	if err != nil {
+		return err
+	}
+	tag, err := s.inboundService.resolveInboundTag(c, 0)
+	if err != nil {
+		return err
+	}
+	c.Tag = tag
+	err = runSerializedTx(func(tx *gorm.DB) error {
+		conflict, err := checkPortConflictTx(tx, c, 0)
+		if err != nil {
+			return err
+		}
+		if conflict != nil {
+			return common.NewError(conflict.String())
+		}
+		if err := tx.Omit("ClientStats").Create(c).Error; err != nil {
+			return err
+		}
+		if err := s.inboundService.clientService.SyncInbound(tx, c.Id, clients); err != nil {
+			return err
+		}
+		nodeSvc := NodeService{}
+		if err := nodeSvc.EnsureInboundTagAllowedTx(tx, *c.NodeID, c.Tag); err != nil {
+			return err
+		}
+		return nodeSvc.MarkNodeDirtyTx(tx, *c.NodeID)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Infof("failover: node %d is down, inbound %q now served by node %d as %q", *p.NodeID, p.Tag, *c.NodeID, c.Tag)
+	return nil
+}
+
+func (s *FailoverService) deactivate(p, c *model.Inbound) error {
+	clients, err := s.inboundService.GetClients(c)
+	if err != nil {
+		return err
+	}
+	if _, err := s.inboundService.DelInbound(c.Id); err != nil {
+		return err
+	}
+	if c.NodeID != nil {
+		if err := dropStandbyBaselines(*c.NodeID, clients); err != nil {
+			logger.Warning("failover: drop standby traffic baselines failed:", err)
+		}
+	}
+	if p != nil {
+		logger.Infof("failover: inbound %q is back on node %d, standby copy %q removed", p.Tag, *p.NodeID, c.Tag)
+	}
+	return nil
+}
+
+// dropStandbyBaselines: the node counts a client from zero next time, so a
+// stale baseline would swallow that traffic. Clients still served there stay.
+func dropStandbyBaselines(nodeID int, clients []model.Client) error {
+	if len(clients) == 0 {
+		return nil
+	}
+	db := database.GetDB()
+	var kept []string
+	if err := db.Table("clients").
+		Joins("JOIN client_inbounds ON client_inbounds.client_id = clients.id").
+		Joins("JOIN inbounds ON inbounds.id = client_inbounds.inbound_id").
+		Where("inbounds.node_id = ?", nodeID).
+		Pluck("clients.email", &kept).Error; err != nil {
+		return err
+	}
+	keep := make(map[string]struct{}, len(kept))
+	for _, e := range kept {
+		keep[e] = struct{}{}
+	}
+	gone := make([]string, 0, len(clients))
+	for _, c := range clients {
+		if _, ok := keep[c.Email]; !ok && c.Email != "" {
+			gone = append(gone, c.Email)
+		}
+	}
+	for _, batch := range chunkStrings(gone, sqliteMaxVars) {
+		if err := db.Where("node_id = ? AND email IN ?", nodeID, batch).
+			Delete(&model.NodeClientTraffic{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refresh carries edits of the primary, including clients added, removed or
+// changed while it is failed over, onto its standby copy.
+func (s *FailoverService) refresh(p, c *model.Inbound) error {
+	want := standbyCopyOf(p)
+	if failoverFingerprint(want) == failoverFingerprint(c) {
+		return nil
+	}
+	clients, err := s.inboundService.GetClients(p)
+	if err != nil {
+		return err
+	}
+	return runSerializedTx(func(tx *gorm.DB) error {
+		if want.Port != c.Port {
+			want.Id = c.Id
+			conflict, err := checkPortConflictTx(tx, want, c.Id)
+			if err != nil {
+				return err
+			}
+			if conflict != nil {
+				return common.NewError(conflict.String())
+			}
+		}
+		if err := tx.Model(model.Inbound{}).Where("id = ?", c.Id).Updates(map[string]any{
+			"remark":              want.Remark,
+			"sub_sort_index":      want.SubSortIndex,
+			"enable":              true,
+			"port":                want.Port,
+			"protocol":            want.Protocol,
+			"settings":            want.Settings,
+			"stream_settings":     want.StreamSettings,
+			"sniffing":            want.Sniffing,
+			"disable_flow":        want.DisableFlow,
+			"traffic_coefficient": want.TrafficCoefficient,
+		}).Error; err != nil {
+			return err
+		}
+		if err := s.inboundService.clientService.SyncInbound(tx, c.Id, clients); err != nil {
+			return err
+		}
+		return (&NodeService{}).MarkNodeDirtyTx(tx, *c.NodeID)
+	})
+}
+
+// failoverFingerprint compares JSON decoded and leaves client timestamps out,
+// since the node re-encodes the settings it stores.
+func failoverFingerprint(ib *model.Inbound) string {
+	canon := func(raw string) any {
+		var v any
+		if json.Unmarshal([]byte(raw), &v) != nil {
+			return raw
+		}
+		return v
+	}
+	settings, _ := canon(ib.Settings).(map[string]any)
+	var clients []any
+	if settings != nil {
+		clients, _ = settings["clients"].([]any)
+		delete(settings, "clients")
+	}
+	for _, cl := range clients {
+		if m, ok := cl.(map[string]any); ok {
+			delete(m, "created_at")
+			delete(m, "updated_at")
+		}
+	}
+	email := func(v any) string {
+		m, _ := v.(map[string]any)
+		e, _ := m["email"].(string)
+		return strings.ToLower(e)
+	}
+	slices.SortStableFunc(clients, func(a, b any) int { return strings.Compare(email(a), email(b)) })
+	b, _ := json.Marshal([]any{
+		ib.Remark, ib.SubSortIndex, ib.Enable, ib.Port, ib.Protocol, ib.DisableFlow,
+		model.TrafficWeight(ib.TrafficCoefficient), settings, clients,
+		canon(ib.StreamSettings), canon(ib.Sniffing),
+	})
+	return string(b)
+}
+
+// StandbyCopyIds returns the ids of every standby copy, which the state
+// document leaves out: they follow from their primary.
+func (s *FailoverService) StandbyCopyIds() (map[int]struct{}, error) {
+	var ids []int
+	if err := database.GetDB().Model(model.Inbound{}).Where("failover_of IS NOT NULL").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		out[id] = struct{}{}
+	}
+	return out, nil
+}
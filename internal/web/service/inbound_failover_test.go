@@ -0,0 +1,146 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+func resetFailoverClock(t *testing.T, down, up time.Duration) {
+	t.Helper()
+	prev := failoverHysteresis
+	failoverHysteresis.down, failoverHysteresis.up = down, up
+	reset := func() {
+		nodeStatusMu.Lock()
+		nodeStatusSince = map[int]nodeStatusMark{}
+		nodeStatusMu.Unlock()
+	}
+	reset()
+	t.Cleanup(func() {
+		failoverHysteresis = prev
+		reset()
+	})
+}
+
+func standbyCopies(t *testing.T) []model.Inbound {
+	t.Helper()
+	var out []model.Inbound
+	if err := database.GetDB().Where("failover_of IS NOT NULL").Find(&out).Error; err != nil {
+		t.Fatalf("load standby copies: %v", err)
+	}
+	return out
+}
+
+func TestValidateStandbyNode(t *testing.T) {
+	setupBulkDB(t)
+	nodeID, _ := setupNodeRuntime(t)
+	db := database.GetDB()
+
+	missing := 999
+	same := nodeID
+	cases := []struct {
+		name string
+		ib   *model.Inbound
+		ok   bool
+	}{
+		{"no standby", &model.Inbound{NodeID: &nodeID}, true},
+		{"local inbound", &model.Inbound{StandbyNodeID: &same}, false},
+		{"own node", &model.Inbound{NodeID: &nodeID, StandbyNodeID: &same}, false},
+		{"missing node", &model.Inbound{NodeID: &nodeID, StandbyNodeID: &missing}, false},
+		{"copy", &model.Inbound{NodeID: &nodeID, StandbyNodeID: &missing, FailoverOf: &same}, false},
+	}
+	for _, tc := range cases {
+		if err := validateStandbyNode(db, tc.ib); (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+// The inbound moves to its standby after the hysteresis, follows client edits
+// of the primary while there, and moves back once its node has recovered.
+func TestFailover_ActivateRefreshRestore(t *testing.T) {
+	setupBulkDB(t)
+	resetFailoverClock(t, 0, time.Hour)
+	db := database.GetDB()
+
+	primaryNode, _ := setupNodeRuntime(t)
+	standbyNode := &model.Node{Name: "standby", Address: "127.0.0.2", Port: 2096, ApiToken: "tok", Enable: true, Status: "online"}
+	if err := db.Create(standbyNode).Error; err != nil {
+		t.Fatalf("create standby node: %v", err)
+	}
+	alice := model.Client{ID: uuid.NewString(), Email: "alice@fo", SubID: "alice-fo", Enable: true}
+	p := nodeInbound(t, primaryNode, 34001, []model.Client{alice})
+	if err := db.Model(p).Update("standby_node_id", standbyNode.Id).Error; err != nil {
+		t.Fatalf("set standby node: %v", err)
+	}
+
+	svc := &FailoverService{}
+	reconcile := func(wantChanged bool) {
+		t.Helper()
+		changed, err := svc.Reconcile()
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		if changed != wantChanged {
+			t.Fatalf("Reconcile changed = %v, want %v", changed, wantChanged)
+		}
+	}
+
+	reconcile(false)
+	if got := standbyCopies(t); len(got) != 0 {
+		t.Fatalf("online node must not fail over, got %d copies", len(got))
+	}
+
+	if err := db.Model(model.Node{}).Where("id = ?", primaryNode).Update("status", "offline").Error; err != nil {
+		t.Fatalf("mark node offline: %v", err)
+	}
+	reconcile(true)
+	copies := standbyCopies(t)
+	if len(copies) != 1 {
+		t.Fatalf("want one standby copy, got %d", len(copies))
+	}
+	c := copies[0]
+	if c.NodeID == nil || *c.NodeID != standbyNode.Id || *c.FailoverOf != p.Id || c.Port != p.Port {
+		t.Fatalf("standby copy = node %v of %v port %d", c.NodeID, c.FailoverOf, c.Port)
+	}
+	clientSvc := &ClientService{}
+	if list, _ := clientSvc.ListForInbound(nil, c.Id); len(list) != 1 || list[0].Email != alice.Email {
+		t.Fatalf("standby copy clients = %v, want [alice@fo]", emailsOf(list))
+	}
+	var sn model.Node
+	if err := db.First(&sn, standbyNode.Id).Error; err != nil || !sn.ConfigDirty {
+		t.Fatalf("standby node must be marked dirty for the push: dirty=%v err=%v", sn.ConfigDirty, err)
+	}
+
+	bob := model.Client{ID: uuid.NewString(), Email: "bob@fo", SubID: "bob-fo", Enable: true}
+	if _, err := clientSvc.AddInboundClient(&InboundService{}, &model.Inbound{Id: p.Id, Protocol: model.VLESS, Settings: clientsSettings(t, []model.Client{bob})}); err != nil {
+		t.Fatalf("add client to primary: %v", err)
+	}
+	reconcile(false)
+	if list, _ := clientSvc.ListForInbound(nil, c.Id); len(list) != 2 {
+		t.Fatalf("standby copy must follow the primary's clients, got %v", emailsOf(list))
+	}
+
+	if err := db.Model(model.Node{}).Where("id = ?", primaryNode).Update("status", "online").Error; err != nil {
+		t.Fatalf("mark node online: %v", err)
+	}
+	reconcile(false)
+	if got := standbyCopies(t); len(got) != 1 {
+		t.Fatalf("a freshly recovered node must wait out the hysteresis, got %d copies", len(got))
+	}
+
+	nodeStatusMu.Lock()
+	nodeStatusSince[primaryNode] = nodeStatusMark{status: "online", since: time.Now().Add(-2 * time.Hour)}
+	nodeStatusMu.Unlock()
+	reconcile(true)
+	if got := standbyCopies(t); len(got) != 0 {
+		t.Fatalf("standby copy must be removed once the node is back, got %d", len(got))
+	}
+	if list, _ := clientSvc.ListForInbound(nil, p.Id); len(list) != 2 {
+		t.Fatalf("primary clients after restore = %v, want 2", emailsOf(list))
+	}
+}
@@ -48,14 +48,19 @@ func (s *InboundService) nodePushPlan(ib *model.Inbound) (runtime.Runtime, bool,
 	if err != nil {
 		return nil, false, false, err
 	}
-	if !enabled || status == "offline" {
+	if !enabled {
 		return nil, false, true, nil
 	}
+	// An offline node still gets a runtime: its operations queue in the node's
+	// outbox and replay when it is back.
+	if status == "offline" {
+		return newOutboxRuntime(*ib.NodeID, nil), true, true, nil
+	}
 	rt, err := s.runtimeFor(ib)
 	if err != nil {
-		return nil, false, true, nil
+		return newOutboxRuntime(*ib.NodeID, nil), true, true, nil
 	}
-	return rt, true, false, nil
+	return newOutboxRuntime(*ib.NodeID, rt), true, false, nil
 }
 
 func (s *InboundService) NodeIsPending(nodeID *int) bool {
@@ -246,6 +251,15 @@ func nodeClientRenewed(existing *xray.ClientTraffic, cs xray.ClientTraffic, cano
 	return canon.Up < base.Up || canon.Down < base.Down
 }
 
+// nodeQuotaDisabled reports whether a node disabled cs for reaching its quota
+// rather than for expiring.
+func nodeQuotaDisabled(cs xray.ClientTraffic, nowMs int64) bool {
+	if cs.Enable || cs.Total <= 0 || cs.Up+cs.Down < cs.Total {
+		return false
+	}
+	return cs.ExpiryTime <= 0 || cs.ExpiryTime > nowMs
+}
+
 // liftActivatedClientRecordExpiries copies a node-activated deadline from
 // client_traffics onto client records still holding the negative duration (#5714).
 func liftActivatedClientRecordExpiries(tx *gorm.DB) error {
@@ -378,11 +392,15 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 	// origin (an inbound the node forwards from its own sub-node) is kept as-is,
 	// so a chained Node1->Node2->Node3 still attributes Node3's inbounds to Node3.
 	var nodeRow model.Node
-	db.Select("guid", "config_dirty", "inbound_sync_mode", "inbound_tags").Where("id = ?", nodeID).First(&nodeRow)
+	db.Select("guid", "config_dirty", "inbound_sync_mode", "inbound_tags", "traffic_coefficient").Where("id = ?", nodeID).First(&nodeRow)
 	// Re-read inside the serialized writer: a client added while this snapshot
 	// was in flight marks the node dirty after the caller sampled the flag.
 	dirty = dirty || nodeRow.ConfigDirty
 	nodeRow.Id = nodeID
+	nodeWeight := model.TrafficWeight(nodeRow.TrafficCoefficient)
+	quotaWeighted := func(c *model.Inbound) bool {
+		return nodeWeight*model.TrafficWeight(c.TrafficCoefficient) != 1
+	}
 	unmanagedTag := unmanagedTagPredicate(&nodeRow)
 	selfKey := effectiveNodeKey(&model.Node{Id: nodeID, Guid: nodeRow.Guid})
 	guidShared := nodeRow.Guid != "" && selfKey != nodeRow.Guid
@@ -474,13 +492,23 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 	// the reset clamp re-add a lower sibling as fresh traffic (#5274).
 	snapEmailsAll := make(map[string]struct{})
 	nodeEmailTotals := make(map[string]nodeTrafficCounter)
+	// The shared counter can't say which inbound carried the bytes, so the
+	// highest coefficient among a client's inbounds applies.
+	inboundWeights := make(map[string]float64)
 	for _, snapIb := range snap.Inbounds {
 		if snapIb == nil {
 			continue
 		}
+		weight := 1.0
+		if c, ok := tagToCentral[snapIb.Tag]; ok {
+			weight = model.TrafficWeight(c.TrafficCoefficient)
+		}
 		for i := range snapIb.ClientStats {
 			email := snapIb.ClientStats[i].Email
 			snapEmailsAll[email] = struct{}{}
+			if w, seen := inboundWeights[email]; !seen || weight > w {
+				inboundWeights[email] = weight
+			}
 			cur := nodeEmailTotals[email]
 			if snapIb.ClientStats[i].Up > cur.Up {
 				cur.Up = snapIb.ClientStats[i].Up
@@ -659,6 +687,11 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 		if deduped, changed := dedupeSettingsClients(adoptedSettings); changed {
 			adoptedSettings = deduped
 		}
+		if quotaWeighted(c) {
+			if restored, changed := restoreSettingsQuota(adoptedSettings, c.Settings); changed {
+				adoptedSettings = restored
+			}
+		}
 
 		updates := map[string]any{}
 		if !dirty {
@@ -793,6 +826,8 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 		structuralChange = true
 	}
 
+	// History keeps the raw bytes; client_traffics counts them weighted.
+	historyDeltas := make(map[string]nodeTrafficCounter)
 	for _, snapIb := range snap.Inbounds {
 		if snapIb == nil {
 			continue
@@ -807,6 +842,7 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 
 			// Node-wide total, not this inbound's possibly-stale copy (#5274).
 			canon := nodeEmailTotals[cs.Email]
+			weight := nodeWeight * inboundWeights[cs.Email]
 
 			base, seen := nodeBaselines[cs.Email]
 			var deltaUp, deltaDown int64
@@ -844,8 +880,8 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 					ExpiryTime: cs.ExpiryTime,
 					Reset:      cs.Reset,
 					ResetDay:   cs.ResetDay,
-					Up:         seedUp,
-					Down:       seedDown,
+					Up:         weighTraffic(seedUp, weight),
+					Down:       weighTraffic(seedDown, weight),
 					LastOnline: cs.LastOnline,
 				}
 				if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "email"}}, DoNothing: true}).
@@ -864,61 +900,69 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 			}
 
 			existing := centralCSByEmail[cs.Email]
+			// Nodes get no quota for weighted clients (runtime.Remote.wire), so
+			// the master keeps its own and ignores a raw-quota disable from an older push.
+			total, enable := cs.Total, cs.Enable
+			if weight != 1 && existing != nil {
+				total = existing.Total
+				enable = enable || nodeQuotaDisabled(cs, now)
+			}
 			if existing != nil &&
-				(existing.Enable != cs.Enable ||
-					existing.Total != cs.Total ||
+				(existing.Enable != enable ||
+					existing.Total != total ||
 					existing.ExpiryTime != mergeActivationExpiry(existing.ExpiryTime, cs.ExpiryTime) ||
 					existing.Reset != cs.Reset) {
 				structuralChange = true
 			}
 
 			if seen && existing != nil && nodeClientRenewed(existing, cs, canon, base) {
-				// A renewal starts a fresh quota window: adopt the node's counters
-				// and enable state, drop stale pushes (mirrors autoRenewClients).
+				// A renewal starts a fresh quota window: adopt the node's enable state,
+				// drop stale pushes and restart the counters (mirrors autoRenewClients).
 				if err := tx.Exec(
-					fmt.Sprintf(
-						`UPDATE client_traffics
-						 SET up = ?, down = ?, enable = ?, total = ?,
-						     expiry_time = ?, reset = ?, reset_day = ?, last_online = %s
-						 WHERE email = ?`,
-						database.GreatestExpr("last_online", "?"),
-					),
-					canon.Up, canon.Down, cs.Enable, cs.Total,
-					cs.ExpiryTime, cs.Reset, cs.ResetDay,
-					cs.LastOnline, cs.Email,
+					`UPDATE client_traffics
+					 SET up = 0, down = 0, enable = ?, total = ?,
+					     expiry_time = ?, reset = ?, reset_day = ?
+					 WHERE email = ?`,
+					enable, total, cs.ExpiryTime, cs.Reset, cs.ResetDay, cs.Email,
 				).Error; err != nil {
 					return false, err
 				}
 				if err := clearGlobalTraffic(tx, cs.Email); err != nil {
 					return false, err
 				}
-			} else {
-				enableExpr := database.ClientTrafficEnableMergeExpr()
-				// expiry_time merge mirrors mergeActivationExpiry: a node that has not
-				// yet seen the client's first connection keeps reporting the negative
-				// "start after first connect" duration, which must never reset the
-				// absolute deadline another node already activated. A positive node
-				// value is still adopted (e.g. auto-renew moves the deadline forward).
-				// CAST(? AS BIGINT): in the `<= 0` comparison Postgres would otherwise
-				// infer int4 from the literal and overflow on real expiry values.
-				if err := tx.Exec(
-					fmt.Sprintf(
-						`UPDATE client_traffics
-						 SET up = %s, down = %s, enable = %s, total = ?,
-						     expiry_time = CASE WHEN expiry_time > 0 AND CAST(? AS BIGINT) <= 0 THEN expiry_time ELSE CAST(? AS BIGINT) END,
-						     reset = ?, reset_day = ?, last_online = %s
-						 WHERE email = ?`,
-						database.ClampedAddExpr("up"),
-						database.ClampedAddExpr("down"),
-						enableExpr,
-						database.GreatestExpr("last_online", "?"),
-					),
-					deltaUp, deltaDown, cs.Enable, cs.Total,
-					cs.ExpiryTime, cs.ExpiryTime, cs.Reset, cs.ResetDay,
-					cs.LastOnline, cs.Email,
-				).Error; err != nil {
-					return false, err
-				}
+				// The node's counters restarted at zero too, so its whole counter is
+				// this window's delta and gets weighted like any other.
+				deltaUp, deltaDown = canon.Up, canon.Down
+			}
+			enableExpr := database.ClientTrafficEnableMergeExpr()
+			// expiry_time merge mirrors mergeActivationExpiry: a node that has not
+			// yet seen the client's first connection keeps reporting the negative
+			// "start after first connect" duration, which must never reset the
+			// absolute deadline another node already activated. A positive node
+			// value is still adopted (e.g. auto-renew moves the deadline forward).
+			// CAST(? AS BIGINT): in the `<= 0` comparison Postgres would otherwise
+			// infer int4 from the literal and overflow on real expiry values.
+			if err := tx.Exec(
+				fmt.Sprintf(
+					`UPDATE client_traffics
+					 SET up = %s, down = %s, enable = %s, total = ?,
+					     expiry_time = CASE WHEN expiry_time > 0 AND CAST(? AS BIGINT) <= 0 THEN expiry_time ELSE CAST(? AS BIGINT) END,
+					     reset = ?, reset_day = ?, last_online = %s
+					 WHERE email = ?`,
+					database.ClampedAddExpr("up"),
+					database.ClampedAddExpr("down"),
+					enableExpr,
+					database.GreatestExpr("last_online", "?"),
+				),
+				weighTraffic(deltaUp, weight), weighTraffic(deltaDown, weight), enable, total,
+				cs.ExpiryTime, cs.ExpiryTime, cs.Reset, cs.ResetDay,
+				cs.LastOnline, cs.Email,
+			).Error; err != nil {
+				return false, err
+			}
+			if deltaUp > 0 || deltaDown > 0 {
+				d := historyDeltas[cs.Email]
+				historyDeltas[cs.Email] = nodeTrafficCounter{Up: d.Up + deltaUp, Down: d.Down + deltaDown}
 			}
 			if err := s.upsertNodeBaseline(tx, nodeID, cs.Email, canon.Up, canon.Down); err != nil {
 				return false, err
@@ -964,6 +1008,7 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 			structuralChange = true
 		}
 	}
+	recordClientTrafficHistory(tx, nodeID, historyDeltas)
 
 	type oldSet struct {
 		inboundID int
@@ -1001,9 +1046,10 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 			logger.Warningf("setRemoteTraffic: parse clients for tag %q failed: %v", snapIb.Tag, gcErr)
 			continue
 		}
+		weighted := quotaWeighted(c)
 		csEnableByEmail := make(map[string]bool, len(snapIb.ClientStats))
 		for _, cs := range snapIb.ClientStats {
-			csEnableByEmail[cs.Email] = cs.Enable
+			csEnableByEmail[cs.Email] = cs.Enable || weighted && nodeQuotaDisabled(cs, now)
 		}
 		filtered := clients[:0]
 		for i := range clients {
@@ -1025,18 +1071,22 @@ func (s *InboundService) setRemoteTrafficLocked(nodeID int, snap *runtime.Traffi
 			var localMeta []struct {
 				Email   string
 				Comment string `gorm:"column:comment"`
+				TotalGB int64  `gorm:"column:total_gb"`
 			}
 			if err := tx.Table("clients").
-				Select("email, comment").
+				Select("email, comment, total_gb").
 				Where("email IN ?", localEmails).
 				Find(&localMeta).Error; err == nil {
-				commentByEmail := make(map[string]string, len(localMeta))
-				for _, m := range localMeta {
-					commentByEmail[m.Email] = m.Comment
+				metaByEmail := make(map[string]int, len(localMeta))
+				for i, m := range localMeta {
+					metaByEmail[m.Email] = i
 				}
 				for i := range filtered {
-					if cmt, ok := commentByEmail[filtered[i].Email]; ok {
-						filtered[i].Comment = cmt
+					if j, ok := metaByEmail[filtered[i].Email]; ok {
+						filtered[i].Comment = localMeta[j].Comment
+						if weighted {
+							filtered[i].TotalGB = localMeta[j].TotalGB
+						}
 					}
 				}
 			}
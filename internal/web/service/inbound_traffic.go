@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"math"
 	"slices"
 	"strconv"
 	"strings"
@@ -164,7 +165,13 @@ func (s *InboundService) addClientTraffic(tx *gorm.DB, traffics []*xray.ClientTr
 			trafficByEmail[traffics[i].Email] = traffics[i]
 		}
 	}
+	weights, err := localTrafficWeights(tx, emails)
+	if err != nil {
+		return err
+	}
 	now := time.Now().UnixMilli()
+	// History keeps the raw bytes; client_traffics counts them weighted.
+	historyDeltas := make(map[string]nodeTrafficCounter, len(dbClientTraffics))
 	// Use atomic per-row UPDATE instead of read-modify-write Save. tx.Save
 	// issues UPDATEs in slice order, which varies between concurrent callers;
 	// on PostgreSQL two transactions locking the same rows in opposite order
@@ -175,6 +182,10 @@ func (s *InboundService) addClientTraffic(tx *gorm.DB, traffics []*xray.ClientTr
 		if !ok || (t.Up == 0 && t.Down == 0) {
 			continue
 		}
+		up, down := t.Up, t.Down
+		if w, weighted := weights[ct.Email]; weighted {
+			up, down = weighTraffic(up, w), weighTraffic(down, w)
+		}
 		if err = tx.Exec(
 			fmt.Sprintf(
 				`UPDATE client_traffics SET up = %s, down = %s, last_online = %s WHERE email = ?`,
@@ -182,11 +193,14 @@ func (s *InboundService) addClientTraffic(tx *gorm.DB, traffics []*xray.ClientTr
 				database.ClampedAddExpr("down"),
 				database.GreatestExpr("last_online", "?"),
 			),
-			t.Up, t.Down, now, ct.Email,
+			up, down, now, ct.Email,
 		).Error; err != nil {
 			logger.Warning("AddClientTraffic update data ", err)
+			continue
 		}
+		historyDeltas[ct.Email] = nodeTrafficCounter{Up: t.Up, Down: t.Down}
 	}
+	recordClientTrafficHistory(tx, 0, historyDeltas)
 
 	// adjustTraffics converts delayed-start rows (negative ExpiryTime → absolute
 	// deadline) in-memory. Persist that conversion now since the traffic UPDATE
@@ -205,6 +219,47 @@ func (s *InboundService) addClientTraffic(tx *gorm.DB, traffics []*xray.ClientTr
 	return nil
 }
 
+// localTrafficWeights returns the coefficient of every email not counted 1:1.
+// Xray counts per server, so a client on several inbounds takes the highest.
+func localTrafficWeights(tx *gorm.DB, emails []string) (map[string]float64, error) {
+	var weighted int64
+	if err := tx.Model(&model.Inbound{}).
+		Where("node_id IS NULL AND traffic_coefficient IS NOT NULL AND traffic_coefficient <> 1").
+		Count(&weighted).Error; err != nil || weighted == 0 {
+		return nil, err
+	}
+	weights := make(map[string]float64)
+	for _, batch := range chunkStrings(emails, sqlInChunk) {
+		var rows []struct {
+			Email  string
+			Weight float64
+		}
+		if err := tx.Table("client_inbounds AS ci").
+			Select("c.email AS email, MAX(COALESCE(i.traffic_coefficient, 1)) AS weight").
+			Joins("JOIN clients c ON c.id = ci.client_id").
+			Joins("JOIN inbounds i ON i.id = ci.inbound_id").
+			Where("i.node_id IS NULL AND c.email IN ?", batch).
+			Group("c.email").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			if r.Weight != 1 {
+				weights[r.Email] = r.Weight
+			}
+		}
+	}
+	return weights, nil
+}
+
+// weighTraffic applies a traffic coefficient to a byte count.
+func weighTraffic(bytes int64, weight float64) int64 {
+	if weight == 1 {
+		return bytes
+	}
+	return int64(math.Round(float64(bytes) * weight))
+}
+
 func (s *InboundService) adjustTraffics(tx *gorm.DB, dbClientTraffics []*xray.ClientTraffic) ([]*xray.ClientTraffic, map[string]int64, error) {
 	now := time.Now().UnixMilli()
 
@@ -592,6 +647,9 @@ func (s *InboundService) DelClientStat(tx *gorm.DB, email string) error {
 	if err := clearGlobalTraffic(tx, email); err != nil {
 		return err
 	}
+	if err := deleteClientTrafficHistory(tx, email); err != nil {
+		return err
+	}
 	return tx.Where("email = ?", email).Delete(&model.NodeClientTraffic{}).Error
 }
 
@@ -612,6 +670,9 @@ func (s *InboundService) delClientStatsByEmails(tx *gorm.DB, emails []string) er
 		if err := tx.Where("email IN ?", batch).Delete(&model.NodeClientTraffic{}).Error; err != nil {
 			return err
 		}
+		if err := deleteClientTrafficHistory(tx, batch...); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -647,16 +708,7 @@ func (s *InboundService) ResetClientTraffic(id int, clientEmail string) (needRes
 		return inner
 	})
 	if err == nil {
-		s.resetMtprotoClientQuota(clientEmail)
-		if resetInbound != nil && resetInbound.NodeID != nil {
-			if rt, rterr := s.runtimeFor(resetInbound); rterr == nil {
-				if e := rt.ResetClientTraffic(context.Background(), resetInbound, clientEmail); e != nil {
-					logger.Warning("ResetClientTraffic: remote propagation to", rt.Name(), "failed:", e)
-				}
-			} else {
-				logger.Warning("ResetClientTraffic: runtime lookup failed:", rterr)
-			}
-		}
+		s.propagateClientReset(resetInbound, clientEmail)
 	}
 	return
 }
@@ -710,32 +762,13 @@ func (s *InboundService) resetClientTrafficLocked(id int, clientEmail string) (b
 		}
 	}
 
-	traffic.Up = 0
-	traffic.Down = 0
-	traffic.Enable = true
-
 	db := database.GetDB()
-	now := time.Now().UnixMilli()
 	inbound, err := s.GetInbound(id)
 	if err != nil {
 		return false, nil, err
 	}
 	if err := db.Transaction(func(tx *gorm.DB) error {
-		if err := adjustGroupBaselinesForRemovedTraffic(tx, []string{clientEmail}); err != nil {
-			return err
-		}
-		if err := tx.Save(traffic).Error; err != nil {
-			return err
-		}
-		if err := clearGlobalTraffic(tx, clientEmail); err != nil {
-			return err
-		}
-		if err := tx.Where("email = ?", clientEmail).Delete(&model.NodeClientTraffic{}).Error; err != nil {
-			return err
-		}
-		if err := tx.Model(model.Inbound{}).
-			Where("id = ?", id).
-			Update("last_traffic_reset_time", now).Error; err != nil {
+		if err := resetClientUsage(tx, id, clientEmail); err != nil {
 			return err
 		}
 		if reenableNodeID != nil {
@@ -764,6 +797,47 @@ func (s *InboundService) resetClientTrafficLocked(id int, clientEmail string) (b
 	return needRestart, inbound, nil
 }
 
+// resetClientUsage zeroes and re-enables a client's counters in tx and restarts
+// its node baselines; inboundId, when set, records the reset on that inbound.
+func resetClientUsage(tx *gorm.DB, inboundId int, email string) error {
+	if err := adjustGroupBaselinesForRemovedTraffic(tx, []string{email}); err != nil {
+		return err
+	}
+	if err := tx.Model(&xray.ClientTraffic{}).Where("email = ?", email).
+		Updates(map[string]any{"up": 0, "down": 0, "enable": true}).Error; err != nil {
+		return err
+	}
+	if err := clearGlobalTraffic(tx, email); err != nil {
+		return err
+	}
+	if err := tx.Where("email = ?", email).Delete(&model.NodeClientTraffic{}).Error; err != nil {
+		return err
+	}
+	if inboundId == 0 {
+		return nil
+	}
+	return tx.Model(model.Inbound{}).
+		Where("id = ?", inboundId).
+		Update("last_traffic_reset_time", time.Now().UnixMilli()).Error
+}
+
+// propagateClientReset clears a reset client's MTProto quota and, for a node
+// inbound, its counters on the node.
+func (s *InboundService) propagateClientReset(ib *model.Inbound, email string) {
+	s.resetMtprotoClientQuota(email)
+	if ib == nil || ib.NodeID == nil {
+		return
+	}
+	rt, err := s.runtimeFor(ib)
+	if err != nil {
+		logger.Warning("ResetClientTraffic: runtime lookup failed:", err)
+		return
+	}
+	if e := rt.ResetClientTraffic(context.Background(), ib, email); e != nil {
+		logger.Warning("ResetClientTraffic: remote propagation to", rt.Name(), "failed:", e)
+	}
+}
+
 func (s *InboundService) ResetAllTraffics() error {
 	err := submitTrafficWrite(func() error {
 		return s.resetAllTrafficsLocked()
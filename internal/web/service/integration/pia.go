@@ -3,6 +3,7 @@ package integration
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net"
 	"sort"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/mhsanaei/3x-ui/v3/internal/crypto/nodetoken"
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/secrets"
 	piaprotocol "github.com/mhsanaei/3x-ui/v3/internal/pia"
 	"github.com/mhsanaei/3x-ui/v3/internal/util/wireguard"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
@@ -248,6 +250,9 @@ func (s *PiaService) saveStored(stored piaStored) error {
 
 func (s *PiaService) loadStored() (*piaStored, error) {
 	raw, err := s.GetPia()
+	if errors.Is(err, secrets.ErrNoKey) {
+		return nil, piaprotocol.NewError(piaprotocol.CodeTokenRejected, "The PIA token is encrypted but NODE_TOKEN_ENCRYPTION is off. Sign in again.")
+	}
 	if err != nil || strings.TrimSpace(raw) == "" {
 		return nil, err
 	}
@@ -90,6 +90,7 @@ func (tb *tierBuf) readSamples() []MetricSample {
 // series is the rollup ladder for one metric: a sample is fed to every tier.
 type series struct {
 	tiers []*tierBuf
+	last  float64
 }
 
 func newSeries() *series {
@@ -104,6 +105,7 @@ func (s *series) add(unixSec int64, v float64) {
 	for _, tb := range s.tiers {
 		tb.add(unixSec, v)
 	}
+	s.last = v
 }
 
 // pickTier returns the finest tier whose window covers spanSeconds, falling back
@@ -141,6 +143,17 @@ func (h *metricHistory) append(metric string, t time.Time, v float64) {
 	s.add(t.Unix(), v)
 }
 
+// latest returns the raw value of the last sample appended to a metric.
+func (h *metricHistory) latest(metric string) (float64, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.series[metric]
+	if s == nil {
+		return 0, false
+	}
+	return s.last, true
+}
+
 // drop removes the entire history for one metric. Used when a node is deleted so
 // its old samples don't linger forever in the singleton.
 func (h *metricHistory) drop(metric string) {
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/config"
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+)
+
+// OpenMetricsContentType is the media type Prometheus negotiates for the
+// OpenMetrics text exposition format.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// MetricsService reads only what the panel already keeps: the last status
+// sample, the traffic tables, node heartbeats and Xray's expvar/observatory.
+type MetricsService struct {
+	settingService SettingService
+	inboundService InboundService
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// openMetrics writes metric families; labels are passed as name/value pairs.
+type openMetrics struct {
+	buf bytes.Buffer
+}
+
+func (m *openMetrics) family(name, typ, unit, help string) {
+	m.buf.WriteString("# TYPE " + name + " " + typ + "\n")
+	if unit != "" {
+		m.buf.WriteString("# UNIT " + name + " " + unit + "\n")
+	}
+	m.buf.WriteString("# HELP " + name + " " + help + "\n")
+}
+
+func (m *openMetrics) sample(name string, v float64, labels ...string) {
+	m.buf.WriteString(name)
+	if len(labels) > 0 {
+		m.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.buf.WriteByte(',')
+			}
+			m.buf.WriteString(labels[i] + `="` + metricLabelEscaper.Replace(labels[i+1]) + `"`)
+		}
+		m.buf.WriteByte('}')
+	}
+	m.buf.WriteByte(' ')
+	m.buf.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	m.buf.WriteByte('\n')
+}
+
+func metricBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Write renders one scrape. status may be nil before the first status tick,
+// in which case the host gauges are left out.
+func (s *MetricsService) Write(w io.Writer, status *Status, xm *XrayMetricsService) error {
+	m := &openMetrics{}
+	m.family("xui_build", "info", "", "Panel build.")
+	m.sample("xui_build_info", 1, "version", config.GetPanelVersion())
+
+	if status != nil {
+		writeHostMetrics(m, status)
+	}
+	if xm != nil && xm.State().Enabled {
+		writeXrayRuntimeMetrics(m)
+	}
+	if err := s.writeTrafficMetrics(m); err != nil {
+		return err
+	}
+	if xm != nil {
+		obs := xm.ObservatorySnapshot()
+		m.family("xui_outbound_alive", "gauge", "", "Whether the observatory's last probe of the outbound succeeded.")
+		for _, o := range obs {
+			m.sample("xui_outbound_alive", metricBool(o.Alive), "outbound", o.Tag)
+		}
+		m.family("xui_outbound_delay_milliseconds", "gauge", "milliseconds", "Observatory probe delay.")
+		for _, o := range obs {
+			m.sample("xui_outbound_delay_milliseconds", float64(o.Delay), "outbound", o.Tag)
+		}
+	}
+	if err := writeNodeMetrics(m); err != nil {
+		return err
+	}
+	m.buf.WriteString("# EOF\n")
+	_, err := m.buf.WriteTo(w)
+	return err
+}
+
+func writeHostMetrics(m *openMetrics, st *Status) {
+	m.family("xui_cpu_usage_percent", "gauge", "percent", "Host CPU usage.")
+	m.sample("xui_cpu_usage_percent", st.Cpu)
+	m.family("xui_cpu_cores", "gauge", "", "Physical CPU cores.")
+	m.sample("xui_cpu_cores", float64(st.CpuCores))
+	m.family("xui_memory_used_bytes", "gauge", "bytes", "Host memory in use.")
+	m.sample("xui_memory_used_bytes", float64(st.Mem.Current))
+	m.family("xui_memory_total_bytes", "gauge", "bytes", "Host memory size.")
+	m.sample("xui_memory_total_bytes", float64(st.Mem.Total))
+	m.family("xui_swap_used_bytes", "gauge", "bytes", "Swap in use.")
+	m.sample("xui_swap_used_bytes", float64(st.Swap.Current))
+	m.family("xui_swap_total_bytes", "gauge", "bytes", "Swap size.")
+	m.sample("xui_swap_total_bytes", float64(st.Swap.Total))
+	m.family("xui_disk_used_bytes", "gauge", "bytes", "Root filesystem in use.")
+	m.sample("xui_disk_used_bytes", float64(st.Disk.Current))
+	m.family("xui_disk_total_bytes", "gauge", "bytes", "Root filesystem size.")
+	m.sample("xui_disk_total_bytes", float64(st.Disk.Total))
+	m.family("xui_load_average", "gauge", "", "System load average.")
+	for i, period := range []string{"1m", "5m", "15m"} {
+		if i < len(st.Loads) {
+			m.sample("xui_load_average", st.Loads[i], "period", period)
+		}
+	}
+	m.family("xui_uptime_seconds", "gauge", "seconds", "Host uptime.")
+	m.sample("xui_uptime_seconds", float64(st.Uptime))
+	m.family("xui_connections", "gauge", "", "Open sockets on the host.")
+	m.sample("xui_connections", float64(st.TcpCount), "proto", "tcp")
+	m.sample("xui_connections", float64(st.UdpCount), "proto", "udp")
+	m.family("xui_network_sent_bytes", "counter", "bytes", "Bytes sent on physical interfaces since boot.")
+	m.sample("xui_network_sent_bytes_total", float64(st.NetTraffic.Sent))
+	m.family("xui_network_received_bytes", "counter", "bytes", "Bytes received on physical interfaces since boot.")
+	m.sample("xui_network_received_bytes_total", float64(st.NetTraffic.Recv))
+	m.family("xui_xray_up", "gauge", "", "Whether the local Xray core is running.")
+	m.sample("xui_xray_up", metricBool(st.Xray.State == Running))
+}
+
+func writeXrayRuntimeMetrics(m *openMetrics) {
+	gauges := []struct{ key, name, unit, help string }{
+		{"xrAlloc", "xui_xray_heap_alloc_bytes", "bytes", "Xray heap in use."},
+		{"xrSys", "xui_xray_sys_bytes", "bytes", "Memory Xray obtained from the OS."},
+		{"xrHeapObjects", "xui_xray_heap_objects", "", "Live objects on the Xray heap."},
+	}
+	for _, g := range gauges {
+		if v, ok := xrayMetrics.latest(g.key); ok {
+			m.family(g.name, "gauge", g.unit, g.help)
+			m.sample(g.name, v)
+		}
+	}
+	if v, ok := xrayMetrics.latest("xrNumGC"); ok {
+		m.family("xui_xray_gc_cycles", "counter", "", "Completed Xray GC cycles.")
+		m.sample("xui_xray_gc_cycles_total", v)
+	}
+}
+
+func (s *MetricsService) writeTrafficMetrics(m *openMetrics) error {
+	db := database.GetDB()
+	var nodes []model.Node
+	if err := db.Select("id", "name").Find(&nodes).Error; err != nil {
+		return err
+	}
+	nodeNames := make(map[int]string, len(nodes))
+	for _, n := range nodes {
+		nodeNames[n.Id] = n.Name
+	}
+
+	var inbounds []model.Inbound
+	if err := db.Select("id", "tag", "protocol", "node_id", "up", "down", "enable").
+		Order("id").Find(&inbounds).Error; err != nil {
+		return err
+	}
+	inboundTags := make(map[int]string, len(inbounds))
+	labels := func(ib model.Inbound) []string {
+		l := []string{"inbound", ib.Tag, "protocol", string(ib.Protocol)}
+		if ib.NodeID != nil {
+			l = append(l, "node", nodeNames[*ib.NodeID])
+		}
+		return l
+	}
+	m.family("xui_inbound_up_bytes", "counter", "bytes", "Upload traffic through the inbound.")
+	for _, ib := range inbounds {
+		inboundTags[ib.Id] = ib.Tag
+		m.sample("xui_inbound_up_bytes_total", float64(ib.Up), labels(ib)...)
+	}
+	m.family("xui_inbound_down_bytes", "counter", "bytes", "Download traffic through the inbound.")
+	for _, ib := range inbounds {
+		m.sample("xui_inbound_down_bytes_total", float64(ib.Down), labels(ib)...)
+	}
+	m.family("xui_inbound_enabled", "gauge", "", "Whether the inbound is enabled.")
+	for _, ib := range inbounds {
+		m.sample("xui_inbound_enabled", metricBool(ib.Enable), labels(ib)...)
+	}
+
+	var outbounds []model.OutboundTraffics
+	if err := db.Order("tag").Find(&outbounds).Error; err != nil {
+		return err
+	}
+	m.family("xui_outbound_up_bytes", "counter", "bytes", "Upload traffic through the outbound.")
+	for _, ob := range outbounds {
+		m.sample("xui_outbound_up_bytes_total", float64(ob.Up), "outbound", ob.Tag)
+	}
+	m.family("xui_outbound_down_bytes", "counter", "bytes", "Download traffic through the outbound.")
+	for _, ob := range outbounds {
+		m.sample("xui_outbound_down_bytes_total", float64(ob.Down), "outbound", ob.Tag)
+	}
+
+	var clientCount int64
+	if err := db.Model(&xray.ClientTraffic{}).Count(&clientCount).Error; err != nil {
+		return err
+	}
+	online := s.inboundService.GetOnlineClients()
+	m.family("xui_clients", "gauge", "", "Clients with a traffic record.")
+	m.sample("xui_clients", float64(clientCount))
+	m.family("xui_clients_online", "gauge", "", "Clients seen online in the last traffic poll.")
+	m.sample("xui_clients_online", float64(len(online)))
+
+	perClient, err := s.settingService.GetMetricsClientLabels()
+	if err != nil || !perClient {
+		return err
+	}
+	var clients []xray.ClientTraffic
+	if err := db.Select("inbound_id", "email", "up", "down", "enable").
+		Order("email").Find(&clients).Error; err != nil {
+		return err
+	}
+	onlineSet := make(map[string]struct{}, len(online))
+	for _, email := range online {
+		onlineSet[email] = struct{}{}
+	}
+	m.family("xui_client_up_bytes", "counter", "bytes", "Upload traffic of the client.")
+	for _, ct := range clients {
+		m.sample("xui_client_up_bytes_total", float64(ct.Up), "email", ct.Email, "inbound", inboundTags[ct.InboundId])
+	}
+	m.family("xui_client_down_bytes", "counter", "bytes", "Download traffic of the client.")
+	for _, ct := range clients {
+		m.sample("xui_client_down_bytes_total", float64(ct.Down), "email", ct.Email, "inbound", inboundTags[ct.InboundId])
+	}
+	m.family("xui_client_enabled", "gauge", "", "Whether the client is enabled.")
+	for _, ct := range clients {
+		m.sample("xui_client_enabled", metricBool(ct.Enable), "email", ct.Email, "inbound", inboundTags[ct.InboundId])
+	}
+	m.family("xui_client_online", "gauge", "", "Whether the client was online in the last traffic poll.")
+	for _, ct := range clients {
+		_, on := onlineSet[ct.Email]
+		m.sample("xui_client_online", metricBool(on), "email", ct.Email, "inbound", inboundTags[ct.InboundId])
+	}
+	return nil
+}
+
+func writeNodeMetrics(m *openMetrics) error {
+	var nodes []model.Node
+	if err := database.GetDB().Where("enable = ?", true).Order("name").Find(&nodes).Error; err != nil {
+		return err
+	}
+	m.family("xui_node_up", "gauge", "", "Whether the node answered its last heartbeat.")
+	for _, n := range nodes {
+		m.sample("xui_node_up", metricBool(n.Status == "online"), "node", n.Name)
+	}
+	m.family("xui_node_latency_milliseconds", "gauge", "milliseconds", "Heartbeat round-trip time.")
+	for _, n := range nodes {
+		m.sample("xui_node_latency_milliseconds", float64(n.LatencyMs), "node", n.Name)
+	}
+	m.family("xui_node_cpu_usage_percent", "gauge", "percent", "Node CPU usage at the last heartbeat.")
+	for _, n := range nodes {
+		m.sample("xui_node_cpu_usage_percent", n.CpuPct, "node", n.Name)
+	}
+	m.family("xui_node_memory_usage_percent", "gauge", "percent", "Node memory usage at the last heartbeat.")
+	for _, n := range nodes {
+		m.sample("xui_node_memory_usage_percent", n.MemPct, "node", n.Name)
+	}
+	m.family("xui_node_last_heartbeat_timestamp_seconds", "gauge", "seconds", "Time of the last successful heartbeat.")
+	for _, n := range nodes {
+		m.sample("xui_node_last_heartbeat_timestamp_seconds", float64(n.LastHeartbeat), "node", n.Name)
+	}
+	return nil
+}
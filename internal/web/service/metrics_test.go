@@ -0,0 +1,70 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+)
+
+func TestMetricsWriteOpenMetrics(t *testing.T) {
+	setupBulkDB(t)
+	db := database.GetDB()
+	node := &model.Node{Name: "de-1", Address: "10.0.0.2", Port: 2053, Status: "online", LatencyMs: 42}
+	if err := db.Create(node).Error; err != nil {
+		t.Fatal(err)
+	}
+	rows := []any{
+		&model.Inbound{UserId: 1, Tag: "in-a", Port: 40001, Protocol: model.VLESS, Enable: true, Up: 10, Down: 20, Settings: `{"clients":[]}`},
+		&model.Inbound{UserId: 1, Tag: "in-b", Port: 40002, Protocol: model.Trojan, NodeID: &node.Id, Up: 5, Settings: `{"clients":[]}`},
+		&xray.ClientTraffic{InboundId: 1, Email: `al"ice`, Enable: true, Up: 7, Down: 8},
+		&model.OutboundTraffics{Tag: "direct", Up: 3, Down: 4},
+	}
+	for _, r := range rows {
+		if err := db.Create(r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	status := &Status{Cpu: 12.5, Loads: []float64{0.5, 0.25, 0.1}}
+	status.Xray.State = Running
+
+	scrape := func() string {
+		var buf bytes.Buffer
+		if err := (&MetricsService{}).Write(&buf, status, &XrayMetricsService{}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	out := scrape()
+	for _, want := range []string{
+		"# TYPE xui_inbound_up_bytes counter\n# UNIT xui_inbound_up_bytes bytes\n",
+		`xui_inbound_up_bytes_total{inbound="in-a",protocol="vless"} 10`,
+		`xui_inbound_up_bytes_total{inbound="in-b",protocol="trojan",node="de-1"} 5`,
+		`xui_outbound_down_bytes_total{outbound="direct"} 4`,
+		`xui_load_average{period="5m"} 0.25`,
+		`xui_node_latency_milliseconds{node="de-1"} 42`,
+		"xui_cpu_usage_percent 12.5\n",
+		"xui_xray_up 1\n",
+		"xui_clients 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("scrape is missing %q", want)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Error("scrape must end with # EOF")
+	}
+	if strings.Contains(out, "xui_client_up_bytes") {
+		t.Fatal("per-client series must stay off until metricsClientLabels is enabled")
+	}
+
+	if err := (&SettingService{}).setBool("metricsClientLabels", true); err != nil {
+		t.Fatal(err)
+	}
+	if out := scrape(); !strings.Contains(out, `xui_client_down_bytes_total{email="al\"ice",inbound="in-a"} 8`) {
+		t.Fatalf("per-client series missing or unescaped:\n%s", out)
+	}
+}
@@ -128,6 +128,11 @@ func (s *NodeService) GetAll() ([]*model.Node, error) {
 	for _, n := range nodes {
 		decryptToken(n)
 	}
+	if counts, err := nodeOutboxCounts(db); err == nil {
+		for _, n := range nodes {
+			n.OutboxCount = counts[n.Id]
+		}
+	}
 
 	type inboundRow struct {
 		Id     int
@@ -437,7 +442,7 @@ func (s *NodeService) normalize(n *model.Node) error {
 		}
 		n.InboundTags = tags
 	}
-	if n.TlsVerifyMode == "pin" {
+	if n.TlsVerifyMode == "pin" || (n.TlsVerifyMode == "mtls" && n.PinnedCertSha256 != "") {
 		if _, err := runtime.DecodeCertPin(n.PinnedCertSha256); err != nil {
 			return common.NewError(err.Error())
 		}
@@ -524,6 +529,7 @@ func (s *NodeService) Update(id int, in *model.Node) error {
 		"inbound_sync_mode":     in.InboundSyncMode,
 		"inbound_tags":          string(inboundTagsJSON),
 		"outbound_tag":          in.OutboundTag,
+		"traffic_coefficient":   in.TrafficCoefficient,
 	}
 	if err := db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Model(model.Node{}).Where("id = ?", id).Updates(updates).Error; err != nil {
@@ -584,6 +590,7 @@ func (s *NodeService) UpdateFromRequest(id int, req *NodeMutationRequest) error
 		"inbound_sync_mode":     in.InboundSyncMode,
 		"inbound_tags":          string(inboundTagsJSON),
 		"outbound_tag":          in.OutboundTag,
+		"traffic_coefficient":   in.TrafficCoefficient,
 	}
 	if err := db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Model(model.Node{}).Where("id = ?", id).Updates(updates).Error; err != nil {
@@ -839,6 +846,9 @@ func (s *NodeService) Delete(id int) error {
 		if err := tx.Where("node_id = ?", id).Delete(&model.NodeClientTraffic{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("node_id = ?", id).Delete(&model.NodeOutboxEntry{}).Error; err != nil {
+			return err
+		}
 		guids := []string{synthNodeGuid(id)}
 		if guid != "" {
 			guids = append(guids, guid)
@@ -893,56 +903,6 @@ func (s *NodeService) GetWebCertFiles(id int) (*runtime.WebCertFiles, error) {
 	return remote.GetWebCertFiles(ctx)
 }
 
-// NodeUpdateResult reports the outcome of triggering a panel self-update on one
-// node so the UI can show per-node success/failure for a bulk request.
-type NodeUpdateResult struct {
-	Id    int    `json:"id"`
-	Name  string `json:"name"`
-	OK    bool   `json:"ok"`
-	Error string `json:"error,omitempty"`
-}
-
-// UpdatePanels triggers the official self-updater on each given node. Only
-// enabled, online nodes are eligible — an offline node can't be reached, so it
-// is reported as skipped rather than silently dropped.
-func (s *NodeService) UpdatePanels(ids []int, dev bool) ([]NodeUpdateResult, error) {
-	mgr := runtime.GetManager()
-	if mgr == nil {
-		return nil, fmt.Errorf("runtime manager unavailable")
-	}
-	results := make([]NodeUpdateResult, 0, len(ids))
-	for _, id := range ids {
-		n, err := s.GetById(id)
-		if err != nil || n == nil {
-			results = append(results, NodeUpdateResult{Id: id, OK: false, Error: "node not found"})
-			continue
-		}
-		res := NodeUpdateResult{Id: id, Name: n.Name}
-		switch {
-		case !n.Enable:
-			res.Error = "node is disabled"
-		case n.Status != "online":
-			res.Error = "node is offline"
-		default:
-			remote, remoteErr := mgr.RemoteFor(n)
-			if remoteErr != nil {
-				res.Error = remoteErr.Error()
-				break
-			}
-			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-			updErr := remote.UpdatePanel(ctx, dev)
-			cancel()
-			if updErr != nil {
-				res.Error = updErr.Error()
-			} else {
-				res.OK = true
-			}
-		}
-		results = append(results, res)
-	}
-	return results, nil
-}
-
 func (s *NodeService) UpdateHeartbeat(id int, p HeartbeatPatch) error {
 	db := database.GetDB()
 	updates := map[string]any{
@@ -1263,27 +1223,9 @@ func (s *NodeService) probe(ctx context.Context, n *model.Node, proxyURL string)
 	}
 
 	var envelope struct {
-		Success bool   `json:"success"`
-		Msg     string `json:"msg"`
-		Obj     *struct {
-			CpuPct float64 `json:"cpu"`
-			Mem    struct {
-				Current uint64 `json:"current"`
-				Total   uint64 `json:"total"`
-			} `json:"mem"`
-			Xray struct {
-				Version  string `json:"version"`
-				State    string `json:"state"`
-				ErrorMsg string `json:"errorMsg"`
-			} `json:"xray"`
-			PanelVersion string `json:"panelVersion"`
-			PanelGuid    string `json:"panelGuid"`
-			Uptime       uint64 `json:"uptime"`
-			NetIO        struct {
-				Up   uint64 `json:"up"`
-				Down uint64 `json:"down"`
-			} `json:"netIO"`
-		} `json:"obj"`
+		Success bool            `json:"success"`
+		Msg     string          `json:"msg"`
+		Obj     *nodeStatusWire `json:"obj"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
 		patch.LastError = "decode response: " + err.Error()
@@ -1293,7 +1235,32 @@ func (s *NodeService) probe(ctx context.Context, n *model.Node, proxyURL string)
 		patch.LastError = "remote returned success=false: " + envelope.Msg
 		return patch, errors.New(patch.LastError)
 	}
-	o := envelope.Obj
+	envelope.Obj.applyTo(&patch)
+	return patch, nil
+}
+
+// nodeStatusWire is the part of a node's /server/status the heartbeat keeps.
+type nodeStatusWire struct {
+	CpuPct float64 `json:"cpu"`
+	Mem    struct {
+		Current uint64 `json:"current"`
+		Total   uint64 `json:"total"`
+	} `json:"mem"`
+	Xray struct {
+		Version  string `json:"version"`
+		State    string `json:"state"`
+		ErrorMsg string `json:"errorMsg"`
+	} `json:"xray"`
+	PanelVersion string `json:"panelVersion"`
+	PanelGuid    string `json:"panelGuid"`
+	Uptime       uint64 `json:"uptime"`
+	NetIO        struct {
+		Up   uint64 `json:"up"`
+		Down uint64 `json:"down"`
+	} `json:"netIO"`
+}
+
+func (o *nodeStatusWire) applyTo(patch *HeartbeatPatch) {
 	patch.CpuPct = o.CpuPct
 	if o.Mem.Total > 0 {
 		patch.MemPct = float64(o.Mem.Current) * 100.0 / float64(o.Mem.Total)
@@ -1306,6 +1273,21 @@ func (s *NodeService) probe(ctx context.Context, n *model.Node, proxyURL string)
 	patch.UptimeSecs = o.Uptime
 	patch.NetUp = o.NetIO.Up
 	patch.NetDown = o.NetIO.Down
+}
+
+// HeartbeatFromTelemetry builds the heartbeat for a node from the status frame
+// it pushed, standing in for a probe while its telemetry stream is live.
+func HeartbeatFromTelemetry(data json.RawMessage, receivedAt time.Time, latency time.Duration) (HeartbeatPatch, error) {
+	var o nodeStatusWire
+	if err := json.Unmarshal(data, &o); err != nil {
+		return HeartbeatPatch{}, fmt.Errorf("decode status frame: %w", err)
+	}
+	patch := HeartbeatPatch{
+		Status:        "online",
+		LastHeartbeat: receivedAt.Unix(),
+		LatencyMs:     int(latency / time.Millisecond),
+	}
+	o.applyTo(&patch)
 	return patch, nil
 }
 
@@ -25,6 +25,7 @@ type NodeView struct {
 	InboundSyncMode     string   `json:"inboundSyncMode" example:"all"`
 	InboundTags         []string `json:"inboundTags" example:"[\"in-443-tcp\"]"`
 	OutboundTag         string   `json:"outboundTag" example:"direct"`
+	TrafficCoefficient  *float64 `json:"trafficCoefficient,omitempty" example:"1"`
 	Guid                string   `json:"guid" example:"node-guid"`
 	Status              string   `json:"status" example:"online"`
 	LastHeartbeat       int64    `json:"lastHeartbeat" example:"1700000000"`
@@ -73,6 +74,7 @@ func toNodeView(n *model.Node) *NodeView {
 		InboundSyncMode:     n.InboundSyncMode,
 		InboundTags:         n.InboundTags,
 		OutboundTag:         n.OutboundTag,
+		TrafficCoefficient:  n.TrafficCoefficient,
 		Guid:                n.Guid,
 		Status:              n.Status,
 		LastHeartbeat:       n.LastHeartbeat,
@@ -130,6 +132,7 @@ type NodeMutationRequest struct {
 	InboundSyncMode     string   `json:"inboundSyncMode" form:"inboundSyncMode" validate:"omitempty,oneof=all selected"`
 	InboundTags         []string `json:"inboundTags" form:"inboundTags"`
 	OutboundTag         string   `json:"outboundTag" form:"outboundTag"`
+	TrafficCoefficient  *float64 `json:"trafficCoefficient,omitempty" form:"trafficCoefficient" validate:"omitempty,gte=0,lte=100"`
 }
 
 func (r *NodeMutationRequest) validateCredentials(create bool) error {
@@ -178,6 +181,7 @@ func (r *NodeMutationRequest) toNode() *model.Node {
 		InboundSyncMode:     r.InboundSyncMode,
 		InboundTags:         r.InboundTags,
 		OutboundTag:         r.OutboundTag,
+		TrafficCoefficient:  r.TrafficCoefficient,
 	}
 	if r.ApiToken != nil {
 		n.ApiToken = *r.ApiToken
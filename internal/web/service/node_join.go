@@ -0,0 +1,389 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/crypto"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/netsafe"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/random"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+
+	"gorm.io/gorm"
+)
+
+// ErrJoinTokenInvalid is deliberately coarse so a caller cannot tell an
+// unknown token from a used or expired one.
+var ErrJoinTokenInvalid = errors.New("invalid, used or expired join token")
+
+// NodeJoinPath is where a master accepts join requests, relative to its base
+// path. It sits outside /panel/api: the join token is the only credential.
+const NodeJoinPath = "panel/nodes/join"
+
+const (
+	joinTokenLength     = 48
+	joinTokenDefaultTTL = 30 * time.Minute
+	joinTokenMaxTTL     = 24 * time.Hour
+	// Tokens are kept a while past expiry so the list still shows what became
+	// of them, then pruned.
+	joinTokenRetention = 7 * 24 * time.Hour
+	joinProbeTimeout   = 10 * time.Second
+)
+
+// NodeJoinTokenRequest mints a join token.
+type NodeJoinTokenRequest struct {
+	Name                string `json:"name" form:"name" example:"de-fra-2"`
+	TtlMinutes          int    `json:"ttlMinutes" form:"ttlMinutes" validate:"omitempty,gte=1,lte=1440" example:"30"`
+	AllowPrivateAddress bool   `json:"allowPrivateAddress" form:"allowPrivateAddress"`
+}
+
+// NodeJoinTokenCreated is a freshly minted join token. Token is the only time
+// the plaintext is available.
+type NodeJoinTokenCreated struct {
+	Id        int    `json:"id" example:"1"`
+	Token     string `json:"token" example:"k3Jd8s..."`
+	Name      string `json:"name" example:"de-fra-2"`
+	ExpiresAt int64  `json:"expiresAt" example:"1736003600000"`
+	// MasterPin is the SHA-256 of this panel's web certificate when it is not
+	// publicly trusted, for `x-ui join -pin`.
+	MasterPin string `json:"masterPin,omitempty" example:""`
+}
+
+// NodeJoinRequest is what `x-ui join` sends the master: where to reach the
+// node and how to authenticate to it.
+type NodeJoinRequest struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Scheme   string `json:"scheme"`
+	Address  string `json:"address"` // empty: the address the request came from
+	Port     int    `json:"port"`
+	BasePath string `json:"basePath"`
+	// ApiToken is a node-sync token the node minted for the master.
+	ApiToken string `json:"apiToken"`
+	// CertSha256 pins the node's web certificate; empty when it is publicly
+	// trusted.
+	CertSha256 string `json:"certSha256"`
+}
+
+// NodeJoinResponse tells the joined node its identity on the master and the
+// CA the master's client certificate is issued by.
+type NodeJoinResponse struct {
+	NodeId int    `json:"nodeId"`
+	Name   string `json:"name"`
+	CaCert string `json:"caCert"`
+}
+
+// CreateJoinToken mints a single-use join token valid for req.TtlMinutes
+// (30 by default).
+func (s *NodeService) CreateJoinToken(req *NodeJoinTokenRequest) (*NodeJoinTokenCreated, error) {
+	name := strings.TrimSpace(req.Name)
+	if name != "" {
+		if err := ensureNodeNameFree(database.GetDB(), name); err != nil {
+			return nil, err
+		}
+	}
+	ttl := joinTokenDefaultTTL
+	if req.TtlMinutes > 0 {
+		ttl = min(time.Duration(req.TtlMinutes)*time.Minute, joinTokenMaxTTL)
+	}
+	// The CA and the master client certificate are minted now, so the join
+	// itself never has to.
+	if _, err := s.NodeMtlsCaCert(); err != nil {
+		return nil, err
+	}
+	plaintext := random.Seq(joinTokenLength)
+	row := &model.NodeJoinToken{
+		Token:               crypto.HashTokenSHA256(plaintext),
+		Name:                name,
+		AllowPrivateAddress: req.AllowPrivateAddress,
+		ExpiresAt:           time.Now().Add(ttl).UnixMilli(),
+	}
+	if err := database.GetDB().Create(row).Error; err != nil {
+		return nil, err
+	}
+	return &NodeJoinTokenCreated{
+		Id:        row.Id,
+		Token:     plaintext,
+		Name:      row.Name,
+		ExpiresAt: row.ExpiresAt,
+		MasterPin: s.masterCertPin(),
+	}, nil
+}
+
+// masterCertPin returns the pin of this panel's web certificate, or "" when
+// there is none or it is publicly trusted.
+func (s *NodeService) masterCertPin() string {
+	certFile, err := (&SettingService{}).GetCertFile()
+	if err != nil || certFile == "" {
+		return ""
+	}
+	pin, err := selfSignedCertPin(certFile)
+	if err != nil {
+		logger.Warning("join token: read the panel certificate:", err)
+		return ""
+	}
+	return pin
+}
+
+// ListJoinTokens returns the join tokens minted in the last week, newest
+// first, pruning older ones.
+func (s *NodeService) ListJoinTokens() ([]*model.NodeJoinToken, error) {
+	db := database.GetDB()
+	cutoff := time.Now().Add(-joinTokenRetention).UnixMilli()
+	if err := db.Where("expires_at < ?", cutoff).Delete(&model.NodeJoinToken{}).Error; err != nil {
+		return nil, err
+	}
+	tokens := []*model.NodeJoinToken{}
+	if err := db.Order("id desc").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// DeleteJoinToken revokes a join token.
+func (s *NodeService) DeleteJoinToken(id int) error {
+	return database.GetDB().Delete(&model.NodeJoinToken{}, id).Error
+}
+
+func ensureNodeNameFree(tx *gorm.DB, name string) error {
+	var count int64
+	if err := tx.Model(&model.Node{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return common.NewErrorf("a node named %q already exists", name)
+	}
+	return nil
+}
+
+// Join registers req's node in mtls mode with its cert pinned, once the token is
+// valid and the master reached the node; remoteIP stands in for a missing address.
+func (s *NodeService) Join(ctx context.Context, req *NodeJoinRequest, remoteIP string) (*NodeJoinResponse, error) {
+	db := database.GetDB()
+	now := time.Now().UnixMilli()
+	token := &model.NodeJoinToken{}
+	err := db.Where("token = ? AND used_at = 0 AND expires_at > ?", crypto.HashTokenSHA256(req.Token), now).
+		First(token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJoinTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n := &model.Node{
+		Name:                token.Name,
+		Scheme:              req.Scheme,
+		Address:             strings.TrimSpace(req.Address),
+		Port:                req.Port,
+		BasePath:            req.BasePath,
+		ApiToken:            strings.TrimSpace(req.ApiToken),
+		Enable:              true,
+		AllowPrivateAddress: token.AllowPrivateAddress,
+		TlsVerifyMode:       "mtls",
+		PinnedCertSha256:    strings.TrimSpace(req.CertSha256),
+		InboundSyncMode:     "all",
+	}
+	if n.Name == "" {
+		n.Name = strings.TrimSpace(req.Name)
+	}
+	if n.Address == "" {
+		n.Address = remoteIP
+	}
+	if n.Scheme == "http" {
+		// Without TLS there is nothing to pin or to present a certificate over.
+		n.TlsVerifyMode, n.PinnedCertSha256 = "verify", ""
+	}
+	if n.ApiToken == "" {
+		return nil, common.NewError("the node sent no API token")
+	}
+	if err := s.normalize(n); err != nil {
+		return nil, err
+	}
+	if err := ensureNodeNameFree(db, n.Name); err != nil {
+		return nil, err
+	}
+	caCert, err := s.NodeMtlsCaCert()
+	if err != nil {
+		return nil, err
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, joinProbeTimeout)
+	defer cancel()
+	if _, err := s.Probe(probeCtx, n); err != nil {
+		return nil, common.NewError("the master cannot reach the node: ", FriendlyProbeError(err.Error()))
+	}
+
+	claim := db.Model(&model.NodeJoinToken{}).Where("id = ? AND used_at = 0", token.Id).Update("used_at", now)
+	if claim.Error != nil {
+		return nil, claim.Error
+	}
+	if claim.RowsAffected != 1 {
+		return nil, ErrJoinTokenInvalid
+	}
+	if err := s.Create(n); err != nil {
+		// Give the token back: nothing was registered with it.
+		if rerr := db.Model(&model.NodeJoinToken{}).Where("id = ?", token.Id).Update("used_at", 0).Error; rerr != nil {
+			logger.Warning("join: release token after a failed create:", rerr)
+		}
+		return nil, err
+	}
+	if err := db.Model(&model.NodeJoinToken{}).Where("id = ?", token.Id).Update("node_id", n.Id).Error; err != nil {
+		logger.Warning("join: record the node on its token:", err)
+	}
+	logger.Infof("node %q joined from %s", n.Name, remoteIP)
+	return &NodeJoinResponse{NodeId: n.Id, Name: n.Name, CaCert: caCert}, nil
+}
+
+// LocalJoinRequest describes this panel for a join request: its name, how its
+// web server listens and the pin of its certificate.
+func (s *NodeService) LocalJoinRequest() (*NodeJoinRequest, error) {
+	settings := &SettingService{}
+	port, err := settings.GetPort()
+	if err != nil {
+		return nil, err
+	}
+	basePath, err := settings.GetBasePath()
+	if err != nil {
+		return nil, err
+	}
+	certFile, err := settings.GetCertFile()
+	if err != nil {
+		return nil, err
+	}
+	req := &NodeJoinRequest{Scheme: "http", Port: port, BasePath: basePath}
+	if certFile != "" {
+		req.Scheme = "https"
+		if req.CertSha256, err = selfSignedCertPin(certFile); err != nil {
+			return nil, err
+		}
+	}
+	req.Name, _ = os.Hostname()
+	return req, nil
+}
+
+// selfSignedCertPin returns the hex SHA-256 of the leaf in certFile, or ""
+// when the chain is trusted by the system roots and needs no pin.
+func selfSignedCertPin(certFile string) (string, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return "", err
+	}
+	certs, err := parseCertificateBundlePEM(data)
+	if err != nil {
+		return "", err
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{Intermediates: intermediates}); err == nil {
+		return "", nil
+	}
+	sum := sha256.Sum256(certs[0].Raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// JoinMaster sends req to the master at masterURL. pin, when set, is the SHA-256
+// the master's certificate must match instead of chaining to the system roots.
+func (s *NodeService) JoinMaster(ctx context.Context, masterURL, pin string, req *NodeJoinRequest) (*NodeJoinResponse, error) {
+	u, err := url.Parse(strings.TrimSpace(masterURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, common.NewError("master URL must look like https://host:port/basepath/")
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + NodeJoinPath
+	// The master is reached with the same TLS policy the panel uses for
+	// nodes: system roots, or the pin when given.
+	mode := "verify"
+	if pin != "" {
+		mode = "pin"
+	}
+	client, err := runtime.HTTPClientForNode(&model.Node{Scheme: u.Scheme, TlsVerifyMode: mode, PinnedCertSha256: pin}, "")
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	// The master is often on the same private network as its nodes.
+	httpReq, err := http.NewRequestWithContext(netsafe.ContextWithAllowPrivate(ctx, true),
+		http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	var msg struct {
+		Success bool              `json:"success"`
+		Msg     string            `json:"msg"`
+		Obj     *NodeJoinResponse `json:"obj"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, common.NewErrorf("unexpected reply from the master (HTTP %d); check the URL and base path", resp.StatusCode)
+	}
+	if !msg.Success || msg.Obj == nil {
+		return nil, common.NewError("the master refused: ", msg.Msg)
+	}
+	return msg.Obj, nil
+}
+
+// TrustMasterCA adds the master's CA to the trusted node-API client CAs. It
+// reports whether the bundle changed; the listener picks it up on restart.
+func (s *NodeService) TrustMasterCA(caPem string) (bool, error) {
+	caPem = strings.TrimSpace(caPem)
+	incoming, err := parseCertificateBundlePEM([]byte(caPem))
+	if err != nil {
+		return false, common.NewError("invalid master CA certificate: ", err)
+	}
+	current, err := (&SettingService{}).getString(settingNodeMtlsClientCA)
+	if err != nil {
+		return false, err
+	}
+	current = strings.TrimSpace(current)
+	if current != "" {
+		trusted, err := parseCertificateBundlePEM([]byte(current))
+		if err != nil {
+			return false, err
+		}
+		known := func(c *x509.Certificate) bool {
+			for _, t := range trusted {
+				if t.Equal(c) {
+					return true
+				}
+			}
+			return false
+		}
+		missing := false
+		for _, c := range incoming {
+			missing = missing || !known(c)
+		}
+		if !missing {
+			return false, nil
+		}
+		caPem = current + "\n" + caPem
+	}
+	return true, s.SetNodeMtlsTrustCA(caPem)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/crypto"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+)
+
+// fakeJoiningNode serves the status endpoint the master probes a joining node
+// with, accepting only the given bearer token.
+func fakeJoiningNode(t *testing.T, apiToken string) (*httptest.Server, *NodeJoinRequest) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panel/api/server/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+apiToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "obj": map[string]any{"panelVersion": "test"}})
+	})
+	ts := httptest.NewTLSServer(mux)
+	t.Cleanup(ts.Close)
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+	sum := sha256.Sum256(ts.Certificate().Raw)
+	return ts, &NodeJoinRequest{
+		Name:       "joined",
+		Scheme:     "https",
+		Address:    u.Hostname(),
+		Port:       port,
+		BasePath:   "/",
+		ApiToken:   apiToken,
+		CertSha256: hex.EncodeToString(sum[:]),
+	}
+}
+
+func TestNodeJoin(t *testing.T) {
+	_ = setupSettingMtlsDB(t)
+	runtime.SetMasterClientCertProvider(func() (tls.Certificate, error) {
+		pair, err := (&SettingService{}).EnsureMasterClientCert()
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		return tls.X509KeyPair(pair.CertPEM, pair.KeyPEM)
+	})
+	t.Cleanup(func() { runtime.SetMasterClientCertProvider(nil) })
+	s := &NodeService{}
+
+	created, err := s.CreateJoinToken(&NodeJoinTokenRequest{AllowPrivateAddress: true})
+	if err != nil {
+		t.Fatalf("CreateJoinToken: %v", err)
+	}
+	var stored model.NodeJoinToken
+	if err := database.GetDB().First(&stored, created.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Token != crypto.HashTokenSHA256(created.Token) {
+		t.Fatal("the join token is not stored hashed")
+	}
+
+	_, req := fakeJoiningNode(t, "node-sync-token")
+	req.Token = "not-a-token"
+	if _, err := s.Join(context.Background(), req, "127.0.0.1"); !errors.Is(err, ErrJoinTokenInvalid) {
+		t.Fatalf("unknown token: err = %v, want ErrJoinTokenInvalid", err)
+	}
+
+	// A node the master cannot authenticate to does not spend the token.
+	req.Token = created.Token
+	req.ApiToken = "wrong"
+	if _, err := s.Join(context.Background(), req, "127.0.0.1"); err == nil {
+		t.Fatal("join with an API token the node rejects succeeded")
+	}
+	req.ApiToken = "node-sync-token"
+	resp, err := s.Join(context.Background(), req, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	node, err := s.GetById(resp.NodeId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Name != "joined" || node.TlsVerifyMode != "mtls" || node.PinnedCertSha256 != req.CertSha256 {
+		t.Fatalf("joined node = %q mode %q pin %q", node.Name, node.TlsVerifyMode, node.PinnedCertSha256)
+	}
+	if resp.CaCert == "" {
+		t.Fatal("the join response carries no CA")
+	}
+
+	if _, err := s.Join(context.Background(), req, "127.0.0.1"); !errors.Is(err, ErrJoinTokenInvalid) {
+		t.Fatalf("reused token: err = %v, want ErrJoinTokenInvalid", err)
+	}
+}
+
+func TestTrustMasterCA(t *testing.T) {
+	_ = setupSettingMtlsDB(t)
+	s := &NodeService{}
+	ca, err := s.NodeMtlsCaCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := s.TrustMasterCA(ca)
+	if err != nil || !changed {
+		t.Fatalf("first trust: changed=%v err=%v", changed, err)
+	}
+	changed, err = s.TrustMasterCA(ca)
+	if err != nil || changed {
+		t.Fatalf("trusting the same CA again: changed=%v err=%v", changed, err)
+	}
+	if _, err := s.TrustMasterCA("not a certificate"); err == nil {
+		t.Fatal("garbage accepted as a CA")
+	}
+}
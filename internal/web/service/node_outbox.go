@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+
+	"gorm.io/gorm"
+)
+
+// Node outbox: operations a node misses while unreachable are queued and
+// replayed in order, so an outage costs only those instead of a full re-push.
+
+const (
+	outboxStatusPending = "pending"
+	outboxStatusFailed  = "failed"
+
+	outboxBaseBackoff = 5 * time.Second
+	outboxMaxBackoff  = 10 * time.Minute
+	// outboxMaxAttempts is how many times the node may refuse an entry before
+	// automatic retries stop and the entry waits for an operator.
+	outboxMaxAttempts = 8
+	outboxErrorMaxLen = 500
+)
+
+const (
+	outboxOpAddInbound    = "addInbound"
+	outboxOpDelInbound    = "delInbound"
+	outboxOpUpdateInbound = "updateInbound"
+	outboxOpAddUser       = "addUser"
+	outboxOpRemoveUser    = "removeUser"
+	outboxOpUpdateUser    = "updateUser"
+	outboxOpDeleteUser    = "deleteUser"
+	outboxOpAddClient     = "addClient"
+	outboxOpDeleteClient  = "deleteClient"
+	outboxOpSubSortIndex  = "subSortIndex"
+)
+
+// outboxPayload snapshots inbounds when the operation is issued, not at
+// replay, so a rename followed by a delete replays as exactly that.
+type outboxPayload struct {
+	Old     *model.Inbound `json:"old,omitempty"`
+	Inbound *model.Inbound `json:"inbound,omitempty"`
+	Email   string         `json:"email,omitempty"`
+	Client  *model.Client  `json:"client,omitempty"`
+	User    map[string]any `json:"user,omitempty"`
+	Index   int            `json:"index,omitempty"`
+}
+
+// outboxInbound copies ib without its traffic rows, which the node never
+// receives and which would only bloat the stored payload.
+func outboxInbound(ib *model.Inbound) *model.Inbound {
+	if ib == nil {
+		return nil
+	}
+	c := *ib
+	c.ClientStats = nil
+	return &c
+}
+
+// outboxRuntime queues an operation only when the node is unreachable or has a
+// queue ahead of it; a node that answers and refuses still fails it.
+type outboxRuntime struct {
+	nodeID int
+	// live is the node's remote runtime, nil while the node is offline.
+	live runtime.Runtime
+	// queued records that this runtime has queued an operation, so the rest of
+	// the same edit queues behind it instead of overtaking it.
+	queued bool
+}
+
+func newOutboxRuntime(nodeID int, live runtime.Runtime) *outboxRuntime {
+	return &outboxRuntime{nodeID: nodeID, live: live}
+}
+
+func (o *outboxRuntime) Name() string {
+	if o.live != nil {
+		return o.live.Name()
+	}
+	return fmt.Sprintf("node:#%d", o.nodeID)
+}
+
+func (o *outboxRuntime) dispatch(op string, p outboxPayload, send func(runtime.Runtime) error) error {
+	if o.live != nil && !o.queued {
+		pending, err := nodeOutboxPending(o.nodeID)
+		if err != nil {
+			return err
+		}
+		if !pending {
+			err := send(o.live)
+			if err == nil || !runtime.IsNodeUnreachable(err) {
+				return err
+			}
+			logger.Warningf("node outbox: %s on %s failed, queueing: %v", op, o.live.Name(), err)
+		}
+	}
+	if err := enqueueNodeOp(o.nodeID, op, p); err != nil {
+		return err
+	}
+	o.queued = true
+	return nil
+}
+
+func (o *outboxRuntime) AddInbound(ctx context.Context, ib *model.Inbound) error {
+	return o.dispatch(outboxOpAddInbound, outboxPayload{Inbound: outboxInbound(ib)}, func(rt runtime.Runtime) error {
+		return rt.AddInbound(ctx, ib)
+	})
+}
+
+func (o *outboxRuntime) DelInbound(ctx context.Context, ib *model.Inbound) error {
+	return o.dispatch(outboxOpDelInbound, outboxPayload{Inbound: outboxInbound(ib)}, func(rt runtime.Runtime) error {
+		return rt.DelInbound(ctx, ib)
+	})
+}
+
+func (o *outboxRuntime) UpdateInbound(ctx context.Context, oldIb, newIb *model.Inbound) error {
+	p := outboxPayload{Old: outboxInbound(oldIb), Inbound: outboxInbound(newIb)}
+	return o.dispatch(outboxOpUpdateInbound, p, func(rt runtime.Runtime) error {
+		return rt.UpdateInbound(ctx, oldIb, newIb)
+	})
+}
+
+func (o *outboxRuntime) AddUser(ctx context.Context, ib *model.Inbound, user map[string]any) error {
+	p := outboxPayload{Inbound: outboxInbound(ib), User: user}
+	return o.dispatch(outboxOpAddUser, p, func(rt runtime.Runtime) error {
+		return rt.AddUser(ctx, ib, user)
+	})
+}
+
+func (o *outboxRuntime) RemoveUser(ctx context.Context, ib *model.Inbound, email string) error {
+	p := outboxPayload{Inbound: outboxInbound(ib), Email: email}
+	return o.dispatch(outboxOpRemoveUser, p, func(rt runtime.Runtime) error {
+		return rt.RemoveUser(ctx, ib, email)
+	})
+}
+
+func (o *outboxRuntime) UpdateUser(ctx context.Context, ib *model.Inbound, oldEmail string, client model.Client) error {
+	p := outboxPayload{Inbound: outboxInbound(ib), Email: oldEmail, Client: &client}
+	return o.dispatch(outboxOpUpdateUser, p, func(rt runtime.Runtime) error {
+		return rt.UpdateUser(ctx, ib, oldEmail, client)
+	})
+}
+
+func (o *outboxRuntime) DeleteUser(ctx context.Context, ib *model.Inbound, email string) error {
+	p := outboxPayload{Inbound: outboxInbound(ib), Email: email}
+	return o.dispatch(outboxOpDeleteUser, p, func(rt runtime.Runtime) error {
+		return rt.DeleteUser(ctx, ib, email)
+	})
+}
+
+func (o *outboxRuntime) AddClient(ctx context.Context, ib *model.Inbound, client model.Client) error {
+	p := outboxPayload{Inbound: outboxInbound(ib), Client: &client}
+	return o.dispatch(outboxOpAddClient, p, func(rt runtime.Runtime) error {
+		return rt.AddClient(ctx, ib, client)
+	})
+}
+
+func (o *outboxRuntime) DeleteClient(ctx context.Context, email string) error {
+	return o.dispatch(outboxOpDeleteClient, outboxPayload{Email: email}, func(rt runtime.Runtime) error {
+		return rt.DeleteClient(ctx, email)
+	})
+}
+
+func (o *outboxRuntime) SetInboundSubSortIndex(ctx context.Context, ib *model.Inbound, index int) error {
+	p := outboxPayload{Inbound: outboxInbound(ib), Index: index}
+	return o.dispatch(outboxOpSubSortIndex, p, func(rt runtime.Runtime) error {
+		return setSubSortIndexOn(ctx, rt, ib, index)
+	})
+}
+
+// The operations below act on live state only and are never queued.
+
+func (o *outboxRuntime) RestartXray(ctx context.Context) error {
+	if o.live == nil {
+		return fmt.Errorf("%s is offline", o.Name())
+	}
+	return o.live.RestartXray(ctx)
+}
+
+func (o *outboxRuntime) ResetClientTraffic(ctx context.Context, ib *model.Inbound, email string) error {
+	if o.live == nil {
+		return fmt.Errorf("%s is offline", o.Name())
+	}
+	return o.live.ResetClientTraffic(ctx, ib, email)
+}
+
+func (o *outboxRuntime) ResetInboundTraffic(ctx context.Context, ib *model.Inbound) error {
+	if o.live == nil {
+		return fmt.Errorf("%s is offline", o.Name())
+	}
+	return o.live.ResetInboundTraffic(ctx, ib)
+}
+
+func (o *outboxRuntime) ResetAllTraffics(ctx context.Context) error {
+	if o.live == nil {
+		return fmt.Errorf("%s is offline", o.Name())
+	}
+	return o.live.ResetAllTraffics(ctx)
+}
+
+// AdvancePushedInbound skips queued edits: the stale fingerprint makes the
+// reconcile after the queue drains re-send the inbound once.
+func (o *outboxRuntime) AdvancePushedInbound(prevIb, ib *model.Inbound) {
+	if o.queued {
+		return
+	}
+	if adv, ok := o.live.(pushedInboundAdvancer); ok {
+		adv.AdvancePushedInbound(prevIb, ib)
+	}
+}
+
+// pushedInboundAdvancer is implemented by runtimes that skip unchanged pushes
+// during reconcile.
+type pushedInboundAdvancer interface {
+	AdvancePushedInbound(prevIb, ib *model.Inbound)
+}
+
+func setSubSortIndexOn(ctx context.Context, rt runtime.Runtime, ib *model.Inbound, index int) error {
+	narrow, ok := rt.(interface {
+		SetInboundSubSortIndex(context.Context, *model.Inbound, int) error
+	})
+	if !ok {
+		return fmt.Errorf("runtime %s does not support narrow subscription ordering updates", rt.Name())
+	}
+	return narrow.SetInboundSubSortIndex(ctx, ib, index)
+}
+
+func nodeOutboxPending(nodeID int) (bool, error) {
+	var count int64
+	err := database.GetDB().Model(&model.NodeOutboxEntry{}).Where("node_id = ?", nodeID).Count(&count).Error
+	return count > 0, err
+}
+
+func nodeOutboxCounts(db *gorm.DB) (map[int]int, error) {
+	var rows []struct {
+		NodeId int
+		Count  int
+	}
+	if err := db.Model(&model.NodeOutboxEntry{}).
+		Select("node_id, COUNT(*) AS count").
+		Group("node_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		counts[row.NodeId] = row.Count
+	}
+	return counts, nil
+}
+
+// enqueueNodeOp drops an operation identical to the tail of the queue, which
+// is already going to be delivered.
+func enqueueNodeOp(nodeID int, op string, p outboxPayload) error {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("node outbox: encode %s: %w", op, err)
+	}
+	sum := sha256.Sum256([]byte(op + "\x00" + string(payload)))
+	key := hex.EncodeToString(sum[:])
+
+	db := database.GetDB()
+	var tail model.NodeOutboxEntry
+	if err := db.Where("node_id = ?", nodeID).Order("id desc").Limit(1).Find(&tail).Error; err != nil {
+		return err
+	}
+	if tail.Id > 0 && tail.Key == key && tail.Status == outboxStatusPending {
+		return nil
+	}
+	entry := model.NodeOutboxEntry{
+		NodeId:  nodeID,
+		Op:      op,
+		Key:     key,
+		Email:   p.Email,
+		Payload: string(payload),
+		Status:  outboxStatusPending,
+	}
+	if p.Inbound != nil {
+		entry.Tag = p.Inbound.Tag
+	}
+	if entry.Email == "" && p.Client != nil {
+		entry.Email = p.Client.Email
+	}
+	return db.Create(&entry).Error
+}
+
+// replayOutboxEntry must be safe to repeat, since a lost answer may hide an
+// applied request: inbound adds upsert and client adds accept an existing one.
+func replayOutboxEntry(ctx context.Context, rt runtime.Runtime, e *model.NodeOutboxEntry) error {
+	var p outboxPayload
+	if err := json.Unmarshal([]byte(e.Payload), &p); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	needInbound := e.Op != outboxOpDeleteClient
+	if needInbound && p.Inbound == nil {
+		return fmt.Errorf("payload has no inbound")
+	}
+	switch e.Op {
+	case outboxOpAddInbound:
+		return rt.UpdateInbound(ctx, p.Inbound, p.Inbound)
+	case outboxOpDelInbound:
+		return rt.DelInbound(ctx, p.Inbound)
+	case outboxOpUpdateInbound:
+		if p.Old == nil {
+			p.Old = p.Inbound
+		}
+		err := rt.UpdateInbound(ctx, p.Old, p.Inbound)
+		if err != nil && !runtime.IsNodeUnreachable(err) && p.Old.Tag != p.Inbound.Tag {
+			// A rename that already applied leaves only the new tag behind.
+			err = rt.UpdateInbound(ctx, p.Inbound, p.Inbound)
+		}
+		return err
+	case outboxOpAddUser:
+		return rt.AddUser(ctx, p.Inbound, p.User)
+	case outboxOpRemoveUser:
+		return rt.RemoveUser(ctx, p.Inbound, p.Email)
+	case outboxOpUpdateUser:
+		if p.Client == nil {
+			return fmt.Errorf("payload has no client")
+		}
+		return rt.UpdateUser(ctx, p.Inbound, p.Email, *p.Client)
+	case outboxOpDeleteUser:
+		return rt.DeleteUser(ctx, p.Inbound, p.Email)
+	case outboxOpAddClient:
+		if p.Client == nil {
+			return fmt.Errorf("payload has no client")
+		}
+		err := rt.AddClient(ctx, p.Inbound, *p.Client)
+		if err != nil && !runtime.IsNodeUnreachable(err) && clientAlreadyOnNode(err) {
+			return nil
+		}
+		return err
+	case outboxOpDeleteClient:
+		return rt.DeleteClient(ctx, p.Email)
+	case outboxOpSubSortIndex:
+		return setSubSortIndexOn(ctx, rt, p.Inbound, p.Index)
+	}
+	return fmt.Errorf("unknown operation %q", e.Op)
+}
+
+func clientAlreadyOnNode(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already in use") || strings.Contains(msg, "duplicate email")
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	d := outboxBaseBackoff
+	for i := 0; i < attempts && d < outboxMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, outboxMaxBackoff)
+}
+
+// ReplayNodeOutbox stops at the first entry that is failed, backing off or
+// fails now; runtime.IsNodeUnreachable separates an outage from a refusal.
+func (s *NodeService) ReplayNodeOutbox(ctx context.Context, rt runtime.Runtime, nodeID int) (pending bool, err error) {
+	db := database.GetDB()
+	var entries []*model.NodeOutboxEntry
+	if err := db.Where("node_id = ?", nodeID).Order("id asc").Find(&entries).Error; err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		now := time.Now()
+		if e.Status == outboxStatusFailed || e.NextAttemptAt > now.UnixMilli() {
+			return true, nil
+		}
+		if err := replayOutboxEntry(ctx, rt, e); err != nil {
+			if recErr := recordOutboxFailure(db, e, err, now); recErr != nil {
+				logger.Warning("node outbox: record failure:", recErr)
+			}
+			return true, fmt.Errorf("%s #%d: %w", e.Op, e.Id, err)
+		}
+		if err := db.Delete(&model.NodeOutboxEntry{}, e.Id).Error; err != nil {
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// recordOutboxFailure counts only refusals towards outboxMaxAttempts: a node
+// that stopped answering says nothing about the entry itself.
+func recordOutboxFailure(db *gorm.DB, e *model.NodeOutboxEntry, cause error, now time.Time) error {
+	attempts := e.Attempts
+	if !runtime.IsNodeUnreachable(cause) {
+		attempts++
+	}
+	status := outboxStatusPending
+	if attempts >= outboxMaxAttempts {
+		status = outboxStatusFailed
+	}
+	msg := cause.Error()
+	if len(msg) > outboxErrorMaxLen {
+		msg = msg[:outboxErrorMaxLen]
+	}
+	return db.Model(&model.NodeOutboxEntry{}).Where("id = ?", e.Id).Updates(map[string]any{
+		"attempts":        attempts,
+		"next_attempt_at": now.Add(outboxBackoff(attempts)).UnixMilli(),
+		"last_error":      msg,
+		"status":          status,
+	}).Error
+}
+
+// NodeOutboxWatermark returns the id of the node's newest queued entry, or 0.
+func (s *NodeService) NodeOutboxWatermark(nodeID int) (int, error) {
+	var tail model.NodeOutboxEntry
+	err := database.GetDB().Where("node_id = ?", nodeID).Order("id desc").Limit(1).Find(&tail).Error
+	return tail.Id, err
+}
+
+// DropNodeOutbox removes the node's entries up to and including upToID. It is
+// called after a full reconcile has pushed the state those entries led to.
+func (s *NodeService) DropNodeOutbox(nodeID, upToID int) error {
+	if upToID <= 0 {
+		return nil
+	}
+	return database.GetDB().Where("node_id = ? AND id <= ?", nodeID, upToID).Delete(&model.NodeOutboxEntry{}).Error
+}
+
+// ListNodeOutbox returns the node's queued operations in delivery order.
+func (s *NodeService) ListNodeOutbox(nodeID int) ([]*model.NodeOutboxEntry, error) {
+	entries := []*model.NodeOutboxEntry{}
+	err := database.GetDB().Where("node_id = ?", nodeID).Order("id asc").Find(&entries).Error
+	return entries, err
+}
+
+// RetryNodeOutboxEntry makes an entry due now and gives it a fresh set of
+// attempts, un-failing it if automatic retries had given up.
+func (s *NodeService) RetryNodeOutboxEntry(id int) error {
+	res := database.GetDB().Model(&model.NodeOutboxEntry{}).Where("id = ?", id).Updates(map[string]any{
+		"attempts":        0,
+		"next_attempt_at": 0,
+		"status":          outboxStatusPending,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DiscardNodeOutboxEntry drops an entry without delivering it and marks the
+// node dirty, so the next full reconcile pushes whatever the entry would have.
+func (s *NodeService) DiscardNodeOutboxEntry(id int) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var e model.NodeOutboxEntry
+		if err := tx.First(&e, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.NodeOutboxEntry{}, e.Id).Error; err != nil {
+			return err
+		}
+		return s.MarkNodeDirtyTx(tx, e.NodeId)
+	})
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+)
+
+// recordingRuntime logs the node operations delivered to it and fails them
+// while fail is set. Operations the tests never deliver stay unimplemented.
+type recordingRuntime struct {
+	runtime.Runtime
+	calls []string
+	fail  error
+}
+
+func (r *recordingRuntime) Name() string { return "node:test" }
+
+func (r *recordingRuntime) record(call string) error {
+	if r.fail != nil {
+		return r.fail
+	}
+	r.calls = append(r.calls, call)
+	return nil
+}
+
+func (r *recordingRuntime) UpdateInbound(_ context.Context, oldIb, newIb *model.Inbound) error {
+	return r.record("update " + oldIb.Tag + ">" + newIb.Tag)
+}
+
+func (r *recordingRuntime) AddClient(_ context.Context, ib *model.Inbound, client model.Client) error {
+	return r.record("addClient " + ib.Tag + " " + client.Email)
+}
+
+func (r *recordingRuntime) DeleteClient(_ context.Context, email string) error {
+	return r.record("deleteClient " + email)
+}
+
+func TestNodeOutbox(t *testing.T) {
+	setupBulkDB(t)
+	db := database.GetDB()
+	node := model.Node{Name: "n1", Address: "10.0.0.1", Port: 2053, Enable: true, Status: "offline"}
+	if err := db.Create(&node).Error; err != nil {
+		t.Fatal(err)
+	}
+	s := &NodeService{}
+	ctx := context.Background()
+	old := &model.Inbound{Tag: "in-a", NodeID: &node.Id}
+	renamed := &model.Inbound{Tag: "in-b", NodeID: &node.Id}
+
+	// While the node is offline every operation queues, and a repeat of the
+	// operation at the tail of the queue is dropped.
+	offline := newOutboxRuntime(node.Id, nil)
+	if err := offline.UpdateInbound(ctx, old, renamed); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := offline.DeleteClient(ctx, "bob"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Back online, a new operation still queues behind the ones owed.
+	live := &recordingRuntime{}
+	if err := newOutboxRuntime(node.Id, live).AddClient(ctx, renamed, model.Client{Email: "carol"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(live.calls) != 0 {
+		t.Fatalf("an operation overtook the queue: %v", live.calls)
+	}
+	entries, err := s.ListNodeOutbox(node.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("queued %d entries, want 3", len(entries))
+	}
+
+	live.fail = errors.New("remote: inbound is locked")
+	pending, err := s.ReplayNodeOutbox(ctx, live, node.Id)
+	if err == nil || !pending {
+		t.Fatalf("failed replay: pending=%v err=%v", pending, err)
+	}
+	entries, _ = s.ListNodeOutbox(node.Id)
+	if entries[0].Attempts != 1 || entries[0].LastError == "" || entries[0].NextAttemptAt == 0 {
+		t.Fatalf("failure not recorded: %+v", entries[0])
+	}
+	live.fail = nil
+	if pending, err := s.ReplayNodeOutbox(ctx, live, node.Id); err != nil || !pending {
+		t.Fatalf("replay during backoff: pending=%v err=%v", pending, err)
+	}
+	if len(live.calls) != 0 {
+		t.Fatal("an entry was delivered before its backoff elapsed")
+	}
+
+	if err := s.RetryNodeOutboxEntry(entries[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := s.ReplayNodeOutbox(ctx, live, node.Id); err != nil || pending {
+		t.Fatalf("replay: pending=%v err=%v", pending, err)
+	}
+	want := []string{"update in-a>in-b", "deleteClient bob", "addClient in-b carol"}
+	if !slices.Equal(live.calls, want) {
+		t.Fatalf("replayed %v, want %v", live.calls, want)
+	}
+
+	// With nothing owed, operations go straight to the node.
+	if err := newOutboxRuntime(node.Id, live).DeleteClient(ctx, "carol"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := s.ListNodeOutbox(node.Id); len(entries) != 0 || live.calls[len(live.calls)-1] != "deleteClient carol" {
+		t.Fatalf("live delivery queued: %d entries, calls %v", len(entries), live.calls)
+	}
+
+	// Discarding an entry hands it to the full reconcile.
+	if err := offline.DeleteClient(ctx, "dave"); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = s.ListNodeOutbox(node.Id)
+	if err := s.DiscardNodeOutboxEntry(entries[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	_, _, dirty, _, err := s.NodeSyncState(node.Id)
+	if err != nil || !dirty {
+		t.Fatalf("discard left the node clean: dirty=%v err=%v", dirty, err)
+	}
+	if entries, _ := s.ListNodeOutbox(node.Id); len(entries) != 0 {
+		t.Fatalf("discarded entry still queued: %d entries", len(entries))
+	}
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+
+	"gorm.io/gorm"
+)
+
+// Node rollouts are persisted and advanced by a job every few seconds, so a
+// master restart resumes a rollout where it stopped.
+
+const (
+	RolloutKindPanel = "panel"
+	RolloutKindXray  = "xray"
+
+	rolloutStatusRunning   = "running"
+	rolloutStatusSucceeded = "succeeded"
+	rolloutStatusAborted   = "aborted"
+	rolloutStatusCancelled = "cancelled"
+
+	rolloutStepPending   = "pending"
+	rolloutStepUpgrading = "upgrading"
+	rolloutStepDone      = "done"
+	rolloutStepFailed    = "failed"
+	rolloutStepSkipped   = "skipped"
+
+	rolloutDefaultCanary = 1
+	rolloutDefaultBatch  = 5
+	rolloutMaxBatch      = 50
+	rolloutListLimit     = 10
+)
+
+// rolloutHealthTimeout is how long a told node may take to report the target; a
+// panel upgrade restarts the whole panel, an Xray upgrade only swaps the core.
+var rolloutHealthTimeout = map[string]time.Duration{
+	RolloutKindPanel: 15 * time.Minute,
+	RolloutKindXray:  5 * time.Minute,
+}
+
+// rolloutMu serializes rollout writes, so an operator's cancel cannot race
+// the job advancing the same rollout.
+var rolloutMu sync.Mutex
+
+// NodeRolloutRequest starts a rollout over the given nodes. CanarySize and
+// BatchSize default to 1 and 5.
+type NodeRolloutRequest struct {
+	Ids        []int  `json:"ids"`
+	Dev        bool   `json:"dev"`
+	Version    string `json:"version"`
+	CanarySize int    `json:"canarySize"`
+	BatchSize  int    `json:"batchSize"`
+}
+
+// StartNodeRollout plans a rollout of target onto the requested nodes, skipping
+// disabled or offline ones up front; only one rollout runs at a time.
+func (s *NodeService) StartNodeRollout(kind, target string, req NodeRolloutRequest) (*model.NodeRollout, error) {
+	if kind != RolloutKindPanel && kind != RolloutKindXray {
+		return nil, common.NewErrorf("unknown rollout kind %q", kind)
+	}
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil, common.NewError("no target version to roll out")
+	}
+	if len(req.Ids) == 0 {
+		return nil, common.NewError("no nodes selected")
+	}
+	canary := req.CanarySize
+	if canary <= 0 {
+		canary = rolloutDefaultCanary
+	}
+	batch := req.BatchSize
+	if batch <= 0 {
+		batch = rolloutDefaultBatch
+	}
+	canary, batch = min(canary, rolloutMaxBatch), min(batch, rolloutMaxBatch)
+
+	rolloutMu.Lock()
+	defer rolloutMu.Unlock()
+	rollout := &model.NodeRollout{
+		Kind:          kind,
+		TargetVersion: target,
+		Dev:           kind == RolloutKindPanel && req.Dev,
+		CanarySize:    canary,
+		BatchSize:     batch,
+		Status:        rolloutStatusRunning,
+	}
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var running int64
+		if err := tx.Model(model.NodeRollout{}).Where("status = ?", rolloutStatusRunning).Count(&running).Error; err != nil {
+			return err
+		}
+		if running > 0 {
+			return common.NewError("another node rollout is still running")
+		}
+		seen := make(map[int]struct{}, len(req.Ids))
+		eligible := 0
+		for _, id := range req.Ids {
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+			var n model.Node
+			if err := tx.First(&n, id).Error; err != nil {
+				if database.IsNotFound(err) {
+					return common.NewErrorf("node %d not found", id)
+				}
+				return err
+			}
+			step := model.NodeRolloutStep{
+				NodeId:      n.Id,
+				NodeName:    n.Name,
+				State:       rolloutStepPending,
+				FromVersion: rolloutNodeVersion(kind, &n),
+			}
+			switch {
+			case !n.Enable:
+				step.State, step.Error = rolloutStepSkipped, "node is disabled"
+			case n.Status != "online":
+				step.State, step.Error = rolloutStepSkipped, "node is offline"
+			default:
+				step.Batch = rolloutBatchOf(eligible, canary, batch)
+				eligible++
+			}
+			rollout.Steps = append(rollout.Steps, step)
+		}
+		if eligible == 0 {
+			return common.NewError("none of the selected nodes is online")
+		}
+		return tx.Create(rollout).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("node rollout %d: %s %s on %d node(s), canary %d, batches of %d",
+		rollout.Id, kind, target, len(rollout.Steps), canary, batch)
+	return rollout, nil
+}
+
+// rolloutBatchOf places the i-th upgradable node: the first canary nodes form
+// batch 0, the rest follow in batches of size.
+func rolloutBatchOf(i, canary, size int) int {
+	if i < canary {
+		return 0
+	}
+	return 1 + (i-canary)/size
+}
+
+func rolloutNodeVersion(kind string, n *model.Node) string {
+	if kind == RolloutKindXray {
+		return n.XrayVersion
+	}
+	return n.PanelVersion
+}
+
+// rolloutVersionsEqual compares versions the way nodes and release tags spell
+// them: Xray reports "26.7.1" for the tag "v26.7.1".
+func rolloutVersionsEqual(a, b string) bool {
+	norm := func(v string) string { return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(v), "v")) }
+	return norm(a) != "" && norm(a) == norm(b)
+}
+
+// rolloutNodeHealthy reports whether n's heartbeat shows the target running. Only
+// a heartbeat newer than since counts, so a pre-upgrade status cannot pass.
+func rolloutNodeHealthy(r *model.NodeRollout, n *model.Node, since int64) bool {
+	return n.Status == "online" && n.XrayState == "running" && n.LastHeartbeat*1000 > since &&
+		rolloutVersionsEqual(rolloutNodeVersion(r.Kind, n), r.TargetVersion)
+}
+
+// AdvanceNodeRollouts moves every running rollout one step through the health
+// gate, batch starts and finish or abort. It returns the rollouts that changed.
+func (s *NodeService) AdvanceNodeRollouts() ([]*model.NodeRollout, error) {
+	rolloutMu.Lock()
+	defer rolloutMu.Unlock()
+	var rollouts []*model.NodeRollout
+	if err := database.GetDB().Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Where("status = ?", rolloutStatusRunning).Find(&rollouts).Error; err != nil {
+		return nil, err
+	}
+	var changed []*model.NodeRollout
+	for _, r := range rollouts {
+		did, err := s.advanceRollout(r)
+		if err != nil {
+			return changed, fmt.Errorf("node rollout %d: %w", r.Id, err)
+		}
+		if did {
+			changed = append(changed, r)
+		}
+	}
+	return changed, nil
+}
+
+func (s *NodeService) advanceRollout(r *model.NodeRollout) (bool, error) {
+	batch := -1
+	for _, st := range r.Steps {
+		if (st.State == rolloutStepPending || st.State == rolloutStepUpgrading) && (batch < 0 || st.Batch < batch) {
+			batch = st.Batch
+		}
+	}
+	if batch < 0 {
+		return true, s.finishRollout(r, rolloutStatusSucceeded, "")
+	}
+
+	var touched []*model.NodeRolloutStep
+	var start []*model.NodeRolloutStep
+	for i := range r.Steps {
+		st := &r.Steps[i]
+		if st.Batch != batch {
+			continue
+		}
+		switch st.State {
+		case rolloutStepUpgrading:
+			if s.checkRolloutStep(r, st) {
+				touched = append(touched, st)
+			}
+		case rolloutStepPending:
+			start = append(start, st)
+		}
+	}
+	if len(start) > 0 {
+		s.startRolloutSteps(r, start)
+		touched = append(touched, start...)
+	}
+	db := database.GetDB()
+	for _, st := range touched {
+		if err := db.Model(model.NodeRolloutStep{}).Where("id = ?", st.Id).Updates(map[string]any{
+			"state":        st.State,
+			"from_version": st.FromVersion,
+			"error":        st.Error,
+			"started_at":   st.StartedAt,
+			"finished_at":  st.FinishedAt,
+		}).Error; err != nil {
+			return true, err
+		}
+	}
+	for _, st := range r.Steps {
+		if st.State == rolloutStepFailed {
+			return true, s.finishRollout(r, rolloutStatusAborted, fmt.Sprintf("%s: %s", st.NodeName, st.Error))
+		}
+	}
+	return len(touched) > 0, nil
+}
+
+// checkRolloutStep applies the health gate to a node that was told to
+// upgrade. It reports whether the step changed.
+func (s *NodeService) checkRolloutStep(r *model.NodeRollout, st *model.NodeRolloutStep) bool {
+	now := time.Now()
+	n, err := s.GetById(st.NodeId)
+	if err != nil || n == nil {
+		st.State, st.Error, st.FinishedAt = rolloutStepFailed, "node was deleted", now.UnixMilli()
+		return true
+	}
+	if rolloutNodeHealthy(r, n, st.StartedAt) {
+		st.State, st.Error, st.FinishedAt = rolloutStepDone, "", now.UnixMilli()
+		logger.Infof("node rollout %d: %s is healthy on %s %s", r.Id, n.Name, r.Kind, r.TargetVersion)
+		return true
+	}
+	timeout := rolloutHealthTimeout[r.Kind]
+	if now.Sub(time.UnixMilli(st.StartedAt)) < timeout {
+		return false
+	}
+	state := n.XrayState
+	if n.XrayError != "" {
+		state += ": " + n.XrayError
+	}
+	st.State, st.FinishedAt = rolloutStepFailed, now.UnixMilli()
+	st.Error = fmt.Sprintf("not healthy on %s within %s (node %s, version %q, xray %s)",
+		r.TargetVersion, timeout, n.Status, rolloutNodeVersion(r.Kind, n), state)
+	return true
+}
+
+// startRolloutSteps tells a batch's nodes to upgrade, in parallel. Nodes already
+// on target are done; timeouts are left to the health gate, refusals fail.
+func (s *NodeService) startRolloutSteps(r *model.NodeRollout, steps []*model.NodeRolloutStep) {
+	mgr := runtime.GetManager()
+	var wg sync.WaitGroup
+	for _, st := range steps {
+		wg.Go(func() {
+			now := time.Now().UnixMilli()
+			fail := func(msg string) {
+				st.State, st.Error, st.StartedAt, st.FinishedAt = rolloutStepFailed, msg, now, now
+			}
+			n, err := s.GetById(st.NodeId)
+			switch {
+			case err != nil || n == nil:
+				fail("node was deleted")
+				return
+			case !n.Enable:
+				fail("node is disabled")
+				return
+			case n.Status != "online":
+				fail("node is offline")
+				return
+			}
+			st.FromVersion = rolloutNodeVersion(r.Kind, n)
+			if rolloutNodeHealthy(r, n, 0) {
+				st.State, st.Error, st.StartedAt, st.FinishedAt = rolloutStepDone, "", now, now
+				return
+			}
+			if mgr == nil {
+				fail("runtime manager unavailable")
+				return
+			}
+			remote, err := mgr.RemoteFor(n)
+			if err != nil {
+				fail(err.Error())
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if r.Kind == RolloutKindXray {
+				err = remote.InstallXray(ctx, r.TargetVersion)
+			} else {
+				err = remote.UpdatePanel(ctx, r.Dev)
+			}
+			if err != nil && !runtime.IsNodeUnreachable(err) {
+				fail(err.Error())
+				return
+			}
+			if err != nil {
+				logger.Infof("node rollout %d: %s did not answer the %s upgrade yet: %v", r.Id, n.Name, r.Kind, err)
+			}
+			st.State, st.Error, st.StartedAt = rolloutStepUpgrading, "", now
+		})
+	}
+	wg.Wait()
+}
+
+// finishRollout closes r. Nodes it never got to are marked skipped, along with
+// any still upgrading when it was aborted, which nothing verifies any more.
+func (s *NodeService) finishRollout(r *model.NodeRollout, status, reason string) error {
+	now := time.Now().UnixMilli()
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(model.NodeRolloutStep{}).
+			Where("rollout_id = ? AND state IN ?", r.Id, []string{rolloutStepPending, rolloutStepUpgrading}).
+			Updates(map[string]any{"state": rolloutStepSkipped, "error": "rollout " + status, "finished_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Model(model.NodeRollout{}).Where("id = ?", r.Id).Updates(map[string]any{
+			"status":      status,
+			"error":       reason,
+			"finished_at": now,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	for i := range r.Steps {
+		if st := &r.Steps[i]; st.State == rolloutStepPending || st.State == rolloutStepUpgrading {
+			st.State, st.Error, st.FinishedAt = rolloutStepSkipped, "rollout "+status, now
+		}
+	}
+	r.Status, r.Error, r.FinishedAt = status, reason, now
+	if status == rolloutStatusSucceeded {
+		logger.Infof("node rollout %d: %s %s finished", r.Id, r.Kind, r.TargetVersion)
+	} else {
+		logger.Warningf("node rollout %d: %s %s %s: %s", r.Id, r.Kind, r.TargetVersion, status, reason)
+	}
+	return nil
+}
+
+// CancelNodeRollout stops a running rollout. Nodes already upgrading carry on
+// with their upgrade; no further node is started.
+func (s *NodeService) CancelNodeRollout(id int) (*model.NodeRollout, error) {
+	rolloutMu.Lock()
+	defer rolloutMu.Unlock()
+	r, err := s.GetNodeRollout(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != rolloutStatusRunning {
+		return nil, common.NewError("the rollout is not running")
+	}
+	if err := s.finishRollout(r, rolloutStatusCancelled, "cancelled by an operator"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetNodeRollout loads one rollout with its steps.
+func (s *NodeService) GetNodeRollout(id int) (*model.NodeRollout, error) {
+	var r model.NodeRollout
+	err := database.GetDB().Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		First(&r, id).Error
+	if database.IsNotFound(err) {
+		return nil, common.NewErrorf("node rollout %d not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListNodeRollouts returns the most recent rollouts, newest first.
+func (s *NodeService) ListNodeRollouts() ([]*model.NodeRollout, error) {
+	var out []*model.NodeRollout
+	err := database.GetDB().Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Order("id desc").Limit(rolloutListLimit).Find(&out).Error
+	return out, err
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+)
+
+// fakeUpgradeNode answers the node upgrade endpoints, refusing them when
+// refuse is set, and counts the calls.
+func fakeUpgradeNode(t *testing.T, refuse bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": !refuse, "msg": "download failed"})
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panel/api/server/installXray/", handler)
+	mux.HandleFunc("/panel/api/server/updatePanel", handler)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts, &calls
+}
+
+func setupRolloutRuntime(t *testing.T) {
+	t.Helper()
+	prev := runtime.GetManager()
+	runtime.SetManager(runtime.NewManager(runtime.LocalDeps{APIPort: func() int { return 0 }, SetNeedRestart: func() {}}))
+	t.Cleanup(func() { runtime.SetManager(prev) })
+}
+
+func rolloutTestNode(t *testing.T, ts *httptest.Server, name string) *model.Node {
+	t.Helper()
+	n := reconcileTestNode(t, ts, name, "all", nil)
+	if err := database.GetDB().Model(n).Updates(map[string]any{
+		"xray_version": "26.6.27", "xray_state": "running", "panel_version": "3.6.0",
+	}).Error; err != nil {
+		t.Fatalf("seed node versions: %v", err)
+	}
+	return n
+}
+
+// heartbeat records what the node's next probe would report.
+func heartbeat(t *testing.T, n *model.Node, xrayVersion, xrayState string) {
+	t.Helper()
+	if err := database.GetDB().Model(n).Updates(map[string]any{
+		"xray_version": xrayVersion, "xray_state": xrayState, "last_heartbeat": time.Now().Unix() + 1,
+	}).Error; err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+}
+
+func advanceRollouts(t *testing.T, svc *NodeService) {
+	t.Helper()
+	if _, err := svc.AdvanceNodeRollouts(); err != nil {
+		t.Fatalf("AdvanceNodeRollouts: %v", err)
+	}
+}
+
+func rolloutSteps(t *testing.T, svc *NodeService, id int) (*model.NodeRollout, map[int]model.NodeRolloutStep) {
+	t.Helper()
+	r, err := svc.GetNodeRollout(id)
+	if err != nil {
+		t.Fatalf("GetNodeRollout: %v", err)
+	}
+	byNode := make(map[int]model.NodeRolloutStep, len(r.Steps))
+	for _, st := range r.Steps {
+		byNode[st.NodeId] = st
+	}
+	return r, byNode
+}
+
+// The canary batch is upgraded alone; the next batch starts only once the
+// canary's heartbeat reports the target version with Xray running.
+func TestNodeRollout_CanaryGatesNextBatch(t *testing.T) {
+	setupBulkDB(t)
+	setupRolloutRuntime(t)
+	ts, calls := fakeUpgradeNode(t, false)
+	a, b, c := rolloutTestNode(t, ts, "a"), rolloutTestNode(t, ts, "b"), rolloutTestNode(t, ts, "c")
+	off := rolloutTestNode(t, ts, "off")
+	if err := database.GetDB().Model(off).Update("status", "offline").Error; err != nil {
+		t.Fatalf("mark node offline: %v", err)
+	}
+
+	svc := &NodeService{}
+	r, err := svc.StartNodeRollout(RolloutKindXray, "v26.7.1", NodeRolloutRequest{
+		Ids: []int{a.Id, b.Id, c.Id, off.Id}, CanarySize: 1, BatchSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("StartNodeRollout: %v", err)
+	}
+	if _, err := svc.StartNodeRollout(RolloutKindXray, "v26.7.1", NodeRolloutRequest{Ids: []int{a.Id}}); err == nil {
+		t.Fatal("a second rollout must wait for the running one")
+	}
+	_, steps := rolloutSteps(t, svc, r.Id)
+	if steps[a.Id].Batch != 0 || steps[b.Id].Batch != 1 || steps[c.Id].Batch != 1 || steps[off.Id].State != rolloutStepSkipped {
+		t.Fatalf("unexpected plan: %+v", steps)
+	}
+
+	advanceRollouts(t, svc)
+	advanceRollouts(t, svc)
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("only the canary may be upgraded before it is healthy, got %d calls", got)
+	}
+	heartbeat(t, a, "26.6.27", "running")
+	advanceRollouts(t, svc)
+	if _, steps = rolloutSteps(t, svc, r.Id); steps[a.Id].State != rolloutStepUpgrading {
+		t.Fatalf("the old version must not pass the gate, canary is %q", steps[a.Id].State)
+	}
+
+	heartbeat(t, a, "26.7.1", "running")
+	advanceRollouts(t, svc)
+	advanceRollouts(t, svc)
+	if _, steps = rolloutSteps(t, svc, r.Id); steps[a.Id].State != rolloutStepDone || steps[b.Id].State != rolloutStepUpgrading {
+		t.Fatalf("after a healthy canary the next batch starts: a=%q b=%q", steps[a.Id].State, steps[b.Id].State)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("want the canary and one batch of 2 upgraded, got %d calls", got)
+	}
+
+	heartbeat(t, b, "26.7.1", "running")
+	heartbeat(t, c, "26.7.1", "running")
+	advanceRollouts(t, svc)
+	advanceRollouts(t, svc)
+	got, steps := rolloutSteps(t, svc, r.Id)
+	if got.Status != rolloutStatusSucceeded || got.FinishedAt == 0 {
+		t.Fatalf("rollout = %q, want succeeded", got.Status)
+	}
+	if steps[off.Id].State != rolloutStepSkipped {
+		t.Fatalf("offline node = %q, want skipped", steps[off.Id].State)
+	}
+}
+
+// A node refusing the upgrade aborts the rollout; no other node is touched.
+func TestNodeRollout_FailureAborts(t *testing.T) {
+	setupBulkDB(t)
+	setupRolloutRuntime(t)
+	bad, badCalls := fakeUpgradeNode(t, true)
+	good, goodCalls := fakeUpgradeNode(t, false)
+	canary, rest := rolloutTestNode(t, bad, "canary"), rolloutTestNode(t, good, "rest")
+
+	svc := &NodeService{}
+	r, err := svc.StartNodeRollout(RolloutKindPanel, "v3.6.1", NodeRolloutRequest{Ids: []int{canary.Id, rest.Id}})
+	if err != nil {
+		t.Fatalf("StartNodeRollout: %v", err)
+	}
+	advanceRollouts(t, svc)
+	advanceRollouts(t, svc)
+
+	got, steps := rolloutSteps(t, svc, r.Id)
+	if got.Status != rolloutStatusAborted || got.Error == "" {
+		t.Fatalf("rollout = %q (%q), want aborted with a reason", got.Status, got.Error)
+	}
+	if steps[canary.Id].State != rolloutStepFailed || steps[rest.Id].State != rolloutStepSkipped {
+		t.Fatalf("canary=%q rest=%q, want failed and skipped", steps[canary.Id].State, steps[rest.Id].State)
+	}
+	if badCalls.Load() != 1 || goodCalls.Load() != 0 {
+		t.Fatalf("calls: canary %d, rest %d; want 1 and 0", badCalls.Load(), goodCalls.Load())
+	}
+}
+
+// A node that never comes back healthy fails once the gate times out.
+func TestNodeRollout_HealthTimeoutAborts(t *testing.T) {
+	setupBulkDB(t)
+	setupRolloutRuntime(t)
+	prev := rolloutHealthTimeout[RolloutKindXray]
+	rolloutHealthTimeout[RolloutKindXray] = 0
+	t.Cleanup(func() { rolloutHealthTimeout[RolloutKindXray] = prev })
+	ts, _ := fakeUpgradeNode(t, false)
+	n := rolloutTestNode(t, ts, "stuck")
+
+	svc := &NodeService{}
+	r, err := svc.StartNodeRollout(RolloutKindXray, "v26.7.1", NodeRolloutRequest{Ids: []int{n.Id}})
+	if err != nil {
+		t.Fatalf("StartNodeRollout: %v", err)
+	}
+	advanceRollouts(t, svc)
+	heartbeat(t, n, "26.7.1", "error")
+	advanceRollouts(t, svc)
+	if got, steps := rolloutSteps(t, svc, r.Id); got.Status != rolloutStatusAborted || steps[n.Id].State != rolloutStepFailed {
+		t.Fatalf("rollout=%q step=%q, want aborted with Xray not running", got.Status, steps[n.Id].State)
+	}
+}
+
+func TestNodeRollout_Cancel(t *testing.T) {
+	setupBulkDB(t)
+	setupRolloutRuntime(t)
+	ts, calls := fakeUpgradeNode(t, false)
+	n := rolloutTestNode(t, ts, "n")
+
+	svc := &NodeService{}
+	r, err := svc.StartNodeRollout(RolloutKindXray, "v26.7.1", NodeRolloutRequest{Ids: []int{n.Id}})
+	if err != nil {
+		t.Fatalf("StartNodeRollout: %v", err)
+	}
+	if _, err := svc.CancelNodeRollout(r.Id); err != nil {
+		t.Fatalf("CancelNodeRollout: %v", err)
+	}
+	advanceRollouts(t, svc)
+	got, steps := rolloutSteps(t, svc, r.Id)
+	if got.Status != rolloutStatusCancelled || steps[n.Id].State != rolloutStepSkipped || calls.Load() != 0 {
+		t.Fatalf("rollout=%q step=%q calls=%d, want cancelled before any upgrade", got.Status, steps[n.Id].State, calls.Load())
+	}
+	if _, err := svc.CancelNodeRollout(r.Id); err == nil {
+		t.Fatal("cancelling a finished rollout must fail")
+	}
+}
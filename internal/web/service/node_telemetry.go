@@ -0,0 +1,257 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/nodetoken"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+)
+
+// telemetryCadence rebuilds each source at about the rate its data changes;
+// a frame is sent only when it differs from the last one of its type.
+var telemetryCadence = struct {
+	tick        time.Duration
+	status      time.Duration
+	traffic     time.Duration
+	clientIps   time.Duration
+	descendants time.Duration
+	// keepalive is the longest the stream stays silent.
+	keepalive time.Duration
+	// lifetime bounds one stream so a revoked token stops authorizing it; the
+	// master reconnects straight away.
+	lifetime time.Duration
+}{
+	tick:        time.Second,
+	status:      2 * time.Second,
+	traffic:     5 * time.Second,
+	clientIps:   10 * time.Second,
+	descendants: 5 * time.Second,
+	keepalive:   10 * time.Second,
+	lifetime:    10 * time.Minute,
+}
+
+// localWrites numbers the API writes this panel handles, so the traffic
+// frames it streams can tell the master which of its writes they include.
+var localWrites = runtime.NewWriteClock()
+
+// BeginLocalWrite numbers an API write about to run; see runtime.WriteClock.
+func BeginLocalWrite() (stamp string, done func()) {
+	return localWrites.Begin()
+}
+
+// telemetryUplinks holds the streams this panel pushes, keyed by the master
+// endpoint, so a new offer from a master replaces its previous stream.
+var telemetryUplinks = struct {
+	mu      sync.Mutex
+	streams map[string]*telemetryUplink
+}{streams: map[string]*telemetryUplink{}}
+
+type telemetryUplink struct {
+	cancel context.CancelFunc
+}
+
+type telemetrySource struct {
+	typ   string
+	every time.Duration
+	build func() (any, error)
+	// delta sources send every frame they build; the rest skip a frame equal
+	// to the last one.
+	delta bool
+
+	due  time.Time
+	last []byte
+}
+
+// AcceptTelemetryOffer replaces any stream already open to the offer's
+// master; callerIP stands in when the offer names no address.
+func (s *ServerService) AcceptTelemetryOffer(offer *runtime.TelemetryOffer, token, callerIP string, ownerID int) error {
+	if token == "" {
+		return errors.New("the telemetry stream needs an API token to authenticate with")
+	}
+	target, err := offer.StreamURL(callerIP)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	uplink := &telemetryUplink{cancel: cancel}
+	telemetryUplinks.mu.Lock()
+	if prev := telemetryUplinks.streams[target]; prev != nil {
+		prev.cancel()
+	}
+	telemetryUplinks.streams[target] = uplink
+	telemetryUplinks.mu.Unlock()
+
+	go func() {
+		defer func() {
+			cancel()
+			telemetryUplinks.mu.Lock()
+			if telemetryUplinks.streams[target] == uplink {
+				delete(telemetryUplinks.streams, target)
+			}
+			telemetryUplinks.mu.Unlock()
+		}()
+		err := runtime.PushTelemetry(ctx, offer, callerIP, token, func(send func(runtime.TelemetryFrame) error) error {
+			return s.StreamTelemetry(ctx, ownerID, send)
+		})
+		if err != nil && ctx.Err() == nil {
+			logger.Debug("telemetry stream to", target, "ended:", err)
+		}
+	}()
+	return nil
+}
+
+// StopTelemetryUplinks ends every telemetry stream this panel pushes.
+func StopTelemetryUplinks() {
+	telemetryUplinks.mu.Lock()
+	defer telemetryUplinks.mu.Unlock()
+	for target, uplink := range telemetryUplinks.streams {
+		uplink.cancel()
+		delete(telemetryUplinks.streams, target)
+	}
+}
+
+// StreamTelemetry ends with ctx, a failed send or the stream's lifetime.
+// ownerID scopes inbounds as the inbound list endpoint does for nodes.
+func (s *ServerService) StreamTelemetry(ctx context.Context, ownerID int, send func(runtime.TelemetryFrame) error) error {
+	c := telemetryCadence
+	var traffic runtime.TrafficDiffer
+	sources := []*telemetrySource{
+		{typ: runtime.TelemetryStatus, every: c.status, build: func() (any, error) {
+			if st := s.LastStatus(); st != nil {
+				return st, nil
+			}
+			return nil, nil
+		}},
+		{typ: runtime.TelemetryTraffic, every: c.traffic, delta: true, build: func() (any, error) {
+			// Read the clock first: every write settled by now is in the view
+			// read after it.
+			epoch, settled := localWrites.Settled()
+			view, err := s.trafficView(ownerID)
+			if err != nil {
+				return nil, err
+			}
+			f, err := traffic.Next(view, epoch, settled)
+			if f == nil || err != nil {
+				return nil, err
+			}
+			return f, nil
+		}},
+		{typ: runtime.TelemetryClientIps, every: c.clientIps, build: func() (any, error) {
+			return s.inboundService.GetAllInboundClientIps()
+		}},
+		{typ: runtime.TelemetryClientIpsByGuid, every: c.clientIps, build: func() (any, error) {
+			return s.inboundService.GetClientIpsByGuid()
+		}},
+		{typ: runtime.TelemetryDescendants, every: c.descendants, build: func() (any, error) {
+			return (&NodeService{}).LocalDescendants()
+		}},
+	}
+
+	ticker := time.NewTicker(c.tick)
+	defer ticker.Stop()
+	deadline := time.Now().Add(c.lifetime)
+	lastSent := time.Now()
+	for {
+		now := time.Now()
+		if now.After(deadline) {
+			return nil
+		}
+		for _, src := range sources {
+			if now.Before(src.due) {
+				continue
+			}
+			src.due = now.Add(src.every)
+			v, err := src.build()
+			if err != nil || v == nil {
+				continue
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			if !src.delta && bytes.Equal(data, src.last) {
+				continue
+			}
+			if err := send(runtime.TelemetryFrame{Type: src.typ, Data: data}); err != nil {
+				return err
+			}
+			src.last, lastSent = data, now
+		}
+		if now.Sub(lastSent) >= c.keepalive {
+			if err := send(runtime.TelemetryFrame{Type: runtime.TelemetryPing}); err != nil {
+				return err
+			}
+			lastSent = now
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// trafficView is what the traffic endpoints a master polls would return.
+func (s *ServerService) trafficView(ownerID int) (*runtime.TrafficSnapshot, error) {
+	inbounds, err := s.inboundService.GetInbounds(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	lastOnline, err := s.inboundService.GetClientsLastOnline()
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.TrafficSnapshot{
+		Inbounds:          inbounds,
+		OnlineTree:        s.inboundService.GetOnlineClientsByGuid(),
+		ActiveInboundTree: s.inboundService.GetActiveInboundsByGuid(),
+		LastOnlineMap:     lastOnline,
+	}, nil
+}
+
+// ErrTelemetryUnauthorized is deliberately coarse so a caller cannot tell an
+// unknown node from a wrong token.
+var ErrTelemetryUnauthorized = errors.New("unknown node or invalid token")
+
+// TelemetryOffer names the web domain when one is set, else leaves the node
+// to dial the address the offer came from.
+func (s *NodeService) TelemetryOffer(nodeID int) (*runtime.TelemetryOffer, error) {
+	local, err := s.LocalJoinRequest()
+	if err != nil {
+		return nil, err
+	}
+	domain, err := (&SettingService{}).GetWebDomain()
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.TelemetryOffer{
+		NodeId:     nodeID,
+		Scheme:     local.Scheme,
+		Address:    domain,
+		Port:       local.Port,
+		BasePath:   local.BasePath,
+		CertSha256: local.CertSha256,
+	}, nil
+}
+
+// AuthorizeTelemetry returns the node a telemetry stream claiming nodeID
+// comes from, provided token is the API token this panel calls it with.
+func (s *NodeService) AuthorizeTelemetry(nodeID int, token string) (*model.Node, error) {
+	n, err := s.GetById(nodeID)
+	if err != nil || !n.Enable || n.ApiToken == "" || token == "" {
+		return nil, ErrTelemetryUnauthorized
+	}
+	want, err := nodetoken.Decrypt(n.Id, n.ApiToken)
+	if err != nil || subtle.ConstantTimeCompare([]byte(want), []byte(token)) != 1 {
+		return nil, ErrTelemetryUnauthorized
+	}
+	return n, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/runtime"
+)
+
+func TestStreamTelemetry(t *testing.T) {
+	setupBulkDB(t)
+	saved := telemetryCadence
+	t.Cleanup(func() { telemetryCadence = saved })
+	telemetryCadence.tick = 5 * time.Millisecond
+	telemetryCadence.status = 5 * time.Millisecond
+	telemetryCadence.traffic = 5 * time.Millisecond
+	telemetryCadence.clientIps = 5 * time.Millisecond
+	telemetryCadence.descendants = 5 * time.Millisecond
+	telemetryCadence.keepalive = 40 * time.Millisecond
+	telemetryCadence.lifetime = 300 * time.Millisecond
+
+	counts := map[string]int{}
+	var traffic []runtime.TrafficFrame
+	s := &ServerService{}
+	err := s.StreamTelemetry(context.Background(), 1, func(f runtime.TelemetryFrame) error {
+		counts[f.Type]++
+		switch f.Type {
+		case runtime.TelemetryTraffic:
+			var tf runtime.TrafficFrame
+			if err := json.Unmarshal(f.Data, &tf); err != nil {
+				t.Fatalf("traffic frame: %v", err)
+			}
+			traffic = append(traffic, tf)
+		case runtime.TelemetryPing:
+			// A write on the node goes out with the next traffic frame, which
+			// reports it settled.
+			if counts[f.Type] == 1 {
+				_, done := BeginLocalWrite()
+				ib := model.Inbound{UserId: 1, Tag: "in-a", Port: 443, Protocol: model.VLESS, Enable: true}
+				if err := database.GetDB().Create(&ib).Error; err != nil {
+					t.Fatal(err)
+				}
+				done()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	// No status sample yet, so no status frame; every other source is sent
+	// once, then only again when it changes.
+	if counts[runtime.TelemetryStatus] != 0 {
+		t.Fatalf("sent %d status frames without a status sample", counts[runtime.TelemetryStatus])
+	}
+	for _, typ := range []string{runtime.TelemetryClientIps, runtime.TelemetryClientIpsByGuid, runtime.TelemetryDescendants} {
+		if counts[typ] != 1 {
+			t.Fatalf("sent %d %s frames, want 1", counts[typ], typ)
+		}
+	}
+	// Traffic opens with a full frame and then sends only what changed.
+	if len(traffic) != 2 || !traffic[0].Full || len(traffic[0].Inbounds) != 0 ||
+		traffic[1].Full || len(traffic[1].Inbounds) != 1 || traffic[1].Inbounds[0].Tag != "in-a" ||
+		traffic[1].Settled <= traffic[0].Settled || traffic[1].Epoch != traffic[0].Epoch {
+		t.Fatalf("traffic frames = %+v", traffic)
+	}
+	if counts[runtime.TelemetryPing] == 0 {
+		t.Fatal("an idle stream sent no keepalive")
+	}
+}
@@ -70,11 +70,17 @@ func (s *NodeService) RefreshDescendants(ctx context.Context, n *model.Node) {
 	if err != nil {
 		return
 	}
+	s.SetDescendants(n.Id, summaries)
+}
+
+// SetDescendants caches the sub-node summaries a direct node published, here
+// pushed over its telemetry stream rather than fetched.
+func (s *NodeService) SetDescendants(nodeID int, summaries []model.NodeSummary) {
 	nodeDescendantsMu.Lock()
 	if len(summaries) == 0 {
-		delete(nodeDescendantsCache, n.Id)
+		delete(nodeDescendantsCache, nodeID)
 	} else {
-		nodeDescendantsCache[n.Id] = summaries
+		nodeDescendantsCache[nodeID] = summaries
 	}
 	nodeDescendantsMu.Unlock()
 }
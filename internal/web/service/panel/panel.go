@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"os/exec"
@@ -40,7 +39,6 @@ type PanelUpdateInfo struct {
 }
 
 const (
-	panelUpdaterURL      = "https://raw.githubusercontent.com/MHSanaei/3x-ui/main/update.sh"
 	maxPanelUpdaterBytes = 2 << 20
 	// devReleaseTag is the fixed-tag rolling pre-release the CI force-moves to the
 	// newest main commit; the dev update channel installs from it.
@@ -60,10 +58,11 @@ const (
 // Number.MAX_SAFE_INTEGER), which would let two different runs round to the
 // same value on the wire and defeat the whole point of this field.
 type PanelUpdateStatus struct {
-	RunID      string `json:"runId" example:"1735689600123456789"`
-	State      string `json:"state" example:"success"`
-	ExitCode   int    `json:"exitCode" example:"0"`
-	FinishedAt int64  `json:"finishedAt" example:"1735689612"`
+	RunID        string              `json:"runId" example:"1735689600123456789"`
+	State        string              `json:"state" example:"success"`
+	ExitCode     int                 `json:"exitCode" example:"0"`
+	FinishedAt   int64               `json:"finishedAt" example:"1735689612"`
+	Verification *UpdateVerification `json:"verification,omitempty"` // nil from updaters predating the check
 }
 
 var releaseCommitRegex = regexp.MustCompile(`(?i)commit=([0-9a-f]{7,40})`)
@@ -145,6 +144,23 @@ func (s *PanelService) GetUpdateInfo() (*PanelUpdateInfo, error) {
 	}, nil
 }
 
+// LatestVersion returns the version a node reports after updating to the latest
+// release of the channel: the stable tag, or "dev+<commit>" for dev.
+func (s *PanelService) LatestVersion(dev bool) (string, error) {
+	if !dev {
+		return fetchLatestPanelVersion()
+	}
+	release, err := fetchPanelRelease(devReleaseTag)
+	if err != nil {
+		return "", err
+	}
+	commit := extractReleaseCommit(release)
+	if commit == "" {
+		return "", fmt.Errorf("dev release commit is unknown")
+	}
+	return "dev+" + shortCommit(commit), nil
+}
+
 // devChannelActive reports whether self-update should track the rolling dev
 // release. It is driven solely by the opt-in setting so the panel can
 // cross-grade a stable build onto the dev channel once the user enables it;
@@ -233,21 +249,43 @@ func (s *PanelService) startUpdate(useDev bool) (int64, error) {
 		return 0, fmt.Errorf("bash is required to run the panel updater: %w", err)
 	}
 
-	scriptPath, err := downloadPanelUpdater()
+	arch, err := releaseArch()
 	if err != nil {
 		return 0, err
 	}
+	updateTag := devReleaseTag
+	if !useDev {
+		if updateTag, err = fetchLatestPanelVersion(); err != nil {
+			return 0, err
+		}
+	}
+
+	// Both the updater and its archive are checked against the signed manifest,
+	// so a tampered release never reaches update.sh's stop-and-replace.
+	client := (&service.SettingService{}).NewProxiedHTTPClient(5 * time.Minute)
+	rel, err := downloadVerifiedRelease(client, updateTag, arch)
+	if err != nil {
+		logger.Warningf("panel update %s failed verification: %v", updateTag, err)
+		recordVerificationFailure(runID, updateTag, err)
+		return 0, fmt.Errorf("panel update %s failed verification: %w", updateTag, err)
+	}
+	scriptPath := rel.ScriptPath
+	verification, err := json.Marshal(rel.Verification)
+	if err != nil {
+		rel.remove()
+		return 0, err
+	}
+	logger.Infof("panel update %s verified with release key %s", updateTag, rel.Verification.KeyID)
 
 	statusFile := config.GetUpdateStatusFilePath()
 
 	mainFolder, serviceFolder := resolveUpdateFolders()
-	updateTag := ""
-	if useDev {
-		updateTag = devReleaseTag
-	}
-	updateScript := fmt.Sprintf("set -e; trap 'rm -f %s' EXIT; %s %s", shellQuote(scriptPath), shellQuote(bash), shellQuote(scriptPath))
+	updateScript := fmt.Sprintf("set -e; trap 'rm -f %s %s' EXIT; %s %s",
+		shellQuote(scriptPath), shellQuote(rel.ArchivePath), shellQuote(bash), shellQuote(scriptPath))
 	runIDEnv := "XUI_UPDATE_RUN_ID=" + strconv.FormatInt(runID, 10)
 	statusFileEnv := "XUI_UPDATE_STATUS_FILE=" + statusFile
+	archiveEnv := "XUI_UPDATE_ARCHIVE=" + rel.ArchivePath
+	verificationEnv := "XUI_UPDATE_VERIFICATION=" + string(verification)
 
 	if systemdRun, err := exec.LookPath("systemd-run"); err == nil {
 		unitName := fmt.Sprintf("x-ui-web-update-%d", time.Now().Unix())
@@ -258,6 +296,8 @@ func (s *PanelService) startUpdate(useDev bool) (int64, error) {
 			"--setenv", "XUI_UPDATE_TAG="+updateTag,
 			"--setenv", runIDEnv,
 			"--setenv", statusFileEnv,
+			"--setenv", archiveEnv,
+			"--setenv", verificationEnv,
 			bash, "-lc", updateScript,
 		)
 		out, err := cmd.CombinedOutput()
@@ -265,7 +305,7 @@ func (s *PanelService) startUpdate(useDev bool) (int64, error) {
 			output := strings.TrimSpace(string(out))
 			if !strings.Contains(output, "System has not been booted with systemd") &&
 				!strings.Contains(output, "Failed to connect to bus") {
-				_ = os.Remove(scriptPath)
+				rel.remove()
 				return 0, fmt.Errorf("failed to start panel update job: %w: %s", err, output)
 			}
 			logger.Warning("systemd-run is unavailable, falling back to detached update process:", output)
@@ -283,10 +323,12 @@ func (s *PanelService) startUpdate(useDev bool) (int64, error) {
 		"XUI_UPDATE_TAG="+updateTag,
 		runIDEnv,
 		statusFileEnv,
+		archiveEnv,
+		verificationEnv,
 	)
 	setDetachedProcess(cmd)
 	if err := cmd.Start(); err != nil {
-		_ = os.Remove(scriptPath)
+		rel.remove()
 		return 0, fmt.Errorf("failed to start panel update job: %w", err)
 	}
 	if err := cmd.Process.Release(); err != nil {
@@ -354,51 +396,6 @@ func releaseUpdateSlot() {
 	updateMu.Unlock()
 }
 
-func downloadPanelUpdater() (string, error) {
-	client := (&service.SettingService{}).NewProxiedHTTPClient(15 * time.Second)
-	req, reqErr := http.NewRequestWithContext(context.Background(), http.MethodGet, panelUpdaterURL, nil)
-	if reqErr != nil {
-		return "", fmt.Errorf("download panel updater: %w", reqErr)
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("download panel updater: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download panel updater: unexpected HTTP %d", resp.StatusCode)
-	}
-
-	file, err := os.CreateTemp("", "3x-ui-update-*.sh")
-	if err != nil {
-		return "", err
-	}
-	path := file.Name()
-	ok := false
-	defer func() {
-		_ = file.Close()
-		if !ok {
-			_ = os.Remove(path)
-		}
-	}()
-
-	n, err := io.Copy(file, io.LimitReader(resp.Body, maxPanelUpdaterBytes+1))
-	if err != nil {
-		return "", fmt.Errorf("write panel updater: %w", err)
-	}
-	if n == 0 {
-		return "", fmt.Errorf("panel updater download is empty")
-	}
-	if n > maxPanelUpdaterBytes {
-		return "", fmt.Errorf("panel updater exceeds %d bytes", maxPanelUpdaterBytes)
-	}
-	if err := file.Chmod(0o700); err != nil {
-		return "", err
-	}
-	ok = true
-	return path, nil
-}
-
 func fetchLatestPanelVersion() (string, error) {
 	release, err := fetchPanelRelease("")
 	if err != nil {
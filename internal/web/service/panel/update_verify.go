@@ -0,0 +1,211 @@
+package panel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/config"
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/releasesig"
+)
+
+// UpdateVerification is checked before the updater launches; a failed check
+// leaves the running install as it was.
+type UpdateVerification struct {
+	Verified bool   `json:"verified" example:"true"`
+	Tag      string `json:"tag,omitempty" example:"v3.4.0"`
+	KeyID    string `json:"keyId,omitempty" example:"5f1c0e2a9b7d3c41"`
+	Error    string `json:"error,omitempty"`
+}
+
+const (
+	panelUpdaterAsset    = "update.sh"
+	maxPanelArchiveBytes = 300 << 20
+)
+
+// releaseDownloadBaseURL and releaseVerifier are variables so tests can point
+// the updater at a local server signing with a throwaway key.
+var (
+	releaseDownloadBaseURL = "https://github.com/MHSanaei/3x-ui/releases/download"
+	releaseVerifier        = releasesig.Pinned()
+)
+
+// verifiedRelease holds the updater script and release archive downloaded for
+// one update run. Both files are already checked against the signed manifest.
+type verifiedRelease struct {
+	ScriptPath   string
+	ArchivePath  string
+	Verification UpdateVerification
+}
+
+func (r *verifiedRelease) remove() {
+	_ = os.Remove(r.ScriptPath)
+	_ = os.Remove(r.ArchivePath)
+}
+
+// downloadVerifiedRelease leaves nothing on disk unless the signature and
+// every artifact checksum pass.
+func downloadVerifiedRelease(client *http.Client, tag, arch string) (*verifiedRelease, error) {
+	base := strings.TrimRight(releaseDownloadBaseURL, "/") + "/" + tag + "/"
+	manifest, err := fetchReleaseAsset(client, base+releasesig.ManifestName)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := fetchReleaseAsset(client, base+releasesig.SignatureName)
+	if err != nil {
+		return nil, err
+	}
+	sums, keyID, err := releaseVerifier.Verify(manifest, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	rel := &verifiedRelease{Verification: UpdateVerification{Verified: true, Tag: tag, KeyID: keyID}}
+	ok := false
+	defer func() {
+		if !ok {
+			rel.remove()
+		}
+	}()
+	rel.ScriptPath, err = downloadReleaseFile(client, base, panelUpdaterAsset, "3x-ui-update-*.sh", maxPanelUpdaterBytes, sums)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(rel.ScriptPath, 0o700); err != nil {
+		return nil, err
+	}
+	archive := "x-ui-linux-" + arch + ".tar.gz"
+	rel.ArchivePath, err = downloadReleaseFile(client, base, archive, "3x-ui-update-*.tar.gz", maxPanelArchiveBytes, sums)
+	if err != nil {
+		return nil, err
+	}
+	ok = true
+	return rel, nil
+}
+
+func fetchReleaseAsset(client *http.Client, url string) ([]byte, error) {
+	resp, err := getReleaseAsset(client, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, releasesig.MaxManifestBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", filepath.Base(url), err)
+	}
+	if len(data) > releasesig.MaxManifestBytes {
+		return nil, fmt.Errorf("download %s: exceeds %d bytes", filepath.Base(url), releasesig.MaxManifestBytes)
+	}
+	return data, nil
+}
+
+// downloadReleaseFile streams one release asset into a temp file and checks it
+// against the manifest. The temp file is removed on any failure.
+func downloadReleaseFile(client *http.Client, base, name, pattern string, limit int64, sums releasesig.Manifest) (string, error) {
+	resp, err := getReleaseAsset(client, base+name)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	path := file.Name()
+	ok := false
+	defer func() {
+		_ = file.Close()
+		if !ok {
+			_ = os.Remove(path)
+		}
+	}()
+
+	n, err := io.Copy(file, io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return "", fmt.Errorf("download %s: %w", name, err)
+	}
+	if n > limit {
+		return "", fmt.Errorf("download %s: exceeds %d bytes", name, limit)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if err := sums.Check(name, file); err != nil {
+		return "", err
+	}
+	ok = true
+	return path, nil
+}
+
+func getReleaseAsset(client *http.Client, url string) (*http.Response, error) {
+	name := filepath.Base(url)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", name, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download %s: unexpected HTTP %d", name, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// releaseArch maps the running binary to the arch suffix of the release
+// archives, which follows update.sh's arch() naming.
+func releaseArch() (string, error) {
+	switch runtime.GOARCH {
+	case "amd64", "386", "arm64", "s390x":
+		return runtime.GOARCH, nil
+	case "arm":
+		goarm := "7"
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range info.Settings {
+				if s.Key == "GOARM" && s.Value != "" {
+					goarm = s.Value[:1]
+				}
+			}
+		}
+		return "armv" + goarm, nil
+	}
+	return "", fmt.Errorf("no panel release is published for %s", runtime.GOARCH)
+}
+
+// recordVerificationFailure writes a failed status for runID so a
+// getUpdateStatus poll sees why the update never started.
+func recordVerificationFailure(runID int64, tag string, cause error) {
+	status := PanelUpdateStatus{
+		RunID:        strconv.FormatInt(runID, 10),
+		State:        updateStateFailed,
+		FinishedAt:   time.Now().Unix(),
+		Verification: &UpdateVerification{Tag: tag, Error: cause.Error()},
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return
+	}
+	path := config.GetUpdateStatusFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	tmp := fmt.Sprintf("%s.tmp.%d", path, os.Getpid())
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+	}
+}
@@ -0,0 +1,127 @@
+package panel
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/config"
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/releasesig"
+)
+
+// serveSignedRelease serves a release under /v9.9.9/ whose manifest is signed
+// with priv, and points the updater at it trusting pub.
+func serveSignedRelease(t *testing.T, pub ed25519.PublicKey, priv ed25519.PrivateKey, assets map[string]string, listed map[string]string) {
+	t.Helper()
+	manifest := ""
+	for name, body := range listed {
+		sum := sha256.Sum256([]byte(body))
+		manifest += hex.EncodeToString(sum[:]) + "  " + name + "\n"
+	}
+	files := map[string]string{
+		releasesig.ManifestName:  manifest,
+		releasesig.SignatureName: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(manifest))),
+	}
+	for name, body := range assets {
+		files[name] = body
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path[len("/v9.9.9/"):]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	prevURL, prevVerifier := releaseDownloadBaseURL, releaseVerifier
+	releaseDownloadBaseURL = srv.URL
+	releaseVerifier = releasesig.NewVerifier(pub)
+	t.Cleanup(func() { releaseDownloadBaseURL, releaseVerifier = prevURL, prevVerifier })
+}
+
+func TestDownloadVerifiedRelease(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets := map[string]string{"update.sh": "#!/bin/bash\necho update\n", "x-ui-linux-amd64.tar.gz": "archive"}
+	serveSignedRelease(t, pub, priv, assets, assets)
+
+	rel, err := downloadVerifiedRelease(http.DefaultClient, "v9.9.9", "amd64")
+	if err != nil {
+		t.Fatalf("downloadVerifiedRelease: %v", err)
+	}
+	defer rel.remove()
+	if !rel.Verification.Verified || rel.Verification.KeyID != releasesig.KeyID(pub) || rel.Verification.Tag != "v9.9.9" {
+		t.Fatalf("unexpected verification %+v", rel.Verification)
+	}
+	if got, _ := os.ReadFile(rel.ArchivePath); string(got) != "archive" {
+		t.Fatalf("archive content = %q", got)
+	}
+	if fi, err := os.Stat(rel.ScriptPath); err != nil || fi.Mode().Perm() != 0o700 {
+		t.Fatalf("updater script not executable: %v %v", fi, err)
+	}
+}
+
+func TestDownloadVerifiedReleaseRejects(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	good := map[string]string{"update.sh": "#!/bin/bash\n", "x-ui-linux-amd64.tar.gz": "archive"}
+	tampered := map[string]string{"update.sh": "#!/bin/bash\n", "x-ui-linux-amd64.tar.gz": "evil"}
+	unlisted := map[string]string{"update.sh": "#!/bin/bash\n"}
+
+	cases := []struct {
+		name    string
+		trusted ed25519.PublicKey
+		listed  map[string]string
+		served  map[string]string
+		want    error
+	}{
+		{"unpinned key", otherPub, good, good, releasesig.ErrBadSignature},
+		{"tampered archive", pub, good, tampered, releasesig.ErrChecksumMismatch},
+		{"archive missing from manifest", pub, unlisted, good, releasesig.ErrNotInManifest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			serveSignedRelease(t, tc.trusted, priv, tc.served, tc.listed)
+			tmp := t.TempDir()
+			t.Setenv("TMPDIR", tmp)
+			rel, err := downloadVerifiedRelease(http.DefaultClient, "v9.9.9", "amd64")
+			if !errors.Is(err, tc.want) {
+				if rel != nil {
+					rel.remove()
+				}
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+			if left, _ := os.ReadDir(tmp); len(left) != 0 {
+				t.Fatalf("failed verification left %d temp files behind", len(left))
+			}
+		})
+	}
+}
+
+func TestRecordVerificationFailure(t *testing.T) {
+	t.Setenv("XUI_DB_FOLDER", t.TempDir())
+	recordVerificationFailure(42, "v9.9.9", fmt.Errorf("wrapped: %w", releasesig.ErrBadSignature))
+
+	got := (&PanelService{}).GetUpdateStatus()
+	if got.RunID != strconv.Itoa(42) || got.State != updateStateFailed {
+		t.Fatalf("status = %+v", got)
+	}
+	if got.Verification == nil || got.Verification.Verified || got.Verification.Error == "" {
+		t.Fatalf("verification = %+v", got.Verification)
+	}
+	if _, err := os.Stat(config.GetUpdateStatusFilePath()); err != nil {
+		t.Fatal(err)
+	}
+}
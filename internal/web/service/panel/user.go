@@ -2,10 +2,13 @@ package panel
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/xlzd/gotp"
 	"gorm.io/gorm"
 
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/secrets"
 	"github.com/mhsanaei/3x-ui/v3/internal/database"
 	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
@@ -84,25 +87,68 @@ func (s *UserService) CheckUser(username string, password string, twoFactorCode
 		}
 	}
 
-	twoFactorEnable, err := s.settingService.GetTwoFactorEnable()
+	if err := s.VerifyTwoFactorCode(user, twoFactorCode); err != nil {
+		return nil, errors.New("invalid 2fa code")
+	}
+
+	return user, nil
+}
+
+// VerifyTwoFactorCode checks a TOTP code against the account's own secret;
+// accounts without 2FA accept any code.
+func (s *UserService) VerifyTwoFactorCode(user *model.User, code string) error {
+	if user == nil || !user.TwoFactorEnable {
+		return nil
+	}
+	token, err := secrets.Open(secrets.UserTwoFactorScope(user.Id), strings.TrimSpace(user.TwoFactorToken))
 	if err != nil {
-		logger.Warning("check two factor err:", err)
-		return nil, err
+		logger.Warning("2fa: open token:", err)
+		return errors.New("invalid two factor code")
 	}
+	if token == "" || !gotp.NewDefaultTOTP(token).Verify(strings.TrimSpace(code), time.Now().Unix()) {
+		return errors.New("invalid two factor code")
+	}
+	return nil
+}
 
-	if twoFactorEnable {
-		twoFactorToken, err := s.settingService.GetTwoFactorToken()
-		if err != nil {
-			logger.Warning("check two factor token err:", err)
-			return nil, err
-		}
+// AnyTwoFactorEnabled tells the login page whether to offer the code field
+// before it knows which account is signing in.
+func (s *UserService) AnyTwoFactorEnabled() (bool, error) {
+	var count int64
+	err := database.GetDB().Model(model.User{}).Where("two_factor_enable = ?", true).Count(&count).Error
+	return count > 0, err
+}
 
-		if gotp.NewDefaultTOTP(twoFactorToken).Now() != twoFactorCode {
-			return nil, errors.New("invalid 2fa code")
-		}
+// SetTwoFactor binds or removes the account's authenticator and signs out its
+// other sessions.
+func (s *UserService) SetTwoFactor(id int, enable bool, token string) error {
+	token = strings.TrimSpace(token)
+	if enable && token == "" {
+		return errors.New("two factor token can not be empty")
+	}
+	if !enable {
+		token = ""
 	}
+	token, err := secrets.Seal(secrets.UserTwoFactorScope(id), token)
+	if err != nil {
+		return err
+	}
+	return database.GetDB().Model(model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"two_factor_enable": enable,
+			"two_factor_token":  token,
+			"login_epoch":       gorm.Expr("login_epoch + 1"),
+		}).
+		Error
+}
 
-	return user, nil
+// ResetTwoFactor turns 2FA off for every account (the x-ui setting -resetTwoFactor path).
+func (s *UserService) ResetTwoFactor() error {
+	return database.GetDB().Model(model.User{}).
+		Where("1 = 1").
+		Updates(map[string]any{"two_factor_enable": false, "two_factor_token": ""}).
+		Error
 }
 
 func (s *UserService) BumpLoginEpoch() error {
@@ -114,28 +160,27 @@ func (s *UserService) BumpLoginEpoch() error {
 }
 
 func (s *UserService) UpdateUser(id int, username string, password string) error {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return errors.New("username can not be empty")
+	}
 	db := database.GetDB()
-	hashedPassword, err := crypto.HashPasswordAsBcrypt(password)
-	if err != nil {
+	if err := ensureUsernameFree(db, username, id); err != nil {
 		return err
 	}
-
-	twoFactorEnable, err := s.settingService.GetTwoFactorEnable()
+	hashedPassword, err := crypto.HashPasswordAsBcrypt(password)
 	if err != nil {
 		return err
 	}
 
-	if twoFactorEnable {
-		_ = s.settingService.SetTwoFactorEnable(false)
-		_ = s.settingService.SetTwoFactorToken("")
-	}
-
 	return db.Model(model.User{}).
 		Where("id = ?", id).
 		Updates(map[string]any{
-			"username":    username,
-			"password":    hashedPassword,
-			"login_epoch": gorm.Expr("login_epoch + 1"),
+			"username":          username,
+			"password":          hashedPassword,
+			"login_epoch":       gorm.Expr("login_epoch + 1"),
+			"two_factor_enable": false,
+			"two_factor_token":  "",
 		}).
 		Error
 }
@@ -158,6 +203,7 @@ func (s *UserService) UpdateFirstUser(username string, password string) error {
 	if database.IsNotFound(err) {
 		user.Username = username
 		user.Password = hashedPassword
+		user.Role = model.UserRoleOwner
 		return db.Model(model.User{}).Create(user).Error
 	} else if err != nil {
 		return err
@@ -0,0 +1,337 @@
+package panel
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/crypto"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/service"
+)
+
+// UserView leaves out credentials. Effective is what the account can actually
+// do; the reseller limits and Usage are filled in for resellers only.
+type UserView struct {
+	Id              int                    `json:"id" example:"2"`
+	Username        string                 `json:"username" example:"support"`
+	Role            string                 `json:"role" example:"read-only"`
+	Permissions     map[string]string      `json:"permissions"`
+	Effective       map[string]string      `json:"effective"`
+	TwoFactorEnable bool                   `json:"twoFactorEnable" example:"false"`
+	Primary         bool                   `json:"primary" example:"false"`
+	AllowedInbounds []int                  `json:"allowedInbounds"`
+	MaxClients      int                    `json:"maxClients" example:"0"`
+	MaxTrafficGB    int                    `json:"maxTrafficGB" example:"0"`
+	MaxExpiryDays   int                    `json:"maxExpiryDays" example:"0"`
+	Usage           *service.ResellerUsage `json:"usage,omitempty"`
+}
+
+// UserAccountRequest creates or edits a panel account. A blank password on
+// update keeps the current one. The limits apply to resellers; 0 is unlimited.
+type UserAccountRequest struct {
+	Username        string            `json:"username" validate:"required,max=64"`
+	Password        string            `json:"password" validate:"max=128"`
+	Role            string            `json:"role" validate:"required"`
+	Permissions     map[string]string `json:"permissions"`
+	AllowedInbounds []int             `json:"allowedInbounds"`
+	MaxClients      int               `json:"maxClients" validate:"min=0"`
+	MaxTrafficGB    int               `json:"maxTrafficGB" validate:"min=0"`
+	MaxExpiryDays   int               `json:"maxExpiryDays" validate:"min=0"`
+}
+
+func toUserView(u *model.User, primaryId int) (*UserView, error) {
+	role := u.Role
+	if u.IsOwner() {
+		role = model.UserRoleOwner
+	}
+	perms := u.Permissions
+	if perms == nil {
+		perms = map[string]string{}
+	}
+	view := &UserView{
+		Id:              u.Id,
+		Username:        u.Username,
+		Role:            role,
+		Permissions:     perms,
+		Effective:       u.EffectivePermissions(),
+		TwoFactorEnable: u.TwoFactorEnable,
+		Primary:         u.Id == primaryId,
+		AllowedInbounds: []int{},
+	}
+	if scope := service.ClientScopeFor(u); scope != nil {
+		usage, err := (&service.ClientService{}).Usage(scope)
+		if err != nil {
+			return nil, err
+		}
+		if u.AllowedInbounds != nil {
+			view.AllowedInbounds = u.AllowedInbounds
+		}
+		view.MaxClients = u.MaxClients
+		view.MaxTrafficGB = u.MaxTrafficGB
+		view.MaxExpiryDays = u.MaxExpiryDays
+		view.Usage = &usage
+	}
+	return view, nil
+}
+
+// primaryUserId is the original account. Inbounds are stored under its id,
+// so it can be neither deleted nor demoted.
+func primaryUserId(db *gorm.DB) (int, error) {
+	first := &model.User{}
+	if err := db.Model(model.User{}).Order("id asc").First(first).Error; err != nil {
+		return 0, err
+	}
+	return first.Id, nil
+}
+
+func (s *UserService) PrimaryUserId() (int, error) {
+	return primaryUserId(database.GetDB())
+}
+
+func (s *UserService) GetUser(id int) (*model.User, error) {
+	user := &model.User{}
+	if err := database.GetDB().Model(model.User{}).Where("id = ?", id).First(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *UserService) View(u *model.User) (*UserView, error) {
+	primaryId, err := primaryUserId(database.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	return toUserView(u, primaryId)
+}
+
+func (s *UserService) ListUsers() ([]*UserView, error) {
+	db := database.GetDB()
+	var rows []*model.User
+	if err := db.Model(model.User{}).Order("id asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	primaryId := 0
+	if len(rows) > 0 {
+		primaryId = rows[0].Id
+	}
+	out := make([]*UserView, 0, len(rows))
+	for _, u := range rows {
+		view, err := toUserView(u, primaryId)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, view)
+	}
+	return out, nil
+}
+
+func (s *UserService) CreateUser(req *UserAccountRequest) (*UserView, error) {
+	username, perms, err := normalizeAccount(req)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Password) == "" {
+		return nil, errors.New("password can not be empty")
+	}
+	hashed, err := crypto.HashPasswordAsBcrypt(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	db := database.GetDB()
+	if err := ensureUsernameFree(db, username, 0); err != nil {
+		return nil, err
+	}
+	if err := normalizeResellerLimits(db, req); err != nil {
+		return nil, err
+	}
+	user := &model.User{
+		Username:        username,
+		Password:        hashed,
+		Role:            req.Role,
+		Permissions:     perms,
+		AllowedInbounds: req.AllowedInbounds,
+		MaxClients:      req.MaxClients,
+		MaxTrafficGB:    req.MaxTrafficGB,
+		MaxExpiryDays:   req.MaxExpiryDays,
+	}
+	if err := db.Create(user).Error; err != nil {
+		return nil, err
+	}
+	return s.View(user)
+}
+
+// UpdateAccount edits another account. Changing the password signs the
+// account out everywhere; role and permission changes apply on its next request.
+func (s *UserService) UpdateAccount(id int, req *UserAccountRequest) (*UserView, error) {
+	username, perms, err := normalizeAccount(req)
+	if err != nil {
+		return nil, err
+	}
+	db := database.GetDB()
+	user, err := s.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+	primaryId, err := primaryUserId(db)
+	if err != nil {
+		return nil, err
+	}
+	if id == primaryId && req.Role != model.UserRoleOwner {
+		return nil, errors.New("the primary account must stay an owner")
+	}
+	if user.IsOwner() && req.Role != model.UserRoleOwner {
+		if err := ensureAnotherOwner(db, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := ensureUsernameFree(db, username, id); err != nil {
+		return nil, err
+	}
+	if err := normalizeResellerLimits(db, req); err != nil {
+		return nil, err
+	}
+	user.Username = username
+	user.Role = req.Role
+	user.Permissions = perms
+	user.AllowedInbounds = req.AllowedInbounds
+	user.MaxClients = req.MaxClients
+	user.MaxTrafficGB = req.MaxTrafficGB
+	user.MaxExpiryDays = req.MaxExpiryDays
+	columns := []string{"username", "role", "permissions", "allowed_inbounds", "max_clients", "max_traffic_gb", "max_expiry_days"}
+	if strings.TrimSpace(req.Password) != "" {
+		hashed, err := crypto.HashPasswordAsBcrypt(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.Password = hashed
+		user.LoginEpoch++
+		columns = append(columns, "password", "login_epoch")
+	}
+	if err := db.Model(user).Select(columns).Updates(user).Error; err != nil {
+		return nil, err
+	}
+	if user, err = s.GetUser(id); err != nil {
+		return nil, err
+	}
+	return toUserView(user, primaryId)
+}
+
+func (s *UserService) DeleteUser(id int, actorId int) error {
+	if id == actorId {
+		return errors.New("you can not delete your own account")
+	}
+	db := database.GetDB()
+	user, err := s.GetUser(id)
+	if err != nil {
+		return err
+	}
+	primaryId, err := primaryUserId(db)
+	if err != nil {
+		return err
+	}
+	if id == primaryId {
+		return errors.New("the primary account can not be deleted")
+	}
+	if user.IsOwner() {
+		if err := ensureAnotherOwner(db, id); err != nil {
+			return err
+		}
+	}
+	// A removed reseller's clients fall back to the panel instead of vanishing from view.
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ClientRecord{}).Where("owner_id = ?", id).UpdateColumn("owner_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.User{}, id).Error
+	})
+}
+
+// ResetUserTwoFactor lets an owner unlock an account that lost its authenticator.
+func (s *UserService) ResetUserTwoFactor(id int) error {
+	if _, err := s.GetUser(id); err != nil {
+		return err
+	}
+	return s.SetTwoFactor(id, false, "")
+}
+
+func normalizeAccount(req *UserAccountRequest) (string, map[string]string, error) {
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return "", nil, errors.New("username can not be empty")
+	}
+	if !model.IsKnownUserRole(req.Role) {
+		return "", nil, errors.New("unknown role: " + req.Role)
+	}
+	if req.Role == model.UserRoleOwner {
+		return username, nil, nil
+	}
+	perms := map[string]string{}
+	for group, level := range req.Permissions {
+		if !model.IsKnownPermGroup(group) {
+			return "", nil, errors.New("unknown permission group: " + group)
+		}
+		if !model.IsKnownAccess(level) {
+			return "", nil, errors.New("unknown access level: " + level)
+		}
+		perms[group] = level
+	}
+	return username, perms, nil
+}
+
+// normalizeResellerLimits clears the limits on other roles and checks that a
+// reseller is only granted inbounds that exist.
+func normalizeResellerLimits(db *gorm.DB, req *UserAccountRequest) error {
+	if req.Role != model.UserRoleReseller {
+		req.AllowedInbounds = nil
+		req.MaxClients, req.MaxTrafficGB, req.MaxExpiryDays = 0, 0, 0
+		return nil
+	}
+	if req.MaxClients < 0 || req.MaxTrafficGB < 0 || req.MaxExpiryDays < 0 {
+		return errors.New("reseller limits can not be negative")
+	}
+	ids := make([]int, 0, len(req.AllowedInbounds))
+	for _, id := range req.AllowedInbounds {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		var found int64
+		if err := db.Model(&model.Inbound{}).Where("id IN ?", ids).Count(&found).Error; err != nil {
+			return err
+		}
+		if int(found) != len(ids) {
+			return errors.New("unknown inbound in the reseller's allowed inbounds")
+		}
+	}
+	req.AllowedInbounds = ids
+	return nil
+}
+
+func ensureUsernameFree(db *gorm.DB, username string, exceptId int) error {
+	var count int64
+	if err := db.Model(model.User{}).Where("username = ? AND id <> ?", username, exceptId).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("username is already taken")
+	}
+	return nil
+}
+
+func ensureAnotherOwner(db *gorm.DB, exceptId int) error {
+	var count int64
+	if err := db.Model(model.User{}).
+		Where("id <> ? AND (role = ? OR role = '' OR role IS NULL)", exceptId, model.UserRoleOwner).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("at least one owner account is required")
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package panel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlzd/gotp"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/config"
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+func setupUserTestDB(t *testing.T) *UserService {
+	t.Helper()
+	t.Setenv("XUI_DB_FOLDER", t.TempDir())
+	if err := database.InitDB(config.GetDBPath()); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseDB() })
+	return &UserService{}
+}
+
+func TestVerifyTwoFactorCode(t *testing.T) {
+	s := setupUserTestDB(t)
+	const token = "JBSWY3DPEHPK3PXP"
+	user := &model.User{TwoFactorEnable: true, TwoFactorToken: token}
+
+	if err := s.VerifyTwoFactorCode(user, gotp.NewDefaultTOTP(token).Now()); err != nil {
+		t.Fatalf("valid code rejected: %v", err)
+	}
+	if err := s.VerifyTwoFactorCode(user, "000000"); err == nil {
+		t.Fatal("invalid code accepted")
+	}
+	if err := s.VerifyTwoFactorCode(&model.User{}, ""); err != nil {
+		t.Fatalf("account without 2FA must not need a code: %v", err)
+	}
+}
+
+func TestSetTwoFactorIsPerAccount(t *testing.T) {
+	s := setupUserTestDB(t)
+	other, err := s.CreateUser(&UserAccountRequest{Username: "support", Password: "pw", Role: model.UserRoleReadOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetTwoFactor(other.Id, true, "JBSWY3DPEHPK3PXP"); err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.GetFirstUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.TwoFactorEnable {
+		t.Fatal("enabling 2FA on one account changed another")
+	}
+	if on, _ := s.AnyTwoFactorEnabled(); !on {
+		t.Fatal("AnyTwoFactorEnabled = false with one account enrolled")
+	}
+	if err := s.ResetTwoFactor(); err != nil {
+		t.Fatal(err)
+	}
+	if on, _ := s.AnyTwoFactorEnabled(); on {
+		t.Fatal("ResetTwoFactor left an account enrolled")
+	}
+}
+
+func TestAccountChangesKeepAnOwner(t *testing.T) {
+	s := setupUserTestDB(t)
+	primary, err := s.GetFirstUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.CreateUser(&UserAccountRequest{Username: "second", Password: "pw", Role: model.UserRoleOwner})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		run  func() error
+		want string
+	}{
+		{"demote primary", func() error {
+			_, err := s.UpdateAccount(primary.Id, &UserAccountRequest{Username: primary.Username, Role: model.UserRoleAdmin})
+			return err
+		}, "primary"},
+		{"delete primary", func() error { return s.DeleteUser(primary.Id, second.Id) }, "primary"},
+		{"delete self", func() error { return s.DeleteUser(second.Id, second.Id) }, "own account"},
+		{"duplicate username", func() error {
+			_, err := s.UpdateAccount(second.Id, &UserAccountRequest{Username: primary.Username, Role: model.UserRoleOwner})
+			return err
+		}, "taken"},
+		{"unknown role", func() error {
+			_, err := s.CreateUser(&UserAccountRequest{Username: "x", Password: "pw", Role: "root"})
+			return err
+		}, "unknown role"},
+		{"unknown group", func() error {
+			_, err := s.CreateUser(&UserAccountRequest{Username: "x", Password: "pw", Role: model.UserRoleReseller, Permissions: map[string]string{"billing": model.AccessRead}})
+			return err
+		}, "permission group"},
+	}
+	for _, c := range cases {
+		if err := c.run(); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: err = %v, want it to mention %q", c.name, err, c.want)
+		}
+	}
+
+	if _, err := s.UpdateAccount(second.Id, &UserAccountRequest{Username: "second", Role: model.UserRoleReseller}); err != nil {
+		t.Fatalf("demoting a non-primary owner: %v", err)
+	}
+	if err := s.DeleteUser(second.Id, primary.Id); err != nil {
+		t.Fatalf("deleting a non-primary account: %v", err)
+	}
+}
+
+// TestUpdateUserKeepsUsernamesUnique: renaming yourself onto another account
+// would leave that account unable to sign in.
+func TestUpdateUserKeepsUsernamesUnique(t *testing.T) {
+	s := setupUserTestDB(t)
+	primary, err := s.GetFirstUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := s.CreateUser(&UserAccountRequest{Username: "reader", Password: "pw", Role: model.UserRoleReadOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateUser(reader.Id, " "+primary.Username+" ", "pw2"); err == nil || !strings.Contains(err.Error(), "taken") {
+		t.Fatalf("rename onto another account: err = %v", err)
+	}
+	if err := s.UpdateUser(reader.Id, "  ", "pw2"); err == nil {
+		t.Fatal("blank username accepted")
+	}
+	if err := s.UpdateUser(reader.Id, "reader2", "pw2"); err != nil {
+		t.Fatalf("free username rejected: %v", err)
+	}
+	if err := s.UpdateUser(reader.Id, "reader2", "pw3"); err != nil {
+		t.Fatalf("keeping your own username rejected: %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/importer"
+)
+
+// Outcomes of a source inbound in an ImportReport.
+const (
+	ImportInboundCreate = "create"
+	ImportInboundMap    = "map"
+	ImportInboundSkip   = "skip"
+)
+
+// ImportInboundReport says what became of one inbound of the source panel.
+type ImportInboundReport struct {
+	Source string `json:"source" example:"VLESS TCP REALITY"`
+	Action string `json:"action" example:"create"`
+	Target string `json:"target,omitempty" example:"VLESS TCP REALITY"`
+	Reason string `json:"reason,omitempty" example:"port 443 is already in use"`
+}
+
+// ImportReport is the outcome of importing another panel: each source inbound,
+// how many clients were created and the clients skipped with the reason.
+type ImportReport struct {
+	Source   string                `json:"source" example:"marzban"`
+	DryRun   bool                  `json:"dryRun,omitempty"`
+	Inbounds []ImportInboundReport `json:"inbounds"`
+	Created  int                   `json:"created" example:"120"`
+	Skipped  []BulkCreateReport    `json:"skipped,omitempty"`
+	Warnings []string              `json:"warnings,omitempty"`
+}
+
+// PanelImportService recreates the inbounds and users read from another panel.
+type PanelImportService struct {
+	inboundService InboundService
+	clientService  ClientService
+}
+
+// Import creates the bundle's inbounds and clients, skipping taken tags, ports and
+// emails rather than merging. The boolean reports whether Xray needs a restart.
+func (s *PanelImportService) Import(b *importer.Bundle, mapping map[string]string, dryRun bool) (*ImportReport, bool, error) {
+	report := &ImportReport{Source: b.Source, DryRun: dryRun, Inbounds: []ImportInboundReport{}, Warnings: b.Warnings}
+	db := database.GetDB()
+	needRestart := false
+
+	for src := range mapping {
+		if !slices.ContainsFunc(b.Inbounds, func(ib importer.Inbound) bool { return ib.Tag == src }) {
+			report.Warnings = append(report.Warnings, "mapping for unknown source inbound "+src+" ignored")
+		}
+	}
+
+	userId, err := primaryUserIdOrDefault()
+	if err != nil {
+		return nil, false, err
+	}
+	targets := map[string]int{}  // source tag -> inbound id here
+	var planned []*model.Inbound // dry run only: the real run sees them in the DB
+	for _, src := range b.Inbounds {
+		entry := ImportInboundReport{Source: src.Tag}
+		skip := func(reason string) {
+			entry.Action, entry.Reason = ImportInboundSkip, reason
+		}
+		if target, ok := mapping[src.Tag]; ok {
+			existing := &model.Inbound{}
+			res := db.Where("tag = ?", target).Limit(1).Find(existing)
+			switch {
+			case res.Error != nil:
+				return nil, needRestart, res.Error
+			case res.RowsAffected == 0:
+				skip("no inbound " + target + " here")
+			default:
+				entry.Action, entry.Target = ImportInboundMap, target
+				targets[src.Tag] = existing.Id
+			}
+			report.Inbounds = append(report.Inbounds, entry)
+			continue
+		}
+		if src.Port == 0 {
+			skip("the source has no usable definition of it; map it onto an inbound here")
+			report.Inbounds = append(report.Inbounds, entry)
+			continue
+		}
+
+		ib := &model.Inbound{
+			UserId:         userId,
+			Tag:            src.Tag,
+			Remark:         src.Remark,
+			Enable:         src.Enable,
+			Protocol:       src.Protocol,
+			Listen:         src.Listen,
+			Port:           src.Port,
+			Settings:       src.Settings,
+			StreamSettings: src.StreamSettings,
+			Sniffing:       src.Sniffing,
+			Total:          src.Total,
+			ExpiryTime:     src.ExpiryTime,
+		}
+		taken, err := s.inboundService.tagExists(src.Tag, 0)
+		if err != nil {
+			return nil, needRestart, err
+		}
+		if taken || slices.ContainsFunc(planned, func(p *model.Inbound) bool { return p.Tag == src.Tag }) {
+			skip("an inbound with this tag already exists; map it onto that inbound instead")
+			report.Inbounds = append(report.Inbounds, entry)
+			continue
+		}
+		if dryRun {
+			conflict, err := checkPortConflictTx(db, ib, 0)
+			if err != nil {
+				return nil, needRestart, err
+			}
+			if conflict == nil {
+				conflict = portConflictAmong(planned, ib)
+			}
+			if conflict != nil {
+				skip(conflict.String())
+			} else {
+				entry.Action, entry.Target = ImportInboundCreate, src.Tag
+				targets[src.Tag] = -len(report.Inbounds) - 1 // stands in for the id it would get
+				planned = append(planned, ib)
+			}
+			report.Inbounds = append(report.Inbounds, entry)
+			continue
+		}
+		created, nr, err := s.inboundService.AddInbound(ib)
+		if err != nil {
+			skip(err.Error())
+		} else {
+			needRestart = needRestart || nr
+			entry.Action, entry.Target = ImportInboundCreate, created.Tag
+			targets[src.Tag] = created.Id
+		}
+		report.Inbounds = append(report.Inbounds, entry)
+	}
+
+	items := make([]ClientCreatePayload, 0, len(b.Clients))
+	used := map[string]importer.Client{}
+	seen := map[string]bool{}
+	unattached := 0
+	for _, c := range b.Clients {
+		key := strings.ToLower(c.Email)
+		skipClient := func(reason string) {
+			report.Skipped = append(report.Skipped, BulkCreateReport{Email: c.Email, Reason: reason})
+		}
+		if seen[key] {
+			skipClient("email appears more than once in the source")
+			continue
+		}
+		seen[key] = true
+		if c.Email == "" {
+			skipClient("client email is required")
+			continue
+		}
+		var count int64
+		if err := db.Model(&model.ClientRecord{}).Where("LOWER(email) = ?", key).Count(&count).Error; err != nil {
+			return report, needRestart, err
+		}
+		if count > 0 {
+			skipClient("a client with this email already exists")
+			continue
+		}
+		var ids []int
+		for _, tag := range c.Inbounds {
+			if id, ok := targets[tag]; ok && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			unattached++
+		}
+		items = append(items, ClientCreatePayload{Client: c.Client, InboundIds: ids, LimitHwid: c.LimitHwid})
+		used[key] = c
+	}
+	if unattached > 0 {
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("%d client(s) are not attached to any inbound here; attach them after the import", unattached))
+	}
+
+	if dryRun {
+		for _, item := range items {
+			if err := validateClientEmail(item.Client.Email); err != nil {
+				report.Skipped = append(report.Skipped, BulkCreateReport{Email: item.Client.Email, Reason: err.Error()})
+				continue
+			}
+			if err := validateClientSubID(item.Client.SubID); err != nil {
+				report.Skipped = append(report.Skipped, BulkCreateReport{Email: item.Client.Email, Reason: err.Error()})
+				continue
+			}
+			report.Created++
+		}
+		return report, false, nil
+	}
+
+	result, nr, err := s.clientService.ImportClients(&s.inboundService, items)
+	needRestart = needRestart || nr
+	if err != nil {
+		return report, needRestart, err
+	}
+	report.Created = result.Created
+	report.Skipped = append(report.Skipped, result.Skipped...)
+
+	// Carry over the traffic already used, so quotas keep counting from where
+	// the source left off.
+	skipped := map[string]bool{}
+	for _, sk := range result.Skipped {
+		skipped[strings.ToLower(sk.Email)] = true
+	}
+	var disabled []string
+	for key, c := range used {
+		if skipped[key] {
+			continue
+		}
+		if !c.Enable {
+			disabled = append(disabled, c.Email)
+		}
+		if c.Up == 0 && c.Down == 0 {
+			continue
+		}
+		if err := s.inboundService.UpdateClientTrafficByEmail(c.Email, c.Up, c.Down); err != nil {
+			return report, needRestart, err
+		}
+	}
+	// Creating a client enables it.
+	if len(disabled) > 0 {
+		_, nr, err := s.clientService.BulkSetEnable(&s.inboundService, disabled, false)
+		needRestart = needRestart || nr
+		if err != nil {
+			return report, needRestart, err
+		}
+	}
+	return report, needRestart, nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/importer"
+)
+
+func importBundle() *importer.Bundle {
+	return &importer.Bundle{
+		Source: importer.SourceMarzban,
+		Inbounds: []importer.Inbound{
+			{
+				Tag: "src-tcp", Remark: "src-tcp", Protocol: model.VLESS, Port: 21101, Enable: true,
+				Settings:       `{"clients":[],"decryption":"none"}`,
+				StreamSettings: `{"network":"tcp","security":"none"}`,
+			},
+			{Tag: "vless", Protocol: model.VLESS, Enable: true},
+			{Tag: "trojan", Protocol: model.Trojan, Enable: true},
+		},
+		Clients: []importer.Client{
+			{
+				Client:   model.Client{Email: "alice", ID: "11111111-1111-1111-1111-111111111111", SubID: "alice", Enable: true, TotalGB: 1000},
+				Down:     400,
+				Inbounds: []string{"src-tcp", "vless"},
+			},
+			{
+				Client:   model.Client{Email: "bob", ID: "22222222-2222-2222-2222-222222222222", SubID: "bob"},
+				Inbounds: []string{"src-tcp"},
+			},
+			{
+				Client:   model.Client{Email: "Taken", ID: "33333333-3333-3333-3333-333333333333", SubID: "taken", Enable: true},
+				Inbounds: []string{"src-tcp"},
+			},
+		},
+	}
+}
+
+func TestPanelImportCreatesMapsAndSkips(t *testing.T) {
+	setupBulkDB(t)
+	existing := mkInbound(t, 21102, model.VLESS, `{"clients":[],"decryption":"none"}`)
+	svc := &PanelImportService{}
+	if _, _, err := svc.clientService.ImportClients(&svc.inboundService, []ClientCreatePayload{{
+		Client:     model.Client{ID: "44444444-4444-4444-4444-444444444444", Email: "taken", SubID: "taken-here", Enable: true},
+		InboundIds: []int{existing.Id},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	mapping := map[string]string{"vless": existing.Tag, "nowhere": "x"}
+
+	preview, _, err := svc.Import(importBundle(), mapping, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if preview.Created != 2 {
+		t.Fatalf("dry run created %d, want 2: %+v", preview.Created, preview)
+	}
+	if n, _ := svc.inboundService.tagExists("src-tcp", 0); n {
+		t.Fatal("dry run created an inbound")
+	}
+
+	report, _, err := svc.Import(importBundle(), mapping, false)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	actions := []string{}
+	for _, ib := range report.Inbounds {
+		actions = append(actions, ib.Action+" "+ib.Source)
+	}
+	want := []string{"create src-tcp", "map vless", "skip trojan"}
+	if !slices.Equal(actions, want) {
+		t.Fatalf("inbounds = %v, want %v", actions, want)
+	}
+	if report.Created != 2 || len(report.Skipped) != 1 || report.Skipped[0].Email != "Taken" {
+		t.Fatalf("report = %+v", report)
+	}
+	if !slices.ContainsFunc(report.Warnings, func(w string) bool { return w == "mapping for unknown source inbound nowhere ignored" }) {
+		t.Fatalf("warnings = %v", report.Warnings)
+	}
+
+	cs := &ClientService{}
+	alice, err := cs.GetRecordByEmail(nil, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.UUID != "11111111-1111-1111-1111-111111111111" || alice.SubID != "alice" || !alice.Enable {
+		t.Fatalf("alice = %+v", alice)
+	}
+	ids, err := cs.GetInboundIdsForEmail(nil, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || !slices.Contains(ids, existing.Id) {
+		t.Fatalf("alice inbounds = %v", ids)
+	}
+	traffic, err := svc.inboundService.GetClientTrafficByEmail("alice")
+	if err != nil || traffic == nil || traffic.Down != 400 {
+		t.Fatalf("alice traffic = %+v, %v", traffic, err)
+	}
+	bob, err := cs.GetRecordByEmail(nil, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Enable {
+		t.Fatal("disabled source client imported enabled")
+	}
+
+	again, _, err := svc.Import(importBundle(), mapping, false)
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	if again.Created != 0 || again.Inbounds[0].Action != ImportInboundSkip {
+		t.Fatalf("second import = %+v", again)
+	}
+}
+
+// TestPanelImportDryRunSeesItsOwnPlan: a dry run must skip a source inbound
+// that clashes with one planned earlier in the bundle, as the real run does.
+func TestPanelImportDryRunSeesItsOwnPlan(t *testing.T) {
+	setupBulkDB(t)
+	inbound := func(tag string, port int) importer.Inbound {
+		return importer.Inbound{
+			Tag: tag, Protocol: model.VLESS, Port: port, Enable: true,
+			Settings:       `{"clients":[],"decryption":"none"}`,
+			StreamSettings: `{"network":"tcp","security":"none"}`,
+		}
+	}
+	bundle := func() *importer.Bundle {
+		return &importer.Bundle{Source: importer.SourceMarzban, Inbounds: []importer.Inbound{
+			inbound("first", 21201),
+			inbound("same-port", 21201),
+			inbound("first", 21202),
+		}}
+	}
+	actions := func(r *ImportReport) []string {
+		var out []string
+		for _, ib := range r.Inbounds {
+			out = append(out, ib.Action+" "+ib.Source)
+		}
+		return out
+	}
+	svc := &PanelImportService{}
+
+	preview, _, err := svc.Import(bundle(), nil, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	want := []string{"create first", "skip same-port", "skip first"}
+	if got := actions(preview); !slices.Equal(got, want) {
+		t.Fatalf("dry run inbounds = %v, want %v", got, want)
+	}
+	report, _, err := svc.Import(bundle(), nil, false)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if got := actions(report); !slices.Equal(got, want) {
+		t.Fatalf("import inbounds = %v, want %v", got, want)
+	}
+}
@@ -192,8 +192,15 @@ func checkPortConflictTx(db *gorm.DB, inbound *model.Inbound, ignoreId int) (*po
 		return nil, err
 	}
 
+	return portConflictAmong(candidates, inbound), nil
+}
+
+// portConflictAmong is checkPortConflictTx against inbounds that need not be
+// stored yet, such as the ones an import run has already planned.
+func portConflictAmong(candidates []*model.Inbound, inbound *model.Inbound) *portConflictDetail {
+	newBits := inboundTransports(inbound.Protocol, inbound.StreamSettings, inbound.Settings)
 	for _, c := range candidates {
-		if !sameNode(c.NodeID, inbound.NodeID) {
+		if c.Port != inbound.Port || !sameNode(c.NodeID, inbound.NodeID) {
 			continue
 		}
 		if !listenOverlaps(c.Listen, inbound.Listen) {
@@ -211,9 +218,9 @@ func checkPortConflictTx(db *gorm.DB, inbound *model.Inbound, ignoreId int) (*po
 			Listen:     c.Listen,
 			Port:       c.Port,
 			Transports: shared,
-		}, nil
+		}
 	}
-	return nil, nil
+	return nil
 }
 
 func sameNode(a, b *int) bool {
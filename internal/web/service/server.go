@@ -994,12 +994,8 @@ func (s *ServerService) UpdateXray(version string) error {
 		return fmt.Errorf("xray version %q is not in the fetched release list", version)
 	}
 
-	// 1. Stop xray before doing anything
-	if err := s.StopXrayService(); err != nil {
-		logger.Warning("failed to stop xray before update:", err)
-	}
-
-	// 2. Download the zip
+	// 1. Download and checksum the zip while the current core keeps running,
+	// so a failed download or a mismatched checksum leaves it untouched.
 	zipFileName, err := s.downloadXRay(version)
 	if err != nil {
 		return err
@@ -1021,6 +1017,11 @@ func (s *ServerService) UpdateXray(version string) error {
 		return err
 	}
 
+	// 2. Stop xray only once there is a verified archive to install
+	if err := s.StopXrayService(); err != nil {
+		logger.Warning("failed to stop xray before update:", err)
+	}
+
 	// 3. Helper to extract files
 	copyZipFile := func(zipName string, fileName string) error {
 		zipFile, err := reader.Open(zipName)
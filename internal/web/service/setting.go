@@ -15,10 +15,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/xlzd/gotp"
 	"gorm.io/gorm"
 
+	"github.com/mhsanaei/3x-ui/v3/internal/acme"
 	"github.com/mhsanaei/3x-ui/v3/internal/config"
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/secrets"
 	"github.com/mhsanaei/3x-ui/v3/internal/database"
 	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
@@ -34,11 +35,12 @@ import (
 var xrayTemplateConfig string
 
 const (
-	DefaultSubClashUserAgentRegex = `(?i)(clash|mihomo)`
-	DefaultSubJsonUserAgentRegex  = ``
-	DefaultRemarkTemplate         = "{{INBOUND}}-{{EMAIL}}|📊{{TRAFFIC_LEFT}}|⏳{{DAYS_LEFT}}D"
-	DefaultTrustedProxyCIDRs      = "127.0.0.1/32,::1/128"
-	maxRegexLength                = 2048
+	DefaultSubClashUserAgentRegex   = `(?i)(clash|mihomo)`
+	DefaultSubJsonUserAgentRegex    = ``
+	DefaultSubSingboxUserAgentRegex = `(?i)(sing-box|^sf[aimt]/|hiddify|nekobox|karing)`
+	DefaultRemarkTemplate           = "{{INBOUND}}-{{EMAIL}}|📊{{TRAFFIC_LEFT}}|⏳{{DAYS_LEFT}}D"
+	DefaultTrustedProxyCIDRs        = "127.0.0.1/32,::1/128"
+	maxRegexLength                  = 2048
 )
 
 var defaultValueMap = map[string]string{
@@ -81,8 +83,6 @@ var defaultValueMap = map[string]string{
 	"tgCpu":                       "80",
 	"tgMemory":                    "80",
 	"tgLang":                      "en-US",
-	"twoFactorEnable":             "false",
-	"twoFactorToken":              "",
 	"subEnable":                   "true",
 	"subJsonEnable":               "false",
 	"subJsonAutoDetect":           "false",
@@ -90,6 +90,8 @@ var defaultValueMap = map[string]string{
 	"subJsonUserAgentRegex":       "",
 	"subClashAutoDetect":          "false",
 	"subClashUserAgentRegex":      "",
+	"subSingboxAutoDetect":        "false",
+	"subSingboxUserAgentRegex":    "",
 	"subTitle":                    "",
 	"subSupportUrl":               "",
 	"subProfileUrl":               "",
@@ -115,6 +117,11 @@ var defaultValueMap = map[string]string{
 	"subClashURI":                 "",
 	"subClashEnableRouting":       "false",
 	"subClashRules":               "",
+	"subSingboxEnable":            "false",
+	"subSingboxPath":              "/singbox/",
+	"subSingboxURI":               "",
+	"subSingboxMux":               "",
+	"subSingboxRules":             "",
 	"subJsonMux":                  "",
 	"subJsonRules":                "",
 	"subJsonFinalMask":            "",
@@ -122,6 +129,28 @@ var defaultValueMap = map[string]string{
 	"datepicker":                  "gregorian",
 	"warp":                        "",
 	"warpUpdateInterval":          "0",
+	"acmeEmail":                   "",
+	"acmeDirectoryUrl":            acme.LetsEncryptDirectoryURL,
+	"acmeHttpPort":                "80",
+	"acmeTlsAlpnPort":             "443",
+	"acmeRenewDays":               "30",
+	"acmeAccountKey":              "", // minted on the first order, kept out of AllSetting
+	"auditRetentionDays":          "90",
+	"metricsClientLabels":         "false",
+	"trafficHistoryHourlyDays":    "7",
+	"trafficHistoryDays":          "365",
+	"backupEnable":                "false",
+	"backupCron":                  "@daily",
+	"backupKeep":                  "7",
+	"backupPassphrase":            "",
+	"backupLocalDir":              "",
+	"backupS3Endpoint":            "",
+	"backupS3Region":              "us-east-1",
+	"backupS3Bucket":              "",
+	"backupS3Prefix":              "x-ui/",
+	"backupS3AccessKey":           "",
+	"backupS3SecretKey":           "",
+	"backupS3PathStyle":           "true",
 	"nord":                        "",
 	"pia":                         "",
 	"externalTrafficInformEnable": "false",
@@ -159,6 +188,8 @@ var defaultValueMap = map[string]string{
 	"smtpEnabledEvents": "login.attempt,cpu.high",
 	"smtpCpu":           "80",
 	"smtpMemory":        "80",
+	"webhookCpu":        "80",
+	"webhookMemory":     "80",
 
 	// Consecutive failed observatory probes before an outbound.down event fires
 	"outboundDownThreshold": "3",
@@ -243,7 +274,15 @@ func (s *SettingService) GetAllSetting() (*entity.AllSetting, error) {
 
 	keyMap := map[string]bool{}
 	for _, setting := range settings {
-		err := setSetting(setting.Key, setting.Value)
+		value, err := openSetting(setting.Key, setting.Value)
+		if errors.Is(err, secrets.ErrUnreadable) {
+			// Served blank; GetAllSettingView lists it for re-entry.
+			keyMap[setting.Key] = true
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		err = setSetting(setting.Key, value)
 		if err != nil {
 			return nil, err
 		}
@@ -270,19 +309,26 @@ func (s *SettingService) GetAllSettingView() (*entity.AllSettingView, error) {
 	}
 	view := &entity.AllSettingView{AllSetting: *allSetting}
 	view.HasTgBotToken = secretConfigured(allSetting.TgBotToken)
-	view.HasTwoFactorToken = secretConfigured(allSetting.TwoFactorToken)
 	view.HasLdapPassword = secretConfigured(allSetting.LdapPassword)
 	view.HasWarpSecret = secretConfigured(mustString(s.GetWarp()))
 	view.HasNordSecret = secretConfigured(mustString(s.GetNord()))
 	view.HasSmtpPassword = secretConfigured(allSetting.SmtpPassword)
+	view.HasBackupPassphrase = secretConfigured(allSetting.BackupPassphrase)
+	view.HasBackupS3SecretKey = secretConfigured(allSetting.BackupS3SecretKey)
 	var apiTokenCount int64
 	if err := database.GetDB().Model(model.ApiToken{}).Where("enabled = ?", true).Count(&apiTokenCount).Error; err == nil {
 		view.HasApiToken = apiTokenCount > 0
 	}
+	unreadable, err := UnreadableSecrets()
+	if err != nil {
+		return nil, err
+	}
+	view.UnreadableSecrets = unreadable
 	view.TgBotToken = ""
-	view.TwoFactorToken = ""
 	view.LdapPassword = ""
 	view.SmtpPassword = ""
+	view.BackupPassphrase = ""
+	view.BackupS3SecretKey = ""
 	return view, nil
 }
 
@@ -327,6 +373,10 @@ func (s *SettingService) getSetting(key string) (*model.Setting, error) {
 }
 
 func (s *SettingService) saveSetting(key string, value string) error {
+	value, err := sealSetting(key, value)
+	if err != nil {
+		return err
+	}
 	setting, err := s.getSetting(key)
 	db := database.GetDB()
 	if database.IsNotFound(err) {
@@ -353,7 +403,7 @@ func (s *SettingService) getString(key string) (string, error) {
 	} else if err != nil {
 		return "", err
 	}
-	return setting.Value, nil
+	return openSetting(key, setting.Value)
 }
 
 func (s *SettingService) setString(key string, value string) error {
@@ -582,40 +632,6 @@ func (s *SettingService) GetTgLang() (string, error) {
 	return s.getString("tgLang")
 }
 
-func (s *SettingService) GetTwoFactorEnable() (bool, error) {
-	return s.getBool("twoFactorEnable")
-}
-
-func (s *SettingService) SetTwoFactorEnable(value bool) error {
-	return s.setBool("twoFactorEnable", value)
-}
-
-func (s *SettingService) GetTwoFactorToken() (string, error) {
-	return s.getString("twoFactorToken")
-}
-
-func (s *SettingService) SetTwoFactorToken(value string) error {
-	return s.setString("twoFactorToken", value)
-}
-
-func (s *SettingService) VerifyTwoFactorCode(code string) error {
-	enabled, err := s.GetTwoFactorEnable()
-	if err != nil {
-		return err
-	}
-	if !enabled {
-		return nil
-	}
-	token, err := s.GetTwoFactorToken()
-	if err != nil {
-		return err
-	}
-	if strings.TrimSpace(token) == "" || !gotp.NewDefaultTOTP(token).Verify(strings.TrimSpace(code), time.Now().Unix()) {
-		return common.NewError("invalid two factor code")
-	}
-	return nil
-}
-
 func (s *SettingService) GetPort() (int, error) {
 	return s.getInt("webPort")
 }
@@ -672,6 +688,11 @@ func (s *SettingService) GetSubShowIdentityOnAllLinks() (bool, error) {
 
 func (s *SettingService) GetSecret() ([]byte, error) {
 	secret, err := s.getString("secret")
+	if errors.Is(err, secrets.ErrUnreadable) {
+		// Replacing a session key no key opens only signs everyone out.
+		logger.Warning("session secret cannot be opened with this keyring; generating a new one")
+		secret, err = "", nil
+	}
 	if secret == "" || secret == defaultValueMap["secret"] {
 		if secret == "" {
 			secret = defaultValueMap["secret"]
@@ -767,6 +788,14 @@ func (s *SettingService) GetSubClashUserAgentRegex() (string, error) {
 	return s.getString("subClashUserAgentRegex")
 }
 
+func (s *SettingService) GetSubSingboxAutoDetect() (bool, error) {
+	return s.getBool("subSingboxAutoDetect")
+}
+
+func (s *SettingService) GetSubSingboxUserAgentRegex() (string, error) {
+	return s.getString("subSingboxUserAgentRegex")
+}
+
 func (s *SettingService) GetSubTitle() (string, error) {
 	return s.getString("subTitle")
 }
@@ -881,6 +910,26 @@ func (s *SettingService) GetSubClashRules() (string, error) {
 	return s.getString("subClashRules")
 }
 
+func (s *SettingService) GetSubSingboxEnable() (bool, error) {
+	return s.getBool("subSingboxEnable")
+}
+
+func (s *SettingService) GetSubSingboxPath() (string, error) {
+	return s.getString("subSingboxPath")
+}
+
+func (s *SettingService) GetSubSingboxURI() (string, error) {
+	return s.getString("subSingboxURI")
+}
+
+func (s *SettingService) GetSubSingboxMux() (string, error) {
+	return s.getString("subSingboxMux")
+}
+
+func (s *SettingService) GetSubSingboxRules() (string, error) {
+	return s.getString("subSingboxRules")
+}
+
 func (s *SettingService) GetSubJsonMux() (string, error) {
 	return s.getString("subJsonMux")
 }
@@ -1171,6 +1220,14 @@ func (s *SettingService) SetSmtpMemory(value int) error {
 	return s.setInt("smtpMemory", value)
 }
 
+func (s *SettingService) GetWebhookCpu() (int, error) {
+	return s.getInt("webhookCpu")
+}
+
+func (s *SettingService) GetWebhookMemory() (int, error) {
+	return s.getInt("webhookMemory")
+}
+
 // GetOutboundDownThreshold returns how many consecutive failed observatory
 // probes an outbound must accumulate before an outbound.down notification is
 // emitted. 1 preserves the legacy "notify on the first failed probe" behaviour.
@@ -1182,13 +1239,88 @@ func (s *SettingService) SetOutboundDownThreshold(value int) error {
 	return s.setInt("outboundDownThreshold", value)
 }
 
+// GetAuditRetentionDays returns how long audit entries are kept; 0 keeps them forever.
+func (s *SettingService) GetAuditRetentionDays() (int, error) {
+	return s.getInt("auditRetentionDays")
+}
+
+// GetMetricsClientLabels reports whether /metrics emits a series per client
+// email, which multiplies the scrape size by the number of clients.
+func (s *SettingService) GetMetricsClientLabels() (bool, error) {
+	return s.getBool("metricsClientLabels")
+}
+
+// GetTrafficHistoryHourlyDays returns how long per-client traffic keeps hourly
+// resolution before it is folded into daily buckets.
+func (s *SettingService) GetTrafficHistoryHourlyDays() (int, error) {
+	return s.getInt("trafficHistoryHourlyDays")
+}
+
+// GetTrafficHistoryDays returns how long daily traffic buckets are kept; 0 keeps them forever.
+func (s *SettingService) GetTrafficHistoryDays() (int, error) {
+	return s.getInt("trafficHistoryDays")
+}
+
+func (s *SettingService) GetBackupEnable() (bool, error) {
+	return s.getBool("backupEnable")
+}
+
+func (s *SettingService) GetBackupCron() (string, error) {
+	return s.getString("backupCron")
+}
+
+// GetBackupKeep returns how many backups each target keeps; 0 keeps them all.
+func (s *SettingService) GetBackupKeep() (int, error) {
+	return s.getInt("backupKeep")
+}
+
+// GetBackupPassphrase returns the passphrase backups are encrypted with;
+// empty leaves them unencrypted.
+func (s *SettingService) GetBackupPassphrase() (string, error) {
+	return s.getString("backupPassphrase")
+}
+
+func (s *SettingService) GetBackupLocalDir() (string, error) {
+	return s.getString("backupLocalDir")
+}
+
+func (s *SettingService) GetBackupS3Endpoint() (string, error) {
+	return s.getString("backupS3Endpoint")
+}
+
+func (s *SettingService) GetBackupS3Region() (string, error) {
+	return s.getString("backupS3Region")
+}
+
+func (s *SettingService) GetBackupS3Bucket() (string, error) {
+	return s.getString("backupS3Bucket")
+}
+
+func (s *SettingService) GetBackupS3Prefix() (string, error) {
+	return s.getString("backupS3Prefix")
+}
+
+func (s *SettingService) GetBackupS3AccessKey() (string, error) {
+	return s.getString("backupS3AccessKey")
+}
+
+func (s *SettingService) GetBackupS3SecretKey() (string, error) {
+	return s.getString("backupS3SecretKey")
+}
+
+func (s *SettingService) GetBackupS3PathStyle() (bool, error) {
+	return s.getBool("backupS3PathStyle")
+}
+
 // SecretClears marks redacted secrets the user explicitly emptied. Without a
 // flag, a blank submitted secret means "unchanged" (the field is always served
 // blank to the browser) and the stored value is preserved.
 type SecretClears struct {
-	TgBotToken   bool
-	LdapPassword bool
-	SmtpPassword bool
+	TgBotToken        bool
+	LdapPassword      bool
+	SmtpPassword      bool
+	BackupPassphrase  bool
+	BackupS3SecretKey bool
 }
 
 func (s *SettingService) UpdateAllSetting(allSetting *entity.AllSetting, clears SecretClears) error {
@@ -1201,6 +1333,12 @@ func (s *SettingService) UpdateAllSetting(allSetting *entity.AllSetting, clears
 	if err := validateSubUserAgentRegexes(allSetting); err != nil {
 		return err
 	}
+	if err := validateSubSingboxJSON(allSetting); err != nil {
+		return err
+	}
+	if err := validateBackupSettings(allSetting); err != nil {
+		return err
+	}
 	if err := allSetting.CheckValid(); err != nil {
 		return err
 	}
@@ -1224,15 +1362,31 @@ func (s *SettingService) UpdateAllSetting(allSetting *entity.AllSetting, clears
 			fieldV := v.FieldByName(field.Name)
 			value := fmt.Sprint(fieldV.Interface())
 			if st, ok := byKey[key]; ok {
-				if st.Value == value {
+				current, err := openSetting(key, st.Value)
+				if errors.Is(err, secrets.ErrUnreadable) {
+					// Blank keeps the unreadable value until one is entered.
+					if value == "" {
+						continue
+					}
+				} else if err != nil {
+					return err
+				}
+				if current == value {
 					continue
 				}
+				if value, err = sealSetting(key, value); err != nil {
+					return err
+				}
 				st.Value = value
 				if err := tx.Save(st).Error; err != nil {
 					return err
 				}
 				continue
 			}
+			value, err := sealSetting(key, value)
+			if err != nil {
+				return err
+			}
 			if err := tx.Create(&model.Setting{Key: key, Value: value}).Error; err != nil {
 				return err
 			}
@@ -1250,8 +1404,36 @@ func validateSubUserAgentRegexes(allSetting *entity.AllSetting) error {
 	if err != nil {
 		return err
 	}
+	singboxPattern, err := validateSubUserAgentRegex("sing-box", allSetting.SubSingboxUserAgentRegex, DefaultSubSingboxUserAgentRegex)
+	if err != nil {
+		return err
+	}
 	allSetting.SubJsonUserAgentRegex = jsonPattern
 	allSetting.SubClashUserAgentRegex = clashPattern
+	allSetting.SubSingboxUserAgentRegex = singboxPattern
+	return nil
+}
+
+// validateSubSingboxJSON rejects sing-box mux/rules values the renderer would
+// silently drop: mux must be an object, rules an array or an object.
+func validateSubSingboxJSON(allSetting *entity.AllSetting) error {
+	if mux := strings.TrimSpace(allSetting.SubSingboxMux); mux != "" {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(mux), &obj); err != nil {
+			return common.NewError("sing-box multiplex must be a JSON object:", err)
+		}
+	}
+	if rules := strings.TrimSpace(allSetting.SubSingboxRules); rules != "" {
+		var custom any
+		if err := json.Unmarshal([]byte(rules), &custom); err != nil {
+			return common.NewError("sing-box rules must be valid JSON:", err)
+		}
+		switch custom.(type) {
+		case []any, map[string]any:
+		default:
+			return common.NewError("sing-box rules must be a JSON array or object")
+		}
+	}
 	return nil
 }
 
@@ -1286,32 +1468,39 @@ func ValidateRegex(pattern string) error {
 func (s *SettingService) preserveRedactedSecrets(allSetting *entity.AllSetting, clears SecretClears) error {
 	if !clears.TgBotToken && strings.TrimSpace(allSetting.TgBotToken) == "" {
 		value, err := s.GetTgBotToken()
-		if err != nil {
+		if err != nil && !errors.Is(err, secrets.ErrUnreadable) {
 			return err
 		}
 		allSetting.TgBotToken = value
 	}
 	if !clears.LdapPassword && strings.TrimSpace(allSetting.LdapPassword) == "" {
 		value, err := s.GetLdapPassword()
-		if err != nil {
+		if err != nil && !errors.Is(err, secrets.ErrUnreadable) {
 			return err
 		}
 		allSetting.LdapPassword = value
 	}
-	if allSetting.TwoFactorEnable && strings.TrimSpace(allSetting.TwoFactorToken) == "" {
-		value, err := s.GetTwoFactorToken()
-		if err != nil {
-			return err
-		}
-		allSetting.TwoFactorToken = value
-	}
 	if !clears.SmtpPassword && strings.TrimSpace(allSetting.SmtpPassword) == "" {
 		value, err := s.GetSmtpPassword()
-		if err != nil {
+		if err != nil && !errors.Is(err, secrets.ErrUnreadable) {
 			return err
 		}
 		allSetting.SmtpPassword = value
 	}
+	if !clears.BackupPassphrase && strings.TrimSpace(allSetting.BackupPassphrase) == "" {
+		value, err := s.GetBackupPassphrase()
+		if err != nil && !errors.Is(err, secrets.ErrUnreadable) {
+			return err
+		}
+		allSetting.BackupPassphrase = value
+	}
+	if !clears.BackupS3SecretKey && strings.TrimSpace(allSetting.BackupS3SecretKey) == "" {
+		value, err := s.GetBackupS3SecretKey()
+		if err != nil && !errors.Is(err, secrets.ErrUnreadable) {
+			return err
+		}
+		allSetting.BackupS3SecretKey = value
+	}
 	return nil
 }
 
@@ -1361,7 +1550,7 @@ func validateRemoteRoutingURLSetting(name string, value *string) error {
 
 func (s *SettingService) UpdateSecret(key string, value string) error {
 	switch key {
-	case "tgBotToken", "ldapPassword", "twoFactorToken":
+	case "tgBotToken", "ldapPassword":
 		return s.saveSetting(key, strings.TrimSpace(value))
 	default:
 		return common.NewError("secret key is not replaceable:", key)
@@ -1434,10 +1623,12 @@ func (s *SettingService) GetDefaultSettings(host string) (any, error) {
 		"subEnable":        func() (any, error) { return s.GetSubEnable() },
 		"subJsonEnable":    func() (any, error) { return s.GetSubJsonEnable() },
 		"subClashEnable":   func() (any, error) { return s.GetSubClashEnable() },
+		"subSingboxEnable": func() (any, error) { return s.GetSubSingboxEnable() },
 		"subTitle":         func() (any, error) { return s.GetSubTitle() },
 		"subURI":           func() (any, error) { return s.GetSubURI() },
 		"subJsonURI":       func() (any, error) { return s.GetSubJsonURI() },
 		"subClashURI":      func() (any, error) { return s.GetSubClashURI() },
+		"subSingboxURI":    func() (any, error) { return s.GetSubSingboxURI() },
 		"datepicker":       func() (any, error) { return s.GetDatepicker() },
 		"ipLimitEnable":    func() (any, error) { return s.GetIpLimitEnable() },
 		"accessLogEnable":  func() (any, error) { return s.GetAccessLogEnable() },
@@ -1470,12 +1661,19 @@ func (s *SettingService) GetDefaultSettings(host string) (any, error) {
 			subClashEnable = b
 		}
 	}
-	if (subEnable && result["subURI"].(string) == "") || (subJsonEnable && result["subJsonURI"].(string) == "") || (subClashEnable && result["subClashURI"].(string) == "") {
+	subSingboxEnable := false
+	if v, ok := result["subSingboxEnable"]; ok {
+		if b, ok2 := v.(bool); ok2 {
+			subSingboxEnable = b
+		}
+	}
+	if (subEnable && result["subURI"].(string) == "") || (subJsonEnable && result["subJsonURI"].(string) == "") || (subClashEnable && result["subClashURI"].(string) == "") || (subSingboxEnable && result["subSingboxURI"].(string) == "") {
 		subURI := s.BuildSubURIBase(host)
 		subTitle, _ := s.GetSubTitle()
 		subPath, _ := s.GetSubPath()
 		subJsonPath, _ := s.GetSubJsonPath()
 		subClashPath, _ := s.GetSubClashPath()
+		subSingboxPath, _ := s.GetSubSingboxPath()
 		if subEnable && result["subURI"].(string) == "" {
 			result["subURI"] = subURI + subPath
 		}
@@ -1488,16 +1686,18 @@ func (s *SettingService) GetDefaultSettings(host string) (any, error) {
 		if subClashEnable && result["subClashURI"].(string) == "" {
 			result["subClashURI"] = subURI + subClashPath
 		}
+		if subSingboxEnable && result["subSingboxURI"].(string) == "" {
+			result["subSingboxURI"] = subURI + subSingboxPath
+		}
 	}
 
 	return result, nil
 }
 
 var factoryDefaultSecretKeys = map[string]bool{
-	"tgBotToken":     true,
-	"twoFactorToken": true,
-	"ldapPassword":   true,
-	"smtpPassword":   true,
+	"tgBotToken":   true,
+	"ldapPassword": true,
+	"smtpPassword": true,
 }
 
 /*
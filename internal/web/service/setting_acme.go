@@ -0,0 +1,50 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/acme"
+)
+
+const settingAcmeAccountKey = "acmeAccountKey"
+
+var acmeAccountKeyMu sync.Mutex
+
+func (s *SettingService) GetAcmeEmail() (string, error) {
+	return s.getString("acmeEmail")
+}
+
+func (s *SettingService) GetAcmeDirectoryUrl() (string, error) {
+	return s.getString("acmeDirectoryUrl")
+}
+
+func (s *SettingService) GetAcmeHttpPort() (int, error) {
+	return s.getInt("acmeHttpPort")
+}
+
+func (s *SettingService) GetAcmeTlsAlpnPort() (int, error) {
+	return s.getInt("acmeTlsAlpnPort")
+}
+
+func (s *SettingService) GetAcmeRenewDays() (int, error) {
+	return s.getInt("acmeRenewDays")
+}
+
+// EnsureAcmeAccountKey returns the ACME account key PEM, minting it on the
+// first order so every later order reuses the same CA account.
+func (s *SettingService) EnsureAcmeAccountKey() (string, error) {
+	acmeAccountKeyMu.Lock()
+	defer acmeAccountKeyMu.Unlock()
+	keyPem, err := s.getString(settingAcmeAccountKey)
+	if err != nil || keyPem != "" {
+		return keyPem, err
+	}
+	keyPem, err = acme.NewAccountKey()
+	if err != nil {
+		return "", err
+	}
+	if err := s.saveSetting(settingAcmeAccountKey, keyPem); err != nil {
+		return "", err
+	}
+	return keyPem, nil
+}
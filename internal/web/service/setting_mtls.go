@@ -149,6 +149,10 @@ func saveMasterClientCredential(client crypto.CertKeyPEM, pin string) error {
 	}
 	return database.GetDB().Transaction(func(tx *gorm.DB) error {
 		for key, value := range values {
+			value, err := sealSetting(key, value)
+			if err != nil {
+				return err
+			}
 			result := tx.Model(&model.Setting{}).Where("key = ?", key).Update("value", value)
 			if result.Error != nil {
 				return result.Error
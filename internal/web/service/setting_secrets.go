@@ -0,0 +1,274 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/secrets"
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+// secretSettingKeys are sealed at rest with the node-token keyring whenever
+// NODE_TOKEN_ENCRYPTION is on. The rest of the settings table stays plaintext.
+var secretSettingKeys = map[string]bool{
+	"secret":               true, // session cookie signing key
+	"tgBotToken":           true,
+	"smtpPassword":         true,
+	"ldapPassword":         true,
+	"acmeAccountKey":       true,
+	"backupPassphrase":     true,
+	"backupS3SecretKey":    true,
+	"warp":                 true,
+	"nord":                 true,
+	"pia":                  true,
+	"nodeMtlsCaKeyPem":     true,
+	"nodeMtlsClientKeyPem": true,
+}
+
+func secretSettingKeyList() []string {
+	keys := make([]string, 0, len(secretSettingKeys))
+	for key := range secretSettingKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func sealSetting(key, value string) (string, error) {
+	if !secretSettingKeys[key] {
+		return value, nil
+	}
+	return secrets.Seal(secrets.SettingScope(key), value)
+}
+
+func openSetting(key, stored string) (string, error) {
+	if !secretSettingKeys[key] {
+		return stored, nil
+	}
+	return secrets.Open(secrets.SettingScope(key), stored)
+}
+
+// unreadableSecret reports an Open failure the migration steps over: the value
+// stays as stored and UnreadableSecrets lists it until it is entered again.
+func unreadableSecret(scope []byte, err error) bool {
+	if !errors.Is(err, secrets.ErrUnreadable) {
+		return false
+	}
+	logger.Warningf("secrets: skipping %s, it cannot be opened with this keyring; enter it again: %v", scope, err)
+	return true
+}
+
+// MigrateSecretsToActiveKey swaps values only if unchanged, like the node-token
+// migration, so a concurrent edit is left for the next run. Values no key opens
+// are skipped, so a database restored from another host still boots.
+func (s *SettingService) MigrateSecretsToActiveKey() (int, int, error) {
+	db := database.GetDB()
+	changed, skipped := 0, 0
+
+	var rows []model.Setting
+	if err := db.Where("key IN ?", secretSettingKeyList()).Order("id asc").Find(&rows).Error; err != nil {
+		return 0, 0, err
+	}
+	for _, row := range rows {
+		if !secrets.Stale(row.Value) {
+			skipped++
+			continue
+		}
+		scope := secrets.SettingScope(row.Key)
+		plain, err := secrets.Open(scope, row.Value)
+		if unreadableSecret(scope, err) {
+			skipped++
+			continue
+		} else if err != nil {
+			return changed, skipped, fmt.Errorf("setting %s: %w", row.Key, err)
+		}
+		enc, err := secrets.Seal(scope, plain)
+		if err != nil {
+			return changed, skipped, fmt.Errorf("setting %s: %w", row.Key, err)
+		}
+		res := db.Model(&model.Setting{}).Where("id = ? AND value = ?", row.Id, row.Value).Update("value", enc)
+		if res.Error != nil {
+			return changed, skipped, res.Error
+		}
+		if res.RowsAffected == 1 {
+			changed++
+		} else {
+			skipped++
+		}
+	}
+
+	var users []model.User
+	if err := db.Where("two_factor_token <> ''").Order("id asc").Find(&users).Error; err != nil {
+		return changed, skipped, err
+	}
+	for _, u := range users {
+		if !secrets.Stale(u.TwoFactorToken) {
+			skipped++
+			continue
+		}
+		scope := secrets.UserTwoFactorScope(u.Id)
+		plain, err := secrets.Open(scope, u.TwoFactorToken)
+		if unreadableSecret(scope, err) {
+			skipped++
+			continue
+		} else if err != nil {
+			return changed, skipped, fmt.Errorf("user %d 2fa token: %w", u.Id, err)
+		}
+		enc, err := secrets.Seal(scope, plain)
+		if err != nil {
+			return changed, skipped, fmt.Errorf("user %d 2fa token: %w", u.Id, err)
+		}
+		res := db.Model(&model.User{}).Where("id = ? AND two_factor_token = ?", u.Id, u.TwoFactorToken).
+			Update("two_factor_token", enc)
+		if res.Error != nil {
+			return changed, skipped, res.Error
+		}
+		if res.RowsAffected == 1 {
+			changed++
+		} else {
+			skipped++
+		}
+	}
+
+	for _, col := range sealedColumns {
+		c, sk, err := resealColumn(db, col)
+		changed, skipped = changed+c, skipped+sk
+		if err != nil {
+			return changed, skipped, err
+		}
+	}
+	return changed, skipped, nil
+}
+
+// sealedColumn is a secret column outside the settings table, sealed under a
+// scope bound to its row id.
+type sealedColumn struct {
+	table, column string
+	scope         func(id int) []byte
+}
+
+var sealedColumns = []sealedColumn{
+	{"acme_certificates", "dns_config", func(id int) []byte { return secrets.AcmeScope("dns_config", id) }},
+	{"acme_certificates", "key_pem", func(id int) []byte { return secrets.AcmeScope("key_pem", id) }},
+	{"webhooks", "secret", secrets.WebhookSecretScope},
+}
+
+func resealColumn(db *gorm.DB, col sealedColumn) (int, int, error) {
+	changed, skipped := 0, 0
+	var rows []struct {
+		Id    int
+		Value string
+	}
+	if err := db.Table(col.table).Select("id, " + col.column + " AS value").Where(col.column + " <> ''").
+		Order("id asc").Scan(&rows).Error; err != nil {
+		return 0, 0, err
+	}
+	for _, row := range rows {
+		if !secrets.Stale(row.Value) {
+			skipped++
+			continue
+		}
+		scope := col.scope(row.Id)
+		plain, err := secrets.Open(scope, row.Value)
+		if unreadableSecret(scope, err) {
+			skipped++
+			continue
+		} else if err != nil {
+			return changed, skipped, fmt.Errorf("%s %d %s: %w", col.table, row.Id, col.column, err)
+		}
+		enc, err := secrets.Seal(scope, plain)
+		if err != nil {
+			return changed, skipped, fmt.Errorf("%s %d %s: %w", col.table, row.Id, col.column, err)
+		}
+		res := db.Table(col.table).Where("id = ? AND "+col.column+" = ?", row.Id, row.Value).Update(col.column, enc)
+		if res.Error != nil {
+			return changed, skipped, res.Error
+		}
+		if res.RowsAffected == 1 {
+			changed++
+		} else {
+			skipped++
+		}
+	}
+	return changed, skipped, nil
+}
+
+// CountStaleSecrets includes node tokens and the sealed columns; rotate-key
+// -prune refuses to drop old keys while it is non-zero.
+func CountStaleSecrets() (int, error) {
+	db := database.GetDB()
+	var settings, seeds, tokens []string
+	if err := db.Model(&model.Setting{}).Where("key IN ?", secretSettingKeyList()).Pluck("value", &settings).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Model(&model.User{}).Pluck("two_factor_token", &seeds).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Model(&model.Node{}).Pluck("api_token", &tokens).Error; err != nil {
+		return 0, err
+	}
+	all := [][]string{settings, seeds, tokens}
+	for _, col := range sealedColumns {
+		var values []string
+		if err := db.Table(col.table).Pluck(col.column, &values).Error; err != nil {
+			return 0, err
+		}
+		all = append(all, values)
+	}
+	stale := 0
+	for _, values := range all {
+		for _, v := range values {
+			if secrets.Stale(v) {
+				stale++
+			}
+		}
+	}
+	return stale, nil
+}
+
+// UnreadableSecrets lists, by scope, the sealed values no key in the keyring
+// opens, so the settings page can ask for them again.
+func UnreadableSecrets() ([]string, error) {
+	db := database.GetDB()
+	var out []string
+	check := func(scope []byte, stored string) {
+		if stored == "" {
+			return
+		}
+		if _, err := secrets.Open(scope, stored); errors.Is(err, secrets.ErrUnreadable) {
+			out = append(out, string(scope))
+		}
+	}
+
+	var rows []model.Setting
+	if err := db.Where("key IN ?", secretSettingKeyList()).Order("id asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		check(secrets.SettingScope(row.Key), row.Value)
+	}
+	var users []model.User
+	if err := db.Where("two_factor_token <> ''").Order("id asc").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		check(secrets.UserTwoFactorScope(u.Id), u.TwoFactorToken)
+	}
+	for _, col := range sealedColumns {
+		var values []struct {
+			Id    int
+			Value string
+		}
+		if err := db.Table(col.table).Select("id, " + col.column + " AS value").Where(col.column + " <> ''").
+			Order("id asc").Scan(&values).Error; err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			check(col.scope(v.Id), v.Value)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/nodetoken"
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/secrets"
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+)
+
+func rawSetting(t *testing.T, key string) string {
+	t.Helper()
+	var row model.Setting
+	if err := database.GetDB().Where("key = ?", key).First(&row).Error; err != nil {
+		t.Fatalf("load setting %s: %v", key, err)
+	}
+	return row.Value
+}
+
+func rawColumn(t *testing.T, table, column string, id int) string {
+	t.Helper()
+	var value string
+	if err := database.GetDB().Table(table).Where("id = ?", id).Pluck(column, &value).Error; err != nil {
+		t.Fatalf("load %s.%s: %v", table, column, err)
+	}
+	return value
+}
+
+func TestSecretSettingsSealedAtRest(t *testing.T) {
+	setupBulkDB(t)
+	svc := &SettingService{}
+	db := database.GetDB()
+
+	// Written while encryption was still off.
+	if err := svc.saveSetting("smtpPassword", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.saveSetting("smtpHost", "mail.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{Username: "owner", Password: "x", TwoFactorEnable: true, TwoFactorToken: "JBSWY3DPEHPK3PXP"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	cert := &model.AcmeCertificate{Domains: []string{"a.example.com"}, DnsConfig: `{"token":"cf"}`, KeyPem: "PRIVATE KEY"}
+	if err := db.Create(cert).Error; err != nil {
+		t.Fatal(err)
+	}
+	hook := &model.Webhook{Name: "ops", Url: "https://hooks.example.com", Secret: "hmac-key"}
+	if err := db.Create(hook).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	enableNodeTokenEncryption(t)
+	if n, err := CountStaleSecrets(); err != nil || n != 5 {
+		t.Fatalf("stale before migration = %d, %v; want 5", n, err)
+	}
+	changed, _, err := svc.MigrateSecretsToActiveKey()
+	if err != nil || changed != 5 {
+		t.Fatalf("MigrateSecretsToActiveKey = %d, %v; want 5", changed, err)
+	}
+	if raw := rawSetting(t, "smtpPassword"); !nodetoken.IsEncrypted(raw) {
+		t.Fatalf("smtpPassword still plaintext at rest: %q", raw)
+	}
+	if raw := rawSetting(t, "smtpHost"); raw != "mail.example.com" {
+		t.Fatalf("non-secret setting was touched: %q", raw)
+	}
+	if got, err := svc.GetSmtpPassword(); err != nil || got != "hunter2" {
+		t.Fatalf("GetSmtpPassword = %q, %v", got, err)
+	}
+	var stored model.User
+	db.First(&stored, user.Id)
+	if !nodetoken.IsEncrypted(stored.TwoFactorToken) {
+		t.Fatalf("2FA seed still plaintext at rest")
+	}
+	if pt, err := secrets.Open(secrets.UserTwoFactorScope(user.Id), stored.TwoFactorToken); err != nil || pt != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("2FA seed round trip: %q %v", pt, err)
+	}
+	for _, c := range []struct {
+		table, column string
+		id            int
+		scope         []byte
+		want          string
+	}{
+		{"acme_certificates", "dns_config", cert.Id, secrets.AcmeScope("dns_config", cert.Id), `{"token":"cf"}`},
+		{"acme_certificates", "key_pem", cert.Id, secrets.AcmeScope("key_pem", cert.Id), "PRIVATE KEY"},
+		{"webhooks", "secret", hook.Id, secrets.WebhookSecretScope(hook.Id), "hmac-key"},
+	} {
+		raw := rawColumn(t, c.table, c.column, c.id)
+		if !nodetoken.IsEncrypted(raw) {
+			t.Fatalf("%s.%s still plaintext at rest", c.table, c.column)
+		}
+		if pt, err := secrets.Open(c.scope, raw); err != nil || pt != c.want {
+			t.Fatalf("%s.%s round trip: %q %v", c.table, c.column, pt, err)
+		}
+	}
+
+	// New rows are sealed on write, never stored in the clear first.
+	added, err := (&WebhookService{}).Add(&entity.WebhookRequest{Name: "new", Url: "https://hooks.example.com", Secret: "fresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := rawColumn(t, "webhooks", "secret", added.Id); !nodetoken.IsEncrypted(raw) {
+		t.Fatalf("new webhook secret saved as plaintext: %q", raw)
+	}
+	issued := &model.AcmeCertificate{Domains: []string{"b.example.com"}, DnsConfig: `{"token":"cf2"}`}
+	if err := createAcmeCertificate(db, issued); err != nil {
+		t.Fatal(err)
+	}
+	if raw := rawColumn(t, "acme_certificates", "dns_config", issued.Id); !nodetoken.IsEncrypted(raw) {
+		t.Fatalf("new ACME DNS config saved as plaintext: %q", raw)
+	}
+
+	all, err := svc.GetAllSetting()
+	if err != nil || all.SmtpPassword != "hunter2" {
+		t.Fatalf("GetAllSetting smtpPassword = %q, %v", all.SmtpPassword, err)
+	}
+	before := rawSetting(t, "smtpPassword")
+	all.TgBotToken = "123:abc"
+	if err := svc.UpdateAllSetting(all, SecretClears{}); err != nil {
+		t.Fatalf("UpdateAllSetting: %v", err)
+	}
+	if rawSetting(t, "smtpPassword") != before {
+		t.Fatal("unchanged secret was re-encrypted on save")
+	}
+	if raw := rawSetting(t, "tgBotToken"); !nodetoken.IsEncrypted(raw) {
+		t.Fatalf("tgBotToken saved as plaintext: %q", raw)
+	}
+	if n, _ := CountStaleSecrets(); n != 0 {
+		t.Fatalf("stale after migration = %d", n)
+	}
+}
+
+func TestSecretsReencryptedAfterRotation(t *testing.T) {
+	setupBulkDB(t)
+	svc := &SettingService{}
+	enableNodeTokenEncryption(t)
+	if err := svc.saveSetting("ldapPassword", "bind-pw"); err != nil {
+		t.Fatal(err)
+	}
+	oldRaw := rawSetting(t, "ldapPassword")
+
+	// The key enableNodeTokenEncryption installed, now rotated out of the
+	// active slot.
+	var k [32]byte
+	for i := range k {
+		k[i] = byte(i + 1)
+	}
+	ring, err := (&nodetoken.Keyring{ActiveID: "t1", Keys: map[string][32]byte{"t1": k}}).Rotated(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	codec, err := nodetoken.NewCodec(nodetoken.ModeRequired, ring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodetoken.Init(codec)
+
+	if n, _ := CountStaleSecrets(); n != 1 {
+		t.Fatalf("stale after rotation = %d, want 1", n)
+	}
+	if got, err := svc.GetLdapPassword(); err != nil || got != "bind-pw" {
+		t.Fatalf("old-key value unreadable after rotation: %q %v", got, err)
+	}
+	if changed, _, err := svc.MigrateSecretsToActiveKey(); err != nil || changed != 1 {
+		t.Fatalf("MigrateSecretsToActiveKey = %d, %v", changed, err)
+	}
+	if raw := rawSetting(t, "ldapPassword"); raw == oldRaw || !codec.EncryptedWithActive(raw) {
+		t.Fatalf("value not re-encrypted with the new key: %q", raw)
+	}
+	if got, _ := svc.GetLdapPassword(); got != "bind-pw" {
+		t.Fatalf("GetLdapPassword after rotation = %q", got)
+	}
+}
+
+func TestForeignKeySecretsSkippedForReentry(t *testing.T) {
+	setupBulkDB(t)
+	svc := &SettingService{}
+
+	// A backup restored from a host whose key this panel never had.
+	var k [32]byte
+	for i := range k {
+		k[i] = byte(200 - i)
+	}
+	foreign, err := nodetoken.NewCodec(nodetoken.ModeRequired, &nodetoken.Keyring{ActiveID: "f1", Keys: map[string][32]byte{"f1": k}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodetoken.Init(foreign)
+	if err := svc.saveSetting("smtpPassword", "foreign-pw"); err != nil {
+		t.Fatal(err)
+	}
+	foreignRaw := rawSetting(t, "smtpPassword")
+
+	enableNodeTokenEncryption(t)
+	if err := database.GetDB().Create(&model.Setting{Key: "ldapPassword", Value: "bind-pw"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	changed, _, err := svc.MigrateSecretsToActiveKey()
+	if err != nil || changed != 1 {
+		t.Fatalf("MigrateSecretsToActiveKey = %d, %v; want the readable value sealed and no error", changed, err)
+	}
+	if raw := rawSetting(t, "smtpPassword"); raw != foreignRaw {
+		t.Fatalf("unreadable value rewritten: %q", raw)
+	}
+	view, err := svc.GetAllSettingView()
+	if err != nil {
+		t.Fatalf("settings page fails on an unreadable secret: %v", err)
+	}
+	if len(view.UnreadableSecrets) != 1 || view.UnreadableSecrets[0] != "settings/smtpPassword" {
+		t.Fatalf("UnreadableSecrets = %v, want settings/smtpPassword", view.UnreadableSecrets)
+	}
+
+	all := view.AllSetting
+	if err := svc.UpdateAllSetting(&all, SecretClears{}); err != nil {
+		t.Fatalf("save without re-entering: %v", err)
+	}
+	if raw := rawSetting(t, "smtpPassword"); raw != foreignRaw {
+		t.Fatal("a blank field replaced the unreadable value")
+	}
+	all.SmtpPassword = "new-pw"
+	if err := svc.UpdateAllSetting(&all, SecretClears{}); err != nil {
+		t.Fatalf("re-entering the value: %v", err)
+	}
+	if got, err := svc.GetSmtpPassword(); err != nil || got != "new-pw" {
+		t.Fatalf("GetSmtpPassword after re-entry = %q, %v", got, err)
+	}
+	if left, err := UnreadableSecrets(); err != nil || len(left) != 0 {
+		t.Fatalf("UnreadableSecrets after re-entry = %v, %v", left, err)
+	}
+}
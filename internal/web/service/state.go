@@ -0,0 +1,401 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	yaml "github.com/goccy/go-yaml"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+)
+
+// StateVersion is the only state document version this panel reads.
+const StateVersion = 1
+
+// StateDocument sections that are present are managed in full; absent ones
+// are left alone, and settings only ever apply the listed keys. No secrets.
+type StateDocument struct {
+	Version               int                         `json:"version"`
+	Settings              map[string]any              `json:"settings,omitempty"`
+	XrayTemplate          json.RawMessage             `json:"xrayTemplate,omitempty"`
+	OutboundSubscriptions []StateOutboundSubscription `json:"outboundSubscriptions"`
+	Nodes                 []StateNode                 `json:"nodes"`
+	ClientGroups          []string                    `json:"clientGroups"`
+	Inbounds              []StateInbound              `json:"inbounds"`
+	Hosts                 []StateHost                 `json:"hosts"`
+	Clients               []StateClient               `json:"clients"`
+}
+
+// StateOutboundSubscription is keyed by URL.
+type StateOutboundSubscription struct {
+	Url            string `json:"url"`
+	Remark         string `json:"remark,omitempty"`
+	Enabled        bool   `json:"enabled"`
+	TagPrefix      string `json:"tagPrefix,omitempty"`
+	UpdateInterval int    `json:"updateInterval,omitempty"`
+	AllowPrivate   bool   `json:"allowPrivate,omitempty"`
+	AllowInsecure  bool   `json:"allowInsecure,omitempty"`
+	Prepend        bool   `json:"prepend,omitempty"`
+}
+
+// StateNode is keyed by name. ApiToken is never exported; apply reads it only
+// to create a node, so an existing node keeps the token it has.
+type StateNode struct {
+	Name                string   `json:"name"`
+	Remark              string   `json:"remark,omitempty"`
+	Scheme              string   `json:"scheme"`
+	Address             string   `json:"address"`
+	Port                int      `json:"port"`
+	BasePath            string   `json:"basePath"`
+	Enable              bool     `json:"enable"`
+	AllowPrivateAddress bool     `json:"allowPrivateAddress,omitempty"`
+	TlsVerifyMode       string   `json:"tlsVerifyMode"`
+	PinnedCertSha256    string   `json:"pinnedCertSha256,omitempty"`
+	InboundSyncMode     string   `json:"inboundSyncMode"`
+	InboundTags         []string `json:"inboundTags,omitempty"`
+	OutboundTag         string   `json:"outboundTag,omitempty"`
+	TrafficCoefficient  *float64 `json:"trafficCoefficient,omitempty"`
+	ApiToken            string   `json:"apiToken,omitempty"`
+}
+
+// StateInbound is keyed by tag; clients are attached by the clients section.
+// StandbyNode is where it fails over; standby copies are left out.
+type StateInbound struct {
+	Tag                string          `json:"tag"`
+	Remark             string          `json:"remark,omitempty"`
+	Enable             bool            `json:"enable"`
+	Node               string          `json:"node,omitempty"`
+	Protocol           model.Protocol  `json:"protocol"`
+	Listen             string          `json:"listen,omitempty"`
+	Port               int             `json:"port"`
+	Settings           json.RawMessage `json:"settings,omitempty"`
+	StreamSettings     json.RawMessage `json:"streamSettings,omitempty"`
+	Sniffing           json.RawMessage `json:"sniffing,omitempty"`
+	Total              int64           `json:"total,omitempty"`
+	ExpiryTime         int64           `json:"expiryTime,omitempty"`
+	TrafficReset       string          `json:"trafficReset,omitempty"`
+	TrafficResetDay    int             `json:"trafficResetDay,omitempty"`
+	SubSortIndex       int             `json:"subSortIndex,omitempty"`
+	ShareAddrStrategy  string          `json:"shareAddrStrategy,omitempty"`
+	ShareAddr          string          `json:"shareAddr,omitempty"`
+	DisableFlow        bool            `json:"disableFlow,omitempty"`
+	TrafficCoefficient *float64        `json:"trafficCoefficient,omitempty"`
+	StandbyNode        string          `json:"standbyNode,omitempty"`
+	Fallbacks          []StateFallback `json:"fallbacks,omitempty"`
+}
+
+// StateFallback is one fallback rule of a master inbound; Child is the tag of
+// the inbound it falls back to, empty when Dest is used.
+type StateFallback struct {
+	Child string `json:"child,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Alpn  string `json:"alpn,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Dest  string `json:"dest,omitempty"`
+	Xver  int    `json:"xver,omitempty"`
+}
+
+// StateHost is a host group keyed by its groupId, attached to inbounds by tag.
+type StateHost struct {
+	entity.HostGroup
+	// InboundIds shadows the embedded ids, which are panel-local; it is
+	// always empty here so only Inbounds is written.
+	InboundIds []int    `json:"inboundIds,omitempty"`
+	Inbounds   []string `json:"inbounds"`
+}
+
+// StateClient is keyed by email and attached to inbounds by tag.
+type StateClient struct {
+	model.Client
+	LimitHwid int      `json:"limitHwid,omitempty"`
+	Inbounds  []string `json:"inbounds"`
+}
+
+// stateExcludedSetting reports whether key is a setting a state document never
+// carries: a secret or a setting bound to this machine.
+func stateExcludedSetting(key string) bool {
+	return key == "xrayTemplateConfig" || secretSettingKeys[key] || slices.Contains(hostBoundSettingKeys, key)
+}
+
+// StateService exports the panel as a StateDocument and applies one back.
+type StateService struct {
+	inboundService  InboundService
+	clientService   ClientService
+	settingService  SettingService
+	xraySetting     XraySettingService
+	nodeService     NodeService
+	hostService     HostService
+	fallbackService FallbackService
+	subService      OutboundSubscriptionService
+}
+
+// Export describes the current panel.
+func (s *StateService) Export() (*StateDocument, error) {
+	db := database.GetDB()
+	doc := &StateDocument{Version: StateVersion}
+
+	all, err := s.settingService.GetAllSetting()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := settingsMap(all)
+	if err != nil {
+		return nil, err
+	}
+	doc.Settings = settings
+
+	template, err := s.settingService.GetXrayConfigTemplate()
+	if err != nil {
+		return nil, err
+	}
+	if doc.XrayTemplate, err = compactJSON(template); err != nil {
+		return nil, common.NewError("xray template is not valid JSON:", err)
+	}
+
+	var subs []*model.OutboundSubscription
+	if err := db.Order("priority asc, id asc").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	doc.OutboundSubscriptions = make([]StateOutboundSubscription, 0, len(subs))
+	for _, sub := range subs {
+		doc.OutboundSubscriptions = append(doc.OutboundSubscriptions, StateOutboundSubscription{
+			Url:            sub.Url,
+			Remark:         sub.Remark,
+			Enabled:        sub.Enabled,
+			TagPrefix:      sub.TagPrefix,
+			UpdateInterval: sub.UpdateInterval,
+			AllowPrivate:   sub.AllowPrivate,
+			AllowInsecure:  sub.AllowInsecure,
+			Prepend:        sub.Prepend,
+		})
+	}
+
+	var nodes []*model.Node
+	if err := db.Order("id asc").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	nodeNames := make(map[int]string, len(nodes))
+	doc.Nodes = make([]StateNode, 0, len(nodes))
+	for _, n := range nodes {
+		nodeNames[n.Id] = n.Name
+		doc.Nodes = append(doc.Nodes, stateNodeOf(n))
+	}
+
+	groups, err := s.clientService.ListGroups()
+	if err != nil {
+		return nil, err
+	}
+	doc.ClientGroups = make([]string, 0, len(groups))
+	for _, g := range groups {
+		doc.ClientGroups = append(doc.ClientGroups, g.Name)
+	}
+
+	var inbounds []*model.Inbound
+	if err := db.Where("failover_of IS NULL").Order("id asc").Find(&inbounds).Error; err != nil {
+		return nil, err
+	}
+	tags := make(map[int]string, len(inbounds))
+	for _, ib := range inbounds {
+		tags[ib.Id] = ib.Tag
+	}
+	doc.Inbounds = make([]StateInbound, 0, len(inbounds))
+	for _, ib := range inbounds {
+		si, err := stateInboundOf(ib, nodeNames)
+		if err != nil {
+			return nil, fmt.Errorf("inbound %s: %w", ib.Tag, err)
+		}
+		fallbacks, err := s.fallbackService.GetByMaster(ib.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fallbacks {
+			si.Fallbacks = append(si.Fallbacks, StateFallback{
+				Child: tags[f.ChildId], Name: f.Name, Alpn: f.Alpn, Path: f.Path, Dest: f.Dest, Xver: f.Xver,
+			})
+		}
+		doc.Inbounds = append(doc.Inbounds, si)
+	}
+
+	hostGroups, err := s.hostService.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(hostGroups, func(i, j int) bool { return hostGroups[i].GroupId < hostGroups[j].GroupId })
+	doc.Hosts = make([]StateHost, 0, len(hostGroups))
+	for _, g := range hostGroups {
+		doc.Hosts = append(doc.Hosts, stateHostOf(g, tags))
+	}
+
+	clients, err := s.clientService.ExportAll(0)
+	if err != nil {
+		return nil, err
+	}
+	doc.Clients = make([]StateClient, 0, len(clients))
+	for _, c := range clients {
+		doc.Clients = append(doc.Clients, stateClientOf(c, tags))
+	}
+	return doc, nil
+}
+
+func stateNodeOf(n *model.Node) StateNode {
+	return StateNode{
+		Name:                n.Name,
+		Remark:              n.Remark,
+		Scheme:              n.Scheme,
+		Address:             n.Address,
+		Port:                n.Port,
+		BasePath:            n.BasePath,
+		Enable:              n.Enable,
+		AllowPrivateAddress: n.AllowPrivateAddress,
+		TlsVerifyMode:       n.TlsVerifyMode,
+		PinnedCertSha256:    n.PinnedCertSha256,
+		InboundSyncMode:     n.InboundSyncMode,
+		InboundTags:         n.InboundTags,
+		OutboundTag:         n.OutboundTag,
+		TrafficCoefficient:  n.TrafficCoefficient,
+	}
+}
+
+func stateInboundOf(ib *model.Inbound, nodeNames map[int]string) (StateInbound, error) {
+	si := StateInbound{
+		Tag:                ib.Tag,
+		Remark:             ib.Remark,
+		Enable:             ib.Enable,
+		Protocol:           ib.Protocol,
+		Listen:             ib.Listen,
+		Port:               ib.Port,
+		Total:              ib.Total,
+		ExpiryTime:         ib.ExpiryTime,
+		TrafficReset:       ib.TrafficReset,
+		TrafficResetDay:    ib.TrafficResetDay,
+		SubSortIndex:       ib.SubSortIndex,
+		ShareAddrStrategy:  ib.ShareAddrStrategy,
+		ShareAddr:          ib.ShareAddr,
+		DisableFlow:        ib.DisableFlow,
+		TrafficCoefficient: ib.TrafficCoefficient,
+	}
+	if ib.NodeID != nil {
+		si.Node = nodeNames[*ib.NodeID]
+	}
+	if ib.StandbyNodeID != nil {
+		si.StandbyNode = nodeNames[*ib.StandbyNodeID]
+	}
+	var err error
+	if si.Settings, err = settingsWithoutClients(ib.Settings); err != nil {
+		return si, err
+	}
+	if si.StreamSettings, err = compactJSON(ib.StreamSettings); err != nil {
+		return si, err
+	}
+	if si.Sniffing, err = compactJSON(ib.Sniffing); err != nil {
+		return si, err
+	}
+	return si, nil
+}
+
+func stateHostOf(g *entity.HostGroup, tags map[int]string) StateHost {
+	h := StateHost{HostGroup: *g, Inbounds: make([]string, 0, len(g.InboundIds))}
+	for _, id := range g.InboundIds {
+		h.Inbounds = append(h.Inbounds, tags[id])
+	}
+	h.HostGroup.InboundIds = nil
+	return h
+}
+
+func stateClientOf(c ClientCreatePayload, tags map[int]string) StateClient {
+	sc := StateClient{Client: c.Client, LimitHwid: c.LimitHwid, Inbounds: make([]string, 0, len(c.InboundIds))}
+	sc.CreatedAt, sc.UpdatedAt = 0, 0
+	for _, id := range c.InboundIds {
+		// An id with no tag is a standby copy.
+		if tag, ok := tags[id]; ok {
+			sc.Inbounds = append(sc.Inbounds, tag)
+		}
+	}
+	return sc
+}
+
+// settingsWithoutClients returns inbound settings with an empty clients list
+// in place of the attached clients.
+func settingsWithoutClients(raw string) (json.RawMessage, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		return nil, err
+	}
+	if _, ok := m["clients"]; ok {
+		m["clients"] = json.RawMessage("[]")
+	}
+	return json.Marshal(m)
+}
+
+func compactJSON(raw string) (json.RawMessage, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(raw)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// settingsMap renders settings as their JSON-typed values, minus the ones a
+// state document never carries.
+func settingsMap(all *entity.AllSetting) (map[string]any, error) {
+	b, err := json.Marshal(all)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for key := range m {
+		if stateExcludedSetting(key) {
+			delete(m, key)
+		}
+	}
+	return m, nil
+}
+
+// MarshalStateDocument renders doc as YAML, or as indented JSON when asJSON.
+func MarshalStateDocument(doc *StateDocument, asJSON bool) ([]byte, error) {
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil || asJSON {
+		return b, err
+	}
+	return yaml.JSONToYAML(b)
+}
+
+// ParseStateDocument reads a YAML or JSON state document.
+func ParseStateDocument(data []byte) (*StateDocument, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, common.NewError("state document is empty")
+	}
+	if trimmed[0] != '{' {
+		converted, err := yaml.YAMLToJSON(trimmed)
+		if err != nil {
+			return nil, common.NewError("state document is not valid YAML:", err)
+		}
+		trimmed = converted
+	}
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.DisallowUnknownFields()
+	doc := &StateDocument{}
+	if err := dec.Decode(doc); err != nil {
+		return nil, common.NewError("state document is invalid:", err)
+	}
+	if doc.Version != StateVersion {
+		return nil, common.NewErrorf("unsupported state document version %d (want %d)", doc.Version, StateVersion)
+	}
+	return doc, nil
+}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+)
+
+// StateApplyResult reports an apply. Applied counts the changes that went
+// through; when apply stops on an error it is the number done before it.
+type StateApplyResult struct {
+	Changes     []StateChange `json:"changes"`
+	Applied     int           `json:"applied" example:"3"`
+	NeedRestart bool          `json:"needRestart" example:"true"`
+}
+
+// Apply refuses a plan with errors and stops at the first failing change;
+// the changes before it stay applied.
+func (s *StateService) Apply(doc *StateDocument) (*StateApplyResult, error) {
+	plan, err := s.Plan(doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Errors) > 0 {
+		return nil, common.NewError("state document is invalid:", strings.Join(plan.Errors, "; "))
+	}
+	res := &StateApplyResult{Changes: plan.Changes}
+	clientsCreated := false
+	for _, ch := range plan.Changes {
+		var nr bool
+		var err error
+		switch {
+		case ch.Kind == StateKindClient && ch.Action == StateActionCreate:
+			// New clients go in as one import at the first create.
+			if !clientsCreated {
+				clientsCreated = true
+				nr, err = s.createClients(doc, plan.Changes)
+			}
+		default:
+			nr, err = s.applyChange(doc, ch)
+		}
+		if err != nil {
+			return res, fmt.Errorf("%s %s %s: %w", ch.Action, ch.Kind, ch.Key, err)
+		}
+		res.NeedRestart = res.NeedRestart || nr
+		res.Applied++
+	}
+	return res, nil
+}
+
+func (s *StateService) applyChange(doc *StateDocument, ch StateChange) (bool, error) {
+	switch ch.Kind {
+	case StateKindSettings:
+		return false, s.applySettings(doc.Settings)
+	case StateKindXrayTemplate:
+		return true, s.xraySetting.SaveXraySetting(string(doc.XrayTemplate))
+	case StateKindOutboundSubscription:
+		return true, s.applyOutboundSubscription(doc, ch)
+	case StateKindNode:
+		return false, s.applyNode(doc, ch)
+	case StateKindClientGroup:
+		if ch.Action == StateActionDelete {
+			_, err := s.clientService.DeleteGroup(ch.Key)
+			return false, err
+		}
+		return false, s.clientService.CreateGroup(ch.Key)
+	case StateKindInbound:
+		return s.applyInbound(doc, ch)
+	case StateKindFallbacks:
+		return true, s.applyFallbacks(doc, ch.Key)
+	case StateKindHost:
+		return false, s.applyHost(doc, ch)
+	case StateKindClient:
+		return s.applyClient(doc, ch)
+	}
+	return false, common.NewError("unknown change kind:", ch.Kind)
+}
+
+func (s *StateService) applySettings(overlay map[string]any) error {
+	all, err := s.settingService.GetAllSetting()
+	if err != nil {
+		return err
+	}
+	merged, err := toJSONMap(all)
+	if err != nil {
+		return err
+	}
+	for k, v := range overlay {
+		merged[k] = v
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	next := &entity.AllSetting{}
+	if err := json.Unmarshal(b, next); err != nil {
+		return err
+	}
+	return s.settingService.UpdateAllSetting(next, SecretClears{})
+}
+
+func (s *StateService) applyOutboundSubscription(doc *StateDocument, ch StateChange) error {
+	existing := &model.OutboundSubscription{}
+	found := database.GetDB().Where("url = ?", ch.Key).Order("id asc").Limit(1).Find(existing)
+	if found.Error != nil {
+		return found.Error
+	}
+	if ch.Action == StateActionDelete {
+		return s.subService.Delete(existing.Id)
+	}
+	i := slices.IndexFunc(doc.OutboundSubscriptions, func(o StateOutboundSubscription) bool { return o.Url == ch.Key })
+	o := doc.OutboundSubscriptions[i]
+	if ch.Action == StateActionCreate {
+		_, err := s.subService.Create(o.Remark, o.Url, o.TagPrefix, o.Enabled, o.UpdateInterval, o.AllowPrivate, o.Prepend, o.AllowInsecure)
+		return err
+	}
+	return s.subService.Update(existing.Id, o.Remark, o.Url, o.TagPrefix, o.Enabled, o.UpdateInterval, o.AllowPrivate, o.Prepend, o.AllowInsecure)
+}
+
+func (s *StateService) applyNode(doc *StateDocument, ch StateChange) error {
+	id, err := s.nodeIdByName(ch.Key)
+	if err != nil && ch.Action != StateActionCreate {
+		return err
+	}
+	if ch.Action == StateActionDelete {
+		return s.nodeService.Delete(id)
+	}
+	i := slices.IndexFunc(doc.Nodes, func(n StateNode) bool { return n.Name == ch.Key })
+	sn := doc.Nodes[i]
+	n := &model.Node{
+		Name:                sn.Name,
+		Remark:              sn.Remark,
+		Scheme:              sn.Scheme,
+		Address:             sn.Address,
+		Port:                sn.Port,
+		BasePath:            sn.BasePath,
+		Enable:              sn.Enable,
+		AllowPrivateAddress: sn.AllowPrivateAddress,
+		TlsVerifyMode:       sn.TlsVerifyMode,
+		PinnedCertSha256:    sn.PinnedCertSha256,
+		InboundSyncMode:     sn.InboundSyncMode,
+		InboundTags:         sn.InboundTags,
+		OutboundTag:         sn.OutboundTag,
+		TrafficCoefficient:  sn.TrafficCoefficient,
+	}
+	if ch.Action == StateActionCreate {
+		n.ApiToken = sn.ApiToken
+		return s.nodeService.Create(n)
+	}
+	return s.nodeService.Update(id, n)
+}
+
+func (s *StateService) applyInbound(doc *StateDocument, ch StateChange) (bool, error) {
+	id, err := s.inboundIdByTag(ch.Key)
+	if err != nil && ch.Action != StateActionCreate {
+		return false, err
+	}
+	if ch.Action == StateActionDelete {
+		return s.inboundService.DelInbound(id)
+	}
+	i := slices.IndexFunc(doc.Inbounds, func(ib StateInbound) bool { return ib.Tag == ch.Key })
+	si := doc.Inbounds[i]
+	ib := &model.Inbound{
+		Tag:                si.Tag,
+		Remark:             si.Remark,
+		Enable:             si.Enable,
+		Protocol:           si.Protocol,
+		Listen:             si.Listen,
+		Port:               si.Port,
+		Settings:           string(si.Settings),
+		StreamSettings:     string(si.StreamSettings),
+		Sniffing:           string(si.Sniffing),
+		Total:              si.Total,
+		ExpiryTime:         si.ExpiryTime,
+		TrafficReset:       si.TrafficReset,
+		TrafficResetDay:    si.TrafficResetDay,
+		SubSortIndex:       si.SubSortIndex,
+		ShareAddrStrategy:  si.ShareAddrStrategy,
+		ShareAddr:          si.ShareAddr,
+		DisableFlow:        si.DisableFlow,
+		TrafficCoefficient: si.TrafficCoefficient,
+	}
+	if si.StandbyNode != "" {
+		standbyId, err := s.nodeIdByName(si.StandbyNode)
+		if err != nil {
+			return false, err
+		}
+		ib.StandbyNodeID = &standbyId
+	}
+
+	if ch.Action == StateActionCreate {
+		if si.Node != "" {
+			nodeId, err := s.nodeIdByName(si.Node)
+			if err != nil {
+				return false, err
+			}
+			ib.NodeID = &nodeId
+		}
+		if ib.UserId, err = primaryUserIdOrDefault(); err != nil {
+			return false, err
+		}
+		// The clients section attaches clients once the inbound exists.
+		if ib.Settings, err = withClients(ib.Settings, nil); err != nil {
+			return false, err
+		}
+		_, nr, err := s.inboundService.AddInbound(ib)
+		return nr, err
+	}
+
+	old, err := s.inboundService.GetInbound(id)
+	if err != nil {
+		return false, err
+	}
+	// Settings in a state document carry no clients; keep the attached ones.
+	var oldSettings map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(old.Settings), &oldSettings); err != nil {
+		return false, err
+	}
+	if ib.Settings, err = withClients(ib.Settings, oldSettings["clients"]); err != nil {
+		return false, err
+	}
+	ib.Id = id
+	// An auto-generated tag is renamed when its port or transport changes;
+	// the document names the tag, so keep it.
+	_, nr, err := s.inboundService.updateInbound(ib, true)
+	return nr, err
+}
+
+func (s *StateService) applyFallbacks(doc *StateDocument, tag string) error {
+	masterId, err := s.inboundIdByTag(tag)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(doc.Inbounds, func(ib StateInbound) bool { return ib.Tag == tag })
+	items := make([]FallbackInput, 0, len(doc.Inbounds[i].Fallbacks))
+	for order, f := range doc.Inbounds[i].Fallbacks {
+		in := FallbackInput{Name: f.Name, Alpn: f.Alpn, Path: f.Path, Dest: f.Dest, Xver: f.Xver, SortOrder: order}
+		if f.Child != "" {
+			if in.ChildId, err = s.inboundIdByTag(f.Child); err != nil {
+				return err
+			}
+		}
+		items = append(items, in)
+	}
+	return s.fallbackService.SetByMaster(masterId, items)
+}
+
+func (s *StateService) applyHost(doc *StateDocument, ch StateChange) error {
+	if ch.Action == StateActionDelete {
+		return s.hostService.DeleteHostGroup(ch.Key)
+	}
+	i := slices.IndexFunc(doc.Hosts, func(h StateHost) bool { return h.GroupId == ch.Key })
+	group := doc.Hosts[i].HostGroup
+	ids, err := s.inboundIdsByTag(doc.Hosts[i].Inbounds)
+	if err != nil {
+		return err
+	}
+	group.InboundIds = ids
+	if ch.Action == StateActionCreate {
+		_, err = s.hostService.AddHostGroup(&group)
+		return err
+	}
+	_, err = s.hostService.UpdateHostGroup(ch.Key, &group)
+	return err
+}
+
+// createClients imports every client the plan creates in one batch.
+func (s *StateService) createClients(doc *StateDocument, changes []StateChange) (bool, error) {
+	byEmail := make(map[string]StateClient, len(doc.Clients))
+	for _, c := range doc.Clients {
+		byEmail[c.Email] = c
+	}
+	var items []ClientCreatePayload
+	var disabled []string
+	for _, ch := range changes {
+		if ch.Kind != StateKindClient || ch.Action != StateActionCreate {
+			continue
+		}
+		c := byEmail[ch.Key]
+		ids, err := s.inboundIdsByTag(c.Inbounds)
+		if err != nil {
+			return false, err
+		}
+		items = append(items, ClientCreatePayload{Client: c.Client, InboundIds: ids, LimitHwid: c.LimitHwid})
+		if !c.Enable {
+			disabled = append(disabled, c.Email)
+		}
+	}
+	result, nr, err := s.clientService.ImportClients(&s.inboundService, items)
+	if err != nil {
+		return nr, err
+	}
+	if len(result.Skipped) > 0 {
+		return nr, common.NewErrorf("client %s: %s", result.Skipped[0].Email, result.Skipped[0].Reason)
+	}
+	// Creating a client enables it.
+	if len(disabled) > 0 {
+		_, dnr, err := s.clientService.BulkSetEnable(&s.inboundService, disabled, false)
+		return nr || dnr, err
+	}
+	return nr, nil
+}
+
+func (s *StateService) applyClient(doc *StateDocument, ch StateChange) (bool, error) {
+	rec, err := s.clientService.GetRecordByEmail(nil, ch.Key)
+	if err != nil {
+		return false, err
+	}
+	if ch.Action == StateActionDelete {
+		return s.clientService.Delete(&s.inboundService, rec.Id, false)
+	}
+	i := slices.IndexFunc(doc.Clients, func(c StateClient) bool { return c.Email == ch.Key })
+	sc := doc.Clients[i]
+	want, err := s.inboundIdsByTag(sc.Inbounds)
+	if err != nil {
+		return false, err
+	}
+	have, err := s.clientService.GetInboundIdsForRecord(rec.Id)
+	if err != nil {
+		return false, err
+	}
+	// Attachments to standby copies follow the primary, not the document.
+	copies, err := (&FailoverService{}).StandbyCopyIds()
+	if err != nil {
+		return false, err
+	}
+	have = slices.DeleteFunc(have, func(id int) bool {
+		_, isCopy := copies[id]
+		return isCopy
+	})
+	var attach, detach []int
+	for _, id := range want {
+		if !slices.Contains(have, id) {
+			attach = append(attach, id)
+		}
+	}
+	for _, id := range have {
+		if !slices.Contains(want, id) {
+			detach = append(detach, id)
+		}
+	}
+
+	needRestart := false
+	if len(detach) > 0 {
+		nr, err := s.clientService.Detach(&s.inboundService, rec.Id, detach)
+		if err != nil {
+			return needRestart, err
+		}
+		needRestart = needRestart || nr
+	}
+	nr, err := s.clientService.Update(&s.inboundService, nil, rec.Id, sc.Client, sc.LimitHwid)
+	if err != nil {
+		return needRestart, err
+	}
+	needRestart = needRestart || nr
+	if len(attach) > 0 {
+		nr, err := s.clientService.Attach(&s.inboundService, rec.Id, attach)
+		if err != nil {
+			return needRestart, err
+		}
+		needRestart = needRestart || nr
+	}
+	return needRestart, nil
+}
+
+func (s *StateService) nodeIdByName(name string) (int, error) {
+	n := &model.Node{}
+	if err := database.GetDB().Select("id").Where("name = ?", name).First(n).Error; err != nil {
+		return 0, fmt.Errorf("node %s: %w", name, err)
+	}
+	return n.Id, nil
+}
+
+func (s *StateService) inboundIdByTag(tag string) (int, error) {
+	ib := &model.Inbound{}
+	if err := database.GetDB().Select("id").Where("tag = ?", tag).First(ib).Error; err != nil {
+		return 0, fmt.Errorf("inbound %s: %w", tag, err)
+	}
+	return ib.Id, nil
+}
+
+func (s *StateService) inboundIdsByTag(tags []string) ([]int, error) {
+	ids := make([]int, 0, len(tags))
+	for _, tag := range tags {
+		id, err := s.inboundIdByTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// primaryUserIdOrDefault returns the first user's id, which owns inbounds the
+// panel creates on its own.
+func primaryUserIdOrDefault() (int, error) {
+	u := &model.User{}
+	res := database.GetDB().Model(model.User{}).Order("id asc").Limit(1).Find(u)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 1, nil
+	}
+	return u.Id, nil
+}
+
+// withClients sets the clients list of inbound settings, for protocols that
+// have one.
+func withClients(settings string, clients json.RawMessage) (string, error) {
+	if settings == "" {
+		return settings, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(settings), &m); err != nil {
+		return "", err
+	}
+	if _, ok := m["clients"]; !ok {
+		return settings, nil
+	}
+	if len(clients) == 0 {
+		clients = json.RawMessage("[]")
+	}
+	m["clients"] = clients
+	b, err := json.Marshal(m)
+	return string(b), err
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+)
+
+// State change kinds in create/update order; deletes run in reverse, so
+// nothing is removed while another object still points at it.
+const (
+	StateKindSettings             = "settings"
+	StateKindXrayTemplate         = "xrayTemplate"
+	StateKindOutboundSubscription = "outboundSubscription"
+	StateKindNode                 = "node"
+	StateKindClientGroup          = "clientGroup"
+	StateKindInbound              = "inbound"
+	StateKindFallbacks            = "fallbacks"
+	StateKindHost                 = "host"
+	StateKindClient               = "client"
+)
+
+const (
+	StateActionCreate = "create"
+	StateActionUpdate = "update"
+	StateActionDelete = "delete"
+)
+
+// StateChange is one step of an apply. Fields lists the top-level fields an
+// update changes.
+type StateChange struct {
+	Kind   string   `json:"kind" example:"inbound"`
+	Key    string   `json:"key" example:"in-443-tcp"`
+	Action string   `json:"action" example:"update"`
+	Fields []string `json:"fields,omitempty" example:"[\"port\",\"streamSettings\"]"`
+}
+
+// StatePlan is what applying a state document would change, in execution
+// order. A plan with Errors cannot be applied.
+type StatePlan struct {
+	Changes []StateChange `json:"changes"`
+	Errors  []string      `json:"errors,omitempty"`
+}
+
+func (p *StatePlan) errorf(format string, args ...any) {
+	p.Errors = append(p.Errors, fmt.Sprintf(format, args...))
+}
+
+// Plan compares doc with the panel and returns the changes applying it makes.
+func (s *StateService) Plan(doc *StateDocument) (*StatePlan, error) {
+	cur, err := s.Export()
+	if err != nil {
+		return nil, err
+	}
+	return planState(cur, doc)
+}
+
+func planState(cur, doc *StateDocument) (*StatePlan, error) {
+	plan := &StatePlan{Changes: []StateChange{}}
+	var deletes []StateChange
+	add := func(upserts, dels []StateChange, err error) error {
+		if err != nil {
+			return err
+		}
+		plan.Changes = append(plan.Changes, upserts...)
+		deletes = append(dels, deletes...)
+		return nil
+	}
+
+	if err := planSettings(plan, cur.Settings, doc.Settings); err != nil {
+		return nil, err
+	}
+	if doc.XrayTemplate != nil {
+		if !json.Valid(doc.XrayTemplate) {
+			plan.errorf("xrayTemplate: not valid JSON")
+		} else if !jsonEqual(cur.XrayTemplate, doc.XrayTemplate) {
+			plan.Changes = append(plan.Changes, StateChange{Kind: StateKindXrayTemplate, Key: StateKindXrayTemplate, Action: StateActionUpdate})
+		}
+	}
+
+	if doc.OutboundSubscriptions != nil {
+		if err := add(planSection(plan, StateKindOutboundSubscription, cur.OutboundSubscriptions, doc.OutboundSubscriptions,
+			func(o StateOutboundSubscription) string { return o.Url })); err != nil {
+			return nil, err
+		}
+	}
+
+	nodes := namesOf(cur.Nodes, func(n StateNode) string { return n.Name })
+	if doc.Nodes != nil {
+		nodes = namesOf(doc.Nodes, func(n StateNode) string { return n.Name })
+		existing := namesOf(cur.Nodes, func(n StateNode) string { return n.Name })
+		for _, n := range doc.Nodes {
+			if !existing[n.Name] && n.ApiToken == "" && n.TlsVerifyMode != "mtls" {
+				plan.errorf("node %s: apiToken is required to create a node", n.Name)
+			}
+		}
+		if err := add(planSection(plan, StateKindNode, cur.Nodes, doc.Nodes,
+			func(n StateNode) string { return n.Name }, "apiToken")); err != nil {
+			return nil, err
+		}
+	}
+
+	if doc.ClientGroups != nil {
+		wrap := func(names []string) []struct{ Name string } {
+			out := make([]struct{ Name string }, 0, len(names))
+			for _, n := range names {
+				out = append(out, struct{ Name string }{strings.TrimSpace(n)})
+			}
+			return out
+		}
+		if err := add(planSection(plan, StateKindClientGroup, wrap(cur.ClientGroups), wrap(doc.ClientGroups),
+			func(g struct{ Name string }) string { return g.Name })); err != nil {
+			return nil, err
+		}
+	}
+
+	tags := namesOf(cur.Inbounds, func(i StateInbound) string { return i.Tag })
+	if doc.Inbounds != nil {
+		tags = namesOf(doc.Inbounds, func(i StateInbound) string { return i.Tag })
+		curByTag := make(map[string]StateInbound, len(cur.Inbounds))
+		for _, ib := range cur.Inbounds {
+			curByTag[ib.Tag] = ib
+		}
+		for _, ib := range doc.Inbounds {
+			if ib.Node != "" && !nodes[ib.Node] {
+				plan.errorf("inbound %s: unknown node %q", ib.Tag, ib.Node)
+			}
+			if ib.StandbyNode != "" && !nodes[ib.StandbyNode] {
+				plan.errorf("inbound %s: unknown standby node %q", ib.Tag, ib.StandbyNode)
+			}
+			if old, ok := curByTag[ib.Tag]; ok && old.Node != ib.Node {
+				plan.errorf("inbound %s: an inbound cannot move to another node; give it a new tag to recreate it", ib.Tag)
+			}
+			for _, f := range ib.Fallbacks {
+				if f.Child != "" && !tags[f.Child] {
+					plan.errorf("inbound %s: fallback to unknown inbound %q", ib.Tag, f.Child)
+				}
+			}
+		}
+		upserts, dels, err := planSection(plan, StateKindInbound, cur.Inbounds, doc.Inbounds,
+			func(i StateInbound) string { return i.Tag }, "fallbacks")
+		if err := add(upserts, dels, err); err != nil {
+			return nil, err
+		}
+		// Fallbacks point at other inbounds, so they are set once every
+		// inbound exists.
+		for _, ib := range doc.Inbounds {
+			old, ok := curByTag[ib.Tag]
+			if (!ok && len(ib.Fallbacks) == 0) || (ok && reflect.DeepEqual(old.Fallbacks, ib.Fallbacks)) {
+				continue
+			}
+			plan.Changes = append(plan.Changes, StateChange{Kind: StateKindFallbacks, Key: ib.Tag, Action: StateActionUpdate})
+		}
+	}
+
+	if doc.Hosts != nil {
+		for _, h := range doc.Hosts {
+			for _, tag := range h.Inbounds {
+				if !tags[tag] {
+					plan.errorf("host %s: unknown inbound %q", h.GroupId, tag)
+				}
+			}
+		}
+		if err := add(planSection(plan, StateKindHost, cur.Hosts, doc.Hosts,
+			func(h StateHost) string { return h.GroupId })); err != nil {
+			return nil, err
+		}
+	}
+
+	if doc.Clients != nil {
+		var groups map[string]bool
+		if doc.ClientGroups != nil {
+			groups = namesOf(doc.ClientGroups, strings.TrimSpace)
+		}
+		for _, c := range doc.Clients {
+			if groups != nil && c.Group != "" && !groups[c.Group] {
+				plan.errorf("client %s: group %q is not listed in clientGroups", c.Email, c.Group)
+			}
+			for _, tag := range c.Inbounds {
+				if !tags[tag] {
+					plan.errorf("client %s: unknown inbound %q", c.Email, tag)
+				}
+			}
+		}
+		if err := add(planSection(plan, StateKindClient, cur.Clients, doc.Clients,
+			func(c StateClient) string { return c.Email }, "created_at", "updated_at")); err != nil {
+			return nil, err
+		}
+	}
+
+	plan.Changes = append(plan.Changes, deletes...)
+	return plan, nil
+}
+
+// planSection diffs one keyed section. It returns the creates and updates in
+// document order and the deletes.
+func planSection[T any](plan *StatePlan, kind string, cur, next []T, key func(T) string, ignore ...string) ([]StateChange, []StateChange, error) {
+	curByKey := make(map[string]T, len(cur))
+	for _, item := range cur {
+		curByKey[key(item)] = item
+	}
+	seen := make(map[string]bool, len(next))
+	var upserts []StateChange
+	for _, item := range next {
+		k := key(item)
+		if k == "" {
+			plan.errorf("%s: an entry has no key", kind)
+			continue
+		}
+		if seen[k] {
+			plan.errorf("%s %s: listed more than once", kind, k)
+			continue
+		}
+		seen[k] = true
+		old, ok := curByKey[k]
+		if !ok {
+			upserts = append(upserts, StateChange{Kind: kind, Key: k, Action: StateActionCreate})
+			continue
+		}
+		fields, err := diffFields(old, item, ignore...)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(fields) > 0 {
+			upserts = append(upserts, StateChange{Kind: kind, Key: k, Action: StateActionUpdate, Fields: fields})
+		}
+	}
+	var deletes []StateChange
+	for _, item := range cur {
+		if k := key(item); !seen[k] {
+			deletes = append(deletes, StateChange{Kind: kind, Key: k, Action: StateActionDelete})
+		}
+	}
+	return upserts, deletes, nil
+}
+
+// planSettings decodes the merged set as settings, catching unknown keys and
+// mistyped values before anything is written.
+func planSettings(plan *StatePlan, cur, next map[string]any) error {
+	if len(next) == 0 {
+		return nil
+	}
+	merged := make(map[string]any, len(cur))
+	for k, v := range cur {
+		merged[k] = v
+	}
+	keys := make([]string, 0, len(next))
+	for k := range next {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if stateExcludedSetting(k) {
+			plan.errorf("setting %s: not managed by state documents", k)
+			continue
+		}
+		if _, ok := cur[k]; !ok {
+			plan.errorf("setting %s: unknown setting", k)
+			continue
+		}
+		merged[k] = next[k]
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	var decoded entity.AllSetting
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		plan.errorf("settings: %v", err)
+		return nil
+	}
+	normalized, err := settingsMap(&decoded)
+	if err != nil {
+		return err
+	}
+	var changed []string
+	for _, k := range keys {
+		if v, ok := normalized[k]; ok && !reflect.DeepEqual(cur[k], v) {
+			changed = append(changed, k)
+		}
+	}
+	if len(changed) > 0 {
+		plan.Changes = append(plan.Changes, StateChange{Kind: StateKindSettings, Key: StateKindSettings, Action: StateActionUpdate, Fields: changed})
+	}
+	return nil
+}
+
+// diffFields lists the top-level JSON fields that differ between a and b.
+func diffFields(a, b any, ignore ...string) ([]string, error) {
+	am, err := toJSONMap(a)
+	if err != nil {
+		return nil, err
+	}
+	bm, err := toJSONMap(b)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for k, av := range am {
+		if !slices.Contains(ignore, k) && !reflect.DeepEqual(av, bm[k]) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range bm {
+		if _, ok := am[k]; !ok && !slices.Contains(ignore, k) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+func toJSONMap(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	return m, json.Unmarshal(b, &m)
+}
+
+func namesOf[T any](items []T, key func(T) string) map[string]bool {
+	out := make(map[string]bool, len(items))
+	for _, item := range items {
+		out[key(item)] = true
+	}
+	return out
+}
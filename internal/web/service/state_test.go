@@ -0,0 +1,226 @@
+package service
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+)
+
+func stateInbound(tag string, port int) StateInbound {
+	return StateInbound{
+		Tag:            tag,
+		Enable:         true,
+		Protocol:       model.VLESS,
+		Port:           port,
+		Settings:       json.RawMessage(`{"clients":[],"decryption":"none"}`),
+		StreamSettings: json.RawMessage(`{"network":"tcp","security":"none"}`),
+	}
+}
+
+func stateClient(email, id string, tags ...string) StateClient {
+	return StateClient{
+		Client:   model.Client{Email: email, ID: id, SubID: "sub-" + email, Enable: true},
+		Inbounds: tags,
+	}
+}
+
+func mustApply(t *testing.T, svc *StateService, doc *StateDocument) *StateApplyResult {
+	t.Helper()
+	res, err := svc.Apply(doc)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	return res
+}
+
+// mustRoundTrip exports the panel and reads the document back, as a user
+// editing the exported file would.
+func mustRoundTrip(t *testing.T, svc *StateService) *StateDocument {
+	t.Helper()
+	cur, err := svc.Export()
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	data, err := MarshalStateDocument(cur, false)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	doc, err := ParseStateDocument(data)
+	if err != nil {
+		t.Fatalf("Parse: %v\n%s", err, data)
+	}
+	return doc
+}
+
+func changeKeys(changes []StateChange) []string {
+	out := make([]string, 0, len(changes))
+	for _, ch := range changes {
+		out = append(out, ch.Action+" "+ch.Kind+" "+ch.Key)
+	}
+	return out
+}
+
+func TestStateApplyThenExportPlansNothing(t *testing.T) {
+	setupBulkDB(t)
+	svc := &StateService{}
+
+	doc := &StateDocument{
+		Version:      StateVersion,
+		ClientGroups: []string{"team"},
+		Inbounds:     []StateInbound{stateInbound("in-a", 21001), stateInbound("in-b", 21002)},
+		Clients: []StateClient{
+			stateClient("alice", "11111111-1111-1111-1111-111111111111", "in-a", "in-b"),
+			stateClient("bob", "22222222-2222-2222-2222-222222222222", "in-a"),
+		},
+	}
+	doc.Inbounds[0].Fallbacks = []StateFallback{{Child: "in-b", Path: "/ws"}}
+	doc.Clients[1].Group = "team"
+	doc.Clients[1].Enable = false
+	res := mustApply(t, svc, doc)
+	if res.Applied != len(res.Changes) {
+		t.Fatalf("applied %d of %d", res.Applied, len(res.Changes))
+	}
+
+	again := mustRoundTrip(t, svc)
+	plan, err := svc.Plan(again)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(plan.Changes) != 0 || len(plan.Errors) != 0 {
+		t.Fatalf("re-applying the export plans %v, errors %v", changeKeys(plan.Changes), plan.Errors)
+	}
+	i := slices.IndexFunc(again.Clients, func(c StateClient) bool { return c.Email == "alice" })
+	if i < 0 || !slices.Equal(again.Clients[i].Inbounds, []string{"in-a", "in-b"}) {
+		t.Fatalf("alice not exported on both inbounds: %+v", again.Clients)
+	}
+	if got := again.Inbounds[0].Fallbacks; len(got) != 1 || got[0].Child != "in-b" {
+		t.Fatalf("fallbacks = %+v", got)
+	}
+}
+
+func TestStateApplyUpdatesAndDeletes(t *testing.T) {
+	setupBulkDB(t)
+	svc := &StateService{}
+	mustApply(t, svc, &StateDocument{
+		Version:  StateVersion,
+		Inbounds: []StateInbound{stateInbound("in-a", 21011), stateInbound("in-b", 21012)},
+		Clients: []StateClient{
+			stateClient("alice", "11111111-1111-1111-1111-111111111111", "in-a"),
+			stateClient("bob", "22222222-2222-2222-2222-222222222222", "in-b"),
+		},
+	})
+
+	doc := mustRoundTrip(t, svc)
+	doc.Inbounds = doc.Inbounds[:1] // drop in-b
+	doc.Inbounds[0].Remark = "edge"
+	doc.Inbounds[0].Port = 21013
+	doc.Clients = []StateClient{stateClient("alice", "11111111-1111-1111-1111-111111111111", "in-a")}
+	doc.Clients[0].TotalGB = 1 << 30
+
+	plan, err := svc.Plan(doc)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	want := []string{"update inbound in-a", "update client alice", "delete client bob", "delete inbound in-b"}
+	if got := changeKeys(plan.Changes); !slices.Equal(got, want) {
+		t.Fatalf("plan = %v, want %v", got, want)
+	}
+	mustApply(t, svc, doc)
+
+	after := mustRoundTrip(t, svc)
+	if len(after.Inbounds) != 1 || after.Inbounds[0].Tag != "in-a" || after.Inbounds[0].Port != 21013 || after.Inbounds[0].Remark != "edge" {
+		t.Fatalf("inbounds after apply = %+v", after.Inbounds)
+	}
+	if len(after.Clients) != 1 || after.Clients[0].TotalGB != 1<<30 {
+		t.Fatalf("clients after apply = %+v", after.Clients)
+	}
+	ib, err := svc.inboundService.GetInbound(mustInboundId(t, svc, "in-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ib.Settings, "alice") {
+		t.Fatalf("updating the inbound dropped its clients: %s", ib.Settings)
+	}
+}
+
+func mustInboundId(t *testing.T, svc *StateService, tag string) int {
+	t.Helper()
+	id, err := svc.inboundIdByTag(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestStatePlanRejectsInvalidDocument(t *testing.T) {
+	setupBulkDB(t)
+	svc := &StateService{}
+	doc := &StateDocument{
+		Version:  StateVersion,
+		Settings: map[string]any{"webPort": "not a number", "tgBotToken": "x"},
+		Inbounds: []StateInbound{stateInbound("in-a", 21021), stateInbound("in-a", 21022)},
+		Clients:  []StateClient{stateClient("alice", "11111111-1111-1111-1111-111111111111", "in-missing")},
+		Nodes:    []StateNode{{Name: "n1", Address: "n1.example.com", Port: 2053}},
+	}
+	plan, err := svc.Plan(doc)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	for _, want := range []string{"tgBotToken", "webPort", "in-a: listed more than once", "in-missing", "apiToken is required"} {
+		if !slices.ContainsFunc(plan.Errors, func(e string) bool { return strings.Contains(e, want) }) {
+			t.Errorf("no plan error mentions %q: %v", want, plan.Errors)
+		}
+	}
+	if _, err := svc.Apply(doc); err == nil {
+		t.Fatal("Apply accepted an invalid document")
+	}
+	if n, _ := svc.Export(); len(n.Inbounds) != 0 {
+		t.Fatalf("invalid document changed the panel: %+v", n.Inbounds)
+	}
+}
+
+func TestParseStateDocument(t *testing.T) {
+	doc, err := ParseStateDocument([]byte("version: 1\nclientGroups: [a, b]\n"))
+	if err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	if !slices.Equal(doc.ClientGroups, []string{"a", "b"}) || doc.Inbounds != nil {
+		t.Fatalf("doc = %+v", doc)
+	}
+	if _, err := ParseStateDocument([]byte(`{"version":1,"inbounds":[]}`)); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	for _, bad := range []string{"", "version: 2\n", "version: 1\nbogus: true\n"} {
+		if _, err := ParseStateDocument([]byte(bad)); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
+
+// A document that keeps an auto-generated tag across a port change keeps it,
+// though the edit form would rename it.
+func TestStateApplyKeepsAutoGeneratedTag(t *testing.T) {
+	setupBulkDB(t)
+	svc := &StateService{}
+	tag := composeInboundTag(21021, nil, inboundTransports(model.VLESS, `{"network":"tcp","security":"none"}`, ""))
+	mustApply(t, svc, &StateDocument{Version: StateVersion, Inbounds: []StateInbound{stateInbound(tag, 21021)}})
+
+	doc := mustRoundTrip(t, svc)
+	doc.Inbounds[0].Port = 21022
+	mustApply(t, svc, doc)
+
+	ib, err := svc.inboundService.GetInbound(mustInboundId(t, svc, tag))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ib.Port != 21022 {
+		t.Fatalf("port = %d, want 21022", ib.Port)
+	}
+	plan, err := svc.Plan(mustRoundTrip(t, svc))
+	if err != nil || len(plan.Changes) != 0 {
+		t.Fatalf("re-applying the export plans %v (%v)", changeKeys(plan.Changes), err)
+	}
+}
@@ -253,7 +253,7 @@ func TestGroupAndListPostgresScale(t *testing.T) {
 			rmDur := time.Since(start)
 
 			start = time.Now()
-			list, err := svc.List()
+			list, err := svc.List(0)
 			if err != nil {
 				t.Fatalf("List: %v", err)
 			}
@@ -347,7 +347,7 @@ func TestBulkOpsPostgresScale(t *testing.T) {
 			}
 
 			t0 := time.Now()
-			if _, _, err := svc.BulkAdjust(inboundSvc, emailsM, 7, 1<<30, ""); err != nil {
+			if _, _, err := svc.BulkAdjust(inboundSvc, nil, emailsM, 7, 1<<30, ""); err != nil {
 				t.Fatalf("BulkAdjust: %v", err)
 			}
 			adjustDur := time.Since(t0)
@@ -81,6 +81,7 @@ var (
 	client_SubID         string
 	client_Comment       string
 	client_Reset         int
+	client_PlanID        int // its group, reset cycle and HWID limit apply on submit
 )
 
 // userStateStore guards the per-chat conversation states. The Telegram command
@@ -171,6 +172,7 @@ type Tgbot struct {
 	settingService service.SettingService
 	serverService  service.ServerService
 	xrayService    service.XrayService
+	voucherService service.VoucherService
 	lastStatus     *service.Status
 }
 
@@ -346,6 +348,7 @@ func (t *Tgbot) trySetBotCommands(bot *telego.Bot) {
 			{Command: "status", Description: t.I18nBot("tgbot.commands.statusDesc")},
 			{Command: "id", Description: t.I18nBot("tgbot.commands.idDesc")},
 			{Command: "usage", Description: t.I18nBot("tgbot.commands.usageDesc")},
+			{Command: "redeem", Description: t.I18nBot("tgbot.commands.redeemDesc")},
 			{Command: "inbound", Description: t.I18nBot("tgbot.commands.inboundDesc")},
 			{Command: "restart", Description: t.I18nBot("tgbot.commands.restartDesc")},
 			{Command: "clearall", Description: t.I18nBot("tgbot.commands.clearallDesc")},
@@ -75,6 +75,11 @@ func (t *Tgbot) BuildClientDraftMessage() string {
 	var b strings.Builder
 	b.WriteString("📝 *New client draft*\r\n")
 	fmt.Fprintf(&b, "📧 Email: `%s`\r\n", client_Email)
+	if client_PlanID > 0 {
+		if plan, err := t.clientService.GetPlan(client_PlanID); err == nil {
+			fmt.Fprintf(&b, "📦 Plan: %s\r\n", plan.Name)
+		}
+	}
 	fmt.Fprintf(&b, "🔗 Attached: %s\r\n", attached)
 	fmt.Fprintf(&b, "📊 Traffic: %s\r\n", traffic)
 	fmt.Fprintf(&b, "📅 Expire: %s\r\n", expiry)
@@ -84,6 +89,21 @@ func (t *Tgbot) BuildClientDraftMessage() string {
 	return b.String()
 }
 
+// applyPlanToDraft fills the draft's limits and inbounds from a plan. The
+// remaining plan fields are applied by SubmitAddClient.
+func (t *Tgbot) applyPlanToDraft(plan *model.Plan) {
+	var c model.Client
+	service.ApplyPlan(&c, plan, time.Now().UnixMilli())
+	client_PlanID = plan.Id
+	client_TotalGB = c.TotalGB
+	client_ExpiryTime = c.ExpiryTime
+	client_LimitIP = c.LimitIP
+	if len(plan.InboundIds) > 0 {
+		receiver_inbound_IDs = slices.Clone(plan.InboundIds)
+		receiver_inbound_ID = receiver_inbound_IDs[0]
+	}
+}
+
 // describeAttachedInbounds returns a short "remark1, remark2" list for the given
 // inbound ids, falling back to "#id" when an inbound can't be loaded.
 func (t *Tgbot) describeAttachedInbounds(ids []int) string {
@@ -132,9 +152,22 @@ func (t *Tgbot) SubmitAddClient() (bool, error) {
 		TgID:       tgIDInt,
 	}
 
+	limitHwid := 0
+	if client_PlanID > 0 {
+		plan, err := t.clientService.GetPlan(client_PlanID)
+		if err != nil {
+			return false, err
+		}
+		client.Group = plan.Group
+		client.TrafficReset = plan.TrafficReset
+		client.TrafficResetDay = plan.TrafficResetDay
+		limitHwid = plan.LimitHwid
+	}
+
 	return t.clientService.Create(&t.inboundService, &service.ClientCreatePayload{
 		Client:     client,
 		InboundIds: inboundIDs,
+		LimitHwid:  limitHwid,
 	})
 }
 
@@ -517,6 +550,11 @@ func (t *Tgbot) clientInfoMsg(
 	}
 	if printActive {
 		output += t.I18nBot("tgbot.messages.active", "Enable=="+active)
+		if paused, next, err := t.clientService.ClientAccess(traffic.Email); err != nil {
+			logger.Warning(err)
+		} else if paused && !next.IsZero() {
+			output += t.I18nBot("tgbot.messages.outsideSchedule", "Time=="+next.Format("2006-01-02 15:04"))
+		}
 	}
 	if printDate {
 		if flag {
@@ -580,6 +618,58 @@ func (t *Tgbot) getClientUsage(chatId int64, tgUserID int64, email ...string) {
 	t.SendAnswer(chatId, output, false)
 }
 
+// redeemVoucher tops up the subscription of email, one of the user's own
+// clients; without it, it creates a client linked to the user.
+func (t *Tgbot) redeemVoucher(chatId int64, tgUserID int64, code string, email ...string) {
+	subId := ""
+	if len(email) > 0 {
+		rec, err := t.clientService.GetRecordByEmail(nil, email[0])
+		if err != nil || rec.TgID != tgUserID {
+			t.SendMsgToTgbot(chatId, t.I18nBot("tgbot.noResult"))
+			return
+		}
+		subId = rec.SubID
+	}
+
+	res, err := t.voucherService.Redeem(&t.inboundService, service.VoucherRedemption{
+		Code:   code,
+		SubId:  subId,
+		Source: service.VoucherSourceTgbot,
+		Key:    "tg:" + strconv.FormatInt(tgUserID, 10),
+		TgID:   tgUserID,
+	})
+	switch {
+	case errors.Is(err, service.ErrVoucherRateLimited):
+		t.SendMsgToTgbot(chatId, t.I18nBot("tgbot.answers.voucherRateLimited"))
+		return
+	case errors.Is(err, service.ErrVoucherInvalid):
+		t.SendMsgToTgbot(chatId, t.I18nBot("tgbot.answers.voucherInvalid"))
+		return
+	case errors.Is(err, service.ErrVoucherNotApplicable):
+		t.SendMsgToTgbot(chatId, t.I18nBot("tgbot.answers.voucherNotApplicable"))
+		return
+	case err != nil:
+		logger.Warning("tgbot: redeem voucher:", err)
+		t.SendMsgToTgbot(chatId, t.I18nBot("tgbot.answers.errorOperation"))
+		return
+	}
+	if res.NeedRestart {
+		t.xrayService.SetToNeedRestart()
+	}
+
+	if !res.Created {
+		t.SendMsgToTgbot(chatId, t.I18nBot("tgbot.answers.voucherToppedUp", "Email=="+res.Email))
+		t.getClientUsage(chatId, tgUserID, res.Email)
+		return
+	}
+	subURL, _, err := t.buildSubscriptionURLs(res.Email)
+	if err != nil {
+		t.SendMsgToTgbot(chatId, t.I18nBot("tgbot.answers.errorOperation")+"\r\n"+err.Error())
+		return
+	}
+	t.SendMsgToTgbot(chatId, t.I18nBot("tgbot.answers.voucherCreated", "Email=="+res.Email, "SubURL=="+subURL))
+}
+
 // searchClientIps searches and sends client IP addresses for the given email.
 func (t *Tgbot) searchClientIps(chatId int64, email string, messageID ...int) {
 	ips, err := t.inboundService.GetInboundClientIps(email)
@@ -759,6 +849,7 @@ func (t *Tgbot) getCommonClientButtons() [][]telego.InlineKeyboardButton {
 			tu.InlineKeyboardButton(t.I18nBot("tgbot.buttons.setTGUser")).WithCallbackData("add_client_ch_default_tg_id"),
 		),
 		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(t.I18nBot("tgbot.buttons.choosePlan")).WithCallbackData("add_client_ch_plan"),
 			tu.InlineKeyboardButton(attachLabel).WithCallbackData("add_client_attach_more"),
 		),
 		tu.InlineKeyboardRow(
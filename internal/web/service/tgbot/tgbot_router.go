@@ -209,6 +209,13 @@ func (t *Tgbot) answerCommand(message *telego.Message, chatId int64, isAdmin boo
 		} else {
 			msg += t.I18nBot("tgbot.commands.usage")
 		}
+	case "redeem":
+		onlyMessage = true
+		if len(commandArgs) > 0 {
+			t.redeemVoucher(chatId, message.From.ID, commandArgs[0], commandArgs[1:]...)
+		} else {
+			msg += t.I18nBot("tgbot.commands.redeemUsage")
+		}
 	case "inbound":
 		onlyMessage = true
 		if isAdmin && len(commandArgs) > 0 {
@@ -847,6 +854,20 @@ func (t *Tgbot) answerCallback(callbackQuery *telego.CallbackQuery, isAdmin bool
 
 				t.addClient(callbackQuery.Message.GetChat().ID, message_text, messageId)
 				t.sendCallbackAnswerTgBot(callbackQuery.ID, t.I18nBot("tgbot.answers.successfulOperation"))
+			case "add_client_plan":
+				if len(dataArray) == 2 {
+					planId, _ := strconv.Atoi(dataArray[1])
+					plan, err := t.clientService.GetPlan(planId)
+					if err != nil {
+						t.sendCallbackAnswerTgBot(callbackQuery.ID, t.I18nBot("tgbot.answers.errorOperation"))
+						return
+					}
+					t.applyPlanToDraft(plan)
+				}
+
+				messageId := callbackQuery.Message.GetMessageID()
+				t.addClient(callbackQuery.Message.GetChat().ID, t.BuildClientDraftMessage(), messageId)
+				t.sendCallbackAnswerTgBot(callbackQuery.ID, t.I18nBot("tgbot.answers.planApplied"))
 			case "add_client_ip_limit_in":
 				if len(dataArray) >= 2 {
 					oldInputNumber, err := strconv.Atoi(dataArray[1])
@@ -1001,6 +1022,7 @@ func (t *Tgbot) answerCallback(callbackQuery *telego.CallbackQuery, isAdmin bool
 				client_SubID = t.randomLowerAndNum(16)
 				client_Comment = ""
 				client_Reset = 0
+				client_PlanID = 0
 
 				inboundId := dataArray[1]
 				inboundIdInt, err := strconv.Atoi(inboundId)
@@ -1190,6 +1212,7 @@ func (t *Tgbot) answerCallback(callbackQuery *telego.CallbackQuery, isAdmin bool
 		client_SubID = t.randomLowerAndNum(16)
 		client_Comment = ""
 		client_Reset = 0
+		client_PlanID = 0
 
 		inbounds, err := t.getInboundsAddClient()
 		if err != nil {
@@ -1339,6 +1362,22 @@ func (t *Tgbot) answerCallback(callbackQuery *telego.CallbackQuery, isAdmin bool
 		message_text := t.BuildClientDraftMessage()
 		t.addClient(chatId, message_text, messageId)
 		t.sendCallbackAnswerTgBot(callbackQuery.ID, t.I18nBot("tgbot.answers.canceled", "Email=="+client_Email))
+	case "add_client_ch_plan":
+		plans, err := t.clientService.ListPlans()
+		if err != nil || len(plans) == 0 {
+			t.sendCallbackAnswerTgBot(callbackQuery.ID, t.I18nBot("tgbot.answers.noPlans"))
+			return
+		}
+		rows := make([][]telego.InlineKeyboardButton, 0, len(plans)+1)
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(t.I18nBot("tgbot.buttons.cancel")).WithCallbackData(t.encodeQuery("add_client_default_traffic_exp")),
+		))
+		for _, plan := range plans {
+			rows = append(rows, tu.InlineKeyboardRow(
+				tu.InlineKeyboardButton(plan.Name).WithCallbackData(t.encodeQuery("add_client_plan "+strconv.Itoa(plan.Id))),
+			))
+		}
+		t.editMessageCallbackTgBot(chatId, callbackQuery.Message.GetMessageID(), tu.InlineKeyboard(rows...))
 	case "add_client_attach_more":
 		picker, err := t.getInboundsAttachPicker()
 		if err != nil {
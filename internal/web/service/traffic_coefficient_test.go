@@ -0,0 +1,150 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/xray"
+)
+
+func historyTotals(t *testing.T, db *gorm.DB, email string) (up, down int64) {
+	t.Helper()
+	var rows []model.ClientTrafficHistory
+	if err := db.Where("email = ?", email).Find(&rows).Error; err != nil {
+		t.Fatalf("read history: %v", err)
+	}
+	for _, r := range rows {
+		up += r.Up
+		down += r.Down
+	}
+	return up, down
+}
+
+func TestAddClientTrafficWeighsByInboundCoefficient(t *testing.T) {
+	db := initTrafficTestDB(t)
+	double, free := 2.0, 0.0
+	premium := &model.Inbound{UserId: 1, Tag: "premium", Enable: true, Port: 44001, Protocol: model.VLESS, TrafficCoefficient: &double}
+	cdn := &model.Inbound{UserId: 1, Tag: "cdn", Enable: true, Port: 44002, Protocol: model.VLESS, TrafficCoefficient: &free}
+	plain := &model.Inbound{UserId: 1, Tag: "plain", Enable: true, Port: 44003, Protocol: model.VLESS}
+	for _, ib := range []*model.Inbound{premium, cdn, plain} {
+		if err := db.Create(ib).Error; err != nil {
+			t.Fatalf("create inbound %s: %v", ib.Tag, err)
+		}
+	}
+	cs := &ClientService{}
+	link := func(ib *model.Inbound, emails ...string) {
+		clients := make([]model.Client, 0, len(emails))
+		for _, e := range emails {
+			clients = append(clients, model.Client{Email: e, Enable: true})
+		}
+		if err := cs.SyncInbound(db, ib.Id, clients); err != nil {
+			t.Fatalf("link %s: %v", ib.Tag, err)
+		}
+	}
+	link(premium, "both")
+	link(cdn, "both", "free")
+	link(plain, "plain")
+	for _, email := range []string{"both", "free", "plain"} {
+		if err := db.Create(&xray.ClientTraffic{InboundId: plain.Id, Email: email, Enable: true}).Error; err != nil {
+			t.Fatalf("seed %s: %v", email, err)
+		}
+	}
+
+	svc := &InboundService{}
+	if err := svc.addClientTraffic(db, []*xray.ClientTraffic{
+		{Email: "both", Up: 10, Down: 20},
+		{Email: "free", Up: 10, Down: 20},
+		{Email: "plain", Up: 10, Down: 20},
+	}); err != nil {
+		t.Fatalf("addClientTraffic: %v", err)
+	}
+
+	assertUpDown(t, readTraffic(t, db, "both"), 20, 40, "client on premium and cdn counts at the highest coefficient")
+	assertUpDown(t, readTraffic(t, db, "free"), 0, 0, "client on a free inbound")
+	assertUpDown(t, readTraffic(t, db, "plain"), 10, 20, "client on an unweighted inbound")
+	if up, down := historyTotals(t, db, "both"); up != 10 || down != 20 {
+		t.Errorf("history = %d/%d, want the raw 10/20", up, down)
+	}
+}
+
+func TestNodeTrafficWeighsByNodeAndInboundCoefficient(t *testing.T) {
+	db := initTrafficTestDB(t)
+	nodeCoefficient, inboundCoefficient := 1.5, 2.0
+	node := &model.Node{Name: "expensive", Address: "10.0.0.1", Port: 2053, TrafficCoefficient: &nodeCoefficient}
+	if err := db.Create(node).Error; err != nil {
+		t.Fatalf("create node: %v", err)
+	}
+	createNodeInbound(t, db, node.Id, "n-in", 44011)
+	if err := db.Model(&model.Inbound{}).Where("tag = ?", "n-in").
+		Update("traffic_coefficient", inboundCoefficient).Error; err != nil {
+		t.Fatalf("weigh inbound: %v", err)
+	}
+	svc := &InboundService{}
+
+	const email = "roamer"
+	syncNode(t, svc, node.Id, "n-in", xray.ClientTraffic{Email: email, Up: 100, Down: 100, Enable: true})
+	syncNode(t, svc, node.Id, "n-in", xray.ClientTraffic{Email: email, Up: 110, Down: 120, Enable: true})
+	assertUpDown(t, readTraffic(t, db, email), 30, 60, "delta weighed 1.5 x 2")
+
+	var baseline model.NodeClientTraffic
+	if err := db.Where("node_id = ? AND email = ?", node.Id, email).First(&baseline).Error; err != nil {
+		t.Fatalf("read baseline: %v", err)
+	}
+	if baseline.Up != 110 || baseline.Down != 120 {
+		t.Errorf("baseline = %d/%d, want the raw node counters 110/120", baseline.Up, baseline.Down)
+	}
+}
+
+func TestWeightedNodeQuotaStaysOnMaster(t *testing.T) {
+	db := initTrafficTestDB(t)
+	coefficient := 2.0
+	node := &model.Node{Name: "weighted", Address: "10.0.0.2", Port: 2053, TrafficCoefficient: &coefficient}
+	if err := db.Create(node).Error; err != nil {
+		t.Fatalf("create node: %v", err)
+	}
+	const email = "heavy"
+	createNodeInboundWithClient(t, db, node.Id, "w-in", 44021, email)
+	var ib model.Inbound
+	if err := db.Where("tag = ?", "w-in").First(&ib).Error; err != nil {
+		t.Fatalf("read inbound: %v", err)
+	}
+	if err := db.Model(&ib).Update("settings", `{"clients": [{"email": "heavy", "enable": true, "totalGB": 300}]}`).Error; err != nil {
+		t.Fatalf("set quota: %v", err)
+	}
+	if err := (&ClientService{}).SyncInbound(db, ib.Id, []model.Client{{Email: email, Enable: true, TotalGB: 300}}); err != nil {
+		t.Fatalf("link client: %v", err)
+	}
+	if err := db.Create(&xray.ClientTraffic{InboundId: ib.Id, Email: email, Enable: true, Total: 300}).Error; err != nil {
+		t.Fatalf("seed traffic: %v", err)
+	}
+	svc := &InboundService{}
+
+	// The node holds no quota for the client, or a raw one from an older push
+	// that it has already cut the client off at.
+	nodeSettings := `{"clients": [{"email": "heavy", "enable": true, "totalGB": 0}]}`
+	syncNodeWithSettings(t, svc, node.Id, "w-in", nodeSettings, xray.ClientTraffic{Email: email, Up: 100, Enable: true})
+	syncNodeWithSettings(t, svc, node.Id, "w-in", nodeSettings, xray.ClientTraffic{Email: email, Up: 200, Total: 150, Enable: false})
+
+	ct := readTraffic(t, db, email)
+	assertUpDown(t, ct, 200, 0, "delta weighed by the node coefficient")
+	if !ct.Enable || ct.Total != 300 {
+		t.Errorf("traffic enable=%v total=%d, want the master's enabled client with total 300", ct.Enable, ct.Total)
+	}
+	var rec model.ClientRecord
+	if err := db.Where("email = ?", email).First(&rec).Error; err != nil {
+		t.Fatalf("read client: %v", err)
+	}
+	if !rec.Enable || rec.TotalGB != 300 {
+		t.Errorf("client enable=%v totalGB=%d, want enabled with 300", rec.Enable, rec.TotalGB)
+	}
+	if err := db.First(&ib, ib.Id).Error; err != nil {
+		t.Fatalf("reread inbound: %v", err)
+	}
+	if !strings.Contains(ib.Settings, `"totalGB": 300`) {
+		t.Errorf("inbound settings = %s, want the master's totalGB kept", ib.Settings)
+	}
+
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/random"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+
+	"gorm.io/gorm"
+)
+
+// Redeem failures. They are deliberately coarse so a caller cannot tell an
+// unknown code from a used, expired or disabled one.
+var (
+	ErrVoucherInvalid       = errors.New("invalid or used voucher code")
+	ErrVoucherRateLimited   = errors.New("too many failed voucher attempts")
+	ErrVoucherNotApplicable = errors.New("voucher does not apply to this subscription")
+)
+
+// Where a redemption came from, recorded as the audit route.
+const (
+	VoucherSourceSub   = "sub"
+	VoucherSourceTgbot = "tgbot"
+)
+
+const (
+	voucherCodeGroups   = 3
+	voucherCodeGroupLen = 4
+	// No 0/O or 1/I, so codes survive being read out or retyped.
+	voucherCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+	redeemLimitMaxFailures = 5
+	redeemLimitWindow      = 10 * time.Minute
+	redeemLimitCooldown    = 30 * time.Minute
+	redeemLimitMaxRecords  = 10000
+)
+
+// VoucherRedemption's Key identifies the caller for rate limiting (client IP,
+// or the Telegram user); TgID links a created client to that Telegram user.
+type VoucherRedemption struct {
+	Code     string
+	SubId    string
+	Source   string
+	Key      string
+	SourceIP string
+	TgID     int64
+}
+
+// VoucherRedeemResult says what a redemption did.
+type VoucherRedeemResult struct {
+	Created     bool   `json:"created"`
+	Email       string `json:"email"`
+	SubId       string `json:"subId"`
+	NeedRestart bool   `json:"-"`
+}
+
+// VoucherService mints voucher codes and redeems them against plans.
+type VoucherService struct {
+	clientService ClientService
+}
+
+var defaultRedeemLimiter = newRedeemLimiter()
+
+func (s *VoucherService) List() ([]*model.Voucher, error) {
+	var vouchers []*model.Voucher
+	if err := database.GetDB().Order("id desc").Find(&vouchers).Error; err != nil {
+		return nil, err
+	}
+	return vouchers, nil
+}
+
+func (s *VoucherService) Get(id int) (*model.Voucher, error) {
+	v := &model.Voucher{}
+	if err := database.GetDB().First(v, id).Error; err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Generate mints req.Count fresh codes bound to a plan in one transaction.
+func (s *VoucherService) Generate(req *entity.VoucherGenerateRequest) ([]*model.Voucher, error) {
+	if _, err := s.clientService.GetPlan(req.PlanId); err != nil {
+		return nil, common.NewError("plan not found")
+	}
+	kind := req.Kind
+	if kind == "" {
+		kind = model.VoucherKindAny
+	}
+	prefix := strings.ToUpper(strings.TrimSpace(req.Prefix))
+	vouchers := make([]*model.Voucher, 0, req.Count)
+	for range req.Count {
+		vouchers = append(vouchers, &model.Voucher{
+			Code:      newVoucherCode(prefix),
+			PlanId:    req.PlanId,
+			Kind:      kind,
+			MaxUses:   req.MaxUses,
+			ExpiresAt: req.ExpiresAt,
+			Enable:    true,
+			Comment:   req.Comment,
+		})
+	}
+	if err := database.GetDB().Create(&vouchers).Error; err != nil {
+		return nil, err
+	}
+	return vouchers, nil
+}
+
+func (s *VoucherService) Update(id int, req *entity.VoucherUpdateRequest) (*model.Voucher, error) {
+	v, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Kind != "" {
+		v.Kind = req.Kind
+	}
+	v.MaxUses = req.MaxUses
+	v.ExpiresAt = req.ExpiresAt
+	v.Enable = req.Enable
+	v.Comment = req.Comment
+	// Select so a false Enable is written instead of skipped as a zero value.
+	if err := database.GetDB().Model(v).
+		Select("kind", "max_uses", "expires_at", "enable", "comment", "updated_at").
+		Updates(v).Error; err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *VoucherService) Delete(id int) error {
+	res := database.GetDB().Delete(&model.Voucher{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return common.NewError("voucher not found")
+	}
+	return nil
+}
+
+// newVoucherCode returns prefix-XXXX-XXXX-XXXX, or the bare groups when prefix
+// is empty.
+func newVoucherCode(prefix string) string {
+	groups := make([]string, 0, voucherCodeGroups+1)
+	if prefix != "" {
+		groups = append(groups, prefix)
+	}
+	for range voucherCodeGroups {
+		var b strings.Builder
+		for range voucherCodeGroupLen {
+			b.WriteByte(voucherCodeAlphabet[random.Num(len(voucherCodeAlphabet))])
+		}
+		groups = append(groups, b.String())
+	}
+	return strings.Join(groups, "-")
+}
+
+// normalizeVoucherCode accepts codes typed in lower case or with stray spaces.
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(code), ""))
+}
+
+// Redeem locks out callers that keep failing, and audits every attempt on an
+// existing voucher. An empty r.SubId provisions a new client from the plan.
+func (s *VoucherService) Redeem(inboundSvc *InboundService, r VoucherRedemption) (*VoucherRedeemResult, error) {
+	if !defaultRedeemLimiter.allow(r.Key) {
+		return nil, ErrVoucherRateLimited
+	}
+	v, err := findVoucher(normalizeVoucherCode(r.Code))
+	if err != nil {
+		// Unknown codes are guesses: auditing them would let anyone write to the log.
+		if errors.Is(err, ErrVoucherInvalid) {
+			defaultRedeemLimiter.registerFailure(r.Key)
+		}
+		return nil, err
+	}
+	result, targetEmail, before, err := s.redeem(inboundSvc, v, r)
+	if errors.Is(err, ErrVoucherInvalid) || errors.Is(err, ErrVoucherNotApplicable) {
+		defaultRedeemLimiter.registerFailure(r.Key)
+	}
+
+	entry := &AuditEntry{
+		Actor:      "#" + strconv.Itoa(v.Id),
+		ActorType:  AuditActorVoucher,
+		SourceIP:   r.SourceIP,
+		Method:     "REDEEM",
+		Route:      r.Source,
+		TargetType: AuditTargetClient,
+		TargetId:   targetEmail,
+		Success:    err == nil,
+		Before:     before,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else if rec, gErr := s.clientService.GetRecordByEmail(nil, result.Email); gErr == nil {
+		entry.After = rec
+	}
+	// A local AuditService: it snapshots vouchers, so it cannot be a field.
+	var audit AuditService
+	if aErr := audit.Record(entry); aErr != nil {
+		logger.Warning("voucher: record audit entry:", aErr)
+	}
+	return result, err
+}
+
+func findVoucher(code string) (*model.Voucher, error) {
+	if code == "" {
+		return nil, ErrVoucherInvalid
+	}
+	v := &model.Voucher{}
+	if err := database.GetDB().Where("code = ?", code).First(v).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVoucherInvalid
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *VoucherService) redeem(inboundSvc *InboundService, v *model.Voucher, r VoucherRedemption) (*VoucherRedeemResult, string, any, error) {
+	create := r.SubId == ""
+	if !v.Enable ||
+		(v.ExpiresAt > 0 && time.Now().UnixMilli() >= v.ExpiresAt) ||
+		(v.MaxUses > 0 && v.Uses >= v.MaxUses) ||
+		(create && v.Kind == model.VoucherKindTopUp) ||
+		(!create && v.Kind == model.VoucherKindCreate) {
+		return nil, "", nil, ErrVoucherInvalid
+	}
+
+	var emails []string
+	if !create {
+		if err := database.GetDB().Model(&model.ClientRecord{}).
+			Where("sub_id = ?", r.SubId).Order("id asc").Pluck("email", &emails).Error; err != nil {
+			return nil, "", nil, err
+		}
+		if len(emails) == 0 {
+			return nil, "", nil, ErrVoucherNotApplicable
+		}
+	}
+
+	// Claim a use before doing anything, so two concurrent redemptions of a
+	// single-use code cannot both succeed.
+	claim := database.GetDB().Model(&model.Voucher{}).
+		Where("id = ? AND (max_uses = 0 OR uses < max_uses)", v.Id).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	if claim.Error != nil {
+		return nil, "", nil, claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return nil, "", nil, ErrVoucherInvalid
+	}
+
+	var (
+		result *VoucherRedeemResult
+		target string
+		before any
+		err    error
+	)
+	if create {
+		result, err = s.redeemCreate(inboundSvc, v, r.TgID)
+		if result != nil {
+			target = result.Email
+		}
+	} else {
+		target = emails[0]
+		if rec, gErr := s.clientService.GetRecordByEmail(nil, target); gErr == nil {
+			before = rec
+		}
+		result, err = s.redeemTopUp(inboundSvc, v, r.SubId, emails)
+	}
+	if err != nil {
+		if rErr := database.GetDB().Model(&model.Voucher{}).Where("id = ?", v.Id).
+			UpdateColumn("uses", gorm.Expr("uses - 1")).Error; rErr != nil {
+			logger.Warning("voucher: release claimed use:", rErr)
+		}
+		return nil, target, before, err
+	}
+	return result, target, before, nil
+}
+
+func (s *VoucherService) redeemCreate(inboundSvc *InboundService, v *model.Voucher, tgID int64) (*VoucherRedeemResult, error) {
+	email := "v-" + random.NumLower(10)
+	res, needRestart, err := s.clientService.CreateFromPlan(inboundSvc, nil, v.PlanId,
+		[]model.Client{{Email: email, TgID: tgID, Comment: "voucher #" + strconv.Itoa(v.Id)}})
+	if err != nil {
+		return nil, err
+	}
+	if res.Created == 0 {
+		reason := "client was not created"
+		if len(res.Skipped) > 0 {
+			reason = res.Skipped[0].Reason
+		}
+		return nil, common.NewError(reason)
+	}
+	rec, err := s.clientService.GetRecordByEmail(nil, email)
+	if err != nil {
+		return nil, err
+	}
+	return &VoucherRedeemResult{Created: true, Email: email, SubId: rec.SubID, NeedRestart: needRestart}, nil
+}
+
+func (s *VoucherService) redeemTopUp(inboundSvc *InboundService, v *model.Voucher, subId string, emails []string) (*VoucherRedeemResult, error) {
+	res, needRestart, err := s.clientService.RenewWithPlan(inboundSvc, nil, v.PlanId, emails)
+	if err != nil {
+		return nil, err
+	}
+	if res.Renewed == 0 {
+		return &VoucherRedeemResult{NeedRestart: needRestart}, ErrVoucherNotApplicable
+	}
+	return &VoucherRedeemResult{Email: emails[0], SubId: subId, NeedRestart: needRestart}, nil
+}
+
+// redeemLimiter counts failed redemptions per caller and locks a caller out
+// for a cooldown once it fails too often within the window.
+type redeemLimiter struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	failures map[string][]time.Time
+	blocked  map[string]time.Time
+}
+
+func newRedeemLimiter() *redeemLimiter {
+	return &redeemLimiter{
+		now:      time.Now,
+		failures: make(map[string][]time.Time),
+		blocked:  make(map[string]time.Time),
+	}
+}
+
+func (l *redeemLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until, ok := l.blocked[key]
+	if !ok {
+		return true
+	}
+	if l.now().Before(until) {
+		return false
+	}
+	delete(l.blocked, key)
+	return true
+}
+
+func (l *redeemLimiter) registerFailure(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	cutoff := now.Add(-redeemLimitWindow)
+	if _, tracked := l.failures[key]; !tracked && len(l.failures) >= redeemLimitMaxRecords {
+		// A flood of distinct callers: start over rather than grow unbounded.
+		clear(l.failures)
+	}
+	kept := l.failures[key][:0]
+	for _, t := range l.failures[key] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	kept = append(kept, now)
+	if len(kept) >= redeemLimitMaxFailures {
+		delete(l.failures, key)
+		if len(l.blocked) >= redeemLimitMaxRecords {
+			for k, until := range l.blocked {
+				if !now.Before(until) {
+					delete(l.blocked, k)
+				}
+			}
+		}
+		l.blocked[key] = now.Add(redeemLimitCooldown)
+		return
+	}
+	l.failures[key] = kept
+}
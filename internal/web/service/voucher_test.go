@@ -0,0 +1,194 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+)
+
+func TestVoucherGenerate(t *testing.T) {
+	setupBulkDB(t)
+	svc := &VoucherService{}
+
+	if _, err := svc.Generate(&entity.VoucherGenerateRequest{PlanId: 99, Count: 1}); err == nil {
+		t.Fatalf("generate for a missing plan was accepted")
+	}
+	plan, err := svc.clientService.AddPlan(&entity.PlanRequest{Name: "gift", TotalGB: bytesPerGB})
+	if err != nil {
+		t.Fatalf("AddPlan: %v", err)
+	}
+	vouchers, err := svc.Generate(&entity.VoucherGenerateRequest{PlanId: plan.Id, Count: 3, Prefix: "spring", MaxUses: 1})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(vouchers) != 3 {
+		t.Fatalf("expected 3 vouchers, got %d", len(vouchers))
+	}
+	seen := map[string]bool{}
+	for _, v := range vouchers {
+		if !strings.HasPrefix(v.Code, "SPRING-") || len(v.Code) != len("SPRING-XXXX-XXXX-XXXX") {
+			t.Fatalf("unexpected code shape %q", v.Code)
+		}
+		if v.Kind != model.VoucherKindAny || !v.Enable {
+			t.Fatalf("defaults not applied: %+v", v)
+		}
+		seen[v.Code] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("codes are not unique: %v", seen)
+	}
+	if err := svc.clientService.DeletePlan(plan.Id); err == nil {
+		t.Fatalf("plan with vouchers was deleted")
+	}
+}
+
+func TestVoucherRedeemCreate(t *testing.T) {
+	setupBulkDB(t)
+	svc := &VoucherService{}
+	inboundSvc := &InboundService{}
+
+	ib := mkInbound(t, 21101, model.VLESS, `{"clients":[]}`)
+	plan, err := svc.clientService.AddPlan(&entity.PlanRequest{Name: "new", TotalGB: bytesPerGB, DurationDays: 7, InboundIds: []int{ib.Id}})
+	if err != nil {
+		t.Fatalf("AddPlan: %v", err)
+	}
+	vouchers, err := svc.Generate(&entity.VoucherGenerateRequest{PlanId: plan.Id, Count: 1, MaxUses: 1})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	code := vouchers[0].Code
+
+	res, err := svc.Redeem(inboundSvc, VoucherRedemption{Code: strings.ToLower(code), Key: "create-a", TgID: 42})
+	if err != nil {
+		t.Fatalf("Redeem: %v", err)
+	}
+	if !res.Created || res.SubId == "" {
+		t.Fatalf("expected a new client, got %+v", res)
+	}
+	rec, err := svc.clientService.GetRecordByEmail(nil, res.Email)
+	if err != nil {
+		t.Fatalf("GetRecordByEmail: %v", err)
+	}
+	if rec.TotalGB != bytesPerGB || rec.TgID != 42 || !rec.Enable {
+		t.Fatalf("plan not applied to redeemed client: %+v", rec)
+	}
+	if strings.Contains(rec.Comment, code) || rec.Comment != "voucher #"+strconv.Itoa(vouchers[0].Id) {
+		t.Fatalf("client comment = %q, want the voucher id and never its code", rec.Comment)
+	}
+
+	if _, err := svc.Redeem(inboundSvc, VoucherRedemption{Code: code, Key: "create-b"}); !errors.Is(err, ErrVoucherInvalid) {
+		t.Fatalf("single-use code redeemed twice: %v", err)
+	}
+	v, _ := svc.Get(vouchers[0].Id)
+	if v.Uses != 1 {
+		t.Fatalf("expected 1 use, got %d", v.Uses)
+	}
+
+	if _, err := svc.Redeem(inboundSvc, VoucherRedemption{Code: "GUESSED-CODE", Key: "create-c"}); !errors.Is(err, ErrVoucherInvalid) {
+		t.Fatalf("unknown code: %v", err)
+	}
+
+	var entries []model.AuditLog
+	if err := database.GetDB().Where("actor_type = ?", AuditActorVoucher).Find(&entries).Error; err != nil {
+		t.Fatalf("load audit entries: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.Actor != "#"+strconv.Itoa(vouchers[0].Id) {
+			t.Fatalf("audit actor = %q, want the voucher id and never the code", e.Actor)
+		}
+	}
+}
+
+func TestVoucherRedeemTopUp(t *testing.T) {
+	setupBulkDB(t)
+	svc := &VoucherService{}
+	inboundSvc := &InboundService{}
+
+	nowMs := time.Now().UnixMilli()
+	clients := []model.Client{
+		{Email: "bob@v", ID: "44444444-4444-4444-4444-444444444444", SubID: "bobsub", Enable: true, TotalGB: bytesPerGB, ExpiryTime: nowMs + msPerDay},
+	}
+	ib := mkInbound(t, 21102, model.VLESS, clientsSettings(t, clients))
+	if err := svc.clientService.SyncInbound(nil, ib.Id, clients); err != nil {
+		t.Fatalf("seed linkage: %v", err)
+	}
+	mkTraffic(t, ib.Id, "bob@v", 0, 0, bytesPerGB, nowMs+msPerDay, true)
+
+	plan, err := svc.clientService.AddPlan(&entity.PlanRequest{Name: "topup", TotalGB: 2 * bytesPerGB, RenewMode: model.PlanRenewAddQuota})
+	if err != nil {
+		t.Fatalf("AddPlan: %v", err)
+	}
+	createOnly, err := svc.Generate(&entity.VoucherGenerateRequest{PlanId: plan.Id, Count: 1, Kind: model.VoucherKindCreate, MaxUses: 1})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if _, err := svc.Redeem(inboundSvc, VoucherRedemption{Code: createOnly[0].Code, SubId: "bobsub", Key: "topup"}); !errors.Is(err, ErrVoucherInvalid) {
+		t.Fatalf("create-only code topped up a subscription: %v", err)
+	}
+
+	vouchers, err := svc.Generate(&entity.VoucherGenerateRequest{PlanId: plan.Id, Count: 1, Kind: model.VoucherKindTopUp, MaxUses: 0})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	code := vouchers[0].Code
+	if _, err := svc.Redeem(inboundSvc, VoucherRedemption{Code: code, SubId: "nosuchsub", Key: "topup"}); !errors.Is(err, ErrVoucherNotApplicable) {
+		t.Fatalf("expected not applicable for an unknown subscription, got %v", err)
+	}
+	res, err := svc.Redeem(inboundSvc, VoucherRedemption{Code: code, SubId: "bobsub", Key: "topup"})
+	if err != nil {
+		t.Fatalf("Redeem: %v", err)
+	}
+	if res.Created || res.Email != "bob@v" {
+		t.Fatalf("expected a top-up of bob@v, got %+v", res)
+	}
+	rec, _ := svc.clientService.GetRecordByEmail(nil, "bob@v")
+	if rec.TotalGB != 3*bytesPerGB {
+		t.Fatalf("expected 3GB after top-up, got %d", rec.TotalGB)
+	}
+	v, _ := svc.Get(vouchers[0].Id)
+	if v.Uses != 1 {
+		t.Fatalf("failed attempts should not count as uses, got %d", v.Uses)
+	}
+}
+
+func TestRedeemLimiter(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := newRedeemLimiter()
+	l.now = func() time.Time { return now }
+
+	for range redeemLimitMaxFailures - 1 {
+		l.registerFailure("ip:1")
+	}
+	if !l.allow("ip:1") {
+		t.Fatalf("caller blocked before reaching the limit")
+	}
+	l.registerFailure("ip:1")
+	if l.allow("ip:1") {
+		t.Fatalf("caller not blocked after %d failures", redeemLimitMaxFailures)
+	}
+	if !l.allow("ip:2") {
+		t.Fatalf("unrelated caller was blocked")
+	}
+	now = now.Add(redeemLimitCooldown)
+	if !l.allow("ip:1") {
+		t.Fatalf("caller still blocked after the cooldown")
+	}
+
+	// Failures spread wider than the window never add up to a block.
+	for range redeemLimitMaxFailures * 2 {
+		l.registerFailure("ip:3")
+		now = now.Add(redeemLimitWindow / time.Duration(redeemLimitMaxFailures-1))
+	}
+	if !l.allow("ip:3") {
+		t.Fatalf("slow failures should not trigger a block")
+	}
+}
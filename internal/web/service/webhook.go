@@ -0,0 +1,502 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/crypto/secrets"
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/eventbus"
+	"github.com/mhsanaei/3x-ui/v3/internal/logger"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
+	"github.com/mhsanaei/3x-ui/v3/internal/util/netsafe"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookTestEvent is the event type of payloads sent by the test endpoint.
+const WebhookTestEvent = "webhook.test"
+
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookMaxAttempts = 6
+	webhookBackoffBase = 30 * time.Second
+	webhookBackoffMax  = time.Hour
+	// webhookLease pushes a claimed delivery's next attempt past its send, so
+	// the retry job never picks up a row that is still in flight.
+	webhookLease         = 2 * webhookTimeout
+	webhookRetryBatch    = 100
+	webhookDeliveryKeep  = 14 * msPerDay
+	webhookResponseLimit = 64 << 10
+)
+
+// webhookEventTypes are the event bus events a webhook may subscribe to.
+var webhookEventTypes = []eventbus.EventType{
+	eventbus.EventOutboundDown,
+	eventbus.EventOutboundUp,
+	eventbus.EventXrayCrash,
+	eventbus.EventNodeDown,
+	eventbus.EventNodeUp,
+	eventbus.EventCPUHigh,
+	eventbus.EventMemoryHigh,
+	eventbus.EventLoginAttempt,
+}
+
+var webhookEventLimiter = eventbus.NewRateLimiter(1 * time.Minute)
+
+// webhookClient is shared by every delivery. Keep-alives are off because the
+// SSRF guard judges each hook's private-address allowance when dialing, which
+// a pooled connection to the same host would skip.
+var webhookClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:       netsafe.SSRFGuardedDialContext,
+		DisableKeepAlives: true,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// WebhookPayload is the JSON body POSTed to a webhook. Id is shared by every
+// webhook receiving the same event and survives retries and replays.
+type WebhookPayload struct {
+	Id        string `json:"id"`
+	Event     string `json:"event"`
+	Source    string `json:"source"`
+	Hostname  string `json:"hostname"`
+	Timestamp int64  `json:"timestamp"`
+	Data      any    `json:"data"`
+}
+
+// WebhookDeliveryParams are the query params accepted by
+// /panel/api/webhooks/deliveries.
+type WebhookDeliveryParams struct {
+	Page      int    `form:"page"`
+	PageSize  int    `form:"pageSize"`
+	WebhookId int    `form:"webhookId"`
+	Status    string `form:"status"`
+}
+
+// WebhookDeliveryPage is one page of deliveries, newest first.
+type WebhookDeliveryPage struct {
+	Items    []model.WebhookDelivery `json:"items"`
+	Total    int64                   `json:"total" example:"42"`
+	Page     int                     `json:"page" example:"1"`
+	PageSize int                     `json:"pageSize" example:"50"`
+}
+
+// WebhookService manages webhooks and delivers event bus events to them.
+type WebhookService struct {
+	settingService SettingService
+}
+
+func (s *WebhookService) List() ([]*model.Webhook, error) {
+	var hooks []*model.Webhook
+	if err := database.GetDB().Order("id asc").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (s *WebhookService) Get(id int) (*model.Webhook, error) {
+	hook := &model.Webhook{}
+	if err := database.GetDB().First(hook, id).Error; err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *WebhookService) Add(req *entity.WebhookRequest) (*model.Webhook, error) {
+	hook := &model.Webhook{}
+	if err := applyWebhookRequest(hook, req); err != nil {
+		return nil, err
+	}
+	if hook.Secret == "" {
+		return nil, common.NewError("webhook secret is required")
+	}
+	secret := hook.Secret
+	hook.Secret = ""
+	// The id-bound ciphertext can only be produced after insertion, so the
+	// secret is written in a second statement of the same transaction.
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(hook).Error; err != nil {
+			return err
+		}
+		enc, err := secrets.Seal(secrets.WebhookSecretScope(hook.Id), secret)
+		if err != nil {
+			return err
+		}
+		hook.Secret = enc
+		return tx.Model(hook).Update("secret", enc).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *WebhookService) Update(id int, req *entity.WebhookRequest) (*model.Webhook, error) {
+	hook, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWebhookRequest(hook, req); err != nil {
+		return nil, err
+	}
+	// A kept secret is already sealed and Seal passes it through unchanged.
+	if hook.Secret, err = secrets.Seal(secrets.WebhookSecretScope(hook.Id), hook.Secret); err != nil {
+		return nil, err
+	}
+	if err := database.GetDB().Save(hook).Error; err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// Delete removes a webhook together with its delivery log.
+func (s *WebhookService) Delete(id int) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&model.Webhook{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func applyWebhookRequest(hook *model.Webhook, req *entity.WebhookRequest) error {
+	url, err := SanitizeHTTPURL(req.Url)
+	if err != nil {
+		return err
+	}
+	events := make([]string, 0, len(req.Events))
+	for _, e := range req.Events {
+		e = strings.TrimSpace(e)
+		if !slices.Contains(webhookEventTypes, eventbus.EventType(e)) {
+			return common.NewErrorf("unknown event %q", e)
+		}
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+	hook.Name = strings.TrimSpace(req.Name)
+	hook.Url = url
+	hook.Events = events
+	hook.Enable = req.Enable
+	hook.AllowPrivate = req.AllowPrivate
+	if secret := strings.TrimSpace(req.Secret); secret != "" {
+		hook.Secret = secret
+	}
+	return nil
+}
+
+func webhookSubscribed(hook *model.Webhook, event string) bool {
+	return len(hook.Events) == 0 || slices.Contains(hook.Events, event)
+}
+
+// Wants reports whether any enabled webhook subscribes to event.
+func (s *WebhookService) Wants(event eventbus.EventType) bool {
+	var hooks []*model.Webhook
+	if err := database.GetDB().Where("enable = ?", true).Find(&hooks).Error; err != nil {
+		return false
+	}
+	return slices.ContainsFunc(hooks, func(h *model.Webhook) bool { return webhookSubscribed(h, string(event)) })
+}
+
+// Deliveries returns one page of the delivery log, newest first.
+func (s *WebhookService) Deliveries(p WebhookDeliveryParams) (*WebhookDeliveryPage, error) {
+	if p.PageSize <= 0 {
+		p.PageSize = auditPageDefaultSize
+	}
+	p.PageSize = min(p.PageSize, auditPageMaxSize)
+	p.Page = max(p.Page, 1)
+
+	q := database.GetDB().Model(&model.WebhookDelivery{})
+	if p.WebhookId > 0 {
+		q = q.Where("webhook_id = ?", p.WebhookId)
+	}
+	if p.Status != "" {
+		q = q.Where("status = ?", p.Status)
+	}
+	page := &WebhookDeliveryPage{Items: []model.WebhookDelivery{}, Page: p.Page, PageSize: p.PageSize}
+	if err := q.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	err := q.Order("id desc").Offset((p.Page - 1) * p.PageSize).Limit(p.PageSize).Find(&page.Items).Error
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// HandleEvent is the eventbus subscriber callback. It queues one delivery per
+// subscribed webhook and sends them in the background.
+func (s *WebhookService) HandleEvent(e eventbus.Event) {
+	if !slices.Contains(webhookEventTypes, e.Type) {
+		return
+	}
+	var hooks []*model.Webhook
+	if err := database.GetDB().Where("enable = ?", true).Find(&hooks).Error; err != nil {
+		logger.Warning("webhook: load webhooks failed:", err)
+		return
+	}
+	hooks = slices.DeleteFunc(hooks, func(h *model.Webhook) bool { return !webhookSubscribed(h, string(e.Type)) })
+	if len(hooks) == 0 {
+		return
+	}
+	data, ok := s.eventData(e)
+	if !ok {
+		return
+	}
+	if e.Type != eventbus.EventLoginAttempt && !webhookEventLimiter.Allow(e.Type, e.Source) {
+		return
+	}
+	payload, err := newWebhookPayload(string(e.Type), e.Source, e.Timestamp, data)
+	if err != nil {
+		logger.Warning("webhook: encode", e.Type, "failed:", err)
+		return
+	}
+	for _, hook := range hooks {
+		d, err := s.queue(hook.Id, string(e.Type), payload)
+		if err != nil {
+			logger.Warning("webhook: queue delivery to", hook.Name, "failed:", err)
+			continue
+		}
+		go s.attempt(hook, d, true)
+	}
+}
+
+// eventData applies the webhook CPU and memory thresholds, which the samplers
+// leave to each notifier, and reports whether the event should be sent.
+func (s *WebhookService) eventData(e eventbus.Event) (any, bool) {
+	var threshold int
+	switch e.Type {
+	case eventbus.EventCPUHigh:
+		threshold, _ = s.settingService.GetWebhookCpu()
+	case eventbus.EventMemoryHigh:
+		threshold, _ = s.settingService.GetWebhookMemory()
+	case eventbus.EventXrayCrash:
+		if e.Data != nil {
+			return map[string]any{"error": fmt.Sprint(e.Data)}, true
+		}
+		return nil, true
+	default:
+		return e.Data, true
+	}
+	data, ok := e.Data.(*eventbus.SystemMetricData)
+	if !ok || threshold <= 0 || data.Percent <= float64(threshold) {
+		return nil, false
+	}
+	return &eventbus.SystemMetricData{Percent: data.Percent, Threshold: threshold}, true
+}
+
+func newWebhookPayload(event, source string, at time.Time, data any) (string, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	hostname, _ := os.Hostname()
+	raw, err := json.Marshal(&WebhookPayload{
+		Id:        uuid.NewString(),
+		Event:     event,
+		Source:    source,
+		Hostname:  hostname,
+		Timestamp: at.UnixMilli(),
+		Data:      data,
+	})
+	return string(raw), err
+}
+
+func (s *WebhookService) queue(webhookId int, event, payload string) (*model.WebhookDelivery, error) {
+	d := &model.WebhookDelivery{
+		WebhookId:     webhookId,
+		Event:         event,
+		Payload:       payload,
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: time.Now().Add(webhookLease).UnixMilli(),
+	}
+	if err := database.GetDB().Create(d).Error; err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Test sends a webhook.test payload once, without retries, and returns the
+// recorded delivery.
+func (s *WebhookService) Test(id int) (*model.WebhookDelivery, error) {
+	hook, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := newWebhookPayload(WebhookTestEvent, hook.Name, time.Now(),
+		map[string]any{"message": "Test event from 3x-ui"})
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.queue(hook.Id, WebhookTestEvent, payload)
+	if err != nil {
+		return nil, err
+	}
+	s.attempt(hook, d, false)
+	return d, nil
+}
+
+// Replay sends a logged payload again as a new delivery, retried like any
+// other, and returns it after the first attempt.
+func (s *WebhookService) Replay(deliveryId int) (*model.WebhookDelivery, error) {
+	orig := &model.WebhookDelivery{}
+	if err := database.GetDB().First(orig, deliveryId).Error; err != nil {
+		return nil, err
+	}
+	hook, err := s.Get(orig.WebhookId)
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.queue(hook.Id, orig.Event, orig.Payload)
+	if err != nil {
+		return nil, err
+	}
+	s.attempt(hook, d, orig.Event != WebhookTestEvent)
+	return d, nil
+}
+
+// RetryDue sends every pending delivery whose backoff has elapsed. Each row is
+// claimed first, so overlapping runs never send it twice.
+func (s *WebhookService) RetryDue() (int, error) {
+	now := time.Now()
+	var due []*model.WebhookDelivery
+	err := database.GetDB().
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now.UnixMilli()).
+		Order("id asc").Limit(webhookRetryBatch).Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, d := range due {
+		lease := now.Add(webhookLease).UnixMilli()
+		res := database.GetDB().Model(&model.WebhookDelivery{}).
+			Where("id = ? AND next_attempt_at = ?", d.Id, d.NextAttemptAt).
+			Update("next_attempt_at", lease)
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
+		}
+		d.NextAttemptAt = lease
+		hook, err := s.Get(d.WebhookId)
+		if err != nil || !hook.Enable {
+			s.finish(d, model.WebhookDeliveryFailed, 0, "webhook deleted or disabled")
+			continue
+		}
+		s.attempt(hook, d, true)
+		sent++
+	}
+	return sent, nil
+}
+
+// PruneDeliveries drops settled deliveries older than two weeks.
+func (s *WebhookService) PruneDeliveries() (int64, error) {
+	cutoff := time.Now().UnixMilli() - webhookDeliveryKeep
+	res := database.GetDB().Where("created_at < ? AND status <> ?", cutoff, model.WebhookDeliveryPending).
+		Delete(&model.WebhookDelivery{})
+	return res.RowsAffected, res.Error
+}
+
+// attempt sends d once and records the outcome. A failure is rescheduled with
+// exponential backoff while retry is set and attempts remain.
+func (s *WebhookService) attempt(hook *model.Webhook, d *model.WebhookDelivery, retry bool) {
+	d.Attempts++
+	code, err := s.send(hook, d)
+	d.ResponseCode = code
+	if err == nil {
+		s.finish(d, model.WebhookDeliverySuccess, 0, "")
+		return
+	}
+	if retry && d.Attempts < webhookMaxAttempts {
+		s.finish(d, model.WebhookDeliveryPending, time.Now().Add(webhookBackoff(d.Attempts)).UnixMilli(), err.Error())
+		return
+	}
+	logger.Warning("webhook: delivery", d.Id, "to", hook.Name, "failed:", err)
+	s.finish(d, model.WebhookDeliveryFailed, 0, err.Error())
+}
+
+func (s *WebhookService) finish(d *model.WebhookDelivery, status string, next int64, errMsg string) {
+	d.Status, d.NextAttemptAt, d.Error = status, next, errMsg
+	err := database.GetDB().Model(d).Select("status", "attempts", "next_attempt_at", "response_code", "error").
+		Updates(d).Error
+	if err != nil {
+		logger.Warning("webhook: save delivery", d.Id, "failed:", err)
+	}
+}
+
+// webhookBackoff is the wait after the given failed attempt: 30s, 1m, 2m, ...
+// capped at an hour.
+func webhookBackoff(attempts int) time.Duration {
+	return min(webhookBackoffBase<<(attempts-1), webhookBackoffMax)
+}
+
+// webhookSignature is the hex HMAC-SHA256 of "<timestamp>.<body>", so a
+// captured request cannot be replayed under a fresh timestamp.
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// send POSTs the payload through the SSRF-guarded dialer. Redirects are not
+// followed, so a 3xx counts as a failure rather than a second, unchecked hop.
+func (s *WebhookService) send(hook *model.Webhook, d *model.WebhookDelivery) (int, error) {
+	url, err := SanitizeHTTPURL(hook.Url)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(netsafe.ContextWithAllowPrivate(context.Background(), hook.AllowPrivate), webhookTimeout)
+	defer cancel()
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	secret, err := secrets.Open(secrets.WebhookSecretScope(hook.Id), hook.Secret)
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "3x-ui-webhook/1.0")
+	req.Header.Set("X-3xui-Event", d.Event)
+	req.Header.Set("X-3xui-Delivery", strconv.Itoa(d.Id))
+	req.Header.Set("X-3xui-Timestamp", timestamp)
+	req.Header.Set("X-3xui-Signature", "sha256="+webhookSignature(secret, timestamp, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("http %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/eventbus"
+	"github.com/mhsanaei/3x-ui/v3/internal/web/entity"
+)
+
+type webhookRecorder struct {
+	mu       sync.Mutex
+	fail     int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	if r.fail > 0 {
+		r.fail--
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *webhookRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func TestWebhookDeliveryRetriesAndSigns(t *testing.T) {
+	setupBulkDB(t)
+	rec := &webhookRecorder{fail: 1}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+
+	svc := &WebhookService{}
+	hook, err := svc.Add(&entity.WebhookRequest{
+		Name: "ops", Url: srv.URL, Secret: "s3cret", Events: []string{"node.down"}, Enable: true, AllowPrivate: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc.HandleEvent(eventbus.Event{Type: eventbus.EventNodeUp, Source: "edge-1"})
+	svc.HandleEvent(eventbus.Event{Type: eventbus.EventNodeDown, Source: "edge-1", Data: &eventbus.NodeHealthData{NodeId: 7}})
+
+	var d model.WebhookDelivery
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := database.GetDB().Where("webhook_id = ?", hook.Id).First(&d).Error; err == nil && d.Attempts > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first attempt never recorded")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if d.Event != "node.down" || d.Status != model.WebhookDeliveryPending || d.ResponseCode != http.StatusBadGateway ||
+		d.NextAttemptAt <= time.Now().UnixMilli() {
+		t.Fatalf("after a failed first attempt = %+v, want it pending with a backoff", d)
+	}
+
+	database.GetDB().Model(&d).Update("next_attempt_at", 0)
+	if sent, err := svc.RetryDue(); err != nil || sent != 1 {
+		t.Fatalf("RetryDue = %d, %v", sent, err)
+	}
+	database.GetDB().First(&d, d.Id)
+	if d.Status != model.WebhookDeliverySuccess || d.Attempts != 2 {
+		t.Fatalf("after retry = %+v", d)
+	}
+	var total int64
+	database.GetDB().Model(&model.WebhookDelivery{}).Count(&total)
+	if total != 1 || rec.count() != 2 {
+		t.Fatalf("deliveries = %d, requests = %d; the unsubscribed node.up must not be sent", total, rec.count())
+	}
+
+	req, body := rec.requests[1], rec.bodies[1]
+	want := "sha256=" + webhookSignature("s3cret", req.Header.Get("X-3xui-Timestamp"), body)
+	if got := req.Header.Get("X-3xui-Signature"); got != want {
+		t.Fatalf("signature = %q, want %q", got, want)
+	}
+	var payload struct {
+		Event string `json:"event"`
+		Data  struct {
+			NodeId int `json:"nodeId"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Event != "node.down" || payload.Data.NodeId != 7 {
+		t.Fatalf("payload = %s (%v)", body, err)
+	}
+
+	replayed, err := svc.Replay(d.Id)
+	if err != nil || replayed.Status != model.WebhookDeliverySuccess || replayed.Payload != d.Payload {
+		t.Fatalf("Replay = %+v, %v", replayed, err)
+	}
+}
+
+func TestWebhookTestDoesNotRetry(t *testing.T) {
+	setupBulkDB(t)
+	srv := httptest.NewServer(&webhookRecorder{fail: 10})
+	t.Cleanup(srv.Close)
+	svc := &WebhookService{}
+	hook, err := svc.Add(&entity.WebhookRequest{Name: "x", Url: srv.URL, Secret: "k", Enable: true, AllowPrivate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := svc.Test(hook.Id)
+	if err != nil || d.Status != model.WebhookDeliveryFailed || d.Attempts != 1 || d.Error != "http 502" {
+		t.Fatalf("Test = %+v, %v; want one failed attempt", d, err)
+	}
+}
+
+func TestWebhookRejectsPrivateTargetAndBadEvents(t *testing.T) {
+	setupBulkDB(t)
+	srv := httptest.NewServer(&webhookRecorder{})
+	t.Cleanup(srv.Close)
+	svc := &WebhookService{}
+	if _, err := svc.Add(&entity.WebhookRequest{Name: "x", Url: srv.URL, Secret: "k", Events: []string{"node.gone"}}); err == nil {
+		t.Fatal("an unknown event must be rejected")
+	}
+	if _, err := svc.Add(&entity.WebhookRequest{Name: "x", Url: srv.URL}); err == nil {
+		t.Fatal("a webhook without a secret must be rejected")
+	}
+	hook, err := svc.Add(&entity.WebhookRequest{Name: "x", Url: srv.URL, Secret: "k", Enable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := svc.Test(hook.Id); d.Status != model.WebhookDeliveryFailed || d.ResponseCode != 0 {
+		t.Fatalf("loopback delivery = %+v, want it blocked without AllowPrivate", d)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 5: 8 * time.Minute, 9: time.Hour} {
+		if got := webhookBackoff(attempts); got != want {
+			t.Fatalf("webhookBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+// Deliveries share one client but never pool connections: an idle connection
+// would be reused without the SSRF guard judging the next hook.
+func TestWebhookSendLeavesNoIdleConnections(t *testing.T) {
+	setupBulkDB(t)
+	var open, dialed atomic.Int32
+	srv := httptest.NewUnstartedServer(&webhookRecorder{})
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			open.Add(1)
+			dialed.Add(1)
+		case http.StateClosed, http.StateHijacked:
+			open.Add(-1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	svc := &WebhookService{}
+	added, err := svc.Add(&entity.WebhookRequest{
+		Name: "ops", Url: srv.URL, Secret: "s3cret", Events: []string{"node.down"}, Enable: true, AllowPrivate: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hook model.Webhook
+	if err := database.GetDB().First(&hook, added.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if code, err := svc.send(&hook, &model.WebhookDelivery{Id: i + 1, Event: "node.down", Payload: "{}"}); err != nil || code != http.StatusNoContent {
+			t.Fatalf("send %d = %d, %v", i, code, err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for open.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d connections left open after the deliveries", open.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if dialed.Load() != 3 {
+		t.Fatalf("dialed %d connections for 3 deliveries, want one each", dialed.Load())
+	}
+}
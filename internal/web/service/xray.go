@@ -7,6 +7,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 
@@ -140,7 +141,13 @@ func RemoveIndex(s []any, index int) []any {
 
 // GetXrayConfig retrieves and builds the Xray configuration from settings and inbounds.
 func (s *XrayService) GetXrayConfig() (*xray.Config, error) {
-	templateConfig, err := s.settingService.GetXrayConfigTemplate()
+	return s.buildXrayConfig(nil)
+}
+
+// buildXrayConfig generates the config from the stored template and inbounds,
+// with the pending changes in ov laid over them when it is non-nil.
+func (s *XrayService) buildXrayConfig(ov *configOverlay) (*xray.Config, error) {
+	templateConfig, err := ov.xrayTemplate(&s.settingService)
 	if err != nil {
 		return nil, err
 	}
@@ -159,12 +166,15 @@ func (s *XrayService) GetXrayConfig() (*xray.Config, error) {
 	// the per-inbound lift below).
 	xrayConfig.OutboundConfigs = liftOutboundsXhttpSessionIDKeys(xrayConfig.OutboundConfigs)
 
-	_, _, _ = s.inboundService.AddTraffic(nil, nil)
+	if ov == nil {
+		_, _, _ = s.inboundService.AddTraffic(nil, nil)
+	}
 
 	inbounds, err := s.inboundService.GetAllInbounds()
 	if err != nil {
 		return nil, err
 	}
+	inbounds = ov.applyInbounds(inbounds)
 	for _, inbound := range inbounds {
 		if !inbound.Enable {
 			continue
@@ -175,174 +185,10 @@ func (s *XrayService) GetXrayConfig() (*xray.Config, error) {
 		if inbound.Protocol == model.MTProto {
 			continue
 		}
-		settings := map[string]any{}
-		_ = json.Unmarshal([]byte(inbound.Settings), &settings)
-
-		dbClients, listErr := s.inboundService.clientService.ListForInbound(nil, inbound.Id)
-		if listErr != nil {
-			return nil, listErr
-		}
-
-		clientStats := inbound.ClientStats
-		enableMap := make(map[string]bool, len(clientStats))
-		for _, clientTraffic := range clientStats {
-			enableMap[clientTraffic.Email] = clientTraffic.Enable
-		}
-
-		finalClients := make([]any, 0, len(dbClients))
-		var wgPeers []any
-		for i := range dbClients {
-			c := dbClients[i]
-			if enable, exists := enableMap[c.Email]; exists && !enable {
-				logger.Infof("Remove Inbound User %s due to expiration or traffic limit", c.Email)
-				continue
-			}
-			if !c.Enable {
-				continue
-			}
-			flow := c.Flow
-			if flow == "xtls-rprx-vision-udp443" {
-				flow = "xtls-rprx-vision"
-			}
-			if inbound.DisableFlow {
-				flow = ""
-			}
-			entry := map[string]any{"email": c.Email}
-			switch inbound.Protocol {
-			case model.VLESS:
-				if c.ID != "" {
-					entry["id"] = c.ID
-				}
-				if flow != "" {
-					entry["flow"] = flow
-				}
-				if c.Reverse != nil {
-					entry["reverse"] = c.Reverse
-				}
-			case model.VMESS:
-				if c.ID != "" {
-					entry["id"] = c.ID
-				}
-				if c.Security != "" {
-					entry["security"] = c.Security
-				}
-			case model.Trojan:
-				if c.Password != "" {
-					entry["password"] = c.Password
-				}
-				if flow != "" {
-					entry["flow"] = flow
-				}
-			case model.Shadowsocks:
-				if c.Password != "" {
-					entry["password"] = c.Password
-				}
-			case model.Hysteria:
-				if c.Auth != "" {
-					entry["auth"] = c.Auth
-				}
-			case model.WireGuard:
-				wgPeers = append(wgPeers, model.WireguardPeerFromClient(c))
-				continue
-			}
-			finalClients = append(finalClients, entry)
-		}
-
-		var mutated bool
-		if inbound.Protocol == model.WireGuard {
-			delete(settings, "clients")
-			if wgPeers == nil {
-				wgPeers = []any{}
-			}
-			settings["peers"] = wgPeers
-			mutated = true
-		} else {
-			_, hadClients := settings["clients"]
-			mutated = hadClients || len(finalClients) > 0
-			if mutated {
-				settings["clients"] = finalClients
-			}
-		}
-
-		if inboundCanHostFallbacks(inbound) {
-			fallbacks, fbErr := s.inboundService.fallbackService.BuildFallbacksJSON(nil, inbound.Id)
-			if fbErr != nil {
-				return nil, fbErr
-			}
-			if len(fallbacks) > 0 {
-				generic := make([]any, 0, len(fallbacks))
-				for _, f := range fallbacks {
-					generic = append(generic, f)
-				}
-				settings["fallbacks"] = generic
-				mutated = true
-			}
-		}
-
-		if mutated {
-			modifiedSettings, err := json.MarshalIndent(settings, "", "  ")
-			if err != nil {
-				return nil, err
-			}
-			inbound.Settings = string(modifiedSettings)
-		}
-
-		if len(inbound.StreamSettings) > 0 {
-			// Unmarshal stream JSON
-			var stream map[string]any
-			_ = json.Unmarshal([]byte(inbound.StreamSettings), &stream)
-
-			// Remove the "settings" field under "tlsSettings" and "realitySettings"
-			tlsSettings, ok1 := stream["tlsSettings"].(map[string]any)
-			realitySettings, ok2 := stream["realitySettings"].(map[string]any)
-			if ok1 || ok2 {
-				if ok1 {
-					delete(tlsSettings, "settings")
-				} else if ok2 {
-					delete(realitySettings, "settings")
-				}
-			}
-
-			delete(stream, "externalProxy")
-
-			// finalmask.tcp + REALITY panics Xray-core on the first connection
-			// (XTLS/Xray-core#6453). AddInbound/UpdateInbound reject this
-			// combination at save time, but a row saved before that guard
-			// existed (upgrade, node sync, restored backup, direct DB edit)
-			// would still crash Xray on the next restart without this — drop
-			// it here too, the same way liftXhttpSessionIDKeys and
-			// HealShadowsocksClientMethods heal other legacy data in place.
-			if len(finalMaskRealityTcpMasks(stream)) > 0 {
-				logger.Warningf("Inbound %q: dropping finalmask, incompatible with REALITY security (crashes Xray-core, see XTLS/Xray-core#6453)", inbound.Tag)
-				delete(stream, "finalmask")
-			}
-
-			dropEmptyRandPackets(stream["finalmask"])
-
-			if dropped := stripIncompleteXmcMasks(stream); dropped > 0 {
-				logger.Warningf("Inbound %q: dropping %d XMC finalmask mask(s) without complete Minecraft profiles — reconfigure them to restore the obfuscation (see XTLS/Xray-core#6487)", inbound.Tag, dropped)
-			}
-
-			// xray-core v26.6.22 (#6258) renamed the XHTTP session keys and
-			// kept no fallback. Lift legacy sessionPlacement/sessionKey onto the
-			// new names here so inbounds stored before the rename keep working
-			// without the admin re-saving them.
-			liftXhttpSessionIDKeys(stream)
-
-			newStream, err := json.MarshalIndent(stream, "", "  ")
-			if err != nil {
-				return nil, err
-			}
-			inbound.StreamSettings = string(newStream)
-		}
-
-		if inbound.Protocol == model.Shadowsocks {
-			if healed, ok := model.HealShadowsocksClientMethods(inbound.Settings); ok {
-				inbound.Settings = healed
-			}
+		inboundConfig, err := s.genInboundConfig(inbound, ov)
+		if err != nil {
+			return nil, err
 		}
-
-		inboundConfig := inbound.GenXrayInboundConfig()
 		xrayConfig.InboundConfigs = append(xrayConfig.InboundConfigs, *inboundConfig)
 	}
 
@@ -369,6 +215,13 @@ func (s *XrayService) GetXrayConfig() (*xray.Config, error) {
 		injectMtprotoEgress(xrayConfig, inbound)
 	}
 
+	// Pin clients to their egress, also after the subscription merge.
+	if routes, err := ov.egressRoutes(); err != nil {
+		logger.Warning("read client egress failed:", err)
+	} else if len(routes) > 0 {
+		injectClientEgresses(xrayConfig, routes)
+	}
+
 	// Wire the panel's own HTTP traffic through the configured outbound, after
 	// the subscription merge so subscription outbound tags are valid targets.
 	if egressTag, err := s.settingService.GetPanelOutbound(); err != nil {
@@ -387,6 +240,184 @@ func (s *XrayService) GetXrayConfig() (*xray.Config, error) {
 	return xrayConfig, nil
 }
 
+// genInboundConfig builds the Xray inbound for a local inbound row, with its
+// enabled clients (from ov when it overrides them) and fallbacks injected.
+func (s *XrayService) genInboundConfig(inbound *model.Inbound, ov *configOverlay) (*xray.InboundConfig, error) {
+	settings := map[string]any{}
+	_ = json.Unmarshal([]byte(inbound.Settings), &settings)
+
+	dbClients, listErr := ov.clientsFor(&s.inboundService.clientService, inbound.Id)
+	if listErr != nil {
+		return nil, listErr
+	}
+
+	paused := ov.pausedClients()
+	clientStats := inbound.ClientStats
+	enableMap := make(map[string]bool, len(clientStats))
+	for _, clientTraffic := range clientStats {
+		enableMap[clientTraffic.Email] = clientTraffic.Enable
+	}
+	ov.applyEnable(enableMap)
+
+	finalClients := make([]any, 0, len(dbClients))
+	var wgPeers []any
+	for i := range dbClients {
+		c := dbClients[i]
+		if enable, exists := enableMap[c.Email]; exists && !enable {
+			logger.Infof("Remove Inbound User %s due to expiration or traffic limit", c.Email)
+			continue
+		}
+		if !c.Enable {
+			continue
+		}
+		if _, out := paused[c.Email]; out {
+			continue
+		}
+		flow := c.Flow
+		if flow == "xtls-rprx-vision-udp443" {
+			flow = "xtls-rprx-vision"
+		}
+		if inbound.DisableFlow {
+			flow = ""
+		}
+		entry := map[string]any{"email": c.Email}
+		switch inbound.Protocol {
+		case model.VLESS:
+			if c.ID != "" {
+				entry["id"] = c.ID
+			}
+			if flow != "" {
+				entry["flow"] = flow
+			}
+			if c.Reverse != nil {
+				entry["reverse"] = c.Reverse
+			}
+		case model.VMESS:
+			if c.ID != "" {
+				entry["id"] = c.ID
+			}
+			if c.Security != "" {
+				entry["security"] = c.Security
+			}
+		case model.Trojan:
+			if c.Password != "" {
+				entry["password"] = c.Password
+			}
+			if flow != "" {
+				entry["flow"] = flow
+			}
+		case model.Shadowsocks:
+			if c.Password != "" {
+				entry["password"] = c.Password
+			}
+		case model.Hysteria:
+			if c.Auth != "" {
+				entry["auth"] = c.Auth
+			}
+		case model.WireGuard:
+			wgPeers = append(wgPeers, model.WireguardPeerFromClient(c))
+			continue
+		}
+		finalClients = append(finalClients, entry)
+	}
+
+	var mutated bool
+	if inbound.Protocol == model.WireGuard {
+		delete(settings, "clients")
+		if wgPeers == nil {
+			wgPeers = []any{}
+		}
+		settings["peers"] = wgPeers
+		mutated = true
+	} else {
+		_, hadClients := settings["clients"]
+		mutated = hadClients || len(finalClients) > 0
+		if mutated {
+			settings["clients"] = finalClients
+		}
+	}
+
+	if inboundCanHostFallbacks(inbound) {
+		fallbacks, fbErr := s.inboundService.fallbackService.BuildFallbacksJSON(nil, inbound.Id)
+		if fbErr != nil {
+			return nil, fbErr
+		}
+		if len(fallbacks) > 0 {
+			generic := make([]any, 0, len(fallbacks))
+			for _, f := range fallbacks {
+				generic = append(generic, f)
+			}
+			settings["fallbacks"] = generic
+			mutated = true
+		}
+	}
+
+	if mutated {
+		modifiedSettings, err := json.MarshalIndent(settings, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		inbound.Settings = string(modifiedSettings)
+	}
+
+	if len(inbound.StreamSettings) > 0 {
+		// Unmarshal stream JSON
+		var stream map[string]any
+		_ = json.Unmarshal([]byte(inbound.StreamSettings), &stream)
+
+		// Remove the "settings" field under "tlsSettings" and "realitySettings"
+		tlsSettings, ok1 := stream["tlsSettings"].(map[string]any)
+		realitySettings, ok2 := stream["realitySettings"].(map[string]any)
+		if ok1 || ok2 {
+			if ok1 {
+				delete(tlsSettings, "settings")
+			} else if ok2 {
+				delete(realitySettings, "settings")
+			}
+		}
+
+		delete(stream, "externalProxy")
+
+		// finalmask.tcp + REALITY panics Xray-core on the first connection
+		// (XTLS/Xray-core#6453). AddInbound/UpdateInbound reject this
+		// combination at save time, but a row saved before that guard
+		// existed (upgrade, node sync, restored backup, direct DB edit)
+		// would still crash Xray on the next restart without this — drop
+		// it here too, the same way liftXhttpSessionIDKeys and
+		// HealShadowsocksClientMethods heal other legacy data in place.
+		if len(finalMaskRealityTcpMasks(stream)) > 0 {
+			logger.Warningf("Inbound %q: dropping finalmask, incompatible with REALITY security (crashes Xray-core, see XTLS/Xray-core#6453)", inbound.Tag)
+			delete(stream, "finalmask")
+		}
+
+		dropEmptyRandPackets(stream["finalmask"])
+
+		if dropped := stripIncompleteXmcMasks(stream); dropped > 0 {
+			logger.Warningf("Inbound %q: dropping %d XMC finalmask mask(s) without complete Minecraft profiles — reconfigure them to restore the obfuscation (see XTLS/Xray-core#6487)", inbound.Tag, dropped)
+		}
+
+		// xray-core v26.6.22 (#6258) renamed the XHTTP session keys and
+		// kept no fallback. Lift legacy sessionPlacement/sessionKey onto the
+		// new names here so inbounds stored before the rename keep working
+		// without the admin re-saving them.
+		liftXhttpSessionIDKeys(stream)
+
+		newStream, err := json.MarshalIndent(stream, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		inbound.StreamSettings = string(newStream)
+	}
+
+	if inbound.Protocol == model.Shadowsocks {
+		if healed, ok := model.HealShadowsocksClientMethods(inbound.Settings); ok {
+			inbound.Settings = healed
+		}
+	}
+
+	return inbound.GenXrayInboundConfig(), nil
+}
+
 // PanelEgressInboundTag is the tag of the loopback SOCKS inbound injected into
 // the generated config when a panel outbound is configured. The panel's own
 // HTTP clients dial through it to egress via the chosen outbound.
@@ -483,6 +514,72 @@ func routingTargetExists(routing map[string]any, outbounds json_util.RawMessage,
 	return routingTagIsBalancer(routing, tag) || outboundTagExists(outbounds, tag)
 }
 
+// injectClientEgresses adds a rule per egress route after the leading stats and
+// blackhole rules, so pinned clients still can't reach what the template blocks.
+func injectClientEgresses(cfg *xray.Config, routes []ClientEgressRoute) {
+	routing := map[string]any{}
+	if len(cfg.RouterConfig) > 0 {
+		if err := json.Unmarshal(cfg.RouterConfig, &routing); err != nil {
+			logger.Warning("client egress: routing section is unparsable, skipping injection:", err)
+			return
+		}
+	}
+	var outbounds []struct {
+		Tag      string `json:"tag"`
+		Protocol string `json:"protocol"`
+	}
+	_ = json.Unmarshal(cfg.OutboundConfigs, &outbounds)
+	blackholes := map[string]bool{}
+	for _, o := range outbounds {
+		if o.Protocol == "blackhole" {
+			blackholes[o.Tag] = true
+		}
+	}
+
+	newRules := make([]any, 0, len(routes))
+	for _, r := range routes {
+		if !routingTargetExists(routing, cfg.OutboundConfigs, r.Outbound) {
+			logger.Warning("client egress: target tag [", r.Outbound, "] not found, skipping ", len(r.Emails), " client(s)")
+			continue
+		}
+		users := make([]any, 0, len(r.Emails))
+		for _, e := range r.Emails {
+			users = append(users, e)
+		}
+		rule := map[string]any{
+			"type": "field",
+			"user": users,
+		}
+		if routingTagIsBalancer(routing, r.Outbound) {
+			rule["balancerTag"] = r.Outbound
+		} else {
+			rule["outboundTag"] = r.Outbound
+		}
+		newRules = append(newRules, rule)
+	}
+	if len(newRules) == 0 {
+		return
+	}
+
+	rules, _ := routing["rules"].([]any)
+	at := 0
+	for at < len(rules) {
+		rule, _ := rules[at].(map[string]any)
+		outTag, _ := rule["outboundTag"].(string)
+		if rule == nil || !(isApiRule(rule) || blackholes[outTag]) {
+			break
+		}
+		at++
+	}
+	routing["rules"] = slices.Concat(rules[:at], newRules, rules[at:])
+	newRouting, err := json.Marshal(routing)
+	if err != nil {
+		logger.Warning("client egress: failed to rebuild routing section, skipping injection:", err)
+		return
+	}
+	cfg.RouterConfig = json_util.RawMessage(newRouting)
+}
+
 // NodeEgressInboundTag returns the loopback SOCKS inbound tag for a given node.
 func NodeEgressInboundTag(nodeID int) string {
 	return fmt.Sprintf("node-egress-%d", nodeID)
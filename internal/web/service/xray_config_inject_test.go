@@ -557,3 +557,45 @@ func TestInjectMtprotoEgress_BadRoutingSkips(t *testing.T) {
 		t.Fatalf("unparsable routing must be left untouched, got %s", cfg.RouterConfig)
 	}
 }
+
+func TestInjectClientEgresses(t *testing.T) {
+	cfg := egressTestConfig()
+	cfg.RouterConfig = json_util.RawMessage(`{"rules":[` +
+		`{"type":"field","inboundTag":["api"],"outboundTag":"api"},` +
+		`{"type":"field","ip":["geoip:private"],"outboundTag":"blocked"},` +
+		`{"type":"field","domain":["geosite:cn"],"outboundTag":"direct"}],` +
+		`"balancers":[{"tag":"pool","selector":["warp"]}]}`)
+	cfg.OutboundConfigs = json_util.RawMessage(`[{"protocol":"freedom","tag":"direct"},` +
+		`{"protocol":"socks","tag":"warp"},{"protocol":"blackhole","tag":"blocked"}]`)
+	injectClientEgresses(cfg, []ClientEgressRoute{
+		{Outbound: "gone", Emails: []string{"dave"}},
+		{Outbound: "pool", Emails: []string{"bob"}},
+		{Outbound: "warp", Emails: []string{"alice", "carol"}},
+	})
+
+	var routing struct {
+		Rules []struct {
+			User        []string `json:"user"`
+			OutboundTag string   `json:"outboundTag"`
+			BalancerTag string   `json:"balancerTag"`
+		} `json:"rules"`
+	}
+	if err := json.Unmarshal(cfg.RouterConfig, &routing); err != nil {
+		t.Fatal(err)
+	}
+	if len(routing.Rules) != 5 {
+		t.Fatalf("expected 2 egress rules among 3 existing, got %+v", routing.Rules)
+	}
+	if routing.Rules[0].OutboundTag != "api" || routing.Rules[1].OutboundTag != "blocked" {
+		t.Fatalf("api and block rules must stay ahead of the egress rules, got %+v", routing.Rules)
+	}
+	if r := routing.Rules[2]; r.BalancerTag != "pool" || r.OutboundTag != "" || len(r.User) != 1 || r.User[0] != "bob" {
+		t.Fatalf("balancer egress must emit a balancerTag rule, got %+v", r)
+	}
+	if r := routing.Rules[3]; r.OutboundTag != "warp" || len(r.User) != 2 {
+		t.Fatalf("outbound egress rule wrong, got %+v", r)
+	}
+	if routing.Rules[4].OutboundTag != "direct" {
+		t.Fatalf("template rules must follow the egress rules, got %+v", routing.Rules)
+	}
+}
@@ -24,6 +24,16 @@ const (
 )
 
 func (s *XraySettingService) SaveXraySetting(newXraySettings string) error {
+	newXraySettings, err := s.prepareXrayTemplate(newXraySettings)
+	if err != nil {
+		return err
+	}
+	return s.saveSetting("xrayTemplateConfig", newXraySettings)
+}
+
+// prepareXrayTemplate validates a template and returns it in the form
+// SaveXraySetting stores.
+func (s *XraySettingService) prepareXrayTemplate(newXraySettings string) (string, error) {
 	// The frontend round-trips the whole getXraySetting response back
 	// through the textarea, so if it has ever received a wrapped
 	// payload (see UnwrapXrayTemplateConfig) it sends that same wrapper
@@ -31,7 +41,7 @@ func (s *XraySettingService) SaveXraySetting(newXraySettings string) error {
 	// garbage the next read can't recover from without this same call.
 	newXraySettings = UnwrapXrayTemplateConfig(newXraySettings)
 	if err := s.CheckXrayConfig(newXraySettings); err != nil {
-		return err
+		return "", err
 	}
 	if hoisted, err := EnsureStatsRouting(newXraySettings); err == nil {
 		newXraySettings = hoisted
@@ -39,7 +49,7 @@ func (s *XraySettingService) SaveXraySetting(newXraySettings string) error {
 	if synced, err := EnsureDnsServerRouting(newXraySettings); err == nil {
 		newXraySettings = synced
 	}
-	return s.saveSetting("xrayTemplateConfig", newXraySettings)
+	return newXraySettings, nil
 }
 
 func (s *XraySettingService) CheckXrayConfig(XrayTemplateConfig string) error {
@@ -248,7 +248,21 @@ func (s *XraySettingService) PropagateInboundTagRename(oldTag, newTag string) (b
 	if err != nil {
 		return false, err
 	}
-	updated, changed, err := mutateXrayTemplateRouting(template, func(cfg map[string]any) bool {
+	updated, changed, err := renameInboundTagInTemplate(template, oldTag, newTag)
+	if err != nil || !changed {
+		return false, err
+	}
+	if err := s.SaveXraySetting(updated); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func renameInboundTagInTemplate(template, oldTag, newTag string) (string, bool, error) {
+	if oldTag == "" || newTag == "" || oldTag == newTag {
+		return template, false, nil
+	}
+	return mutateXrayTemplateRouting(template, func(cfg map[string]any) bool {
 		mutated := false
 		rules := routingRulesFromCfg(cfg)
 		if len(rules) > 0 {
@@ -264,13 +278,6 @@ func (s *XraySettingService) PropagateInboundTagRename(oldTag, newTag string) (b
 		}
 		return mutated
 	})
-	if err != nil || !changed {
-		return false, err
-	}
-	if err := s.SaveXraySetting(updated); err != nil {
-		return false, err
-	}
-	return true, nil
 }
 
 // RemoveInboundTagReferences drops a deleted inbound tag from routing rules.
@@ -284,7 +291,21 @@ func (s *XraySettingService) RemoveInboundTagReferences(deletedTag string) (bool
 	if err != nil {
 		return false, err
 	}
-	updated, changed, err := mutateXrayTemplateRouting(template, func(cfg map[string]any) bool {
+	updated, changed, err := removeInboundTagFromTemplate(template, deletedTag)
+	if err != nil || !changed {
+		return false, err
+	}
+	if err := s.SaveXraySetting(updated); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func removeInboundTagFromTemplate(template, deletedTag string) (string, bool, error) {
+	if deletedTag == "" {
+		return template, false, nil
+	}
+	return mutateXrayTemplateRouting(template, func(cfg map[string]any) bool {
 		mutated := false
 		rules := routingRulesFromCfg(cfg)
 		if len(rules) > 0 {
@@ -301,11 +322,4 @@ func (s *XraySettingService) RemoveInboundTagReferences(deletedTag string) (bool
 		}
 		return mutated
 	})
-	if err != nil || !changed {
-		return false, err
-	}
-	if err := s.SaveXraySetting(updated); err != nil {
-		return false, err
-	}
-	return true, nil
 }
@@ -14,10 +14,14 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/mhsanaei/3x-ui/v3/internal/acme"
 	"github.com/mhsanaei/3x-ui/v3/internal/config"
+	"github.com/mhsanaei/3x-ui/v3/internal/database"
 	"github.com/mhsanaei/3x-ui/v3/internal/eventbus"
+	"github.com/mhsanaei/3x-ui/v3/internal/ha"
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
 	"github.com/mhsanaei/3x-ui/v3/internal/mtproto"
 	"github.com/mhsanaei/3x-ui/v3/internal/util/common"
@@ -126,6 +130,11 @@ type Server struct {
 	bus  *eventbus.Bus
 	cron *cron.Cron
 
+	// leaderCron exists only while this panel leads (always, without HA);
+	// leaderMu guards it against a promotion or demotion during stop.
+	leaderMu   sync.Mutex
+	leaderCron *cron.Cron
+
 	ctx    context.Context
 	cancel context.CancelFunc
 }
@@ -168,11 +177,13 @@ func (s *Server) initRouter() (*gin.Engine, error) {
 	// Cap request bodies on state-changing requests so a stolen session/API
 	// token or a buggy client can't force large allocations or long DB
 	// transactions via bulk create/attach/import endpoints. GET/HEAD/OPTIONS
-	// carry no body and are left untouched. Database restore legitimately accepts
-	// large backups and streams them to disk, so only its exact route suffix is
-	// exempt. Follow-up: make the limit a setting.
+	// carry no body and are left untouched. Database restore and the panel
+	// importer legitimately accept large databases and stream them to disk, so
+	// only their exact route suffixes are exempt. Follow-up: make the limit a setting.
 	const maxRequestBodyBytes = 10 << 20 // 10 MiB
-	engine.Use(middleware.MaxBodyBytes(maxRequestBodyBytes, "/panel/api/server/importDB"))
+	engine.Use(middleware.MaxBodyBytes(maxRequestBodyBytes, "/panel/api/server/importDB",
+		"/panel/api/importer/import",
+		"/"+runtime.TelemetryStreamPath)) // never ends; read one bounded frame at a time
 
 	webDomain, err := s.settingService.GetWebDomain()
 	if err != nil {
@@ -252,6 +263,8 @@ func (s *Server) initRouter() (*gin.Engine, error) {
 	s.index = controller.NewIndexController(g)
 	s.panel = controller.NewXUIController(g)
 	s.api = controller.NewAPIController(g)
+	controller.NewNodeJoinController(g)
+	controller.NewNodeTelemetryController(g, job.ReceiveNodeTelemetry)
 
 	// Initialize WebSocket hub
 	s.wsHub = websocket.NewHub()
@@ -263,6 +276,9 @@ func (s *Server) initRouter() (*gin.Engine, error) {
 	// Register WebSocket route with basePath (g already has basePath prefix)
 	g.GET("/ws", s.ws.HandleWebSocket)
 
+	// ACME http-01 challenges are answered outside the base path.
+	engine.GET(acme.HTTPChallengePrefix+":token", gin.WrapH(acme.DefaultResponder))
+
 	// Chrome DevTools endpoint for debugging web apps
 	engine.GET("/.well-known/appspecific/com.chrome.devtools.json", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{})
@@ -294,20 +310,28 @@ const (
 	cadenceClientIPScan  = "@every 10s"
 	cadenceNodeHeartbeat = "@every 5s"
 	cadenceNodeTraffic   = "@every 5s"
+	cadenceNodeTelemetry = "@every 5s"
+	cadenceNodeFailover  = "@every 5s"
+	cadenceNodeRollout   = "@every 5s"
 	cadenceOutboundSub   = "@every 5m"
 	cadenceReapOrphans   = "@every 5m"
 	cadenceRemoteRouting = "@every 5m"
 	cadenceXrayLogPrune  = "@every 10m"
 	cadenceCheckHash     = "@every 2m"
+	cadenceAcmeRenew     = "@every 12h"
+	cadenceWebhookRetry  = "@every 30s"
+	// On the minute, so access windows open and close on time.
+	cadenceAccessSchedule = "0 * * * * *"
 	// cpu.Percent samples over a full minute (blocking), so a finer cadence just
 	// stacks overlapping samplers; subscribers rate-limit alerts to 1/min anyway.
 	cadenceCPUAlarm    = "@every 1m"
 	cadenceMemoryAlarm = "@every 1m"
 )
 
-// startTask schedules background jobs (Xray checks, traffic jobs, cron
-// jobs) which the panel relies on for periodic maintenance and monitoring.
+// startTask schedules the leader-only jobs: run twice against one database,
+// they would double-count traffic and fight over Xray.
 func (s *Server) startTask(restartXray bool, loc *time.Location) {
+	c := s.leaderCron
 	if restartXray {
 		err := s.xrayService.RestartXray(true)
 		if err != nil {
@@ -315,55 +339,89 @@ func (s *Server) startTask(restartXray bool, loc *time.Location) {
 		}
 	}
 	// Check whether xray is running every second
-	_, _ = s.cron.AddJob(cadenceXrayRunning, job.NewCheckXrayRunningJob())
+	_, _ = c.AddJob(cadenceXrayRunning, job.NewCheckXrayRunningJob())
 
 	// Check if xray needs to be restarted every 30 seconds
-	_, _ = s.cron.AddFunc(cadenceXrayRestart, func() {
+	_, _ = c.AddFunc(cadenceXrayRestart, func() {
 		s.xrayService.ApplyPendingRestart()
 	})
 
 	go func() {
 		time.Sleep(time.Second * 5)
-		_, _ = s.cron.AddJob(cadenceXrayTraffic, job.NewXrayTrafficJob())
+		_, _ = c.AddJob(cadenceXrayTraffic, job.NewXrayTrafficJob())
 	}()
 
 	// Reconcile mtproto (mtg) sidecars and scrape their traffic
 	mtJob := job.NewMtprotoJob()
-	_, _ = s.cron.AddJob(cadenceMtproto, mtJob)
+	_, _ = c.AddJob(cadenceMtproto, mtJob)
 	go mtJob.Run()
 
 	// check client ips from log file every 10 sec
-	_, _ = s.cron.AddJob(cadenceClientIPScan, job.NewCheckClientIpJob())
+	_, _ = c.AddJob(cadenceClientIPScan, job.NewCheckClientIpJob())
+
+	_, _ = c.AddJob(cadenceNodeHeartbeat, job.NewNodeHeartbeatJob())
 
-	_, _ = s.cron.AddJob(cadenceNodeHeartbeat, job.NewNodeHeartbeatJob())
+	_, _ = c.AddJob(cadenceNodeTraffic, job.NewNodeTrafficSyncJob())
 
-	_, _ = s.cron.AddJob(cadenceNodeTraffic, job.NewNodeTrafficSyncJob())
+	// Offer push telemetry streams to the nodes that support them; the two
+	// jobs above stop polling a node while its stream is live.
+	_, _ = c.AddJob(cadenceNodeTelemetry, job.NewNodeTelemetryJob())
+
+	// Move inbounds to their standby node while their own node is down.
+	_, _ = c.AddJob(cadenceNodeFailover, job.NewNodeFailoverJob())
+
+	// Advance rolling panel / Xray upgrades across nodes, batch by batch.
+	_, _ = c.AddJob(cadenceNodeRollout, job.NewNodeRolloutJob())
 
 	// Outbound subscription auto-refresh (respects per-sub updateInterval)
-	_, _ = s.cron.AddJob(cadenceOutboundSub, job.NewOutboundSubscriptionJob())
+	_, _ = c.AddJob(cadenceOutboundSub, job.NewOutboundSubscriptionJob())
+
+	_, _ = c.AddJob(cadenceReapOrphans, job.NewReapSyncOrphansJob())
 
-	_, _ = s.cron.AddJob(cadenceReapOrphans, job.NewReapSyncOrphansJob())
+	_, _ = c.AddJob(cadenceAccessSchedule, job.NewAccessScheduleJob(loc))
 
 	// Warm permanent routing URLs immediately and refresh them outside the
 	// latency-sensitive subscription request path.
 	remoteRoutingJob := job.NewRemoteRoutingJob()
-	_, _ = s.cron.AddJob(cadenceRemoteRouting, remoteRoutingJob)
+	_, _ = c.AddJob(cadenceRemoteRouting, remoteRoutingJob)
 	common.GoRecover("remote-routing-warm", remoteRoutingJob.Run)
 
+	// Renew ACME certificates ahead of expiry; the warm run also re-creates
+	// certificate files missing after a database restore.
+	acmeRenewJob := job.NewAcmeRenewJob()
+	_, _ = c.AddJob(cadenceAcmeRenew, acmeRenewJob)
+	common.GoRecover("acme-renew-warm", acmeRenewJob.Run)
+
+	// Resend failed webhook deliveries once their backoff has elapsed
+	_, _ = c.AddJob(cadenceWebhookRetry, job.NewWebhookRetryJob())
+
 	// check client ips from log file every day
-	_, _ = s.cron.AddJob("@daily", job.NewClearLogsJob())
-	_, _ = s.cron.AddJob(cadenceXrayLogPrune, job.NewPruneXrayLogsJob())
-	_, _ = s.cron.AddJob("@hourly", job.NewWarpIpJob())
+	_, _ = c.AddJob("@daily", job.NewClearLogsJob())
+	_, _ = c.AddJob("@daily", job.NewPruneAuditLogsJob())
+	_, _ = c.AddJob("@hourly", job.NewTrafficHistoryRollupJob())
+	_, _ = c.AddJob(cadenceXrayLogPrune, job.NewPruneXrayLogsJob())
+	_, _ = c.AddJob("@hourly", job.NewWarpIpJob())
 
 	// Inbound traffic reset jobs
 	// Run every hour
-	_, _ = s.cron.AddJob("@hourly", job.NewPeriodicTrafficResetJob("hourly", loc))
+	_, _ = c.AddJob("@hourly", job.NewPeriodicTrafficResetJob("hourly", loc))
 	// Run once a day, midnight
-	_, _ = s.cron.AddJob("@daily", job.NewPeriodicTrafficResetJob("daily", loc))
+	_, _ = c.AddJob("@daily", job.NewPeriodicTrafficResetJob("daily", loc))
 	// Run once a week, midnight between Sat/Sun
-	_, _ = s.cron.AddJob("@weekly", job.NewPeriodicTrafficResetJob("weekly", loc))
+	_, _ = c.AddJob("@weekly", job.NewPeriodicTrafficResetJob("weekly", loc))
 	// Check monthly reset days at midnight
-	_, _ = s.cron.AddJob("@daily", job.NewPeriodicTrafficResetJob("monthly", loc))
+	_, _ = c.AddJob("@daily", job.NewPeriodicTrafficResetJob("monthly", loc))
+
+	// Scheduled database backups to local disk and/or S3
+	if backupEnabled, _ := s.settingService.GetBackupEnable(); backupEnabled {
+		runtime, err := s.settingService.GetBackupCron()
+		if err != nil || strings.TrimSpace(runtime) == "" {
+			runtime = "@daily"
+		}
+		if _, err := c.AddJob(runtime, job.NewBackupJob()); err != nil {
+			logger.Warningf("Add BackupJob: failed to schedule %q: %v", runtime, err)
+		}
+	}
 
 	// LDAP sync scheduling
 	if ldapEnabled, _ := s.settingService.GetLdapEnable(); ldapEnabled {
@@ -373,7 +431,7 @@ func (s *Server) startTask(restartXray bool, loc *time.Location) {
 		}
 		j := job.NewLdapSyncJob()
 		// job has zero-value services with method receivers that read settings on demand
-		_, _ = s.cron.AddJob(runtime, j)
+		_, _ = c.AddJob(runtime, j)
 	}
 
 	// Telegram-bot–dependent jobs: periodic stats report + callback-hash cleanup.
@@ -388,14 +446,18 @@ func (s *Server) startTask(restartXray bool, loc *time.Location) {
 			runtime = "@daily"
 		}
 		logger.Infof("Tg notify enabled,run at %s", runtime)
-		if _, err = s.cron.AddJob(runtime, job.NewStatsNotifyJob()); err != nil {
+		if _, err = c.AddJob(runtime, job.NewStatsNotifyJob()); err != nil {
 			logger.Warningf("Add NewStatsNotifyJob: failed to schedule runtime %q: %v", runtime, err)
 		}
 
 		// check for Telegram bot callback query hash storage reset
-		_, _ = s.cron.AddJob(cadenceCheckHash, job.NewCheckHashStorageJob())
+		_, _ = c.AddJob(cadenceCheckHash, job.NewCheckHashStorageJob())
 	}
+}
 
+// startLocalTask registers the jobs that watch this host only, which every
+// panel of an HA pair runs.
+func (s *Server) startLocalTask() {
 	// CPU monitor publishes cpu.high events; register it whenever any notifier
 	// (Telegram or Email) wants them, independent of the Telegram bot being on.
 	if s.cpuAlarmWanted() {
@@ -415,6 +477,89 @@ func (s *Server) startTask(restartXray bool, loc *time.Location) {
 	}
 }
 
+// newJobCron builds a scheduler with the panel's job chain.
+func newJobCron(loc *time.Location) *cron.Cron {
+	// SkipIfStillRunning stops a slow job (e.g. the 5s traffic poll on a large
+	// install) from overlapping itself: two concurrent runs of the same job race
+	// the shared xrayAPI — leaking a grpc connection — and the StatsLastValues
+	// map, whose concurrent write is a fatal runtime throw cron.Recover can't
+	// catch. cron.Recover then logs any panic and keeps the scheduler alive.
+	return cron.New(
+		cron.WithLocation(loc),
+		cron.WithSeconds(),
+		cron.WithChain(
+			cron.SkipIfStillRunning(cron.DiscardLogger),
+			cron.Recover(cron.PrintfLogger(cronPanicLogger{})),
+		),
+	)
+}
+
+// startLeaderDuties starts Xray, the leader jobs and the Telegram bot. With
+// HA it runs on each promotion; otherwise once at startup.
+func (s *Server) startLeaderDuties(restartXray, startTgBot bool, loc *time.Location) {
+	s.leaderMu.Lock()
+	defer s.leaderMu.Unlock()
+	if s.leaderCron != nil {
+		return
+	}
+	s.leaderCron = newJobCron(loc)
+	s.leaderCron.Start()
+	s.startTask(restartXray, loc)
+
+	if startTgBot {
+		isTgbotenabled, err := s.settingService.GetTgbotEnabled()
+		if (err == nil) && isTgbotenabled {
+			tgBot := s.tgbotService.NewTgbot()
+			_ = tgBot.Start(i18nFS)
+			// Subscribe Telegram notifications for event bus
+			s.bus.Subscribe("tg-notifier", s.tgbotService.HandleEvent)
+		}
+	}
+}
+
+// stopLeaderDuties is the demotion path: once it returns this panel runs no
+// leader job, no Xray and no bot, so the new leader can take them over.
+func (s *Server) stopLeaderDuties() {
+	s.leaderMu.Lock()
+	defer s.leaderMu.Unlock()
+	if s.leaderCron == nil {
+		return
+	}
+	<-s.leaderCron.Stop().Done()
+	s.leaderCron = nil
+	job.StopNodeTelemetry()
+	_ = s.xrayService.StopXray()
+	mtproto.GetManager().StopAll()
+	if s.tgbotService.IsRunning() {
+		s.tgbotService.Stop()
+	}
+	if s.bus != nil {
+		s.bus.Unsubscribe("tg-notifier")
+	}
+}
+
+// startHA joins leader election. The panel starts as a standby and takes on
+// the leader duties when it wins the lock; a demotion stops them again.
+func (s *Server) startHA(loc *time.Location) error {
+	if !database.IsPostgres() {
+		return fmt.Errorf("XUI_HA requires XUI_DB_TYPE=postgres")
+	}
+	err := ha.Start(config.GetDBDSN(), config.GetHANodeName(),
+		func() { s.startLeaderDuties(true, true, loc) },
+		s.stopLeaderDuties,
+	)
+	if err != nil {
+		return err
+	}
+	// Browsers on the standby learn about the leader's changes through the
+	// same invalidate messages its own mutations would send.
+	for _, t := range websocket.ReplicatedTypes {
+		ha.Subscribe(string(t), func() { websocket.BroadcastInvalidate(t) })
+	}
+	logger.Infof("HA enabled as %q: waiting for the leader lock", config.GetHANodeName())
+	return nil
+}
+
 // cpuAlarmWanted reports whether any notifier is configured to receive cpu.high
 // alerts, so the minute-long blocking CPU sampler only runs when it's needed.
 func (s *Server) cpuAlarmWanted() bool {
@@ -443,6 +588,12 @@ func (s *Server) cpuAlarmWanted() bool {
 			return true
 		}
 	}
+	if cpu, _ := s.settingService.GetWebhookCpu(); cpu > 0 {
+		webhookService := service.WebhookService{}
+		if webhookService.Wants(eventbus.EventCPUHigh) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -473,6 +624,12 @@ func (s *Server) memoryAlarmWanted() bool {
 			return true
 		}
 	}
+	if mem, _ := s.settingService.GetWebhookMemory(); mem > 0 {
+		webhookService := service.WebhookService{}
+		if webhookService.Wants(eventbus.EventMemoryHigh) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -499,19 +656,7 @@ func (s *Server) start(restartXray bool, startTgBot bool) (err error) {
 	}
 	service.StartTrafficWriter()
 
-	// SkipIfStillRunning stops a slow job (e.g. the 5s traffic poll on a large
-	// install) from overlapping itself: two concurrent runs of the same job race
-	// the shared xrayAPI — leaking a grpc connection — and the StatsLastValues
-	// map, whose concurrent write is a fatal runtime throw cron.Recover can't
-	// catch. cron.Recover then logs any panic and keeps the scheduler alive.
-	s.cron = cron.New(
-		cron.WithLocation(loc),
-		cron.WithSeconds(),
-		cron.WithChain(
-			cron.SkipIfStillRunning(cron.DiscardLogger),
-			cron.Recover(cron.PrintfLogger(cronPanicLogger{})),
-		),
-	)
+	s.cron = newJobCron(loc)
 	s.cron.Start()
 
 	// Wire the inbound-runtime manager once so InboundService can route
@@ -538,6 +683,12 @@ func (s *Server) start(restartXray bool, startTgBot bool) (err error) {
 		return err
 	}
 
+	// ACME certificates live in the DB; bring their files back before the
+	// panel and sub listeners load them.
+	if err := (&service.AcmeService{}).EnsureFiles(); err != nil {
+		logger.Warning("ACME: restore certificate files:", err)
+	}
+
 	certFile, err := s.settingService.GetCertFile()
 	if err != nil {
 		return err
@@ -568,10 +719,11 @@ func (s *Server) start(restartXray bool, startTgBot bool) (err error) {
 		return err
 	}
 	if certFile != "" || keyFile != "" {
-		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		reloader, err := network.NewCertReloader(certFile, keyFile)
 		if err == nil {
 			c := &tls.Config{
-				Certificates: []tls.Certificate{cert},
+				GetCertificate:     reloader.GetCertificate,
+				GetConfigForClient: acme.DefaultResponder.GetConfigForClient,
 			}
 			// Opt-in node mTLS: when a trust CA is configured, request and verify
 			// client certs (VerifyClientCertIfGiven keeps browsers working). With
@@ -625,6 +777,14 @@ func (s *Server) start(restartXray bool, startTgBot bool) (err error) {
 	emailSub := email.NewSubscriber(s.settingService, emailService)
 	s.bus.Subscribe("email-notifier", emailSub.HandleEvent)
 
+	// Register webhook subscriber (always — it loads enabled webhooks per event)
+	webhookService := service.WebhookService{}
+	s.bus.Subscribe("webhook-notifier", webhookService.HandleEvent)
+
+	// Time node transitions for the failover hysteresis
+	failoverService := service.FailoverService{}
+	s.bus.Subscribe("node-failover", failoverService.HandleEvent)
+
 	// Wire email service to controller for test endpoint
 	controller.SetEmailService(emailService)
 
@@ -641,6 +801,9 @@ func (s *Server) start(restartXray bool, startTgBot bool) (err error) {
 	})
 
 	controller.SetReloadTgbotFunc(func() {
+		if ha.IsStandby() {
+			return
+		}
 		enabled, err := s.settingService.GetTgbotEnabled()
 		if err != nil || !enabled {
 			if s.tgbotService.IsRunning() {
@@ -662,18 +825,11 @@ func (s *Server) start(restartXray bool, startTgBot bool) (err error) {
 		}
 	})
 
-	s.startTask(restartXray, loc)
-
-	if startTgBot {
-		isTgbotenabled, err := s.settingService.GetTgbotEnabled()
-		if (err == nil) && isTgbotenabled {
-			tgBot := s.tgbotService.NewTgbot()
-			_ = tgBot.Start(i18nFS)
-			// Subscribe Telegram notifications for event bus
-			s.bus.Subscribe("tg-notifier", s.tgbotService.HandleEvent)
-		}
+	s.startLocalTask()
+	if config.GetHAEnable() {
+		return s.startHA(loc)
 	}
-
+	s.startLeaderDuties(restartXray, startTgBot, loc)
 	return nil
 }
 
@@ -688,6 +844,16 @@ func (s *Server) StopPanelOnly() error {
 
 func (s *Server) stop(stopXray bool, stopTgBot bool) error {
 	s.cancel()
+	// HA hands leadership over on any stop, so Xray stops even on a panel-only
+	// restart; otherwise two would run once the standby takes over.
+	ha.Stop()
+	s.leaderMu.Lock()
+	if s.leaderCron != nil {
+		s.leaderCron.Stop()
+		s.leaderCron = nil
+		job.StopNodeTelemetry()
+	}
+	s.leaderMu.Unlock()
 	if stopXray {
 		_ = s.xrayService.StopXray()
 		mtproto.GetManager().StopAll()
@@ -707,6 +873,7 @@ func (s *Server) stop(stopXray bool, stopTgBot bool) error {
 	if stopTgBot && s.tgbotService.IsRunning() {
 		s.tgbotService.Stop()
 	}
+	service.StopTelemetryUplinks()
 	// Gracefully stop WebSocket hub
 	if s.wsHub != nil {
 		s.wsHub.Stop()
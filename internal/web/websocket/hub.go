@@ -24,6 +24,7 @@ const (
 	MessageTypeClientStats  MessageType = "client_stats"
 	MessageTypeClients      MessageType = "clients"
 	MessageTypeInvalidate   MessageType = "invalidate"
+	MessageTypeNodeRollout  MessageType = "node_rollout"
 	maxMessageSize                      = 10 * 1024 * 1024 // 10MB
 
 	enqueueTimeout       = 100 * time.Millisecond
@@ -2,6 +2,7 @@
 package websocket
 
 import (
+	"github.com/mhsanaei/3x-ui/v3/internal/ha"
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
 	"github.com/mhsanaei/3x-ui/v3/internal/web/global"
 )
@@ -56,21 +57,36 @@ func BroadcastClientStats(stats any) {
 	}
 }
 
+// ReplicatedTypes are the data types whose changes an HA leader forwards to
+// the standby, which then tells its own browsers to re-fetch them.
+var ReplicatedTypes = []MessageType{MessageTypeInbounds, MessageTypeClients, MessageTypeOutbounds, MessageTypeNodes}
+
 // BroadcastInbounds broadcasts inbounds list update to all connected clients.
 func BroadcastInbounds(inbounds any) {
+	ha.Publish(string(MessageTypeInbounds))
 	if hub := GetHub(); hub != nil {
 		hub.Broadcast(MessageTypeInbounds, inbounds)
 	}
 }
 
 func BroadcastNodes(nodes any) {
+	ha.Publish(string(MessageTypeNodes))
 	if hub := GetHub(); hub != nil {
 		hub.Broadcast(MessageTypeNodes, nodes)
 	}
 }
 
+// BroadcastNodeRollout pushes the current state of a node rollout, so the
+// nodes page can follow its progress without polling.
+func BroadcastNodeRollout(rollout any) {
+	if hub := GetHub(); hub != nil {
+		hub.Broadcast(MessageTypeNodeRollout, rollout)
+	}
+}
+
 // BroadcastOutbounds broadcasts outbounds list update to all connected clients.
 func BroadcastOutbounds(outbounds any) {
+	ha.Publish(string(MessageTypeOutbounds))
 	if hub := GetHub(); hub != nil {
 		hub.Broadcast(MessageTypeOutbounds, outbounds)
 	}
@@ -106,6 +122,7 @@ func BroadcastXrayState(state string, errorMsg string) {
 // payload is too large to push directly (e.g., 10k+ clients) to skip the
 // JSON-marshal cost on the hot path.
 func BroadcastInvalidate(dataType MessageType) {
+	ha.Publish(string(dataType))
 	if hub := GetHub(); hub != nil {
 		hub.broadcastInvalidate(dataType)
 	}
@@ -9,14 +9,20 @@ import (
 	"log"
 	"net/http"
 	_ "net/http/pprof"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
+	"time"
 	_ "unsafe"
 
 	"github.com/mhsanaei/3x-ui/v3/internal/config"
 	"github.com/mhsanaei/3x-ui/v3/internal/crypto/nodetoken"
 	"github.com/mhsanaei/3x-ui/v3/internal/database"
+	"github.com/mhsanaei/3x-ui/v3/internal/database/model"
+	"github.com/mhsanaei/3x-ui/v3/internal/importer"
 	"github.com/mhsanaei/3x-ui/v3/internal/logger"
 	"github.com/mhsanaei/3x-ui/v3/internal/sub"
 	"github.com/mhsanaei/3x-ui/v3/internal/tunnelmonitor"
@@ -48,12 +54,9 @@ func initNodeTokenCrypto() error {
 		nodetoken.Init(c)
 		return nil
 	}
-	ring, ferr := (nodetoken.FileKeySource{Path: config.GetNodeTokenKeyFile()}).Load()
-	if ferr != nil {
-		var eerr error
-		if ring, eerr = (nodetoken.EnvKeySource{Var: config.GetNodeTokenKeyEnv()}).Load(); eerr != nil {
-			return fmt.Errorf("load node-token key: file: %w; env: %w", ferr, eerr)
-		}
+	ring, _, err := loadNodeTokenKeyring()
+	if err != nil {
+		return err
 	}
 	c, err := nodetoken.NewCodec(mode, ring)
 	if err != nil {
@@ -65,6 +68,20 @@ func initNodeTokenCrypto() error {
 	return nil
 }
 
+// loadNodeTokenKeyring reads the key file, falling back to the environment.
+// fromFile tells rotate-key whether the keyring already lives in the file.
+func loadNodeTokenKeyring() (ring *nodetoken.Keyring, fromFile bool, err error) {
+	ring, ferr := (nodetoken.FileKeySource{Path: config.GetNodeTokenKeyFile()}).Load()
+	if ferr == nil {
+		return ring, true, nil
+	}
+	ring, eerr := (nodetoken.EnvKeySource{Var: config.GetNodeTokenKeyEnv()}).Load()
+	if eerr != nil {
+		return nil, false, fmt.Errorf("load node-token key: file: %w; env: %w", ferr, eerr)
+	}
+	return ring, false, nil
+}
+
 // runWebServer initializes and starts the web server for the 3x-ui panel.
 func runWebServer() {
 	log.Printf("Starting %v %v", config.GetName(), config.GetPanelVersion())
@@ -107,6 +124,18 @@ func runWebServer() {
 	if err != nil {
 		log.Fatalf("Error initializing database: %v", err)
 	}
+	if nodetoken.Enabled() {
+		// Seal secrets stored before encryption was turned on, or under a key
+		// that has since been rotated out of the active slot.
+		// A failure leaves the values as they are, so it never stops the boot.
+		changed, _, err := (&service.SettingService{}).MigrateSecretsToActiveKey()
+		if err != nil {
+			log.Printf("Error encrypting stored secrets: %v", err)
+		}
+		if changed > 0 {
+			log.Printf("encrypted %d stored secret(s) with key %s", changed, nodetoken.Active().ActiveKeyID())
+		}
+	}
 
 	server := web.NewServer()
 	global.SetWebServer(server)
@@ -357,6 +386,83 @@ func encryptNodeTokens() {
 	fmt.Printf("node-token migration complete: %d re-encrypted, %d already current/skipped\n", changed, skipped)
 }
 
+// rotateSecretKey keeps old keys in the file for decryption unless prune is
+// set and nothing still depends on them.
+func rotateSecretKey(prune bool) {
+	_ = godotenv.Load()
+	mode, err := nodetoken.ParseMode(config.GetNodeTokenEncryptionMode())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if mode == nodetoken.ModeOff {
+		fmt.Println("encryption is off; set NODE_TOKEN_ENCRYPTION=migration|required first")
+		os.Exit(1)
+	}
+	ring, fromFile, err := loadNodeTokenKeyring()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := database.InitDB(config.GetDBPath()); err != nil {
+		fmt.Println("database initialization failed:", err)
+		os.Exit(1)
+	}
+
+	next, err := ring.Rotated(time.Now())
+	if err != nil {
+		fmt.Println("key generation failed:", err)
+		os.Exit(1)
+	}
+	keyFile := config.GetNodeTokenKeyFile()
+	// Write the file before touching any row: every value is then readable
+	// with the keys on disk, even if the migration below stops half way.
+	if err := nodetoken.WriteKeyFile(keyFile, next); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("new active key %s written to %s\n", next.ActiveID, keyFile)
+	if !fromFile {
+		fmt.Printf("the keyring now lives in the key file; remove %s from the environment\n", config.GetNodeTokenKeyEnv())
+	}
+	codec, err := nodetoken.NewCodec(mode, next)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	nodetoken.Init(codec)
+
+	tokens, _, err := (&service.NodeService{}).MigrateNodeTokensToActiveKey()
+	if err != nil {
+		fmt.Println("node-token re-encryption failed:", err)
+		os.Exit(1)
+	}
+	secrets, _, err := (&service.SettingService{}).MigrateSecretsToActiveKey()
+	if err != nil {
+		fmt.Println("secret re-encryption failed:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("re-encrypted %d node token(s) and %d secret(s)\n", tokens, secrets)
+
+	if prune {
+		stale, err := service.CountStaleSecrets()
+		if err != nil {
+			fmt.Println("could not verify re-encryption:", err)
+			os.Exit(1)
+		}
+		if stale > 0 {
+			fmt.Printf("%d value(s) changed during rotation and still use an old key; run rotate-key again before pruning\n", stale)
+			os.Exit(1)
+		}
+		if err := nodetoken.WriteKeyFile(keyFile, next.Pruned()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("old keys removed from the key file")
+	}
+	fmt.Println("restart the panel to load the new key")
+}
+
 // updateSetting updates various panel settings including port, credentials, base path, listen IP, and two-factor authentication.
 func updateSetting(port int, username string, password string, webBasePath string, listenIP string, resetTwoFactor bool) error {
 	err := database.InitDB(config.GetDBPath())
@@ -396,12 +502,11 @@ func updateSetting(port int, username string, password string, webBasePath strin
 	}
 
 	if resetTwoFactor {
-		err := settingService.SetTwoFactorEnable(false)
+		err := userService.ResetTwoFactor()
 
 		if err != nil {
 			fmt.Println("Failed to reset two-factor authentication:", err)
 		} else {
-			_ = settingService.SetTwoFactorToken("")
 			fmt.Println("Two-factor authentication reset successfully")
 		}
 	}
@@ -545,6 +650,211 @@ func migrateDb() {
 	fmt.Println("Migration done!")
 }
 
+// exportState writes the panel's declarative state document to out, or to
+// stdout when out is empty.
+func exportState(out string, asJSON bool) {
+	if err := initNodeTokenCrypto(); err != nil {
+		fmt.Println("node-token encryption init failed:", err)
+		os.Exit(1)
+	}
+	if err := database.InitDB(config.GetDBPath()); err != nil {
+		fmt.Println("database initialization failed:", err)
+		os.Exit(1)
+	}
+	doc, err := (&service.StateService{}).Export()
+	if err != nil {
+		fmt.Println("export failed:", err)
+		os.Exit(1)
+	}
+	data, err := service.MarshalStateDocument(doc, asJSON)
+	if err != nil {
+		fmt.Println("export failed:", err)
+		os.Exit(1)
+	}
+	if out == "" {
+		_, _ = os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(out, data, 0o600); err != nil {
+		fmt.Println("export failed:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("State written to %s\n", out)
+}
+
+// applyState makes the panel match the state document in file. With planOnly
+// it prints the changes without making them.
+func applyState(file string, planOnly bool) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	doc, err := service.ParseStateDocument(data)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := initNodeTokenCrypto(); err != nil {
+		fmt.Println("node-token encryption init failed:", err)
+		os.Exit(1)
+	}
+	if err := database.InitDB(config.GetDBPath()); err != nil {
+		fmt.Println("database initialization failed:", err)
+		os.Exit(1)
+	}
+	stateService := service.StateService{}
+	plan, err := stateService.Plan(doc)
+	if err != nil {
+		fmt.Println("plan failed:", err)
+		os.Exit(1)
+	}
+	for _, ch := range plan.Changes {
+		line := fmt.Sprintf("%-7s %s %s", ch.Action, ch.Kind, ch.Key)
+		if len(ch.Fields) > 0 {
+			line += " (" + strings.Join(ch.Fields, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+	for _, e := range plan.Errors {
+		fmt.Println("error:", e)
+	}
+	if len(plan.Errors) > 0 {
+		os.Exit(1)
+	}
+	if len(plan.Changes) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+	if planOnly {
+		fmt.Printf("%d change(s) planned.\n", len(plan.Changes))
+		return
+	}
+	result, err := stateService.Apply(doc)
+	if result != nil {
+		fmt.Printf("%d of %d change(s) applied.\n", result.Applied, len(result.Changes))
+	}
+	if err != nil {
+		fmt.Println("apply failed:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Restart the panel to load the changes into Xray: x-ui restart")
+}
+
+// importPanel imports another panel read from in; mapping sends source inbound
+// tags onto existing ones and dryRun only prints what would happen.
+func importPanel(source string, in importer.Input, mapping map[string]string, dryRun bool) {
+	bundle, err := importer.Read(source, in)
+	if err != nil {
+		fmt.Println("reading the source failed:", err)
+		os.Exit(1)
+	}
+	if err := initNodeTokenCrypto(); err != nil {
+		fmt.Println("node-token encryption init failed:", err)
+		os.Exit(1)
+	}
+	if err := database.InitDB(config.GetDBPath()); err != nil {
+		fmt.Println("database initialization failed:", err)
+		os.Exit(1)
+	}
+	report, _, err := (&service.PanelImportService{}).Import(bundle, mapping, dryRun)
+	if report != nil {
+		for _, ib := range report.Inbounds {
+			line := fmt.Sprintf("%-6s inbound %s", ib.Action, ib.Source)
+			if ib.Target != "" && ib.Target != ib.Source {
+				line += " -> " + ib.Target
+			}
+			if ib.Reason != "" {
+				line += ": " + ib.Reason
+			}
+			fmt.Println(line)
+		}
+		for _, sk := range report.Skipped {
+			fmt.Printf("skip   client %s: %s\n", sk.Email, sk.Reason)
+		}
+		for _, w := range report.Warnings {
+			fmt.Println("warning:", w)
+		}
+		verb := "imported"
+		if dryRun {
+			verb = "would be imported"
+		}
+		fmt.Printf("%d client(s) %s, %d skipped.\n", report.Created, verb, len(report.Skipped))
+	}
+	if err != nil {
+		fmt.Println("import failed:", err)
+		os.Exit(1)
+	}
+	if !dryRun {
+		fmt.Println("Restart the panel to load the changes into Xray: x-ui restart")
+	}
+}
+
+// joinMaster enrolls this panel as a node of masterURL with a join token, minting
+// the node-sync API token and trusting the master's client certificate CA.
+func joinMaster(masterURL, token, pin, address, name string) {
+	if err := database.InitDB(config.GetDBPath()); err != nil {
+		fmt.Println("database initialization failed:", err)
+		os.Exit(1)
+	}
+	nodeService := service.NodeService{}
+	req, err := nodeService.LocalJoinRequest()
+	if err != nil {
+		fmt.Println("reading the panel settings failed:", err)
+		os.Exit(1)
+	}
+	req.Token = token
+	req.Address = address
+	if name != "" {
+		req.Name = name
+	}
+
+	// One token per master: joining the same master again replaces it.
+	tokenName := "master"
+	if u, err := url.Parse(masterURL); err == nil && u.Host != "" {
+		tokenName += " " + u.Host
+	}
+	tokenName = tokenName[:min(len(tokenName), 64)]
+	apiTokenService := panel.ApiTokenService{}
+	tokens, err := apiTokenService.List()
+	if err != nil {
+		fmt.Println("listing API tokens failed:", err)
+		os.Exit(1)
+	}
+	for _, t := range tokens {
+		if t.Name == tokenName {
+			if err := apiTokenService.Delete(t.Id); err != nil {
+				fmt.Println("replacing the previous master token failed:", err)
+				os.Exit(1)
+			}
+		}
+	}
+	created, err := apiTokenService.Create(tokenName, model.ApiScopeNodeSync, 0)
+	if err != nil {
+		fmt.Println("creating the API token for the master failed:", err)
+		os.Exit(1)
+	}
+	req.ApiToken = created.Token
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resp, err := nodeService.JoinMaster(ctx, masterURL, strings.ToLower(strings.TrimSpace(pin)), req)
+	if err != nil {
+		_ = apiTokenService.Delete(created.Id)
+		fmt.Println("join failed:", err)
+		os.Exit(1)
+	}
+	changed, err := nodeService.TrustMasterCA(resp.CaCert)
+	if err != nil {
+		fmt.Println("joined, but trusting the master CA failed:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Joined as node %q (id %d).\n", resp.Name, resp.NodeId)
+	if changed {
+		fmt.Println("Restart the panel to accept the master's client certificate: x-ui restart")
+	}
+}
+
 // loadServiceEnvFile loads the systemd EnvironmentFile so CLI subcommands like
 // "x-ui setting" hit the same database backend as the panel. godotenv.Load does
 // not override variables already in the environment, so it is a no-op for the
@@ -588,6 +898,46 @@ func main() {
 	migrateDbCmd.StringVar(&migrateRestore, "restore", "", "Rebuild a SQLite database from this SQL text dump (.dump -> .db); requires --out")
 	migrateDbCmd.StringVar(&migrateOut, "out", "", "Destination SQLite file for --restore (must not already exist)")
 
+	rotateKeyCmd := flag.NewFlagSet("rotate-key", flag.ExitOnError)
+	var rotatePrune bool
+	rotateKeyCmd.BoolVar(&rotatePrune, "prune", false, "Drop the old keys from the key file once nothing uses them (stop the panel first)")
+
+	exportStateCmd := flag.NewFlagSet("export-state", flag.ExitOnError)
+	var exportOut string
+	var exportFormat string
+	exportStateCmd.StringVar(&exportOut, "o", "", "Write the state document to this file instead of stdout")
+	exportStateCmd.StringVar(&exportFormat, "format", "yaml", "Document format: yaml or json")
+
+	applyCmd := flag.NewFlagSet("apply", flag.ExitOnError)
+	var applyFile string
+	var applyPlan bool
+	applyCmd.StringVar(&applyFile, "f", "", "State document to apply (YAML or JSON)")
+	applyCmd.BoolVar(&applyPlan, "plan", false, "Print the changes without making them")
+
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	var importSource string
+	var importIn importer.Input
+	var importMap string
+	var importDryRun bool
+	importCmd.StringVar(&importSource, "source", "", "Source panel: "+strings.Join(importer.Sources, ", "))
+	importCmd.StringVar(&importIn.Path, "f", "", "Source SQLite database, or the backup file for hiddify")
+	importCmd.StringVar(&importIn.DSN, "dsn", "", "Source PostgreSQL DSN, instead of -f")
+	importCmd.StringVar(&importIn.XrayConfig, "xray-config", "", "Marzban's xray_config.json, to recreate its inbounds")
+	importCmd.StringVar(&importMap, "map", "", "Put source inbounds onto existing ones instead of creating them: src=dst,src2=dst2")
+	importCmd.BoolVar(&importDryRun, "dry-run", false, "Print what would be imported without writing anything")
+
+	joinCmd := flag.NewFlagSet("join", flag.ExitOnError)
+	var joinPin string
+	var joinAddress string
+	var joinName string
+	joinCmd.StringVar(&joinPin, "pin", "", "SHA-256 of the master's certificate, when it is not publicly trusted")
+	joinCmd.StringVar(&joinAddress, "address", "", "Address the master should reach this panel at (defaults to the one the request comes from)")
+	joinCmd.StringVar(&joinName, "name", "", "Node name, unless the join token sets one (defaults to the hostname)")
+	joinCmd.Usage = func() {
+		fmt.Fprintln(joinCmd.Output(), "Usage: x-ui join [-pin sha256] [-address host] [-name name] <master-url> <token>")
+		joinCmd.PrintDefaults()
+	}
+
 	settingCmd := flag.NewFlagSet("setting", flag.ExitOnError)
 	var port int
 	var username string
@@ -648,6 +998,12 @@ func main() {
 		migrateDb()
 	case "encrypt-tokens":
 		encryptNodeTokens()
+	case "rotate-key":
+		if err := rotateKeyCmd.Parse(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		rotateSecretKey(rotatePrune)
 	case "migrate-db":
 		if err := migrateDbCmd.Parse(os.Args[2:]); err != nil {
 			fmt.Println(err)
@@ -682,6 +1038,62 @@ func main() {
 		default:
 			fmt.Println("nothing to do: pass --dump <file>, --restore <file> --out <db>, or --dsn <postgres-dsn>")
 		}
+	case "export-state":
+		if err := exportStateCmd.Parse(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if exportFormat != "yaml" && exportFormat != "json" {
+			fmt.Println("--format must be yaml or json")
+			return
+		}
+		exportState(exportOut, exportFormat == "json")
+	case "apply":
+		if err := applyCmd.Parse(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if applyFile == "" {
+			fmt.Println("-f is required: the state document to apply")
+			return
+		}
+		applyState(applyFile, applyPlan)
+	case "import":
+		if err := importCmd.Parse(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if !slices.Contains(importer.Sources, importSource) {
+			fmt.Println("-source must be one of:", strings.Join(importer.Sources, ", "))
+			return
+		}
+		if importIn.Path == "" && importIn.DSN == "" {
+			fmt.Println("-f or -dsn is required: where to read the source panel from")
+			return
+		}
+		mapping := map[string]string{}
+		for pair := range strings.SplitSeq(importMap, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			src, dst, ok := strings.Cut(pair, "=")
+			if !ok || src == "" || dst == "" {
+				fmt.Printf("-map: %q is not src=dst\n", pair)
+				return
+			}
+			mapping[strings.TrimSpace(src)] = strings.TrimSpace(dst)
+		}
+		importPanel(importSource, importIn, mapping, importDryRun)
+	case "join":
+		if err := joinCmd.Parse(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if joinCmd.NArg() != 2 {
+			joinCmd.Usage()
+			return
+		}
+		joinMaster(joinCmd.Arg(0), joinCmd.Arg(1), joinPin, joinAddress, joinName)
 	case "setting":
 		err := settingCmd.Parse(os.Args[2:])
 		if err != nil {
@@ -745,6 +1157,11 @@ Commands:
     migrate        migrate from other/old x-ui
     migrate-db     SQLite <-> .dump (--dump/--restore) or copy into PostgreSQL (--dsn)
     encrypt-tokens encrypt node bearer tokens with the configured active key
+    rotate-key     make a new key active and re-encrypt node tokens and stored secrets
+    export-state   write inbounds, clients, nodes and settings as a YAML/JSON state document
+    apply          make the panel match a state document (-f file, --plan to preview)
+    import         import users and inbounds from Marzban, Hiddify, Remnawave or x-ui (--dry-run to preview)
+    join           enroll this panel as a node of a master: join <master-url> <token>
     setting        set settings
 `
 }
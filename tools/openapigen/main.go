@@ -39,6 +39,17 @@ func run(root, outDir string) error {
 				"ClientInbound",
 				"InboundFallback",
 				"Host",
+				"AcmeCertificate",
+				"AuditLog",
+				"Webhook",
+				"WebhookDelivery",
+				"Plan",
+				"Voucher",
+				"AuditChange",
+				"NodeJoinToken",
+				"NodeOutboxEntry",
+				"NodeRollout",
+				"NodeRolloutStep",
 			),
 			AliasAllow: setOf("Protocol"),
 			Overrides: map[string][]walkOverride{
@@ -66,6 +77,7 @@ func run(root, outDir string) error {
 				"AllSetting",
 				"AllSettingView",
 				"HostGroup",
+				"AcmeIssueRequest",
 			),
 		},
 		{
@@ -94,11 +106,29 @@ func run(root, outDir string) error {
 				"ProbeResultUI",
 				"RealityScanResult",
 				"GeodataTokenIssue",
+				"ResellerUsage",
+				"AuditPage",
+				"WebhookDeliveryPage",
+				"TrafficHistoryPoint",
+				"BackupManifest",
+				"ConfigPreview",
+				"RuntimePreview",
+				"StatePlan",
+				"StateChange",
+				"StateApplyResult",
+				"ImportReport",
+				"ImportInboundReport",
+				"BulkCreateReport",
+				"NodeJoinTokenCreated",
 			),
 		},
 		{
 			Path:        resolveRel(root, "internal/web/service/panel"),
-			StructAllow: setOf("ApiTokenView", "PanelUpdateStatus"),
+			StructAllow: setOf("ApiTokenView", "PanelUpdateStatus", "UpdateVerification", "UserView", "UserAccountRequest"),
+		},
+		{
+			Path:        resolveRel(root, "internal/ha"),
+			StructAllow: setOf("HAStatus"),
 		},
 	}
 
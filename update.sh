@@ -40,6 +40,11 @@ _fail() {
 xui_update_run_id="${XUI_UPDATE_RUN_ID:-0}"
 [[ "${xui_update_run_id}" =~ ^[0-9]+$ ]] || xui_update_run_id="0"
 xui_update_status_file="${XUI_UPDATE_STATUS_FILE:-/etc/x-ui/update-status.json}"
+# The panel checks the release against its signed manifest before launching
+# this script and passes the result along as a JSON object, which is copied
+# into the status file verbatim.
+xui_update_verification="${XUI_UPDATE_VERIFICATION:-null}"
+[[ "${xui_update_verification}" == "{"*"}" ]] || xui_update_verification="null"
 
 _write_update_status() {
     local state="$1"
@@ -48,8 +53,8 @@ _write_update_status() {
     status_dir="$(dirname "${xui_update_status_file}")"
     mkdir -p "${status_dir}" > /dev/null 2>&1
     local tmp_file="${xui_update_status_file}.tmp.$$"
-    printf '{"runId":"%s","state":"%s","exitCode":%s,"finishedAt":%s}\n' \
-        "${xui_update_run_id}" "${state}" "${exit_code}" "$(date +%s)" > "${tmp_file}" 2> /dev/null
+    printf '{"runId":"%s","state":"%s","exitCode":%s,"finishedAt":%s,"verification":%s}\n' \
+        "${xui_update_run_id}" "${state}" "${exit_code}" "$(date +%s)" "${xui_update_verification}" > "${tmp_file}" 2> /dev/null
     mv -f "${tmp_file}" "${xui_update_status_file}" > /dev/null 2>&1
 }
 
@@ -993,9 +998,19 @@ update_x-ui() {
         fi
     fi
     echo -e "Got x-ui latest version: ${tag_version}, beginning the installation..."
-    ${curl_bin} -fLRo ${xui_folder}-linux-$(arch).tar.gz https://github.com/MHSanaei/3x-ui/releases/download/${tag_version}/x-ui-linux-$(arch).tar.gz 2> /dev/null
-    if [[ $? -ne 0 ]]; then
-        _fail "ERROR: Failed to download x-ui, please be sure that your server can access GitHub"
+    # XUI_UPDATE_ARCHIVE is the release archive the panel already downloaded
+    # and verified against the signed manifest; use it instead of fetching an
+    # unverified copy.
+    if [[ -n "${XUI_UPDATE_ARCHIVE}" ]]; then
+        mv -f "${XUI_UPDATE_ARCHIVE}" ${xui_folder}-linux-$(arch).tar.gz
+        if [[ $? -ne 0 ]]; then
+            _fail "ERROR: Failed to move the verified x-ui release archive into place"
+        fi
+    else
+        ${curl_bin} -fLRo ${xui_folder}-linux-$(arch).tar.gz https://github.com/MHSanaei/3x-ui/releases/download/${tag_version}/x-ui-linux-$(arch).tar.gz 2> /dev/null
+        if [[ $? -ne 0 ]]; then
+            _fail "ERROR: Failed to download x-ui, please be sure that your server can access GitHub"
+        fi
     fi
     if [[ ! -s ${xui_folder}-linux-$(arch).tar.gz ]]; then
         rm ${xui_folder}-linux-$(arch).tar.gz -f > /dev/null 2>&1
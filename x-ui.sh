@@ -3376,6 +3376,29 @@ migrate_db_prompt() {
     migrate_db "$input" "$output"
 }
 
+join_master() {
+    local bin="${xui_folder}/x-ui"
+
+    if [[ ! -x "$bin" ]]; then
+        LOGE "x-ui binary not found at ${bin}. Is the panel installed?"
+        return 1
+    fi
+
+    if ! "$bin" join -h 2>&1 | grep -q -- '-pin'; then
+        LOGE "This x-ui build cannot join a master yet. Update the panel first (x-ui update)."
+        return 1
+    fi
+
+    if [[ $# -lt 2 ]]; then
+        echo -e "Usage: ${green}x-ui join [-pin sha256] [-address host] [-name name] <master-url> <token>${plain}"
+        return 1
+    fi
+
+    "$bin" join "$@" || return 1
+    # The panel only starts accepting the master's client certificate after a restart.
+    restart 0
+}
+
 show_usage() {
     echo -e "┌────────────────────────────────────────────────────────────────┐
 │  ${blue}x-ui control menu usages (subcommands):${plain}                       │
@@ -3396,6 +3419,7 @@ show_usage() {
 │  ${blue}x-ui update-all-geofiles${plain}   - Update all geo files             │
 │  ${blue}x-ui migrateDB [file]${plain}      - Convert .db <-> .dump (SQLite)   │
 │  ${blue}x-ui pgclient [ver]${plain}        - Upgrade pg_dump/pg_restore tools │
+│  ${blue}x-ui join <url> <token>${plain}    - Join a master panel as a node    │
 │  ${blue}x-ui legacy${plain}                - Legacy version                   │
 │  ${blue}x-ui install${plain}               - Install                          │
 │  ${blue}x-ui uninstall${plain}             - Uninstall                        │
@@ -3602,6 +3626,9 @@ if [[ $# > 0 ]]; then
         "pgclient")
             pg_upgrade_client "$2"
             ;;
+        "join")
+            check_install 0 && join_master "${@:2}"
+            ;;
         *) show_usage ;;
     esac
 else